@@ -7,14 +7,20 @@ import (
 	"fmt"
 	"io"
 	"reflect"
+	"strings"
+	"time"
 
 	"github.com/alecthomas/jsonschema"
 	ma "github.com/multiformats/go-multiaddr"
+	"github.com/textileio/go-threads/api/common"
 	pb "github.com/textileio/go-threads/api/pb"
+	core "github.com/textileio/go-threads/core/db"
 	"github.com/textileio/go-threads/core/thread"
 	"github.com/textileio/go-threads/db"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
 	"google.golang.org/grpc"
 	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
 	"google.golang.org/grpc/status"
 )
 
@@ -234,13 +240,41 @@ func collectionConfigToPb(c db.CollectionConfig) (*pb.CollectionConfig, error) {
 	if err != nil {
 		return nil, err
 	}
-	return &pb.CollectionConfig{
+	cc := &pb.CollectionConfig{
 		Name:           c.Name,
 		Schema:         schemaBytes,
 		Indexes:        idx,
 		WriteValidator: c.WriteValidator,
 		ReadFilter:     c.ReadFilter,
-	}, nil
+		DefaultOrderBy: &pb.CollectionConfig_Sort{
+			FieldPath:       c.DefaultOrderBy.FieldPath,
+			Desc:            c.DefaultOrderBy.Desc,
+			CaseInsensitive: c.DefaultOrderBy.CaseInsensitive,
+		},
+		Version:          int64(c.Version),
+		TtlField:         c.TTLField,
+		ConflictStrategy: int32(c.ConflictStrategy),
+		EncryptedFields:  c.EncryptedFields,
+		IdStrategy:       int32(c.IDStrategy),
+		SerializeWrites:  c.SerializeWrites,
+	}
+	for _, ref := range c.References {
+		cc.References = append(cc.References, &pb.CollectionConfig_Reference{
+			Path:       ref.Path,
+			Collection: ref.Collection,
+			OnDelete:   int32(ref.OnDelete),
+		})
+	}
+	for _, f := range c.ComputedFields {
+		if f.Func != nil {
+			return nil, fmt.Errorf("computed field %s: functions can't be sent, use JS", f.Path)
+		}
+		cc.ComputedFields = append(cc.ComputedFields, &pb.CollectionConfig_ComputedField{
+			Path: f.Path,
+			Js:   f.JS,
+		})
+	}
+	return cc, nil
 }
 
 // ListDBs lists all dbs.
@@ -375,18 +409,83 @@ func (c *Client) GetCollectionInfo(ctx context.Context, dbID thread.ID, name str
 	if err != nil {
 		return db.CollectionConfig{}, err
 	}
+	return collectionConfigFromPb(resp)
+}
+
+// collectionConfigFromPb returns the config of a collection info. Computed
+// fields only have their JavaScript function.
+func collectionConfigFromPb(info *pb.GetCollectionInfoReply) (db.CollectionConfig, error) {
 	schema := &jsonschema.Schema{}
-	err = json.Unmarshal(resp.Schema, schema)
-	if err != nil {
+	if err := json.Unmarshal(info.Schema, schema); err != nil {
 		return db.CollectionConfig{}, err
 	}
-	return db.CollectionConfig{
-		Name:           resp.Name,
-		Schema:         schema,
-		Indexes:        indexesFromPb(resp.Indexes),
-		WriteValidator: resp.WriteValidator,
-		ReadFilter:     resp.ReadFilter,
-	}, nil
+	config := db.CollectionConfig{
+		Name:             info.Name,
+		Schema:           schema,
+		Indexes:          indexesFromPb(info.Indexes),
+		WriteValidator:   info.WriteValidator,
+		ReadFilter:       info.ReadFilter,
+		Version:          int(info.Version),
+		TTLField:         info.TtlField,
+		ConflictStrategy: core.ConflictStrategy(info.ConflictStrategy),
+		EncryptedFields:  info.EncryptedFields,
+		IDStrategy:       db.IDStrategy(info.IdStrategy),
+		SerializeWrites:  info.SerializeWrites,
+	}
+	if info.DefaultOrderBy != nil {
+		config.DefaultOrderBy = db.Sort{
+			FieldPath:       info.DefaultOrderBy.FieldPath,
+			Desc:            info.DefaultOrderBy.Desc,
+			CaseInsensitive: info.DefaultOrderBy.CaseInsensitive,
+		}
+	}
+	for _, ref := range info.References {
+		config.References = append(config.References, db.Reference{
+			Path:       ref.Path,
+			Collection: ref.Collection,
+			OnDelete:   db.RefDeleteAction(ref.OnDelete),
+		})
+	}
+	for _, f := range info.ComputedFields {
+		config.ComputedFields = append(config.ComputedFields, db.ComputedField{
+			Path: f.Path,
+			JS:   f.Js,
+		})
+	}
+	return config, nil
+}
+
+// GetCollectionStats returns storage statistics of a collection, see
+// db.Collection.Stats.
+func (c *Client) GetCollectionStats(ctx context.Context, dbID thread.ID, name string, opts ...db.TxnOption) (db.CollectionStats, error) {
+	args := &db.TxnOptions{}
+	for _, opt := range opts {
+		opt(args)
+	}
+	ctx = thread.NewTokenContext(ctx, args.Token)
+	resp, err := c.c.GetCollectionStats(ctx, &pb.GetCollectionStatsRequest{
+		DbID: dbID.Bytes(),
+		Name: name,
+	})
+	if err != nil {
+		return db.CollectionStats{}, err
+	}
+	stats := db.CollectionStats{
+		Instances: int(resp.Instances),
+		Bytes:     resp.Bytes,
+		Indexes:   make([]db.IndexStats, len(resp.Indexes)),
+	}
+	for i, is := range resp.Indexes {
+		stats.Indexes[i] = db.IndexStats{
+			Path:    is.Path,
+			Entries: int(is.Entries),
+			Bytes:   is.Bytes,
+		}
+	}
+	if resp.LastModified > 0 {
+		stats.LastModified = time.Unix(0, resp.LastModified)
+	}
+	return stats, nil
 }
 
 func indexesFromPb(pbindexes []*pb.Index) []db.Index {
@@ -431,18 +530,9 @@ func (c *Client) ListCollections(ctx context.Context, dbID thread.ID, opts ...db
 	}
 	list := make([]db.CollectionConfig, len(resp.Collections))
 	for i, c := range resp.Collections {
-		schema := &jsonschema.Schema{}
-		err = json.Unmarshal(c.Schema, schema)
-		if err != nil {
+		if list[i], err = collectionConfigFromPb(c); err != nil {
 			return nil, err
 		}
-		list[i] = db.CollectionConfig{
-			Name:           c.Name,
-			Schema:         schema,
-			Indexes:        indexesFromPb(c.Indexes),
-			WriteValidator: c.WriteValidator,
-			ReadFilter:     c.ReadFilter,
-		}
 	}
 	return list, nil
 }
@@ -464,7 +554,7 @@ func (c *Client) Create(ctx context.Context, dbID thread.ID, collectionName stri
 		Instances:      values,
 	})
 	if err != nil {
-		return nil, err
+		return nil, validationError(err)
 	}
 	return resp.GetInstanceIDs(), nil
 }
@@ -485,7 +575,28 @@ func (c *Client) Verify(ctx context.Context, dbID thread.ID, collectionName stri
 		CollectionName: collectionName,
 		Instances:      values,
 	})
-	return err
+	return validationError(err)
+}
+
+// Validate validates an instance against the collection schema and write
+// validator without writing it, see db.Collection.Validate. Schema violations
+// are returned as a *db.ValidationError.
+func (c *Client) Validate(ctx context.Context, dbID thread.ID, collectionName string, instance interface{}, opts ...db.TxnOption) error {
+	args := &db.TxnOptions{}
+	for _, opt := range opts {
+		opt(args)
+	}
+	value, err := json.Marshal(instance)
+	if err != nil {
+		return err
+	}
+	ctx = thread.NewTokenContext(ctx, args.Token)
+	_, err = c.c.Validate(ctx, &pb.ValidateRequest{
+		DbID:           dbID.Bytes(),
+		CollectionName: collectionName,
+		Instance:       value,
+	})
+	return validationError(err)
 }
 
 // Save saves existing instances.
@@ -504,7 +615,7 @@ func (c *Client) Save(ctx context.Context, dbID thread.ID, collectionName string
 		CollectionName: collectionName,
 		Instances:      values,
 	})
-	return err
+	return validationError(err)
 }
 
 // Delete deletes data.
@@ -562,6 +673,150 @@ func (c *Client) Find(ctx context.Context, dbID thread.ID, collectionName string
 	return processFindReply(resp, dummy)
 }
 
+// FindByIDs finds instances by their IDs in a single read transaction, in the
+// order of instanceIDs. If any doesn't exist, returns db.ErrInstanceNotFound.
+func (c *Client) FindByIDs(ctx context.Context, dbID thread.ID, collectionName string, instanceIDs []string, dummy interface{}, opts ...db.TxnOption) (interface{}, error) {
+	args := &db.TxnOptions{}
+	for _, opt := range opts {
+		opt(args)
+	}
+	ctx = thread.NewTokenContext(ctx, args.Token)
+	resp, err := c.c.FindByIDs(ctx, &pb.FindByIDsRequest{
+		DbID:           dbID.Bytes(),
+		CollectionName: collectionName,
+		InstanceIDs:    instanceIDs,
+	})
+	if err != nil {
+		return nil, instanceNotFoundError(err)
+	}
+	return processFindReply(&pb.FindReply{Instances: resp.Instances}, dummy)
+}
+
+// instanceNotFoundError returns db.ErrInstanceNotFound, with the message of
+// the server, for the codes.NotFound errors of missing instances.
+func instanceNotFoundError(err error) error {
+	stat, ok := status.FromError(err)
+	if !ok || stat.Code() != codes.NotFound || !strings.HasSuffix(stat.Message(), db.ErrInstanceNotFound.Error()) {
+		return err
+	}
+	return fmt.Errorf("%s%w", strings.TrimSuffix(stat.Message(), db.ErrInstanceNotFound.Error()), db.ErrInstanceNotFound)
+}
+
+// GetMany gets instances by their IDs in a single read transaction. Found
+// instances are returned in the order of instanceIDs, along with the IDs that
+// don't exist, instead of failing the whole batch like FindByIDs.
+func (c *Client) GetMany(ctx context.Context, dbID thread.ID, collectionName string, instanceIDs []string, dummy interface{}, opts ...db.TxnOption) (interface{}, []string, error) {
+	args := &db.TxnOptions{}
+	for _, opt := range opts {
+		opt(args)
+	}
+	ctx = thread.NewTokenContext(ctx, args.Token)
+	resp, err := c.c.GetMany(ctx, &pb.FindByIDsRequest{
+		DbID:           dbID.Bytes(),
+		CollectionName: collectionName,
+		InstanceIDs:    instanceIDs,
+	})
+	if err != nil {
+		return nil, nil, err
+	}
+	results, err := processFindReply(&pb.FindReply{Instances: resp.Instances}, dummy)
+	if err != nil {
+		return nil, nil, err
+	}
+	return results, resp.Missing, nil
+}
+
+// Export writes all instances of the collection to w as JSON Lines, as they're
+// streamed from the server, see db.Collection.Export.
+func (c *Client) Export(ctx context.Context, dbID thread.ID, collectionName string, w io.Writer, opts ...db.TxnOption) error {
+	args := &db.TxnOptions{}
+	for _, opt := range opts {
+		opt(args)
+	}
+	ctx, cancel := context.WithCancel(thread.NewTokenContext(ctx, args.Token))
+	defer cancel()
+	stream, err := c.c.Export(ctx, &pb.ExportRequest{
+		DbID:           dbID.Bytes(),
+		CollectionName: collectionName,
+	})
+	if err != nil {
+		return err
+	}
+	for {
+		reply, err := stream.Recv()
+		if err == io.EOF {
+			return nil
+		} else if err != nil {
+			return err
+		}
+		if _, err := w.Write(reply.Chunk); err != nil {
+			return err
+		}
+	}
+}
+
+// FindIterate queries for instances by Query, calling fn with each instance
+// in order as it's streamed from the server, so large result sets aren't
+// buffered in memory, see db.Txn.FindIterate. If fn returns an error, the
+// stream is cancelled and the error is returned.
+func (c *Client) FindIterate(ctx context.Context, dbID thread.ID, collectionName string, query *db.Query, fn func(instance []byte) error, opts ...db.TxnOption) error {
+	args := &db.TxnOptions{}
+	for _, opt := range opts {
+		opt(args)
+	}
+	queryBytes, err := json.Marshal(query)
+	if err != nil {
+		return err
+	}
+	ctx, cancel := context.WithCancel(thread.NewTokenContext(ctx, args.Token))
+	defer cancel()
+	stream, err := c.c.FindIterate(ctx, &pb.FindRequest{
+		DbID:           dbID.Bytes(),
+		CollectionName: collectionName,
+		QueryJSON:      queryBytes,
+	})
+	if err != nil {
+		return err
+	}
+	for {
+		reply, err := stream.Recv()
+		if err == io.EOF {
+			return nil
+		} else if err != nil {
+			return err
+		}
+		if err := fn(reply.Instance); err != nil {
+			return err
+		}
+	}
+}
+
+// Count returns the number of instances matching the criteria of query.
+// Ordering, paging, and selection in query are ignored, see db.Txn.Count.
+func (c *Client) Count(ctx context.Context, dbID thread.ID, collectionName string, query *db.Query, opts ...db.TxnOption) (int, error) {
+	args := &db.TxnOptions{}
+	for _, opt := range opts {
+		opt(args)
+	}
+	if query == nil {
+		query = &db.Query{}
+	}
+	queryBytes, err := json.Marshal(query)
+	if err != nil {
+		return 0, err
+	}
+	ctx = thread.NewTokenContext(ctx, args.Token)
+	resp, err := c.c.Count(ctx, &pb.CountRequest{
+		DbID:           dbID.Bytes(),
+		CollectionName: collectionName,
+		QueryJSON:      queryBytes,
+	})
+	if err != nil {
+		return 0, err
+	}
+	return int(resp.Count), nil
+}
+
 // FindByID finds an instance by id.
 func (c *Client) FindByID(ctx context.Context, dbID thread.ID, collectionName, instanceID string, instance interface{}, opts ...db.TxnOption) error {
 	args := &db.TxnOptions{}
@@ -605,6 +860,13 @@ func (c *Client) WriteTransaction(ctx context.Context, dbID thread.ID, collectio
 		opt(args)
 	}
 	ctx = thread.NewTokenContext(ctx, args.Token)
+	if len(args.Metadata) > 0 {
+		md, err := json.Marshal(args.Metadata)
+		if err != nil {
+			return nil, err
+		}
+		ctx = metadata.AppendToOutgoingContext(ctx, common.TxnMetadataKey, string(md))
+	}
 	client, err := c.c.WriteTransaction(ctx)
 	if err != nil {
 		return nil, err
@@ -727,3 +989,34 @@ func txnError(s string) (err error) {
 	}
 	return
 }
+
+// validationError returns the *db.ValidationError described by the details
+// of a status error, or err if it doesn't describe schema violations.
+func validationError(err error) error {
+	stat, ok := status.FromError(err)
+	if !ok || stat == nil {
+		return err
+	}
+	verr := &db.ValidationError{}
+	for _, d := range stat.Details() {
+		info, ok := d.(*errdetails.ErrorInfo)
+		if !ok || info.Reason != common.SchemaViolationReason {
+			continue
+		}
+		v := db.SchemaViolation{
+			Pointer:     info.Metadata["pointer"],
+			Constraint:  info.Metadata["constraint"],
+			Description: info.Metadata["description"],
+		}
+		if value := info.Metadata["value"]; value != "" {
+			if err := json.Unmarshal([]byte(value), &v.Value); err != nil {
+				return err
+			}
+		}
+		verr.Violations = append(verr.Violations, v)
+	}
+	if len(verr.Violations) == 0 {
+		return err
+	}
+	return verr
+}
@@ -13,6 +13,7 @@ import (
 	"net"
 	"os"
 	"reflect"
+	"strings"
 	"testing"
 	"time"
 
@@ -23,10 +24,14 @@ import (
 	. "github.com/textileio/go-threads/api/client"
 	pb "github.com/textileio/go-threads/api/pb"
 	"github.com/textileio/go-threads/common"
+	core "github.com/textileio/go-threads/core/db"
 	"github.com/textileio/go-threads/core/thread"
 	"github.com/textileio/go-threads/db"
 	"github.com/textileio/go-threads/util"
 	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+	"google.golang.org/grpc/status"
 )
 
 func TestClient_GetToken(t *testing.T) {
@@ -229,14 +234,23 @@ func TestClient_GetCollectionInfo(t *testing.T) {
 		err := client.NewDB(context.Background(), id)
 		checkErr(t, err)
 		jschema := util.SchemaFromSchemaString(schema)
-		err = client.NewCollection(context.Background(), id, db.CollectionConfig{
+		config := db.CollectionConfig{
 			Name:   collectionName,
 			Schema: jschema,
 			Indexes: []db.Index{{
 				Path:   "lastName",
 				Unique: true,
 			}},
-		})
+			DefaultOrderBy:   db.Sort{FieldPath: "age", Desc: true},
+			TTLField:         "age",
+			ConflictStrategy: core.ConflictLastWriterWins,
+			References:       []db.Reference{{Path: "lastName", Collection: collectionName, OnDelete: db.RefCascade}},
+			EncryptedFields:  []string{"firstName"},
+			IDStrategy:       db.IDSequential,
+			ComputedFields:   []db.ComputedField{{Path: "initial", JS: "return instance.lastName[0]"}},
+			SerializeWrites:  true,
+		}
+		err = client.NewCollection(context.Background(), id, config)
 		checkErr(t, err)
 		info, err := client.GetCollectionInfo(context.Background(), id, collectionName)
 		checkErr(t, err)
@@ -253,6 +267,18 @@ func TestClient_GetCollectionInfo(t *testing.T) {
 		if len(info.Indexes) != 1 {
 			t.Fatalf("expected 1 indexes, but got %v", len(info.Indexes))
 		}
+		if info.DefaultOrderBy != config.DefaultOrderBy || info.TTLField != config.TTLField || info.ConflictStrategy != config.ConflictStrategy {
+			t.Fatalf("unexpected config %+v", info)
+		}
+		if !reflect.DeepEqual(info.References, config.References) || !reflect.DeepEqual(info.EncryptedFields, config.EncryptedFields) {
+			t.Fatalf("unexpected references and encrypted fields %+v", info)
+		}
+		if info.IDStrategy != config.IDStrategy || !reflect.DeepEqual(info.ComputedFields, config.ComputedFields) || !info.SerializeWrites {
+			t.Fatalf("unexpected config %+v", info)
+		}
+		if info.EncryptionKey != nil {
+			t.Fatal("expected info without the encryption key")
+		}
 	})
 }
 
@@ -355,6 +381,26 @@ func TestClient_Create(t *testing.T) {
 			t.Fatal("expected a new id, got none")
 		}
 	})
+
+	t.Run("test collection create with invalid instance", func(t *testing.T) {
+		id := thread.NewIDV1(thread.Raw, 32)
+		err := client.NewDB(context.Background(), id)
+		checkErr(t, err)
+		err = client.NewCollection(context.Background(), id, db.CollectionConfig{Name: collectionName, Schema: util.SchemaFromSchemaString(schema)})
+		checkErr(t, err)
+
+		_, err = client.Create(context.Background(), id, collectionName, Instances{map[string]interface{}{"_id": "", "age": "old"}})
+		var verr *db.ValidationError
+		if !errors.As(err, &verr) || !errors.Is(err, db.ErrInvalidSchemaInstance) {
+			t.Fatalf("expected a validation error, got %v", err)
+		}
+		if len(verr.Violations) != 1 {
+			t.Fatalf("expected 1 violation, got %v", verr.Violations)
+		}
+		if v := verr.Violations[0]; v.Pointer != "/age" || v.Constraint != "invalid_type" || v.Value != "old" {
+			t.Fatalf("unexpected violation %+v", v)
+		}
+	})
 }
 
 func TestClient_Verify(t *testing.T) {
@@ -561,6 +607,244 @@ func TestClient_FindWithIndex(t *testing.T) {
 	})
 }
 
+func TestClient_FindWithSelect(t *testing.T) {
+	t.Parallel()
+	client, done := setup(t)
+	defer done()
+
+	id := thread.NewIDV1(thread.Raw, 32)
+	err := client.NewDB(context.Background(), id)
+	checkErr(t, err)
+	err = client.NewCollection(context.Background(), id, db.CollectionConfig{Name: collectionName, Schema: util.SchemaFromSchemaString(schema)})
+	checkErr(t, err)
+
+	person := createPerson()
+	ids, err := client.Create(context.Background(), id, collectionName, Instances{person})
+	checkErr(t, err)
+
+	q := db.Select("_id", "age").And("lastName").Eq(person.LastName)
+	rawResults, err := client.Find(context.Background(), id, collectionName, q, &Person{})
+	if err != nil {
+		t.Fatalf("failed to find: %v", err)
+	}
+	results := rawResults.([]*Person)
+	if len(results) != 1 {
+		t.Fatalf("expected 1 result, but got %v", len(results))
+	}
+	expected := &Person{ID: ids[0], Age: person.Age}
+	if !reflect.DeepEqual(results[0], expected) {
+		t.Fatalf("expected only selected fields %v, got %v", expected, results[0])
+	}
+}
+
+func TestClient_FindByIDs(t *testing.T) {
+	t.Parallel()
+	client, done := setup(t)
+	defer done()
+
+	id := thread.NewIDV1(thread.Raw, 32)
+	err := client.NewDB(context.Background(), id)
+	checkErr(t, err)
+	err = client.NewCollection(context.Background(), id, db.CollectionConfig{Name: collectionName, Schema: util.SchemaFromSchemaString(schema)})
+	checkErr(t, err)
+
+	person1, person2 := createPerson(), createPerson()
+	person2.FirstName = "Eve"
+	ids, err := client.Create(context.Background(), id, collectionName, Instances{person1, person2})
+	checkErr(t, err)
+	person1.ID, person2.ID = ids[0], ids[1]
+
+	rawResults, err := client.FindByIDs(context.Background(), id, collectionName, []string{ids[1], ids[0]}, &Person{})
+	checkErr(t, err)
+	results := rawResults.([]*Person)
+	if !reflect.DeepEqual(results, []*Person{person2, person1}) {
+		t.Fatalf("expected instances in the order of ids, got %v", results)
+	}
+
+	_, err = client.FindByIDs(context.Background(), id, collectionName, []string{ids[0], "missing"}, &Person{})
+	if !errors.Is(err, db.ErrInstanceNotFound) {
+		t.Fatalf("expected instance not found error, got %v", err)
+	}
+
+	rawResults, missing, err := client.GetMany(context.Background(), id, collectionName, []string{"missing", ids[1], ids[0]}, &Person{})
+	checkErr(t, err)
+	results = rawResults.([]*Person)
+	if !reflect.DeepEqual(results, []*Person{person2, person1}) {
+		t.Fatalf("expected found instances in the order of ids, got %v", results)
+	}
+	if !reflect.DeepEqual(missing, []string{"missing"}) {
+		t.Fatalf("expected missing ids, got %v", missing)
+	}
+}
+
+func TestClient_Count(t *testing.T) {
+	t.Parallel()
+	client, done := setup(t)
+	defer done()
+
+	id := thread.NewIDV1(thread.Raw, 32)
+	err := client.NewDB(context.Background(), id)
+	checkErr(t, err)
+	err = client.NewCollection(context.Background(), id, db.CollectionConfig{Name: collectionName, Schema: util.SchemaFromSchemaString(schema)})
+	checkErr(t, err)
+
+	person := createPerson()
+	_, err = client.Create(context.Background(), id, collectionName, Instances{person, createPerson()})
+	checkErr(t, err)
+
+	n, err := client.Count(context.Background(), id, collectionName, db.Where("lastName").Eq(person.LastName))
+	checkErr(t, err)
+	if n != 2 {
+		t.Fatalf("expected count 2, got %d", n)
+	}
+	n, err = client.Count(context.Background(), id, collectionName, db.Where("lastName").Eq("Nobody"))
+	checkErr(t, err)
+	if n != 0 {
+		t.Fatalf("expected count 0, got %d", n)
+	}
+}
+
+func TestClient_FindIterate(t *testing.T) {
+	t.Parallel()
+	client, done := setup(t)
+	defer done()
+
+	id := thread.NewIDV1(thread.Raw, 32)
+	err := client.NewDB(context.Background(), id)
+	checkErr(t, err)
+	err = client.NewCollection(context.Background(), id, db.CollectionConfig{Name: collectionName, Schema: util.SchemaFromSchemaString(schema)})
+	checkErr(t, err)
+
+	person := createPerson()
+	_, err = client.Create(context.Background(), id, collectionName, Instances{person, createPerson(), createPerson()})
+	checkErr(t, err)
+
+	var count int
+	err = client.FindIterate(context.Background(), id, collectionName, db.Where("lastName").Eq(person.LastName), func(instance []byte) error {
+		p := &Person{}
+		util.InstanceFromJSON(instance, p)
+		if p.LastName != person.LastName {
+			t.Fatalf("expected last name %s, got %s", person.LastName, p.LastName)
+		}
+		count++
+		return nil
+	})
+	checkErr(t, err)
+	if count != 3 {
+		t.Fatalf("expected 3 instances, got %d", count)
+	}
+
+	errStop := errors.New("stop")
+	count = 0
+	err = client.FindIterate(context.Background(), id, collectionName, &db.Query{}, func([]byte) error {
+		count++
+		return errStop
+	})
+	if !errors.Is(err, errStop) || count != 1 {
+		t.Fatalf("expected iteration to stop after 1 instance, got %d and error %v", count, err)
+	}
+}
+
+func TestClient_Export(t *testing.T) {
+	t.Parallel()
+	client, done := setup(t)
+	defer done()
+
+	id := thread.NewIDV1(thread.Raw, 32)
+	err := client.NewDB(context.Background(), id)
+	checkErr(t, err)
+	err = client.NewCollection(context.Background(), id, db.CollectionConfig{Name: collectionName, Schema: util.SchemaFromSchemaString(schema)})
+	checkErr(t, err)
+
+	ids, err := client.Create(context.Background(), id, collectionName, Instances{createPerson(), createPerson()})
+	checkErr(t, err)
+
+	var buf bytes.Buffer
+	err = client.Export(context.Background(), id, collectionName, &buf)
+	checkErr(t, err)
+	lines := strings.Split(strings.TrimSuffix(buf.String(), "\n"), "\n")
+	if len(lines) != len(ids) {
+		t.Fatalf("expected %d exported lines, got %d", len(ids), len(lines))
+	}
+	for _, line := range lines {
+		p := &Person{}
+		checkErr(t, json.Unmarshal([]byte(line), p))
+		if p.ID != ids[0] && p.ID != ids[1] {
+			t.Fatalf("unexpected exported instance %s", p.ID)
+		}
+	}
+}
+
+func TestClient_GetCollectionStats(t *testing.T) {
+	t.Parallel()
+	client, done := setup(t)
+	defer done()
+
+	id := thread.NewIDV1(thread.Raw, 32)
+	err := client.NewDB(context.Background(), id)
+	checkErr(t, err)
+	err = client.NewCollection(context.Background(), id, db.CollectionConfig{
+		Name:    collectionName,
+		Schema:  util.SchemaFromSchemaString(schema),
+		Indexes: []db.Index{{Path: "lastName"}},
+	})
+	checkErr(t, err)
+
+	stats, err := client.GetCollectionStats(context.Background(), id, collectionName)
+	checkErr(t, err)
+	if stats.Instances != 0 || !stats.LastModified.IsZero() {
+		t.Fatalf("expected empty stats, got %v", stats)
+	}
+
+	_, err = client.Create(context.Background(), id, collectionName, Instances{createPerson(), createPerson()})
+	checkErr(t, err)
+	stats, err = client.GetCollectionStats(context.Background(), id, collectionName)
+	checkErr(t, err)
+	if stats.Instances != 2 || stats.Bytes == 0 || stats.LastModified.IsZero() {
+		t.Fatalf("expected stats of 2 instances, got %v", stats)
+	}
+	if len(stats.Indexes) != 1 || stats.Indexes[0].Path != "lastName" || stats.Indexes[0].Entries == 0 {
+		t.Fatalf("expected stats of the lastName index, got %v", stats.Indexes)
+	}
+}
+
+func TestClient_Validate(t *testing.T) {
+	t.Parallel()
+	client, done := setup(t)
+	defer done()
+
+	id := thread.NewIDV1(thread.Raw, 32)
+	err := client.NewDB(context.Background(), id)
+	checkErr(t, err)
+	err = client.NewCollection(context.Background(), id, db.CollectionConfig{
+		Name:           collectionName,
+		Schema:         util.SchemaFromSchemaString(schema),
+		WriteValidator: `return event.patch.json_patch.age <= 50`,
+	})
+	checkErr(t, err)
+
+	err = client.Validate(context.Background(), id, collectionName, createPerson())
+	checkErr(t, err)
+
+	err = client.Validate(context.Background(), id, collectionName, map[string]interface{}{"_id": "", "age": "old"})
+	var verr *db.ValidationError
+	if !errors.As(err, &verr) || len(verr.Violations) != 1 || verr.Violations[0].Pointer != "/age" {
+		t.Fatalf("expected a validation error of /age, got %v", err)
+	}
+
+	invalid := createPerson()
+	invalid.Age = 51
+	if err := client.Validate(context.Background(), id, collectionName, invalid); err == nil {
+		t.Fatal("expected the write validator to fail")
+	}
+
+	n, err := client.Count(context.Background(), id, collectionName, nil)
+	checkErr(t, err)
+	if n != 0 {
+		t.Fatalf("expected validated instances not to be written, got %d", n)
+	}
+}
+
 func TestClient_FindByID(t *testing.T) {
 	t.Parallel()
 	client, done := setup(t)
@@ -891,6 +1175,39 @@ func TestClient_Close(t *testing.T) {
 	})
 }
 
+func TestClient_Health(t *testing.T) {
+	t.Parallel()
+	addr, shutdown := makeServer(t)
+	defer shutdown()
+	target, err := util.TCPAddrFromMultiAddr(addr)
+	checkErr(t, err)
+	conn, err := grpc.Dial(target, grpc.WithInsecure())
+	checkErr(t, err)
+	defer conn.Close()
+	health := healthpb.NewHealthClient(conn)
+
+	for _, service := range []string{"", "threads.pb.API"} {
+		res, err := health.Check(context.Background(), &healthpb.HealthCheckRequest{Service: service})
+		checkErr(t, err)
+		if res.Status != healthpb.HealthCheckResponse_SERVING {
+			t.Fatalf("expected %q to be serving, got %s", service, res.Status)
+		}
+	}
+	if _, err := health.Check(context.Background(), &healthpb.HealthCheckRequest{Service: "unknown"}); status.Code(err) != codes.NotFound {
+		t.Fatalf("expected unknown service to be not found, got %v", err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	stream, err := health.Watch(ctx, &healthpb.HealthCheckRequest{})
+	checkErr(t, err)
+	res, err := stream.Recv()
+	checkErr(t, err)
+	if res.Status != healthpb.HealthCheckResponse_SERVING {
+		t.Fatalf("expected watch to report serving, got %s", res.Status)
+	}
+}
+
 func setup(t *testing.T) (*Client, func()) {
 	addr, shutdown := makeServer(t)
 	target, err := util.TCPAddrFromMultiAddr(addr)
@@ -946,6 +1263,7 @@ func makeServer(t *testing.T) (ma.Multiaddr, func()) {
 	}
 	go func() {
 		pb.RegisterAPIServer(server, service)
+		healthpb.RegisterHealthServer(server, service.HealthServer())
 		if err := server.Serve(listener); err != nil && !errors.Is(err, grpc.ErrServerStopped) {
 			log.Fatalf("serve error: %v", err)
 		}
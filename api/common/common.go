@@ -0,0 +1,10 @@
+// Package common contains definitions shared by the threads API client and
+// service.
+package common
+
+// TxnMetadataKey is the request metadata key used to send write transaction metadata.
+const TxnMetadataKey = "txn-metadata"
+
+// SchemaViolationReason is the reason of the error info status details
+// describing the violations of a db.ValidationError.
+const SchemaViolationReason = "SCHEMA_VIOLATION"
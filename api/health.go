@@ -0,0 +1,73 @@
+package api
+
+import (
+	"context"
+	"time"
+
+	"github.com/textileio/go-threads/db"
+	"google.golang.org/grpc/codes"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+	"google.golang.org/grpc/status"
+)
+
+// healthWatchInterval is how often the serving status of watched services
+// is checked for changes.
+var healthWatchInterval = time.Second * 5
+
+// healthService is a gRPC health service reporting the readiness of a
+// db manager, see db.Manager.Health.
+type healthService struct {
+	healthpb.UnimplementedHealthServer
+
+	manager *db.Manager
+}
+
+// HealthServer returns a gRPC health service for the db manager of the
+// service, e.g., for the probes of orchestrators. The overall status, with an
+// empty service name, and the status of threads.pb.API are serving while the
+// manager is ready.
+func (s *Service) HealthServer() healthpb.HealthServer {
+	return &healthService{manager: s.manager}
+}
+
+// servingStatus returns the serving status of a service.
+func (h *healthService) servingStatus(service string) (healthpb.HealthCheckResponse_ServingStatus, bool) {
+	switch service {
+	case "", "threads.pb.API":
+	default:
+		return healthpb.HealthCheckResponse_SERVICE_UNKNOWN, false
+	}
+	report := h.manager.Health()
+	if !report.Ready() {
+		log.Warnf("manager isn't ready: datastore: %v, network: %v", report.Datastore, report.Network)
+		return healthpb.HealthCheckResponse_NOT_SERVING, true
+	}
+	return healthpb.HealthCheckResponse_SERVING, true
+}
+
+func (h *healthService) Check(_ context.Context, req *healthpb.HealthCheckRequest) (*healthpb.HealthCheckResponse, error) {
+	s, ok := h.servingStatus(req.Service)
+	if !ok {
+		return nil, status.Errorf(codes.NotFound, "unknown service %s", req.Service)
+	}
+	return &healthpb.HealthCheckResponse{Status: s}, nil
+}
+
+func (h *healthService) Watch(req *healthpb.HealthCheckRequest, server healthpb.Health_WatchServer) error {
+	ticker := time.NewTicker(healthWatchInterval)
+	defer ticker.Stop()
+	last := healthpb.HealthCheckResponse_ServingStatus(-1)
+	for {
+		if s, _ := h.servingStatus(req.Service); s != last {
+			if err := server.Send(&healthpb.HealthCheckResponse{Status: s}); err != nil {
+				return err
+			}
+			last = s
+		}
+		select {
+		case <-server.Context().Done():
+			return status.Error(codes.Canceled, "stream has ended")
+		case <-ticker.C:
+		}
+	}
+}
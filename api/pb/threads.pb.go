@@ -477,11 +477,20 @@ type CollectionConfig struct {
 	sizeCache     protoimpl.SizeCache
 	unknownFields protoimpl.UnknownFields
 
-	Name           string   `protobuf:"bytes,1,opt,name=name,proto3" json:"name,omitempty"`
-	Schema         []byte   `protobuf:"bytes,2,opt,name=schema,proto3" json:"schema,omitempty"`
-	Indexes        []*Index `protobuf:"bytes,3,rep,name=indexes,proto3" json:"indexes,omitempty"`
-	WriteValidator string   `protobuf:"bytes,4,opt,name=writeValidator,proto3" json:"writeValidator,omitempty"`
-	ReadFilter     string   `protobuf:"bytes,5,opt,name=readFilter,proto3" json:"readFilter,omitempty"`
+	Name             string                            `protobuf:"bytes,1,opt,name=name,proto3" json:"name,omitempty"`
+	Schema           []byte                            `protobuf:"bytes,2,opt,name=schema,proto3" json:"schema,omitempty"`
+	Indexes          []*Index                          `protobuf:"bytes,3,rep,name=indexes,proto3" json:"indexes,omitempty"`
+	WriteValidator   string                            `protobuf:"bytes,4,opt,name=writeValidator,proto3" json:"writeValidator,omitempty"`
+	ReadFilter       string                            `protobuf:"bytes,5,opt,name=readFilter,proto3" json:"readFilter,omitempty"`
+	DefaultOrderBy   *CollectionConfig_Sort            `protobuf:"bytes,6,opt,name=defaultOrderBy,proto3" json:"defaultOrderBy,omitempty"`
+	Version          int64                             `protobuf:"varint,7,opt,name=version,proto3" json:"version,omitempty"`
+	TtlField         string                            `protobuf:"bytes,8,opt,name=ttlField,proto3" json:"ttlField,omitempty"`
+	ConflictStrategy int32                             `protobuf:"varint,9,opt,name=conflictStrategy,proto3" json:"conflictStrategy,omitempty"`
+	References       []*CollectionConfig_Reference     `protobuf:"bytes,10,rep,name=references,proto3" json:"references,omitempty"`
+	EncryptedFields  []string                          `protobuf:"bytes,11,rep,name=encryptedFields,proto3" json:"encryptedFields,omitempty"`
+	IdStrategy       int32                             `protobuf:"varint,12,opt,name=idStrategy,proto3" json:"idStrategy,omitempty"`
+	ComputedFields   []*CollectionConfig_ComputedField `protobuf:"bytes,13,rep,name=computedFields,proto3" json:"computedFields,omitempty"`
+	SerializeWrites  bool                              `protobuf:"varint,14,opt,name=serializeWrites,proto3" json:"serializeWrites,omitempty"`
 }
 
 func (x *CollectionConfig) Reset() {
@@ -551,6 +560,69 @@ func (x *CollectionConfig) GetReadFilter() string {
 	return ""
 }
 
+func (x *CollectionConfig) GetDefaultOrderBy() *CollectionConfig_Sort {
+	if x != nil {
+		return x.DefaultOrderBy
+	}
+	return nil
+}
+
+func (x *CollectionConfig) GetVersion() int64 {
+	if x != nil {
+		return x.Version
+	}
+	return 0
+}
+
+func (x *CollectionConfig) GetTtlField() string {
+	if x != nil {
+		return x.TtlField
+	}
+	return ""
+}
+
+func (x *CollectionConfig) GetConflictStrategy() int32 {
+	if x != nil {
+		return x.ConflictStrategy
+	}
+	return 0
+}
+
+func (x *CollectionConfig) GetReferences() []*CollectionConfig_Reference {
+	if x != nil {
+		return x.References
+	}
+	return nil
+}
+
+func (x *CollectionConfig) GetEncryptedFields() []string {
+	if x != nil {
+		return x.EncryptedFields
+	}
+	return nil
+}
+
+func (x *CollectionConfig) GetIdStrategy() int32 {
+	if x != nil {
+		return x.IdStrategy
+	}
+	return 0
+}
+
+func (x *CollectionConfig) GetComputedFields() []*CollectionConfig_ComputedField {
+	if x != nil {
+		return x.ComputedFields
+	}
+	return nil
+}
+
+func (x *CollectionConfig) GetSerializeWrites() bool {
+	if x != nil {
+		return x.SerializeWrites
+	}
+	return false
+}
+
 type Index struct {
 	state         protoimpl.MessageState
 	sizeCache     protoimpl.SizeCache
@@ -1263,11 +1335,20 @@ type GetCollectionInfoReply struct {
 	sizeCache     protoimpl.SizeCache
 	unknownFields protoimpl.UnknownFields
 
-	Name           string   `protobuf:"bytes,1,opt,name=name,proto3" json:"name,omitempty"`
-	Schema         []byte   `protobuf:"bytes,2,opt,name=schema,proto3" json:"schema,omitempty"`
-	Indexes        []*Index `protobuf:"bytes,3,rep,name=indexes,proto3" json:"indexes,omitempty"`
-	WriteValidator string   `protobuf:"bytes,4,opt,name=writeValidator,proto3" json:"writeValidator,omitempty"`
-	ReadFilter     string   `protobuf:"bytes,5,opt,name=readFilter,proto3" json:"readFilter,omitempty"`
+	Name             string                            `protobuf:"bytes,1,opt,name=name,proto3" json:"name,omitempty"`
+	Schema           []byte                            `protobuf:"bytes,2,opt,name=schema,proto3" json:"schema,omitempty"`
+	Indexes          []*Index                          `protobuf:"bytes,3,rep,name=indexes,proto3" json:"indexes,omitempty"`
+	WriteValidator   string                            `protobuf:"bytes,4,opt,name=writeValidator,proto3" json:"writeValidator,omitempty"`
+	ReadFilter       string                            `protobuf:"bytes,5,opt,name=readFilter,proto3" json:"readFilter,omitempty"`
+	DefaultOrderBy   *CollectionConfig_Sort            `protobuf:"bytes,6,opt,name=defaultOrderBy,proto3" json:"defaultOrderBy,omitempty"`
+	Version          int64                             `protobuf:"varint,7,opt,name=version,proto3" json:"version,omitempty"`
+	TtlField         string                            `protobuf:"bytes,8,opt,name=ttlField,proto3" json:"ttlField,omitempty"`
+	ConflictStrategy int32                             `protobuf:"varint,9,opt,name=conflictStrategy,proto3" json:"conflictStrategy,omitempty"`
+	References       []*CollectionConfig_Reference     `protobuf:"bytes,10,rep,name=references,proto3" json:"references,omitempty"`
+	EncryptedFields  []string                          `protobuf:"bytes,11,rep,name=encryptedFields,proto3" json:"encryptedFields,omitempty"`
+	IdStrategy       int32                             `protobuf:"varint,12,opt,name=idStrategy,proto3" json:"idStrategy,omitempty"`
+	ComputedFields   []*CollectionConfig_ComputedField `protobuf:"bytes,13,rep,name=computedFields,proto3" json:"computedFields,omitempty"`
+	SerializeWrites  bool                              `protobuf:"varint,14,opt,name=serializeWrites,proto3" json:"serializeWrites,omitempty"`
 }
 
 func (x *GetCollectionInfoReply) Reset() {
@@ -1337,6 +1418,69 @@ func (x *GetCollectionInfoReply) GetReadFilter() string {
 	return ""
 }
 
+func (x *GetCollectionInfoReply) GetDefaultOrderBy() *CollectionConfig_Sort {
+	if x != nil {
+		return x.DefaultOrderBy
+	}
+	return nil
+}
+
+func (x *GetCollectionInfoReply) GetVersion() int64 {
+	if x != nil {
+		return x.Version
+	}
+	return 0
+}
+
+func (x *GetCollectionInfoReply) GetTtlField() string {
+	if x != nil {
+		return x.TtlField
+	}
+	return ""
+}
+
+func (x *GetCollectionInfoReply) GetConflictStrategy() int32 {
+	if x != nil {
+		return x.ConflictStrategy
+	}
+	return 0
+}
+
+func (x *GetCollectionInfoReply) GetReferences() []*CollectionConfig_Reference {
+	if x != nil {
+		return x.References
+	}
+	return nil
+}
+
+func (x *GetCollectionInfoReply) GetEncryptedFields() []string {
+	if x != nil {
+		return x.EncryptedFields
+	}
+	return nil
+}
+
+func (x *GetCollectionInfoReply) GetIdStrategy() int32 {
+	if x != nil {
+		return x.IdStrategy
+	}
+	return 0
+}
+
+func (x *GetCollectionInfoReply) GetComputedFields() []*CollectionConfig_ComputedField {
+	if x != nil {
+		return x.ComputedFields
+	}
+	return nil
+}
+
+func (x *GetCollectionInfoReply) GetSerializeWrites() bool {
+	if x != nil {
+		return x.SerializeWrites
+	}
+	return false
+}
+
 type GetCollectionIndexesRequest struct {
 	state         protoimpl.MessageState
 	sizeCache     protoimpl.SizeCache
@@ -3136,17 +3280,18 @@ func (x *ListenReply) GetInstance() []byte {
 	return nil
 }
 
-type ListDBsReply_DB struct {
+type CountRequest struct {
 	state         protoimpl.MessageState
 	sizeCache     protoimpl.SizeCache
 	unknownFields protoimpl.UnknownFields
 
-	DbID []byte          `protobuf:"bytes,1,opt,name=dbID,proto3" json:"dbID,omitempty"`
-	Info *GetDBInfoReply `protobuf:"bytes,2,opt,name=info,proto3" json:"info,omitempty"`
+	DbID           []byte `protobuf:"bytes,1,opt,name=dbID,proto3" json:"dbID,omitempty"`
+	CollectionName string `protobuf:"bytes,2,opt,name=collectionName,proto3" json:"collectionName,omitempty"`
+	QueryJSON      []byte `protobuf:"bytes,3,opt,name=queryJSON,proto3" json:"queryJSON,omitempty"`
 }
 
-func (x *ListDBsReply_DB) Reset() {
-	*x = ListDBsReply_DB{}
+func (x *CountRequest) Reset() {
+	*x = CountRequest{}
 	if protoimpl.UnsafeEnabled {
 		mi := &file_threads_proto_msgTypes[48]
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
@@ -3154,13 +3299,13 @@ func (x *ListDBsReply_DB) Reset() {
 	}
 }
 
-func (x *ListDBsReply_DB) String() string {
+func (x *CountRequest) String() string {
 	return protoimpl.X.MessageStringOf(x)
 }
 
-func (*ListDBsReply_DB) ProtoMessage() {}
+func (*CountRequest) ProtoMessage() {}
 
-func (x *ListDBsReply_DB) ProtoReflect() protoreflect.Message {
+func (x *CountRequest) ProtoReflect() protoreflect.Message {
 	mi := &file_threads_proto_msgTypes[48]
 	if protoimpl.UnsafeEnabled && x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
@@ -3172,37 +3317,42 @@ func (x *ListDBsReply_DB) ProtoReflect() protoreflect.Message {
 	return mi.MessageOf(x)
 }
 
-// Deprecated: Use ListDBsReply_DB.ProtoReflect.Descriptor instead.
-func (*ListDBsReply_DB) Descriptor() ([]byte, []int) {
-	return file_threads_proto_rawDescGZIP(), []int{8, 0}
+// Deprecated: Use CountRequest.ProtoReflect.Descriptor instead.
+func (*CountRequest) Descriptor() ([]byte, []int) {
+	return file_threads_proto_rawDescGZIP(), []int{48}
 }
 
-func (x *ListDBsReply_DB) GetDbID() []byte {
+func (x *CountRequest) GetDbID() []byte {
 	if x != nil {
 		return x.DbID
 	}
 	return nil
 }
 
-func (x *ListDBsReply_DB) GetInfo() *GetDBInfoReply {
+func (x *CountRequest) GetCollectionName() string {
 	if x != nil {
-		return x.Info
+		return x.CollectionName
+	}
+	return ""
+}
+
+func (x *CountRequest) GetQueryJSON() []byte {
+	if x != nil {
+		return x.QueryJSON
 	}
 	return nil
 }
 
-type ListenRequest_Filter struct {
+type CountReply struct {
 	state         protoimpl.MessageState
 	sizeCache     protoimpl.SizeCache
 	unknownFields protoimpl.UnknownFields
 
-	CollectionName string                      `protobuf:"bytes,1,opt,name=collectionName,proto3" json:"collectionName,omitempty"`
-	InstanceID     string                      `protobuf:"bytes,2,opt,name=instanceID,proto3" json:"instanceID,omitempty"`
-	Action         ListenRequest_Filter_Action `protobuf:"varint,3,opt,name=action,proto3,enum=threads.pb.ListenRequest_Filter_Action" json:"action,omitempty"`
+	Count int64 `protobuf:"varint,1,opt,name=count,proto3" json:"count,omitempty"`
 }
 
-func (x *ListenRequest_Filter) Reset() {
-	*x = ListenRequest_Filter{}
+func (x *CountReply) Reset() {
+	*x = CountReply{}
 	if protoimpl.UnsafeEnabled {
 		mi := &file_threads_proto_msgTypes[49]
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
@@ -3210,13 +3360,13 @@ func (x *ListenRequest_Filter) Reset() {
 	}
 }
 
-func (x *ListenRequest_Filter) String() string {
+func (x *CountReply) String() string {
 	return protoimpl.X.MessageStringOf(x)
 }
 
-func (*ListenRequest_Filter) ProtoMessage() {}
+func (*CountReply) ProtoMessage() {}
 
-func (x *ListenRequest_Filter) ProtoReflect() protoreflect.Message {
+func (x *CountReply) ProtoReflect() protoreflect.Message {
 	mi := &file_threads_proto_msgTypes[49]
 	if protoimpl.UnsafeEnabled && x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
@@ -3228,58 +3378,947 @@ func (x *ListenRequest_Filter) ProtoReflect() protoreflect.Message {
 	return mi.MessageOf(x)
 }
 
-// Deprecated: Use ListenRequest_Filter.ProtoReflect.Descriptor instead.
-func (*ListenRequest_Filter) Descriptor() ([]byte, []int) {
-	return file_threads_proto_rawDescGZIP(), []int{46, 0}
+// Deprecated: Use CountReply.ProtoReflect.Descriptor instead.
+func (*CountReply) Descriptor() ([]byte, []int) {
+	return file_threads_proto_rawDescGZIP(), []int{49}
 }
 
-func (x *ListenRequest_Filter) GetCollectionName() string {
+func (x *CountReply) GetCount() int64 {
 	if x != nil {
-		return x.CollectionName
+		return x.Count
 	}
-	return ""
+	return 0
 }
 
-func (x *ListenRequest_Filter) GetInstanceID() string {
+type FindIterateReply struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Instance []byte `protobuf:"bytes,1,opt,name=instance,proto3" json:"instance,omitempty"`
+}
+
+func (x *FindIterateReply) Reset() {
+	*x = FindIterateReply{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_threads_proto_msgTypes[50]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *FindIterateReply) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*FindIterateReply) ProtoMessage() {}
+
+func (x *FindIterateReply) ProtoReflect() protoreflect.Message {
+	mi := &file_threads_proto_msgTypes[50]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use FindIterateReply.ProtoReflect.Descriptor instead.
+func (*FindIterateReply) Descriptor() ([]byte, []int) {
+	return file_threads_proto_rawDescGZIP(), []int{50}
+}
+
+func (x *FindIterateReply) GetInstance() []byte {
 	if x != nil {
-		return x.InstanceID
+		return x.Instance
+	}
+	return nil
+}
+
+type ExportRequest struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	DbID           []byte `protobuf:"bytes,1,opt,name=dbID,proto3" json:"dbID,omitempty"`
+	CollectionName string `protobuf:"bytes,2,opt,name=collectionName,proto3" json:"collectionName,omitempty"`
+}
+
+func (x *ExportRequest) Reset() {
+	*x = ExportRequest{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_threads_proto_msgTypes[51]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *ExportRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ExportRequest) ProtoMessage() {}
+
+func (x *ExportRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_threads_proto_msgTypes[51]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ExportRequest.ProtoReflect.Descriptor instead.
+func (*ExportRequest) Descriptor() ([]byte, []int) {
+	return file_threads_proto_rawDescGZIP(), []int{51}
+}
+
+func (x *ExportRequest) GetDbID() []byte {
+	if x != nil {
+		return x.DbID
+	}
+	return nil
+}
+
+func (x *ExportRequest) GetCollectionName() string {
+	if x != nil {
+		return x.CollectionName
 	}
 	return ""
 }
 
-func (x *ListenRequest_Filter) GetAction() ListenRequest_Filter_Action {
+type ExportReply struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Chunk []byte `protobuf:"bytes,1,opt,name=chunk,proto3" json:"chunk,omitempty"`
+}
+
+func (x *ExportReply) Reset() {
+	*x = ExportReply{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_threads_proto_msgTypes[52]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *ExportReply) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ExportReply) ProtoMessage() {}
+
+func (x *ExportReply) ProtoReflect() protoreflect.Message {
+	mi := &file_threads_proto_msgTypes[52]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ExportReply.ProtoReflect.Descriptor instead.
+func (*ExportReply) Descriptor() ([]byte, []int) {
+	return file_threads_proto_rawDescGZIP(), []int{52}
+}
+
+func (x *ExportReply) GetChunk() []byte {
 	if x != nil {
-		return x.Action
+		return x.Chunk
 	}
-	return ListenRequest_Filter_ALL
+	return nil
 }
 
-var File_threads_proto protoreflect.FileDescriptor
+type GetCollectionStatsRequest struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
 
-var file_threads_proto_rawDesc = []byte{
-	0x0a, 0x0d, 0x74, 0x68, 0x72, 0x65, 0x61, 0x64, 0x73, 0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x12,
-	0x0a, 0x74, 0x68, 0x72, 0x65, 0x61, 0x64, 0x73, 0x2e, 0x70, 0x62, 0x22, 0x50, 0x0a, 0x0f, 0x47,
-	0x65, 0x74, 0x54, 0x6f, 0x6b, 0x65, 0x6e, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x12, 0x12,
-	0x0a, 0x03, 0x6b, 0x65, 0x79, 0x18, 0x01, 0x20, 0x01, 0x28, 0x09, 0x48, 0x00, 0x52, 0x03, 0x6b,
-	0x65, 0x79, 0x12, 0x1e, 0x0a, 0x09, 0x73, 0x69, 0x67, 0x6e, 0x61, 0x74, 0x75, 0x72, 0x65, 0x18,
-	0x02, 0x20, 0x01, 0x28, 0x0c, 0x48, 0x00, 0x52, 0x09, 0x73, 0x69, 0x67, 0x6e, 0x61, 0x74, 0x75,
-	0x72, 0x65, 0x42, 0x09, 0x0a, 0x07, 0x70, 0x61, 0x79, 0x6c, 0x6f, 0x61, 0x64, 0x22, 0x52, 0x0a,
-	0x0d, 0x47, 0x65, 0x74, 0x54, 0x6f, 0x6b, 0x65, 0x6e, 0x52, 0x65, 0x70, 0x6c, 0x79, 0x12, 0x1e,
-	0x0a, 0x09, 0x63, 0x68, 0x61, 0x6c, 0x6c, 0x65, 0x6e, 0x67, 0x65, 0x18, 0x01, 0x20, 0x01, 0x28,
-	0x0c, 0x48, 0x00, 0x52, 0x09, 0x63, 0x68, 0x61, 0x6c, 0x6c, 0x65, 0x6e, 0x67, 0x65, 0x12, 0x16,
-	0x0a, 0x05, 0x74, 0x6f, 0x6b, 0x65, 0x6e, 0x18, 0x02, 0x20, 0x01, 0x28, 0x09, 0x48, 0x00, 0x52,
-	0x05, 0x74, 0x6f, 0x6b, 0x65, 0x6e, 0x42, 0x09, 0x0a, 0x07, 0x70, 0x61, 0x79, 0x6c, 0x6f, 0x61,
-	0x64, 0x22, 0xc2, 0x01, 0x0a, 0x0c, 0x4e, 0x65, 0x77, 0x44, 0x42, 0x52, 0x65, 0x71, 0x75, 0x65,
-	0x73, 0x74, 0x12, 0x12, 0x0a, 0x04, 0x64, 0x62, 0x49, 0x44, 0x18, 0x01, 0x20, 0x01, 0x28, 0x0c,
-	0x52, 0x04, 0x64, 0x62, 0x49, 0x44, 0x12, 0x3e, 0x0a, 0x0b, 0x63, 0x6f, 0x6c, 0x6c, 0x65, 0x63,
-	0x74, 0x69, 0x6f, 0x6e, 0x73, 0x18, 0x02, 0x20, 0x03, 0x28, 0x0b, 0x32, 0x1c, 0x2e, 0x74, 0x68,
-	0x72, 0x65, 0x61, 0x64, 0x73, 0x2e, 0x70, 0x62, 0x2e, 0x43, 0x6f, 0x6c, 0x6c, 0x65, 0x63, 0x74,
-	0x69, 0x6f, 0x6e, 0x43, 0x6f, 0x6e, 0x66, 0x69, 0x67, 0x52, 0x0b, 0x63, 0x6f, 0x6c, 0x6c, 0x65,
-	0x63, 0x74, 0x69, 0x6f, 0x6e, 0x73, 0x12, 0x12, 0x0a, 0x04, 0x6e, 0x61, 0x6d, 0x65, 0x18, 0x03,
-	0x20, 0x01, 0x28, 0x09, 0x52, 0x04, 0x6e, 0x61, 0x6d, 0x65, 0x12, 0x14, 0x0a, 0x05, 0x62, 0x6c,
-	0x6f, 0x63, 0x6b, 0x18, 0x05, 0x20, 0x01, 0x28, 0x08, 0x52, 0x05, 0x62, 0x6c, 0x6f, 0x63, 0x6b,
-	0x12, 0x1c, 0x0a, 0x09, 0x74, 0x68, 0x72, 0x65, 0x61, 0x64, 0x4b, 0x65, 0x79, 0x18, 0x06, 0x20,
-	0x01, 0x28, 0x0c, 0x52, 0x09, 0x74, 0x68, 0x72, 0x65, 0x61, 0x64, 0x4b, 0x65, 0x79, 0x12, 0x16,
+	DbID []byte `protobuf:"bytes,1,opt,name=dbID,proto3" json:"dbID,omitempty"`
+	Name string `protobuf:"bytes,2,opt,name=name,proto3" json:"name,omitempty"`
+}
+
+func (x *GetCollectionStatsRequest) Reset() {
+	*x = GetCollectionStatsRequest{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_threads_proto_msgTypes[53]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *GetCollectionStatsRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*GetCollectionStatsRequest) ProtoMessage() {}
+
+func (x *GetCollectionStatsRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_threads_proto_msgTypes[53]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use GetCollectionStatsRequest.ProtoReflect.Descriptor instead.
+func (*GetCollectionStatsRequest) Descriptor() ([]byte, []int) {
+	return file_threads_proto_rawDescGZIP(), []int{53}
+}
+
+func (x *GetCollectionStatsRequest) GetDbID() []byte {
+	if x != nil {
+		return x.DbID
+	}
+	return nil
+}
+
+func (x *GetCollectionStatsRequest) GetName() string {
+	if x != nil {
+		return x.Name
+	}
+	return ""
+}
+
+type GetCollectionStatsReply struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Instances    int64                                 `protobuf:"varint,1,opt,name=instances,proto3" json:"instances,omitempty"`
+	Bytes        int64                                 `protobuf:"varint,2,opt,name=bytes,proto3" json:"bytes,omitempty"`
+	Indexes      []*GetCollectionStatsReply_IndexStats `protobuf:"bytes,3,rep,name=indexes,proto3" json:"indexes,omitempty"`
+	LastModified int64                                 `protobuf:"varint,4,opt,name=lastModified,proto3" json:"lastModified,omitempty"`
+}
+
+func (x *GetCollectionStatsReply) Reset() {
+	*x = GetCollectionStatsReply{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_threads_proto_msgTypes[54]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *GetCollectionStatsReply) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*GetCollectionStatsReply) ProtoMessage() {}
+
+func (x *GetCollectionStatsReply) ProtoReflect() protoreflect.Message {
+	mi := &file_threads_proto_msgTypes[54]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use GetCollectionStatsReply.ProtoReflect.Descriptor instead.
+func (*GetCollectionStatsReply) Descriptor() ([]byte, []int) {
+	return file_threads_proto_rawDescGZIP(), []int{54}
+}
+
+func (x *GetCollectionStatsReply) GetInstances() int64 {
+	if x != nil {
+		return x.Instances
+	}
+	return 0
+}
+
+func (x *GetCollectionStatsReply) GetBytes() int64 {
+	if x != nil {
+		return x.Bytes
+	}
+	return 0
+}
+
+func (x *GetCollectionStatsReply) GetIndexes() []*GetCollectionStatsReply_IndexStats {
+	if x != nil {
+		return x.Indexes
+	}
+	return nil
+}
+
+func (x *GetCollectionStatsReply) GetLastModified() int64 {
+	if x != nil {
+		return x.LastModified
+	}
+	return 0
+}
+
+type ValidateRequest struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	DbID           []byte `protobuf:"bytes,1,opt,name=dbID,proto3" json:"dbID,omitempty"`
+	CollectionName string `protobuf:"bytes,2,opt,name=collectionName,proto3" json:"collectionName,omitempty"`
+	Instance       []byte `protobuf:"bytes,3,opt,name=instance,proto3" json:"instance,omitempty"`
+}
+
+func (x *ValidateRequest) Reset() {
+	*x = ValidateRequest{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_threads_proto_msgTypes[55]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *ValidateRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ValidateRequest) ProtoMessage() {}
+
+func (x *ValidateRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_threads_proto_msgTypes[55]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ValidateRequest.ProtoReflect.Descriptor instead.
+func (*ValidateRequest) Descriptor() ([]byte, []int) {
+	return file_threads_proto_rawDescGZIP(), []int{55}
+}
+
+func (x *ValidateRequest) GetDbID() []byte {
+	if x != nil {
+		return x.DbID
+	}
+	return nil
+}
+
+func (x *ValidateRequest) GetCollectionName() string {
+	if x != nil {
+		return x.CollectionName
+	}
+	return ""
+}
+
+func (x *ValidateRequest) GetInstance() []byte {
+	if x != nil {
+		return x.Instance
+	}
+	return nil
+}
+
+type ValidateReply struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+}
+
+func (x *ValidateReply) Reset() {
+	*x = ValidateReply{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_threads_proto_msgTypes[56]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *ValidateReply) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ValidateReply) ProtoMessage() {}
+
+func (x *ValidateReply) ProtoReflect() protoreflect.Message {
+	mi := &file_threads_proto_msgTypes[56]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ValidateReply.ProtoReflect.Descriptor instead.
+func (*ValidateReply) Descriptor() ([]byte, []int) {
+	return file_threads_proto_rawDescGZIP(), []int{56}
+}
+
+type FindByIDsRequest struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	DbID           []byte   `protobuf:"bytes,1,opt,name=dbID,proto3" json:"dbID,omitempty"`
+	CollectionName string   `protobuf:"bytes,2,opt,name=collectionName,proto3" json:"collectionName,omitempty"`
+	InstanceIDs    []string `protobuf:"bytes,3,rep,name=instanceIDs,proto3" json:"instanceIDs,omitempty"`
+}
+
+func (x *FindByIDsRequest) Reset() {
+	*x = FindByIDsRequest{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_threads_proto_msgTypes[57]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *FindByIDsRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*FindByIDsRequest) ProtoMessage() {}
+
+func (x *FindByIDsRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_threads_proto_msgTypes[57]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use FindByIDsRequest.ProtoReflect.Descriptor instead.
+func (*FindByIDsRequest) Descriptor() ([]byte, []int) {
+	return file_threads_proto_rawDescGZIP(), []int{57}
+}
+
+func (x *FindByIDsRequest) GetDbID() []byte {
+	if x != nil {
+		return x.DbID
+	}
+	return nil
+}
+
+func (x *FindByIDsRequest) GetCollectionName() string {
+	if x != nil {
+		return x.CollectionName
+	}
+	return ""
+}
+
+func (x *FindByIDsRequest) GetInstanceIDs() []string {
+	if x != nil {
+		return x.InstanceIDs
+	}
+	return nil
+}
+
+type FindByIDsReply struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Instances [][]byte `protobuf:"bytes,1,rep,name=instances,proto3" json:"instances,omitempty"`
+}
+
+func (x *FindByIDsReply) Reset() {
+	*x = FindByIDsReply{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_threads_proto_msgTypes[58]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *FindByIDsReply) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*FindByIDsReply) ProtoMessage() {}
+
+func (x *FindByIDsReply) ProtoReflect() protoreflect.Message {
+	mi := &file_threads_proto_msgTypes[58]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use FindByIDsReply.ProtoReflect.Descriptor instead.
+func (*FindByIDsReply) Descriptor() ([]byte, []int) {
+	return file_threads_proto_rawDescGZIP(), []int{58}
+}
+
+func (x *FindByIDsReply) GetInstances() [][]byte {
+	if x != nil {
+		return x.Instances
+	}
+	return nil
+}
+
+type GetManyReply struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Instances [][]byte `protobuf:"bytes,1,rep,name=instances,proto3" json:"instances,omitempty"`
+	Missing   []string `protobuf:"bytes,2,rep,name=missing,proto3" json:"missing,omitempty"`
+}
+
+func (x *GetManyReply) Reset() {
+	*x = GetManyReply{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_threads_proto_msgTypes[59]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *GetManyReply) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*GetManyReply) ProtoMessage() {}
+
+func (x *GetManyReply) ProtoReflect() protoreflect.Message {
+	mi := &file_threads_proto_msgTypes[59]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use GetManyReply.ProtoReflect.Descriptor instead.
+func (*GetManyReply) Descriptor() ([]byte, []int) {
+	return file_threads_proto_rawDescGZIP(), []int{59}
+}
+
+func (x *GetManyReply) GetInstances() [][]byte {
+	if x != nil {
+		return x.Instances
+	}
+	return nil
+}
+
+func (x *GetManyReply) GetMissing() []string {
+	if x != nil {
+		return x.Missing
+	}
+	return nil
+}
+
+type CollectionConfig_Sort struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	FieldPath       string `protobuf:"bytes,1,opt,name=fieldPath,proto3" json:"fieldPath,omitempty"`
+	Desc            bool   `protobuf:"varint,2,opt,name=desc,proto3" json:"desc,omitempty"`
+	CaseInsensitive bool   `protobuf:"varint,3,opt,name=caseInsensitive,proto3" json:"caseInsensitive,omitempty"`
+}
+
+func (x *CollectionConfig_Sort) Reset() {
+	*x = CollectionConfig_Sort{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_threads_proto_msgTypes[60]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *CollectionConfig_Sort) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*CollectionConfig_Sort) ProtoMessage() {}
+
+func (x *CollectionConfig_Sort) ProtoReflect() protoreflect.Message {
+	mi := &file_threads_proto_msgTypes[60]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use CollectionConfig_Sort.ProtoReflect.Descriptor instead.
+func (*CollectionConfig_Sort) Descriptor() ([]byte, []int) {
+	return file_threads_proto_rawDescGZIP(), []int{4, 0}
+}
+
+func (x *CollectionConfig_Sort) GetFieldPath() string {
+	if x != nil {
+		return x.FieldPath
+	}
+	return ""
+}
+
+func (x *CollectionConfig_Sort) GetDesc() bool {
+	if x != nil {
+		return x.Desc
+	}
+	return false
+}
+
+func (x *CollectionConfig_Sort) GetCaseInsensitive() bool {
+	if x != nil {
+		return x.CaseInsensitive
+	}
+	return false
+}
+
+type CollectionConfig_Reference struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Path       string `protobuf:"bytes,1,opt,name=path,proto3" json:"path,omitempty"`
+	Collection string `protobuf:"bytes,2,opt,name=collection,proto3" json:"collection,omitempty"`
+	OnDelete   int32  `protobuf:"varint,3,opt,name=onDelete,proto3" json:"onDelete,omitempty"`
+}
+
+func (x *CollectionConfig_Reference) Reset() {
+	*x = CollectionConfig_Reference{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_threads_proto_msgTypes[61]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *CollectionConfig_Reference) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*CollectionConfig_Reference) ProtoMessage() {}
+
+func (x *CollectionConfig_Reference) ProtoReflect() protoreflect.Message {
+	mi := &file_threads_proto_msgTypes[61]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use CollectionConfig_Reference.ProtoReflect.Descriptor instead.
+func (*CollectionConfig_Reference) Descriptor() ([]byte, []int) {
+	return file_threads_proto_rawDescGZIP(), []int{4, 1}
+}
+
+func (x *CollectionConfig_Reference) GetPath() string {
+	if x != nil {
+		return x.Path
+	}
+	return ""
+}
+
+func (x *CollectionConfig_Reference) GetCollection() string {
+	if x != nil {
+		return x.Collection
+	}
+	return ""
+}
+
+func (x *CollectionConfig_Reference) GetOnDelete() int32 {
+	if x != nil {
+		return x.OnDelete
+	}
+	return 0
+}
+
+type CollectionConfig_ComputedField struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Path string `protobuf:"bytes,1,opt,name=path,proto3" json:"path,omitempty"`
+	Js   string `protobuf:"bytes,2,opt,name=js,proto3" json:"js,omitempty"`
+}
+
+func (x *CollectionConfig_ComputedField) Reset() {
+	*x = CollectionConfig_ComputedField{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_threads_proto_msgTypes[62]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *CollectionConfig_ComputedField) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*CollectionConfig_ComputedField) ProtoMessage() {}
+
+func (x *CollectionConfig_ComputedField) ProtoReflect() protoreflect.Message {
+	mi := &file_threads_proto_msgTypes[62]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use CollectionConfig_ComputedField.ProtoReflect.Descriptor instead.
+func (*CollectionConfig_ComputedField) Descriptor() ([]byte, []int) {
+	return file_threads_proto_rawDescGZIP(), []int{4, 2}
+}
+
+func (x *CollectionConfig_ComputedField) GetPath() string {
+	if x != nil {
+		return x.Path
+	}
+	return ""
+}
+
+func (x *CollectionConfig_ComputedField) GetJs() string {
+	if x != nil {
+		return x.Js
+	}
+	return ""
+}
+
+type ListDBsReply_DB struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	DbID []byte          `protobuf:"bytes,1,opt,name=dbID,proto3" json:"dbID,omitempty"`
+	Info *GetDBInfoReply `protobuf:"bytes,2,opt,name=info,proto3" json:"info,omitempty"`
+}
+
+func (x *ListDBsReply_DB) Reset() {
+	*x = ListDBsReply_DB{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_threads_proto_msgTypes[63]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *ListDBsReply_DB) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ListDBsReply_DB) ProtoMessage() {}
+
+func (x *ListDBsReply_DB) ProtoReflect() protoreflect.Message {
+	mi := &file_threads_proto_msgTypes[63]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ListDBsReply_DB.ProtoReflect.Descriptor instead.
+func (*ListDBsReply_DB) Descriptor() ([]byte, []int) {
+	return file_threads_proto_rawDescGZIP(), []int{8, 0}
+}
+
+func (x *ListDBsReply_DB) GetDbID() []byte {
+	if x != nil {
+		return x.DbID
+	}
+	return nil
+}
+
+func (x *ListDBsReply_DB) GetInfo() *GetDBInfoReply {
+	if x != nil {
+		return x.Info
+	}
+	return nil
+}
+
+type ListenRequest_Filter struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	CollectionName string                      `protobuf:"bytes,1,opt,name=collectionName,proto3" json:"collectionName,omitempty"`
+	InstanceID     string                      `protobuf:"bytes,2,opt,name=instanceID,proto3" json:"instanceID,omitempty"`
+	Action         ListenRequest_Filter_Action `protobuf:"varint,3,opt,name=action,proto3,enum=threads.pb.ListenRequest_Filter_Action" json:"action,omitempty"`
+}
+
+func (x *ListenRequest_Filter) Reset() {
+	*x = ListenRequest_Filter{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_threads_proto_msgTypes[64]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *ListenRequest_Filter) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ListenRequest_Filter) ProtoMessage() {}
+
+func (x *ListenRequest_Filter) ProtoReflect() protoreflect.Message {
+	mi := &file_threads_proto_msgTypes[64]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ListenRequest_Filter.ProtoReflect.Descriptor instead.
+func (*ListenRequest_Filter) Descriptor() ([]byte, []int) {
+	return file_threads_proto_rawDescGZIP(), []int{46, 0}
+}
+
+func (x *ListenRequest_Filter) GetCollectionName() string {
+	if x != nil {
+		return x.CollectionName
+	}
+	return ""
+}
+
+func (x *ListenRequest_Filter) GetInstanceID() string {
+	if x != nil {
+		return x.InstanceID
+	}
+	return ""
+}
+
+func (x *ListenRequest_Filter) GetAction() ListenRequest_Filter_Action {
+	if x != nil {
+		return x.Action
+	}
+	return ListenRequest_Filter_ALL
+}
+
+type GetCollectionStatsReply_IndexStats struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Path    string `protobuf:"bytes,1,opt,name=path,proto3" json:"path,omitempty"`
+	Entries int64  `protobuf:"varint,2,opt,name=entries,proto3" json:"entries,omitempty"`
+	Bytes   int64  `protobuf:"varint,3,opt,name=bytes,proto3" json:"bytes,omitempty"`
+}
+
+func (x *GetCollectionStatsReply_IndexStats) Reset() {
+	*x = GetCollectionStatsReply_IndexStats{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_threads_proto_msgTypes[65]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *GetCollectionStatsReply_IndexStats) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*GetCollectionStatsReply_IndexStats) ProtoMessage() {}
+
+func (x *GetCollectionStatsReply_IndexStats) ProtoReflect() protoreflect.Message {
+	mi := &file_threads_proto_msgTypes[65]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use GetCollectionStatsReply_IndexStats.ProtoReflect.Descriptor instead.
+func (*GetCollectionStatsReply_IndexStats) Descriptor() ([]byte, []int) {
+	return file_threads_proto_rawDescGZIP(), []int{54, 0}
+}
+
+func (x *GetCollectionStatsReply_IndexStats) GetPath() string {
+	if x != nil {
+		return x.Path
+	}
+	return ""
+}
+
+func (x *GetCollectionStatsReply_IndexStats) GetEntries() int64 {
+	if x != nil {
+		return x.Entries
+	}
+	return 0
+}
+
+func (x *GetCollectionStatsReply_IndexStats) GetBytes() int64 {
+	if x != nil {
+		return x.Bytes
+	}
+	return 0
+}
+
+var File_threads_proto protoreflect.FileDescriptor
+
+var file_threads_proto_rawDesc = []byte{
+	0x0a, 0x0d, 0x74, 0x68, 0x72, 0x65, 0x61, 0x64, 0x73, 0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x12,
+	0x0a, 0x74, 0x68, 0x72, 0x65, 0x61, 0x64, 0x73, 0x2e, 0x70, 0x62, 0x22, 0x50, 0x0a, 0x0f, 0x47,
+	0x65, 0x74, 0x54, 0x6f, 0x6b, 0x65, 0x6e, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x12, 0x12,
+	0x0a, 0x03, 0x6b, 0x65, 0x79, 0x18, 0x01, 0x20, 0x01, 0x28, 0x09, 0x48, 0x00, 0x52, 0x03, 0x6b,
+	0x65, 0x79, 0x12, 0x1e, 0x0a, 0x09, 0x73, 0x69, 0x67, 0x6e, 0x61, 0x74, 0x75, 0x72, 0x65, 0x18,
+	0x02, 0x20, 0x01, 0x28, 0x0c, 0x48, 0x00, 0x52, 0x09, 0x73, 0x69, 0x67, 0x6e, 0x61, 0x74, 0x75,
+	0x72, 0x65, 0x42, 0x09, 0x0a, 0x07, 0x70, 0x61, 0x79, 0x6c, 0x6f, 0x61, 0x64, 0x22, 0x52, 0x0a,
+	0x0d, 0x47, 0x65, 0x74, 0x54, 0x6f, 0x6b, 0x65, 0x6e, 0x52, 0x65, 0x70, 0x6c, 0x79, 0x12, 0x1e,
+	0x0a, 0x09, 0x63, 0x68, 0x61, 0x6c, 0x6c, 0x65, 0x6e, 0x67, 0x65, 0x18, 0x01, 0x20, 0x01, 0x28,
+	0x0c, 0x48, 0x00, 0x52, 0x09, 0x63, 0x68, 0x61, 0x6c, 0x6c, 0x65, 0x6e, 0x67, 0x65, 0x12, 0x16,
+	0x0a, 0x05, 0x74, 0x6f, 0x6b, 0x65, 0x6e, 0x18, 0x02, 0x20, 0x01, 0x28, 0x09, 0x48, 0x00, 0x52,
+	0x05, 0x74, 0x6f, 0x6b, 0x65, 0x6e, 0x42, 0x09, 0x0a, 0x07, 0x70, 0x61, 0x79, 0x6c, 0x6f, 0x61,
+	0x64, 0x22, 0xc2, 0x01, 0x0a, 0x0c, 0x4e, 0x65, 0x77, 0x44, 0x42, 0x52, 0x65, 0x71, 0x75, 0x65,
+	0x73, 0x74, 0x12, 0x12, 0x0a, 0x04, 0x64, 0x62, 0x49, 0x44, 0x18, 0x01, 0x20, 0x01, 0x28, 0x0c,
+	0x52, 0x04, 0x64, 0x62, 0x49, 0x44, 0x12, 0x3e, 0x0a, 0x0b, 0x63, 0x6f, 0x6c, 0x6c, 0x65, 0x63,
+	0x74, 0x69, 0x6f, 0x6e, 0x73, 0x18, 0x02, 0x20, 0x03, 0x28, 0x0b, 0x32, 0x1c, 0x2e, 0x74, 0x68,
+	0x72, 0x65, 0x61, 0x64, 0x73, 0x2e, 0x70, 0x62, 0x2e, 0x43, 0x6f, 0x6c, 0x6c, 0x65, 0x63, 0x74,
+	0x69, 0x6f, 0x6e, 0x43, 0x6f, 0x6e, 0x66, 0x69, 0x67, 0x52, 0x0b, 0x63, 0x6f, 0x6c, 0x6c, 0x65,
+	0x63, 0x74, 0x69, 0x6f, 0x6e, 0x73, 0x12, 0x12, 0x0a, 0x04, 0x6e, 0x61, 0x6d, 0x65, 0x18, 0x03,
+	0x20, 0x01, 0x28, 0x09, 0x52, 0x04, 0x6e, 0x61, 0x6d, 0x65, 0x12, 0x14, 0x0a, 0x05, 0x62, 0x6c,
+	0x6f, 0x63, 0x6b, 0x18, 0x05, 0x20, 0x01, 0x28, 0x08, 0x52, 0x05, 0x62, 0x6c, 0x6f, 0x63, 0x6b,
+	0x12, 0x1c, 0x0a, 0x09, 0x74, 0x68, 0x72, 0x65, 0x61, 0x64, 0x4b, 0x65, 0x79, 0x18, 0x06, 0x20,
+	0x01, 0x28, 0x0c, 0x52, 0x09, 0x74, 0x68, 0x72, 0x65, 0x61, 0x64, 0x4b, 0x65, 0x79, 0x12, 0x16,
 	0x0a, 0x06, 0x6c, 0x6f, 0x67, 0x4b, 0x65, 0x79, 0x18, 0x07, 0x20, 0x01, 0x28, 0x0c, 0x52, 0x06,
 	0x6c, 0x6f, 0x67, 0x4b, 0x65, 0x79, 0x22, 0xdc, 0x01, 0x0a, 0x14, 0x4e, 0x65, 0x77, 0x44, 0x42,
 	0x46, 0x72, 0x6f, 0x6d, 0x41, 0x64, 0x64, 0x72, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x12,
@@ -3295,7 +4334,7 @@ var file_threads_proto_rawDesc = []byte{
 	0x1c, 0x0a, 0x09, 0x74, 0x68, 0x72, 0x65, 0x61, 0x64, 0x4b, 0x65, 0x79, 0x18, 0x06, 0x20, 0x01,
 	0x28, 0x0c, 0x52, 0x09, 0x74, 0x68, 0x72, 0x65, 0x61, 0x64, 0x4b, 0x65, 0x79, 0x12, 0x16, 0x0a,
 	0x06, 0x6c, 0x6f, 0x67, 0x4b, 0x65, 0x79, 0x18, 0x07, 0x20, 0x01, 0x28, 0x0c, 0x52, 0x06, 0x6c,
-	0x6f, 0x67, 0x4b, 0x65, 0x79, 0x22, 0xb3, 0x01, 0x0a, 0x10, 0x43, 0x6f, 0x6c, 0x6c, 0x65, 0x63,
+	0x6f, 0x67, 0x4b, 0x65, 0x79, 0x22, 0xe6, 0x06, 0x0a, 0x10, 0x43, 0x6f, 0x6c, 0x6c, 0x65, 0x63,
 	0x74, 0x69, 0x6f, 0x6e, 0x43, 0x6f, 0x6e, 0x66, 0x69, 0x67, 0x12, 0x12, 0x0a, 0x04, 0x6e, 0x61,
 	0x6d, 0x65, 0x18, 0x01, 0x20, 0x01, 0x28, 0x09, 0x52, 0x04, 0x6e, 0x61, 0x6d, 0x65, 0x12, 0x16,
 	0x0a, 0x06, 0x73, 0x63, 0x68, 0x65, 0x6d, 0x61, 0x18, 0x02, 0x20, 0x01, 0x28, 0x0c, 0x52, 0x06,
@@ -3306,69 +4345,140 @@ var file_threads_proto_rawDesc = []byte{
 	0x64, 0x61, 0x74, 0x6f, 0x72, 0x18, 0x04, 0x20, 0x01, 0x28, 0x09, 0x52, 0x0e, 0x77, 0x72, 0x69,
 	0x74, 0x65, 0x56, 0x61, 0x6c, 0x69, 0x64, 0x61, 0x74, 0x6f, 0x72, 0x12, 0x1e, 0x0a, 0x0a, 0x72,
 	0x65, 0x61, 0x64, 0x46, 0x69, 0x6c, 0x74, 0x65, 0x72, 0x18, 0x05, 0x20, 0x01, 0x28, 0x09, 0x52,
-	0x0a, 0x72, 0x65, 0x61, 0x64, 0x46, 0x69, 0x6c, 0x74, 0x65, 0x72, 0x22, 0x33, 0x0a, 0x05, 0x49,
-	0x6e, 0x64, 0x65, 0x78, 0x12, 0x12, 0x0a, 0x04, 0x70, 0x61, 0x74, 0x68, 0x18, 0x01, 0x20, 0x01,
-	0x28, 0x09, 0x52, 0x04, 0x70, 0x61, 0x74, 0x68, 0x12, 0x16, 0x0a, 0x06, 0x75, 0x6e, 0x69, 0x71,
-	0x75, 0x65, 0x18, 0x02, 0x20, 0x01, 0x28, 0x08, 0x52, 0x06, 0x75, 0x6e, 0x69, 0x71, 0x75, 0x65,
-	0x22, 0x0c, 0x0a, 0x0a, 0x4e, 0x65, 0x77, 0x44, 0x42, 0x52, 0x65, 0x70, 0x6c, 0x79, 0x22, 0x10,
-	0x0a, 0x0e, 0x4c, 0x69, 0x73, 0x74, 0x44, 0x42, 0x73, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74,
-	0x22, 0x87, 0x01, 0x0a, 0x0c, 0x4c, 0x69, 0x73, 0x74, 0x44, 0x42, 0x73, 0x52, 0x65, 0x70, 0x6c,
-	0x79, 0x12, 0x2d, 0x0a, 0x03, 0x64, 0x62, 0x73, 0x18, 0x01, 0x20, 0x03, 0x28, 0x0b, 0x32, 0x1b,
-	0x2e, 0x74, 0x68, 0x72, 0x65, 0x61, 0x64, 0x73, 0x2e, 0x70, 0x62, 0x2e, 0x4c, 0x69, 0x73, 0x74,
-	0x44, 0x42, 0x73, 0x52, 0x65, 0x70, 0x6c, 0x79, 0x2e, 0x44, 0x42, 0x52, 0x03, 0x64, 0x62, 0x73,
-	0x1a, 0x48, 0x0a, 0x02, 0x44, 0x42, 0x12, 0x12, 0x0a, 0x04, 0x64, 0x62, 0x49, 0x44, 0x18, 0x01,
-	0x20, 0x01, 0x28, 0x0c, 0x52, 0x04, 0x64, 0x62, 0x49, 0x44, 0x12, 0x2e, 0x0a, 0x04, 0x69, 0x6e,
-	0x66, 0x6f, 0x18, 0x02, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x1a, 0x2e, 0x74, 0x68, 0x72, 0x65, 0x61,
-	0x64, 0x73, 0x2e, 0x70, 0x62, 0x2e, 0x47, 0x65, 0x74, 0x44, 0x42, 0x49, 0x6e, 0x66, 0x6f, 0x52,
-	0x65, 0x70, 0x6c, 0x79, 0x52, 0x04, 0x69, 0x6e, 0x66, 0x6f, 0x22, 0x26, 0x0a, 0x10, 0x47, 0x65,
-	0x74, 0x44, 0x42, 0x49, 0x6e, 0x66, 0x6f, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x12, 0x12,
-	0x0a, 0x04, 0x64, 0x62, 0x49, 0x44, 0x18, 0x01, 0x20, 0x01, 0x28, 0x0c, 0x52, 0x04, 0x64, 0x62,
-	0x49, 0x44, 0x22, 0x4c, 0x0a, 0x0e, 0x47, 0x65, 0x74, 0x44, 0x42, 0x49, 0x6e, 0x66, 0x6f, 0x52,
-	0x65, 0x70, 0x6c, 0x79, 0x12, 0x14, 0x0a, 0x05, 0x61, 0x64, 0x64, 0x72, 0x73, 0x18, 0x01, 0x20,
-	0x03, 0x28, 0x0c, 0x52, 0x05, 0x61, 0x64, 0x64, 0x72, 0x73, 0x12, 0x10, 0x0a, 0x03, 0x6b, 0x65,
-	0x79, 0x18, 0x02, 0x20, 0x01, 0x28, 0x0c, 0x52, 0x03, 0x6b, 0x65, 0x79, 0x12, 0x12, 0x0a, 0x04,
-	0x6e, 0x61, 0x6d, 0x65, 0x18, 0x03, 0x20, 0x01, 0x28, 0x09, 0x52, 0x04, 0x6e, 0x61, 0x6d, 0x65,
-	0x22, 0x25, 0x0a, 0x0f, 0x44, 0x65, 0x6c, 0x65, 0x74, 0x65, 0x44, 0x42, 0x52, 0x65, 0x71, 0x75,
+	0x0a, 0x72, 0x65, 0x61, 0x64, 0x46, 0x69, 0x6c, 0x74, 0x65, 0x72, 0x12, 0x49, 0x0a, 0x0e, 0x64,
+	0x65, 0x66, 0x61, 0x75, 0x6c, 0x74, 0x4f, 0x72, 0x64, 0x65, 0x72, 0x42, 0x79, 0x18, 0x06, 0x20,
+	0x01, 0x28, 0x0b, 0x32, 0x21, 0x2e, 0x74, 0x68, 0x72, 0x65, 0x61, 0x64, 0x73, 0x2e, 0x70, 0x62,
+	0x2e, 0x43, 0x6f, 0x6c, 0x6c, 0x65, 0x63, 0x74, 0x69, 0x6f, 0x6e, 0x43, 0x6f, 0x6e, 0x66, 0x69,
+	0x67, 0x2e, 0x53, 0x6f, 0x72, 0x74, 0x52, 0x0e, 0x64, 0x65, 0x66, 0x61, 0x75, 0x6c, 0x74, 0x4f,
+	0x72, 0x64, 0x65, 0x72, 0x42, 0x79, 0x12, 0x18, 0x0a, 0x07, 0x76, 0x65, 0x72, 0x73, 0x69, 0x6f,
+	0x6e, 0x18, 0x07, 0x20, 0x01, 0x28, 0x03, 0x52, 0x07, 0x76, 0x65, 0x72, 0x73, 0x69, 0x6f, 0x6e,
+	0x12, 0x1a, 0x0a, 0x08, 0x74, 0x74, 0x6c, 0x46, 0x69, 0x65, 0x6c, 0x64, 0x18, 0x08, 0x20, 0x01,
+	0x28, 0x09, 0x52, 0x08, 0x74, 0x74, 0x6c, 0x46, 0x69, 0x65, 0x6c, 0x64, 0x12, 0x2a, 0x0a, 0x10,
+	0x63, 0x6f, 0x6e, 0x66, 0x6c, 0x69, 0x63, 0x74, 0x53, 0x74, 0x72, 0x61, 0x74, 0x65, 0x67, 0x79,
+	0x18, 0x09, 0x20, 0x01, 0x28, 0x05, 0x52, 0x10, 0x63, 0x6f, 0x6e, 0x66, 0x6c, 0x69, 0x63, 0x74,
+	0x53, 0x74, 0x72, 0x61, 0x74, 0x65, 0x67, 0x79, 0x12, 0x46, 0x0a, 0x0a, 0x72, 0x65, 0x66, 0x65,
+	0x72, 0x65, 0x6e, 0x63, 0x65, 0x73, 0x18, 0x0a, 0x20, 0x03, 0x28, 0x0b, 0x32, 0x26, 0x2e, 0x74,
+	0x68, 0x72, 0x65, 0x61, 0x64, 0x73, 0x2e, 0x70, 0x62, 0x2e, 0x43, 0x6f, 0x6c, 0x6c, 0x65, 0x63,
+	0x74, 0x69, 0x6f, 0x6e, 0x43, 0x6f, 0x6e, 0x66, 0x69, 0x67, 0x2e, 0x52, 0x65, 0x66, 0x65, 0x72,
+	0x65, 0x6e, 0x63, 0x65, 0x52, 0x0a, 0x72, 0x65, 0x66, 0x65, 0x72, 0x65, 0x6e, 0x63, 0x65, 0x73,
+	0x12, 0x28, 0x0a, 0x0f, 0x65, 0x6e, 0x63, 0x72, 0x79, 0x70, 0x74, 0x65, 0x64, 0x46, 0x69, 0x65,
+	0x6c, 0x64, 0x73, 0x18, 0x0b, 0x20, 0x03, 0x28, 0x09, 0x52, 0x0f, 0x65, 0x6e, 0x63, 0x72, 0x79,
+	0x70, 0x74, 0x65, 0x64, 0x46, 0x69, 0x65, 0x6c, 0x64, 0x73, 0x12, 0x1e, 0x0a, 0x0a, 0x69, 0x64,
+	0x53, 0x74, 0x72, 0x61, 0x74, 0x65, 0x67, 0x79, 0x18, 0x0c, 0x20, 0x01, 0x28, 0x05, 0x52, 0x0a,
+	0x69, 0x64, 0x53, 0x74, 0x72, 0x61, 0x74, 0x65, 0x67, 0x79, 0x12, 0x52, 0x0a, 0x0e, 0x63, 0x6f,
+	0x6d, 0x70, 0x75, 0x74, 0x65, 0x64, 0x46, 0x69, 0x65, 0x6c, 0x64, 0x73, 0x18, 0x0d, 0x20, 0x03,
+	0x28, 0x0b, 0x32, 0x2a, 0x2e, 0x74, 0x68, 0x72, 0x65, 0x61, 0x64, 0x73, 0x2e, 0x70, 0x62, 0x2e,
+	0x43, 0x6f, 0x6c, 0x6c, 0x65, 0x63, 0x74, 0x69, 0x6f, 0x6e, 0x43, 0x6f, 0x6e, 0x66, 0x69, 0x67,
+	0x2e, 0x43, 0x6f, 0x6d, 0x70, 0x75, 0x74, 0x65, 0x64, 0x46, 0x69, 0x65, 0x6c, 0x64, 0x52, 0x0e,
+	0x63, 0x6f, 0x6d, 0x70, 0x75, 0x74, 0x65, 0x64, 0x46, 0x69, 0x65, 0x6c, 0x64, 0x73, 0x12, 0x28,
+	0x0a, 0x0f, 0x73, 0x65, 0x72, 0x69, 0x61, 0x6c, 0x69, 0x7a, 0x65, 0x57, 0x72, 0x69, 0x74, 0x65,
+	0x73, 0x18, 0x0e, 0x20, 0x01, 0x28, 0x08, 0x52, 0x0f, 0x73, 0x65, 0x72, 0x69, 0x61, 0x6c, 0x69,
+	0x7a, 0x65, 0x57, 0x72, 0x69, 0x74, 0x65, 0x73, 0x1a, 0x62, 0x0a, 0x04, 0x53, 0x6f, 0x72, 0x74,
+	0x12, 0x1c, 0x0a, 0x09, 0x66, 0x69, 0x65, 0x6c, 0x64, 0x50, 0x61, 0x74, 0x68, 0x18, 0x01, 0x20,
+	0x01, 0x28, 0x09, 0x52, 0x09, 0x66, 0x69, 0x65, 0x6c, 0x64, 0x50, 0x61, 0x74, 0x68, 0x12, 0x12,
+	0x0a, 0x04, 0x64, 0x65, 0x73, 0x63, 0x18, 0x02, 0x20, 0x01, 0x28, 0x08, 0x52, 0x04, 0x64, 0x65,
+	0x73, 0x63, 0x12, 0x28, 0x0a, 0x0f, 0x63, 0x61, 0x73, 0x65, 0x49, 0x6e, 0x73, 0x65, 0x6e, 0x73,
+	0x69, 0x74, 0x69, 0x76, 0x65, 0x18, 0x03, 0x20, 0x01, 0x28, 0x08, 0x52, 0x0f, 0x63, 0x61, 0x73,
+	0x65, 0x49, 0x6e, 0x73, 0x65, 0x6e, 0x73, 0x69, 0x74, 0x69, 0x76, 0x65, 0x1a, 0x5b, 0x0a, 0x09,
+	0x52, 0x65, 0x66, 0x65, 0x72, 0x65, 0x6e, 0x63, 0x65, 0x12, 0x12, 0x0a, 0x04, 0x70, 0x61, 0x74,
+	0x68, 0x18, 0x01, 0x20, 0x01, 0x28, 0x09, 0x52, 0x04, 0x70, 0x61, 0x74, 0x68, 0x12, 0x1e, 0x0a,
+	0x0a, 0x63, 0x6f, 0x6c, 0x6c, 0x65, 0x63, 0x74, 0x69, 0x6f, 0x6e, 0x18, 0x02, 0x20, 0x01, 0x28,
+	0x09, 0x52, 0x0a, 0x63, 0x6f, 0x6c, 0x6c, 0x65, 0x63, 0x74, 0x69, 0x6f, 0x6e, 0x12, 0x1a, 0x0a,
+	0x08, 0x6f, 0x6e, 0x44, 0x65, 0x6c, 0x65, 0x74, 0x65, 0x18, 0x03, 0x20, 0x01, 0x28, 0x05, 0x52,
+	0x08, 0x6f, 0x6e, 0x44, 0x65, 0x6c, 0x65, 0x74, 0x65, 0x1a, 0x33, 0x0a, 0x0d, 0x43, 0x6f, 0x6d,
+	0x70, 0x75, 0x74, 0x65, 0x64, 0x46, 0x69, 0x65, 0x6c, 0x64, 0x12, 0x12, 0x0a, 0x04, 0x70, 0x61,
+	0x74, 0x68, 0x18, 0x01, 0x20, 0x01, 0x28, 0x09, 0x52, 0x04, 0x70, 0x61, 0x74, 0x68, 0x12, 0x0e,
+	0x0a, 0x02, 0x6a, 0x73, 0x18, 0x02, 0x20, 0x01, 0x28, 0x09, 0x52, 0x02, 0x6a, 0x73, 0x22, 0x33,
+	0x0a, 0x05, 0x49, 0x6e, 0x64, 0x65, 0x78, 0x12, 0x12, 0x0a, 0x04, 0x70, 0x61, 0x74, 0x68, 0x18,
+	0x01, 0x20, 0x01, 0x28, 0x09, 0x52, 0x04, 0x70, 0x61, 0x74, 0x68, 0x12, 0x16, 0x0a, 0x06, 0x75,
+	0x6e, 0x69, 0x71, 0x75, 0x65, 0x18, 0x02, 0x20, 0x01, 0x28, 0x08, 0x52, 0x06, 0x75, 0x6e, 0x69,
+	0x71, 0x75, 0x65, 0x22, 0x0c, 0x0a, 0x0a, 0x4e, 0x65, 0x77, 0x44, 0x42, 0x52, 0x65, 0x70, 0x6c,
+	0x79, 0x22, 0x10, 0x0a, 0x0e, 0x4c, 0x69, 0x73, 0x74, 0x44, 0x42, 0x73, 0x52, 0x65, 0x71, 0x75,
+	0x65, 0x73, 0x74, 0x22, 0x87, 0x01, 0x0a, 0x0c, 0x4c, 0x69, 0x73, 0x74, 0x44, 0x42, 0x73, 0x52,
+	0x65, 0x70, 0x6c, 0x79, 0x12, 0x2d, 0x0a, 0x03, 0x64, 0x62, 0x73, 0x18, 0x01, 0x20, 0x03, 0x28,
+	0x0b, 0x32, 0x1b, 0x2e, 0x74, 0x68, 0x72, 0x65, 0x61, 0x64, 0x73, 0x2e, 0x70, 0x62, 0x2e, 0x4c,
+	0x69, 0x73, 0x74, 0x44, 0x42, 0x73, 0x52, 0x65, 0x70, 0x6c, 0x79, 0x2e, 0x44, 0x42, 0x52, 0x03,
+	0x64, 0x62, 0x73, 0x1a, 0x48, 0x0a, 0x02, 0x44, 0x42, 0x12, 0x12, 0x0a, 0x04, 0x64, 0x62, 0x49,
+	0x44, 0x18, 0x01, 0x20, 0x01, 0x28, 0x0c, 0x52, 0x04, 0x64, 0x62, 0x49, 0x44, 0x12, 0x2e, 0x0a,
+	0x04, 0x69, 0x6e, 0x66, 0x6f, 0x18, 0x02, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x1a, 0x2e, 0x74, 0x68,
+	0x72, 0x65, 0x61, 0x64, 0x73, 0x2e, 0x70, 0x62, 0x2e, 0x47, 0x65, 0x74, 0x44, 0x42, 0x49, 0x6e,
+	0x66, 0x6f, 0x52, 0x65, 0x70, 0x6c, 0x79, 0x52, 0x04, 0x69, 0x6e, 0x66, 0x6f, 0x22, 0x26, 0x0a,
+	0x10, 0x47, 0x65, 0x74, 0x44, 0x42, 0x49, 0x6e, 0x66, 0x6f, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73,
+	0x74, 0x12, 0x12, 0x0a, 0x04, 0x64, 0x62, 0x49, 0x44, 0x18, 0x01, 0x20, 0x01, 0x28, 0x0c, 0x52,
+	0x04, 0x64, 0x62, 0x49, 0x44, 0x22, 0x4c, 0x0a, 0x0e, 0x47, 0x65, 0x74, 0x44, 0x42, 0x49, 0x6e,
+	0x66, 0x6f, 0x52, 0x65, 0x70, 0x6c, 0x79, 0x12, 0x14, 0x0a, 0x05, 0x61, 0x64, 0x64, 0x72, 0x73,
+	0x18, 0x01, 0x20, 0x03, 0x28, 0x0c, 0x52, 0x05, 0x61, 0x64, 0x64, 0x72, 0x73, 0x12, 0x10, 0x0a,
+	0x03, 0x6b, 0x65, 0x79, 0x18, 0x02, 0x20, 0x01, 0x28, 0x0c, 0x52, 0x03, 0x6b, 0x65, 0x79, 0x12,
+	0x12, 0x0a, 0x04, 0x6e, 0x61, 0x6d, 0x65, 0x18, 0x03, 0x20, 0x01, 0x28, 0x09, 0x52, 0x04, 0x6e,
+	0x61, 0x6d, 0x65, 0x22, 0x25, 0x0a, 0x0f, 0x44, 0x65, 0x6c, 0x65, 0x74, 0x65, 0x44, 0x42, 0x52,
+	0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x12, 0x12, 0x0a, 0x04, 0x64, 0x62, 0x49, 0x44, 0x18, 0x01,
+	0x20, 0x01, 0x28, 0x0c, 0x52, 0x04, 0x64, 0x62, 0x49, 0x44, 0x22, 0x0f, 0x0a, 0x0d, 0x44, 0x65,
+	0x6c, 0x65, 0x74, 0x65, 0x44, 0x42, 0x52, 0x65, 0x70, 0x6c, 0x79, 0x22, 0x60, 0x0a, 0x14, 0x4e,
+	0x65, 0x77, 0x43, 0x6f, 0x6c, 0x6c, 0x65, 0x63, 0x74, 0x69, 0x6f, 0x6e, 0x52, 0x65, 0x71, 0x75,
 	0x65, 0x73, 0x74, 0x12, 0x12, 0x0a, 0x04, 0x64, 0x62, 0x49, 0x44, 0x18, 0x01, 0x20, 0x01, 0x28,
-	0x0c, 0x52, 0x04, 0x64, 0x62, 0x49, 0x44, 0x22, 0x0f, 0x0a, 0x0d, 0x44, 0x65, 0x6c, 0x65, 0x74,
-	0x65, 0x44, 0x42, 0x52, 0x65, 0x70, 0x6c, 0x79, 0x22, 0x60, 0x0a, 0x14, 0x4e, 0x65, 0x77, 0x43,
-	0x6f, 0x6c, 0x6c, 0x65, 0x63, 0x74, 0x69, 0x6f, 0x6e, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74,
-	0x12, 0x12, 0x0a, 0x04, 0x64, 0x62, 0x49, 0x44, 0x18, 0x01, 0x20, 0x01, 0x28, 0x0c, 0x52, 0x04,
-	0x64, 0x62, 0x49, 0x44, 0x12, 0x34, 0x0a, 0x06, 0x63, 0x6f, 0x6e, 0x66, 0x69, 0x67, 0x18, 0x02,
-	0x20, 0x01, 0x28, 0x0b, 0x32, 0x1c, 0x2e, 0x74, 0x68, 0x72, 0x65, 0x61, 0x64, 0x73, 0x2e, 0x70,
-	0x62, 0x2e, 0x43, 0x6f, 0x6c, 0x6c, 0x65, 0x63, 0x74, 0x69, 0x6f, 0x6e, 0x43, 0x6f, 0x6e, 0x66,
-	0x69, 0x67, 0x52, 0x06, 0x63, 0x6f, 0x6e, 0x66, 0x69, 0x67, 0x22, 0x14, 0x0a, 0x12, 0x4e, 0x65,
-	0x77, 0x43, 0x6f, 0x6c, 0x6c, 0x65, 0x63, 0x74, 0x69, 0x6f, 0x6e, 0x52, 0x65, 0x70, 0x6c, 0x79,
-	0x22, 0x63, 0x0a, 0x17, 0x55, 0x70, 0x64, 0x61, 0x74, 0x65, 0x43, 0x6f, 0x6c, 0x6c, 0x65, 0x63,
-	0x74, 0x69, 0x6f, 0x6e, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x12, 0x12, 0x0a, 0x04, 0x64,
-	0x62, 0x49, 0x44, 0x18, 0x01, 0x20, 0x01, 0x28, 0x0c, 0x52, 0x04, 0x64, 0x62, 0x49, 0x44, 0x12,
-	0x34, 0x0a, 0x06, 0x63, 0x6f, 0x6e, 0x66, 0x69, 0x67, 0x18, 0x02, 0x20, 0x01, 0x28, 0x0b, 0x32,
-	0x1c, 0x2e, 0x74, 0x68, 0x72, 0x65, 0x61, 0x64, 0x73, 0x2e, 0x70, 0x62, 0x2e, 0x43, 0x6f, 0x6c,
-	0x6c, 0x65, 0x63, 0x74, 0x69, 0x6f, 0x6e, 0x43, 0x6f, 0x6e, 0x66, 0x69, 0x67, 0x52, 0x06, 0x63,
-	0x6f, 0x6e, 0x66, 0x69, 0x67, 0x22, 0x17, 0x0a, 0x15, 0x55, 0x70, 0x64, 0x61, 0x74, 0x65, 0x43,
-	0x6f, 0x6c, 0x6c, 0x65, 0x63, 0x74, 0x69, 0x6f, 0x6e, 0x52, 0x65, 0x70, 0x6c, 0x79, 0x22, 0x41,
-	0x0a, 0x17, 0x44, 0x65, 0x6c, 0x65, 0x74, 0x65, 0x43, 0x6f, 0x6c, 0x6c, 0x65, 0x63, 0x74, 0x69,
-	0x6f, 0x6e, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x12, 0x12, 0x0a, 0x04, 0x64, 0x62, 0x49,
+	0x0c, 0x52, 0x04, 0x64, 0x62, 0x49, 0x44, 0x12, 0x34, 0x0a, 0x06, 0x63, 0x6f, 0x6e, 0x66, 0x69,
+	0x67, 0x18, 0x02, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x1c, 0x2e, 0x74, 0x68, 0x72, 0x65, 0x61, 0x64,
+	0x73, 0x2e, 0x70, 0x62, 0x2e, 0x43, 0x6f, 0x6c, 0x6c, 0x65, 0x63, 0x74, 0x69, 0x6f, 0x6e, 0x43,
+	0x6f, 0x6e, 0x66, 0x69, 0x67, 0x52, 0x06, 0x63, 0x6f, 0x6e, 0x66, 0x69, 0x67, 0x22, 0x14, 0x0a,
+	0x12, 0x4e, 0x65, 0x77, 0x43, 0x6f, 0x6c, 0x6c, 0x65, 0x63, 0x74, 0x69, 0x6f, 0x6e, 0x52, 0x65,
+	0x70, 0x6c, 0x79, 0x22, 0x63, 0x0a, 0x17, 0x55, 0x70, 0x64, 0x61, 0x74, 0x65, 0x43, 0x6f, 0x6c,
+	0x6c, 0x65, 0x63, 0x74, 0x69, 0x6f, 0x6e, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x12, 0x12,
+	0x0a, 0x04, 0x64, 0x62, 0x49, 0x44, 0x18, 0x01, 0x20, 0x01, 0x28, 0x0c, 0x52, 0x04, 0x64, 0x62,
+	0x49, 0x44, 0x12, 0x34, 0x0a, 0x06, 0x63, 0x6f, 0x6e, 0x66, 0x69, 0x67, 0x18, 0x02, 0x20, 0x01,
+	0x28, 0x0b, 0x32, 0x1c, 0x2e, 0x74, 0x68, 0x72, 0x65, 0x61, 0x64, 0x73, 0x2e, 0x70, 0x62, 0x2e,
+	0x43, 0x6f, 0x6c, 0x6c, 0x65, 0x63, 0x74, 0x69, 0x6f, 0x6e, 0x43, 0x6f, 0x6e, 0x66, 0x69, 0x67,
+	0x52, 0x06, 0x63, 0x6f, 0x6e, 0x66, 0x69, 0x67, 0x22, 0x17, 0x0a, 0x15, 0x55, 0x70, 0x64, 0x61,
+	0x74, 0x65, 0x43, 0x6f, 0x6c, 0x6c, 0x65, 0x63, 0x74, 0x69, 0x6f, 0x6e, 0x52, 0x65, 0x70, 0x6c,
+	0x79, 0x22, 0x41, 0x0a, 0x17, 0x44, 0x65, 0x6c, 0x65, 0x74, 0x65, 0x43, 0x6f, 0x6c, 0x6c, 0x65,
+	0x63, 0x74, 0x69, 0x6f, 0x6e, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x12, 0x12, 0x0a, 0x04,
+	0x64, 0x62, 0x49, 0x44, 0x18, 0x01, 0x20, 0x01, 0x28, 0x0c, 0x52, 0x04, 0x64, 0x62, 0x49, 0x44,
+	0x12, 0x12, 0x0a, 0x04, 0x6e, 0x61, 0x6d, 0x65, 0x18, 0x02, 0x20, 0x01, 0x28, 0x09, 0x52, 0x04,
+	0x6e, 0x61, 0x6d, 0x65, 0x22, 0x17, 0x0a, 0x15, 0x44, 0x65, 0x6c, 0x65, 0x74, 0x65, 0x43, 0x6f,
+	0x6c, 0x6c, 0x65, 0x63, 0x74, 0x69, 0x6f, 0x6e, 0x52, 0x65, 0x70, 0x6c, 0x79, 0x22, 0x42, 0x0a,
+	0x18, 0x47, 0x65, 0x74, 0x43, 0x6f, 0x6c, 0x6c, 0x65, 0x63, 0x74, 0x69, 0x6f, 0x6e, 0x49, 0x6e,
+	0x66, 0x6f, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x12, 0x12, 0x0a, 0x04, 0x64, 0x62, 0x49,
 	0x44, 0x18, 0x01, 0x20, 0x01, 0x28, 0x0c, 0x52, 0x04, 0x64, 0x62, 0x49, 0x44, 0x12, 0x12, 0x0a,
 	0x04, 0x6e, 0x61, 0x6d, 0x65, 0x18, 0x02, 0x20, 0x01, 0x28, 0x09, 0x52, 0x04, 0x6e, 0x61, 0x6d,
-	0x65, 0x22, 0x17, 0x0a, 0x15, 0x44, 0x65, 0x6c, 0x65, 0x74, 0x65, 0x43, 0x6f, 0x6c, 0x6c, 0x65,
-	0x63, 0x74, 0x69, 0x6f, 0x6e, 0x52, 0x65, 0x70, 0x6c, 0x79, 0x22, 0x42, 0x0a, 0x18, 0x47, 0x65,
-	0x74, 0x43, 0x6f, 0x6c, 0x6c, 0x65, 0x63, 0x74, 0x69, 0x6f, 0x6e, 0x49, 0x6e, 0x66, 0x6f, 0x52,
-	0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x12, 0x12, 0x0a, 0x04, 0x64, 0x62, 0x49, 0x44, 0x18, 0x01,
-	0x20, 0x01, 0x28, 0x0c, 0x52, 0x04, 0x64, 0x62, 0x49, 0x44, 0x12, 0x12, 0x0a, 0x04, 0x6e, 0x61,
-	0x6d, 0x65, 0x18, 0x02, 0x20, 0x01, 0x28, 0x09, 0x52, 0x04, 0x6e, 0x61, 0x6d, 0x65, 0x22, 0xb9,
-	0x01, 0x0a, 0x16, 0x47, 0x65, 0x74, 0x43, 0x6f, 0x6c, 0x6c, 0x65, 0x63, 0x74, 0x69, 0x6f, 0x6e,
-	0x49, 0x6e, 0x66, 0x6f, 0x52, 0x65, 0x70, 0x6c, 0x79, 0x12, 0x12, 0x0a, 0x04, 0x6e, 0x61, 0x6d,
-	0x65, 0x18, 0x01, 0x20, 0x01, 0x28, 0x09, 0x52, 0x04, 0x6e, 0x61, 0x6d, 0x65, 0x12, 0x16, 0x0a,
-	0x06, 0x73, 0x63, 0x68, 0x65, 0x6d, 0x61, 0x18, 0x02, 0x20, 0x01, 0x28, 0x0c, 0x52, 0x06, 0x73,
-	0x63, 0x68, 0x65, 0x6d, 0x61, 0x12, 0x2b, 0x0a, 0x07, 0x69, 0x6e, 0x64, 0x65, 0x78, 0x65, 0x73,
-	0x18, 0x03, 0x20, 0x03, 0x28, 0x0b, 0x32, 0x11, 0x2e, 0x74, 0x68, 0x72, 0x65, 0x61, 0x64, 0x73,
-	0x2e, 0x70, 0x62, 0x2e, 0x49, 0x6e, 0x64, 0x65, 0x78, 0x52, 0x07, 0x69, 0x6e, 0x64, 0x65, 0x78,
-	0x65, 0x73, 0x12, 0x26, 0x0a, 0x0e, 0x77, 0x72, 0x69, 0x74, 0x65, 0x56, 0x61, 0x6c, 0x69, 0x64,
-	0x61, 0x74, 0x6f, 0x72, 0x18, 0x04, 0x20, 0x01, 0x28, 0x09, 0x52, 0x0e, 0x77, 0x72, 0x69, 0x74,
-	0x65, 0x56, 0x61, 0x6c, 0x69, 0x64, 0x61, 0x74, 0x6f, 0x72, 0x12, 0x1e, 0x0a, 0x0a, 0x72, 0x65,
-	0x61, 0x64, 0x46, 0x69, 0x6c, 0x74, 0x65, 0x72, 0x18, 0x05, 0x20, 0x01, 0x28, 0x09, 0x52, 0x0a,
-	0x72, 0x65, 0x61, 0x64, 0x46, 0x69, 0x6c, 0x74, 0x65, 0x72, 0x22, 0x45, 0x0a, 0x1b, 0x47, 0x65,
+	0x65, 0x22, 0xf6, 0x04, 0x0a, 0x16, 0x47, 0x65, 0x74, 0x43, 0x6f, 0x6c, 0x6c, 0x65, 0x63, 0x74,
+	0x69, 0x6f, 0x6e, 0x49, 0x6e, 0x66, 0x6f, 0x52, 0x65, 0x70, 0x6c, 0x79, 0x12, 0x12, 0x0a, 0x04,
+	0x6e, 0x61, 0x6d, 0x65, 0x18, 0x01, 0x20, 0x01, 0x28, 0x09, 0x52, 0x04, 0x6e, 0x61, 0x6d, 0x65,
+	0x12, 0x16, 0x0a, 0x06, 0x73, 0x63, 0x68, 0x65, 0x6d, 0x61, 0x18, 0x02, 0x20, 0x01, 0x28, 0x0c,
+	0x52, 0x06, 0x73, 0x63, 0x68, 0x65, 0x6d, 0x61, 0x12, 0x2b, 0x0a, 0x07, 0x69, 0x6e, 0x64, 0x65,
+	0x78, 0x65, 0x73, 0x18, 0x03, 0x20, 0x03, 0x28, 0x0b, 0x32, 0x11, 0x2e, 0x74, 0x68, 0x72, 0x65,
+	0x61, 0x64, 0x73, 0x2e, 0x70, 0x62, 0x2e, 0x49, 0x6e, 0x64, 0x65, 0x78, 0x52, 0x07, 0x69, 0x6e,
+	0x64, 0x65, 0x78, 0x65, 0x73, 0x12, 0x26, 0x0a, 0x0e, 0x77, 0x72, 0x69, 0x74, 0x65, 0x56, 0x61,
+	0x6c, 0x69, 0x64, 0x61, 0x74, 0x6f, 0x72, 0x18, 0x04, 0x20, 0x01, 0x28, 0x09, 0x52, 0x0e, 0x77,
+	0x72, 0x69, 0x74, 0x65, 0x56, 0x61, 0x6c, 0x69, 0x64, 0x61, 0x74, 0x6f, 0x72, 0x12, 0x1e, 0x0a,
+	0x0a, 0x72, 0x65, 0x61, 0x64, 0x46, 0x69, 0x6c, 0x74, 0x65, 0x72, 0x18, 0x05, 0x20, 0x01, 0x28,
+	0x09, 0x52, 0x0a, 0x72, 0x65, 0x61, 0x64, 0x46, 0x69, 0x6c, 0x74, 0x65, 0x72, 0x12, 0x49, 0x0a,
+	0x0e, 0x64, 0x65, 0x66, 0x61, 0x75, 0x6c, 0x74, 0x4f, 0x72, 0x64, 0x65, 0x72, 0x42, 0x79, 0x18,
+	0x06, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x21, 0x2e, 0x74, 0x68, 0x72, 0x65, 0x61, 0x64, 0x73, 0x2e,
+	0x70, 0x62, 0x2e, 0x43, 0x6f, 0x6c, 0x6c, 0x65, 0x63, 0x74, 0x69, 0x6f, 0x6e, 0x43, 0x6f, 0x6e,
+	0x66, 0x69, 0x67, 0x2e, 0x53, 0x6f, 0x72, 0x74, 0x52, 0x0e, 0x64, 0x65, 0x66, 0x61, 0x75, 0x6c,
+	0x74, 0x4f, 0x72, 0x64, 0x65, 0x72, 0x42, 0x79, 0x12, 0x18, 0x0a, 0x07, 0x76, 0x65, 0x72, 0x73,
+	0x69, 0x6f, 0x6e, 0x18, 0x07, 0x20, 0x01, 0x28, 0x03, 0x52, 0x07, 0x76, 0x65, 0x72, 0x73, 0x69,
+	0x6f, 0x6e, 0x12, 0x1a, 0x0a, 0x08, 0x74, 0x74, 0x6c, 0x46, 0x69, 0x65, 0x6c, 0x64, 0x18, 0x08,
+	0x20, 0x01, 0x28, 0x09, 0x52, 0x08, 0x74, 0x74, 0x6c, 0x46, 0x69, 0x65, 0x6c, 0x64, 0x12, 0x2a,
+	0x0a, 0x10, 0x63, 0x6f, 0x6e, 0x66, 0x6c, 0x69, 0x63, 0x74, 0x53, 0x74, 0x72, 0x61, 0x74, 0x65,
+	0x67, 0x79, 0x18, 0x09, 0x20, 0x01, 0x28, 0x05, 0x52, 0x10, 0x63, 0x6f, 0x6e, 0x66, 0x6c, 0x69,
+	0x63, 0x74, 0x53, 0x74, 0x72, 0x61, 0x74, 0x65, 0x67, 0x79, 0x12, 0x46, 0x0a, 0x0a, 0x72, 0x65,
+	0x66, 0x65, 0x72, 0x65, 0x6e, 0x63, 0x65, 0x73, 0x18, 0x0a, 0x20, 0x03, 0x28, 0x0b, 0x32, 0x26,
+	0x2e, 0x74, 0x68, 0x72, 0x65, 0x61, 0x64, 0x73, 0x2e, 0x70, 0x62, 0x2e, 0x43, 0x6f, 0x6c, 0x6c,
+	0x65, 0x63, 0x74, 0x69, 0x6f, 0x6e, 0x43, 0x6f, 0x6e, 0x66, 0x69, 0x67, 0x2e, 0x52, 0x65, 0x66,
+	0x65, 0x72, 0x65, 0x6e, 0x63, 0x65, 0x52, 0x0a, 0x72, 0x65, 0x66, 0x65, 0x72, 0x65, 0x6e, 0x63,
+	0x65, 0x73, 0x12, 0x28, 0x0a, 0x0f, 0x65, 0x6e, 0x63, 0x72, 0x79, 0x70, 0x74, 0x65, 0x64, 0x46,
+	0x69, 0x65, 0x6c, 0x64, 0x73, 0x18, 0x0b, 0x20, 0x03, 0x28, 0x09, 0x52, 0x0f, 0x65, 0x6e, 0x63,
+	0x72, 0x79, 0x70, 0x74, 0x65, 0x64, 0x46, 0x69, 0x65, 0x6c, 0x64, 0x73, 0x12, 0x1e, 0x0a, 0x0a,
+	0x69, 0x64, 0x53, 0x74, 0x72, 0x61, 0x74, 0x65, 0x67, 0x79, 0x18, 0x0c, 0x20, 0x01, 0x28, 0x05,
+	0x52, 0x0a, 0x69, 0x64, 0x53, 0x74, 0x72, 0x61, 0x74, 0x65, 0x67, 0x79, 0x12, 0x52, 0x0a, 0x0e,
+	0x63, 0x6f, 0x6d, 0x70, 0x75, 0x74, 0x65, 0x64, 0x46, 0x69, 0x65, 0x6c, 0x64, 0x73, 0x18, 0x0d,
+	0x20, 0x03, 0x28, 0x0b, 0x32, 0x2a, 0x2e, 0x74, 0x68, 0x72, 0x65, 0x61, 0x64, 0x73, 0x2e, 0x70,
+	0x62, 0x2e, 0x43, 0x6f, 0x6c, 0x6c, 0x65, 0x63, 0x74, 0x69, 0x6f, 0x6e, 0x43, 0x6f, 0x6e, 0x66,
+	0x69, 0x67, 0x2e, 0x43, 0x6f, 0x6d, 0x70, 0x75, 0x74, 0x65, 0x64, 0x46, 0x69, 0x65, 0x6c, 0x64,
+	0x52, 0x0e, 0x63, 0x6f, 0x6d, 0x70, 0x75, 0x74, 0x65, 0x64, 0x46, 0x69, 0x65, 0x6c, 0x64, 0x73,
+	0x12, 0x28, 0x0a, 0x0f, 0x73, 0x65, 0x72, 0x69, 0x61, 0x6c, 0x69, 0x7a, 0x65, 0x57, 0x72, 0x69,
+	0x74, 0x65, 0x73, 0x18, 0x0e, 0x20, 0x01, 0x28, 0x08, 0x52, 0x0f, 0x73, 0x65, 0x72, 0x69, 0x61,
+	0x6c, 0x69, 0x7a, 0x65, 0x57, 0x72, 0x69, 0x74, 0x65, 0x73, 0x22, 0x45, 0x0a, 0x1b, 0x47, 0x65,
 	0x74, 0x43, 0x6f, 0x6c, 0x6c, 0x65, 0x63, 0x74, 0x69, 0x6f, 0x6e, 0x49, 0x6e, 0x64, 0x65, 0x78,
 	0x65, 0x73, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x12, 0x12, 0x0a, 0x04, 0x64, 0x62, 0x49,
 	0x44, 0x18, 0x01, 0x20, 0x01, 0x28, 0x0c, 0x52, 0x04, 0x64, 0x62, 0x49, 0x44, 0x12, 0x12, 0x0a,
@@ -3612,116 +4722,210 @@ var file_threads_proto_rawDesc = []byte{
 	0x04, 0x20, 0x01, 0x28, 0x0c, 0x52, 0x08, 0x69, 0x6e, 0x73, 0x74, 0x61, 0x6e, 0x63, 0x65, 0x22,
 	0x2a, 0x0a, 0x06, 0x41, 0x63, 0x74, 0x69, 0x6f, 0x6e, 0x12, 0x0a, 0x0a, 0x06, 0x43, 0x52, 0x45,
 	0x41, 0x54, 0x45, 0x10, 0x00, 0x12, 0x08, 0x0a, 0x04, 0x53, 0x41, 0x56, 0x45, 0x10, 0x01, 0x12,
-	0x0a, 0x0a, 0x06, 0x44, 0x45, 0x4c, 0x45, 0x54, 0x45, 0x10, 0x02, 0x32, 0x99, 0x0d, 0x0a, 0x03,
-	0x41, 0x50, 0x49, 0x12, 0x48, 0x0a, 0x08, 0x47, 0x65, 0x74, 0x54, 0x6f, 0x6b, 0x65, 0x6e, 0x12,
-	0x1b, 0x2e, 0x74, 0x68, 0x72, 0x65, 0x61, 0x64, 0x73, 0x2e, 0x70, 0x62, 0x2e, 0x47, 0x65, 0x74,
-	0x54, 0x6f, 0x6b, 0x65, 0x6e, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x1a, 0x19, 0x2e, 0x74,
-	0x68, 0x72, 0x65, 0x61, 0x64, 0x73, 0x2e, 0x70, 0x62, 0x2e, 0x47, 0x65, 0x74, 0x54, 0x6f, 0x6b,
-	0x65, 0x6e, 0x52, 0x65, 0x70, 0x6c, 0x79, 0x22, 0x00, 0x28, 0x01, 0x30, 0x01, 0x12, 0x3b, 0x0a,
-	0x05, 0x4e, 0x65, 0x77, 0x44, 0x42, 0x12, 0x18, 0x2e, 0x74, 0x68, 0x72, 0x65, 0x61, 0x64, 0x73,
-	0x2e, 0x70, 0x62, 0x2e, 0x4e, 0x65, 0x77, 0x44, 0x42, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74,
-	0x1a, 0x16, 0x2e, 0x74, 0x68, 0x72, 0x65, 0x61, 0x64, 0x73, 0x2e, 0x70, 0x62, 0x2e, 0x4e, 0x65,
-	0x77, 0x44, 0x42, 0x52, 0x65, 0x70, 0x6c, 0x79, 0x22, 0x00, 0x12, 0x4b, 0x0a, 0x0d, 0x4e, 0x65,
-	0x77, 0x44, 0x42, 0x46, 0x72, 0x6f, 0x6d, 0x41, 0x64, 0x64, 0x72, 0x12, 0x20, 0x2e, 0x74, 0x68,
-	0x72, 0x65, 0x61, 0x64, 0x73, 0x2e, 0x70, 0x62, 0x2e, 0x4e, 0x65, 0x77, 0x44, 0x42, 0x46, 0x72,
-	0x6f, 0x6d, 0x41, 0x64, 0x64, 0x72, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x1a, 0x16, 0x2e,
-	0x74, 0x68, 0x72, 0x65, 0x61, 0x64, 0x73, 0x2e, 0x70, 0x62, 0x2e, 0x4e, 0x65, 0x77, 0x44, 0x42,
-	0x52, 0x65, 0x70, 0x6c, 0x79, 0x22, 0x00, 0x12, 0x41, 0x0a, 0x07, 0x4c, 0x69, 0x73, 0x74, 0x44,
-	0x42, 0x73, 0x12, 0x1a, 0x2e, 0x74, 0x68, 0x72, 0x65, 0x61, 0x64, 0x73, 0x2e, 0x70, 0x62, 0x2e,
-	0x4c, 0x69, 0x73, 0x74, 0x44, 0x42, 0x73, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x1a, 0x18,
-	0x2e, 0x74, 0x68, 0x72, 0x65, 0x61, 0x64, 0x73, 0x2e, 0x70, 0x62, 0x2e, 0x4c, 0x69, 0x73, 0x74,
-	0x44, 0x42, 0x73, 0x52, 0x65, 0x70, 0x6c, 0x79, 0x22, 0x00, 0x12, 0x47, 0x0a, 0x09, 0x47, 0x65,
-	0x74, 0x44, 0x42, 0x49, 0x6e, 0x66, 0x6f, 0x12, 0x1c, 0x2e, 0x74, 0x68, 0x72, 0x65, 0x61, 0x64,
-	0x73, 0x2e, 0x70, 0x62, 0x2e, 0x47, 0x65, 0x74, 0x44, 0x42, 0x49, 0x6e, 0x66, 0x6f, 0x52, 0x65,
-	0x71, 0x75, 0x65, 0x73, 0x74, 0x1a, 0x1a, 0x2e, 0x74, 0x68, 0x72, 0x65, 0x61, 0x64, 0x73, 0x2e,
-	0x70, 0x62, 0x2e, 0x47, 0x65, 0x74, 0x44, 0x42, 0x49, 0x6e, 0x66, 0x6f, 0x52, 0x65, 0x70, 0x6c,
-	0x79, 0x22, 0x00, 0x12, 0x44, 0x0a, 0x08, 0x44, 0x65, 0x6c, 0x65, 0x74, 0x65, 0x44, 0x42, 0x12,
-	0x1b, 0x2e, 0x74, 0x68, 0x72, 0x65, 0x61, 0x64, 0x73, 0x2e, 0x70, 0x62, 0x2e, 0x44, 0x65, 0x6c,
-	0x65, 0x74, 0x65, 0x44, 0x42, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x1a, 0x19, 0x2e, 0x74,
-	0x68, 0x72, 0x65, 0x61, 0x64, 0x73, 0x2e, 0x70, 0x62, 0x2e, 0x44, 0x65, 0x6c, 0x65, 0x74, 0x65,
-	0x44, 0x42, 0x52, 0x65, 0x70, 0x6c, 0x79, 0x22, 0x00, 0x12, 0x53, 0x0a, 0x0d, 0x4e, 0x65, 0x77,
-	0x43, 0x6f, 0x6c, 0x6c, 0x65, 0x63, 0x74, 0x69, 0x6f, 0x6e, 0x12, 0x20, 0x2e, 0x74, 0x68, 0x72,
-	0x65, 0x61, 0x64, 0x73, 0x2e, 0x70, 0x62, 0x2e, 0x4e, 0x65, 0x77, 0x43, 0x6f, 0x6c, 0x6c, 0x65,
-	0x63, 0x74, 0x69, 0x6f, 0x6e, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x1a, 0x1e, 0x2e, 0x74,
-	0x68, 0x72, 0x65, 0x61, 0x64, 0x73, 0x2e, 0x70, 0x62, 0x2e, 0x4e, 0x65, 0x77, 0x43, 0x6f, 0x6c,
-	0x6c, 0x65, 0x63, 0x74, 0x69, 0x6f, 0x6e, 0x52, 0x65, 0x70, 0x6c, 0x79, 0x22, 0x00, 0x12, 0x5c,
-	0x0a, 0x10, 0x55, 0x70, 0x64, 0x61, 0x74, 0x65, 0x43, 0x6f, 0x6c, 0x6c, 0x65, 0x63, 0x74, 0x69,
-	0x6f, 0x6e, 0x12, 0x23, 0x2e, 0x74, 0x68, 0x72, 0x65, 0x61, 0x64, 0x73, 0x2e, 0x70, 0x62, 0x2e,
+	0x0a, 0x0a, 0x06, 0x44, 0x45, 0x4c, 0x45, 0x54, 0x45, 0x10, 0x02, 0x22, 0x68, 0x0a, 0x0c, 0x43,
+	0x6f, 0x75, 0x6e, 0x74, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x12, 0x12, 0x0a, 0x04, 0x64,
+	0x62, 0x49, 0x44, 0x18, 0x01, 0x20, 0x01, 0x28, 0x0c, 0x52, 0x04, 0x64, 0x62, 0x49, 0x44, 0x12,
+	0x26, 0x0a, 0x0e, 0x63, 0x6f, 0x6c, 0x6c, 0x65, 0x63, 0x74, 0x69, 0x6f, 0x6e, 0x4e, 0x61, 0x6d,
+	0x65, 0x18, 0x02, 0x20, 0x01, 0x28, 0x09, 0x52, 0x0e, 0x63, 0x6f, 0x6c, 0x6c, 0x65, 0x63, 0x74,
+	0x69, 0x6f, 0x6e, 0x4e, 0x61, 0x6d, 0x65, 0x12, 0x1c, 0x0a, 0x09, 0x71, 0x75, 0x65, 0x72, 0x79,
+	0x4a, 0x53, 0x4f, 0x4e, 0x18, 0x03, 0x20, 0x01, 0x28, 0x0c, 0x52, 0x09, 0x71, 0x75, 0x65, 0x72,
+	0x79, 0x4a, 0x53, 0x4f, 0x4e, 0x22, 0x22, 0x0a, 0x0a, 0x43, 0x6f, 0x75, 0x6e, 0x74, 0x52, 0x65,
+	0x70, 0x6c, 0x79, 0x12, 0x14, 0x0a, 0x05, 0x63, 0x6f, 0x75, 0x6e, 0x74, 0x18, 0x01, 0x20, 0x01,
+	0x28, 0x03, 0x52, 0x05, 0x63, 0x6f, 0x75, 0x6e, 0x74, 0x22, 0x2e, 0x0a, 0x10, 0x46, 0x69, 0x6e,
+	0x64, 0x49, 0x74, 0x65, 0x72, 0x61, 0x74, 0x65, 0x52, 0x65, 0x70, 0x6c, 0x79, 0x12, 0x1a, 0x0a,
+	0x08, 0x69, 0x6e, 0x73, 0x74, 0x61, 0x6e, 0x63, 0x65, 0x18, 0x01, 0x20, 0x01, 0x28, 0x0c, 0x52,
+	0x08, 0x69, 0x6e, 0x73, 0x74, 0x61, 0x6e, 0x63, 0x65, 0x22, 0x4b, 0x0a, 0x0d, 0x45, 0x78, 0x70,
+	0x6f, 0x72, 0x74, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x12, 0x12, 0x0a, 0x04, 0x64, 0x62,
+	0x49, 0x44, 0x18, 0x01, 0x20, 0x01, 0x28, 0x0c, 0x52, 0x04, 0x64, 0x62, 0x49, 0x44, 0x12, 0x26,
+	0x0a, 0x0e, 0x63, 0x6f, 0x6c, 0x6c, 0x65, 0x63, 0x74, 0x69, 0x6f, 0x6e, 0x4e, 0x61, 0x6d, 0x65,
+	0x18, 0x02, 0x20, 0x01, 0x28, 0x09, 0x52, 0x0e, 0x63, 0x6f, 0x6c, 0x6c, 0x65, 0x63, 0x74, 0x69,
+	0x6f, 0x6e, 0x4e, 0x61, 0x6d, 0x65, 0x22, 0x23, 0x0a, 0x0b, 0x45, 0x78, 0x70, 0x6f, 0x72, 0x74,
+	0x52, 0x65, 0x70, 0x6c, 0x79, 0x12, 0x14, 0x0a, 0x05, 0x63, 0x68, 0x75, 0x6e, 0x6b, 0x18, 0x01,
+	0x20, 0x01, 0x28, 0x0c, 0x52, 0x05, 0x63, 0x68, 0x75, 0x6e, 0x6b, 0x22, 0x43, 0x0a, 0x19, 0x47,
+	0x65, 0x74, 0x43, 0x6f, 0x6c, 0x6c, 0x65, 0x63, 0x74, 0x69, 0x6f, 0x6e, 0x53, 0x74, 0x61, 0x74,
+	0x73, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x12, 0x12, 0x0a, 0x04, 0x64, 0x62, 0x49, 0x44,
+	0x18, 0x01, 0x20, 0x01, 0x28, 0x0c, 0x52, 0x04, 0x64, 0x62, 0x49, 0x44, 0x12, 0x12, 0x0a, 0x04,
+	0x6e, 0x61, 0x6d, 0x65, 0x18, 0x02, 0x20, 0x01, 0x28, 0x09, 0x52, 0x04, 0x6e, 0x61, 0x6d, 0x65,
+	0x22, 0x8d, 0x02, 0x0a, 0x17, 0x47, 0x65, 0x74, 0x43, 0x6f, 0x6c, 0x6c, 0x65, 0x63, 0x74, 0x69,
+	0x6f, 0x6e, 0x53, 0x74, 0x61, 0x74, 0x73, 0x52, 0x65, 0x70, 0x6c, 0x79, 0x12, 0x1c, 0x0a, 0x09,
+	0x69, 0x6e, 0x73, 0x74, 0x61, 0x6e, 0x63, 0x65, 0x73, 0x18, 0x01, 0x20, 0x01, 0x28, 0x03, 0x52,
+	0x09, 0x69, 0x6e, 0x73, 0x74, 0x61, 0x6e, 0x63, 0x65, 0x73, 0x12, 0x14, 0x0a, 0x05, 0x62, 0x79,
+	0x74, 0x65, 0x73, 0x18, 0x02, 0x20, 0x01, 0x28, 0x03, 0x52, 0x05, 0x62, 0x79, 0x74, 0x65, 0x73,
+	0x12, 0x48, 0x0a, 0x07, 0x69, 0x6e, 0x64, 0x65, 0x78, 0x65, 0x73, 0x18, 0x03, 0x20, 0x03, 0x28,
+	0x0b, 0x32, 0x2e, 0x2e, 0x74, 0x68, 0x72, 0x65, 0x61, 0x64, 0x73, 0x2e, 0x70, 0x62, 0x2e, 0x47,
+	0x65, 0x74, 0x43, 0x6f, 0x6c, 0x6c, 0x65, 0x63, 0x74, 0x69, 0x6f, 0x6e, 0x53, 0x74, 0x61, 0x74,
+	0x73, 0x52, 0x65, 0x70, 0x6c, 0x79, 0x2e, 0x49, 0x6e, 0x64, 0x65, 0x78, 0x53, 0x74, 0x61, 0x74,
+	0x73, 0x52, 0x07, 0x69, 0x6e, 0x64, 0x65, 0x78, 0x65, 0x73, 0x12, 0x22, 0x0a, 0x0c, 0x6c, 0x61,
+	0x73, 0x74, 0x4d, 0x6f, 0x64, 0x69, 0x66, 0x69, 0x65, 0x64, 0x18, 0x04, 0x20, 0x01, 0x28, 0x03,
+	0x52, 0x0c, 0x6c, 0x61, 0x73, 0x74, 0x4d, 0x6f, 0x64, 0x69, 0x66, 0x69, 0x65, 0x64, 0x1a, 0x50,
+	0x0a, 0x0a, 0x49, 0x6e, 0x64, 0x65, 0x78, 0x53, 0x74, 0x61, 0x74, 0x73, 0x12, 0x12, 0x0a, 0x04,
+	0x70, 0x61, 0x74, 0x68, 0x18, 0x01, 0x20, 0x01, 0x28, 0x09, 0x52, 0x04, 0x70, 0x61, 0x74, 0x68,
+	0x12, 0x18, 0x0a, 0x07, 0x65, 0x6e, 0x74, 0x72, 0x69, 0x65, 0x73, 0x18, 0x02, 0x20, 0x01, 0x28,
+	0x03, 0x52, 0x07, 0x65, 0x6e, 0x74, 0x72, 0x69, 0x65, 0x73, 0x12, 0x14, 0x0a, 0x05, 0x62, 0x79,
+	0x74, 0x65, 0x73, 0x18, 0x03, 0x20, 0x01, 0x28, 0x03, 0x52, 0x05, 0x62, 0x79, 0x74, 0x65, 0x73,
+	0x22, 0x69, 0x0a, 0x0f, 0x56, 0x61, 0x6c, 0x69, 0x64, 0x61, 0x74, 0x65, 0x52, 0x65, 0x71, 0x75,
+	0x65, 0x73, 0x74, 0x12, 0x12, 0x0a, 0x04, 0x64, 0x62, 0x49, 0x44, 0x18, 0x01, 0x20, 0x01, 0x28,
+	0x0c, 0x52, 0x04, 0x64, 0x62, 0x49, 0x44, 0x12, 0x26, 0x0a, 0x0e, 0x63, 0x6f, 0x6c, 0x6c, 0x65,
+	0x63, 0x74, 0x69, 0x6f, 0x6e, 0x4e, 0x61, 0x6d, 0x65, 0x18, 0x02, 0x20, 0x01, 0x28, 0x09, 0x52,
+	0x0e, 0x63, 0x6f, 0x6c, 0x6c, 0x65, 0x63, 0x74, 0x69, 0x6f, 0x6e, 0x4e, 0x61, 0x6d, 0x65, 0x12,
+	0x1a, 0x0a, 0x08, 0x69, 0x6e, 0x73, 0x74, 0x61, 0x6e, 0x63, 0x65, 0x18, 0x03, 0x20, 0x01, 0x28,
+	0x0c, 0x52, 0x08, 0x69, 0x6e, 0x73, 0x74, 0x61, 0x6e, 0x63, 0x65, 0x22, 0x0f, 0x0a, 0x0d, 0x56,
+	0x61, 0x6c, 0x69, 0x64, 0x61, 0x74, 0x65, 0x52, 0x65, 0x70, 0x6c, 0x79, 0x22, 0x70, 0x0a, 0x10,
+	0x46, 0x69, 0x6e, 0x64, 0x42, 0x79, 0x49, 0x44, 0x73, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74,
+	0x12, 0x12, 0x0a, 0x04, 0x64, 0x62, 0x49, 0x44, 0x18, 0x01, 0x20, 0x01, 0x28, 0x0c, 0x52, 0x04,
+	0x64, 0x62, 0x49, 0x44, 0x12, 0x26, 0x0a, 0x0e, 0x63, 0x6f, 0x6c, 0x6c, 0x65, 0x63, 0x74, 0x69,
+	0x6f, 0x6e, 0x4e, 0x61, 0x6d, 0x65, 0x18, 0x02, 0x20, 0x01, 0x28, 0x09, 0x52, 0x0e, 0x63, 0x6f,
+	0x6c, 0x6c, 0x65, 0x63, 0x74, 0x69, 0x6f, 0x6e, 0x4e, 0x61, 0x6d, 0x65, 0x12, 0x20, 0x0a, 0x0b,
+	0x69, 0x6e, 0x73, 0x74, 0x61, 0x6e, 0x63, 0x65, 0x49, 0x44, 0x73, 0x18, 0x03, 0x20, 0x03, 0x28,
+	0x09, 0x52, 0x0b, 0x69, 0x6e, 0x73, 0x74, 0x61, 0x6e, 0x63, 0x65, 0x49, 0x44, 0x73, 0x22, 0x2e,
+	0x0a, 0x0e, 0x46, 0x69, 0x6e, 0x64, 0x42, 0x79, 0x49, 0x44, 0x73, 0x52, 0x65, 0x70, 0x6c, 0x79,
+	0x12, 0x1c, 0x0a, 0x09, 0x69, 0x6e, 0x73, 0x74, 0x61, 0x6e, 0x63, 0x65, 0x73, 0x18, 0x01, 0x20,
+	0x03, 0x28, 0x0c, 0x52, 0x09, 0x69, 0x6e, 0x73, 0x74, 0x61, 0x6e, 0x63, 0x65, 0x73, 0x22, 0x46,
+	0x0a, 0x0c, 0x47, 0x65, 0x74, 0x4d, 0x61, 0x6e, 0x79, 0x52, 0x65, 0x70, 0x6c, 0x79, 0x12, 0x1c,
+	0x0a, 0x09, 0x69, 0x6e, 0x73, 0x74, 0x61, 0x6e, 0x63, 0x65, 0x73, 0x18, 0x01, 0x20, 0x03, 0x28,
+	0x0c, 0x52, 0x09, 0x69, 0x6e, 0x73, 0x74, 0x61, 0x6e, 0x63, 0x65, 0x73, 0x12, 0x18, 0x0a, 0x07,
+	0x6d, 0x69, 0x73, 0x73, 0x69, 0x6e, 0x67, 0x18, 0x02, 0x20, 0x03, 0x28, 0x09, 0x52, 0x07, 0x6d,
+	0x69, 0x73, 0x73, 0x69, 0x6e, 0x67, 0x32, 0x8c, 0x11, 0x0a, 0x03, 0x41, 0x50, 0x49, 0x12, 0x48,
+	0x0a, 0x08, 0x47, 0x65, 0x74, 0x54, 0x6f, 0x6b, 0x65, 0x6e, 0x12, 0x1b, 0x2e, 0x74, 0x68, 0x72,
+	0x65, 0x61, 0x64, 0x73, 0x2e, 0x70, 0x62, 0x2e, 0x47, 0x65, 0x74, 0x54, 0x6f, 0x6b, 0x65, 0x6e,
+	0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x1a, 0x19, 0x2e, 0x74, 0x68, 0x72, 0x65, 0x61, 0x64,
+	0x73, 0x2e, 0x70, 0x62, 0x2e, 0x47, 0x65, 0x74, 0x54, 0x6f, 0x6b, 0x65, 0x6e, 0x52, 0x65, 0x70,
+	0x6c, 0x79, 0x22, 0x00, 0x28, 0x01, 0x30, 0x01, 0x12, 0x3b, 0x0a, 0x05, 0x4e, 0x65, 0x77, 0x44,
+	0x42, 0x12, 0x18, 0x2e, 0x74, 0x68, 0x72, 0x65, 0x61, 0x64, 0x73, 0x2e, 0x70, 0x62, 0x2e, 0x4e,
+	0x65, 0x77, 0x44, 0x42, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x1a, 0x16, 0x2e, 0x74, 0x68,
+	0x72, 0x65, 0x61, 0x64, 0x73, 0x2e, 0x70, 0x62, 0x2e, 0x4e, 0x65, 0x77, 0x44, 0x42, 0x52, 0x65,
+	0x70, 0x6c, 0x79, 0x22, 0x00, 0x12, 0x4b, 0x0a, 0x0d, 0x4e, 0x65, 0x77, 0x44, 0x42, 0x46, 0x72,
+	0x6f, 0x6d, 0x41, 0x64, 0x64, 0x72, 0x12, 0x20, 0x2e, 0x74, 0x68, 0x72, 0x65, 0x61, 0x64, 0x73,
+	0x2e, 0x70, 0x62, 0x2e, 0x4e, 0x65, 0x77, 0x44, 0x42, 0x46, 0x72, 0x6f, 0x6d, 0x41, 0x64, 0x64,
+	0x72, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x1a, 0x16, 0x2e, 0x74, 0x68, 0x72, 0x65, 0x61,
+	0x64, 0x73, 0x2e, 0x70, 0x62, 0x2e, 0x4e, 0x65, 0x77, 0x44, 0x42, 0x52, 0x65, 0x70, 0x6c, 0x79,
+	0x22, 0x00, 0x12, 0x41, 0x0a, 0x07, 0x4c, 0x69, 0x73, 0x74, 0x44, 0x42, 0x73, 0x12, 0x1a, 0x2e,
+	0x74, 0x68, 0x72, 0x65, 0x61, 0x64, 0x73, 0x2e, 0x70, 0x62, 0x2e, 0x4c, 0x69, 0x73, 0x74, 0x44,
+	0x42, 0x73, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x1a, 0x18, 0x2e, 0x74, 0x68, 0x72, 0x65,
+	0x61, 0x64, 0x73, 0x2e, 0x70, 0x62, 0x2e, 0x4c, 0x69, 0x73, 0x74, 0x44, 0x42, 0x73, 0x52, 0x65,
+	0x70, 0x6c, 0x79, 0x22, 0x00, 0x12, 0x47, 0x0a, 0x09, 0x47, 0x65, 0x74, 0x44, 0x42, 0x49, 0x6e,
+	0x66, 0x6f, 0x12, 0x1c, 0x2e, 0x74, 0x68, 0x72, 0x65, 0x61, 0x64, 0x73, 0x2e, 0x70, 0x62, 0x2e,
+	0x47, 0x65, 0x74, 0x44, 0x42, 0x49, 0x6e, 0x66, 0x6f, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74,
+	0x1a, 0x1a, 0x2e, 0x74, 0x68, 0x72, 0x65, 0x61, 0x64, 0x73, 0x2e, 0x70, 0x62, 0x2e, 0x47, 0x65,
+	0x74, 0x44, 0x42, 0x49, 0x6e, 0x66, 0x6f, 0x52, 0x65, 0x70, 0x6c, 0x79, 0x22, 0x00, 0x12, 0x44,
+	0x0a, 0x08, 0x44, 0x65, 0x6c, 0x65, 0x74, 0x65, 0x44, 0x42, 0x12, 0x1b, 0x2e, 0x74, 0x68, 0x72,
+	0x65, 0x61, 0x64, 0x73, 0x2e, 0x70, 0x62, 0x2e, 0x44, 0x65, 0x6c, 0x65, 0x74, 0x65, 0x44, 0x42,
+	0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x1a, 0x19, 0x2e, 0x74, 0x68, 0x72, 0x65, 0x61, 0x64,
+	0x73, 0x2e, 0x70, 0x62, 0x2e, 0x44, 0x65, 0x6c, 0x65, 0x74, 0x65, 0x44, 0x42, 0x52, 0x65, 0x70,
+	0x6c, 0x79, 0x22, 0x00, 0x12, 0x53, 0x0a, 0x0d, 0x4e, 0x65, 0x77, 0x43, 0x6f, 0x6c, 0x6c, 0x65,
+	0x63, 0x74, 0x69, 0x6f, 0x6e, 0x12, 0x20, 0x2e, 0x74, 0x68, 0x72, 0x65, 0x61, 0x64, 0x73, 0x2e,
+	0x70, 0x62, 0x2e, 0x4e, 0x65, 0x77, 0x43, 0x6f, 0x6c, 0x6c, 0x65, 0x63, 0x74, 0x69, 0x6f, 0x6e,
+	0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x1a, 0x1e, 0x2e, 0x74, 0x68, 0x72, 0x65, 0x61, 0x64,
+	0x73, 0x2e, 0x70, 0x62, 0x2e, 0x4e, 0x65, 0x77, 0x43, 0x6f, 0x6c, 0x6c, 0x65, 0x63, 0x74, 0x69,
+	0x6f, 0x6e, 0x52, 0x65, 0x70, 0x6c, 0x79, 0x22, 0x00, 0x12, 0x5c, 0x0a, 0x10, 0x55, 0x70, 0x64,
+	0x61, 0x74, 0x65, 0x43, 0x6f, 0x6c, 0x6c, 0x65, 0x63, 0x74, 0x69, 0x6f, 0x6e, 0x12, 0x23, 0x2e,
+	0x74, 0x68, 0x72, 0x65, 0x61, 0x64, 0x73, 0x2e, 0x70, 0x62, 0x2e, 0x55, 0x70, 0x64, 0x61, 0x74,
+	0x65, 0x43, 0x6f, 0x6c, 0x6c, 0x65, 0x63, 0x74, 0x69, 0x6f, 0x6e, 0x52, 0x65, 0x71, 0x75, 0x65,
+	0x73, 0x74, 0x1a, 0x21, 0x2e, 0x74, 0x68, 0x72, 0x65, 0x61, 0x64, 0x73, 0x2e, 0x70, 0x62, 0x2e,
 	0x55, 0x70, 0x64, 0x61, 0x74, 0x65, 0x43, 0x6f, 0x6c, 0x6c, 0x65, 0x63, 0x74, 0x69, 0x6f, 0x6e,
-	0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x1a, 0x21, 0x2e, 0x74, 0x68, 0x72, 0x65, 0x61, 0x64,
-	0x73, 0x2e, 0x70, 0x62, 0x2e, 0x55, 0x70, 0x64, 0x61, 0x74, 0x65, 0x43, 0x6f, 0x6c, 0x6c, 0x65,
-	0x63, 0x74, 0x69, 0x6f, 0x6e, 0x52, 0x65, 0x70, 0x6c, 0x79, 0x22, 0x00, 0x12, 0x5c, 0x0a, 0x10,
-	0x44, 0x65, 0x6c, 0x65, 0x74, 0x65, 0x43, 0x6f, 0x6c, 0x6c, 0x65, 0x63, 0x74, 0x69, 0x6f, 0x6e,
-	0x12, 0x23, 0x2e, 0x74, 0x68, 0x72, 0x65, 0x61, 0x64, 0x73, 0x2e, 0x70, 0x62, 0x2e, 0x44, 0x65,
+	0x52, 0x65, 0x70, 0x6c, 0x79, 0x22, 0x00, 0x12, 0x5c, 0x0a, 0x10, 0x44, 0x65, 0x6c, 0x65, 0x74,
+	0x65, 0x43, 0x6f, 0x6c, 0x6c, 0x65, 0x63, 0x74, 0x69, 0x6f, 0x6e, 0x12, 0x23, 0x2e, 0x74, 0x68,
+	0x72, 0x65, 0x61, 0x64, 0x73, 0x2e, 0x70, 0x62, 0x2e, 0x44, 0x65, 0x6c, 0x65, 0x74, 0x65, 0x43,
+	0x6f, 0x6c, 0x6c, 0x65, 0x63, 0x74, 0x69, 0x6f, 0x6e, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74,
+	0x1a, 0x21, 0x2e, 0x74, 0x68, 0x72, 0x65, 0x61, 0x64, 0x73, 0x2e, 0x70, 0x62, 0x2e, 0x44, 0x65,
 	0x6c, 0x65, 0x74, 0x65, 0x43, 0x6f, 0x6c, 0x6c, 0x65, 0x63, 0x74, 0x69, 0x6f, 0x6e, 0x52, 0x65,
-	0x71, 0x75, 0x65, 0x73, 0x74, 0x1a, 0x21, 0x2e, 0x74, 0x68, 0x72, 0x65, 0x61, 0x64, 0x73, 0x2e,
-	0x70, 0x62, 0x2e, 0x44, 0x65, 0x6c, 0x65, 0x74, 0x65, 0x43, 0x6f, 0x6c, 0x6c, 0x65, 0x63, 0x74,
-	0x69, 0x6f, 0x6e, 0x52, 0x65, 0x70, 0x6c, 0x79, 0x22, 0x00, 0x12, 0x5f, 0x0a, 0x11, 0x47, 0x65,
-	0x74, 0x43, 0x6f, 0x6c, 0x6c, 0x65, 0x63, 0x74, 0x69, 0x6f, 0x6e, 0x49, 0x6e, 0x66, 0x6f, 0x12,
-	0x24, 0x2e, 0x74, 0x68, 0x72, 0x65, 0x61, 0x64, 0x73, 0x2e, 0x70, 0x62, 0x2e, 0x47, 0x65, 0x74,
-	0x43, 0x6f, 0x6c, 0x6c, 0x65, 0x63, 0x74, 0x69, 0x6f, 0x6e, 0x49, 0x6e, 0x66, 0x6f, 0x52, 0x65,
-	0x71, 0x75, 0x65, 0x73, 0x74, 0x1a, 0x22, 0x2e, 0x74, 0x68, 0x72, 0x65, 0x61, 0x64, 0x73, 0x2e,
-	0x70, 0x62, 0x2e, 0x47, 0x65, 0x74, 0x43, 0x6f, 0x6c, 0x6c, 0x65, 0x63, 0x74, 0x69, 0x6f, 0x6e,
-	0x49, 0x6e, 0x66, 0x6f, 0x52, 0x65, 0x70, 0x6c, 0x79, 0x22, 0x00, 0x12, 0x6b, 0x0a, 0x14, 0x47,
-	0x65, 0x74, 0x43, 0x6f, 0x6c, 0x6c, 0x65, 0x63, 0x74, 0x69, 0x6f, 0x6e, 0x49, 0x6e, 0x64, 0x65,
-	0x78, 0x65, 0x73, 0x12, 0x27, 0x2e, 0x74, 0x68, 0x72, 0x65, 0x61, 0x64, 0x73, 0x2e, 0x70, 0x62,
-	0x2e, 0x47, 0x65, 0x74, 0x43, 0x6f, 0x6c, 0x6c, 0x65, 0x63, 0x74, 0x69, 0x6f, 0x6e, 0x49, 0x6e,
-	0x64, 0x65, 0x78, 0x65, 0x73, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x1a, 0x25, 0x2e, 0x74,
-	0x68, 0x72, 0x65, 0x61, 0x64, 0x73, 0x2e, 0x70, 0x62, 0x2e, 0x47, 0x65, 0x74, 0x43, 0x6f, 0x6c,
-	0x6c, 0x65, 0x63, 0x74, 0x69, 0x6f, 0x6e, 0x49, 0x6e, 0x64, 0x65, 0x78, 0x65, 0x73, 0x52, 0x65,
-	0x70, 0x6c, 0x79, 0x22, 0x03, 0x88, 0x02, 0x01, 0x12, 0x59, 0x0a, 0x0f, 0x4c, 0x69, 0x73, 0x74,
-	0x43, 0x6f, 0x6c, 0x6c, 0x65, 0x63, 0x74, 0x69, 0x6f, 0x6e, 0x73, 0x12, 0x22, 0x2e, 0x74, 0x68,
-	0x72, 0x65, 0x61, 0x64, 0x73, 0x2e, 0x70, 0x62, 0x2e, 0x4c, 0x69, 0x73, 0x74, 0x43, 0x6f, 0x6c,
-	0x6c, 0x65, 0x63, 0x74, 0x69, 0x6f, 0x6e, 0x73, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x1a,
-	0x20, 0x2e, 0x74, 0x68, 0x72, 0x65, 0x61, 0x64, 0x73, 0x2e, 0x70, 0x62, 0x2e, 0x4c, 0x69, 0x73,
-	0x74, 0x43, 0x6f, 0x6c, 0x6c, 0x65, 0x63, 0x74, 0x69, 0x6f, 0x6e, 0x73, 0x52, 0x65, 0x70, 0x6c,
-	0x79, 0x22, 0x00, 0x12, 0x3e, 0x0a, 0x06, 0x43, 0x72, 0x65, 0x61, 0x74, 0x65, 0x12, 0x19, 0x2e,
-	0x74, 0x68, 0x72, 0x65, 0x61, 0x64, 0x73, 0x2e, 0x70, 0x62, 0x2e, 0x43, 0x72, 0x65, 0x61, 0x74,
-	0x65, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x1a, 0x17, 0x2e, 0x74, 0x68, 0x72, 0x65, 0x61,
-	0x64, 0x73, 0x2e, 0x70, 0x62, 0x2e, 0x43, 0x72, 0x65, 0x61, 0x74, 0x65, 0x52, 0x65, 0x70, 0x6c,
-	0x79, 0x22, 0x00, 0x12, 0x3e, 0x0a, 0x06, 0x56, 0x65, 0x72, 0x69, 0x66, 0x79, 0x12, 0x19, 0x2e,
-	0x74, 0x68, 0x72, 0x65, 0x61, 0x64, 0x73, 0x2e, 0x70, 0x62, 0x2e, 0x56, 0x65, 0x72, 0x69, 0x66,
-	0x79, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x1a, 0x17, 0x2e, 0x74, 0x68, 0x72, 0x65, 0x61,
-	0x64, 0x73, 0x2e, 0x70, 0x62, 0x2e, 0x56, 0x65, 0x72, 0x69, 0x66, 0x79, 0x52, 0x65, 0x70, 0x6c,
-	0x79, 0x22, 0x00, 0x12, 0x38, 0x0a, 0x04, 0x53, 0x61, 0x76, 0x65, 0x12, 0x17, 0x2e, 0x74, 0x68,
-	0x72, 0x65, 0x61, 0x64, 0x73, 0x2e, 0x70, 0x62, 0x2e, 0x53, 0x61, 0x76, 0x65, 0x52, 0x65, 0x71,
-	0x75, 0x65, 0x73, 0x74, 0x1a, 0x15, 0x2e, 0x74, 0x68, 0x72, 0x65, 0x61, 0x64, 0x73, 0x2e, 0x70,
-	0x62, 0x2e, 0x53, 0x61, 0x76, 0x65, 0x52, 0x65, 0x70, 0x6c, 0x79, 0x22, 0x00, 0x12, 0x3e, 0x0a,
-	0x06, 0x44, 0x65, 0x6c, 0x65, 0x74, 0x65, 0x12, 0x19, 0x2e, 0x74, 0x68, 0x72, 0x65, 0x61, 0x64,
-	0x73, 0x2e, 0x70, 0x62, 0x2e, 0x44, 0x65, 0x6c, 0x65, 0x74, 0x65, 0x52, 0x65, 0x71, 0x75, 0x65,
-	0x73, 0x74, 0x1a, 0x17, 0x2e, 0x74, 0x68, 0x72, 0x65, 0x61, 0x64, 0x73, 0x2e, 0x70, 0x62, 0x2e,
-	0x44, 0x65, 0x6c, 0x65, 0x74, 0x65, 0x52, 0x65, 0x70, 0x6c, 0x79, 0x22, 0x00, 0x12, 0x35, 0x0a,
-	0x03, 0x48, 0x61, 0x73, 0x12, 0x16, 0x2e, 0x74, 0x68, 0x72, 0x65, 0x61, 0x64, 0x73, 0x2e, 0x70,
-	0x62, 0x2e, 0x48, 0x61, 0x73, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x1a, 0x14, 0x2e, 0x74,
-	0x68, 0x72, 0x65, 0x61, 0x64, 0x73, 0x2e, 0x70, 0x62, 0x2e, 0x48, 0x61, 0x73, 0x52, 0x65, 0x70,
-	0x6c, 0x79, 0x22, 0x00, 0x12, 0x38, 0x0a, 0x04, 0x46, 0x69, 0x6e, 0x64, 0x12, 0x17, 0x2e, 0x74,
-	0x68, 0x72, 0x65, 0x61, 0x64, 0x73, 0x2e, 0x70, 0x62, 0x2e, 0x46, 0x69, 0x6e, 0x64, 0x52, 0x65,
-	0x71, 0x75, 0x65, 0x73, 0x74, 0x1a, 0x15, 0x2e, 0x74, 0x68, 0x72, 0x65, 0x61, 0x64, 0x73, 0x2e,
-	0x70, 0x62, 0x2e, 0x46, 0x69, 0x6e, 0x64, 0x52, 0x65, 0x70, 0x6c, 0x79, 0x22, 0x00, 0x12, 0x44,
-	0x0a, 0x08, 0x46, 0x69, 0x6e, 0x64, 0x42, 0x79, 0x49, 0x44, 0x12, 0x1b, 0x2e, 0x74, 0x68, 0x72,
-	0x65, 0x61, 0x64, 0x73, 0x2e, 0x70, 0x62, 0x2e, 0x46, 0x69, 0x6e, 0x64, 0x42, 0x79, 0x49, 0x44,
-	0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x1a, 0x19, 0x2e, 0x74, 0x68, 0x72, 0x65, 0x61, 0x64,
-	0x73, 0x2e, 0x70, 0x62, 0x2e, 0x46, 0x69, 0x6e, 0x64, 0x42, 0x79, 0x49, 0x44, 0x52, 0x65, 0x70,
-	0x6c, 0x79, 0x22, 0x00, 0x12, 0x5d, 0x0a, 0x0f, 0x52, 0x65, 0x61, 0x64, 0x54, 0x72, 0x61, 0x6e,
-	0x73, 0x61, 0x63, 0x74, 0x69, 0x6f, 0x6e, 0x12, 0x22, 0x2e, 0x74, 0x68, 0x72, 0x65, 0x61, 0x64,
-	0x73, 0x2e, 0x70, 0x62, 0x2e, 0x52, 0x65, 0x61, 0x64, 0x54, 0x72, 0x61, 0x6e, 0x73, 0x61, 0x63,
-	0x74, 0x69, 0x6f, 0x6e, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x1a, 0x20, 0x2e, 0x74, 0x68,
-	0x72, 0x65, 0x61, 0x64, 0x73, 0x2e, 0x70, 0x62, 0x2e, 0x52, 0x65, 0x61, 0x64, 0x54, 0x72, 0x61,
-	0x6e, 0x73, 0x61, 0x63, 0x74, 0x69, 0x6f, 0x6e, 0x52, 0x65, 0x70, 0x6c, 0x79, 0x22, 0x00, 0x28,
-	0x01, 0x30, 0x01, 0x12, 0x60, 0x0a, 0x10, 0x57, 0x72, 0x69, 0x74, 0x65, 0x54, 0x72, 0x61, 0x6e,
-	0x73, 0x61, 0x63, 0x74, 0x69, 0x6f, 0x6e, 0x12, 0x23, 0x2e, 0x74, 0x68, 0x72, 0x65, 0x61, 0x64,
+	0x70, 0x6c, 0x79, 0x22, 0x00, 0x12, 0x5f, 0x0a, 0x11, 0x47, 0x65, 0x74, 0x43, 0x6f, 0x6c, 0x6c,
+	0x65, 0x63, 0x74, 0x69, 0x6f, 0x6e, 0x49, 0x6e, 0x66, 0x6f, 0x12, 0x24, 0x2e, 0x74, 0x68, 0x72,
+	0x65, 0x61, 0x64, 0x73, 0x2e, 0x70, 0x62, 0x2e, 0x47, 0x65, 0x74, 0x43, 0x6f, 0x6c, 0x6c, 0x65,
+	0x63, 0x74, 0x69, 0x6f, 0x6e, 0x49, 0x6e, 0x66, 0x6f, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74,
+	0x1a, 0x22, 0x2e, 0x74, 0x68, 0x72, 0x65, 0x61, 0x64, 0x73, 0x2e, 0x70, 0x62, 0x2e, 0x47, 0x65,
+	0x74, 0x43, 0x6f, 0x6c, 0x6c, 0x65, 0x63, 0x74, 0x69, 0x6f, 0x6e, 0x49, 0x6e, 0x66, 0x6f, 0x52,
+	0x65, 0x70, 0x6c, 0x79, 0x22, 0x00, 0x12, 0x6b, 0x0a, 0x14, 0x47, 0x65, 0x74, 0x43, 0x6f, 0x6c,
+	0x6c, 0x65, 0x63, 0x74, 0x69, 0x6f, 0x6e, 0x49, 0x6e, 0x64, 0x65, 0x78, 0x65, 0x73, 0x12, 0x27,
+	0x2e, 0x74, 0x68, 0x72, 0x65, 0x61, 0x64, 0x73, 0x2e, 0x70, 0x62, 0x2e, 0x47, 0x65, 0x74, 0x43,
+	0x6f, 0x6c, 0x6c, 0x65, 0x63, 0x74, 0x69, 0x6f, 0x6e, 0x49, 0x6e, 0x64, 0x65, 0x78, 0x65, 0x73,
+	0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x1a, 0x25, 0x2e, 0x74, 0x68, 0x72, 0x65, 0x61, 0x64,
+	0x73, 0x2e, 0x70, 0x62, 0x2e, 0x47, 0x65, 0x74, 0x43, 0x6f, 0x6c, 0x6c, 0x65, 0x63, 0x74, 0x69,
+	0x6f, 0x6e, 0x49, 0x6e, 0x64, 0x65, 0x78, 0x65, 0x73, 0x52, 0x65, 0x70, 0x6c, 0x79, 0x22, 0x03,
+	0x88, 0x02, 0x01, 0x12, 0x59, 0x0a, 0x0f, 0x4c, 0x69, 0x73, 0x74, 0x43, 0x6f, 0x6c, 0x6c, 0x65,
+	0x63, 0x74, 0x69, 0x6f, 0x6e, 0x73, 0x12, 0x22, 0x2e, 0x74, 0x68, 0x72, 0x65, 0x61, 0x64, 0x73,
+	0x2e, 0x70, 0x62, 0x2e, 0x4c, 0x69, 0x73, 0x74, 0x43, 0x6f, 0x6c, 0x6c, 0x65, 0x63, 0x74, 0x69,
+	0x6f, 0x6e, 0x73, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x1a, 0x20, 0x2e, 0x74, 0x68, 0x72,
+	0x65, 0x61, 0x64, 0x73, 0x2e, 0x70, 0x62, 0x2e, 0x4c, 0x69, 0x73, 0x74, 0x43, 0x6f, 0x6c, 0x6c,
+	0x65, 0x63, 0x74, 0x69, 0x6f, 0x6e, 0x73, 0x52, 0x65, 0x70, 0x6c, 0x79, 0x22, 0x00, 0x12, 0x3e,
+	0x0a, 0x06, 0x43, 0x72, 0x65, 0x61, 0x74, 0x65, 0x12, 0x19, 0x2e, 0x74, 0x68, 0x72, 0x65, 0x61,
+	0x64, 0x73, 0x2e, 0x70, 0x62, 0x2e, 0x43, 0x72, 0x65, 0x61, 0x74, 0x65, 0x52, 0x65, 0x71, 0x75,
+	0x65, 0x73, 0x74, 0x1a, 0x17, 0x2e, 0x74, 0x68, 0x72, 0x65, 0x61, 0x64, 0x73, 0x2e, 0x70, 0x62,
+	0x2e, 0x43, 0x72, 0x65, 0x61, 0x74, 0x65, 0x52, 0x65, 0x70, 0x6c, 0x79, 0x22, 0x00, 0x12, 0x3e,
+	0x0a, 0x06, 0x56, 0x65, 0x72, 0x69, 0x66, 0x79, 0x12, 0x19, 0x2e, 0x74, 0x68, 0x72, 0x65, 0x61,
+	0x64, 0x73, 0x2e, 0x70, 0x62, 0x2e, 0x56, 0x65, 0x72, 0x69, 0x66, 0x79, 0x52, 0x65, 0x71, 0x75,
+	0x65, 0x73, 0x74, 0x1a, 0x17, 0x2e, 0x74, 0x68, 0x72, 0x65, 0x61, 0x64, 0x73, 0x2e, 0x70, 0x62,
+	0x2e, 0x56, 0x65, 0x72, 0x69, 0x66, 0x79, 0x52, 0x65, 0x70, 0x6c, 0x79, 0x22, 0x00, 0x12, 0x38,
+	0x0a, 0x04, 0x53, 0x61, 0x76, 0x65, 0x12, 0x17, 0x2e, 0x74, 0x68, 0x72, 0x65, 0x61, 0x64, 0x73,
+	0x2e, 0x70, 0x62, 0x2e, 0x53, 0x61, 0x76, 0x65, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x1a,
+	0x15, 0x2e, 0x74, 0x68, 0x72, 0x65, 0x61, 0x64, 0x73, 0x2e, 0x70, 0x62, 0x2e, 0x53, 0x61, 0x76,
+	0x65, 0x52, 0x65, 0x70, 0x6c, 0x79, 0x22, 0x00, 0x12, 0x3e, 0x0a, 0x06, 0x44, 0x65, 0x6c, 0x65,
+	0x74, 0x65, 0x12, 0x19, 0x2e, 0x74, 0x68, 0x72, 0x65, 0x61, 0x64, 0x73, 0x2e, 0x70, 0x62, 0x2e,
+	0x44, 0x65, 0x6c, 0x65, 0x74, 0x65, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x1a, 0x17, 0x2e,
+	0x74, 0x68, 0x72, 0x65, 0x61, 0x64, 0x73, 0x2e, 0x70, 0x62, 0x2e, 0x44, 0x65, 0x6c, 0x65, 0x74,
+	0x65, 0x52, 0x65, 0x70, 0x6c, 0x79, 0x22, 0x00, 0x12, 0x35, 0x0a, 0x03, 0x48, 0x61, 0x73, 0x12,
+	0x16, 0x2e, 0x74, 0x68, 0x72, 0x65, 0x61, 0x64, 0x73, 0x2e, 0x70, 0x62, 0x2e, 0x48, 0x61, 0x73,
+	0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x1a, 0x14, 0x2e, 0x74, 0x68, 0x72, 0x65, 0x61, 0x64,
+	0x73, 0x2e, 0x70, 0x62, 0x2e, 0x48, 0x61, 0x73, 0x52, 0x65, 0x70, 0x6c, 0x79, 0x22, 0x00, 0x12,
+	0x38, 0x0a, 0x04, 0x46, 0x69, 0x6e, 0x64, 0x12, 0x17, 0x2e, 0x74, 0x68, 0x72, 0x65, 0x61, 0x64,
+	0x73, 0x2e, 0x70, 0x62, 0x2e, 0x46, 0x69, 0x6e, 0x64, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74,
+	0x1a, 0x15, 0x2e, 0x74, 0x68, 0x72, 0x65, 0x61, 0x64, 0x73, 0x2e, 0x70, 0x62, 0x2e, 0x46, 0x69,
+	0x6e, 0x64, 0x52, 0x65, 0x70, 0x6c, 0x79, 0x22, 0x00, 0x12, 0x44, 0x0a, 0x08, 0x46, 0x69, 0x6e,
+	0x64, 0x42, 0x79, 0x49, 0x44, 0x12, 0x1b, 0x2e, 0x74, 0x68, 0x72, 0x65, 0x61, 0x64, 0x73, 0x2e,
+	0x70, 0x62, 0x2e, 0x46, 0x69, 0x6e, 0x64, 0x42, 0x79, 0x49, 0x44, 0x52, 0x65, 0x71, 0x75, 0x65,
+	0x73, 0x74, 0x1a, 0x19, 0x2e, 0x74, 0x68, 0x72, 0x65, 0x61, 0x64, 0x73, 0x2e, 0x70, 0x62, 0x2e,
+	0x46, 0x69, 0x6e, 0x64, 0x42, 0x79, 0x49, 0x44, 0x52, 0x65, 0x70, 0x6c, 0x79, 0x22, 0x00, 0x12,
+	0x5d, 0x0a, 0x0f, 0x52, 0x65, 0x61, 0x64, 0x54, 0x72, 0x61, 0x6e, 0x73, 0x61, 0x63, 0x74, 0x69,
+	0x6f, 0x6e, 0x12, 0x22, 0x2e, 0x74, 0x68, 0x72, 0x65, 0x61, 0x64, 0x73, 0x2e, 0x70, 0x62, 0x2e,
+	0x52, 0x65, 0x61, 0x64, 0x54, 0x72, 0x61, 0x6e, 0x73, 0x61, 0x63, 0x74, 0x69, 0x6f, 0x6e, 0x52,
+	0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x1a, 0x20, 0x2e, 0x74, 0x68, 0x72, 0x65, 0x61, 0x64, 0x73,
+	0x2e, 0x70, 0x62, 0x2e, 0x52, 0x65, 0x61, 0x64, 0x54, 0x72, 0x61, 0x6e, 0x73, 0x61, 0x63, 0x74,
+	0x69, 0x6f, 0x6e, 0x52, 0x65, 0x70, 0x6c, 0x79, 0x22, 0x00, 0x28, 0x01, 0x30, 0x01, 0x12, 0x60,
+	0x0a, 0x10, 0x57, 0x72, 0x69, 0x74, 0x65, 0x54, 0x72, 0x61, 0x6e, 0x73, 0x61, 0x63, 0x74, 0x69,
+	0x6f, 0x6e, 0x12, 0x23, 0x2e, 0x74, 0x68, 0x72, 0x65, 0x61, 0x64, 0x73, 0x2e, 0x70, 0x62, 0x2e,
+	0x57, 0x72, 0x69, 0x74, 0x65, 0x54, 0x72, 0x61, 0x6e, 0x73, 0x61, 0x63, 0x74, 0x69, 0x6f, 0x6e,
+	0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x1a, 0x21, 0x2e, 0x74, 0x68, 0x72, 0x65, 0x61, 0x64,
 	0x73, 0x2e, 0x70, 0x62, 0x2e, 0x57, 0x72, 0x69, 0x74, 0x65, 0x54, 0x72, 0x61, 0x6e, 0x73, 0x61,
-	0x63, 0x74, 0x69, 0x6f, 0x6e, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x1a, 0x21, 0x2e, 0x74,
-	0x68, 0x72, 0x65, 0x61, 0x64, 0x73, 0x2e, 0x70, 0x62, 0x2e, 0x57, 0x72, 0x69, 0x74, 0x65, 0x54,
-	0x72, 0x61, 0x6e, 0x73, 0x61, 0x63, 0x74, 0x69, 0x6f, 0x6e, 0x52, 0x65, 0x70, 0x6c, 0x79, 0x22,
-	0x00, 0x28, 0x01, 0x30, 0x01, 0x12, 0x40, 0x0a, 0x06, 0x4c, 0x69, 0x73, 0x74, 0x65, 0x6e, 0x12,
-	0x19, 0x2e, 0x74, 0x68, 0x72, 0x65, 0x61, 0x64, 0x73, 0x2e, 0x70, 0x62, 0x2e, 0x4c, 0x69, 0x73,
-	0x74, 0x65, 0x6e, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x1a, 0x17, 0x2e, 0x74, 0x68, 0x72,
+	0x63, 0x74, 0x69, 0x6f, 0x6e, 0x52, 0x65, 0x70, 0x6c, 0x79, 0x22, 0x00, 0x28, 0x01, 0x30, 0x01,
+	0x12, 0x40, 0x0a, 0x06, 0x4c, 0x69, 0x73, 0x74, 0x65, 0x6e, 0x12, 0x19, 0x2e, 0x74, 0x68, 0x72,
 	0x65, 0x61, 0x64, 0x73, 0x2e, 0x70, 0x62, 0x2e, 0x4c, 0x69, 0x73, 0x74, 0x65, 0x6e, 0x52, 0x65,
-	0x70, 0x6c, 0x79, 0x22, 0x00, 0x30, 0x01, 0x42, 0x2e, 0x0a, 0x17, 0x69, 0x6f, 0x2e, 0x74, 0x65,
-	0x78, 0x74, 0x69, 0x6c, 0x65, 0x2e, 0x74, 0x68, 0x72, 0x65, 0x61, 0x64, 0x73, 0x5f, 0x67, 0x72,
-	0x70, 0x63, 0x42, 0x07, 0x54, 0x68, 0x72, 0x65, 0x61, 0x64, 0x73, 0x50, 0x01, 0xa2, 0x02, 0x07,
-	0x54, 0x48, 0x52, 0x45, 0x41, 0x44, 0x53, 0x62, 0x06, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x33,
+	0x71, 0x75, 0x65, 0x73, 0x74, 0x1a, 0x17, 0x2e, 0x74, 0x68, 0x72, 0x65, 0x61, 0x64, 0x73, 0x2e,
+	0x70, 0x62, 0x2e, 0x4c, 0x69, 0x73, 0x74, 0x65, 0x6e, 0x52, 0x65, 0x70, 0x6c, 0x79, 0x22, 0x00,
+	0x30, 0x01, 0x12, 0x39, 0x0a, 0x05, 0x43, 0x6f, 0x75, 0x6e, 0x74, 0x12, 0x18, 0x2e, 0x74, 0x68,
+	0x72, 0x65, 0x61, 0x64, 0x73, 0x2e, 0x70, 0x62, 0x2e, 0x43, 0x6f, 0x75, 0x6e, 0x74, 0x52, 0x65,
+	0x71, 0x75, 0x65, 0x73, 0x74, 0x1a, 0x16, 0x2e, 0x74, 0x68, 0x72, 0x65, 0x61, 0x64, 0x73, 0x2e,
+	0x70, 0x62, 0x2e, 0x43, 0x6f, 0x75, 0x6e, 0x74, 0x52, 0x65, 0x70, 0x6c, 0x79, 0x12, 0x46, 0x0a,
+	0x0b, 0x46, 0x69, 0x6e, 0x64, 0x49, 0x74, 0x65, 0x72, 0x61, 0x74, 0x65, 0x12, 0x17, 0x2e, 0x74,
+	0x68, 0x72, 0x65, 0x61, 0x64, 0x73, 0x2e, 0x70, 0x62, 0x2e, 0x46, 0x69, 0x6e, 0x64, 0x52, 0x65,
+	0x71, 0x75, 0x65, 0x73, 0x74, 0x1a, 0x1c, 0x2e, 0x74, 0x68, 0x72, 0x65, 0x61, 0x64, 0x73, 0x2e,
+	0x70, 0x62, 0x2e, 0x46, 0x69, 0x6e, 0x64, 0x49, 0x74, 0x65, 0x72, 0x61, 0x74, 0x65, 0x52, 0x65,
+	0x70, 0x6c, 0x79, 0x30, 0x01, 0x12, 0x3e, 0x0a, 0x06, 0x45, 0x78, 0x70, 0x6f, 0x72, 0x74, 0x12,
+	0x19, 0x2e, 0x74, 0x68, 0x72, 0x65, 0x61, 0x64, 0x73, 0x2e, 0x70, 0x62, 0x2e, 0x45, 0x78, 0x70,
+	0x6f, 0x72, 0x74, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x1a, 0x17, 0x2e, 0x74, 0x68, 0x72,
+	0x65, 0x61, 0x64, 0x73, 0x2e, 0x70, 0x62, 0x2e, 0x45, 0x78, 0x70, 0x6f, 0x72, 0x74, 0x52, 0x65,
+	0x70, 0x6c, 0x79, 0x30, 0x01, 0x12, 0x60, 0x0a, 0x12, 0x47, 0x65, 0x74, 0x43, 0x6f, 0x6c, 0x6c,
+	0x65, 0x63, 0x74, 0x69, 0x6f, 0x6e, 0x53, 0x74, 0x61, 0x74, 0x73, 0x12, 0x25, 0x2e, 0x74, 0x68,
+	0x72, 0x65, 0x61, 0x64, 0x73, 0x2e, 0x70, 0x62, 0x2e, 0x47, 0x65, 0x74, 0x43, 0x6f, 0x6c, 0x6c,
+	0x65, 0x63, 0x74, 0x69, 0x6f, 0x6e, 0x53, 0x74, 0x61, 0x74, 0x73, 0x52, 0x65, 0x71, 0x75, 0x65,
+	0x73, 0x74, 0x1a, 0x23, 0x2e, 0x74, 0x68, 0x72, 0x65, 0x61, 0x64, 0x73, 0x2e, 0x70, 0x62, 0x2e,
+	0x47, 0x65, 0x74, 0x43, 0x6f, 0x6c, 0x6c, 0x65, 0x63, 0x74, 0x69, 0x6f, 0x6e, 0x53, 0x74, 0x61,
+	0x74, 0x73, 0x52, 0x65, 0x70, 0x6c, 0x79, 0x12, 0x42, 0x0a, 0x08, 0x56, 0x61, 0x6c, 0x69, 0x64,
+	0x61, 0x74, 0x65, 0x12, 0x1b, 0x2e, 0x74, 0x68, 0x72, 0x65, 0x61, 0x64, 0x73, 0x2e, 0x70, 0x62,
+	0x2e, 0x56, 0x61, 0x6c, 0x69, 0x64, 0x61, 0x74, 0x65, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74,
+	0x1a, 0x19, 0x2e, 0x74, 0x68, 0x72, 0x65, 0x61, 0x64, 0x73, 0x2e, 0x70, 0x62, 0x2e, 0x56, 0x61,
+	0x6c, 0x69, 0x64, 0x61, 0x74, 0x65, 0x52, 0x65, 0x70, 0x6c, 0x79, 0x12, 0x45, 0x0a, 0x09, 0x46,
+	0x69, 0x6e, 0x64, 0x42, 0x79, 0x49, 0x44, 0x73, 0x12, 0x1c, 0x2e, 0x74, 0x68, 0x72, 0x65, 0x61,
+	0x64, 0x73, 0x2e, 0x70, 0x62, 0x2e, 0x46, 0x69, 0x6e, 0x64, 0x42, 0x79, 0x49, 0x44, 0x73, 0x52,
+	0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x1a, 0x1a, 0x2e, 0x74, 0x68, 0x72, 0x65, 0x61, 0x64, 0x73,
+	0x2e, 0x70, 0x62, 0x2e, 0x46, 0x69, 0x6e, 0x64, 0x42, 0x79, 0x49, 0x44, 0x73, 0x52, 0x65, 0x70,
+	0x6c, 0x79, 0x12, 0x41, 0x0a, 0x07, 0x47, 0x65, 0x74, 0x4d, 0x61, 0x6e, 0x79, 0x12, 0x1c, 0x2e,
+	0x74, 0x68, 0x72, 0x65, 0x61, 0x64, 0x73, 0x2e, 0x70, 0x62, 0x2e, 0x46, 0x69, 0x6e, 0x64, 0x42,
+	0x79, 0x49, 0x44, 0x73, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x1a, 0x18, 0x2e, 0x74, 0x68,
+	0x72, 0x65, 0x61, 0x64, 0x73, 0x2e, 0x70, 0x62, 0x2e, 0x47, 0x65, 0x74, 0x4d, 0x61, 0x6e, 0x79,
+	0x52, 0x65, 0x70, 0x6c, 0x79, 0x42, 0x2e, 0x0a, 0x17, 0x69, 0x6f, 0x2e, 0x74, 0x65, 0x78, 0x74,
+	0x69, 0x6c, 0x65, 0x2e, 0x74, 0x68, 0x72, 0x65, 0x61, 0x64, 0x73, 0x5f, 0x67, 0x72, 0x70, 0x63,
+	0x42, 0x07, 0x54, 0x68, 0x72, 0x65, 0x61, 0x64, 0x73, 0x50, 0x01, 0xa2, 0x02, 0x07, 0x54, 0x48,
+	0x52, 0x45, 0x41, 0x44, 0x53, 0x62, 0x06, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x33,
 }
 
 var (
@@ -3737,148 +4941,185 @@ func file_threads_proto_rawDescGZIP() []byte {
 }
 
 var file_threads_proto_enumTypes = make([]protoimpl.EnumInfo, 2)
-var file_threads_proto_msgTypes = make([]protoimpl.MessageInfo, 50)
+var file_threads_proto_msgTypes = make([]protoimpl.MessageInfo, 66)
 var file_threads_proto_goTypes = []interface{}{
-	(ListenRequest_Filter_Action)(0),    // 0: threads.pb.ListenRequest.Filter.Action
-	(ListenReply_Action)(0),             // 1: threads.pb.ListenReply.Action
-	(*GetTokenRequest)(nil),             // 2: threads.pb.GetTokenRequest
-	(*GetTokenReply)(nil),               // 3: threads.pb.GetTokenReply
-	(*NewDBRequest)(nil),                // 4: threads.pb.NewDBRequest
-	(*NewDBFromAddrRequest)(nil),        // 5: threads.pb.NewDBFromAddrRequest
-	(*CollectionConfig)(nil),            // 6: threads.pb.CollectionConfig
-	(*Index)(nil),                       // 7: threads.pb.Index
-	(*NewDBReply)(nil),                  // 8: threads.pb.NewDBReply
-	(*ListDBsRequest)(nil),              // 9: threads.pb.ListDBsRequest
-	(*ListDBsReply)(nil),                // 10: threads.pb.ListDBsReply
-	(*GetDBInfoRequest)(nil),            // 11: threads.pb.GetDBInfoRequest
-	(*GetDBInfoReply)(nil),              // 12: threads.pb.GetDBInfoReply
-	(*DeleteDBRequest)(nil),             // 13: threads.pb.DeleteDBRequest
-	(*DeleteDBReply)(nil),               // 14: threads.pb.DeleteDBReply
-	(*NewCollectionRequest)(nil),        // 15: threads.pb.NewCollectionRequest
-	(*NewCollectionReply)(nil),          // 16: threads.pb.NewCollectionReply
-	(*UpdateCollectionRequest)(nil),     // 17: threads.pb.UpdateCollectionRequest
-	(*UpdateCollectionReply)(nil),       // 18: threads.pb.UpdateCollectionReply
-	(*DeleteCollectionRequest)(nil),     // 19: threads.pb.DeleteCollectionRequest
-	(*DeleteCollectionReply)(nil),       // 20: threads.pb.DeleteCollectionReply
-	(*GetCollectionInfoRequest)(nil),    // 21: threads.pb.GetCollectionInfoRequest
-	(*GetCollectionInfoReply)(nil),      // 22: threads.pb.GetCollectionInfoReply
-	(*GetCollectionIndexesRequest)(nil), // 23: threads.pb.GetCollectionIndexesRequest
-	(*GetCollectionIndexesReply)(nil),   // 24: threads.pb.GetCollectionIndexesReply
-	(*ListCollectionsRequest)(nil),      // 25: threads.pb.ListCollectionsRequest
-	(*ListCollectionsReply)(nil),        // 26: threads.pb.ListCollectionsReply
-	(*CreateRequest)(nil),               // 27: threads.pb.CreateRequest
-	(*CreateReply)(nil),                 // 28: threads.pb.CreateReply
-	(*VerifyRequest)(nil),               // 29: threads.pb.VerifyRequest
-	(*VerifyReply)(nil),                 // 30: threads.pb.VerifyReply
-	(*SaveRequest)(nil),                 // 31: threads.pb.SaveRequest
-	(*SaveReply)(nil),                   // 32: threads.pb.SaveReply
-	(*DeleteRequest)(nil),               // 33: threads.pb.DeleteRequest
-	(*DeleteReply)(nil),                 // 34: threads.pb.DeleteReply
-	(*HasRequest)(nil),                  // 35: threads.pb.HasRequest
-	(*HasReply)(nil),                    // 36: threads.pb.HasReply
-	(*FindRequest)(nil),                 // 37: threads.pb.FindRequest
-	(*FindReply)(nil),                   // 38: threads.pb.FindReply
-	(*FindByIDRequest)(nil),             // 39: threads.pb.FindByIDRequest
-	(*FindByIDReply)(nil),               // 40: threads.pb.FindByIDReply
-	(*DiscardRequest)(nil),              // 41: threads.pb.DiscardRequest
-	(*DiscardReply)(nil),                // 42: threads.pb.DiscardReply
-	(*StartTransactionRequest)(nil),     // 43: threads.pb.StartTransactionRequest
-	(*ReadTransactionRequest)(nil),      // 44: threads.pb.ReadTransactionRequest
-	(*ReadTransactionReply)(nil),        // 45: threads.pb.ReadTransactionReply
-	(*WriteTransactionRequest)(nil),     // 46: threads.pb.WriteTransactionRequest
-	(*WriteTransactionReply)(nil),       // 47: threads.pb.WriteTransactionReply
-	(*ListenRequest)(nil),               // 48: threads.pb.ListenRequest
-	(*ListenReply)(nil),                 // 49: threads.pb.ListenReply
-	(*ListDBsReply_DB)(nil),             // 50: threads.pb.ListDBsReply.DB
-	(*ListenRequest_Filter)(nil),        // 51: threads.pb.ListenRequest.Filter
+	(ListenRequest_Filter_Action)(0),           // 0: threads.pb.ListenRequest.Filter.Action
+	(ListenReply_Action)(0),                    // 1: threads.pb.ListenReply.Action
+	(*GetTokenRequest)(nil),                    // 2: threads.pb.GetTokenRequest
+	(*GetTokenReply)(nil),                      // 3: threads.pb.GetTokenReply
+	(*NewDBRequest)(nil),                       // 4: threads.pb.NewDBRequest
+	(*NewDBFromAddrRequest)(nil),               // 5: threads.pb.NewDBFromAddrRequest
+	(*CollectionConfig)(nil),                   // 6: threads.pb.CollectionConfig
+	(*Index)(nil),                              // 7: threads.pb.Index
+	(*NewDBReply)(nil),                         // 8: threads.pb.NewDBReply
+	(*ListDBsRequest)(nil),                     // 9: threads.pb.ListDBsRequest
+	(*ListDBsReply)(nil),                       // 10: threads.pb.ListDBsReply
+	(*GetDBInfoRequest)(nil),                   // 11: threads.pb.GetDBInfoRequest
+	(*GetDBInfoReply)(nil),                     // 12: threads.pb.GetDBInfoReply
+	(*DeleteDBRequest)(nil),                    // 13: threads.pb.DeleteDBRequest
+	(*DeleteDBReply)(nil),                      // 14: threads.pb.DeleteDBReply
+	(*NewCollectionRequest)(nil),               // 15: threads.pb.NewCollectionRequest
+	(*NewCollectionReply)(nil),                 // 16: threads.pb.NewCollectionReply
+	(*UpdateCollectionRequest)(nil),            // 17: threads.pb.UpdateCollectionRequest
+	(*UpdateCollectionReply)(nil),              // 18: threads.pb.UpdateCollectionReply
+	(*DeleteCollectionRequest)(nil),            // 19: threads.pb.DeleteCollectionRequest
+	(*DeleteCollectionReply)(nil),              // 20: threads.pb.DeleteCollectionReply
+	(*GetCollectionInfoRequest)(nil),           // 21: threads.pb.GetCollectionInfoRequest
+	(*GetCollectionInfoReply)(nil),             // 22: threads.pb.GetCollectionInfoReply
+	(*GetCollectionIndexesRequest)(nil),        // 23: threads.pb.GetCollectionIndexesRequest
+	(*GetCollectionIndexesReply)(nil),          // 24: threads.pb.GetCollectionIndexesReply
+	(*ListCollectionsRequest)(nil),             // 25: threads.pb.ListCollectionsRequest
+	(*ListCollectionsReply)(nil),               // 26: threads.pb.ListCollectionsReply
+	(*CreateRequest)(nil),                      // 27: threads.pb.CreateRequest
+	(*CreateReply)(nil),                        // 28: threads.pb.CreateReply
+	(*VerifyRequest)(nil),                      // 29: threads.pb.VerifyRequest
+	(*VerifyReply)(nil),                        // 30: threads.pb.VerifyReply
+	(*SaveRequest)(nil),                        // 31: threads.pb.SaveRequest
+	(*SaveReply)(nil),                          // 32: threads.pb.SaveReply
+	(*DeleteRequest)(nil),                      // 33: threads.pb.DeleteRequest
+	(*DeleteReply)(nil),                        // 34: threads.pb.DeleteReply
+	(*HasRequest)(nil),                         // 35: threads.pb.HasRequest
+	(*HasReply)(nil),                           // 36: threads.pb.HasReply
+	(*FindRequest)(nil),                        // 37: threads.pb.FindRequest
+	(*FindReply)(nil),                          // 38: threads.pb.FindReply
+	(*FindByIDRequest)(nil),                    // 39: threads.pb.FindByIDRequest
+	(*FindByIDReply)(nil),                      // 40: threads.pb.FindByIDReply
+	(*DiscardRequest)(nil),                     // 41: threads.pb.DiscardRequest
+	(*DiscardReply)(nil),                       // 42: threads.pb.DiscardReply
+	(*StartTransactionRequest)(nil),            // 43: threads.pb.StartTransactionRequest
+	(*ReadTransactionRequest)(nil),             // 44: threads.pb.ReadTransactionRequest
+	(*ReadTransactionReply)(nil),               // 45: threads.pb.ReadTransactionReply
+	(*WriteTransactionRequest)(nil),            // 46: threads.pb.WriteTransactionRequest
+	(*WriteTransactionReply)(nil),              // 47: threads.pb.WriteTransactionReply
+	(*ListenRequest)(nil),                      // 48: threads.pb.ListenRequest
+	(*ListenReply)(nil),                        // 49: threads.pb.ListenReply
+	(*CountRequest)(nil),                       // 50: threads.pb.CountRequest
+	(*CountReply)(nil),                         // 51: threads.pb.CountReply
+	(*FindIterateReply)(nil),                   // 52: threads.pb.FindIterateReply
+	(*ExportRequest)(nil),                      // 53: threads.pb.ExportRequest
+	(*ExportReply)(nil),                        // 54: threads.pb.ExportReply
+	(*GetCollectionStatsRequest)(nil),          // 55: threads.pb.GetCollectionStatsRequest
+	(*GetCollectionStatsReply)(nil),            // 56: threads.pb.GetCollectionStatsReply
+	(*ValidateRequest)(nil),                    // 57: threads.pb.ValidateRequest
+	(*ValidateReply)(nil),                      // 58: threads.pb.ValidateReply
+	(*FindByIDsRequest)(nil),                   // 59: threads.pb.FindByIDsRequest
+	(*FindByIDsReply)(nil),                     // 60: threads.pb.FindByIDsReply
+	(*GetManyReply)(nil),                       // 61: threads.pb.GetManyReply
+	(*CollectionConfig_Sort)(nil),              // 62: threads.pb.CollectionConfig.Sort
+	(*CollectionConfig_Reference)(nil),         // 63: threads.pb.CollectionConfig.Reference
+	(*CollectionConfig_ComputedField)(nil),     // 64: threads.pb.CollectionConfig.ComputedField
+	(*ListDBsReply_DB)(nil),                    // 65: threads.pb.ListDBsReply.DB
+	(*ListenRequest_Filter)(nil),               // 66: threads.pb.ListenRequest.Filter
+	(*GetCollectionStatsReply_IndexStats)(nil), // 67: threads.pb.GetCollectionStatsReply.IndexStats
 }
 var file_threads_proto_depIdxs = []int32{
 	6,  // 0: threads.pb.NewDBRequest.collections:type_name -> threads.pb.CollectionConfig
 	6,  // 1: threads.pb.NewDBFromAddrRequest.collections:type_name -> threads.pb.CollectionConfig
 	7,  // 2: threads.pb.CollectionConfig.indexes:type_name -> threads.pb.Index
-	50, // 3: threads.pb.ListDBsReply.dbs:type_name -> threads.pb.ListDBsReply.DB
-	6,  // 4: threads.pb.NewCollectionRequest.config:type_name -> threads.pb.CollectionConfig
-	6,  // 5: threads.pb.UpdateCollectionRequest.config:type_name -> threads.pb.CollectionConfig
-	7,  // 6: threads.pb.GetCollectionInfoReply.indexes:type_name -> threads.pb.Index
-	7,  // 7: threads.pb.GetCollectionIndexesReply.indexes:type_name -> threads.pb.Index
-	22, // 8: threads.pb.ListCollectionsReply.collections:type_name -> threads.pb.GetCollectionInfoReply
-	43, // 9: threads.pb.ReadTransactionRequest.startTransactionRequest:type_name -> threads.pb.StartTransactionRequest
-	35, // 10: threads.pb.ReadTransactionRequest.hasRequest:type_name -> threads.pb.HasRequest
-	37, // 11: threads.pb.ReadTransactionRequest.findRequest:type_name -> threads.pb.FindRequest
-	39, // 12: threads.pb.ReadTransactionRequest.findByIDRequest:type_name -> threads.pb.FindByIDRequest
-	36, // 13: threads.pb.ReadTransactionReply.hasReply:type_name -> threads.pb.HasReply
-	38, // 14: threads.pb.ReadTransactionReply.findReply:type_name -> threads.pb.FindReply
-	40, // 15: threads.pb.ReadTransactionReply.findByIDReply:type_name -> threads.pb.FindByIDReply
-	43, // 16: threads.pb.WriteTransactionRequest.startTransactionRequest:type_name -> threads.pb.StartTransactionRequest
-	27, // 17: threads.pb.WriteTransactionRequest.createRequest:type_name -> threads.pb.CreateRequest
-	29, // 18: threads.pb.WriteTransactionRequest.verifyRequest:type_name -> threads.pb.VerifyRequest
-	31, // 19: threads.pb.WriteTransactionRequest.saveRequest:type_name -> threads.pb.SaveRequest
-	33, // 20: threads.pb.WriteTransactionRequest.deleteRequest:type_name -> threads.pb.DeleteRequest
-	35, // 21: threads.pb.WriteTransactionRequest.hasRequest:type_name -> threads.pb.HasRequest
-	37, // 22: threads.pb.WriteTransactionRequest.findRequest:type_name -> threads.pb.FindRequest
-	39, // 23: threads.pb.WriteTransactionRequest.findByIDRequest:type_name -> threads.pb.FindByIDRequest
-	41, // 24: threads.pb.WriteTransactionRequest.discardRequest:type_name -> threads.pb.DiscardRequest
-	28, // 25: threads.pb.WriteTransactionReply.createReply:type_name -> threads.pb.CreateReply
-	30, // 26: threads.pb.WriteTransactionReply.verifyReply:type_name -> threads.pb.VerifyReply
-	32, // 27: threads.pb.WriteTransactionReply.saveReply:type_name -> threads.pb.SaveReply
-	34, // 28: threads.pb.WriteTransactionReply.deleteReply:type_name -> threads.pb.DeleteReply
-	36, // 29: threads.pb.WriteTransactionReply.hasReply:type_name -> threads.pb.HasReply
-	38, // 30: threads.pb.WriteTransactionReply.findReply:type_name -> threads.pb.FindReply
-	40, // 31: threads.pb.WriteTransactionReply.findByIDReply:type_name -> threads.pb.FindByIDReply
-	42, // 32: threads.pb.WriteTransactionReply.discardReply:type_name -> threads.pb.DiscardReply
-	51, // 33: threads.pb.ListenRequest.filters:type_name -> threads.pb.ListenRequest.Filter
-	1,  // 34: threads.pb.ListenReply.action:type_name -> threads.pb.ListenReply.Action
-	12, // 35: threads.pb.ListDBsReply.DB.info:type_name -> threads.pb.GetDBInfoReply
-	0,  // 36: threads.pb.ListenRequest.Filter.action:type_name -> threads.pb.ListenRequest.Filter.Action
-	2,  // 37: threads.pb.API.GetToken:input_type -> threads.pb.GetTokenRequest
-	4,  // 38: threads.pb.API.NewDB:input_type -> threads.pb.NewDBRequest
-	5,  // 39: threads.pb.API.NewDBFromAddr:input_type -> threads.pb.NewDBFromAddrRequest
-	9,  // 40: threads.pb.API.ListDBs:input_type -> threads.pb.ListDBsRequest
-	11, // 41: threads.pb.API.GetDBInfo:input_type -> threads.pb.GetDBInfoRequest
-	13, // 42: threads.pb.API.DeleteDB:input_type -> threads.pb.DeleteDBRequest
-	15, // 43: threads.pb.API.NewCollection:input_type -> threads.pb.NewCollectionRequest
-	17, // 44: threads.pb.API.UpdateCollection:input_type -> threads.pb.UpdateCollectionRequest
-	19, // 45: threads.pb.API.DeleteCollection:input_type -> threads.pb.DeleteCollectionRequest
-	21, // 46: threads.pb.API.GetCollectionInfo:input_type -> threads.pb.GetCollectionInfoRequest
-	23, // 47: threads.pb.API.GetCollectionIndexes:input_type -> threads.pb.GetCollectionIndexesRequest
-	25, // 48: threads.pb.API.ListCollections:input_type -> threads.pb.ListCollectionsRequest
-	27, // 49: threads.pb.API.Create:input_type -> threads.pb.CreateRequest
-	29, // 50: threads.pb.API.Verify:input_type -> threads.pb.VerifyRequest
-	31, // 51: threads.pb.API.Save:input_type -> threads.pb.SaveRequest
-	33, // 52: threads.pb.API.Delete:input_type -> threads.pb.DeleteRequest
-	35, // 53: threads.pb.API.Has:input_type -> threads.pb.HasRequest
-	37, // 54: threads.pb.API.Find:input_type -> threads.pb.FindRequest
-	39, // 55: threads.pb.API.FindByID:input_type -> threads.pb.FindByIDRequest
-	44, // 56: threads.pb.API.ReadTransaction:input_type -> threads.pb.ReadTransactionRequest
-	46, // 57: threads.pb.API.WriteTransaction:input_type -> threads.pb.WriteTransactionRequest
-	48, // 58: threads.pb.API.Listen:input_type -> threads.pb.ListenRequest
-	3,  // 59: threads.pb.API.GetToken:output_type -> threads.pb.GetTokenReply
-	8,  // 60: threads.pb.API.NewDB:output_type -> threads.pb.NewDBReply
-	8,  // 61: threads.pb.API.NewDBFromAddr:output_type -> threads.pb.NewDBReply
-	10, // 62: threads.pb.API.ListDBs:output_type -> threads.pb.ListDBsReply
-	12, // 63: threads.pb.API.GetDBInfo:output_type -> threads.pb.GetDBInfoReply
-	14, // 64: threads.pb.API.DeleteDB:output_type -> threads.pb.DeleteDBReply
-	16, // 65: threads.pb.API.NewCollection:output_type -> threads.pb.NewCollectionReply
-	18, // 66: threads.pb.API.UpdateCollection:output_type -> threads.pb.UpdateCollectionReply
-	20, // 67: threads.pb.API.DeleteCollection:output_type -> threads.pb.DeleteCollectionReply
-	22, // 68: threads.pb.API.GetCollectionInfo:output_type -> threads.pb.GetCollectionInfoReply
-	24, // 69: threads.pb.API.GetCollectionIndexes:output_type -> threads.pb.GetCollectionIndexesReply
-	26, // 70: threads.pb.API.ListCollections:output_type -> threads.pb.ListCollectionsReply
-	28, // 71: threads.pb.API.Create:output_type -> threads.pb.CreateReply
-	30, // 72: threads.pb.API.Verify:output_type -> threads.pb.VerifyReply
-	32, // 73: threads.pb.API.Save:output_type -> threads.pb.SaveReply
-	34, // 74: threads.pb.API.Delete:output_type -> threads.pb.DeleteReply
-	36, // 75: threads.pb.API.Has:output_type -> threads.pb.HasReply
-	38, // 76: threads.pb.API.Find:output_type -> threads.pb.FindReply
-	40, // 77: threads.pb.API.FindByID:output_type -> threads.pb.FindByIDReply
-	45, // 78: threads.pb.API.ReadTransaction:output_type -> threads.pb.ReadTransactionReply
-	47, // 79: threads.pb.API.WriteTransaction:output_type -> threads.pb.WriteTransactionReply
-	49, // 80: threads.pb.API.Listen:output_type -> threads.pb.ListenReply
-	59, // [59:81] is the sub-list for method output_type
-	37, // [37:59] is the sub-list for method input_type
-	37, // [37:37] is the sub-list for extension type_name
-	37, // [37:37] is the sub-list for extension extendee
-	0,  // [0:37] is the sub-list for field type_name
+	62, // 3: threads.pb.CollectionConfig.defaultOrderBy:type_name -> threads.pb.CollectionConfig.Sort
+	63, // 4: threads.pb.CollectionConfig.references:type_name -> threads.pb.CollectionConfig.Reference
+	64, // 5: threads.pb.CollectionConfig.computedFields:type_name -> threads.pb.CollectionConfig.ComputedField
+	65, // 6: threads.pb.ListDBsReply.dbs:type_name -> threads.pb.ListDBsReply.DB
+	6,  // 7: threads.pb.NewCollectionRequest.config:type_name -> threads.pb.CollectionConfig
+	6,  // 8: threads.pb.UpdateCollectionRequest.config:type_name -> threads.pb.CollectionConfig
+	7,  // 9: threads.pb.GetCollectionInfoReply.indexes:type_name -> threads.pb.Index
+	62, // 10: threads.pb.GetCollectionInfoReply.defaultOrderBy:type_name -> threads.pb.CollectionConfig.Sort
+	63, // 11: threads.pb.GetCollectionInfoReply.references:type_name -> threads.pb.CollectionConfig.Reference
+	64, // 12: threads.pb.GetCollectionInfoReply.computedFields:type_name -> threads.pb.CollectionConfig.ComputedField
+	7,  // 13: threads.pb.GetCollectionIndexesReply.indexes:type_name -> threads.pb.Index
+	22, // 14: threads.pb.ListCollectionsReply.collections:type_name -> threads.pb.GetCollectionInfoReply
+	43, // 15: threads.pb.ReadTransactionRequest.startTransactionRequest:type_name -> threads.pb.StartTransactionRequest
+	35, // 16: threads.pb.ReadTransactionRequest.hasRequest:type_name -> threads.pb.HasRequest
+	37, // 17: threads.pb.ReadTransactionRequest.findRequest:type_name -> threads.pb.FindRequest
+	39, // 18: threads.pb.ReadTransactionRequest.findByIDRequest:type_name -> threads.pb.FindByIDRequest
+	36, // 19: threads.pb.ReadTransactionReply.hasReply:type_name -> threads.pb.HasReply
+	38, // 20: threads.pb.ReadTransactionReply.findReply:type_name -> threads.pb.FindReply
+	40, // 21: threads.pb.ReadTransactionReply.findByIDReply:type_name -> threads.pb.FindByIDReply
+	43, // 22: threads.pb.WriteTransactionRequest.startTransactionRequest:type_name -> threads.pb.StartTransactionRequest
+	27, // 23: threads.pb.WriteTransactionRequest.createRequest:type_name -> threads.pb.CreateRequest
+	29, // 24: threads.pb.WriteTransactionRequest.verifyRequest:type_name -> threads.pb.VerifyRequest
+	31, // 25: threads.pb.WriteTransactionRequest.saveRequest:type_name -> threads.pb.SaveRequest
+	33, // 26: threads.pb.WriteTransactionRequest.deleteRequest:type_name -> threads.pb.DeleteRequest
+	35, // 27: threads.pb.WriteTransactionRequest.hasRequest:type_name -> threads.pb.HasRequest
+	37, // 28: threads.pb.WriteTransactionRequest.findRequest:type_name -> threads.pb.FindRequest
+	39, // 29: threads.pb.WriteTransactionRequest.findByIDRequest:type_name -> threads.pb.FindByIDRequest
+	41, // 30: threads.pb.WriteTransactionRequest.discardRequest:type_name -> threads.pb.DiscardRequest
+	28, // 31: threads.pb.WriteTransactionReply.createReply:type_name -> threads.pb.CreateReply
+	30, // 32: threads.pb.WriteTransactionReply.verifyReply:type_name -> threads.pb.VerifyReply
+	32, // 33: threads.pb.WriteTransactionReply.saveReply:type_name -> threads.pb.SaveReply
+	34, // 34: threads.pb.WriteTransactionReply.deleteReply:type_name -> threads.pb.DeleteReply
+	36, // 35: threads.pb.WriteTransactionReply.hasReply:type_name -> threads.pb.HasReply
+	38, // 36: threads.pb.WriteTransactionReply.findReply:type_name -> threads.pb.FindReply
+	40, // 37: threads.pb.WriteTransactionReply.findByIDReply:type_name -> threads.pb.FindByIDReply
+	42, // 38: threads.pb.WriteTransactionReply.discardReply:type_name -> threads.pb.DiscardReply
+	66, // 39: threads.pb.ListenRequest.filters:type_name -> threads.pb.ListenRequest.Filter
+	1,  // 40: threads.pb.ListenReply.action:type_name -> threads.pb.ListenReply.Action
+	67, // 41: threads.pb.GetCollectionStatsReply.indexes:type_name -> threads.pb.GetCollectionStatsReply.IndexStats
+	12, // 42: threads.pb.ListDBsReply.DB.info:type_name -> threads.pb.GetDBInfoReply
+	0,  // 43: threads.pb.ListenRequest.Filter.action:type_name -> threads.pb.ListenRequest.Filter.Action
+	2,  // 44: threads.pb.API.GetToken:input_type -> threads.pb.GetTokenRequest
+	4,  // 45: threads.pb.API.NewDB:input_type -> threads.pb.NewDBRequest
+	5,  // 46: threads.pb.API.NewDBFromAddr:input_type -> threads.pb.NewDBFromAddrRequest
+	9,  // 47: threads.pb.API.ListDBs:input_type -> threads.pb.ListDBsRequest
+	11, // 48: threads.pb.API.GetDBInfo:input_type -> threads.pb.GetDBInfoRequest
+	13, // 49: threads.pb.API.DeleteDB:input_type -> threads.pb.DeleteDBRequest
+	15, // 50: threads.pb.API.NewCollection:input_type -> threads.pb.NewCollectionRequest
+	17, // 51: threads.pb.API.UpdateCollection:input_type -> threads.pb.UpdateCollectionRequest
+	19, // 52: threads.pb.API.DeleteCollection:input_type -> threads.pb.DeleteCollectionRequest
+	21, // 53: threads.pb.API.GetCollectionInfo:input_type -> threads.pb.GetCollectionInfoRequest
+	23, // 54: threads.pb.API.GetCollectionIndexes:input_type -> threads.pb.GetCollectionIndexesRequest
+	25, // 55: threads.pb.API.ListCollections:input_type -> threads.pb.ListCollectionsRequest
+	27, // 56: threads.pb.API.Create:input_type -> threads.pb.CreateRequest
+	29, // 57: threads.pb.API.Verify:input_type -> threads.pb.VerifyRequest
+	31, // 58: threads.pb.API.Save:input_type -> threads.pb.SaveRequest
+	33, // 59: threads.pb.API.Delete:input_type -> threads.pb.DeleteRequest
+	35, // 60: threads.pb.API.Has:input_type -> threads.pb.HasRequest
+	37, // 61: threads.pb.API.Find:input_type -> threads.pb.FindRequest
+	39, // 62: threads.pb.API.FindByID:input_type -> threads.pb.FindByIDRequest
+	44, // 63: threads.pb.API.ReadTransaction:input_type -> threads.pb.ReadTransactionRequest
+	46, // 64: threads.pb.API.WriteTransaction:input_type -> threads.pb.WriteTransactionRequest
+	48, // 65: threads.pb.API.Listen:input_type -> threads.pb.ListenRequest
+	50, // 66: threads.pb.API.Count:input_type -> threads.pb.CountRequest
+	37, // 67: threads.pb.API.FindIterate:input_type -> threads.pb.FindRequest
+	53, // 68: threads.pb.API.Export:input_type -> threads.pb.ExportRequest
+	55, // 69: threads.pb.API.GetCollectionStats:input_type -> threads.pb.GetCollectionStatsRequest
+	57, // 70: threads.pb.API.Validate:input_type -> threads.pb.ValidateRequest
+	59, // 71: threads.pb.API.FindByIDs:input_type -> threads.pb.FindByIDsRequest
+	59, // 72: threads.pb.API.GetMany:input_type -> threads.pb.FindByIDsRequest
+	3,  // 73: threads.pb.API.GetToken:output_type -> threads.pb.GetTokenReply
+	8,  // 74: threads.pb.API.NewDB:output_type -> threads.pb.NewDBReply
+	8,  // 75: threads.pb.API.NewDBFromAddr:output_type -> threads.pb.NewDBReply
+	10, // 76: threads.pb.API.ListDBs:output_type -> threads.pb.ListDBsReply
+	12, // 77: threads.pb.API.GetDBInfo:output_type -> threads.pb.GetDBInfoReply
+	14, // 78: threads.pb.API.DeleteDB:output_type -> threads.pb.DeleteDBReply
+	16, // 79: threads.pb.API.NewCollection:output_type -> threads.pb.NewCollectionReply
+	18, // 80: threads.pb.API.UpdateCollection:output_type -> threads.pb.UpdateCollectionReply
+	20, // 81: threads.pb.API.DeleteCollection:output_type -> threads.pb.DeleteCollectionReply
+	22, // 82: threads.pb.API.GetCollectionInfo:output_type -> threads.pb.GetCollectionInfoReply
+	24, // 83: threads.pb.API.GetCollectionIndexes:output_type -> threads.pb.GetCollectionIndexesReply
+	26, // 84: threads.pb.API.ListCollections:output_type -> threads.pb.ListCollectionsReply
+	28, // 85: threads.pb.API.Create:output_type -> threads.pb.CreateReply
+	30, // 86: threads.pb.API.Verify:output_type -> threads.pb.VerifyReply
+	32, // 87: threads.pb.API.Save:output_type -> threads.pb.SaveReply
+	34, // 88: threads.pb.API.Delete:output_type -> threads.pb.DeleteReply
+	36, // 89: threads.pb.API.Has:output_type -> threads.pb.HasReply
+	38, // 90: threads.pb.API.Find:output_type -> threads.pb.FindReply
+	40, // 91: threads.pb.API.FindByID:output_type -> threads.pb.FindByIDReply
+	45, // 92: threads.pb.API.ReadTransaction:output_type -> threads.pb.ReadTransactionReply
+	47, // 93: threads.pb.API.WriteTransaction:output_type -> threads.pb.WriteTransactionReply
+	49, // 94: threads.pb.API.Listen:output_type -> threads.pb.ListenReply
+	51, // 95: threads.pb.API.Count:output_type -> threads.pb.CountReply
+	52, // 96: threads.pb.API.FindIterate:output_type -> threads.pb.FindIterateReply
+	54, // 97: threads.pb.API.Export:output_type -> threads.pb.ExportReply
+	56, // 98: threads.pb.API.GetCollectionStats:output_type -> threads.pb.GetCollectionStatsReply
+	58, // 99: threads.pb.API.Validate:output_type -> threads.pb.ValidateReply
+	60, // 100: threads.pb.API.FindByIDs:output_type -> threads.pb.FindByIDsReply
+	61, // 101: threads.pb.API.GetMany:output_type -> threads.pb.GetManyReply
+	73, // [73:102] is the sub-list for method output_type
+	44, // [44:73] is the sub-list for method input_type
+	44, // [44:44] is the sub-list for extension type_name
+	44, // [44:44] is the sub-list for extension extendee
+	0,  // [0:44] is the sub-list for field type_name
 }
 
 func init() { file_threads_proto_init() }
@@ -4211,8 +5452,200 @@ func file_threads_proto_init() {
 				return nil
 			}
 		}
-		file_threads_proto_msgTypes[27].Exporter = func(v interface{}, i int) interface{} {
-			switch v := v.(*VerifyRequest); i {
+		file_threads_proto_msgTypes[27].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*VerifyRequest); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_threads_proto_msgTypes[28].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*VerifyReply); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_threads_proto_msgTypes[29].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*SaveRequest); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_threads_proto_msgTypes[30].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*SaveReply); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_threads_proto_msgTypes[31].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*DeleteRequest); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_threads_proto_msgTypes[32].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*DeleteReply); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_threads_proto_msgTypes[33].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*HasRequest); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_threads_proto_msgTypes[34].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*HasReply); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_threads_proto_msgTypes[35].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*FindRequest); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_threads_proto_msgTypes[36].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*FindReply); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_threads_proto_msgTypes[37].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*FindByIDRequest); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_threads_proto_msgTypes[38].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*FindByIDReply); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_threads_proto_msgTypes[39].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*DiscardRequest); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_threads_proto_msgTypes[40].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*DiscardReply); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_threads_proto_msgTypes[41].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*StartTransactionRequest); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_threads_proto_msgTypes[42].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*ReadTransactionRequest); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_threads_proto_msgTypes[43].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*ReadTransactionReply); i {
 			case 0:
 				return &v.state
 			case 1:
@@ -4223,8 +5656,8 @@ func file_threads_proto_init() {
 				return nil
 			}
 		}
-		file_threads_proto_msgTypes[28].Exporter = func(v interface{}, i int) interface{} {
-			switch v := v.(*VerifyReply); i {
+		file_threads_proto_msgTypes[44].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*WriteTransactionRequest); i {
 			case 0:
 				return &v.state
 			case 1:
@@ -4235,8 +5668,8 @@ func file_threads_proto_init() {
 				return nil
 			}
 		}
-		file_threads_proto_msgTypes[29].Exporter = func(v interface{}, i int) interface{} {
-			switch v := v.(*SaveRequest); i {
+		file_threads_proto_msgTypes[45].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*WriteTransactionReply); i {
 			case 0:
 				return &v.state
 			case 1:
@@ -4247,8 +5680,8 @@ func file_threads_proto_init() {
 				return nil
 			}
 		}
-		file_threads_proto_msgTypes[30].Exporter = func(v interface{}, i int) interface{} {
-			switch v := v.(*SaveReply); i {
+		file_threads_proto_msgTypes[46].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*ListenRequest); i {
 			case 0:
 				return &v.state
 			case 1:
@@ -4259,8 +5692,8 @@ func file_threads_proto_init() {
 				return nil
 			}
 		}
-		file_threads_proto_msgTypes[31].Exporter = func(v interface{}, i int) interface{} {
-			switch v := v.(*DeleteRequest); i {
+		file_threads_proto_msgTypes[47].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*ListenReply); i {
 			case 0:
 				return &v.state
 			case 1:
@@ -4271,8 +5704,8 @@ func file_threads_proto_init() {
 				return nil
 			}
 		}
-		file_threads_proto_msgTypes[32].Exporter = func(v interface{}, i int) interface{} {
-			switch v := v.(*DeleteReply); i {
+		file_threads_proto_msgTypes[48].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*CountRequest); i {
 			case 0:
 				return &v.state
 			case 1:
@@ -4283,8 +5716,8 @@ func file_threads_proto_init() {
 				return nil
 			}
 		}
-		file_threads_proto_msgTypes[33].Exporter = func(v interface{}, i int) interface{} {
-			switch v := v.(*HasRequest); i {
+		file_threads_proto_msgTypes[49].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*CountReply); i {
 			case 0:
 				return &v.state
 			case 1:
@@ -4295,8 +5728,8 @@ func file_threads_proto_init() {
 				return nil
 			}
 		}
-		file_threads_proto_msgTypes[34].Exporter = func(v interface{}, i int) interface{} {
-			switch v := v.(*HasReply); i {
+		file_threads_proto_msgTypes[50].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*FindIterateReply); i {
 			case 0:
 				return &v.state
 			case 1:
@@ -4307,8 +5740,8 @@ func file_threads_proto_init() {
 				return nil
 			}
 		}
-		file_threads_proto_msgTypes[35].Exporter = func(v interface{}, i int) interface{} {
-			switch v := v.(*FindRequest); i {
+		file_threads_proto_msgTypes[51].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*ExportRequest); i {
 			case 0:
 				return &v.state
 			case 1:
@@ -4319,8 +5752,8 @@ func file_threads_proto_init() {
 				return nil
 			}
 		}
-		file_threads_proto_msgTypes[36].Exporter = func(v interface{}, i int) interface{} {
-			switch v := v.(*FindReply); i {
+		file_threads_proto_msgTypes[52].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*ExportReply); i {
 			case 0:
 				return &v.state
 			case 1:
@@ -4331,8 +5764,8 @@ func file_threads_proto_init() {
 				return nil
 			}
 		}
-		file_threads_proto_msgTypes[37].Exporter = func(v interface{}, i int) interface{} {
-			switch v := v.(*FindByIDRequest); i {
+		file_threads_proto_msgTypes[53].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*GetCollectionStatsRequest); i {
 			case 0:
 				return &v.state
 			case 1:
@@ -4343,8 +5776,8 @@ func file_threads_proto_init() {
 				return nil
 			}
 		}
-		file_threads_proto_msgTypes[38].Exporter = func(v interface{}, i int) interface{} {
-			switch v := v.(*FindByIDReply); i {
+		file_threads_proto_msgTypes[54].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*GetCollectionStatsReply); i {
 			case 0:
 				return &v.state
 			case 1:
@@ -4355,8 +5788,8 @@ func file_threads_proto_init() {
 				return nil
 			}
 		}
-		file_threads_proto_msgTypes[39].Exporter = func(v interface{}, i int) interface{} {
-			switch v := v.(*DiscardRequest); i {
+		file_threads_proto_msgTypes[55].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*ValidateRequest); i {
 			case 0:
 				return &v.state
 			case 1:
@@ -4367,8 +5800,8 @@ func file_threads_proto_init() {
 				return nil
 			}
 		}
-		file_threads_proto_msgTypes[40].Exporter = func(v interface{}, i int) interface{} {
-			switch v := v.(*DiscardReply); i {
+		file_threads_proto_msgTypes[56].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*ValidateReply); i {
 			case 0:
 				return &v.state
 			case 1:
@@ -4379,8 +5812,8 @@ func file_threads_proto_init() {
 				return nil
 			}
 		}
-		file_threads_proto_msgTypes[41].Exporter = func(v interface{}, i int) interface{} {
-			switch v := v.(*StartTransactionRequest); i {
+		file_threads_proto_msgTypes[57].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*FindByIDsRequest); i {
 			case 0:
 				return &v.state
 			case 1:
@@ -4391,8 +5824,8 @@ func file_threads_proto_init() {
 				return nil
 			}
 		}
-		file_threads_proto_msgTypes[42].Exporter = func(v interface{}, i int) interface{} {
-			switch v := v.(*ReadTransactionRequest); i {
+		file_threads_proto_msgTypes[58].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*FindByIDsReply); i {
 			case 0:
 				return &v.state
 			case 1:
@@ -4403,8 +5836,8 @@ func file_threads_proto_init() {
 				return nil
 			}
 		}
-		file_threads_proto_msgTypes[43].Exporter = func(v interface{}, i int) interface{} {
-			switch v := v.(*ReadTransactionReply); i {
+		file_threads_proto_msgTypes[59].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*GetManyReply); i {
 			case 0:
 				return &v.state
 			case 1:
@@ -4415,8 +5848,8 @@ func file_threads_proto_init() {
 				return nil
 			}
 		}
-		file_threads_proto_msgTypes[44].Exporter = func(v interface{}, i int) interface{} {
-			switch v := v.(*WriteTransactionRequest); i {
+		file_threads_proto_msgTypes[60].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*CollectionConfig_Sort); i {
 			case 0:
 				return &v.state
 			case 1:
@@ -4427,8 +5860,8 @@ func file_threads_proto_init() {
 				return nil
 			}
 		}
-		file_threads_proto_msgTypes[45].Exporter = func(v interface{}, i int) interface{} {
-			switch v := v.(*WriteTransactionReply); i {
+		file_threads_proto_msgTypes[61].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*CollectionConfig_Reference); i {
 			case 0:
 				return &v.state
 			case 1:
@@ -4439,8 +5872,8 @@ func file_threads_proto_init() {
 				return nil
 			}
 		}
-		file_threads_proto_msgTypes[46].Exporter = func(v interface{}, i int) interface{} {
-			switch v := v.(*ListenRequest); i {
+		file_threads_proto_msgTypes[62].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*CollectionConfig_ComputedField); i {
 			case 0:
 				return &v.state
 			case 1:
@@ -4451,8 +5884,8 @@ func file_threads_proto_init() {
 				return nil
 			}
 		}
-		file_threads_proto_msgTypes[47].Exporter = func(v interface{}, i int) interface{} {
-			switch v := v.(*ListenReply); i {
+		file_threads_proto_msgTypes[63].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*ListDBsReply_DB); i {
 			case 0:
 				return &v.state
 			case 1:
@@ -4463,8 +5896,8 @@ func file_threads_proto_init() {
 				return nil
 			}
 		}
-		file_threads_proto_msgTypes[48].Exporter = func(v interface{}, i int) interface{} {
-			switch v := v.(*ListDBsReply_DB); i {
+		file_threads_proto_msgTypes[64].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*ListenRequest_Filter); i {
 			case 0:
 				return &v.state
 			case 1:
@@ -4475,8 +5908,8 @@ func file_threads_proto_init() {
 				return nil
 			}
 		}
-		file_threads_proto_msgTypes[49].Exporter = func(v interface{}, i int) interface{} {
-			switch v := v.(*ListenRequest_Filter); i {
+		file_threads_proto_msgTypes[65].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*GetCollectionStatsReply_IndexStats); i {
 			case 0:
 				return &v.state
 			case 1:
@@ -4534,7 +5967,7 @@ func file_threads_proto_init() {
 			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
 			RawDescriptor: file_threads_proto_rawDesc,
 			NumEnums:      2,
-			NumMessages:   50,
+			NumMessages:   66,
 			NumExtensions: 0,
 			NumServices:   1,
 		},
@@ -4584,6 +6017,13 @@ type APIClient interface {
 	ReadTransaction(ctx context.Context, opts ...grpc.CallOption) (API_ReadTransactionClient, error)
 	WriteTransaction(ctx context.Context, opts ...grpc.CallOption) (API_WriteTransactionClient, error)
 	Listen(ctx context.Context, in *ListenRequest, opts ...grpc.CallOption) (API_ListenClient, error)
+	Count(ctx context.Context, in *CountRequest, opts ...grpc.CallOption) (*CountReply, error)
+	FindIterate(ctx context.Context, in *FindRequest, opts ...grpc.CallOption) (API_FindIterateClient, error)
+	Export(ctx context.Context, in *ExportRequest, opts ...grpc.CallOption) (API_ExportClient, error)
+	GetCollectionStats(ctx context.Context, in *GetCollectionStatsRequest, opts ...grpc.CallOption) (*GetCollectionStatsReply, error)
+	Validate(ctx context.Context, in *ValidateRequest, opts ...grpc.CallOption) (*ValidateReply, error)
+	FindByIDs(ctx context.Context, in *FindByIDsRequest, opts ...grpc.CallOption) (*FindByIDsReply, error)
+	GetMany(ctx context.Context, in *FindByIDsRequest, opts ...grpc.CallOption) (*GetManyReply, error)
 }
 
 type aPIClient struct {
@@ -4882,6 +6322,115 @@ func (x *aPIListenClient) Recv() (*ListenReply, error) {
 	return m, nil
 }
 
+func (c *aPIClient) Count(ctx context.Context, in *CountRequest, opts ...grpc.CallOption) (*CountReply, error) {
+	out := new(CountReply)
+	err := c.cc.Invoke(ctx, "/threads.pb.API/Count", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *aPIClient) FindIterate(ctx context.Context, in *FindRequest, opts ...grpc.CallOption) (API_FindIterateClient, error) {
+	stream, err := c.cc.NewStream(ctx, &_API_serviceDesc.Streams[4], "/threads.pb.API/FindIterate", opts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &aPIFindIterateClient{stream}
+	if err := x.ClientStream.SendMsg(in); err != nil {
+		return nil, err
+	}
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	return x, nil
+}
+
+type API_FindIterateClient interface {
+	Recv() (*FindIterateReply, error)
+	grpc.ClientStream
+}
+
+type aPIFindIterateClient struct {
+	grpc.ClientStream
+}
+
+func (x *aPIFindIterateClient) Recv() (*FindIterateReply, error) {
+	m := new(FindIterateReply)
+	if err := x.ClientStream.RecvMsg(m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+func (c *aPIClient) Export(ctx context.Context, in *ExportRequest, opts ...grpc.CallOption) (API_ExportClient, error) {
+	stream, err := c.cc.NewStream(ctx, &_API_serviceDesc.Streams[5], "/threads.pb.API/Export", opts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &aPIExportClient{stream}
+	if err := x.ClientStream.SendMsg(in); err != nil {
+		return nil, err
+	}
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	return x, nil
+}
+
+type API_ExportClient interface {
+	Recv() (*ExportReply, error)
+	grpc.ClientStream
+}
+
+type aPIExportClient struct {
+	grpc.ClientStream
+}
+
+func (x *aPIExportClient) Recv() (*ExportReply, error) {
+	m := new(ExportReply)
+	if err := x.ClientStream.RecvMsg(m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+func (c *aPIClient) GetCollectionStats(ctx context.Context, in *GetCollectionStatsRequest, opts ...grpc.CallOption) (*GetCollectionStatsReply, error) {
+	out := new(GetCollectionStatsReply)
+	err := c.cc.Invoke(ctx, "/threads.pb.API/GetCollectionStats", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *aPIClient) Validate(ctx context.Context, in *ValidateRequest, opts ...grpc.CallOption) (*ValidateReply, error) {
+	out := new(ValidateReply)
+	err := c.cc.Invoke(ctx, "/threads.pb.API/Validate", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *aPIClient) FindByIDs(ctx context.Context, in *FindByIDsRequest, opts ...grpc.CallOption) (*FindByIDsReply, error) {
+	out := new(FindByIDsReply)
+	err := c.cc.Invoke(ctx, "/threads.pb.API/FindByIDs", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *aPIClient) GetMany(ctx context.Context, in *FindByIDsRequest, opts ...grpc.CallOption) (*GetManyReply, error) {
+	out := new(GetManyReply)
+	err := c.cc.Invoke(ctx, "/threads.pb.API/GetMany", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
 // APIServer is the server API for API service.
 type APIServer interface {
 	GetToken(API_GetTokenServer) error
@@ -4907,6 +6456,13 @@ type APIServer interface {
 	ReadTransaction(API_ReadTransactionServer) error
 	WriteTransaction(API_WriteTransactionServer) error
 	Listen(*ListenRequest, API_ListenServer) error
+	Count(context.Context, *CountRequest) (*CountReply, error)
+	FindIterate(*FindRequest, API_FindIterateServer) error
+	Export(*ExportRequest, API_ExportServer) error
+	GetCollectionStats(context.Context, *GetCollectionStatsRequest) (*GetCollectionStatsReply, error)
+	Validate(context.Context, *ValidateRequest) (*ValidateReply, error)
+	FindByIDs(context.Context, *FindByIDsRequest) (*FindByIDsReply, error)
+	GetMany(context.Context, *FindByIDsRequest) (*GetManyReply, error)
 }
 
 // UnimplementedAPIServer can be embedded to have forward compatible implementations.
@@ -4979,6 +6535,27 @@ func (*UnimplementedAPIServer) WriteTransaction(API_WriteTransactionServer) erro
 func (*UnimplementedAPIServer) Listen(*ListenRequest, API_ListenServer) error {
 	return status.Errorf(codes.Unimplemented, "method Listen not implemented")
 }
+func (*UnimplementedAPIServer) Count(context.Context, *CountRequest) (*CountReply, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method Count not implemented")
+}
+func (*UnimplementedAPIServer) FindIterate(*FindRequest, API_FindIterateServer) error {
+	return status.Errorf(codes.Unimplemented, "method FindIterate not implemented")
+}
+func (*UnimplementedAPIServer) Export(*ExportRequest, API_ExportServer) error {
+	return status.Errorf(codes.Unimplemented, "method Export not implemented")
+}
+func (*UnimplementedAPIServer) GetCollectionStats(context.Context, *GetCollectionStatsRequest) (*GetCollectionStatsReply, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetCollectionStats not implemented")
+}
+func (*UnimplementedAPIServer) Validate(context.Context, *ValidateRequest) (*ValidateReply, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method Validate not implemented")
+}
+func (*UnimplementedAPIServer) FindByIDs(context.Context, *FindByIDsRequest) (*FindByIDsReply, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method FindByIDs not implemented")
+}
+func (*UnimplementedAPIServer) GetMany(context.Context, *FindByIDsRequest) (*GetManyReply, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetMany not implemented")
+}
 
 func RegisterAPIServer(s *grpc.Server, srv APIServer) {
 	s.RegisterService(&_API_serviceDesc, srv)
@@ -5407,6 +6984,138 @@ func (x *aPIListenServer) Send(m *ListenReply) error {
 	return x.ServerStream.SendMsg(m)
 }
 
+func _API_Count_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(CountRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(APIServer).Count(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/threads.pb.API/Count",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(APIServer).Count(ctx, req.(*CountRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _API_FindIterate_Handler(srv interface{}, stream grpc.ServerStream) error {
+	m := new(FindRequest)
+	if err := stream.RecvMsg(m); err != nil {
+		return err
+	}
+	return srv.(APIServer).FindIterate(m, &aPIFindIterateServer{stream})
+}
+
+type API_FindIterateServer interface {
+	Send(*FindIterateReply) error
+	grpc.ServerStream
+}
+
+type aPIFindIterateServer struct {
+	grpc.ServerStream
+}
+
+func (x *aPIFindIterateServer) Send(m *FindIterateReply) error {
+	return x.ServerStream.SendMsg(m)
+}
+
+func _API_Export_Handler(srv interface{}, stream grpc.ServerStream) error {
+	m := new(ExportRequest)
+	if err := stream.RecvMsg(m); err != nil {
+		return err
+	}
+	return srv.(APIServer).Export(m, &aPIExportServer{stream})
+}
+
+type API_ExportServer interface {
+	Send(*ExportReply) error
+	grpc.ServerStream
+}
+
+type aPIExportServer struct {
+	grpc.ServerStream
+}
+
+func (x *aPIExportServer) Send(m *ExportReply) error {
+	return x.ServerStream.SendMsg(m)
+}
+
+func _API_GetCollectionStats_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(GetCollectionStatsRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(APIServer).GetCollectionStats(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/threads.pb.API/GetCollectionStats",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(APIServer).GetCollectionStats(ctx, req.(*GetCollectionStatsRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _API_Validate_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ValidateRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(APIServer).Validate(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/threads.pb.API/Validate",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(APIServer).Validate(ctx, req.(*ValidateRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _API_FindByIDs_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(FindByIDsRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(APIServer).FindByIDs(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/threads.pb.API/FindByIDs",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(APIServer).FindByIDs(ctx, req.(*FindByIDsRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _API_GetMany_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(FindByIDsRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(APIServer).GetMany(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/threads.pb.API/GetMany",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(APIServer).GetMany(ctx, req.(*FindByIDsRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
 var _API_serviceDesc = grpc.ServiceDesc{
 	ServiceName: "threads.pb.API",
 	HandlerType: (*APIServer)(nil),
@@ -5483,6 +7192,26 @@ var _API_serviceDesc = grpc.ServiceDesc{
 			MethodName: "FindByID",
 			Handler:    _API_FindByID_Handler,
 		},
+		{
+			MethodName: "Count",
+			Handler:    _API_Count_Handler,
+		},
+		{
+			MethodName: "GetCollectionStats",
+			Handler:    _API_GetCollectionStats_Handler,
+		},
+		{
+			MethodName: "Validate",
+			Handler:    _API_Validate_Handler,
+		},
+		{
+			MethodName: "FindByIDs",
+			Handler:    _API_FindByIDs_Handler,
+		},
+		{
+			MethodName: "GetMany",
+			Handler:    _API_GetMany_Handler,
+		},
 	},
 	Streams: []grpc.StreamDesc{
 		{
@@ -5508,6 +7237,16 @@ var _API_serviceDesc = grpc.ServiceDesc{
 			Handler:       _API_Listen_Handler,
 			ServerStreams: true,
 		},
+		{
+			StreamName:    "FindIterate",
+			Handler:       _API_FindIterate_Handler,
+			ServerStreams: true,
+		},
+		{
+			StreamName:    "Export",
+			Handler:       _API_Export_Handler,
+			ServerStreams: true,
+		},
 	},
 	Metadata: "threads.proto",
 }
@@ -8,9 +8,12 @@ import (
 	"io"
 
 	"github.com/alecthomas/jsonschema"
+	"github.com/golang/protobuf/proto"
+	"github.com/grpc-ecosystem/go-grpc-middleware/util/metautils"
 	logging "github.com/ipfs/go-log"
 	"github.com/libp2p/go-libp2p-core/crypto"
 	ma "github.com/multiformats/go-multiaddr"
+	"github.com/textileio/go-threads/api/common"
 	pb "github.com/textileio/go-threads/api/pb"
 	"github.com/textileio/go-threads/core/app"
 	core "github.com/textileio/go-threads/core/db"
@@ -18,6 +21,7 @@ import (
 	"github.com/textileio/go-threads/core/thread"
 	"github.com/textileio/go-threads/db"
 	"github.com/textileio/go-threads/util"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
 	"google.golang.org/grpc/codes"
 	"google.golang.org/grpc/status"
 )
@@ -199,6 +203,7 @@ func (s *Service) NewDB(ctx context.Context, req *pb.NewDBRequest) (*pb.NewDBRep
 		db.WithNewManagedCollections(collections...)); err != nil {
 		return nil, err
 	}
+	s.manager.ReleaseDB(id)
 	return &pb.NewDBReply{}, nil
 }
 
@@ -209,6 +214,10 @@ func (s *Service) NewDBFromAddr(ctx context.Context, req *pb.NewDBFromAddrReques
 	if err != nil {
 		return nil, status.Error(codes.InvalidArgument, err.Error())
 	}
+	id, err := thread.FromAddr(addr)
+	if err != nil {
+		return nil, status.Error(codes.InvalidArgument, err.Error())
+	}
 	key, err := thread.KeyFromBytes(req.Key)
 	if err != nil {
 		return nil, status.Error(codes.InvalidArgument, err.Error())
@@ -248,6 +257,7 @@ func (s *Service) NewDBFromAddr(ctx context.Context, req *pb.NewDBFromAddrReques
 		db.WithNewManagedBackfillBlock(req.Block)); err != nil {
 		return nil, err
 	}
+	s.manager.ReleaseDB(id)
 	return &pb.NewDBReply{}, nil
 }
 
@@ -277,13 +287,40 @@ func collectionConfigFromPb(pbc *pb.CollectionConfig) (db.CollectionConfig, erro
 	if err := json.Unmarshal(pbc.GetSchema(), schema); err != nil {
 		return db.CollectionConfig{}, err
 	}
-	return db.CollectionConfig{
-		Name:           pbc.Name,
-		Schema:         schema,
-		Indexes:        indexes,
-		WriteValidator: pbc.WriteValidator,
-		ReadFilter:     pbc.ReadFilter,
-	}, nil
+	config := db.CollectionConfig{
+		Name:             pbc.Name,
+		Schema:           schema,
+		Indexes:          indexes,
+		WriteValidator:   pbc.WriteValidator,
+		ReadFilter:       pbc.ReadFilter,
+		Version:          int(pbc.Version),
+		TTLField:         pbc.TtlField,
+		ConflictStrategy: core.ConflictStrategy(pbc.ConflictStrategy),
+		EncryptedFields:  pbc.EncryptedFields,
+		IDStrategy:       db.IDStrategy(pbc.IdStrategy),
+		SerializeWrites:  pbc.SerializeWrites,
+	}
+	if pbc.DefaultOrderBy != nil {
+		config.DefaultOrderBy = db.Sort{
+			FieldPath:       pbc.DefaultOrderBy.FieldPath,
+			Desc:            pbc.DefaultOrderBy.Desc,
+			CaseInsensitive: pbc.DefaultOrderBy.CaseInsensitive,
+		}
+	}
+	for _, ref := range pbc.References {
+		config.References = append(config.References, db.Reference{
+			Path:       ref.Path,
+			Collection: ref.Collection,
+			OnDelete:   db.RefDeleteAction(ref.OnDelete),
+		})
+	}
+	for _, f := range pbc.ComputedFields {
+		config.ComputedFields = append(config.ComputedFields, db.ComputedField{
+			Path: f.Path,
+			JS:   f.Js,
+		})
+	}
+	return config, nil
 }
 
 func (s *Service) ListDBs(ctx context.Context, _ *pb.ListDBsRequest) (*pb.ListDBsReply, error) {
@@ -296,6 +333,11 @@ func (s *Service) ListDBs(ctx context.Context, _ *pb.ListDBsRequest) (*pb.ListDB
 	if err != nil {
 		return nil, err
 	}
+	defer func() {
+		for id := range dbs {
+			s.manager.ReleaseDB(id)
+		}
+	}()
 	pbdbs := make([]*pb.ListDBsReply_DB, len(dbs))
 	var i int
 	for id, d := range dbs {
@@ -326,6 +368,7 @@ func (s *Service) GetDBInfo(ctx context.Context, req *pb.GetDBInfoRequest) (*pb.
 	if err != nil {
 		return nil, err
 	}
+	defer s.manager.ReleaseDB(id)
 	return dBInfoToPb(d, token)
 }
 
@@ -378,6 +421,7 @@ func (s *Service) NewCollection(ctx context.Context, req *pb.NewCollectionReques
 	if err != nil {
 		return nil, err
 	}
+	defer s.manager.ReleaseDB(id)
 	cc, err := collectionConfigFromPb(req.Config)
 	if err != nil {
 		return nil, err
@@ -401,6 +445,7 @@ func (s *Service) UpdateCollection(ctx context.Context, req *pb.UpdateCollection
 	if err != nil {
 		return nil, err
 	}
+	defer s.manager.ReleaseDB(id)
 	cc, err := collectionConfigFromPb(req.Config)
 	if err != nil {
 		return nil, err
@@ -424,6 +469,7 @@ func (s *Service) DeleteCollection(ctx context.Context, req *pb.DeleteCollection
 	if err != nil {
 		return nil, err
 	}
+	defer s.manager.ReleaseDB(id)
 	if err = d.DeleteCollection(req.Name); err != nil {
 		return nil, err
 	}
@@ -443,15 +489,108 @@ func (s *Service) GetCollectionInfo(ctx context.Context, req *pb.GetCollectionIn
 	if err != nil {
 		return nil, err
 	}
+	defer s.manager.ReleaseDB(id)
+	return collectionInfoToPb(collection)
+}
+
+// collectionInfoToPb returns the config of a collection. Resolvers,
+// generators, migrations, and functions of computed fields aren't sent, and
+// neither is the encryption key of encrypted fields.
+func collectionInfoToPb(c *db.Collection) (*pb.GetCollectionInfoReply, error) {
+	config, err := c.GetConfig()
+	if err != nil {
+		return nil, err
+	}
+	cc := collectionConfigToPb(config, c.GetSchema())
 	return &pb.GetCollectionInfoReply{
-		Name:           collection.GetName(),
-		Schema:         collection.GetSchema(),
-		Indexes:        indexesToPb(collection.GetIndexes()),
-		WriteValidator: string(collection.GetWriteValidator()),
-		ReadFilter:     string(collection.GetReadFilter()),
+		Name:             cc.Name,
+		Schema:           cc.Schema,
+		Indexes:          cc.Indexes,
+		WriteValidator:   cc.WriteValidator,
+		ReadFilter:       cc.ReadFilter,
+		DefaultOrderBy:   cc.DefaultOrderBy,
+		Version:          cc.Version,
+		TtlField:         cc.TtlField,
+		ConflictStrategy: cc.ConflictStrategy,
+		References:       cc.References,
+		EncryptedFields:  cc.EncryptedFields,
+		IdStrategy:       cc.IdStrategy,
+		ComputedFields:   cc.ComputedFields,
+		SerializeWrites:  cc.SerializeWrites,
 	}, nil
 }
 
+func collectionConfigToPb(config db.CollectionConfig, schema []byte) *pb.CollectionConfig {
+	cc := &pb.CollectionConfig{
+		Name:           config.Name,
+		Schema:         schema,
+		Indexes:        indexesToPb(config.Indexes),
+		WriteValidator: config.WriteValidator,
+		ReadFilter:     config.ReadFilter,
+		DefaultOrderBy: &pb.CollectionConfig_Sort{
+			FieldPath:       config.DefaultOrderBy.FieldPath,
+			Desc:            config.DefaultOrderBy.Desc,
+			CaseInsensitive: config.DefaultOrderBy.CaseInsensitive,
+		},
+		Version:          int64(config.Version),
+		TtlField:         config.TTLField,
+		ConflictStrategy: int32(config.ConflictStrategy),
+		EncryptedFields:  config.EncryptedFields,
+		IdStrategy:       int32(config.IDStrategy),
+		SerializeWrites:  config.SerializeWrites,
+	}
+	for _, ref := range config.References {
+		cc.References = append(cc.References, &pb.CollectionConfig_Reference{
+			Path:       ref.Path,
+			Collection: ref.Collection,
+			OnDelete:   int32(ref.OnDelete),
+		})
+	}
+	for _, f := range config.ComputedFields {
+		cc.ComputedFields = append(cc.ComputedFields, &pb.CollectionConfig_ComputedField{
+			Path: f.Path,
+			Js:   f.JS,
+		})
+	}
+	return cc
+}
+
+func (s *Service) GetCollectionStats(ctx context.Context, req *pb.GetCollectionStatsRequest) (*pb.GetCollectionStatsReply, error) {
+	id, err := thread.Cast(req.DbID)
+	if err != nil {
+		return nil, status.Error(codes.InvalidArgument, err.Error())
+	}
+	token, err := thread.NewTokenFromMD(ctx)
+	if err != nil {
+		return nil, err
+	}
+	collection, err := s.getCollection(ctx, req.Name, id, token)
+	if err != nil {
+		return nil, err
+	}
+	defer s.manager.ReleaseDB(id)
+	stats, err := collection.Stats(db.WithTxnToken(token), db.WithTxnContext(ctx))
+	if err != nil {
+		return nil, err
+	}
+	reply := &pb.GetCollectionStatsReply{
+		Instances: int64(stats.Instances),
+		Bytes:     stats.Bytes,
+		Indexes:   make([]*pb.GetCollectionStatsReply_IndexStats, len(stats.Indexes)),
+	}
+	for i, is := range stats.Indexes {
+		reply.Indexes[i] = &pb.GetCollectionStatsReply_IndexStats{
+			Path:    is.Path,
+			Entries: int64(is.Entries),
+			Bytes:   is.Bytes,
+		}
+	}
+	if !stats.LastModified.IsZero() {
+		reply.LastModified = stats.LastModified.UnixNano()
+	}
+	return reply, nil
+}
+
 func indexesToPb(indexes []db.Index) []*pb.Index {
 	pbindexes := make([]*pb.Index, len(indexes))
 	for i, index := range indexes {
@@ -476,6 +615,7 @@ func (s *Service) GetCollectionIndexes(ctx context.Context, req *pb.GetCollectio
 	if err != nil {
 		return nil, err
 	}
+	defer s.manager.ReleaseDB(id)
 	return &pb.GetCollectionIndexesReply{
 		Indexes: indexesToPb(collection.GetIndexes()),
 	}, nil
@@ -494,15 +634,12 @@ func (s *Service) ListCollections(ctx context.Context, req *pb.ListCollectionsRe
 	if err != nil {
 		return nil, err
 	}
+	defer s.manager.ReleaseDB(id)
 	list := d.ListCollections(db.WithToken(token))
 	pblist := make([]*pb.GetCollectionInfoReply, len(list))
 	for i, c := range list {
-		pblist[i] = &pb.GetCollectionInfoReply{
-			Name:           c.GetName(),
-			Schema:         c.GetSchema(),
-			Indexes:        indexesToPb(c.GetIndexes()),
-			WriteValidator: string(c.GetWriteValidator()),
-			ReadFilter:     string(c.GetReadFilter()),
+		if pblist[i], err = collectionInfoToPb(c); err != nil {
+			return nil, err
 		}
 	}
 	return &pb.ListCollectionsReply{Collections: pblist}, nil
@@ -521,7 +658,9 @@ func (s *Service) Create(ctx context.Context, req *pb.CreateRequest) (*pb.Create
 	if err != nil {
 		return nil, err
 	}
-	return s.processCreateRequest(req, token, collection.CreateMany)
+	defer s.manager.ReleaseDB(id)
+	reply, err := s.processCreateRequest(req, token, collection.CreateMany)
+	return reply, validationStatus(err)
 }
 
 func (s *Service) Verify(ctx context.Context, req *pb.VerifyRequest) (*pb.VerifyReply, error) {
@@ -537,7 +676,29 @@ func (s *Service) Verify(ctx context.Context, req *pb.VerifyRequest) (*pb.Verify
 	if err != nil {
 		return nil, err
 	}
-	return s.processVerifyRequest(req, token, collection.VerifyMany)
+	defer s.manager.ReleaseDB(id)
+	reply, err := s.processVerifyRequest(req, token, collection.VerifyMany)
+	return reply, validationStatus(err)
+}
+
+func (s *Service) Validate(ctx context.Context, req *pb.ValidateRequest) (*pb.ValidateReply, error) {
+	id, err := thread.Cast(req.DbID)
+	if err != nil {
+		return nil, status.Error(codes.InvalidArgument, err.Error())
+	}
+	token, err := thread.NewTokenFromMD(ctx)
+	if err != nil {
+		return nil, err
+	}
+	collection, err := s.getCollection(ctx, req.CollectionName, id, token)
+	if err != nil {
+		return nil, err
+	}
+	defer s.manager.ReleaseDB(id)
+	if err := collection.Validate(req.Instance, db.WithTxnToken(token), db.WithTxnContext(ctx)); err != nil {
+		return nil, validationStatus(err)
+	}
+	return &pb.ValidateReply{}, nil
 }
 
 func (s *Service) Save(ctx context.Context, req *pb.SaveRequest) (*pb.SaveReply, error) {
@@ -553,7 +714,12 @@ func (s *Service) Save(ctx context.Context, req *pb.SaveRequest) (*pb.SaveReply,
 	if err != nil {
 		return nil, err
 	}
-	return s.processSaveRequest(req, token, collection.SaveMany)
+	defer s.manager.ReleaseDB(id)
+	reply, err := s.processSaveRequest(req, token, func(vs [][]byte, opts ...db.TxnOption) error {
+		_, err := collection.SaveMany(vs, opts...)
+		return err
+	})
+	return reply, validationStatus(err)
 }
 
 func (s *Service) Delete(ctx context.Context, req *pb.DeleteRequest) (*pb.DeleteReply, error) {
@@ -569,7 +735,11 @@ func (s *Service) Delete(ctx context.Context, req *pb.DeleteRequest) (*pb.Delete
 	if err != nil {
 		return nil, err
 	}
-	return s.processDeleteRequest(req, token, collection.DeleteMany)
+	defer s.manager.ReleaseDB(id)
+	return s.processDeleteRequest(req, token, func(ids []core.InstanceID, opts ...db.TxnOption) error {
+		_, err := collection.DeleteMany(ids, opts...)
+		return err
+	})
 }
 
 func (s *Service) Has(ctx context.Context, req *pb.HasRequest) (*pb.HasReply, error) {
@@ -585,6 +755,7 @@ func (s *Service) Has(ctx context.Context, req *pb.HasRequest) (*pb.HasReply, er
 	if err != nil {
 		return nil, err
 	}
+	defer s.manager.ReleaseDB(id)
 	return s.processHasRequest(req, token, collection.HasMany)
 }
 
@@ -601,7 +772,89 @@ func (s *Service) Find(ctx context.Context, req *pb.FindRequest) (*pb.FindReply,
 	if err != nil {
 		return nil, err
 	}
-	return s.processFindRequest(req, token, collection.Find)
+	defer s.manager.ReleaseDB(id)
+	return s.processFindRequest(req, token, collection.Find, db.WithTxnContext(ctx))
+}
+
+func (s *Service) FindIterate(req *pb.FindRequest, stream pb.API_FindIterateServer) error {
+	id, err := thread.Cast(req.DbID)
+	if err != nil {
+		return status.Error(codes.InvalidArgument, err.Error())
+	}
+	token, err := thread.NewTokenFromMD(stream.Context())
+	if err != nil {
+		return err
+	}
+	collection, err := s.getCollection(stream.Context(), req.CollectionName, id, token)
+	if err != nil {
+		return err
+	}
+	defer s.manager.ReleaseDB(id)
+	q := &db.Query{}
+	if err := json.Unmarshal(req.QueryJSON, q); err != nil {
+		return status.Error(codes.InvalidArgument, err.Error())
+	}
+	return collection.FindIterate(q, func(instance []byte) error {
+		return stream.Send(&pb.FindIterateReply{Instance: instance})
+	}, db.WithTxnToken(token), db.WithTxnContext(stream.Context()))
+}
+
+func (s *Service) Export(req *pb.ExportRequest, stream pb.API_ExportServer) error {
+	id, err := thread.Cast(req.DbID)
+	if err != nil {
+		return status.Error(codes.InvalidArgument, err.Error())
+	}
+	token, err := thread.NewTokenFromMD(stream.Context())
+	if err != nil {
+		return err
+	}
+	collection, err := s.getCollection(stream.Context(), req.CollectionName, id, token)
+	if err != nil {
+		return err
+	}
+	defer s.manager.ReleaseDB(id)
+	return collection.Export(&exportWriter{stream: stream}, db.WithTxnToken(token), db.WithTxnContext(stream.Context()))
+}
+
+// exportWriter sends the chunks written by a collection export over an
+// export stream.
+type exportWriter struct {
+	stream pb.API_ExportServer
+}
+
+func (w *exportWriter) Write(p []byte) (int, error) {
+	// Messages must not be modified once sent, and p is reused by the export
+	chunk := make([]byte, len(p))
+	copy(chunk, p)
+	if err := w.stream.Send(&pb.ExportReply{Chunk: chunk}); err != nil {
+		return 0, err
+	}
+	return len(p), nil
+}
+
+func (s *Service) Count(ctx context.Context, req *pb.CountRequest) (*pb.CountReply, error) {
+	id, err := thread.Cast(req.DbID)
+	if err != nil {
+		return nil, status.Error(codes.InvalidArgument, err.Error())
+	}
+	token, err := thread.NewTokenFromMD(ctx)
+	if err != nil {
+		return nil, err
+	}
+	collection, err := s.getCollection(ctx, req.CollectionName, id, token)
+	if err != nil {
+		return nil, err
+	}
+	defer s.manager.ReleaseDB(id)
+	q := &db.Query{}
+	if err := json.Unmarshal(req.QueryJSON, q); err != nil {
+		return nil, status.Error(codes.InvalidArgument, err.Error())
+	}
+	count, err := collection.Count(q, db.WithTxnToken(token), db.WithTxnContext(ctx))
+	if err != nil {
+		return nil, err
+	}
+	return &pb.CountReply{Count: int64(count)}, nil
 }
 
 func (s *Service) FindByID(ctx context.Context, req *pb.FindByIDRequest) (*pb.FindByIDReply, error) {
@@ -617,7 +870,68 @@ func (s *Service) FindByID(ctx context.Context, req *pb.FindByIDRequest) (*pb.Fi
 	if err != nil {
 		return nil, err
 	}
-	return s.processFindByIDRequest(req, token, collection.FindByID)
+	defer s.manager.ReleaseDB(id)
+	return s.processFindByIDRequest(req, token, collection.FindByID, db.WithTxnContext(ctx))
+}
+
+// FindByIDs finds instances by their IDs in a single read transaction, see
+// db.Collection.FindByIDs. Missing instances fail with codes.NotFound.
+func (s *Service) FindByIDs(ctx context.Context, req *pb.FindByIDsRequest) (*pb.FindByIDsReply, error) {
+	id, err := thread.Cast(req.DbID)
+	if err != nil {
+		return nil, status.Error(codes.InvalidArgument, err.Error())
+	}
+	token, err := thread.NewTokenFromMD(ctx)
+	if err != nil {
+		return nil, err
+	}
+	collection, err := s.getCollection(ctx, req.CollectionName, id, token)
+	if err != nil {
+		return nil, err
+	}
+	defer s.manager.ReleaseDB(id)
+	instanceIDs := make([]core.InstanceID, len(req.InstanceIDs))
+	for i, ID := range req.InstanceIDs {
+		instanceIDs[i] = core.InstanceID(ID)
+	}
+	instances, err := collection.FindByIDs(instanceIDs, db.WithTxnToken(token), db.WithTxnContext(ctx))
+	if errors.Is(err, db.ErrInstanceNotFound) {
+		return nil, status.Error(codes.NotFound, err.Error())
+	} else if err != nil {
+		return nil, err
+	}
+	return &pb.FindByIDsReply{Instances: instances}, nil
+}
+
+// GetMany gets instances by their IDs in a single read transaction, along
+// with the IDs that don't exist, see db.Collection.GetMany.
+func (s *Service) GetMany(ctx context.Context, req *pb.FindByIDsRequest) (*pb.GetManyReply, error) {
+	id, err := thread.Cast(req.DbID)
+	if err != nil {
+		return nil, status.Error(codes.InvalidArgument, err.Error())
+	}
+	token, err := thread.NewTokenFromMD(ctx)
+	if err != nil {
+		return nil, err
+	}
+	collection, err := s.getCollection(ctx, req.CollectionName, id, token)
+	if err != nil {
+		return nil, err
+	}
+	defer s.manager.ReleaseDB(id)
+	instanceIDs := make([]core.InstanceID, len(req.InstanceIDs))
+	for i, ID := range req.InstanceIDs {
+		instanceIDs[i] = core.InstanceID(ID)
+	}
+	instances, missing, err := collection.GetMany(instanceIDs, db.WithTxnToken(token), db.WithTxnContext(ctx))
+	if err != nil {
+		return nil, err
+	}
+	reply := &pb.GetManyReply{Instances: instances, Missing: make([]string, len(missing))}
+	for i, ID := range missing {
+		reply.Missing[i] = ID.String()
+	}
+	return reply, nil
 }
 
 func (s *Service) ReadTransaction(stream pb.API_ReadTransactionServer) error {
@@ -649,6 +963,7 @@ func (s *Service) ReadTransaction(stream pb.API_ReadTransactionServer) error {
 	if err != nil {
 		return err
 	}
+	defer s.manager.ReleaseDB(id)
 
 	return collection.ReadTxn(func(txn *db.Txn) error {
 		for {
@@ -702,7 +1017,7 @@ func (s *Service) ReadTransaction(stream pb.API_ReadTransactionServer) error {
 				return fmt.Errorf("ReadTransactionRequest.Option has unexpected type %T", x)
 			}
 		}
-	}, db.WithTxnToken(token))
+	}, db.WithTxnToken(token), db.WithTxnContext(stream.Context()))
 }
 
 func (s *Service) WriteTransaction(stream pb.API_WriteTransactionServer) error {
@@ -734,6 +1049,13 @@ func (s *Service) WriteTransaction(stream pb.API_WriteTransactionServer) error {
 	if err != nil {
 		return err
 	}
+	defer s.manager.ReleaseDB(id)
+	var md map[string]string
+	if val := metautils.ExtractIncoming(stream.Context()).Get(common.TxnMetadataKey); val != "" {
+		if err := json.Unmarshal([]byte(val), &md); err != nil {
+			return status.Errorf(codes.InvalidArgument, "invalid transaction metadata: %v", err)
+		}
+	}
 
 	return collection.WriteTxn(func(txn *db.Txn) error {
 		for {
@@ -837,7 +1159,7 @@ func (s *Service) WriteTransaction(stream pb.API_WriteTransactionServer) error {
 				return fmt.Errorf("WriteTransactionRequest.Option has unexpected type %T", x)
 			}
 		}
-	}, db.WithTxnToken(token))
+	}, db.WithTxnToken(token), db.WithTxnMetadata(md), db.WithTxnContext(stream.Context()))
 }
 
 func (s *Service) Listen(req *pb.ListenRequest, server pb.API_ListenServer) error {
@@ -853,6 +1175,7 @@ func (s *Service) Listen(req *pb.ListenRequest, server pb.API_ListenServer) erro
 	if err != nil {
 		return err
 	}
+	defer s.manager.ReleaseDB(id)
 
 	options := make([]db.ListenOption, len(req.Filters))
 	for i, filter := range req.Filters {
@@ -889,6 +1212,16 @@ func (s *Service) Listen(req *pb.ListenRequest, server pb.API_ListenServer) erro
 			return nil
 		case action, ok := <-l.Channel():
 			if !ok {
+				// The db was deleted or closed
+				el, ok := l.(db.ErrListener)
+				if !ok {
+					return nil
+				}
+				if err := el.Err(); errors.Is(err, db.ErrDBDeleted) {
+					return status.Error(codes.NotFound, err.Error())
+				} else if err != nil {
+					return status.Error(codes.Unavailable, err.Error())
+				}
 				return nil
 			}
 			var replyAction pb.ListenReply_Action
@@ -933,6 +1266,40 @@ func (s *Service) instanceForAction(d *db.DB, action db.Action, token thread.Tok
 	return res, nil
 }
 
+// validationStatus returns an InvalidArgument status error whose details describe
+// the violations of a schema validation error, or err if it isn't one.
+func validationStatus(err error) error {
+	var verr *db.ValidationError
+	if !errors.As(err, &verr) {
+		return err
+	}
+	details := make([]proto.Message, len(verr.Violations))
+	for i, v := range verr.Violations {
+		info := &errdetails.ErrorInfo{
+			Reason: common.SchemaViolationReason,
+			Domain: "threads.db",
+			Metadata: map[string]string{
+				"pointer":     v.Pointer,
+				"constraint":  v.Constraint,
+				"description": v.Description,
+			},
+		}
+		if v.Value != nil {
+			value, err := json.Marshal(v.Value)
+			if err != nil {
+				return err
+			}
+			info.Metadata["value"] = string(value)
+		}
+		details[i] = info
+	}
+	stat, serr := status.New(codes.InvalidArgument, err.Error()).WithDetails(details...)
+	if serr != nil {
+		return status.Error(codes.InvalidArgument, err.Error())
+	}
+	return stat.Err()
+}
+
 func (s *Service) processCreateRequest(req *pb.CreateRequest, token thread.Token, createFunc func([][]byte, ...db.TxnOption) ([]core.InstanceID, error)) (*pb.CreateReply, error) {
 	res, err := createFunc(req.Instances, db.WithTxnToken(token))
 	if err != nil {
@@ -973,21 +1340,22 @@ func (s *Service) processHasRequest(req *pb.HasRequest, token thread.Token, hasF
 	return &pb.HasReply{Exists: exists}, err
 }
 
-func (s *Service) processFindByIDRequest(req *pb.FindByIDRequest, token thread.Token, findFunc func(id core.InstanceID, opts ...db.TxnOption) ([]byte, error)) (*pb.FindByIDReply, error) {
+func (s *Service) processFindByIDRequest(req *pb.FindByIDRequest, token thread.Token, findFunc func(id core.InstanceID, opts ...db.TxnOption) ([]byte, error), opts ...db.TxnOption) (*pb.FindByIDReply, error) {
 	instanceID := core.InstanceID(req.InstanceID)
-	found, err := findFunc(instanceID, db.WithTxnToken(token))
+	found, err := findFunc(instanceID, append(opts, db.WithTxnToken(token))...)
 	return &pb.FindByIDReply{Instance: found}, err
 }
 
-func (s *Service) processFindRequest(req *pb.FindRequest, token thread.Token, findFunc func(q *db.Query, opts ...db.TxnOption) (ret [][]byte, err error)) (*pb.FindReply, error) {
+func (s *Service) processFindRequest(req *pb.FindRequest, token thread.Token, findFunc func(q *db.Query, opts ...db.TxnOption) (ret [][]byte, err error), opts ...db.TxnOption) (*pb.FindReply, error) {
 	q := &db.Query{}
 	if err := json.Unmarshal(req.QueryJSON, q); err != nil {
 		return &pb.FindReply{}, err
 	}
-	instances, err := findFunc(q, db.WithTxnToken(token))
+	instances, err := findFunc(q, append(opts, db.WithTxnToken(token))...)
 	return &pb.FindReply{Instances: instances}, err
 }
 
+// getDB returns a db of the manager, which is released with ReleaseDB.
 func (s *Service) getDB(ctx context.Context, id thread.ID, token thread.Token) (*db.DB, error) {
 	d, err := s.manager.GetDB(ctx, id, db.WithManagedToken(token))
 	if err != nil {
@@ -1000,13 +1368,17 @@ func (s *Service) getDB(ctx context.Context, id thread.ID, token thread.Token) (
 	return d, nil
 }
 
+// getCollection returns a collection of a db of the manager, which is
+// released with ReleaseDB.
 func (s *Service) getCollection(ctx context.Context, collectionName string, id thread.ID, token thread.Token) (*db.Collection, error) {
 	d, err := s.getDB(ctx, id, token)
 	if err != nil {
 		return nil, err
 	}
+	defer s.manager.ReleaseDB(id)
 	collection := d.GetCollection(collectionName)
 	if collection == nil {
+		s.manager.ReleaseDB(id)
 		return nil, status.Error(codes.NotFound, db.ErrCollectionNotFound.Error())
 	}
 	return collection, nil
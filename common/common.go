@@ -2,6 +2,7 @@ package common
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"os"
 	"path/filepath"
@@ -9,6 +10,7 @@ import (
 
 	ipfslite "github.com/hsanjuan/ipfs-lite"
 	"github.com/libp2p/go-libp2p"
+	circuit "github.com/libp2p/go-libp2p-circuit"
 	connmgr "github.com/libp2p/go-libp2p-connmgr"
 	cconnmgr "github.com/libp2p/go-libp2p-core/connmgr"
 	"github.com/libp2p/go-libp2p-core/peer"
@@ -16,6 +18,8 @@ import (
 	ma "github.com/multiformats/go-multiaddr"
 	"github.com/textileio/go-threads/core/app"
 	core "github.com/textileio/go-threads/core/logstore"
+	corenet "github.com/textileio/go-threads/core/net"
+	"github.com/textileio/go-threads/core/thread"
 	"github.com/textileio/go-threads/logstore/lstoreds"
 	"github.com/textileio/go-threads/logstore/lstorehybrid"
 	"github.com/textileio/go-threads/logstore/lstoremem"
@@ -29,6 +33,14 @@ const (
 	defaultLogstorePath = "logstore"
 )
 
+// ErrQUICUnsupported indicates the QUIC transport isn't supported by the Go
+// version of the binary, see WithNetQUICAddr.
+var ErrQUICUnsupported = errors.New("QUIC transport isn't supported by this Go version")
+
+// ErrRelayHopWithStaticRelays indicates a relay was given static relays, see
+// WithNetRelayHop and WithNetStaticRelays.
+var ErrRelayHopWithStaticRelays = errors.New("a relay can't use static relays")
+
 // DefaultNetwork is a boostrapable default Net with sane defaults.
 type NetBoostrapper interface {
 	app.Net
@@ -48,6 +60,10 @@ func DefaultNetwork(repoPath string, opts ...NetOption) (NetBoostrapper, error)
 		}
 	}
 
+	if config.RelayHop && len(config.StaticRelays) > 0 {
+		return nil, ErrRelayHopWithStaticRelays
+	}
+
 	if err := setDefaults(&config); err != nil {
 		return nil, err
 	}
@@ -73,15 +89,32 @@ func DefaultNetwork(repoPath string, opts ...NetOption) (NetBoostrapper, error)
 	fin.Add(pstore)
 
 	priv := util.LoadKey(filepath.Join(ipfsLitePath, "key"))
+	libp2pOpts := append([]libp2p.Option{
+		libp2p.Peerstore(pstore),
+		libp2p.ConnectionManager(config.ConnManager),
+	}, relayOptions(config)...)
+	transports := config.Transports
+	if config.QUICAddr != nil {
+		if quicTransport == nil {
+			return nil, fin.Cleanup(fmt.Errorf("listening on %s: %w", config.QUICAddr, ErrQUICUnsupported))
+		}
+		// QUIC isn't a default libp2p transport
+		transports = append([]interface{}{quicTransport}, transports...)
+	}
+	if len(transports) > 0 {
+		// Extend the default transports instead of replacing them
+		libp2pOpts = append(libp2pOpts, libp2p.DefaultTransports)
+		for _, t := range transports {
+			libp2pOpts = append(libp2pOpts, libp2p.Transport(t))
+		}
+	}
 	h, d, err := ipfslite.SetupLibp2p(
 		ctx,
 		priv,
 		nil,
-		[]ma.Multiaddr{config.HostAddr},
+		listenAddrs(config),
 		litestore,
-		libp2p.Peerstore(pstore),
-		libp2p.ConnectionManager(config.ConnManager),
-		libp2p.DisableRelay(),
+		libp2pOpts...,
 	)
 	if err != nil {
 		return nil, fin.Cleanup(err)
@@ -99,8 +132,9 @@ func DefaultNetwork(repoPath string, opts ...NetOption) (NetBoostrapper, error)
 
 	// Build a network
 	api, err := net.NewNetwork(ctx, h, lite.BlockStore(), lite, tstore, net.Config{
-		Debug:  config.Debug,
-		PubSub: config.PubSub,
+		Debug:             config.Debug,
+		PubSub:            config.PubSub,
+		HeartbeatInterval: config.HeartbeatInterval,
 	}, config.GRPCServerOptions, config.GRPCDialOptions)
 	if err != nil {
 		return nil, fin.Cleanup(err)
@@ -114,6 +148,36 @@ func DefaultNetwork(repoPath string, opts ...NetOption) (NetBoostrapper, error)
 	}, nil
 }
 
+// relayOptions returns the libp2p options of the circuit relay config.
+func relayOptions(config NetConfig) []libp2p.Option {
+	switch {
+	case config.RelayHop:
+		return []libp2p.Option{libp2p.EnableRelay(circuit.OptHop)}
+	case len(config.StaticRelays) > 0:
+		return []libp2p.Option{
+			libp2p.EnableRelay(),
+			libp2p.EnableAutoRelay(),
+			libp2p.StaticRelays(config.StaticRelays),
+		}
+	case config.Relay:
+		return []libp2p.Option{libp2p.EnableRelay()}
+	default:
+		return []libp2p.Option{libp2p.DisableRelay()}
+	}
+}
+
+// listenAddrs returns the addresses the host listens on.
+func listenAddrs(config NetConfig) []ma.Multiaddr {
+	addrs := []ma.Multiaddr{config.HostAddr}
+	if config.QUICAddr != nil {
+		addrs = append(addrs, config.QUICAddr)
+	}
+	if config.WebSocketAddr != nil {
+		addrs = append(addrs, config.WebSocketAddr)
+	}
+	return addrs
+}
+
 func buildLogstore(ctx context.Context, lstype LogstoreType, repoPath string, fin *util.Finalizer) (core.Logstore, error) {
 	switch lstype {
 	case LogstoreInMemory:
@@ -180,11 +244,18 @@ const (
 
 type NetConfig struct {
 	HostAddr          ma.Multiaddr
+	QUICAddr          ma.Multiaddr
+	WebSocketAddr     ma.Multiaddr
+	Transports        []interface{}
+	Relay             bool
+	RelayHop          bool
+	StaticRelays      []peer.AddrInfo
 	ConnManager       cconnmgr.ConnManager
 	GRPCServerOptions []grpc.ServerOption
 	GRPCDialOptions   []grpc.DialOption
 	LSType            LogstoreType
 	PubSub            bool
+	HeartbeatInterval time.Duration
 	Debug             bool
 }
 
@@ -197,6 +268,82 @@ func WithNetHostAddr(addr ma.Multiaddr) NetOption {
 	}
 }
 
+// WithNetQUICAddr makes the host also listen on a QUIC address, e.g.,
+// /ip4/0.0.0.0/udp/4006/quic, for faster connection setup and better NAT
+// traversal than TCP. The QUIC transport of go-libp2p-quic-transport is added
+// to the transports of the host, so it must not be added again with
+// WithNetTransports. The transport only supports Go 1.14, binaries built with
+// newer versions fail to listen on QUIC with ErrQUICUnsupported.
+func WithNetQUICAddr(addr ma.Multiaddr) NetOption {
+	return func(c *NetConfig) error {
+		if _, err := addr.ValueForProtocol(ma.P_QUIC); err != nil {
+			return fmt.Errorf("invalid QUIC address %s: %w", addr, err)
+		}
+		c.QUICAddr = addr
+		return nil
+	}
+}
+
+// WithNetWebSocketAddr makes the host also listen on a WebSocket address,
+// e.g., /ip4/0.0.0.0/tcp/4007/ws, so peers in browsers, e.g., js-threads, can
+// dial it directly without a relay. Browsers on secure pages can only dial
+// secure WebSockets, which must be served by a TLS terminating proxy in front
+// of the address. WebTransport listeners aren't supported yet, they need a
+// go-libp2p with a WebTransport transport, and a go-multiaddr with its
+// protocol, which are newer than the ones in use.
+func WithNetWebSocketAddr(addr ma.Multiaddr) NetOption {
+	return func(c *NetConfig) error {
+		if _, err := addr.ValueForProtocol(ma.P_WS); err != nil {
+			return fmt.Errorf("invalid WebSocket address %s: %w", addr, err)
+		}
+		c.WebSocketAddr = addr
+		return nil
+	}
+}
+
+// WithNetRelay enables the circuit relay transport of the host, so it can dial
+// and accept connections relayed by other peers, e.g., to exchange records
+// with peers behind NATs. Disabled by default.
+func WithNetRelay(enabled bool) NetOption {
+	return func(c *NetConfig) error {
+		c.Relay = enabled
+		return nil
+	}
+}
+
+// WithNetRelayHop makes the host act as a relay, i.e., relay connections
+// between other peers connected to it. It enables the relay transport.
+// It can't be used with static relays, see WithNetStaticRelays. The relay is
+// a circuit relay v1, which can't be limited per relay, so it relays as many
+// connections as the libp2p default allows. Circuit relay v2 reservations and
+// their limits need a libp2p upgrade, and are left to a follow-up.
+func WithNetRelayHop(enabled bool) NetOption {
+	return func(c *NetConfig) error {
+		c.RelayHop = enabled
+		return nil
+	}
+}
+
+// WithNetStaticRelays makes the host use relays, e.g., when it's behind a NAT.
+// Once the host detects it isn't publicly reachable, it connects to some of
+// the relays, and advertises addresses relayed by them. It enables the relay
+// transport, and can't be used by relays, see WithNetRelayHop.
+func WithNetStaticRelays(relays ...peer.AddrInfo) NetOption {
+	return func(c *NetConfig) error {
+		c.StaticRelays = append(c.StaticRelays, relays...)
+		return nil
+	}
+}
+
+// WithNetTransports adds libp2p transport constructors to the default TCP and
+// WebSocket transports of the host, see libp2p.Transport.
+func WithNetTransports(constructors ...interface{}) NetOption {
+	return func(c *NetConfig) error {
+		c.Transports = append(c.Transports, constructors...)
+		return nil
+	}
+}
+
 func WithConnectionManager(cm cconnmgr.ConnManager) NetOption {
 	return func(c *NetConfig) error {
 		c.ConnManager = cm
@@ -232,6 +379,13 @@ func WithNetPubSub(enabled bool) NetOption {
 	}
 }
 
+func WithNetHeartbeatInterval(interval time.Duration) NetOption {
+	return func(c *NetConfig) error {
+		c.HeartbeatInterval = interval
+		return nil
+	}
+}
+
 func WithNetLogstore(lt LogstoreType) NetOption {
 	return func(c *NetConfig) error {
 		c.LSType = lt
@@ -245,7 +399,10 @@ type netBoostrapper struct {
 	finalizer *util.Finalizer
 }
 
-var _ NetBoostrapper = (*netBoostrapper)(nil)
+var (
+	_ NetBoostrapper         = (*netBoostrapper)(nil)
+	_ corenet.ReadKeyRotator = (*netBoostrapper)(nil)
+)
 
 func (tsb *netBoostrapper) Bootstrap(addrs []peer.AddrInfo) {
 	tsb.litepeer.Bootstrap(addrs)
@@ -255,6 +412,10 @@ func (tsb *netBoostrapper) GetIpfsLite() *ipfslite.Peer {
 	return tsb.litepeer
 }
 
+func (tsb *netBoostrapper) RotateReadKey(ctx context.Context, id thread.ID, opts ...corenet.ThreadOption) (thread.Key, error) {
+	return tsb.Net.(corenet.ReadKeyRotator).RotateReadKey(ctx, id, opts...)
+}
+
 func (tsb *netBoostrapper) Close() error {
 	return tsb.finalizer.Cleanup(nil)
 }
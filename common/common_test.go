@@ -0,0 +1,84 @@
+package common
+
+import (
+	"context"
+	"errors"
+	"io/ioutil"
+	"os"
+	"testing"
+
+	"github.com/libp2p/go-libp2p-core/peer"
+	ma "github.com/multiformats/go-multiaddr"
+	"github.com/textileio/go-threads/util"
+)
+
+func TestDefaultNetwork_QUIC(t *testing.T) {
+	t.Parallel()
+	if quicTransport == nil {
+		dir, err := ioutil.TempDir("", "")
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer os.RemoveAll(dir)
+		_, err = DefaultNetwork(dir, WithNetHostAddr(util.FreeLocalAddr()), WithNetQUICAddr(ma.StringCast("/ip4/127.0.0.1/udp/0/quic")))
+		if !errors.Is(err, ErrQUICUnsupported) {
+			t.Fatalf("expected QUIC unsupported error, got %v", err)
+		}
+		t.Skip("QUIC transport isn't supported by this Go version")
+	}
+	n1, clean1 := createTestNetwork(t, WithNetQUICAddr(ma.StringCast("/ip4/127.0.0.1/udp/0/quic")))
+	defer clean1()
+	var addrs []ma.Multiaddr
+	for _, addr := range n1.Host().Network().ListenAddresses() {
+		if _, err := addr.ValueForProtocol(ma.P_QUIC); err == nil {
+			addrs = append(addrs, addr)
+		}
+	}
+	if len(addrs) == 0 {
+		t.Fatalf("expected a QUIC listen address, got %v", n1.Host().Network().ListenAddresses())
+	}
+
+	// Peers connect to the host over QUIC only
+	n2, clean2 := createTestNetwork(t, WithNetQUICAddr(ma.StringCast("/ip4/127.0.0.1/udp/0/quic")))
+	defer clean2()
+	if err := n2.Host().Connect(context.Background(), peer.AddrInfo{ID: n1.Host().ID(), Addrs: addrs}); err != nil {
+		t.Fatalf("connecting over QUIC: %v", err)
+	}
+}
+
+func TestDefaultNetwork_RelayHopWithStaticRelays(t *testing.T) {
+	t.Parallel()
+	relay, clean := createTestNetwork(t, WithNetRelayHop(true))
+	defer clean()
+	dir, err := ioutil.TempDir("", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	_, err = DefaultNetwork(
+		dir,
+		WithNetHostAddr(util.FreeLocalAddr()),
+		WithNetRelayHop(true),
+		WithNetStaticRelays(peer.AddrInfo{ID: relay.Host().ID(), Addrs: relay.Host().Addrs()}),
+	)
+	if !errors.Is(err, ErrRelayHopWithStaticRelays) {
+		t.Fatalf("expected relay hop with static relays error, got %v", err)
+	}
+}
+
+func createTestNetwork(t *testing.T, opts ...NetOption) (NetBoostrapper, func()) {
+	dir, err := ioutil.TempDir("", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	n, err := DefaultNetwork(dir, append([]NetOption{WithNetHostAddr(util.FreeLocalAddr())}, opts...)...)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return n, func() {
+		if err := n.Close(); err != nil {
+			t.Fatal(err)
+		}
+		_ = os.RemoveAll(dir)
+	}
+}
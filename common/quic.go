@@ -0,0 +1,10 @@
+//go:build !go1.15
+// +build !go1.15
+
+package common
+
+import libp2pquic "github.com/libp2p/go-libp2p-quic-transport"
+
+// quicTransport is the constructor of the QUIC transport of the host, see
+// WithNetQUICAddr.
+var quicTransport interface{} = libp2pquic.NewTransport
@@ -0,0 +1,9 @@
+//go:build go1.15
+// +build go1.15
+
+package common
+
+// quicTransport is nil, the quic-go version of go-libp2p-quic-transport v0.7
+// panics as it's initialized by Go 1.15 and newer, and newer versions of the
+// transport need a libp2p upgrade.
+var quicTransport interface{}
@@ -10,6 +10,7 @@ import (
 	"github.com/textileio/go-threads/broadcast"
 	"github.com/textileio/go-threads/core/net"
 	"github.com/textileio/go-threads/core/thread"
+	sym "github.com/textileio/go-threads/crypto/symmetric"
 	"github.com/textileio/go-threads/util"
 )
 
@@ -167,3 +168,9 @@ func (c *Connector) ValidateNetRecordBody(ctx context.Context, body format.Node,
 func (c *Connector) HandleNetRecord(ctx context.Context, rec net.ThreadRecord) error {
 	return c.app.HandleNetRecord(ctx, rec, c.threadKey)
 }
+
+// HandleNetRecordWithReadKey calls the connection app's HandleNetRecord while supplying
+// thread key with the given read key, which may have been retired by a key rotation.
+func (c *Connector) HandleNetRecordWithReadKey(ctx context.Context, rec net.ThreadRecord, rk *sym.Key) error {
+	return c.app.HandleNetRecord(ctx, rec, thread.NewKey(c.threadKey.Service(), rk))
+}
@@ -7,6 +7,7 @@ import (
 	"github.com/ipfs/go-ipld-format"
 	ulid "github.com/oklog/ulid/v2"
 	ds "github.com/textileio/go-datastore"
+	"github.com/textileio/go-threads/hlc"
 )
 
 const (
@@ -40,6 +41,21 @@ type Event interface {
 	Marshal() ([]byte, error)
 }
 
+// HLCEvent is an Event timestamped with a hybrid logical clock.
+// HLC timestamps preserve causal ordering of events across peers, regardless of clock skew.
+type HLCEvent interface {
+	Event
+	// HLC is the hybrid logical clock timestamp of the event.
+	HLC() hlc.Timestamp
+}
+
+// MetadataEvent is an Event carrying the metadata of the transaction that created it.
+type MetadataEvent interface {
+	Event
+	// Metadata is the transaction metadata, e.g., audit context. It's not part of the instance.
+	Metadata() map[string]string
+}
+
 // ActionType is the type used by actions done in a txn.
 type ActionType int
 
@@ -64,6 +80,8 @@ type Action struct {
 	Previous []byte
 	// Current is the instance after the action was done.
 	Current []byte
+	// Metadata is the metadata of the transaction in which the action was done.
+	Metadata map[string]string
 }
 
 type ReduceAction struct {
@@ -73,6 +91,8 @@ type ReduceAction struct {
 	Collection string
 	// InstanceID of the instance in reduced action.
 	InstanceID InstanceID
+	// Metadata of the transaction in which the reduced action was done.
+	Metadata map[string]string
 }
 
 // IndexFunc handles index updates.
@@ -88,3 +108,48 @@ type EventCodec interface {
 	// EventsFromBytes deserializes a format.Node bytes payload into Events.
 	EventsFromBytes(data []byte) ([]Event, error)
 }
+
+// ConflictStrategy selects how writes to an instance reduced out of causal
+// order, e.g., concurrent writes from different peers, are reconciled.
+type ConflictStrategy int
+
+const (
+	// ConflictMerge applies the changed fields of every write, so concurrent
+	// writes to different fields are all kept.
+	ConflictMerge ConflictStrategy = iota
+	// ConflictLastWriterWins keeps the instance state of the latest write,
+	// writes older than the last one applied to an instance are dropped.
+	ConflictLastWriterWins
+	// ConflictCustom reconciles writes older than the last one applied to an
+	// instance with a ConflictResolver.
+	ConflictCustom
+)
+
+// Conflict is a write reduced after a later write to the same instance.
+type Conflict struct {
+	// Collection is the instance collection name.
+	Collection string
+	// InstanceID is the instance ID.
+	InstanceID InstanceID
+	// Current is the current instance, nil if it doesn't exist.
+	Current []byte
+	// Incoming is the instance with the write applied, nil if it's a delete.
+	Incoming []byte
+}
+
+// ConflictResolver returns the reconciled state of a conflicting write,
+// or nil to delete the instance.
+type ConflictResolver func(c Conflict) ([]byte, error)
+
+// ConflictPolicy is the conflict strategy of a collection.
+type ConflictPolicy struct {
+	Strategy ConflictStrategy
+	Resolver ConflictResolver
+}
+
+// ConflictEventCodec is an EventCodec that supports per-collection conflict policies.
+type ConflictEventCodec interface {
+	EventCodec
+	// SetConflictPolicies sets the function returning the conflict policy of a collection.
+	SetConflictPolicies(policies func(collection string) ConflictPolicy)
+}
@@ -29,6 +29,10 @@ var ErrLogExists = fmt.Errorf("log already exists")
 // ErrEmptyDump indicates an attempt to restore from empty dump.
 var ErrEmptyDump = errors.New("empty dump")
 
+// ErrKeychainUnsupported indicates a key book doesn't retain rotated read
+// keys, see KeychainBook.
+var ErrKeychainUnsupported = errors.New("key book doesn't support read key rotation")
+
 // Logstore stores log keys, addresses, heads and thread meta data.
 type Logstore interface {
 	Close() error
@@ -144,6 +148,21 @@ type KeyBook interface {
 	RestoreKeys(book DumpKeyBook) error
 }
 
+// KeychainBook is a KeyBook that retains the read keys of threads retired by
+// rotation.
+type KeychainBook interface {
+	KeyBook
+
+	// ReadKeys retrieves the read keychain of a thread, ordered from the oldest
+	// key to the current one. Keys retired by rotation are retained so that
+	// historical records can still be decrypted.
+	ReadKeys(thread.ID) ([]*sym.Key, error)
+
+	// RotateReadKey makes a key the current read key of a thread, retaining the
+	// previous read key in the keychain.
+	RotateReadKey(thread.ID, *sym.Key) error
+}
+
 // AddrBook stores log addresses.
 type AddrBook interface {
 	// AddAddr adds an address under a log with a given TTL.
@@ -229,6 +248,7 @@ type (
 			Public  map[thread.ID]map[peer.ID]crypto.PubKey
 			Private map[thread.ID]map[peer.ID]crypto.PrivKey
 			Read    map[thread.ID][]byte
+			Retired map[thread.ID][][]byte
 			Service map[thread.ID][]byte
 		}
 	}
@@ -4,6 +4,7 @@ import (
 	"bytes"
 	"context"
 	"io"
+	"time"
 
 	"github.com/ipfs/go-cid"
 	"github.com/ipfs/go-ipld-format"
@@ -52,6 +53,21 @@ type API interface {
 	// DeleteThread removes a thread by id and opts.
 	DeleteThread(ctx context.Context, id thread.ID, opts ...ThreadOption) error
 
+	// PauseSync stops pulling and pushing records of a thread by id until ResumeSync is called.
+	// The peers the thread is connected to are persisted, so they can be reconnected on resume.
+	// Peers can still pull records from the paused thread.
+	PauseSync(ctx context.Context, id thread.ID, opts ...ThreadOption) error
+
+	// ResumeSync resumes syncing a paused thread by id. Peers that were connected when the thread
+	// was paused are redialed, with backoff for unreachable ones, before the thread is pulled.
+	// The returned reconnects describe the dial attempts made to each peer.
+	ResumeSync(ctx context.Context, id thread.ID, opts ...ThreadOption) ([]Reconnect, error)
+
+	// Heartbeats returns the time of the last heartbeat received from each peer of a thread by id.
+	// Heartbeats are published over pubsub by peers with a heartbeat interval, and are not written
+	// to thread logs. The result is empty if pubsub is disabled.
+	Heartbeats(ctx context.Context, id thread.ID, opts ...ThreadOption) (map[peer.ID]time.Time, error)
+
 	// AddReplicator replicates a thread by id on a different host.
 	// All logs and records are pushed to the new host.
 	AddReplicator(ctx context.Context, id thread.ID, paddr ma.Multiaddr, opts ...ThreadOption) (peer.ID, error)
@@ -69,6 +85,22 @@ type API interface {
 	Subscribe(ctx context.Context, opts ...SubOption) (<-chan ThreadRecord, error)
 }
 
+// ReadKeyRotator is an API that rotates the read keys of threads. The network returned by
+// NewNetwork implements it.
+type ReadKeyRotator interface {
+	API
+
+	// RotateReadKey replaces the read key of a thread by id with a new random key, or the key
+	// provided with WithThreadReadKey, which is used to encrypt new records. Previous read keys
+	// are retained in an ordered keychain, so historical records can still be decrypted.
+	// The returned thread key includes the new read key.
+	// Read keys are never sent over the network. Other peers keep syncing records encrypted with
+	// the previous keys, but records encrypted with the new key are rejected until they learn it.
+	// The new read key must be shared with them out of band, and adopted by calling RotateReadKey
+	// with WithThreadReadKey. Rejected records are fetched again on the next pull.
+	RotateReadKey(ctx context.Context, id thread.ID, opts ...ThreadOption) (thread.Key, error)
+}
+
 // Token is used to restrict network APIs to a single app.App.
 // In other words, a net token protects against writes and deletes
 // which are external to an app.
@@ -78,3 +110,13 @@ type Token []byte
 func (t Token) Equal(b Token) bool {
 	return bytes.Equal(t, b)
 }
+
+// Reconnect describes the attempts to reconnect to a thread peer when resuming sync.
+type Reconnect struct {
+	// PeerID is the peer the thread was connected to when it was paused.
+	PeerID peer.ID
+	// Attempts is the number of dials made to the peer.
+	Attempts int
+	// Err is the last dial error, or nil if the peer was reconnected.
+	Err error
+}
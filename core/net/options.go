@@ -3,6 +3,7 @@ package net
 import (
 	"github.com/libp2p/go-libp2p-core/crypto"
 	"github.com/textileio/go-threads/core/thread"
+	sym "github.com/textileio/go-threads/crypto/symmetric"
 )
 
 // NewThreadOptions defines options to be used when creating / adding a thread.
@@ -43,6 +44,7 @@ func WithNewThreadToken(t thread.Token) NewThreadOption {
 type ThreadOptions struct {
 	Token    thread.Token
 	APIToken Token
+	ReadKey  *sym.Key
 }
 
 // ThreadOption specifies thread options.
@@ -65,6 +67,14 @@ func WithAPIToken(t Token) ThreadOption {
 	}
 }
 
+// WithThreadReadKey provides the read key to use when rotating a thread read key.
+// This is used to adopt a key rotated by another peer.
+func WithThreadReadKey(rk *sym.Key) ThreadOption {
+	return func(args *ThreadOptions) {
+		args.ReadKey = rk
+	}
+}
+
 // SubOptions defines options for a thread subscription.
 type SubOptions struct {
 	ThreadIDs thread.IDSlice
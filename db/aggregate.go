@@ -0,0 +1,216 @@
+package db
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/tidwall/gjson"
+)
+
+// ErrInvalidAggregation indicates an aggregation is malformed, or can't be
+// computed over the values of the instances.
+var ErrInvalidAggregation = errors.New("invalid aggregation")
+
+// AccumulatorOp models the operations of accumulators.
+type AccumulatorOp int
+
+const (
+	// AccCount counts the instances of a group.
+	AccCount AccumulatorOp = iota
+	// AccSum sums the numeric values of a field.
+	AccSum
+	// AccMin is the minimum value of a field.
+	AccMin
+	// AccMax is the maximum value of a field.
+	AccMax
+	// AccAvg averages the numeric values of a field.
+	AccAvg
+)
+
+// Accumulator computes a named value over the instances of a group.
+type Accumulator struct {
+	Name      string
+	Op        AccumulatorOp
+	FieldPath string
+}
+
+// Count counts the instances of a group.
+func Count(name string) Accumulator {
+	return Accumulator{Name: name, Op: AccCount}
+}
+
+// Sum sums the numeric values of field in a group.
+// Instances with a missing or non-numeric value are ignored.
+func Sum(name, field string) Accumulator {
+	return Accumulator{Name: name, Op: AccSum, FieldPath: field}
+}
+
+// Min is the minimum value of field in a group, either a number or a string.
+// Instances with a missing value are ignored.
+func Min(name, field string) Accumulator {
+	return Accumulator{Name: name, Op: AccMin, FieldPath: field}
+}
+
+// Max is the maximum value of field in a group, either a number or a string.
+// Instances with a missing value are ignored.
+func Max(name, field string) Accumulator {
+	return Accumulator{Name: name, Op: AccMax, FieldPath: field}
+}
+
+// Avg averages the numeric values of field in a group.
+// Instances with a missing or non-numeric value are ignored.
+func Avg(name, field string) Accumulator {
+	return Accumulator{Name: name, Op: AccAvg, FieldPath: field}
+}
+
+// Aggregation groups query results by a field and computes accumulators over
+// each group. Without a GroupBy field, all results fall in a single group.
+type Aggregation struct {
+	GroupBy      string
+	Accumulators []Accumulator
+}
+
+// Group is a group of an aggregation result. Key is the value of the GroupBy
+// field, or nil for instances missing it. Values maps accumulator names to
+// their values, which are nil if a group had no values to accumulate.
+type Group struct {
+	Key    interface{}
+	Values map[string]interface{}
+}
+
+// Validate validates an aggregation.
+func (a Aggregation) Validate() error {
+	if len(a.Accumulators) == 0 {
+		return fmt.Errorf("%w: no accumulators", ErrInvalidAggregation)
+	}
+	names := make(map[string]struct{}, len(a.Accumulators))
+	for _, acc := range a.Accumulators {
+		if acc.Name == "" {
+			return fmt.Errorf("%w: accumulators must be named", ErrInvalidAggregation)
+		}
+		if _, ok := names[acc.Name]; ok {
+			return fmt.Errorf("%w: duplicate accumulator %s", ErrInvalidAggregation, acc.Name)
+		}
+		names[acc.Name] = struct{}{}
+		if acc.Op < AccCount || acc.Op > AccAvg {
+			return fmt.Errorf("%w: unknown accumulator operation %d", ErrInvalidAggregation, acc.Op)
+		}
+		if acc.Op != AccCount && acc.FieldPath == "" {
+			return fmt.Errorf("%w: accumulator %s needs a field", ErrInvalidAggregation, acc.Name)
+		}
+	}
+	return nil
+}
+
+// accumulation is the running state of an accumulator in a group.
+type accumulation struct {
+	count int
+	sum   float64
+	value interface{}
+}
+
+// Aggregate computes an aggregation over the results of q. Groups are in
+// order of their first instance in the query results.
+func (t *Txn) Aggregate(q *Query, a Aggregation) ([]Group, error) {
+	if err := a.Validate(); err != nil {
+		return nil, err
+	}
+	res, err := t.Find(q)
+	if err != nil {
+		return nil, err
+	}
+
+	type group struct {
+		key  interface{}
+		accs []accumulation
+	}
+	var groups []*group
+	index := make(map[string]*group)
+	if a.GroupBy == "" {
+		g := &group{accs: make([]accumulation, len(a.Accumulators))}
+		groups = append(groups, g)
+		index[""] = g
+	}
+	for _, v := range res {
+		var gk string
+		var key gjson.Result
+		if a.GroupBy != "" {
+			key = gjson.GetBytes(v, a.GroupBy)
+			// Group keys of different types don't collide, e.g., "1" and 1
+			gk = key.Type.String() + ":" + key.Raw
+		}
+		g, ok := index[gk]
+		if !ok {
+			g = &group{key: key.Value(), accs: make([]accumulation, len(a.Accumulators))}
+			groups = append(groups, g)
+			index[gk] = g
+		}
+		for i, acc := range a.Accumulators {
+			if err := g.accs[i].add(acc, v); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	out := make([]Group, len(groups))
+	for i, g := range groups {
+		out[i] = Group{Key: g.key, Values: make(map[string]interface{}, len(a.Accumulators))}
+		for j, acc := range a.Accumulators {
+			out[i].Values[acc.Name] = g.accs[j].result(acc)
+		}
+	}
+	return out, nil
+}
+
+// add accumulates the instance v.
+func (s *accumulation) add(acc Accumulator, v []byte) error {
+	if acc.Op == AccCount {
+		s.count++
+		return nil
+	}
+	r := gjson.GetBytes(v, acc.FieldPath)
+	switch acc.Op {
+	case AccSum, AccAvg:
+		if r.Type == gjson.Number {
+			s.count++
+			s.sum += r.Float()
+		}
+	case AccMin, AccMax:
+		if !r.Exists() || r.Type == gjson.Null {
+			return nil
+		}
+		if r.Type != gjson.Number && r.Type != gjson.String {
+			return fmt.Errorf("%w: %s of %s must be a number or a string", ErrInvalidAggregation, acc.Name, acc.FieldPath)
+		}
+		val := r.Value()
+		if s.value == nil {
+			s.value = val
+			return nil
+		}
+		res, err := compare(val, s.value)
+		if err != nil {
+			return fmt.Errorf("%w: %s of %s has mixed types", ErrInvalidAggregation, acc.Name, acc.FieldPath)
+		}
+		if (acc.Op == AccMin && res < 0) || (acc.Op == AccMax && res > 0) {
+			s.value = val
+		}
+	}
+	return nil
+}
+
+// result returns the accumulated value.
+func (s *accumulation) result(acc Accumulator) interface{} {
+	switch acc.Op {
+	case AccCount:
+		return s.count
+	case AccSum:
+		return s.sum
+	case AccAvg:
+		if s.count == 0 {
+			return nil
+		}
+		return s.sum / float64(s.count)
+	default:
+		return s.value
+	}
+}
@@ -0,0 +1,281 @@
+package db
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+
+	"github.com/alecthomas/jsonschema"
+	ds "github.com/textileio/go-datastore"
+	core "github.com/textileio/go-threads/core/db"
+	"github.com/textileio/go-threads/core/thread"
+)
+
+// ErrInvalidArchive indicates a db archive is malformed or of an unknown version.
+var ErrInvalidArchive = errors.New("invalid db archive")
+
+const archiveVersion = 1
+
+// dbArchive is the header of a db archive, which is followed by the
+// archived instances of its collections, in the order of the collections.
+type dbArchive struct {
+	Version     int                  `json:"version"`
+	ID          string               `json:"id"`
+	Key         string               `json:"key,omitempty"`
+	Name        string               `json:"name,omitempty"`
+	Description string               `json:"description,omitempty"`
+	Labels      map[string]string    `json:"labels,omitempty"`
+	Collections []archivedCollection `json:"collections"`
+}
+
+// archivedCollection is the persistable part of a collection config.
+type archivedCollection struct {
+	Name             string                `json:"name"`
+	Schema           json.RawMessage       `json:"schema"`
+	Indexes          []Index               `json:"indexes,omitempty"`
+	WriteValidator   string                `json:"writeValidator,omitempty"`
+	ReadFilter       string                `json:"readFilter,omitempty"`
+	DefaultOrderBy   Sort                  `json:"defaultOrderBy"`
+	Version          int                   `json:"version,omitempty"`
+	TTLField         string                `json:"ttlField,omitempty"`
+	ConflictStrategy core.ConflictStrategy `json:"conflictStrategy,omitempty"`
+	References       []Reference           `json:"references,omitempty"`
+	EncryptedFields  []string              `json:"encryptedFields,omitempty"`
+	IDStrategy       IDStrategy            `json:"idStrategy,omitempty"`
+	IDSequence       string                `json:"idSequence,omitempty"`
+	ComputedFields   []ComputedField       `json:"computedFields,omitempty"`
+	SerializeWrites  bool                  `json:"serializeWrites,omitempty"`
+	Instances        int                   `json:"instances"`
+}
+
+// archivedInstance is an instance of a db archive.
+type archivedInstance struct {
+	Collection string          `json:"collection"`
+	Instance   json.RawMessage `json:"instance"`
+}
+
+// ExportDB writes an archive of a managed db to w, e.g., to back it up or to
+// restore it on another node with ImportDB. The archive is a stream of JSON
+// values holding the db collections and a consistent snapshot of their
+// instances. The thread key is only included with WithExportThreadKey.
+// Encrypted fields are archived decrypted, so the archive should be handled
+// with care. Migrations, conflict resolvers, ID generators, and computed
+// field functions aren't persisted, so they aren't archived either.
+func (m *Manager) ExportDB(ctx context.Context, id thread.ID, w io.Writer, opts ...ExportOption) error {
+	args := &ExportOptions{}
+	for _, opt := range opts {
+		opt(args)
+	}
+	d, err := m.GetDB(ctx, id, WithManagedToken(args.Token))
+	if err != nil {
+		return err
+	}
+	defer m.ReleaseDB(id)
+	return d.export(w, args)
+}
+
+// export writes an archive of the db to w.
+func (d *DB) export(w io.Writer, args *ExportOptions) error {
+	if err := d.connector.Validate(args.Token, true); err != nil {
+		return err
+	}
+	md := d.getMetadata()
+	archive := dbArchive{
+		Version:     archiveVersion,
+		ID:          d.connector.ThreadID().String(),
+		Name:        md.Name,
+		Description: md.Description,
+		Labels:      md.Labels,
+	}
+	if args.ThreadKey {
+		info, err := d.GetDBInfo(WithToken(args.Token))
+		if err != nil {
+			return err
+		}
+		archive.Key = info.Key.String()
+	}
+
+	// Collections and instances are read from a snapshot, so writes aren't
+	// blocked while the archive is written
+	d.txnlock.RLock()
+	snapshot, err := d.datastore.NewTransaction(true)
+	if err != nil {
+		d.txnlock.RUnlock()
+		return err
+	}
+	defer snapshot.Discard()
+	configs, err := d.collectionConfigs(args.Token)
+	d.txnlock.RUnlock()
+	if err != nil {
+		return err
+	}
+
+	instances := make([][][]byte, len(configs))
+	for i, config := range configs {
+		c, err := d.getCollection(config.Name)
+		if err != nil {
+			return err
+		}
+		if instances[i], err = c.storedInstances(snapshot); err != nil {
+			return err
+		}
+		schema, err := json.Marshal(config.Schema)
+		if err != nil {
+			return err
+		}
+		ac := archivedCollection{
+			Name:             config.Name,
+			Schema:           schema,
+			Indexes:          config.Indexes,
+			WriteValidator:   config.WriteValidator,
+			ReadFilter:       config.ReadFilter,
+			DefaultOrderBy:   config.DefaultOrderBy,
+			Version:          config.Version,
+			TTLField:         config.TTLField,
+			ConflictStrategy: config.ConflictStrategy,
+			References:       config.References,
+			EncryptedFields:  config.EncryptedFields,
+			IDStrategy:       config.IDStrategy,
+			SerializeWrites:  config.SerializeWrites,
+			Instances:        len(instances[i]),
+		}
+		for _, f := range config.ComputedFields {
+			if f.JS != "" {
+				ac.ComputedFields = append(ac.ComputedFields, ComputedField{Path: f.Path, JS: f.JS})
+			}
+		}
+		seq, err := snapshot.Get(dsIDSequences.ChildString(config.Name))
+		if err == nil {
+			ac.IDSequence = string(seq)
+		} else if !errors.Is(err, ds.ErrNotFound) {
+			return err
+		}
+		archive.Collections = append(archive.Collections, ac)
+	}
+
+	enc := json.NewEncoder(w)
+	if err := enc.Encode(archive); err != nil {
+		return err
+	}
+	for i, config := range configs {
+		for _, v := range instances[i] {
+			if err := enc.Encode(archivedInstance{Collection: config.Name, Instance: v}); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// ImportDB restores a managed db from an archive written by ExportDB, under
+// the thread ID of the archived db. The thread key of the archive is used,
+// if it includes one, unless a key is given with WithNewManagedThreadKey.
+// The archived name, description and labels can be overridden with opts.
+// Instances are written with new events, and encrypted fields are encrypted
+// with new keys.
+func (m *Manager) ImportDB(ctx context.Context, r io.Reader, opts ...NewManagedOption) (*DB, error) {
+	dec := json.NewDecoder(r)
+	var archive dbArchive
+	if err := dec.Decode(&archive); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidArchive, err)
+	}
+	if archive.Version != archiveVersion {
+		return nil, fmt.Errorf("%w: unknown version %d", ErrInvalidArchive, archive.Version)
+	}
+	id, err := thread.Decode(archive.ID)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidArchive, err)
+	}
+	defaults := []NewManagedOption{
+		WithNewManagedName(archive.Name),
+		WithNewManagedDescription(archive.Description),
+		WithNewManagedLabels(archive.Labels),
+	}
+	if archive.Key != "" {
+		key, err := thread.KeyFromString(archive.Key)
+		if err != nil {
+			return nil, fmt.Errorf("%w: %v", ErrInvalidArchive, err)
+		}
+		defaults = append(defaults, WithNewManagedThreadKey(key))
+	}
+	opts = append(defaults, opts...)
+	args := &NewManagedOptions{}
+	for _, opt := range opts {
+		opt(args)
+	}
+
+	d, err := m.NewDB(ctx, id, opts...)
+	if err != nil {
+		return nil, err
+	}
+	if err := d.restore(dec, archive, args.Token); err != nil {
+		// Don't leave a partial restore behind
+		if err := m.DeleteDB(ctx, id, WithManagedToken(args.Token)); err != nil {
+			log.Errorf("error deleting partial restore of %s: %v", id, err)
+		}
+		return nil, fmt.Errorf("restoring db %s: %w", id, err)
+	}
+	return d, nil
+}
+
+// restore creates the collections of the archive in the db, and then its
+// instances, read from dec.
+func (d *DB) restore(dec *json.Decoder, archive dbArchive, token thread.Token) error {
+	for _, ac := range archive.Collections {
+		schema := &jsonschema.Schema{}
+		if err := json.Unmarshal(ac.Schema, schema); err != nil {
+			return fmt.Errorf("%w: %v", ErrInvalidArchive, err)
+		}
+		config := CollectionConfig{
+			Name:             ac.Name,
+			Schema:           schema,
+			Indexes:          ac.Indexes,
+			WriteValidator:   ac.WriteValidator,
+			ReadFilter:       ac.ReadFilter,
+			DefaultOrderBy:   ac.DefaultOrderBy,
+			Version:          ac.Version,
+			TTLField:         ac.TTLField,
+			ConflictStrategy: ac.ConflictStrategy,
+			References:       ac.References,
+			EncryptedFields:  ac.EncryptedFields,
+			IDStrategy:       ac.IDStrategy,
+			ComputedFields:   ac.ComputedFields,
+			SerializeWrites:  ac.SerializeWrites,
+		}
+		if config.IDStrategy == IDCustom {
+			// Generators aren't archived, so IDs are ULIDs until one is registered
+			config.IDStrategy = IDULID
+		}
+		if _, err := d.NewCollection(config, WithToken(token)); err != nil {
+			return err
+		}
+		if ac.IDSequence != "" {
+			if _, err := strconv.ParseUint(ac.IDSequence, 10, 64); err != nil {
+				return fmt.Errorf("%w: %v", ErrInvalidArchive, err)
+			}
+			if err := d.datastore.Put(dsIDSequences.ChildString(ac.Name), []byte(ac.IDSequence)); err != nil {
+				return err
+			}
+		}
+	}
+	for _, ac := range archive.Collections {
+		instances := make([][]byte, 0, ac.Instances)
+		for i := 0; i < ac.Instances; i++ {
+			var ai archivedInstance
+			if err := dec.Decode(&ai); err != nil {
+				return fmt.Errorf("%w: %v", ErrInvalidArchive, err)
+			}
+			if ai.Collection != ac.Name {
+				return fmt.Errorf("%w: unexpected instance of %s", ErrInvalidArchive, ai.Collection)
+			}
+			instances = append(instances, ai.Instance)
+		}
+		if err := d.createInstances(ac.Name, instances, token); err != nil {
+			return err
+		}
+	}
+	return nil
+}
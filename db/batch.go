@@ -0,0 +1,160 @@
+package db
+
+import (
+	"errors"
+	"fmt"
+
+	core "github.com/textileio/go-threads/core/db"
+)
+
+// ErrBatchTooLarge indicates that a batch exceeds the db batch limit and
+// the db was created with WithNewStrictBatchLimit.
+var ErrBatchTooLarge = errors.New("batch exceeds the maximum batch size")
+
+// BatchProgressFunc is called after each sub-batch of a batch operation is
+// committed with the number of items done so far and the total.
+type BatchProgressFunc func(done, total int)
+
+// batchItemFunc applies a single batch item in txn. It returns the
+// instance ID of the item and whether or not the item had an effect.
+type batchItemFunc func(txn *Txn, i int) (id core.InstanceID, ok bool, err error)
+
+// BatchResult is the result of a batch item, see WithTxnContinueOnError.
+type BatchResult struct {
+	// ID is the instance ID of the item, if known.
+	ID core.InstanceID
+	// Err is the item error, or nil if the item was written.
+	Err error
+}
+
+// PartialBatchError is returned by batch operations with
+// WithTxnContinueOnError when some of the items failed.
+type PartialBatchError struct {
+	// Results are the results of the batch items in input order.
+	Results []BatchResult
+}
+
+func (e *PartialBatchError) Error() string {
+	var failed int
+	var first error
+	for _, r := range e.Results {
+		if r.Err != nil {
+			if first == nil {
+				first = r.Err
+			}
+			failed++
+		}
+	}
+	return fmt.Sprintf("%d of %d batch items failed, first: %s", failed, len(e.Results), first)
+}
+
+// Unwrap returns the error of the first failed item.
+func (e *PartialBatchError) Unwrap() error {
+	for _, r := range e.Results {
+		if r.Err != nil {
+			return r.Err
+		}
+	}
+	return nil
+}
+
+type batchBounds struct {
+	start, end int
+}
+
+// splitBatch splits items of the given sizes into sub-batches that are
+// within the db batch limit, and have at most chunkSize items if positive.
+// An item larger than the byte limit gets its own sub-batch.
+func (d *DB) splitBatch(sizes []int, chunkSize int) ([]batchBounds, error) {
+	if d.maxBatchEntries <= 0 && d.maxBatchBytes <= 0 && chunkSize <= 0 {
+		return []batchBounds{{0, len(sizes)}}, nil
+	}
+	var bounds []batchBounds
+	var b batchBounds
+	var bytes int
+	for i, size := range sizes {
+		full := (d.maxBatchEntries > 0 && i-b.start >= d.maxBatchEntries) ||
+			(d.maxBatchBytes > 0 && i > b.start && bytes+size > d.maxBatchBytes)
+		if full && d.strictBatchLimit {
+			return nil, ErrBatchTooLarge
+		}
+		if full || (chunkSize > 0 && i-b.start >= chunkSize) {
+			b.end = i
+			bounds = append(bounds, b)
+			b = batchBounds{start: i}
+			bytes = 0
+		}
+		bytes += size
+	}
+	if d.strictBatchLimit && d.maxBatchBytes > 0 && bytes > d.maxBatchBytes {
+		return nil, ErrBatchTooLarge
+	}
+	b.end = len(sizes)
+	return append(bounds, b), nil
+}
+
+// writeBatch applies fn to each of the batch items in one or more write
+// transactions, as split by the db batch limit and the chunk size option. The IDs of the items that
+// had an effect are returned in input order. If an item fails, the IDs of
+// the items committed by previous sub-batches are returned along with a
+// *BatchError identifying it. With WithTxnContinueOnError, failed items are
+// skipped instead, and reported with a *PartialBatchError.
+func (c *Collection) writeBatch(sizes []int, fn batchItemFunc, opts ...TxnOption) ([]core.InstanceID, error) {
+	args := &TxnOptions{}
+	for _, opt := range opts {
+		opt(args)
+	}
+	bounds, err := c.db.splitBatch(sizes, args.ChunkSize)
+	if err != nil {
+		return nil, err
+	}
+	ids := make([]core.InstanceID, 0, len(sizes))
+	var results []BatchResult
+	if args.ContinueOnError {
+		results = make([]BatchResult, len(sizes))
+	}
+	var failed bool
+	for _, b := range bounds {
+		var committed []core.InstanceID
+		err := c.WriteTxn(func(txn *Txn) error {
+			committed = committed[:0]
+			for i := b.start; i < b.end; i++ {
+				id, ok, err := fn(txn, i)
+				if results != nil {
+					results[i] = BatchResult{ID: id, Err: err}
+				}
+				if err != nil {
+					if results != nil {
+						failed = true
+						continue
+					}
+					return &BatchError{Index: i, ID: id, Err: err}
+				}
+				if ok {
+					committed = append(committed, id)
+				}
+			}
+			return nil
+		}, opts...)
+		if err != nil {
+			return ids, err
+		}
+		ids = append(ids, committed...)
+		if args.BatchProgress != nil {
+			args.BatchProgress(b.end, len(sizes))
+		}
+	}
+	if failed {
+		return ids, &PartialBatchError{Results: results}
+	}
+	return ids, nil
+}
+
+// instanceSizes returns the sizes of the given instances.
+func instanceSizes(vs [][]byte) []int {
+	sizes := make([]int, len(vs))
+	for i := range vs {
+		sizes[i] = len(vs[i])
+	}
+	return sizes
+}
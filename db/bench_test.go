@@ -249,3 +249,36 @@ func BenchmarkIndexFind(b *testing.B) {
 		}
 	}
 }
+
+func BenchmarkLimitFind(b *testing.B) {
+	db, clean := createBenchDB(b)
+	defer clean()
+	collection, err := db.NewCollection(CollectionConfig{Name: "Dog", Schema: util.SchemaFromSchemaString(testBenchSchema)})
+	checkBenchErr(b, err)
+
+	for j := 0; j < 10; j++ {
+		for i := 0; i < nameSize; i++ {
+			var benchItem = []byte(`{"_id": "", "Name": "Name", "Age": 7}`)
+			newItem, err := sjson.SetBytes(benchItem, "Name", fmt.Sprintf("Name%d", j))
+			if err != nil {
+				b.Fatalf("Error modifying instance: %s", err)
+			}
+			_, err = collection.Create(newItem)
+			if err != nil {
+				b.Fatalf("Error creating instance: %s", err)
+			}
+		}
+	}
+
+	b.ResetTimer()
+
+	for i := 0; i < b.N; i++ {
+		result, err := collection.Find(Where("Name").Eq("Name0").LimitTo(10))
+		if err != nil {
+			b.Fatalf("Error finding data: %s", err)
+		}
+		if len(result) != 10 {
+			b.Fatalf("Unexpected length %d, should be %d", len(result), 10)
+		}
+	}
+}
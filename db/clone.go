@@ -0,0 +1,169 @@
+package db
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+
+	ds "github.com/textileio/go-datastore"
+	"github.com/textileio/go-datastore/query"
+	"github.com/textileio/go-threads/core/thread"
+)
+
+// CloneDB creates a managed db under a new thread ID, with new keys unless
+// they're given in opts, and copies the collections and instances of the src
+// db to it, e.g., to use a db as a template or to fork a dataset. Instances
+// are written to the clone with new events, so it doesn't share the write
+// history of src. Instances hidden by read filters are copied too. The name,
+// description and labels of src aren't copied, see WithNewManagedName.
+func (m *Manager) CloneDB(ctx context.Context, srcID thread.ID, opts ...NewManagedOption) (*DB, error) {
+	args := &NewManagedOptions{}
+	for _, opt := range opts {
+		opt(args)
+	}
+	src, err := m.GetDB(ctx, srcID, WithManagedToken(args.Token))
+	if err != nil {
+		return nil, err
+	}
+	defer m.ReleaseDB(srcID)
+	id := thread.NewIDV1(srcID.Variant(), 32)
+	dst, err := m.NewDB(ctx, id, opts...)
+	if err != nil {
+		return nil, err
+	}
+	if err := src.cloneTo(dst, args.Token); err != nil {
+		// Don't leave a partial clone behind
+		if err := m.DeleteDB(ctx, id, WithManagedToken(args.Token)); err != nil {
+			log.Errorf("error deleting partial clone of %s: %v", srcID, err)
+		}
+		return nil, fmt.Errorf("cloning db %s: %w", srcID, err)
+	}
+	return dst, nil
+}
+
+// cloneTo copies the collections and instances of the db to dst.
+func (d *DB) cloneTo(dst *DB, token thread.Token) error {
+	configs, err := d.collectionConfigs(token)
+	if err != nil {
+		return err
+	}
+	for _, config := range configs {
+		if _, err := dst.NewCollection(config, WithToken(token)); err != nil {
+			return err
+		}
+		c, err := d.getCollection(config.Name)
+		if err != nil {
+			return err
+		}
+		var instances [][]byte
+		if err := c.ReadTxn(func(txn *Txn) (err error) {
+			instances, err = c.storedInstances(txn.reader())
+			return err
+		}, WithTxnSnapshot(true), WithTxnToken(token)); err != nil {
+			return err
+		}
+		seqKey := dsIDSequences.ChildString(config.Name)
+		seq, err := d.datastore.Get(seqKey)
+		if err == nil {
+			if err := dst.datastore.Put(seqKey, seq); err != nil {
+				return err
+			}
+		} else if !errors.Is(err, ds.ErrNotFound) {
+			return err
+		}
+		if err := dst.createInstances(config.Name, instances, token); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// collectionConfigs returns the configs of the collections of the db, without
+// their encryption keys, ordered so that collections come after the
+// collections they reference. Creating the collections, and then their
+// instances, in this order satisfies their references.
+func (d *DB) collectionConfigs(token thread.Token) ([]CollectionConfig, error) {
+	var pending []CollectionConfig
+	for _, c := range d.ListCollections(WithToken(token)) {
+		config, err := c.GetConfig()
+		if err != nil {
+			return nil, err
+		}
+		pending = append(pending, config)
+	}
+	sort.Slice(pending, func(i, j int) bool {
+		return pending[i].Name < pending[j].Name
+	})
+	var ordered []CollectionConfig
+	added := make(map[string]bool)
+	for len(pending) > 0 {
+		var next []CollectionConfig
+		for _, config := range pending {
+			if referencesAdded(config, added) {
+				ordered = append(ordered, config)
+				added[config.Name] = true
+			} else {
+				next = append(next, config)
+			}
+		}
+		if len(next) == len(pending) {
+			return nil, fmt.Errorf("%w: collections %s reference each other", ErrInvalidReference, next[0].Name)
+		}
+		pending = next
+	}
+	return ordered, nil
+}
+
+// referencesAdded returns whether the collections referenced by config,
+// other than itself, are added.
+func referencesAdded(config CollectionConfig, added map[string]bool) bool {
+	for _, ref := range config.References {
+		if ref.Collection != config.Name && !added[ref.Collection] {
+			return false
+		}
+	}
+	return true
+}
+
+// storedInstances returns the instances of the collection in store, with
+// their encrypted fields decrypted, and without undeclared _mod fields, so
+// they can be created again.
+func (c *Collection) storedInstances(store ds.Read) ([][]byte, error) {
+	var instances [][]byte
+	var derr error
+	if err := scanPrefix(store, c.baseKey(), func(e query.Entry) {
+		if derr != nil {
+			return
+		}
+		v, err := c.decryptFields(e.Value)
+		if err == nil {
+			v, err = c.withoutUndeclaredModTag(v)
+		}
+		if err != nil {
+			derr = err
+			return
+		}
+		instances = append(instances, v)
+	}); err != nil {
+		return nil, err
+	}
+	return instances, derr
+}
+
+// createInstances creates instances in a collection of the db. Instances in a
+// self-referencing collection may reference instances created after them, so
+// they're all created in a single transaction.
+func (d *DB) createInstances(name string, instances [][]byte, token thread.Token) error {
+	if len(instances) == 0 {
+		return nil
+	}
+	c, err := d.getCollection(name)
+	if err != nil {
+		return err
+	}
+	return c.WriteTxn(func(txn *Txn) error {
+		_, err := txn.Create(instances...)
+		return err
+	}, WithTxnToken(token))
+}
@@ -0,0 +1,120 @@
+package db
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"sync"
+
+	ds "github.com/textileio/go-datastore"
+	core "github.com/textileio/go-threads/core/db"
+	"github.com/textileio/go-threads/jsonpatcher"
+)
+
+// DefaultEventCodec is the name of the event codec used by dbs that don't
+// select one, which encodes writes as JSON merge patches.
+const DefaultEventCodec = "jsonpatcher"
+
+var (
+	// ErrEventCodecNotRegistered indicates an event codec name isn't registered.
+	ErrEventCodecNotRegistered = errors.New("event codec not registered")
+	// ErrEventCodecAlreadyRegistered indicates an event codec name is already registered.
+	ErrEventCodecAlreadyRegistered = errors.New("event codec already registered")
+	// ErrEventCodecMismatch indicates a db is opened with an event codec other
+	// than the one its events were encoded with.
+	ErrEventCodecMismatch = errors.New("event codec doesn't match the db event codec")
+
+	dsEventCodec = dsPrefix.ChildString("codec")
+
+	codecs = struct {
+		sync.RWMutex
+		factories map[string]EventCodecFactory
+	}{factories: map[string]EventCodecFactory{
+		DefaultEventCodec: jsonpatcher.New,
+	}}
+)
+
+// EventCodecFactory returns a new instance of an event codec.
+// Each db gets its own instance.
+type EventCodecFactory func() core.EventCodec
+
+// RegisterEventCodec registers an event codec under name, so dbs can select it
+// with WithNewEventCodecName. Codecs are usually registered in init functions.
+func RegisterEventCodec(name string, factory EventCodecFactory) error {
+	if !nameRx.MatchString(name) {
+		return ErrInvalidName
+	}
+	codecs.Lock()
+	defer codecs.Unlock()
+	if _, ok := codecs.factories[name]; ok {
+		return ErrEventCodecAlreadyRegistered
+	}
+	codecs.factories[name] = factory
+	return nil
+}
+
+// RegisteredEventCodecs returns the sorted names of the registered event codecs.
+func RegisteredEventCodecs() []string {
+	codecs.RLock()
+	defer codecs.RUnlock()
+	names := make([]string, 0, len(codecs.factories))
+	for name := range codecs.factories {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// newEventCodec returns a new instance of the event codec registered under name.
+func newEventCodec(name string) (core.EventCodec, error) {
+	codecs.RLock()
+	defer codecs.RUnlock()
+	factory, ok := codecs.factories[name]
+	if !ok {
+		return nil, fmt.Errorf("%w: %s", ErrEventCodecNotRegistered, name)
+	}
+	return factory(), nil
+}
+
+// negotiateEventCodec sets the event codec of the db. The name of the codec a
+// db is created with is persisted, and reopening the db selects it by default.
+// Reopening a db with a different codec name fails, since its events can't be
+// decoded. Codec instances given with WithNewEventCodec are used as is, and
+// aren't checked unless they're named.
+func (d *DB) negotiateEventCodec(opts *NewOptions) error {
+	stored, err := d.datastore.Get(dsEventCodec)
+	if err != nil && !errors.Is(err, ds.ErrNotFound) {
+		return err
+	}
+	if opts.EventCodec != nil {
+		d.eventcodec = opts.EventCodec
+		if opts.EventCodecName == "" {
+			return nil
+		}
+	}
+	name := opts.EventCodecName
+	if name == "" {
+		name = string(stored)
+		if name == "" {
+			name = DefaultEventCodec
+		}
+	} else if stored != nil && string(stored) != name {
+		return fmt.Errorf("%w: db uses %s", ErrEventCodecMismatch, stored)
+	}
+	if d.eventcodec == nil {
+		if d.eventcodec, err = newEventCodec(name); err != nil {
+			return err
+		}
+	}
+	d.eventcodecName = name
+	if string(stored) == name {
+		return nil
+	}
+	return d.datastore.Put(dsEventCodec, []byte(name))
+}
+
+// EventCodecName returns the name of the db event codec, or an empty string
+// if the db uses an unnamed codec.
+func (d *DB) EventCodecName() string {
+	return d.eventcodecName
+}
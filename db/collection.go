@@ -5,6 +5,7 @@ import (
 	"encoding/json"
 	"errors"
 	"fmt"
+	"sort"
 	"strconv"
 	"strings"
 	"sync"
@@ -19,6 +20,8 @@ import (
 	"github.com/textileio/go-threads/core/app"
 	core "github.com/textileio/go-threads/core/db"
 	"github.com/textileio/go-threads/core/thread"
+	"github.com/tidwall/gjson"
+	"github.com/tidwall/sjson"
 	"github.com/xeipuuv/gojsonschema"
 )
 
@@ -37,6 +40,14 @@ var (
 	// ErrInvalidSchemaInstance indicates the current operation is from an
 	// instance that doesn't satisfy the collection schema.
 	ErrInvalidSchemaInstance = errors.New("instance doesn't correspond to schema")
+	// ErrIncompatibleSchema indicates that existing instances fail validation
+	// against a new collection schema.
+	ErrIncompatibleSchema = errors.New("schema incompatible with existing instances")
+	// ErrInvalidPatch indicates a merge patch is malformed, or changes the instance ID.
+	ErrInvalidPatch = errors.New("invalid merge patch")
+	// ErrConflict indicates a compare-and-swap save failed because the stored
+	// instance changed, see WithTxnCompareMod.
+	ErrConflict = errors.New("instance was modified concurrently")
 
 	errMissingInstanceID           = errors.New("invalid instance: missing _id attribute")
 	errMissingModTag               = errors.New("invalid instance: missing _mod attribute")
@@ -63,6 +74,20 @@ type Collection struct {
 	writeValidator    goja.Callable
 	rawReadFilter     []byte
 	readFilter        goja.Callable
+	defaultOrder      Sort
+	ttlField          string
+	validationStats   *validationStats
+	throughput        *throughputStats
+	encryption        fieldEncryption
+	computed          []computedField
+	references        []Reference
+	defaults          []schemaDefault
+	version           versionState
+	migrations        map[int]MigrateFunc
+	hooks             *writeHooks
+	conflict          core.ConflictPolicy
+	ids               *idGenerator
+	writes            *writeQueue
 	sync.Mutex
 }
 
@@ -81,43 +106,96 @@ func newCollection(d *DB, config CollectionConfig) (*Collection, error) {
 	if idType.Type != "string" {
 		return nil, ErrInvalidCollectionSchema
 	}
+	if config.Version < 0 {
+		return nil, ErrInvalidMigration
+	}
+	if err := d.validConflictPolicy(config); err != nil {
+		return nil, err
+	}
+	if err := validIDStrategy(config); err != nil {
+		return nil, err
+	}
+	if config.TTLField != "" {
+		t, err := getSchemaTypeAtPath(config.Schema, config.TTLField)
+		if err != nil || (t.Type != "number" && t.Type != "integer") {
+			return nil, ErrInvalidTTLField
+		}
+	}
+	if path := config.DefaultOrderBy.FieldPath; path != "" && path != idFieldName {
+		if _, err := getSchemaTypeAtPath(config.Schema, path); err != nil {
+			return nil, ErrInvalidSortingField
+		}
+	}
+	encryption, err := newFieldEncryption(config)
+	if err != nil {
+		return nil, err
+	}
+	defaults, err := schemaDefaults(config.Schema)
+	if err != nil {
+		return nil, err
+	}
 	sb, err := json.Marshal(config.Schema)
 	if err != nil {
 		return nil, err
 	}
-	vm := goja.New()
-	wv := []byte(config.WriteValidator)
-	rf := []byte(config.ReadFilter)
 	c := &Collection{
-		name:              config.Name,
-		schemaLoader:      gojsonschema.NewBytesLoader(sb),
-		db:                d,
-		indexes:           make(map[string]Index),
-		vm:                vm,
-		rawWriteValidator: wv,
-		rawReadFilter:     rf,
+		name:            config.Name,
+		schemaLoader:    gojsonschema.NewBytesLoader(sb),
+		db:              d,
+		indexes:         make(map[string]Index),
+		vm:              goja.New(),
+		defaultOrder:    config.DefaultOrderBy,
+		ttlField:        config.TTLField,
+		hooks:           &writeHooks{},
+		conflict:        core.ConflictPolicy{Strategy: config.ConflictStrategy, Resolver: config.ConflictResolver},
+		ids:             &idGenerator{strategy: config.IDStrategy, custom: config.IDGenerator},
+		encryption:      encryption,
+		references:      config.References,
+		defaults:        defaults,
+		validationStats: &validationStats{},
+		throughput:      newThroughputStats(d.throughputWindow),
+		version:         versionState{Version: config.Version, Migrated: config.Version},
+	}
+	if config.SerializeWrites {
+		c.writes = newWriteQueue()
+	}
+	if err := c.setFuncs(config.WriteValidator, config.ReadFilter); err != nil {
+		return nil, err
 	}
-	wvObj, err := compileJSFunc(wv, writeValidatorFn, "writer", "event", "instance")
-	if err != nil {
+	if err := c.setComputedFields(config); err != nil {
 		return nil, err
 	}
-	if wvObj != nil {
-		c.writeValidator, err = loadJSFunc(vm, writeValidatorFn, wvObj)
-		if err != nil {
-			return nil, err
-		}
+	return c, nil
+}
+
+// setFuncs compiles and sets the write validator and read filter of the collection.
+// Neither is set if one fails to compile.
+func (c *Collection) setFuncs(writeValidator, readFilter string) error {
+	c.Lock()
+	defer c.Unlock()
+	wv, rf := []byte(writeValidator), []byte(readFilter)
+	wvObj, err := compileJSFunc(wv, writeValidatorFn, "writer", "event", "instance")
+	if err != nil {
+		return err
 	}
 	rfObj, err := compileJSFunc(rf, readFilterFn, "reader", "instance")
 	if err != nil {
-		return nil, err
+		return err
+	}
+	var wvFn, rfFn goja.Callable
+	if wvObj != nil {
+		if wvFn, err = loadJSFunc(c.vm, writeValidatorFn, wvObj); err != nil {
+			return err
+		}
 	}
 	if rfObj != nil {
-		c.readFilter, err = loadJSFunc(vm, readFilterFn, rfObj)
-		if err != nil {
-			return nil, err
+		if rfFn, err = loadJSFunc(c.vm, readFilterFn, rfObj); err != nil {
+			return err
 		}
 	}
-	return c, nil
+	c.rawWriteValidator, c.writeValidator = wv, wvFn
+	c.rawReadFilter, c.readFilter = rf, rfFn
+	return nil
 }
 
 // baseKey returns the collections base key.
@@ -137,14 +215,69 @@ func (c *Collection) GetSchema() []byte {
 
 // GetWriteValidator returns the current collection write validator.
 func (c *Collection) GetWriteValidator() []byte {
+	c.Lock()
+	defer c.Unlock()
 	return c.rawWriteValidator
 }
 
 // GetReadFilter returns the current collection read filter.
 func (c *Collection) GetReadFilter() []byte {
+	c.Lock()
+	defer c.Unlock()
 	return c.rawReadFilter
 }
 
+// hasReadFilter returns whether the collection has a read filter.
+func (c *Collection) hasReadFilter() bool {
+	c.Lock()
+	defer c.Unlock()
+	return c.readFilter != nil
+}
+
+// GetDefaultOrderBy returns the sort order applied to queries that don't specify one.
+func (c *Collection) GetDefaultOrderBy() Sort {
+	return c.defaultOrder
+}
+
+// GetConfig returns the current config of the collection, e.g., to introspect
+// it or to update it with UpdateCollection. The returned config doesn't
+// include the encryption key of encrypted fields, see EncryptionKey, so updates
+// with it keep the existing key.
+func (c *Collection) GetConfig() (CollectionConfig, error) {
+	schema := &jsonschema.Schema{}
+	if err := json.Unmarshal(c.GetSchema(), schema); err != nil {
+		return CollectionConfig{}, err
+	}
+	c.Lock()
+	version, migrations := c.version, c.migrations
+	c.Unlock()
+	config := CollectionConfig{
+		Name:             c.name,
+		Schema:           schema,
+		Indexes:          c.GetIndexes(),
+		WriteValidator:   string(c.GetWriteValidator()),
+		ReadFilter:       string(c.GetReadFilter()),
+		DefaultOrderBy:   c.defaultOrder,
+		Version:          version.Version,
+		TTLField:         c.ttlField,
+		ConflictStrategy: c.conflict.Strategy,
+		ConflictResolver: c.conflict.Resolver,
+		References:       append([]Reference(nil), c.references...),
+		EncryptedFields:  append([]string(nil), c.encryption.fields...),
+		ComputedFields:   c.getComputedFields(),
+		IDStrategy:       c.ids.strategy,
+		IDGenerator:      c.ids.custom,
+		SerializeWrites:  c.writes != nil,
+	}
+	for v, fn := range migrations {
+		config.Migrations = append(config.Migrations, Migration{Version: v, Migrate: fn})
+	}
+	sort.Slice(config.Migrations, func(i, j int) bool {
+		return config.Migrations[i].Version < config.Migrations[j].Version
+	})
+	return config, nil
+}
+
 // ReadTxn creates an explicit readonly transaction. Any operation
 // that tries to mutate an instance of the collection will ErrReadonlyTx.
 // Provides serializable isolation gurantees.
@@ -168,6 +301,28 @@ func (c *Collection) FindByID(id core.InstanceID, opts ...TxnOption) (instance [
 	return
 }
 
+// FindByIDs finds instances by their IDs in a single read transaction.
+// Instances are returned in the order of ids. If any doesn't exist,
+// returns ErrInstanceNotFound.
+func (c *Collection) FindByIDs(ids []core.InstanceID, opts ...TxnOption) (instances [][]byte, err error) {
+	err = c.ReadTxn(func(txn *Txn) error {
+		instances, err = txn.FindByIDs(ids...)
+		return err
+	}, opts...)
+	return
+}
+
+// GetMany gets instances by their IDs in a single read transaction.
+// Found instances are returned in the order of ids, along with the IDs
+// that don't exist, instead of failing the whole batch like FindByIDs.
+func (c *Collection) GetMany(ids []core.InstanceID, opts ...TxnOption) (instances [][]byte, missing []core.InstanceID, err error) {
+	err = c.ReadTxn(func(txn *Txn) error {
+		instances, missing, err = txn.GetMany(ids...)
+		return err
+	}, opts...)
+	return
+}
+
 // Create creates an instance in the collection.
 func (c *Collection) Create(v []byte, opts ...TxnOption) (id core.InstanceID, err error) {
 	err = c.WriteTxn(func(txn *Txn) error {
@@ -185,12 +340,26 @@ func (c *Collection) Create(v []byte, opts ...TxnOption) (id core.InstanceID, er
 }
 
 // CreateMany creates multiple instances in the collection.
-func (c *Collection) CreateMany(vs [][]byte, opts ...TxnOption) (ids []core.InstanceID, err error) {
-	err = c.WriteTxn(func(txn *Txn) error {
-		ids, err = txn.Create(vs...)
-		return err
+// The IDs of the created instances are returned in input order. If an
+// instance can't be created, a *BatchError identifying it is returned
+// and none of the instances are created.
+// If the db has a batch limit, see WithNewBatchLimit, a batch exceeding it
+// is created in sequential sub-batches, as is a batch larger than the
+// WithTxnChunkSize option. Each sub-batch is atomic, but the
+// batch as a whole isn't: if an instance fails, the IDs of the instances
+// created by previous sub-batches are returned along with the error.
+// With WithTxnContinueOnError, instances that can't be created are skipped,
+// the IDs of the created ones are returned, and a *PartialBatchError
+// reports the created ID or error of each instance.
+func (c *Collection) CreateMany(vs [][]byte, opts ...TxnOption) ([]core.InstanceID, error) {
+	return c.writeBatch(instanceSizes(vs), func(txn *Txn, i int) (core.InstanceID, bool, error) {
+		res, err := txn.Create(vs[i])
+		if err != nil {
+			id, _ := getInstanceID(vs[i])
+			return id, false, err
+		}
+		return res[0], true, nil
 	}, opts...)
-	return
 }
 
 // Delete deletes an instance by its ID. It doesn't
@@ -203,9 +372,15 @@ func (c *Collection) Delete(id core.InstanceID, opts ...TxnOption) error {
 
 // DeleteMany deletes multiple instances by ID. It doesn't
 // fail if one of the IDs don't exist.
-func (c *Collection) DeleteMany(ids []core.InstanceID, opts ...TxnOption) error {
-	return c.WriteTxn(func(txn *Txn) error {
-		return txn.Delete(ids...)
+// The IDs of the deleted instances are returned in input order, IDs
+// that don't exist are skipped. If an instance can't be deleted, a
+// *BatchError identifying it is returned and none of the instances are deleted.
+// Like CreateMany, a batch exceeding the db batch limit is split into
+// sequential sub-batches.
+func (c *Collection) DeleteMany(ids []core.InstanceID, opts ...TxnOption) ([]core.InstanceID, error) {
+	return c.writeBatch(make([]int, len(ids)), func(txn *Txn, i int) (core.InstanceID, bool, error) {
+		ok, err := txn.delete(ids[i])
+		return ids[i], ok, err
 	}, opts...)
 }
 
@@ -216,10 +391,44 @@ func (c *Collection) Save(v []byte, opts ...TxnOption) error {
 	}, opts...)
 }
 
-// SaveMany saves changes of multiple instances in the collection.
-func (c *Collection) SaveMany(vs [][]byte, opts ...TxnOption) error {
+// Upsert creates an instance in the collection if its ID doesn't exist, and
+// saves it otherwise. The check and the write happen in a single write
+// transaction, so concurrent upserts of an ID can't both create it.
+func (c *Collection) Upsert(v []byte, opts ...TxnOption) (id core.InstanceID, err error) {
+	err = c.WriteTxn(func(txn *Txn) error {
+		var ids []core.InstanceID
+		ids, err = txn.Upsert(v)
+		if err != nil {
+			return err
+		}
+		id = ids[0]
+		return nil
+	}, opts...)
+	return
+}
+
+// Patch updates fields of an instance with a JSON merge patch, see RFC 7386.
+// Fields set to null in the patch are removed.
+func (c *Collection) Patch(id core.InstanceID, patch []byte, opts ...TxnOption) error {
 	return c.WriteTxn(func(txn *Txn) error {
-		return txn.Save(vs...)
+		return txn.Patch(id, patch)
+	}, opts...)
+}
+
+// SaveMany saves changes of multiple instances in the collection.
+// The IDs of the saved instances are returned in input order. If an
+// instance can't be saved, a *BatchError identifying it is returned
+// and none of the instances are saved.
+// Like CreateMany, a batch exceeding the db batch limit is split into
+// sequential sub-batches, and WithTxnContinueOnError skips instances that
+// can't be saved.
+func (c *Collection) SaveMany(vs [][]byte, opts ...TxnOption) ([]core.InstanceID, error) {
+	return c.writeBatch(instanceSizes(vs), func(txn *Txn, i int) (core.InstanceID, bool, error) {
+		id, _ := getInstanceID(vs[i])
+		if err := txn.Save(vs[i]); err != nil {
+			return id, false, err
+		}
+		return id, true, nil
 	}, opts...)
 }
 
@@ -237,10 +446,20 @@ func (c *Collection) VerifyMany(vs [][]byte, opts ...TxnOption) error {
 	}, opts...)
 }
 
+// Validate validates an instance against the collection schema and write
+// validator without writing it, as if it was saved, or created if it doesn't
+// exist. It's meant to pre-validate instances, e.g., of forms. Checks made as
+// writes are committed, e.g., of unique indexes and references, aren't run.
+func (c *Collection) Validate(v []byte, opts ...TxnOption) error {
+	return c.WriteTxn(func(txn *Txn) error {
+		return txn.Validate(v)
+	}, opts...)
+}
+
 // Has returns true if ID exists in the collection, false
 // otherwise.
 func (c *Collection) Has(id core.InstanceID, opts ...TxnOption) (exists bool, err error) {
-	_ = c.ReadTxn(func(txn *Txn) error {
+	err = c.ReadTxn(func(txn *Txn) error {
 		exists, err = txn.Has(id)
 		return err
 	}, opts...)
@@ -250,7 +469,7 @@ func (c *Collection) Has(id core.InstanceID, opts ...TxnOption) (exists bool, er
 // HasMany returns true if all IDs exist in the collection, false
 // otherwise.
 func (c *Collection) HasMany(ids []core.InstanceID, opts ...TxnOption) (exists bool, err error) {
-	_ = c.ReadTxn(func(txn *Txn) error {
+	err = c.ReadTxn(func(txn *Txn) error {
 		exists, err = txn.Has(ids...)
 		return err
 	}, opts...)
@@ -259,13 +478,201 @@ func (c *Collection) HasMany(ids []core.InstanceID, opts ...TxnOption) (exists b
 
 // Find executes a Query and returns the result.
 func (c *Collection) Find(q *Query, opts ...TxnOption) (instances [][]byte, err error) {
-	_ = c.ReadTxn(func(txn *Txn) error {
+	err = c.ReadTxn(func(txn *Txn) error {
 		instances, err = txn.Find(q)
 		return err
 	}, opts...)
 	return
 }
 
+// FindIterate queries for instances by Query, calling fn with each instance,
+// see Txn.FindIterate.
+func (c *Collection) FindIterate(q *Query, fn func(instance []byte) error, opts ...TxnOption) error {
+	return c.ReadTxn(func(txn *Txn) error {
+		return txn.FindIterate(q, fn)
+	}, opts...)
+}
+
+// FindPage queries for a page of instances by Query, see Txn.FindPage.
+func (c *Collection) FindPage(q *Query, opts ...TxnOption) (instances [][]byte, cursor string, err error) {
+	err = c.ReadTxn(func(txn *Txn) error {
+		instances, cursor, err = txn.FindPage(q)
+		return err
+	}, opts...)
+	return
+}
+
+// Count returns the number of instances matching the criteria of q, see Txn.Count.
+func (c *Collection) Count(q *Query, opts ...TxnOption) (count int, err error) {
+	err = c.ReadTxn(func(txn *Txn) error {
+		count, err = txn.Count(q)
+		return err
+	}, opts...)
+	return
+}
+
+// Explain returns the plan of q without running it, see Txn.Explain.
+func (c *Collection) Explain(q *Query, opts ...TxnOption) (plan *QueryPlan, err error) {
+	err = c.ReadTxn(func(txn *Txn) error {
+		plan, err = txn.Explain(q)
+		return err
+	}, opts...)
+	return
+}
+
+// Aggregate computes an aggregation over the results of q, see Txn.Aggregate.
+func (c *Collection) Aggregate(q *Query, a Aggregation, opts ...TxnOption) (groups []Group, err error) {
+	err = c.ReadTxn(func(txn *Txn) error {
+		groups, err = txn.Aggregate(q, a)
+		return err
+	}, opts...)
+	return
+}
+
+// CheckSchema reports the IDs of existing instances that would fail validation
+// against newSchema, without making any changes. Instances are streamed from the
+// datastore, so the collection doesn't need to fit in memory.
+func (c *Collection) CheckSchema(newSchema *jsonschema.Schema, opts ...TxnOption) ([]core.InstanceID, error) {
+	args := &TxnOptions{}
+	for _, opt := range opts {
+		opt(args)
+	}
+	if err := c.db.connector.Validate(args.Token, true); err != nil {
+		return nil, err
+	}
+	idType, err := getSchemaTypeAtPath(newSchema, idFieldName)
+	if err != nil {
+		if errors.Is(err, ErrInvalidCollectionSchemaPath) {
+			return nil, ErrInvalidCollectionSchema
+		}
+		return nil, err
+	}
+	if idType.Type != "string" {
+		return nil, ErrInvalidCollectionSchema
+	}
+	c.db.txnlock.RLock()
+	defer c.db.txnlock.RUnlock()
+	_, incompatible, err := c.checkInstances(newSchema, 0, false)
+	if err != nil {
+		return nil, err
+	}
+	failed := make([]core.InstanceID, len(incompatible))
+	for i, inst := range incompatible {
+		failed[i] = inst.ID
+	}
+	return failed, nil
+}
+
+// checkInstances validates at most sample existing instances against newSchema,
+// or all of them if sample is zero. Pending migrations are applied to the
+// instances first if migrate is true.
+// It returns the number of instances checked and the incompatible ones.
+func (c *Collection) checkInstances(newSchema *jsonschema.Schema, sample int, migrate bool) (int, []IncompatibleInstance, error) {
+	sb, err := json.Marshal(newSchema)
+	if err != nil {
+		return 0, nil, err
+	}
+	schema, err := gojsonschema.NewSchema(gojsonschema.NewBytesLoader(sb))
+	if err != nil {
+		return 0, nil, err
+	}
+	// Stored instances carry the protected _mod field, which strict schemas may not declare
+	_, err = getSchemaTypeAtPath(newSchema, modFieldName)
+	declaresMod := err == nil
+
+	results, err := c.db.datastore.Query(query.Query{
+		Prefix: c.baseKey().String(),
+		Limit:  sample,
+	})
+	if err != nil {
+		return 0, nil, err
+	}
+	defer results.Close()
+	var checked int
+	var incompatible []IncompatibleInstance
+	for res := range results.Next() {
+		if res.Error != nil {
+			return 0, nil, res.Error
+		}
+		key := ds.RawKey(res.Key)
+		v := res.Value
+		if migrate {
+			if v, err = c.migrateInstance(key, v); err != nil {
+				return 0, nil, err
+			}
+		}
+		if v, err = c.decryptFields(v); err != nil {
+			return 0, nil, err
+		}
+		if !declaresMod {
+			if v, err = sjson.DeleteBytes(v, modFieldName); err != nil {
+				return 0, nil, err
+			}
+		}
+		r, err := schema.Validate(gojsonschema.NewBytesLoader(v))
+		if err != nil {
+			return 0, nil, err
+		}
+		checked++
+		if !r.Valid() {
+			inst := IncompatibleInstance{ID: core.InstanceID(key.Name())}
+			for _, e := range r.Errors() {
+				inst.Errors = append(inst.Errors, e.Field()+": "+e.Description())
+			}
+			incompatible = append(incompatible, inst)
+		}
+	}
+	return checked, incompatible, nil
+}
+
+// IncompatibleInstance is an existing instance that fails validation
+// against a new collection schema.
+type IncompatibleInstance struct {
+	// ID is the instance ID.
+	ID core.InstanceID
+	// Errors are the validation errors of the instance.
+	Errors []string
+}
+
+// SchemaCompatibilityError is returned by UpdateCollection when existing
+// instances fail validation against the new schema, see WithCompatibilityCheck.
+type SchemaCompatibilityError struct {
+	// Checked is the number of instances validated.
+	Checked int
+	// Instances are the incompatible instances.
+	Instances []IncompatibleInstance
+}
+
+func (e *SchemaCompatibilityError) Error() string {
+	return fmt.Sprintf("%d of %d checked instances are incompatible with the new schema, first: %s: %s",
+		len(e.Instances), e.Checked, e.Instances[0].ID, strings.Join(e.Instances[0].Errors, "; "))
+}
+
+func (e *SchemaCompatibilityError) Unwrap() error {
+	return ErrIncompatibleSchema
+}
+
+// BatchError is returned by batch operations when a single item fails.
+type BatchError struct {
+	// Index is the position of the failing item in the batch.
+	Index int
+	// ID is the instance ID of the failing item, if known.
+	ID core.InstanceID
+	// Err is the item error.
+	Err error
+}
+
+func (e *BatchError) Error() string {
+	if e.ID != core.EmptyInstanceID {
+		return fmt.Sprintf("batch item %d (%s): %s", e.Index, e.ID, e.Err)
+	}
+	return fmt.Sprintf("batch item %d: %s", e.Index, e.Err)
+}
+
+func (e *BatchError) Unwrap() error {
+	return e.Err
+}
+
 type filter struct {
 	Collection string
 	Time       int
@@ -338,14 +745,21 @@ func (c *Collection) validInstance(v []byte) error {
 	if len(errs) == 0 {
 		return nil
 	}
-	var msg string
-	for i, e := range errs {
-		msg += e.Field() + ": " + e.Description()
-		if i != len(errs)-1 {
-			msg += "; "
-		}
+	c.observeValidationFailure(v, errs)
+	return newValidationError(errs)
+}
+
+// withoutUndeclaredModTag removes the protected _mod field from a stored instance
+// if the collection schema doesn't declare it, so the instance can be re-validated.
+func (c *Collection) withoutUndeclaredModTag(v []byte) ([]byte, error) {
+	schema := &jsonschema.Schema{}
+	if err := json.Unmarshal(c.GetSchema(), schema); err != nil {
+		return nil, err
+	}
+	if _, err := getSchemaTypeAtPath(schema, modFieldName); err == nil {
+		return v, nil
 	}
-	return fmt.Errorf("%w: %s", ErrInvalidSchemaInstance, msg)
+	return sjson.DeleteBytes(v, modFieldName)
 }
 
 // validWrite validates new events against the identity and user-defined write validator function.
@@ -432,13 +846,23 @@ type Txn struct {
 	discarded  bool
 	committed  bool
 	readonly   bool
+	metadata   map[string]string
+	compareMod bool
+	// ctx is the context of the transaction reads, if any.
+	ctx context.Context
 
 	actions []core.Action
+	written []hookedWrite
+	// dbtxn is the db transaction the txn is part of, if any.
+	dbtxn *DBTxn
+	// snapshot is the datastore txn pinned by snapshot read transactions.
+	snapshot ds.Txn
 }
 
 // Create creates new instances in the collection
 // If the ID value on the instance is nil or otherwise a null value (e.g., ""),
 // and ID is generated and used to store the instance.
+// Missing fields with a default value in the collection schema are set to it.
 func (t *Txn) Create(new ...[]byte) ([]core.InstanceID, error) {
 	results := make([]core.InstanceID, len(new))
 	for i := range new {
@@ -448,18 +872,31 @@ func (t *Txn) Create(new ...[]byte) ([]core.InstanceID, error) {
 
 		updated := make([]byte, len(new[i]))
 		copy(updated, new[i])
+		updated, err := t.collection.withoutComputedFields(updated)
+		if err != nil {
+			return nil, err
+		}
 
 		id, err := getInstanceID(updated)
 		if err != nil && !errors.Is(err, errMissingInstanceID) {
 			return nil, err
 		}
 		if id == core.EmptyInstanceID {
-			id, updated = setNewInstanceID(updated)
+			if id, err = t.collection.newInstanceID(); err != nil {
+				return nil, err
+			}
+			updated = setInstanceID(updated, id)
+		}
+		if updated, err = t.collection.applyDefaults(updated); err != nil {
+			return nil, err
 		}
 
 		if err := t.collection.validInstance(updated); err != nil {
 			return nil, err
 		}
+		if updated, err = t.collection.encryptFields(updated, nil); err != nil {
+			return nil, err
+		}
 
 		results[i] = id
 		key := baseKey.ChildString(t.collection.name).ChildString(id.String())
@@ -512,6 +949,55 @@ func (t *Txn) Verify(updated ...[]byte) error {
 	return nil
 }
 
+// Validate validates an instance but does not write it, see Collection.Validate.
+func (t *Txn) Validate(instance []byte) error {
+	if t.readonly {
+		return ErrReadonlyTx
+	}
+	identity, err := t.token.PubKey()
+	if err != nil {
+		return err
+	}
+	id, err := getInstanceID(instance)
+	if err != nil && !errors.Is(err, errMissingInstanceID) {
+		return err
+	}
+	var exists bool
+	if id != core.EmptyInstanceID {
+		if exists, err = t.exists(id); err != nil {
+			return err
+		}
+	}
+	var actions []core.Action
+	if exists {
+		if actions, err = t.createSaveActions(identity, instance); err != nil {
+			return err
+		}
+	} else {
+		if id == core.EmptyInstanceID {
+			// A placeholder ID doesn't advance the ID sequence of the collection
+			instance = setInstanceID(instance, core.NewInstanceID())
+		}
+		n := len(t.actions)
+		if _, err := t.Create(instance); err != nil {
+			return err
+		}
+		actions = append(actions, t.actions[n:]...)
+		t.actions = t.actions[:n]
+	}
+
+	events, _, err := t.createEvents(actions)
+	if err != nil {
+		return err
+	}
+	for _, e := range events {
+		if err := t.collection.validWrite(identity, e); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
 // Save saves an instance changes to be committed when the current transaction commits.
 func (t *Txn) Save(updated ...[]byte) error {
 	identity, err := t.token.PubKey()
@@ -536,10 +1022,15 @@ func (t *Txn) createSaveActions(identity thread.PubKey, updated ...[]byte) ([]co
 
 		next := make([]byte, len(updated[i]))
 		copy(next, updated[i])
+		next, err := t.collection.withoutComputedFields(next)
+		if err != nil {
+			return nil, err
+		}
 
 		if err := t.collection.validInstance(next); err != nil {
 			return nil, err
 		}
+		expectedMod := gjson.GetBytes(next, modFieldName).Int()
 
 		// Update readonly/protected mod tag
 		_, next = setModifiedTag(next)
@@ -552,12 +1043,25 @@ func (t *Txn) createSaveActions(identity thread.PubKey, updated ...[]byte) ([]co
 		}
 		key := baseKey.ChildString(t.collection.name).ChildString(id.String())
 		previous, err := t.collection.db.datastore.Get(key)
+		if t.compareMod {
+			if err := compareMod(id, expectedMod, previous, err); err != nil {
+				t.collection.db.metrics.conflicts.WithLabelValues(t.collection.name).Inc()
+				return nil, err
+			}
+		}
 		if err == ds.ErrNotFound {
 			// Default to an empty doc, downstream reducer will take care of patching, etc
 			previous = []byte("{}")
+			if next, err = t.collection.encryptFields(next, nil); err != nil {
+				return nil, err
+			}
 		} else if err != nil {
 			return nil, err
 		} else {
+			// Unchanged encrypted fields keep their stored ciphertext
+			if next, err = t.collection.encryptFields(next, previous); err != nil {
+				return nil, err
+			}
 			// No errors, carry on
 			previous, err = t.collection.filterRead(identity, previous)
 			if err != nil {
@@ -576,33 +1080,113 @@ func (t *Txn) createSaveActions(identity thread.PubKey, updated ...[]byte) ([]co
 	return actions, nil
 }
 
+// Patch applies a JSON merge patch to the stored instance with the given ID,
+// and saves the result in the current transaction. Changes made earlier in the
+// transaction aren't visible to the patch.
+func (t *Txn) Patch(id core.InstanceID, patch []byte) error {
+	if t.readonly {
+		return ErrReadonlyTx
+	}
+	current, err := t.FindByID(id)
+	if err != nil {
+		return err
+	}
+	patched, err := jsonpatch.MergePatch(current, patch)
+	if err != nil {
+		return fmt.Errorf("%w: %v", ErrInvalidPatch, err)
+	}
+	if pid, err := getInstanceID(patched); err != nil || pid != id {
+		return fmt.Errorf("%w: the instance ID can't be changed", ErrInvalidPatch)
+	}
+	return t.Save(patched)
+}
+
+// Upsert creates new instances in the current transaction, or saves them if
+// their IDs already exist, either in the collection or in the transaction.
+// Instances without an ID are created with a new one.
+func (t *Txn) Upsert(vs ...[]byte) ([]core.InstanceID, error) {
+	identity, err := t.token.PubKey()
+	if err != nil {
+		return nil, err
+	}
+	results := make([]core.InstanceID, len(vs))
+	for i := range vs {
+		id, err := getInstanceID(vs[i])
+		if err != nil && !errors.Is(err, errMissingInstanceID) {
+			return nil, err
+		}
+		exists := false
+		if id != core.EmptyInstanceID {
+			if exists, err = t.exists(id); err != nil {
+				return nil, err
+			}
+		}
+		if exists {
+			actions, err := t.createSaveActions(identity, vs[i])
+			if err != nil {
+				return nil, err
+			}
+			t.actions = append(t.actions, actions...)
+			results[i] = id
+		} else {
+			ids, err := t.Create(vs[i])
+			if err != nil {
+				return nil, err
+			}
+			results[i] = ids[0]
+		}
+	}
+	return results, nil
+}
+
+// exists returns whether or not an instance exists in the collection,
+// or has been written earlier in the transaction.
+func (t *Txn) exists(id core.InstanceID) (bool, error) {
+	for i := len(t.actions) - 1; i >= 0; i-- {
+		if t.actions[i].InstanceID == id {
+			return t.actions[i].Type != core.Delete, nil
+		}
+	}
+	key := baseKey.ChildString(t.collection.name).ChildString(id.String())
+	return t.collection.db.datastore.Has(key)
+}
+
 // Delete deletes instances by ID when the current transaction commits.
 func (t *Txn) Delete(ids ...core.InstanceID) error {
 	for i := range ids {
-		if t.readonly {
-			return ErrReadonlyTx
-		}
-		key := baseKey.ChildString(t.collection.name).ChildString(ids[i].String())
-		exists, err := t.collection.db.datastore.Has(key)
-		if err != nil {
+		if _, err := t.delete(ids[i]); err != nil {
 			return err
 		}
-		if !exists {
-			// Nothing to be done here
-			return nil
-		}
-		a := core.Action{
-			Type:           core.Delete,
-			InstanceID:     ids[i],
-			CollectionName: t.collection.name,
-			Previous:       nil,
-			Current:        nil,
-		}
-		t.actions = append(t.actions, a)
 	}
 	return nil
 }
 
+// delete deletes an instance by ID when the current transaction commits.
+// It returns false if the instance doesn't exist.
+func (t *Txn) delete(id core.InstanceID) (bool, error) {
+	if t.readonly {
+		return false, ErrReadonlyTx
+	}
+	key := baseKey.ChildString(t.collection.name).ChildString(id.String())
+	exists, err := t.collection.db.datastore.Has(key)
+	if err != nil {
+		return false, err
+	}
+	if !exists {
+		// Nothing to be done here
+		return false, nil
+	}
+	a := core.Action{
+		Type:           core.Delete,
+		InstanceID:     id,
+		CollectionName: t.collection.name,
+		Previous:       nil,
+		Current:        nil,
+	}
+	t.actions = append(t.actions, a)
+	return true, nil
+}
+
 // Has returns true if all IDs exists in the collection, false otherwise.
 func (t *Txn) Has(ids ...core.InstanceID) (bool, error) {
 	if err := t.collection.db.connector.Validate(t.token, true); err != nil {
@@ -614,15 +1198,15 @@ func (t *Txn) Has(ids ...core.InstanceID) (bool, error) {
 	}
 	for i := range ids {
 		key := baseKey.ChildString(t.collection.name).ChildString(ids[i].String())
-		exists, err := t.collection.db.datastore.Has(key)
+		exists, err := t.reader().Has(key)
 		if err != nil {
 			return false, err
 		}
 		if exists {
-			if t.collection.readFilter == nil {
+			if !t.collection.hasReadFilter() {
 				continue
 			}
-			bytes, err := t.collection.db.datastore.Get(key)
+			bytes, err := t.reader().Get(key)
 			if err != nil {
 				return false, err
 			}
@@ -645,19 +1229,25 @@ func (t *Txn) FindByID(id core.InstanceID) ([]byte, error) {
 	if err := t.collection.db.connector.Validate(t.token, true); err != nil {
 		return nil, err
 	}
+	if err := t.checkContext(); err != nil {
+		return nil, err
+	}
 	key := baseKey.ChildString(t.collection.name).ChildString(id.String())
-	bytes, err := t.collection.db.datastore.Get(key)
+	bytes, err := t.reader().Get(key)
 	if errors.Is(err, ds.ErrNotFound) {
 		return nil, ErrInstanceNotFound
 	}
 	if err != nil {
 		return nil, err
 	}
+	if bytes, err = t.collection.migrateInstance(key, bytes); err != nil {
+		return nil, err
+	}
 	pk, err := t.token.PubKey()
 	if err != nil {
 		return nil, err
 	}
-	bytes, err = t.collection.filterRead(pk, bytes)
+	bytes, err = t.collection.readInstance(pk, bytes)
 	if err != nil {
 		return nil, err
 	}
@@ -667,10 +1257,60 @@ func (t *Txn) FindByID(id core.InstanceID) ([]byte, error) {
 	return bytes, err
 }
 
+// FindByIDs gets instances by ID in the current txn scope, in the order of ids.
+func (t *Txn) FindByIDs(ids ...core.InstanceID) ([][]byte, error) {
+	instances := make([][]byte, len(ids))
+	for i, id := range ids {
+		instance, err := t.FindByID(id)
+		if err != nil {
+			return nil, fmt.Errorf("finding instance %s: %w", id, err)
+		}
+		instances[i] = instance
+	}
+	return instances, nil
+}
+
+// GetMany gets instances by ID in the current txn scope, in the order of ids,
+// along with the IDs that don't exist.
+func (t *Txn) GetMany(ids ...core.InstanceID) (instances [][]byte, missing []core.InstanceID, err error) {
+	for _, id := range ids {
+		instance, err := t.FindByID(id)
+		if errors.Is(err, ErrInstanceNotFound) {
+			missing = append(missing, id)
+			continue
+		} else if err != nil {
+			return nil, nil, fmt.Errorf("finding instance %s: %w", id, err)
+		}
+		instances = append(instances, instance)
+	}
+	return instances, missing, nil
+}
+
 // Commit applies all changes done in the current transaction
 // to the collection. This is a syncrhonous call so changes can
 // be assumed to be applied on function return.
 func (t *Txn) Commit() error {
+	if t.dbtxn != nil {
+		return errCommitDBTxnCollection
+	}
+	if len(t.actions) > 0 && t.collection.db.readOnly {
+		return ErrReadOnlyDB
+	}
+	if err := t.runBeforeWriteHooks(); err != nil {
+		return err
+	}
+	for i := range t.actions {
+		t.actions[i].Metadata = t.metadata
+	}
+	if err := t.checkReferences(); err != nil {
+		return err
+	}
+	if err := t.checkUnique(); err != nil {
+		return err
+	}
+	if err := t.collection.db.checkQuota(t.actions); err != nil {
+		return err
+	}
 	events, node, err := t.createEvents(t.actions)
 	if err != nil {
 		return err
@@ -698,9 +1338,12 @@ func (t *Txn) Discard() {
 // RefreshCollection updates the transaction's collection reference from the master db map,
 // which may have received updates while the transaction is open.
 func (t *Txn) RefreshCollection() error {
-	c, ok := t.collection.db.collections[t.collection.name]
-	if !ok {
-		return ErrCollectionNotFound
+	d := t.collection.db
+	d.lock.Lock()
+	defer d.lock.Unlock()
+	c, err := d.getCollection(t.collection.name)
+	if err != nil {
+		return err
 	}
 	t.collection = c
 	return nil
@@ -756,13 +1399,12 @@ func getInstanceID(t []byte) (core.InstanceID, error) {
 	return core.InstanceID(*partial.ID), nil
 }
 
-func setNewInstanceID(t []byte) (core.InstanceID, []byte) {
-	newID := core.NewInstanceID()
-	patchedValue, err := jsonpatch.MergePatch(t, []byte(fmt.Sprintf(`{"%s": %q}`, idFieldName, newID.String())))
+func setInstanceID(t []byte, id core.InstanceID) []byte {
+	patchedValue, err := jsonpatch.MergePatch(t, []byte(fmt.Sprintf(`{"%s": %q}`, idFieldName, id.String())))
 	if err != nil {
 		log.Fatalf("while automatically patching autogenerated _id: %v", err)
 	}
-	return newID, patchedValue
+	return patchedValue
 }
 
 func getModifiedTag(t []byte) (int64, error) {
@@ -778,6 +1420,21 @@ func getModifiedTag(t []byte) (int64, error) {
 	return *partial.Mod, nil
 }
 
+// compareMod returns ErrConflict if expected isn't the _mod of the stored
+// instance, whose lookup returned err. Missing instances have a zero _mod.
+func compareMod(id core.InstanceID, expected int64, stored []byte, err error) error {
+	var mod int64
+	if err == nil {
+		mod = gjson.GetBytes(stored, modFieldName).Int()
+	} else if !errors.Is(err, ds.ErrNotFound) {
+		return err
+	}
+	if mod != expected {
+		return fmt.Errorf("%w: instance %s has _mod %d, expected %d", ErrConflict, id, mod, expected)
+	}
+	return nil
+}
+
 func setModifiedTag(t []byte) (newTime int64, patchedValue []byte) {
 	newTime = time.Now().UnixNano()
 	patchedValue, err := jsonpatch.MergePatch(t, []byte(fmt.Sprintf(`{"%s": %d}`, modFieldName, newTime)))
@@ -2,16 +2,23 @@ package db
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
 	"errors"
+	"fmt"
 	"os"
 	"reflect"
+	"strings"
+	"sync"
 	"testing"
 	"time"
 
 	logging "github.com/ipfs/go-log"
 	core "github.com/textileio/go-threads/core/db"
+	"github.com/textileio/go-threads/crypto/symmetric"
 	"github.com/textileio/go-threads/util"
+	"github.com/tidwall/gjson"
+	"github.com/tidwall/sjson"
 	"github.com/xeipuuv/gojsonschema"
 )
 
@@ -232,6 +239,57 @@ func TestNewCollection(t *testing.T) {
 			t.Fatal("the collection name should be invalid")
 		}
 	})
+	t.Run("CompatibilityCheck", func(t *testing.T) {
+		t.Parallel()
+		db, clean := createTestDB(t)
+		defer clean()
+		c, err := db.NewCollection(CollectionConfig{
+			Name:   "Dog",
+			Schema: util.SchemaFromInstance(&Dog{}, false),
+		})
+		checkErr(t, err)
+		id, err := c.Create([]byte(`{"Name": "Fido", "Comments": []}`))
+		checkErr(t, err)
+		_, err = c.Create([]byte(`{"Name": "Lassie", "Comments": []}`))
+		checkErr(t, err)
+
+		_, err = db.UpdateCollection(CollectionConfig{
+			Name:   "Dog",
+			Schema: util.SchemaFromInstance(&Dog2{}, false),
+		}, WithCompatibilityCheck(0))
+		var cerr *SchemaCompatibilityError
+		if !errors.As(err, &cerr) || !errors.Is(err, ErrIncompatibleSchema) {
+			t.Fatalf("expected schema compatibility error, got %v", err)
+		}
+		if cerr.Checked != 2 || len(cerr.Instances) != 2 || len(cerr.Instances[0].Errors) == 0 {
+			t.Fatalf("unexpected report: %+v", cerr)
+		}
+		if _, err := c.FindByID(id); err != nil {
+			t.Fatalf("collection should not be updated: %v", err)
+		}
+		_, err = db.UpdateCollection(CollectionConfig{
+			Name:   "Dog",
+			Schema: util.SchemaFromInstance(&Dog2{}, false),
+		}, WithCompatibilityCheck(1))
+		if !errors.As(err, &cerr) || cerr.Checked != 1 || len(cerr.Instances) != 1 {
+			t.Fatalf("expected a sampled report, got %v", err)
+		}
+
+		// Instances are checked after migration
+		_, err = db.UpdateCollection(CollectionConfig{
+			Name:    "Dog",
+			Schema:  util.SchemaFromInstance(&Dog2{}, false),
+			Version: 1,
+			Migrations: []Migration{{Version: 1, Migrate: func(instance []byte) ([]byte, error) {
+				dog := &Dog{}
+				if err := json.Unmarshal(instance, dog); err != nil {
+					return nil, err
+				}
+				return json.Marshal(&Dog2{ID: dog.ID, FullName: dog.Name, Toys: Toys{Names: []string{}}, Comments: dog.Comments})
+			}}},
+		}, WithCompatibilityCheck(0))
+		checkErr(t, err)
+	})
 	t.Run("Fail/BadIndexPath", func(t *testing.T) {
 		t.Parallel()
 		db, clean := createTestDB(t)
@@ -345,306 +403,1851 @@ func TestUpdateCollection(t *testing.T) {
 			t.Fatal("index path should not be valid")
 		}
 	})
-}
+	t.Run("ValidatorsOnly", func(t *testing.T) {
+		t.Parallel()
+		db, clean := createTestDB(t)
+		defer clean()
+		c, err := db.NewCollection(CollectionConfig{
+			Name:       "Dog",
+			Schema:     util.SchemaFromInstance(&Dog{}, false),
+			Indexes:    []Index{{Path: "Name", Unique: true}},
+			ReadFilter: "return null",
+		})
+		checkErr(t, err)
+		id, err := c.Create([]byte(`{"Name": "Fido", "Comments": []}`))
+		checkErr(t, err)
+		if _, err := c.FindByID(id); !errors.Is(err, ErrInstanceNotFound) {
+			t.Fatalf("expected the instance to be filtered, got %v", err)
+		}
 
-func TestDeleteCollection(t *testing.T) {
-	t.Parallel()
-	db, clean := createTestDB(t)
-	defer clean()
-	_, err := db.NewCollection(CollectionConfig{
-		Name:    "Dog",
-		Schema:  util.SchemaFromInstance(&Dog2{}, false),
-		Indexes: []Index{{Path: "FullName", Unique: true}},
+		uc, err := db.UpdateCollection(CollectionConfig{
+			Name:       "Dog",
+			ReadFilter: "instance.Name = 'Rex'; return instance",
+		}, WithValidatorsOnly(true))
+		checkErr(t, err)
+		if uc != c {
+			t.Fatal("expected the collection to be updated in place")
+		}
+		res, err := c.FindByID(id)
+		checkErr(t, err)
+		if gjson.GetBytes(res, "Name").String() != "Rex" {
+			t.Fatalf("expected the new read filter to be used, got %s", res)
+		}
+		if len(c.GetIndexes()) != 1 || !strings.Contains(string(c.GetSchema()), "Comments") {
+			t.Fatal("expected the schema and indexes to be kept")
+		}
+		rf, err := db.datastore.Get(dsFilters.ChildString("Dog"))
+		checkErr(t, err)
+		if string(rf) != string(c.GetReadFilter()) {
+			t.Fatalf("expected the new read filter to be persisted, got %s", rf)
+		}
+
+		_, err = db.UpdateCollection(CollectionConfig{Name: "Dog", WriteValidator: "return ("}, WithValidatorsOnly(true))
+		if err == nil {
+			t.Fatal("write validator should not compile")
+		}
+		if string(c.GetReadFilter()) != "instance.Name = 'Rex'; return instance" {
+			t.Fatalf("expected the read filter to be kept, got %s", c.GetReadFilter())
+		}
 	})
-	checkErr(t, err)
-	err = db.DeleteCollection("Dog")
-	checkErr(t, err)
-	if db.GetCollection("Dog") != nil {
-		t.Fatal("collection should be deleted")
-	}
 }
 
-func TestAddIndex(t *testing.T) {
+func TestMigration(t *testing.T) {
 	t.Parallel()
-	db, clean := createTestDB(t)
-	defer clean()
-	schema := util.SchemaFromInstance(&Person2{}, false)
-	c, err := db.NewCollection(CollectionConfig{
-		Name:   "Person",
-		Schema: schema,
-	})
-	checkErr(t, err)
+	renameDog := Migration{Version: 1, Migrate: func(instance []byte) ([]byte, error) {
+		dog := &Dog{}
+		if err := json.Unmarshal(instance, dog); err != nil {
+			return nil, err
+		}
+		return json.Marshal(&Dog2{
+			ID:       dog.ID,
+			FullName: dog.Name,
+			Toys:     Toys{Names: []string{}},
+			Comments: dog.Comments,
+		})
+	}}
+	setup := func(t *testing.T) (*DB, []core.InstanceID, func()) {
+		db, clean := createTestDB(t)
+		c, err := db.NewCollection(CollectionConfig{
+			Name:   "Dog",
+			Schema: util.SchemaFromInstance(&Dog{}, false),
+		})
+		checkErr(t, err)
+		var ids []core.InstanceID
+		for _, name := range []string{"Fido", "Lassie", "Rex"} {
+			id, err := c.Create([]byte(fmt.Sprintf(`{"Name": "%s", "Comments": []}`, name)))
+			checkErr(t, err)
+			ids = append(ids, id)
+		}
+		return db, ids, clean
+	}
+	checkDogs := func(t *testing.T, c *Collection, ids []core.InstanceID) {
+		for i, name := range []string{"Fido", "Lassie", "Rex"} {
+			res, err := c.FindByID(ids[i])
+			checkErr(t, err)
+			dog := &Dog2{}
+			checkErr(t, json.Unmarshal(res, dog))
+			if dog.FullName != name {
+				t.Fatalf("expected migrated name %s, got %s", name, dog.FullName)
+			}
+		}
+	}
 
-	t.Run("AddNameUniqueIndex", func(t *testing.T) {
-		err := c.addIndex(schema, Index{Path: "Name", Unique: false})
+	t.Run("Eager", func(t *testing.T) {
+		t.Parallel()
+		db, ids, clean := setup(t)
+		defer clean()
+		var done, total int
+		c, err := db.UpdateCollection(CollectionConfig{
+			Name:       "Dog",
+			Schema:     util.SchemaFromInstance(&Dog2{}, false),
+			Indexes:    []Index{{Path: "FullName"}},
+			Version:    1,
+			Migrations: []Migration{renameDog},
+		}, WithMigrationProgress(func(d, t int) {
+			done, total = d, t
+		}))
+		checkErr(t, err)
+		if done != 3 || total != 3 {
+			t.Fatalf("expected progress 3/3, got %d/%d", done, total)
+		}
+		if c.GetVersion() != 1 {
+			t.Fatalf("expected version 1, got %d", c.GetVersion())
+		}
+		checkDogs(t, c, ids)
+		res, err := c.Find(Where("FullName").Eq("Lassie"))
 		checkErr(t, err)
+		if len(res) != 1 {
+			t.Fatalf("expected the migrated instance to be indexed, got %d results", len(res))
+		}
 	})
-	t.Run("AddAgeNonUniqueIndex", func(t *testing.T) {
-		err := c.addIndex(schema, Index{Path: "Age", Unique: false})
+	t.Run("Lazy", func(t *testing.T) {
+		t.Parallel()
+		db, ids, clean := setup(t)
+		defer clean()
+		c, err := db.UpdateCollection(CollectionConfig{
+			Name:       "Dog",
+			Schema:     util.SchemaFromInstance(&Dog2{}, false),
+			Version:    1,
+			Migrations: []Migration{renameDog},
+		}, WithLazyMigration(true))
 		checkErr(t, err)
-	})
-	t.Run("AddNestedIndex", func(t *testing.T) {
-		err := c.addIndex(schema, Index{Path: "Toys.Favorite", Unique: false})
+		checkDogs(t, c, ids)
+		res, err := c.Find(OrderBy("FullName"))
 		checkErr(t, err)
-	})
-	t.Run("Fail/AddIndexWithBadPath", func(t *testing.T) {
-		err := c.addIndex(schema, Index{Path: "Bad.Path", Unique: false})
-		if err == nil {
-			t.Fatal("index path should not be valid")
+		if len(res) != 3 {
+			t.Fatalf("expected 3 results, got %d", len(res))
 		}
-	})
-	t.Run("Fail/AddIndexOnRef", func(t *testing.T) {
-		err := c.addIndex(schema, Index{Path: "Toys", Unique: false})
-		if err == nil {
-			t.Fatal("index path should not be valid")
+		dog := &Dog2{}
+		checkErr(t, json.Unmarshal(res[0], dog))
+		if dog.FullName != "Fido" {
+			t.Fatalf("expected results sorted by migrated name, got %s first", dog.FullName)
 		}
-	})
-	t.Run("Fail/AddIndexOnBadType", func(t *testing.T) {
-		err := c.addIndex(schema, Index{Path: "Comments", Unique: false})
-		if err == nil {
-			t.Fatal("index path should not be valid")
+
+		// Instances written at the new version aren't migrated again
+		dog.Breed = "Beagle"
+		updated, err := json.Marshal(dog)
+		checkErr(t, err)
+		checkErr(t, c.Save(updated))
+		checkDogs(t, c, ids)
+
+		checkErr(t, c.Migrate())
+		if c.pendingMigration() {
+			t.Fatal("expected no pending migration")
 		}
-	})
-	t.Run("Fail/AddUniqueIndexOnNonUniquePath", func(t *testing.T) {
-		_, err := c.Create(util.JSONFromInstance(Person2{Name: "Foo", Age: 42, Toys: Toys{Favorite: "", Names: []string{}}, Comments: []Comment{}}))
+		checkDogs(t, c, ids)
+		res, err = c.Find(Where("Breed").Eq("Beagle"))
 		checkErr(t, err)
-		_, err = c.Create(util.JSONFromInstance(Person2{Name: "Foo", Age: 42, Toys: Toys{Favorite: "", Names: []string{}}, Comments: []Comment{}}))
+		if len(res) != 1 {
+			t.Fatalf("expected 1 result, got %d", len(res))
+		}
+	})
+	t.Run("Fail/InvalidMigrations", func(t *testing.T) {
+		t.Parallel()
+		db, _, clean := setup(t)
+		defer clean()
+		_, err := db.UpdateCollection(CollectionConfig{
+			Name:       "Dog",
+			Schema:     util.SchemaFromInstance(&Dog2{}, false),
+			Version:    1,
+			Migrations: []Migration{{Version: 2, Migrate: renameDog.Migrate}},
+		})
+		if !errors.Is(err, ErrInvalidMigration) {
+			t.Fatalf("expected invalid migration error, got %v", err)
+		}
+		_, err = db.UpdateCollection(CollectionConfig{
+			Name:       "Dog",
+			Schema:     util.SchemaFromInstance(&Dog2{}, false),
+			Version:    1,
+			Migrations: []Migration{renameDog},
+		})
 		checkErr(t, err)
-		err = c.addIndex(schema, Index{Path: "Name", Unique: true})
-		if err == nil {
-			t.Fatal("index path should not be valid")
+		_, err = db.UpdateCollection(CollectionConfig{
+			Name:   "Dog",
+			Schema: util.SchemaFromInstance(&Dog2{}, false),
+		})
+		if !errors.Is(err, ErrInvalidMigration) {
+			t.Fatalf("expected invalid migration error, got %v", err)
 		}
 	})
 }
 
-func TestEmptySchema(t *testing.T) {
+func TestDeleteCollection(t *testing.T) {
 	t.Parallel()
 	db, clean := createTestDB(t)
 	defer clean()
-	// Note the empty schema {}, this is a catch all schema
-	schema := util.SchemaFromSchemaString("{}")
 	_, err := db.NewCollection(CollectionConfig{
-		Name:   "Person",
-		Schema: schema,
+		Name:    "Dog",
+		Schema:  util.SchemaFromInstance(&Dog2{}, false),
+		Indexes: []Index{{Path: "FullName", Unique: true}},
 	})
-	if err != ErrInvalidCollectionSchema {
-		t.Fatalf("expected to throw ErrInvalidCollectionSchema error")
+	checkErr(t, err)
+	err = db.DeleteCollection("Dog")
+	checkErr(t, err)
+	if db.GetCollection("Dog") != nil {
+		t.Fatal("collection should be deleted")
 	}
 }
 
-func TestGetName(t *testing.T) {
+func TestRenameCollection(t *testing.T) {
 	t.Parallel()
 	db, clean := createTestDB(t)
 	defer clean()
-	schema := util.SchemaFromInstance(&Person2{}, false)
 	c, err := db.NewCollection(CollectionConfig{
-		Name:   "Person",
-		Schema: schema,
+		Name:    "Dog",
+		Schema:  util.SchemaFromInstance(&Dog{}, false),
+		Indexes: []Index{{Path: "Name", Unique: true}},
 	})
 	checkErr(t, err)
-	name := c.GetName()
-	if name != "Person" {
-		t.Fatalf("expected Person, but got %s", name)
+	ids, err := c.CreateMany([][]byte{
+		[]byte(`{"Name": "Fido", "Comments": []}`),
+		[]byte(`{"Name": "Lassie", "Comments": []}`),
+	})
+	checkErr(t, err)
+	_, err = db.NewCollection(CollectionConfig{
+		Name:   "Cat",
+		Schema: util.SchemaFromInstance(&Dog{}, false),
+	})
+	checkErr(t, err)
+
+	if err := db.RenameCollection("Dog", "Cat"); !errors.Is(err, ErrCollectionAlreadyRegistered) {
+		t.Fatalf("expected already registered error, got %v", err)
+	}
+	if err := db.RenameCollection("Dog", "-bad"); !errors.Is(err, ErrInvalidName) {
+		t.Fatalf("expected invalid name error, got %v", err)
+	}
+	checkErr(t, db.RenameCollection("Dog", "Hound"))
+	if db.GetCollection("Dog") != nil {
+		t.Fatal("old collection should be gone")
+	}
+	c = db.GetCollection("Hound")
+	if c == nil {
+		t.Fatal("renamed collection should exist")
+	}
+	for _, id := range ids {
+		_, err := c.FindByID(id)
+		checkErr(t, err)
+	}
+	res, err := c.Find(Where("Name").Eq("Lassie").UseIndex("Name"))
+	checkErr(t, err)
+	if len(res) != 1 {
+		t.Fatalf("expected the index to be moved, got %d results", len(res))
+	}
+	if _, err := c.Create([]byte(`{"Name": "Fido", "Comments": []}`)); !errors.Is(err, ErrUniqueExists) {
+		t.Fatalf("expected unique error, got %v", err)
+	}
+	modified, err := c.ModifiedSince(0)
+	checkErr(t, err)
+	if len(modified) != 2 {
+		t.Fatalf("expected the event history to be moved, got %d modified instances", len(modified))
 	}
+	_, err = c.Create([]byte(`{"Name": "Rex", "Comments": []}`))
+	checkErr(t, err)
 }
 
-func TestGetSchema(t *testing.T) {
+func TestRenameCollectionReferences(t *testing.T) {
 	t.Parallel()
 	db, clean := createTestDB(t)
 	defer clean()
-	schema := util.SchemaFromInstance(&Person2{}, false)
-	c, err := db.NewCollection(CollectionConfig{
-		Name:   "Person",
-		Schema: schema,
+	_, err := db.NewCollection(CollectionConfig{Name: "Person", Schema: util.SchemaFromInstance(&Person{}, false)})
+	checkErr(t, err)
+	_, err = db.NewCollection(CollectionConfig{
+		Name:       "Session",
+		Schema:     util.SchemaFromInstance(&Session{}, false),
+		References: []Reference{{Path: "User", Collection: "Person"}},
 	})
 	checkErr(t, err)
-	sb, err := json.Marshal(schema)
+	_, err = db.NewCollection(CollectionConfig{
+		Name:       "Node",
+		Schema:     util.SchemaFromInstance(&Session{}, false),
+		References: []Reference{{Path: "User", Collection: "Node", OnDelete: RefCascade}},
+	})
 	checkErr(t, err)
-	original := gojsonschema.NewBytesLoader(sb).JsonSource().([]byte)
-	got := c.GetSchema()
-	if !bytes.Equal(got, original) {
-		t.Fatal("got schema does not match original")
+	pid, err := db.GetCollection("Person").Create(util.JSONFromInstance(Person{Name: "Foo"}))
+	checkErr(t, err)
+	root, err := db.GetCollection("Node").Create(util.JSONFromInstance(Session{}))
+	checkErr(t, err)
+	child, err := db.GetCollection("Node").Create(util.JSONFromInstance(Session{User: root.String()}))
+	checkErr(t, err)
+
+	checkErr(t, db.RenameCollection("Person", "Human"))
+	checkErr(t, db.RenameCollection("Node", "Tree"))
+
+	// Writes to referrers check the renamed collection
+	sessions := db.GetCollection("Session")
+	_, err = sessions.Create(util.JSONFromInstance(Session{User: pid.String()}))
+	checkErr(t, err)
+	if _, err := sessions.Create(util.JSONFromInstance(Session{User: "missing"})); !errors.Is(err, ErrReferenceNotFound) {
+		t.Fatalf("expected reference not found error, got %v", err)
+	}
+	refs, err := db.loadReferences("Session")
+	checkErr(t, err)
+	if len(refs) != 1 || refs[0].Collection != "Human" {
+		t.Fatalf("expected stored reference to the renamed collection, got %v", refs)
 	}
-}
 
-func TestGetWriteValidator(t *testing.T) {
-	t.Parallel()
-	db, clean := createTestDB(t)
-	defer clean()
-	schema := util.SchemaFromInstance(&Person2{}, false)
-	wv := "return true"
-	c, err := db.NewCollection(CollectionConfig{
-		Name:           "Person",
-		Schema:         schema,
-		WriteValidator: wv,
-	})
+	// Delete actions apply to the renamed collection
+	if err := db.GetCollection("Human").Delete(pid); !errors.Is(err, ErrReferenced) {
+		t.Fatalf("expected referenced error, got %v", err)
+	}
+	tree := db.GetCollection("Tree")
+	config, err := tree.GetConfig()
 	checkErr(t, err)
-	if !bytes.Equal(c.GetWriteValidator(), []byte(wv)) {
-		t.Fatal("got write validator does not match original")
+	if len(config.References) != 1 || config.References[0].Collection != "Tree" {
+		t.Fatalf("expected self reference to the new name, got %v", config.References)
+	}
+	checkErr(t, tree.Delete(root))
+	if ok, err := tree.Has(child); err != nil || ok {
+		t.Fatalf("expected cascaded delete of the child node, got %v %v", ok, err)
 	}
 }
 
-func TestGetReadFilter(t *testing.T) {
+type Session struct {
+	ID        core.InstanceID `json:"_id"`
+	User      string
+	ExpiresAt int64
+}
+
+func TestExpiration(t *testing.T) {
 	t.Parallel()
-	db, clean := createTestDB(t)
+	db, clean := createTestDB(t, WithNewExpirySweepInterval(time.Millisecond*50))
 	defer clean()
-	schema := util.SchemaFromInstance(&Person2{}, false)
-	rf := "return instance"
+	defer db.Close()
+	if _, err := db.NewCollection(CollectionConfig{
+		Name:     "Session",
+		Schema:   util.SchemaFromInstance(&Session{}, false),
+		TTLField: "User",
+	}); !errors.Is(err, ErrInvalidTTLField) {
+		t.Fatalf("expected invalid ttl field error, got %v", err)
+	}
 	c, err := db.NewCollection(CollectionConfig{
-		Name:       "Person",
-		Schema:     schema,
-		ReadFilter: rf,
+		Name:   "Session",
+		Schema: util.SchemaFromInstance(&Session{}, false),
 	})
 	checkErr(t, err)
-	if !bytes.Equal(c.GetReadFilter(), []byte(rf)) {
-		t.Fatal("got read filter does not match original")
+	now := time.Now().Unix()
+	expired, err := c.Create(util.JSONFromInstance(Session{User: "alice", ExpiresAt: now - 10}))
+	checkErr(t, err)
+	// Existing instances are indexed when the TTL field is declared
+	c, err = db.UpdateCollection(CollectionConfig{
+		Name:     "Session",
+		Schema:   util.SchemaFromInstance(&Session{}, false),
+		TTLField: "ExpiresAt",
+	})
+	checkErr(t, err)
+	later, err := c.Create(util.JSONFromInstance(Session{User: "bob", ExpiresAt: now + 3600}))
+	checkErr(t, err)
+	soon, err := c.Create(util.JSONFromInstance(Session{User: "carol", ExpiresAt: now - 1}))
+	checkErr(t, err)
+
+	deadline := time.Now().Add(time.Second * 5)
+	for _, id := range []core.InstanceID{expired, soon} {
+		for {
+			_, err := c.FindByID(id)
+			if errors.Is(err, ErrInstanceNotFound) {
+				break
+			}
+			checkErr(t, err)
+			if time.Now().After(deadline) {
+				t.Fatalf("expected instance %s to be deleted", id)
+			}
+			time.Sleep(time.Millisecond * 50)
+		}
 	}
+	_, err = c.FindByID(later)
+	checkErr(t, err)
 }
 
-func TestGetIndexes(t *testing.T) {
+func TestWriteHooks(t *testing.T) {
 	t.Parallel()
 	db, clean := createTestDB(t)
 	defer clean()
-	schema := util.SchemaFromInstance(&Person2{}, false)
 	c, err := db.NewCollection(CollectionConfig{
 		Name:   "Person",
-		Schema: schema,
+		Schema: util.SchemaFromInstance(&Person{}, false),
 	})
 	checkErr(t, err)
-	indexes := c.GetIndexes()
-	if len(indexes) != 0 {
-		t.Fatalf("expected %d indexes, got %d", 0, len(indexes))
-	}
-	err = c.addIndex(schema, Index{Path: "Name", Unique: false})
+	counts, err := db.NewCollection(CollectionConfig{
+		Name:   "Count",
+		Schema: util.SchemaFromInstance(&Person{}, false),
+	})
 	checkErr(t, err)
-	indexes = c.GetIndexes()
-	if len(indexes) != 1 {
-		t.Fatalf("expected %d indexes, got %d", 1, len(indexes))
-	}
-	if indexes[0].Unique {
+
+	var before []WriteEvent
+	c.AddBeforeWriteHook(func(txn *Txn, e WriteEvent) error {
+		if e.Current != nil && gjson.GetBytes(e.Current, "Age").Int() < 0 {
+			return errors.New("age can't be negative")
+		}
+		before = append(before, e)
+		return nil
+	})
+	var after []WriteEvent
+	c.AddAfterWriteHook(func(e WriteEvent) {
+		after = append(after, e)
+		// After hooks can write to the db
+		_, err := counts.Create(util.JSONFromInstance(Person{Name: e.ID.String()}))
+		checkErr(t, err)
+	})
+
+	id, err := c.Create(util.JSONFromInstance(Person{Name: "Alice", Age: 42}))
+	checkErr(t, err)
+	checkErr(t, c.Save(util.JSONFromInstance(Person{ID: id, Name: "Alice", Age: 43})))
+	if _, err := c.Create(util.JSONFromInstance(Person{Name: "Bob", Age: -1})); err == nil {
+		t.Fatal("before hook should abort the transaction")
+	}
+	c, err = db.UpdateCollection(CollectionConfig{
+		Name:   "Person",
+		Schema: util.SchemaFromInstance(&Person{}, false),
+	})
+	checkErr(t, err)
+	checkErr(t, c.Delete(id))
+
+	types := []ActionType{ActionCreate, ActionSave, ActionDelete}
+	if len(before) != 3 || len(after) != 3 {
+		t.Fatalf("expected 3 hooked writes, got %d before and %d after", len(before), len(after))
+	}
+	for i, e := range after {
+		if e.Type != types[i] || e.ID != id || e.Collection != "Person" || !reflect.DeepEqual(e, before[i]) {
+			t.Fatalf("unexpected write event %+v", e)
+		}
+	}
+	if before[0].Previous != nil || before[2].Current != nil {
+		t.Fatal("created instances have no previous state and deleted ones no current state")
+	}
+	if gjson.GetBytes(before[1].Previous, "Age").Int() != 42 || gjson.GetBytes(before[2].Previous, "Age").Int() != 43 {
+		t.Fatal("expected previous instance states")
+	}
+	res, err := counts.Find(nil)
+	checkErr(t, err)
+	if len(res) != 3 {
+		t.Fatalf("expected 3 derived instances, got %d", len(res))
+	}
+	if res, err := c.Find(nil); err != nil || len(res) != 0 {
+		t.Fatalf("expected aborted create to be discarded, got %d instances, %v", len(res), err)
+	}
+}
+
+func TestConflictStrategy(t *testing.T) {
+	t.Parallel()
+	// reduceLate applies two saves of an instance in reverse causal order
+	reduceLate := func(t *testing.T, config CollectionConfig) []byte {
+		db, clean := createTestDB(t)
+		defer clean()
+		c, err := db.NewCollection(config)
+		checkErr(t, err)
+		person := Person{Name: "Alice", Age: 42}
+		id, err := c.Create(util.JSONFromInstance(person))
+		checkErr(t, err)
+		current, err := c.FindByID(id)
+		checkErr(t, err)
+
+		person.ID = id
+		person.Name = "Bob"
+		first, _, err := db.eventcodec.Create([]core.Action{{
+			Type: core.Save, InstanceID: id, CollectionName: "Person", Previous: current, Current: util.JSONFromInstance(person),
+		}})
+		checkErr(t, err)
+		person.Name = "Carol"
+		person.Age = 24
+		second, _, err := db.eventcodec.Create([]core.Action{{
+			Type: core.Save, InstanceID: id, CollectionName: "Person", Previous: current, Current: util.JSONFromInstance(person),
+		}})
+		checkErr(t, err)
+		checkErr(t, db.Reduce(second))
+		checkErr(t, db.Reduce(first))
+		res, err := c.FindByID(id)
+		checkErr(t, err)
+		return res
+	}
+	schema := util.SchemaFromInstance(&Person{}, false)
+
+	t.Run("Merge", func(t *testing.T) {
+		t.Parallel()
+		res := reduceLate(t, CollectionConfig{Name: "Person", Schema: schema})
+		if gjson.GetBytes(res, "Name").String() != "Bob" || gjson.GetBytes(res, "Age").Int() != 24 {
+			t.Fatalf("expected the late write to be merged, got %s", res)
+		}
+	})
+	t.Run("LastWriterWins", func(t *testing.T) {
+		t.Parallel()
+		res := reduceLate(t, CollectionConfig{Name: "Person", Schema: schema, ConflictStrategy: core.ConflictLastWriterWins})
+		if gjson.GetBytes(res, "Name").String() != "Carol" || gjson.GetBytes(res, "Age").Int() != 24 {
+			t.Fatalf("expected the latest write to win, got %s", res)
+		}
+	})
+	t.Run("Custom", func(t *testing.T) {
+		t.Parallel()
+		var conflicts []core.Conflict
+		res := reduceLate(t, CollectionConfig{
+			Name:             "Person",
+			Schema:           schema,
+			ConflictStrategy: core.ConflictCustom,
+			ConflictResolver: func(c core.Conflict) ([]byte, error) {
+				conflicts = append(conflicts, c)
+				// Keep the names of both writes
+				names := gjson.GetBytes(c.Current, "Name").String() + " & " + gjson.GetBytes(c.Incoming, "Name").String()
+				return sjson.SetBytes(c.Current, "Name", names)
+			},
+		})
+		if len(conflicts) != 1 || conflicts[0].Collection != "Person" {
+			t.Fatalf("expected a conflict, got %v", conflicts)
+		}
+		if gjson.GetBytes(res, "Name").String() != "Carol & Bob" {
+			t.Fatalf("expected the resolved instance, got %s", res)
+		}
+	})
+	t.Run("Fail/InvalidStrategy", func(t *testing.T) {
+		t.Parallel()
+		db, clean := createTestDB(t)
+		defer clean()
+		for _, s := range []core.ConflictStrategy{core.ConflictCustom, 42} {
+			if _, err := db.NewCollection(CollectionConfig{Name: "Person", Schema: schema, ConflictStrategy: s}); !errors.Is(err, ErrInvalidConflictStrategy) {
+				t.Fatalf("expected invalid conflict strategy error, got %v", err)
+			}
+		}
+	})
+}
+
+func TestIDStrategy(t *testing.T) {
+	t.Parallel()
+	schema := util.SchemaFromInstance(&Person{}, false)
+	createIDs := func(t *testing.T, c *Collection, n int) []core.InstanceID {
+		ids := make([]core.InstanceID, n)
+		for i := range ids {
+			id, err := c.Create(util.JSONFromInstance(Person{Name: "Alice", Age: i}))
+			checkErr(t, err)
+			if _, err := c.FindByID(id); err != nil {
+				t.Fatalf("expected instance with the generated ID %s, got %v", id, err)
+			}
+			ids[i] = id
+		}
+		return ids
+	}
+
+	t.Run("UUIDv7", func(t *testing.T) {
+		t.Parallel()
+		db, clean := createTestDB(t)
+		defer clean()
+		c, err := db.NewCollection(CollectionConfig{Name: "Person", Schema: schema, IDStrategy: IDUUIDv7})
+		checkErr(t, err)
+		for _, id := range createIDs(t, c, 2) {
+			u := id.String()
+			if len(u) != 36 || u[14] != '7' || strings.Count(u, "-") != 4 {
+				t.Fatalf("expected a version 7 UUID, got %s", u)
+			}
+		}
+	})
+	t.Run("Sequential", func(t *testing.T) {
+		t.Parallel()
+		db, clean := createTestDB(t)
+		defer clean()
+		config := CollectionConfig{Name: "Person", Schema: schema, IDStrategy: IDSequential}
+		c, err := db.NewCollection(config)
+		checkErr(t, err)
+		ids := createIDs(t, c, 2)
+		// The sequence is kept across collection updates
+		c, err = db.UpdateCollection(config)
+		checkErr(t, err)
+		ids = append(ids, createIDs(t, c, 1)...)
+		expected := []core.InstanceID{"00000000000000000001", "00000000000000000002", "00000000000000000003"}
+		if !reflect.DeepEqual(ids, expected) {
+			t.Fatalf("expected sequential IDs %v, got %v", expected, ids)
+		}
+	})
+	t.Run("Custom", func(t *testing.T) {
+		t.Parallel()
+		db, clean := createTestDB(t)
+		defer clean()
+		var n int
+		c, err := db.NewCollection(CollectionConfig{
+			Name:       "Person",
+			Schema:     schema,
+			IDStrategy: IDCustom,
+			IDGenerator: func() core.InstanceID {
+				n++
+				return core.InstanceID(fmt.Sprintf("person-%d", n))
+			},
+		})
+		checkErr(t, err)
+		ids := createIDs(t, c, 2)
+		if !reflect.DeepEqual(ids, []core.InstanceID{"person-1", "person-2"}) {
+			t.Fatalf("expected custom IDs, got %v", ids)
+		}
+	})
+	t.Run("Fail/InvalidStrategy", func(t *testing.T) {
+		t.Parallel()
+		db, clean := createTestDB(t)
+		defer clean()
+		for _, s := range []IDStrategy{IDCustom, 42} {
+			if _, err := db.NewCollection(CollectionConfig{Name: "Person", Schema: schema, IDStrategy: s}); !errors.Is(err, ErrInvalidIDStrategy) {
+				t.Fatalf("expected invalid ID strategy error, got %v", err)
+			}
+		}
+	})
+}
+
+func TestCounter(t *testing.T) {
+	t.Parallel()
+	t.Run("Embedded", func(t *testing.T) {
+		t.Parallel()
+		db, clean := createTestDB(t)
+		defer clean()
+		type Post struct {
+			ID    core.InstanceID `json:"_id"`
+			Mod   int64           `json:"_mod"`
+			Title string
+			Likes Counter `json:"likes,omitempty"`
+		}
+		c, err := db.NewCollection(CollectionConfig{Name: "Post", Schema: util.SchemaFromInstance(&Post{}, false)})
+		checkErr(t, err)
+		id, err := c.Create(util.JSONFromInstance(Post{Title: "Hello"}))
+		checkErr(t, err)
+		base, err := c.FindByID(id)
+		checkErr(t, err)
+
+		// A concurrent increment from another peer, made against the same base
+		remote, err := sjson.SetBytes(base, "likes.p.peer", 3)
+		checkErr(t, err)
+		events, _, err := db.eventcodec.Create([]core.Action{{
+			Type: core.Save, InstanceID: id, CollectionName: "Post", Previous: base, Current: remote,
+		}})
+		checkErr(t, err)
+
+		v, err := c.Increment(id, "likes", 2)
+		checkErr(t, err)
+		if v != 2 {
+			t.Fatalf("expected counter value 2, got %d", v)
+		}
+		v, err = c.Increment(id, "likes", -1)
+		checkErr(t, err)
+		if v != 1 {
+			t.Fatalf("expected counter value 1, got %d", v)
+		}
+		checkErr(t, db.Reduce(events))
+		res, err := c.FindByID(id)
+		checkErr(t, err)
+		v, err = CounterValue(res, "likes")
+		checkErr(t, err)
+		if v != 4 {
+			t.Fatalf("expected concurrent increments to converge to 4, got %d", v)
+		}
+		if _, err := c.Increment(id, "Title", 1); !errors.Is(err, ErrInvalidCounter) {
+			t.Fatalf("expected invalid counter error, got %v", err)
+		}
+	})
+	t.Run("Standalone", func(t *testing.T) {
+		t.Parallel()
+		db, clean := createTestDB(t)
+		defer clean()
+		c, err := db.NewCollection(CounterCollectionConfig("Visits"))
+		checkErr(t, err)
+		id, err := c.Create([]byte(`{}`))
+		checkErr(t, err)
+		for i := 0; i < 3; i++ {
+			_, err = c.Increment(id, CounterValueField, 5)
+			checkErr(t, err)
+		}
+		res, err := c.FindByID(id)
+		checkErr(t, err)
+		v, err := CounterValue(res, CounterValueField)
+		checkErr(t, err)
+		if v != 15 {
+			t.Fatalf("expected counter value 15, got %d", v)
+		}
+	})
+}
+
+func TestORSet(t *testing.T) {
+	t.Parallel()
+	db, clean := createTestDB(t)
+	defer clean()
+	type Post struct {
+		ID    core.InstanceID `json:"_id"`
+		Mod   int64           `json:"_mod"`
+		Title string
+		Tags  ORSet `json:"tags,omitempty"`
+	}
+	c, err := db.NewCollection(CollectionConfig{Name: "Post", Schema: util.SchemaFromInstance(&Post{}, false)})
+	checkErr(t, err)
+	id, err := c.Create(util.JSONFromInstance(Post{Title: "Hello"}))
+	checkErr(t, err)
+	checkErr(t, c.AddToSet(id, "tags", []string{"go", "db"}))
+	base, err := c.FindByID(id)
+	checkErr(t, err)
+
+	// A concurrent update from another peer, which removes both observed
+	// elements and adds a new one
+	var remote Post
+	util.InstanceFromJSON(base, &remote)
+	for tag := range remote.Tags {
+		delete(remote.Tags, tag)
+	}
+	remote.Tags["peer"] = "crdt"
+	events, _, err := db.eventcodec.Create([]core.Action{{
+		Type: core.Save, InstanceID: id, CollectionName: "Post", Previous: base, Current: util.JSONFromInstance(remote),
+	}})
+	checkErr(t, err)
+
+	// Re-adding an element concurrently with its removal keeps it
+	checkErr(t, c.AddToSet(id, "tags", []string{"go"}))
+	checkErr(t, db.Reduce(events))
+	res, err := c.FindByID(id)
+	checkErr(t, err)
+	values, err := SetValues(res, "tags")
+	checkErr(t, err)
+	if !reflect.DeepEqual(values, []string{"crdt", "go"}) {
+		t.Fatalf("expected concurrent updates to converge, got %v", values)
+	}
+
+	checkErr(t, c.RemoveFromSet(id, "tags", []string{"go", "missing"}))
+	res, err = c.FindByID(id)
+	checkErr(t, err)
+	values, err = SetValues(res, "tags")
+	checkErr(t, err)
+	if !reflect.DeepEqual(values, []string{"crdt"}) {
+		t.Fatalf("expected removed element to be missing, got %v", values)
+	}
+	if err := c.AddToSet(id, "Title", []string{"go"}); !errors.Is(err, ErrInvalidSet) {
+		t.Fatalf("expected invalid set error, got %v", err)
+	}
+}
+
+func TestAddIndex(t *testing.T) {
+	t.Parallel()
+	db, clean := createTestDB(t)
+	defer clean()
+	schema := util.SchemaFromInstance(&Person2{}, false)
+	c, err := db.NewCollection(CollectionConfig{
+		Name:   "Person",
+		Schema: schema,
+	})
+	checkErr(t, err)
+
+	t.Run("AddNameUniqueIndex", func(t *testing.T) {
+		err := c.addIndex(schema, Index{Path: "Name", Unique: false})
+		checkErr(t, err)
+	})
+	t.Run("AddAgeNonUniqueIndex", func(t *testing.T) {
+		err := c.addIndex(schema, Index{Path: "Age", Unique: false})
+		checkErr(t, err)
+	})
+	t.Run("AddNestedIndex", func(t *testing.T) {
+		err := c.addIndex(schema, Index{Path: "Toys.Favorite", Unique: false})
+		checkErr(t, err)
+	})
+	t.Run("Fail/AddIndexWithBadPath", func(t *testing.T) {
+		err := c.addIndex(schema, Index{Path: "Bad.Path", Unique: false})
+		if err == nil {
+			t.Fatal("index path should not be valid")
+		}
+	})
+	t.Run("Fail/AddIndexOnRef", func(t *testing.T) {
+		err := c.addIndex(schema, Index{Path: "Toys", Unique: false})
+		if err == nil {
+			t.Fatal("index path should not be valid")
+		}
+	})
+	t.Run("Fail/AddIndexOnBadType", func(t *testing.T) {
+		err := c.addIndex(schema, Index{Path: "Comments", Unique: false})
+		if err == nil {
+			t.Fatal("index path should not be valid")
+		}
+	})
+	t.Run("Fail/AddUniqueIndexOnNonUniquePath", func(t *testing.T) {
+		_, err := c.Create(util.JSONFromInstance(Person2{Name: "Foo", Age: 42, Toys: Toys{Favorite: "", Names: []string{}}, Comments: []Comment{}}))
+		checkErr(t, err)
+		_, err = c.Create(util.JSONFromInstance(Person2{Name: "Foo", Age: 42, Toys: Toys{Favorite: "", Names: []string{}}, Comments: []Comment{}}))
+		checkErr(t, err)
+		err = c.addIndex(schema, Index{Path: "Name", Unique: true})
+		if err == nil {
+			t.Fatal("index path should not be valid")
+		}
+	})
+}
+
+func TestEmptySchema(t *testing.T) {
+	t.Parallel()
+	db, clean := createTestDB(t)
+	defer clean()
+	// Note the empty schema {}, this is a catch all schema
+	schema := util.SchemaFromSchemaString("{}")
+	_, err := db.NewCollection(CollectionConfig{
+		Name:   "Person",
+		Schema: schema,
+	})
+	if err != ErrInvalidCollectionSchema {
+		t.Fatalf("expected to throw ErrInvalidCollectionSchema error")
+	}
+}
+
+func TestGetName(t *testing.T) {
+	t.Parallel()
+	db, clean := createTestDB(t)
+	defer clean()
+	schema := util.SchemaFromInstance(&Person2{}, false)
+	c, err := db.NewCollection(CollectionConfig{
+		Name:   "Person",
+		Schema: schema,
+	})
+	checkErr(t, err)
+	name := c.GetName()
+	if name != "Person" {
+		t.Fatalf("expected Person, but got %s", name)
+	}
+}
+
+func TestGetSchema(t *testing.T) {
+	t.Parallel()
+	db, clean := createTestDB(t)
+	defer clean()
+	schema := util.SchemaFromInstance(&Person2{}, false)
+	c, err := db.NewCollection(CollectionConfig{
+		Name:   "Person",
+		Schema: schema,
+	})
+	checkErr(t, err)
+	sb, err := json.Marshal(schema)
+	checkErr(t, err)
+	original := gojsonschema.NewBytesLoader(sb).JsonSource().([]byte)
+	got := c.GetSchema()
+	if !bytes.Equal(got, original) {
+		t.Fatal("got schema does not match original")
+	}
+}
+
+func TestGetWriteValidator(t *testing.T) {
+	t.Parallel()
+	db, clean := createTestDB(t)
+	defer clean()
+	schema := util.SchemaFromInstance(&Person2{}, false)
+	wv := "return true"
+	c, err := db.NewCollection(CollectionConfig{
+		Name:           "Person",
+		Schema:         schema,
+		WriteValidator: wv,
+	})
+	checkErr(t, err)
+	if !bytes.Equal(c.GetWriteValidator(), []byte(wv)) {
+		t.Fatal("got write validator does not match original")
+	}
+}
+
+func TestGetReadFilter(t *testing.T) {
+	t.Parallel()
+	db, clean := createTestDB(t)
+	defer clean()
+	schema := util.SchemaFromInstance(&Person2{}, false)
+	rf := "return instance"
+	c, err := db.NewCollection(CollectionConfig{
+		Name:       "Person",
+		Schema:     schema,
+		ReadFilter: rf,
+	})
+	checkErr(t, err)
+	if !bytes.Equal(c.GetReadFilter(), []byte(rf)) {
+		t.Fatal("got read filter does not match original")
+	}
+}
+
+func TestGetIndexes(t *testing.T) {
+	t.Parallel()
+	db, clean := createTestDB(t)
+	defer clean()
+	schema := util.SchemaFromInstance(&Person2{}, false)
+	c, err := db.NewCollection(CollectionConfig{
+		Name:   "Person",
+		Schema: schema,
+	})
+	checkErr(t, err)
+	indexes := c.GetIndexes()
+	if len(indexes) != 0 {
+		t.Fatalf("expected %d indexes, got %d", 0, len(indexes))
+	}
+	err = c.addIndex(schema, Index{Path: "Name", Unique: false})
+	checkErr(t, err)
+	indexes = c.GetIndexes()
+	if len(indexes) != 1 {
+		t.Fatalf("expected %d indexes, got %d", 1, len(indexes))
+	}
+	if indexes[0].Unique {
 		t.Fatal("index on Name should not be unique")
 	}
 }
 
-func TestDropIndex(t *testing.T) {
+func TestDropIndex(t *testing.T) {
+	t.Parallel()
+	db, clean := createTestDB(t)
+	defer clean()
+	schema := util.SchemaFromInstance(&Person2{}, false)
+	c, err := db.NewCollection(CollectionConfig{
+		Name:   "Person",
+		Schema: schema,
+	})
+	checkErr(t, err)
+	err = c.addIndex(schema, Index{Path: "Name", Unique: true})
+	checkErr(t, err)
+	err = c.addIndex(schema, Index{Path: "Age", Unique: false})
+	checkErr(t, err)
+	err = c.addIndex(schema, Index{Path: "Toys.Favorite", Unique: false})
+	checkErr(t, err)
+
+	t.Run("DropIndex", func(t *testing.T) {
+		err := c.dropIndex("Age")
+		checkErr(t, err)
+		indexes := c.GetIndexes()
+		if len(indexes) != 2 {
+			t.Fatalf("expected %d indexes, got %d", 2, len(indexes))
+		}
+	})
+	t.Run("DropNestedIndex", func(t *testing.T) {
+		err := c.dropIndex("Toys.Favorite")
+		checkErr(t, err)
+		indexes := c.GetIndexes()
+		if len(indexes) != 1 {
+			t.Fatalf("expected %d indexes, got %d", 1, len(indexes))
+		}
+	})
+}
+
+func TestIndexManagement(t *testing.T) {
+	t.Parallel()
+	db, clean := createTestDB(t)
+	defer clean()
+	c, err := db.NewCollection(CollectionConfig{
+		Name:   "Person",
+		Schema: util.SchemaFromInstance(&Person{}, false),
+	})
+	checkErr(t, err)
+	_, err = c.CreateMany([][]byte{
+		util.JSONFromInstance(Person{Name: "Foo", Age: 42}),
+		util.JSONFromInstance(Person{Name: "Bar", Age: 42}),
+		util.JSONFromInstance(Person{Name: "Baz", Age: 21}),
+	})
+	checkErr(t, err)
+
+	var progress int
+	checkErr(t, c.CreateIndex(context.Background(), Index{Path: "Age"}, WithReindexChunkSize(2), WithReindexProgress(func(done, total int) {
+		progress = done
+	})))
+	checkErr(t, c.CreateIndex(context.Background(), Index{Path: "Name"}))
+	if progress != 3 {
+		t.Fatalf("expected progress of 3 instances, got %d", progress)
+	}
+	indexes := c.ListIndexes()
+	if len(indexes) != 2 || indexes[0].Path != "Age" || indexes[1].Path != "Name" {
+		t.Fatalf("expected sorted indexes, got %v", indexes)
+	}
+	res, err := c.Find(Where("Age").Eq(float64(42)).UseIndex("Age"))
+	checkErr(t, err)
+	if len(res) != 2 {
+		t.Fatalf("expected existing instances to be indexed, got %d", len(res))
+	}
+
+	// Indexes of duplicate values can't be made unique
+	err = c.CreateIndex(context.Background(), Index{Path: "Age", Unique: true})
+	if !errors.Is(err, ErrCantCreateUniqueIndex) {
+		t.Fatalf("expected unique index error, got %v", err)
+	}
+	if indexes := c.ListIndexes(); len(indexes) != 2 || indexes[0].Unique {
+		t.Fatalf("expected the existing index to be kept, got %v", indexes)
+	}
+
+	checkErr(t, c.DropIndex("Name"))
+	if indexes := c.ListIndexes(); len(indexes) != 1 || indexes[0].Path != "Age" {
+		t.Fatalf("expected the remaining index, got %v", indexes)
+	}
+	keys, err := listKeys(db.datastore, indexPrefix.Child(c.baseKey()).ChildString("Name"))
+	checkErr(t, err)
+	if len(keys) != 0 {
+		t.Fatalf("expected index values to be deleted, got %d", len(keys))
+	}
+	if _, err := c.Find(Where("Name").Eq("Foo").UseIndex("Name")); !errors.Is(err, ErrIndexNotFound) {
+		t.Fatalf("expected index not found error, got %v", err)
+	}
+	if err := c.DropIndex("Name"); !errors.Is(err, ErrIndexNotFound) {
+		t.Fatalf("expected index not found error, got %v", err)
+	}
+}
+
+func TestCreateInstance(t *testing.T) {
+	t.Parallel()
+	t.Run("Single", func(t *testing.T) {
+		t.Parallel()
+		db, clean := createTestDB(t)
+		defer clean()
+		c, err := db.NewCollection(CollectionConfig{
+			Name:   "Person",
+			Schema: util.SchemaFromInstance(&Person{}, false),
+		})
+		checkErr(t, err)
+
+		t.Run("WithImplicitTx", func(t *testing.T) {
+			newPerson := util.JSONFromInstance(Person{Name: "Foo", Age: 42})
+			res, err := c.Create(newPerson)
+			checkErr(t, err)
+			newPerson = util.SetJSONID(res, newPerson)
+			assertPersonInCollection(t, c, newPerson)
+		})
+		t.Run("WithTx", func(t *testing.T) {
+			newPerson := util.JSONFromInstance(Person{Name: "Foo", Age: 42})
+			var res []core.InstanceID
+			err = c.WriteTxn(func(txn *Txn) (err error) {
+				res, err = txn.Create(newPerson)
+				return
+			})
+			checkErr(t, err)
+			newPerson = util.SetJSONID(res[0], newPerson)
+			assertPersonInCollection(t, c, newPerson)
+		})
+	})
+	t.Run("Multiple", func(t *testing.T) {
+		t.Parallel()
+		db, clean := createTestDB(t)
+		defer clean()
+		c, err := db.NewCollection(CollectionConfig{
+			Name:   "Person",
+			Schema: util.SchemaFromInstance(&Person{}, false),
+		})
+		checkErr(t, err)
+
+		newPerson1 := util.JSONFromInstance(Person{Name: "Foo1", Age: 42})
+		newPerson2 := util.JSONFromInstance(Person{Name: "Foo2", Age: 43})
+		var res1 []core.InstanceID
+		var res2 []core.InstanceID
+		err = c.WriteTxn(func(txn *Txn) (err error) {
+			res1, err = txn.Create(newPerson1)
+			if err != nil {
+				return
+			}
+			res2, err = txn.Create(newPerson2)
+			return
+		})
+		checkErr(t, err)
+		newPerson1 = util.SetJSONID(res1[0], newPerson1)
+		newPerson2 = util.SetJSONID(res2[0], newPerson2)
+		assertPersonInCollection(t, c, newPerson1)
+		assertPersonInCollection(t, c, newPerson2)
+	})
+	t.Run("WithDefinedID", func(t *testing.T) {
+		t.Parallel()
+		db, clean := createTestDB(t)
+		defer clean()
+		c, err := db.NewCollection(CollectionConfig{
+			Name:   "Person",
+			Schema: util.SchemaFromInstance(&Person{}, false),
+		})
+		checkErr(t, err)
+
+		definedID := core.NewInstanceID()
+		newPerson := util.JSONFromInstance(&Person{ID: definedID, Name: "Foo1", Age: 42})
+		_, err = c.Create(newPerson)
+		checkErr(t, err)
+
+		exists, err := c.Has(definedID)
+		checkErr(t, err)
+		if !exists {
+			t.Fatal("manually defined instance ID should exist")
+		}
+		assertPersonInCollection(t, c, newPerson)
+	})
+	t.Run("Re-Create", func(t *testing.T) {
+		t.Parallel()
+		db, clean := createTestDB(t)
+		defer clean()
+		m, err := db.NewCollection(CollectionConfig{
+			Name:   "Person",
+			Schema: util.SchemaFromInstance(&Person{}, false),
+		})
+		checkErr(t, err)
+
+		p := util.JSONFromInstance(Person{Name: "Foo1", Age: 42})
+		res, err := m.Create(p)
+		checkErr(t, err)
+		p2 := util.JSONFromInstance(Person{ID: res, Name: "Fool2", Age: 43})
+		_, err = m.Create(p2)
+		if !errors.Is(err, errCantCreateExistingInstance) {
+			t.Fatal("shouldn't create already existing instance")
+		}
+	})
+}
+
+func TestSnapshotReadTxn(t *testing.T) {
+	t.Parallel()
+	db, clean := createTestDB(t)
+	defer clean()
+	c, err := db.NewCollection(CollectionConfig{Name: "Person", Schema: util.SchemaFromInstance(&Person{}, false)})
+	checkErr(t, err)
+	person := Person{Name: "Alice", Age: 42}
+	id, err := c.Create(util.JSONFromInstance(person))
+	checkErr(t, err)
+	current, err := c.FindByID(id)
+	checkErr(t, err)
+
+	// Reduces an event like one received from a peer while the txn is open
+	person.ID = id
+	person.Age = 43
+	events, _, err := db.eventcodec.Create([]core.Action{{
+		Type: core.Save, InstanceID: id, CollectionName: "Person", Previous: current, Current: util.JSONFromInstance(person),
+	}})
+	checkErr(t, err)
+	checkAge := func(txn *Txn, age int) {
+		res, err := txn.FindByID(id)
+		checkErr(t, err)
+		if a := gjson.GetBytes(res, "Age").Int(); a != int64(age) {
+			t.Fatalf("expected age %d, got %d", age, a)
+		}
+		n, err := txn.Count(Where("Age").Eq(float64(age)))
+		checkErr(t, err)
+		if n != 1 {
+			t.Fatalf("expected query to match age %d", age)
+		}
+	}
+	checkErr(t, c.ReadTxn(func(txn *Txn) error {
+		checkAge(txn, 42)
+		checkErr(t, db.Reduce(events))
+		checkAge(txn, 42)
+		return nil
+	}, WithTxnSnapshot(true)))
+	checkErr(t, c.ReadTxn(func(txn *Txn) error {
+		checkAge(txn, 43)
+		return nil
+	}))
+
+	err = db.ReadTxn(func(txn *DBTxn) error {
+		ct, err := txn.Collection("Person")
+		checkErr(t, err)
+		checkAge(ct, 43)
+		if _, err := ct.Create(util.JSONFromInstance(Person{Name: "Bob"})); !errors.Is(err, ErrReadonlyTx) {
+			t.Fatalf("expected readonly txn error, got %v", err)
+		}
+		return nil
+	}, WithTxnSnapshot(true))
+	checkErr(t, err)
+}
+
+func TestExport(t *testing.T) {
+	t.Parallel()
+	db, clean := createTestDB(t)
+	defer clean()
+	c, err := db.NewCollection(CollectionConfig{Name: "Person", Schema: util.SchemaFromInstance(&Person{}, false)})
+	checkErr(t, err)
+	ids := make(map[core.InstanceID]struct{})
+	for i := 0; i < 5; i++ {
+		id, err := c.Create([]byte(fmt.Sprintf("{\n  \"_mod\": 0,\n  \"Name\": \"Person %d\",\n  \"Age\": %d\n}", i, i)))
+		checkErr(t, err)
+		ids[id] = struct{}{}
+	}
+	var buf bytes.Buffer
+	checkErr(t, c.Export(&buf))
+	lines := strings.Split(strings.TrimSuffix(buf.String(), "\n"), "\n")
+	if len(lines) != len(ids) {
+		t.Fatalf("expected %d lines, got %d", len(ids), len(lines))
+	}
+	for _, line := range lines {
+		p := &Person{}
+		util.InstanceFromJSON([]byte(line), p)
+		if _, ok := ids[p.ID]; !ok {
+			t.Fatalf("unexpected exported instance %s", line)
+		}
+		delete(ids, p.ID)
+	}
+}
+
+func TestImport(t *testing.T) {
+	t.Parallel()
+	t.Run("JSONLines", func(t *testing.T) {
+		t.Parallel()
+		db, clean := createTestDB(t)
+		defer clean()
+		c, err := db.NewCollection(CollectionConfig{Name: "Person", Schema: util.SchemaFromInstance(&Person{}, false)})
+		checkErr(t, err)
+		for i := 0; i < 3; i++ {
+			_, err := c.Create(util.JSONFromInstance(Person{Name: fmt.Sprintf("Person %d", i), Age: i}))
+			checkErr(t, err)
+		}
+		var buf bytes.Buffer
+		checkErr(t, c.Export(&buf))
+		c2, err := db.NewCollection(CollectionConfig{Name: "Person2", Schema: util.SchemaFromInstance(&Person{}, false)})
+		checkErr(t, err)
+		var progress []int
+		ids, err := c2.Import(&buf, ImportJSONLines, WithTxnChunkSize(2), WithTxnBatchProgress(func(done, total int) {
+			progress = append(progress, done)
+		}))
+		checkErr(t, err)
+		if len(ids) != 3 {
+			t.Fatalf("expected 3 imported instances, got %d", len(ids))
+		}
+		if !reflect.DeepEqual(progress, []int{2, 3}) {
+			t.Fatalf("unexpected progress %v", progress)
+		}
+		for _, id := range ids {
+			found, err := c.FindByID(id)
+			checkErr(t, err)
+			imported, err := c2.FindByID(id)
+			checkErr(t, err)
+			p1, p2 := &Person{}, &Person{}
+			util.InstanceFromJSON(found, p1)
+			util.InstanceFromJSON(imported, p2)
+			if p1.Name != p2.Name || p1.Age != p2.Age {
+				t.Fatalf("imported instance %v doesn't match %v", p2, p1)
+			}
+		}
+	})
+	t.Run("CSV", func(t *testing.T) {
+		t.Parallel()
+		db, clean := createTestDB(t)
+		defer clean()
+		c, err := db.NewCollection(CollectionConfig{Name: "Person", Schema: util.SchemaFromInstance(&Person{}, false)})
+		checkErr(t, err)
+		ids, err := c.Import(strings.NewReader("_mod,Name,Age\n0,Foo,42\n0,\"Bar, Jr.\",7\n"), ImportCSV)
+		checkErr(t, err)
+		if len(ids) != 2 {
+			t.Fatalf("expected 2 imported instances, got %d", len(ids))
+		}
+		found, err := c.FindByID(ids[1])
+		checkErr(t, err)
+		p := &Person{}
+		util.InstanceFromJSON(found, p)
+		if p.Name != "Bar, Jr." || p.Age != 7 {
+			t.Fatalf("unexpected imported instance %v", p)
+		}
+		_, err = c.Import(strings.NewReader("_mod,Name,Age\n0,Foo,old\n"), ImportCSV)
+		var batchErr *BatchError
+		if !errors.As(err, &batchErr) || !errors.Is(err, ErrInvalidImportRecord) || batchErr.Index != 0 {
+			t.Fatalf("expected invalid record error, got %v", err)
+		}
+	})
+	t.Run("DryRun", func(t *testing.T) {
+		t.Parallel()
+		db, clean := createTestDB(t)
+		defer clean()
+		c, err := db.NewCollection(CollectionConfig{Name: "Person", Schema: util.SchemaFromInstance(&Person{}, false)})
+		checkErr(t, err)
+		input := "{\"_mod\":0,\"Name\":\"Foo\",\"Age\":42}\n\n{\"_mod\":0,\"Name\":\"Bar\",\"Age\":\"old\"}\n"
+		_, err = c.Import(strings.NewReader(input), ImportJSONLines, WithTxnDryRun(true))
+		var batchErr *BatchError
+		if !errors.As(err, &batchErr) || !errors.Is(err, ErrInvalidSchemaInstance) || batchErr.Index != 1 {
+			t.Fatalf("expected invalid instance error, got %v", err)
+		}
+		input = "{\"_mod\":0,\"Name\":\"Foo\",\"Age\":42}\n"
+		ids, err := c.Import(strings.NewReader(input), ImportJSONLines, WithTxnDryRun(true))
+		checkErr(t, err)
+		if len(ids) != 0 {
+			t.Fatalf("dry run shouldn't return IDs")
+		}
+		n, err := c.Count(&Query{})
+		checkErr(t, err)
+		if n != 0 {
+			t.Fatalf("dry run shouldn't create instances, found %d", n)
+		}
+		if _, err := c.Import(strings.NewReader(input), 3); !errors.Is(err, ErrInvalidImportFormat) {
+			t.Fatalf("expected invalid import format error, got %v", err)
+		}
+	})
+}
+
+func TestCollectionStats(t *testing.T) {
+	t.Parallel()
+	db, clean := createTestDB(t)
+	defer clean()
+	c, err := db.NewCollection(CollectionConfig{
+		Name:    "Person",
+		Schema:  util.SchemaFromInstance(&Person{}, false),
+		Indexes: []Index{{Path: "Name"}},
+	})
+	checkErr(t, err)
+	c2, err := db.NewCollection(CollectionConfig{Name: "Person2", Schema: util.SchemaFromInstance(&Person{}, false)})
+	checkErr(t, err)
+	_, err = c2.Create(util.JSONFromInstance(Person{Name: "Other", Age: 1}))
+	checkErr(t, err)
+
+	stats, err := c.Stats()
+	checkErr(t, err)
+	if stats.Instances != 0 || stats.Bytes != 0 || !stats.LastModified.IsZero() {
+		t.Fatalf("unexpected stats of empty collection %+v", stats)
+	}
+	start := time.Now()
+	for _, name := range []string{"Foo", "Bar", "Foo"} {
+		_, err := c.Create(util.JSONFromInstance(Person{Name: name, Age: 42}))
+		checkErr(t, err)
+	}
+	stats, err = c.Stats()
+	checkErr(t, err)
+	if stats.Instances != 3 || stats.Bytes == 0 {
+		t.Fatalf("unexpected instance stats %+v", stats)
+	}
+	if stats.LastModified.Before(start) {
+		t.Fatalf("last modified %v is before %v", stats.LastModified, start)
+	}
+	if len(stats.Indexes) != 1 || stats.Indexes[0].Path != "Name" || stats.Indexes[0].Entries != 2 || stats.Indexes[0].Bytes == 0 {
+		t.Fatalf("unexpected index stats %+v", stats.Indexes)
+	}
+}
+
+func TestReindex(t *testing.T) {
+	t.Parallel()
+	db, clean := createTestDB(t)
+	defer clean()
+	c, err := db.NewCollection(CollectionConfig{Name: "Person", Schema: util.SchemaFromInstance(&Person{}, false)})
+	checkErr(t, err)
+	for _, name := range []string{"Foo", "Bar", "Foo"} {
+		_, err := c.Create(util.JSONFromInstance(Person{Name: name, Age: 42}))
+		checkErr(t, err)
+	}
+	c, err = db.UpdateCollection(CollectionConfig{
+		Name:    "Person",
+		Schema:  util.SchemaFromInstance(&Person{}, false),
+		Indexes: []Index{{Path: "Name"}},
+	})
+	checkErr(t, err)
+	res, err := c.Find(Where("Name").Eq("Foo").UseIndex("Name"))
+	checkErr(t, err)
+	if len(res) != 0 {
+		t.Fatalf("expected existing instances to be unindexed, got %d", len(res))
+	}
+
+	var progress []int
+	checkErr(t, c.Reindex(context.Background(), WithReindexChunkSize(2), WithReindexProgress(func(done, total int) {
+		progress = append(progress, done)
+	})))
+	if len(progress) == 0 || progress[0] != 2 {
+		t.Fatalf("unexpected progress %v", progress)
+	}
+	res, err = c.Find(Where("Name").Eq("Foo").UseIndex("Name"))
+	checkErr(t, err)
+	if len(res) != 2 {
+		t.Fatalf("expected 2 indexed instances, got %d", len(res))
+	}
+	checkErr(t, c.Reindex(context.Background()))
+	res, err = c.Find(Where("Name").Eq("Foo").UseIndex("Name"))
+	checkErr(t, err)
+	if len(res) != 2 {
+		t.Fatalf("expected reindexing to be idempotent, got %d instances", len(res))
+	}
+
+	// Values of dropped indexes are pruned
+	c, err = db.UpdateCollection(CollectionConfig{Name: "Person", Schema: util.SchemaFromInstance(&Person{}, false)})
+	checkErr(t, err)
+	checkErr(t, c.Reindex(context.Background()))
+	keys, err := listKeys(db.datastore, indexPrefix.Child(c.baseKey()).ChildString("Name"))
+	checkErr(t, err)
+	if len(keys) != 0 {
+		t.Fatalf("expected dropped index values to be pruned, got %v", keys)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if err := c.Reindex(ctx); !errors.Is(err, context.Canceled) {
+		t.Fatalf("expected canceled reindex, got %v", err)
+	}
+}
+
+func TestEncryptedFields(t *testing.T) {
+	t.Parallel()
+	t.Run("InvalidFields", func(t *testing.T) {
+		t.Parallel()
+		db, clean := createTestDB(t)
+		defer clean()
+		for _, config := range []CollectionConfig{
+			{Name: "Person", EncryptedFields: []string{idFieldName}},
+			{Name: "Person", EncryptedFields: []string{"Missing"}},
+			{Name: "Person", EncryptedFields: []string{"Name"}, Indexes: []Index{{Path: "Name"}}},
+		} {
+			config.Schema = util.SchemaFromInstance(&Person{}, false)
+			if _, err := db.NewCollection(config); !errors.Is(err, ErrInvalidEncryptedField) {
+				t.Fatalf("expected invalid encrypted field error, got %v", err)
+			}
+		}
+	})
+	t.Run("EncryptDecrypt", func(t *testing.T) {
+		t.Parallel()
+		db, clean := createTestDB(t)
+		defer clean()
+		c, err := db.NewCollection(CollectionConfig{
+			Name:            "Person",
+			Schema:          util.SchemaFromInstance(&Person{}, false),
+			EncryptedFields: []string{"Age"},
+		})
+		checkErr(t, err)
+		if c.EncryptionKey() == nil {
+			t.Fatal("expected a generated encryption key")
+		}
+		if config, err := c.GetConfig(); err != nil || config.EncryptionKey != nil {
+			t.Fatalf("expected config without the encryption key, got %v", err)
+		}
+		id, err := c.Create(util.JSONFromInstance(Person{Name: "Foo", Age: 42}))
+		checkErr(t, err)
+		key := c.baseKey().ChildString(id.String())
+		stored, err := db.datastore.Get(key)
+		checkErr(t, err)
+		if !isEncryptedValue(gjson.GetBytes(stored, "Age")) {
+			t.Fatalf("expected encrypted field to be stored encrypted, got %s", stored)
+		}
+
+		p := &Person{}
+		found, err := c.FindByID(id)
+		checkErr(t, err)
+		util.InstanceFromJSON(found, p)
+		if p.Age != 42 {
+			t.Fatalf("expected decrypted field, got %s", found)
+		}
+		res, err := c.Find(&Query{})
+		checkErr(t, err)
+		if len(res) != 1 || gjson.GetBytes(res[0], "Age").Int() != 42 {
+			t.Fatalf("expected decrypted query results, got %s", res)
+		}
+
+		// Unchanged encrypted fields keep their ciphertext
+		p.Name = "Bar"
+		checkErr(t, c.Save(util.JSONFromInstance(p)))
+		saved, err := db.datastore.Get(key)
+		checkErr(t, err)
+		if gjson.GetBytes(saved, "Age").Str != gjson.GetBytes(stored, "Age").Str {
+			t.Fatalf("expected unchanged encrypted field to keep its ciphertext")
+		}
+		p.Age = 43
+		checkErr(t, c.Save(util.JSONFromInstance(p)))
+		found, err = c.FindByID(id)
+		checkErr(t, err)
+		if gjson.GetBytes(found, "Age").Int() != 43 {
+			t.Fatalf("expected updated encrypted field, got %s", found)
+		}
+
+		// Updates keep the collection key
+		uc, err := db.UpdateCollection(CollectionConfig{
+			Name:            "Person",
+			Schema:          util.SchemaFromInstance(&Person{}, false),
+			EncryptedFields: []string{"Age"},
+		})
+		checkErr(t, err)
+		if !bytes.Equal(uc.EncryptionKey().Bytes(), c.EncryptionKey().Bytes()) {
+			t.Fatal("expected updated collection to keep its encryption key")
+		}
+		found, err = uc.FindByID(id)
+		checkErr(t, err)
+		if gjson.GetBytes(found, "Age").Int() != 43 {
+			t.Fatalf("expected decrypted field after update, got %s", found)
+		}
+
+		// Values encrypted with another key are read as ciphertext
+		other, err := symmetric.NewRandom()
+		checkErr(t, err)
+		oc, err := db.UpdateCollection(CollectionConfig{
+			Name:            "Person",
+			Schema:          util.SchemaFromInstance(&Person{}, false),
+			EncryptedFields: []string{"Age"},
+			EncryptionKey:   other,
+		})
+		checkErr(t, err)
+		found, err = oc.FindByID(id)
+		checkErr(t, err)
+		if !isEncryptedValue(gjson.GetBytes(found, "Age")) {
+			t.Fatalf("expected ciphertext of a foreign key, got %s", found)
+		}
+		res, err = oc.Find(&Query{})
+		checkErr(t, err)
+		if len(res) != 1 || !isEncryptedValue(gjson.GetBytes(res[0], "Age")) {
+			t.Fatalf("expected ciphertext query results, got %s", res)
+		}
+	})
+	t.Run("KeyEncryptionKey", func(t *testing.T) {
+		t.Parallel()
+		kek, err := symmetric.NewRandom()
+		checkErr(t, err)
+		db, clean := createTestDB(t, WithNewKeyEncryptionKey(kek))
+		defer clean()
+		c, err := db.NewCollection(CollectionConfig{
+			Name:            "Person",
+			Schema:          util.SchemaFromInstance(&Person{}, false),
+			EncryptedFields: []string{"Age"},
+		})
+		checkErr(t, err)
+		v, err := db.datastore.Get(dsEncryption.ChildString("Person"))
+		checkErr(t, err)
+		var state encryptionState
+		checkErr(t, json.Unmarshal(v, &state))
+		if !state.Wrapped || bytes.Equal(state.Key, c.EncryptionKey().Bytes()) {
+			t.Fatal("expected the stored encryption key to be wrapped")
+		}
+		_, key, err := db.loadFieldEncryption("Person")
+		checkErr(t, err)
+		if !bytes.Equal(key.Bytes(), c.EncryptionKey().Bytes()) {
+			t.Fatal("expected the unwrapped encryption key of the collection")
+		}
+
+		other, err := symmetric.NewRandom()
+		checkErr(t, err)
+		for _, k := range []*symmetric.Key{nil, other} {
+			db.keyEncryptionKey = k
+			if _, _, err := db.loadFieldEncryption("Person"); !errors.Is(err, ErrInvalidKeyEncryptionKey) {
+				t.Fatalf("expected invalid key encryption key error, got %v", err)
+			}
+		}
+		db.keyEncryptionKey = kek
+	})
+}
+
+func TestReferences(t *testing.T) {
 	t.Parallel()
 	db, clean := createTestDB(t)
 	defer clean()
-	schema := util.SchemaFromInstance(&Person2{}, false)
-	c, err := db.NewCollection(CollectionConfig{
-		Name:   "Person",
-		Schema: schema,
+	persons, err := db.NewCollection(CollectionConfig{Name: "Person", Schema: util.SchemaFromInstance(&Person{}, false)})
+	checkErr(t, err)
+	_, err = db.NewCollection(CollectionConfig{
+		Name:       "Session",
+		Schema:     util.SchemaFromInstance(&Session{}, false),
+		References: []Reference{{Path: "User", Collection: "Missing"}},
+	})
+	if !errors.Is(err, ErrInvalidReference) {
+		t.Fatalf("expected invalid reference error, got %v", err)
+	}
+	sessions, err := db.NewCollection(CollectionConfig{
+		Name:       "Session",
+		Schema:     util.SchemaFromInstance(&Session{}, false),
+		References: []Reference{{Path: "User", Collection: "Person"}},
 	})
 	checkErr(t, err)
-	err = c.addIndex(schema, Index{Path: "Name", Unique: true})
+
+	_, err = sessions.Create(util.JSONFromInstance(Session{User: "missing"}))
+	if !errors.Is(err, ErrReferenceNotFound) {
+		t.Fatalf("expected reference not found error, got %v", err)
+	}
+	_, err = sessions.Create(util.JSONFromInstance(Session{}))
 	checkErr(t, err)
-	err = c.addIndex(schema, Index{Path: "Age", Unique: false})
+	pid, err := persons.Create(util.JSONFromInstance(Person{Name: "Foo"}))
 	checkErr(t, err)
-	err = c.addIndex(schema, Index{Path: "Toys.Favorite", Unique: false})
+	sid, err := sessions.Create(util.JSONFromInstance(Session{User: pid.String()}))
 	checkErr(t, err)
 
-	t.Run("DropIndex", func(t *testing.T) {
-		err := c.dropIndex("Age")
+	// Referenced instances can be created in the same transaction
+	var pid2 core.InstanceID
+	checkErr(t, db.WriteTxn(func(txn *DBTxn) error {
+		ptxn, err := txn.Collection("Person")
 		checkErr(t, err)
-		indexes := c.GetIndexes()
-		if len(indexes) != 2 {
-			t.Fatalf("expected %d indexes, got %d", 2, len(indexes))
-		}
-	})
-	t.Run("DropNestedIndex", func(t *testing.T) {
-		err := c.dropIndex("Toys.Favorite")
+		ids, err := ptxn.Create(util.JSONFromInstance(Person{Name: "Bar"}))
 		checkErr(t, err)
-		indexes := c.GetIndexes()
-		if len(indexes) != 1 {
-			t.Fatalf("expected %d indexes, got %d", 1, len(indexes))
-		}
+		pid2 = ids[0]
+		stxn, err := txn.Collection("Session")
+		checkErr(t, err)
+		_, err = stxn.Create(util.JSONFromInstance(Session{User: pid2.String()}))
+		return err
+	}))
+
+	if err := persons.Delete(pid); !errors.Is(err, ErrReferenced) {
+		t.Fatalf("expected referenced error, got %v", err)
+	}
+	sessions, err = db.UpdateCollection(CollectionConfig{
+		Name:       "Session",
+		Schema:     util.SchemaFromInstance(&Session{}, false),
+		References: []Reference{{Path: "User", Collection: "Person", OnDelete: RefCascade}},
 	})
+	checkErr(t, err)
+	checkErr(t, persons.Delete(pid))
+	if ok, err := sessions.Has(sid); err != nil || ok {
+		t.Fatalf("expected referencing instance to be deleted, got %v %v", ok, err)
+	}
+	n, err := sessions.Count(&Query{})
+	checkErr(t, err)
+	if n != 2 {
+		t.Fatalf("expected 2 remaining sessions, got %d", n)
+	}
 }
 
-func TestCreateInstance(t *testing.T) {
+func TestSchemaDefaults(t *testing.T) {
 	t.Parallel()
-	t.Run("Single", func(t *testing.T) {
+	db, clean := createTestDB(t)
+	defer clean()
+	schema := util.SchemaFromInstance(&Person2{}, false)
+	schema.Definitions["Person2"].Properties["Age"].Default = 18
+	schema.Definitions["Toys"].Properties["Favorite"].Default = "ball"
+	c, err := db.NewCollection(CollectionConfig{Name: "Person", Schema: schema})
+	checkErr(t, err)
+
+	id, err := c.Create([]byte(`{"_mod": 0, "Name": "Foo", "Toys": {"Names": []}, "Comments": []}`))
+	checkErr(t, err)
+	found, err := c.FindByID(id)
+	checkErr(t, err)
+	p := &Person2{}
+	util.InstanceFromJSON(found, p)
+	if p.Age != 18 || p.Toys.Favorite != "ball" {
+		t.Fatalf("expected defaults to be applied, got %s", found)
+	}
+
+	id, err = c.Create(util.JSONFromInstance(Person2{Name: "Bar", Toys: Toys{Favorite: "bone", Names: []string{}}, Comments: []Comment{}}))
+	checkErr(t, err)
+	found, err = c.FindByID(id)
+	checkErr(t, err)
+	p = &Person2{}
+	util.InstanceFromJSON(found, p)
+	if p.Age != 0 || p.Toys.Favorite != "bone" {
+		t.Fatalf("expected set fields to be kept, got %s", found)
+	}
+}
+
+func TestReadTxnValidation(t *testing.T) {
+	t.Parallel()
+	t.Run("TryCreate", func(t *testing.T) {
 		t.Parallel()
 		db, clean := createTestDB(t)
 		defer clean()
-		c, err := db.NewCollection(CollectionConfig{
+		m, err := db.NewCollection(CollectionConfig{
 			Name:   "Person",
 			Schema: util.SchemaFromInstance(&Person{}, false),
 		})
 		checkErr(t, err)
-
-		t.Run("WithImplicitTx", func(t *testing.T) {
-			newPerson := util.JSONFromInstance(Person{Name: "Foo", Age: 42})
-			res, err := c.Create(newPerson)
-			checkErr(t, err)
-			newPerson = util.SetJSONID(res, newPerson)
-			assertPersonInCollection(t, c, newPerson)
-		})
-		t.Run("WithTx", func(t *testing.T) {
-			newPerson := util.JSONFromInstance(Person{Name: "Foo", Age: 42})
-			var res []core.InstanceID
-			err = c.WriteTxn(func(txn *Txn) (err error) {
-				res, err = txn.Create(newPerson)
-				return
-			})
-			checkErr(t, err)
-			newPerson = util.SetJSONID(res[0], newPerson)
-			assertPersonInCollection(t, c, newPerson)
+		err = m.ReadTxn(func(txn *Txn) error {
+			_, err := txn.Create(util.JSONFromInstance(Person{Name: "Foo1", Age: 42}))
+			return err
 		})
+		if !errors.Is(err, ErrReadonlyTx) {
+			t.Fatal("shouldn't write on read-only transaction")
+		}
 	})
-	t.Run("Multiple", func(t *testing.T) {
+	t.Run("TrySave", func(t *testing.T) {
 		t.Parallel()
 		db, clean := createTestDB(t)
 		defer clean()
-		c, err := db.NewCollection(CollectionConfig{
+		m, err := db.NewCollection(CollectionConfig{
 			Name:   "Person",
 			Schema: util.SchemaFromInstance(&Person{}, false),
 		})
 		checkErr(t, err)
-
-		newPerson1 := util.JSONFromInstance(Person{Name: "Foo1", Age: 42})
-		newPerson2 := util.JSONFromInstance(Person{Name: "Foo2", Age: 43})
-		var res1 []core.InstanceID
-		var res2 []core.InstanceID
-		err = c.WriteTxn(func(txn *Txn) (err error) {
-			res1, err = txn.Create(newPerson1)
-			if err != nil {
-				return
-			}
-			res2, err = txn.Create(newPerson2)
-			return
-		})
+		p := util.JSONFromInstance(Person{Name: "Foo1", Age: 42})
+		res, err := m.Create(p)
 		checkErr(t, err)
-		newPerson1 = util.SetJSONID(res1[0], newPerson1)
-		newPerson2 = util.SetJSONID(res2[0], newPerson2)
-		assertPersonInCollection(t, c, newPerson1)
-		assertPersonInCollection(t, c, newPerson2)
+		p = util.SetJSONID(res, p)
+		err = m.ReadTxn(func(txn *Txn) error {
+			return txn.Save(p)
+		})
+		if !errors.Is(err, ErrReadonlyTx) {
+			t.Fatal("shouldn't write on read-only transaction")
+		}
 	})
-	t.Run("WithDefinedID", func(t *testing.T) {
+	t.Run("TryDelete", func(t *testing.T) {
 		t.Parallel()
 		db, clean := createTestDB(t)
 		defer clean()
-		c, err := db.NewCollection(CollectionConfig{
+		m, err := db.NewCollection(CollectionConfig{
 			Name:   "Person",
 			Schema: util.SchemaFromInstance(&Person{}, false),
 		})
 		checkErr(t, err)
+		p := util.JSONFromInstance(Person{Name: "Foo1", Age: 42})
+		res, err := m.Create(p)
+		checkErr(t, err)
+		err = m.ReadTxn(func(txn *Txn) error {
+			return txn.Delete(res)
+		})
+		if !errors.Is(err, ErrReadonlyTx) {
+			t.Fatal("shouldn't write on read-only transaction")
+		}
+	})
+}
 
-		definedID := core.NewInstanceID()
-		newPerson := util.JSONFromInstance(&Person{ID: definedID, Name: "Foo1", Age: 42})
-		_, err = c.Create(newPerson)
+func TestVariadic(t *testing.T) {
+	t.Parallel()
+
+	db, clean := createTestDB(t)
+	defer clean()
+	m, err := db.NewCollection(CollectionConfig{
+		Name:   "Person",
+		Schema: util.SchemaFromInstance(&Person{}, false),
+	})
+	checkErr(t, err)
+
+	p0 := util.JSONFromInstance(&Person{Name: "Foo1", Age: 42})
+	p1 := util.JSONFromInstance(&Person{Name: "Foo2", Age: 43})
+	p2 := util.JSONFromInstance(&Person{Name: "Foo3", Age: 44})
+	res, err := m.CreateMany([][]byte{p0, p1, p2})
+	checkErr(t, err)
+	p0 = util.SetJSONID(res[0], p0)
+	p1 = util.SetJSONID(res[1], p1)
+	p2 = util.SetJSONID(res[2], p2)
+	assertPersonInCollection(t, m, p0)
+	assertPersonInCollection(t, m, p1)
+	assertPersonInCollection(t, m, p2)
+
+	pp0 := &Person{}
+	util.InstanceFromJSON(p0, pp0)
+	pp1 := &Person{}
+	util.InstanceFromJSON(p1, pp1)
+	pp2 := &Person{}
+	util.InstanceFromJSON(p2, pp2)
+	pp0.Age, pp1.Age, pp2.Age = 51, 52, 53
+	saved, err := m.SaveMany([][]byte{util.JSONFromInstance(pp0), util.JSONFromInstance(pp1), util.JSONFromInstance(pp2)})
+	checkErr(t, err)
+	if !reflect.DeepEqual(saved, res) {
+		t.Fatalf("saved ids should be %v, got %v", res, saved)
+	}
+	assertPersonInCollection(t, m, util.JSONFromInstance(pp0))
+	assertPersonInCollection(t, m, util.JSONFromInstance(pp1))
+	assertPersonInCollection(t, m, util.JSONFromInstance(pp2))
+
+	deleted, err := m.DeleteMany([]core.InstanceID{pp0.ID, pp1.ID, pp2.ID})
+	checkErr(t, err)
+	if !reflect.DeepEqual(deleted, res) {
+		t.Fatalf("deleted ids should be %v, got %v", res, deleted)
+	}
+	exist0, err := m.Has(pp0.ID)
+	checkErr(t, err)
+	exist1, err := m.Has(pp1.ID)
+	checkErr(t, err)
+	exist2, err := m.Has(pp2.ID)
+	checkErr(t, err)
+	if exist0 || exist1 || exist2 {
+		t.Fatal("deleted instances shouldn't exist")
+	}
+}
+
+func TestBatchResults(t *testing.T) {
+	t.Parallel()
+
+	db, clean := createTestDB(t)
+	defer clean()
+	m, err := db.NewCollection(CollectionConfig{
+		Name:   "Person",
+		Schema: util.SchemaFromInstance(&Person{}, false),
+	})
+	checkErr(t, err)
+
+	p0 := util.JSONFromInstance(&Person{ID: "p0", Name: "Foo1", Age: 42})
+	p1 := util.JSONFromInstance(&Person{ID: "p1", Name: "Foo2", Age: 43})
+	ids, err := m.CreateMany([][]byte{p0, p1})
+	checkErr(t, err)
+	if !reflect.DeepEqual(ids, []core.InstanceID{"p0", "p1"}) {
+		t.Fatalf("unexpected created ids %v", ids)
+	}
+
+	t.Run("CreateExisting", func(t *testing.T) {
+		p2 := util.JSONFromInstance(&Person{ID: "p2", Name: "Foo3", Age: 44})
+		_, err := m.CreateMany([][]byte{p2, p1})
+		var berr *BatchError
+		if !errors.As(err, &berr) {
+			t.Fatalf("expected batch error, got %v", err)
+		}
+		if berr.Index != 1 || berr.ID != "p1" {
+			t.Fatalf("unexpected failing item %d (%s)", berr.Index, berr.ID)
+		}
+		if !errors.Is(err, errCantCreateExistingInstance) {
+			t.Fatalf("batch error should wrap the item error, got %v", berr.Err)
+		}
+		exists, err := m.Has("p2")
 		checkErr(t, err)
+		if exists {
+			t.Fatal("failed batch shouldn't create any instance")
+		}
+	})
 
-		exists, err := c.Has(definedID)
+	t.Run("SaveInvalid", func(t *testing.T) {
+		_, err := m.SaveMany([][]byte{p0, []byte(`{"_id": "p1", "age": "old"}`)})
+		var berr *BatchError
+		if !errors.As(err, &berr) {
+			t.Fatalf("expected batch error, got %v", err)
+		}
+		if berr.Index != 1 || berr.ID != "p1" {
+			t.Fatalf("unexpected failing item %d (%s)", berr.Index, berr.ID)
+		}
+		if !errors.Is(err, ErrInvalidSchemaInstance) {
+			t.Fatalf("batch error should wrap the item error, got %v", berr.Err)
+		}
+	})
+
+	t.Run("ContinueOnError", func(t *testing.T) {
+		p3 := util.JSONFromInstance(&Person{ID: "p3", Name: "Foo4", Age: 45})
+		invalid := []byte(`{"_id": "p4", "_mod": 0, "Name": "Foo5", "Age": "old"}`)
+		ids, err := m.CreateMany([][]byte{p3, invalid, p1}, WithTxnContinueOnError(true))
+		var perr *PartialBatchError
+		if !errors.As(err, &perr) {
+			t.Fatalf("expected partial batch error, got %v", err)
+		}
+		if !reflect.DeepEqual(ids, []core.InstanceID{"p3"}) {
+			t.Fatalf("unexpected created ids %v", ids)
+		}
+		if len(perr.Results) != 3 {
+			t.Fatalf("expected 3 results, got %d", len(perr.Results))
+		}
+		if perr.Results[0].ID != "p3" || perr.Results[0].Err != nil {
+			t.Fatalf("unexpected result %v", perr.Results[0])
+		}
+		if perr.Results[1].ID != "p4" || !errors.Is(perr.Results[1].Err, ErrInvalidSchemaInstance) {
+			t.Fatalf("unexpected result %v", perr.Results[1])
+		}
+		if perr.Results[2].ID != "p1" || !errors.Is(perr.Results[2].Err, errCantCreateExistingInstance) {
+			t.Fatalf("unexpected result %v", perr.Results[2])
+		}
+		if !errors.Is(err, ErrInvalidSchemaInstance) {
+			t.Fatalf("partial batch error should wrap the first item error, got %v", err)
+		}
+		exists, err := m.Has("p3")
 		checkErr(t, err)
 		if !exists {
-			t.Fatal("manually defined instance ID should exist")
+			t.Fatal("valid instance should be created")
 		}
-		assertPersonInCollection(t, c, newPerson)
+
+		p3 = util.JSONFromInstance(&Person{ID: "p3", Name: "Foo6", Age: 46})
+		ids, err = m.SaveMany([][]byte{invalid, p3}, WithTxnContinueOnError(true))
+		if !errors.As(err, &perr) || perr.Results[0].Err == nil || perr.Results[1].Err != nil {
+			t.Fatalf("expected first save to fail, got %v", err)
+		}
+		if !reflect.DeepEqual(ids, []core.InstanceID{"p3"}) {
+			t.Fatalf("unexpected saved ids %v", ids)
+		}
+		found, err := m.FindByID("p3")
+		checkErr(t, err)
+		p := &Person{}
+		util.InstanceFromJSON(found, p)
+		if p.Name != "Foo6" {
+			t.Fatalf("valid instance should be saved, got %v", p)
+		}
+
+		ids, err = m.SaveMany([][]byte{p3}, WithTxnContinueOnError(true))
+		checkErr(t, err)
+		if len(ids) != 1 {
+			t.Fatalf("unexpected saved ids %v", ids)
+		}
+		checkErr(t, m.Delete("p3"))
 	})
-	t.Run("Re-Create", func(t *testing.T) {
+
+	t.Run("DeleteMissing", func(t *testing.T) {
+		deleted, err := m.DeleteMany([]core.InstanceID{"missing", "p1", "p0"})
+		checkErr(t, err)
+		if !reflect.DeepEqual(deleted, []core.InstanceID{"p1", "p0"}) {
+			t.Fatalf("unexpected deleted ids %v", deleted)
+		}
+		exists, err := m.HasMany([]core.InstanceID{"p0"})
+		checkErr(t, err)
+		if exists {
+			t.Fatal("instances after a missing id should be deleted")
+		}
+	})
+}
+
+func TestBatchLimit(t *testing.T) {
+	t.Parallel()
+
+	t.Run("Split", func(t *testing.T) {
 		t.Parallel()
-		db, clean := createTestDB(t)
+		db, clean := createTestDB(t, WithNewBatchLimit(2, 0))
 		defer clean()
 		m, err := db.NewCollection(CollectionConfig{
 			Name:   "Person",
@@ -652,81 +2255,124 @@ func TestCreateInstance(t *testing.T) {
 		})
 		checkErr(t, err)
 
-		p := util.JSONFromInstance(Person{Name: "Foo1", Age: 42})
-		res, err := m.Create(p)
+		vs := make([][]byte, 5)
+		for i := range vs {
+			vs[i] = util.JSONFromInstance(&Person{ID: core.InstanceID(fmt.Sprintf("p%d", i)), Age: i})
+		}
+		var progress []int
+		ids, err := m.CreateMany(vs, WithTxnBatchProgress(func(done, total int) {
+			if total != len(vs) {
+				t.Fatalf("unexpected progress total %d", total)
+			}
+			progress = append(progress, done)
+		}))
 		checkErr(t, err)
-		p2 := util.JSONFromInstance(Person{ID: res, Name: "Fool2", Age: 43})
-		_, err = m.Create(p2)
-		if !errors.Is(err, errCantCreateExistingInstance) {
-			t.Fatal("shouldn't create already existing instance")
+		if len(ids) != len(vs) {
+			t.Fatalf("expected %d created ids, got %d", len(vs), len(ids))
+		}
+		if !reflect.DeepEqual(progress, []int{2, 4, 5}) {
+			t.Fatalf("unexpected progress %v", progress)
 		}
 	})
-}
 
-func TestReadTxnValidation(t *testing.T) {
-	t.Parallel()
-	t.Run("TryCreate", func(t *testing.T) {
+	t.Run("PartialFailure", func(t *testing.T) {
 		t.Parallel()
-		db, clean := createTestDB(t)
+		db, clean := createTestDB(t, WithNewBatchLimit(2, 0))
 		defer clean()
 		m, err := db.NewCollection(CollectionConfig{
 			Name:   "Person",
 			Schema: util.SchemaFromInstance(&Person{}, false),
 		})
 		checkErr(t, err)
-		err = m.ReadTxn(func(txn *Txn) error {
-			_, err := txn.Create(util.JSONFromInstance(Person{Name: "Foo1", Age: 42}))
-			return err
-		})
-		if !errors.Is(err, ErrReadonlyTx) {
-			t.Fatal("shouldn't write on read-only transaction")
+
+		_, err = m.Create(util.JSONFromInstance(&Person{ID: "p3"}))
+		checkErr(t, err)
+		vs := make([][]byte, 4)
+		for i := range vs {
+			vs[i] = util.JSONFromInstance(&Person{ID: core.InstanceID(fmt.Sprintf("p%d", i)), Age: i})
+		}
+		ids, err := m.CreateMany(vs)
+		var berr *BatchError
+		if !errors.As(err, &berr) || berr.Index != 3 {
+			t.Fatalf("expected batch error for item 3, got %v", err)
+		}
+		if !reflect.DeepEqual(ids, []core.InstanceID{"p0", "p1"}) {
+			t.Fatalf("expected ids of the committed sub-batch, got %v", ids)
+		}
+		exists, err := m.Has("p2")
+		checkErr(t, err)
+		if exists {
+			t.Fatal("failed sub-batch shouldn't create any instance")
 		}
 	})
-	t.Run("TrySave", func(t *testing.T) {
+
+	t.Run("Strict", func(t *testing.T) {
 		t.Parallel()
-		db, clean := createTestDB(t)
+		db, clean := createTestDB(t, WithNewBatchLimit(1, 0), WithNewStrictBatchLimit(true))
 		defer clean()
 		m, err := db.NewCollection(CollectionConfig{
 			Name:   "Person",
 			Schema: util.SchemaFromInstance(&Person{}, false),
 		})
 		checkErr(t, err)
-		p := util.JSONFromInstance(Person{Name: "Foo1", Age: 42})
-		res, err := m.Create(p)
+
+		p0 := util.JSONFromInstance(&Person{ID: "p0"})
+		p1 := util.JSONFromInstance(&Person{ID: "p1"})
+		if _, err := m.CreateMany([][]byte{p0, p1}); !errors.Is(err, ErrBatchTooLarge) {
+			t.Fatalf("expected batch too large error, got %v", err)
+		}
+		_, err = m.CreateMany([][]byte{p0})
 		checkErr(t, err)
-		p = util.SetJSONID(res, p)
-		err = m.ReadTxn(func(txn *Txn) error {
-			return txn.Save(p)
-		})
-		if !errors.Is(err, ErrReadonlyTx) {
-			t.Fatal("shouldn't write on read-only transaction")
+	})
+
+	t.Run("Bytes", func(t *testing.T) {
+		t.Parallel()
+		d := &DB{maxBatchBytes: 10}
+		bounds, err := d.splitBatch([]int{4, 4, 4, 20, 1}, 0)
+		checkErr(t, err)
+		expected := []batchBounds{{0, 2}, {2, 3}, {3, 4}, {4, 5}}
+		if !reflect.DeepEqual(bounds, expected) {
+			t.Fatalf("unexpected sub-batches %v", bounds)
+		}
+		d.strictBatchLimit = true
+		if _, err := d.splitBatch([]int{4, 4, 4}, 0); !errors.Is(err, ErrBatchTooLarge) {
+			t.Fatalf("expected batch too large error, got %v", err)
 		}
 	})
-	t.Run("TryDelete", func(t *testing.T) {
+
+	t.Run("ChunkSize", func(t *testing.T) {
 		t.Parallel()
-		db, clean := createTestDB(t)
+		// Chunks are explicit, so they don't trip a strict db limit
+		db, clean := createTestDB(t, WithNewBatchLimit(3, 0), WithNewStrictBatchLimit(true))
 		defer clean()
 		m, err := db.NewCollection(CollectionConfig{
 			Name:   "Person",
 			Schema: util.SchemaFromInstance(&Person{}, false),
 		})
 		checkErr(t, err)
-		p := util.JSONFromInstance(Person{Name: "Foo1", Age: 42})
-		res, err := m.Create(p)
+
+		vs := make([][]byte, 5)
+		for i := range vs {
+			vs[i] = util.JSONFromInstance(&Person{ID: core.InstanceID(fmt.Sprintf("p%d", i)), Age: i})
+		}
+		var progress []int
+		ids, err := m.SaveMany(vs, WithTxnChunkSize(2), WithTxnBatchProgress(func(done, _ int) {
+			progress = append(progress, done)
+		}))
 		checkErr(t, err)
-		err = m.ReadTxn(func(txn *Txn) error {
-			return txn.Delete(res)
-		})
-		if !errors.Is(err, ErrReadonlyTx) {
-			t.Fatal("shouldn't write on read-only transaction")
+		if len(ids) != len(vs) {
+			t.Fatalf("expected %d saved ids, got %d", len(vs), len(ids))
+		}
+		if !reflect.DeepEqual(progress, []int{2, 4, 5}) {
+			t.Fatalf("unexpected progress %v", progress)
 		}
 	})
 }
 
-func TestVariadic(t *testing.T) {
+func TestThroughput(t *testing.T) {
 	t.Parallel()
 
-	db, clean := createTestDB(t)
+	db, clean := createTestDB(t, WithNewThroughputWindow(time.Minute))
 	defer clean()
 	m, err := db.NewCollection(CollectionConfig{
 		Name:   "Person",
@@ -734,41 +2380,79 @@ func TestVariadic(t *testing.T) {
 	})
 	checkErr(t, err)
 
-	p0 := util.JSONFromInstance(&Person{Name: "Foo1", Age: 42})
-	p1 := util.JSONFromInstance(&Person{Name: "Foo2", Age: 43})
-	p2 := util.JSONFromInstance(&Person{Name: "Foo3", Age: 44})
-	res, err := m.CreateMany([][]byte{p0, p1, p2})
-	checkErr(t, err)
-	p0 = util.SetJSONID(res[0], p0)
-	p1 = util.SetJSONID(res[1], p1)
-	p2 = util.SetJSONID(res[2], p2)
-	assertPersonInCollection(t, m, p0)
-	assertPersonInCollection(t, m, p1)
-	assertPersonInCollection(t, m, p2)
+	tp := m.Throughput()
+	if tp.Window != time.Minute || tp.Reads != 0 || tp.Writes != 0 {
+		t.Fatalf("unexpected initial throughput %+v", tp)
+	}
 
-	pp0 := &Person{}
-	util.InstanceFromJSON(p0, pp0)
-	pp1 := &Person{}
-	util.InstanceFromJSON(p1, pp1)
-	pp2 := &Person{}
-	util.InstanceFromJSON(p2, pp2)
-	pp0.Age, pp1.Age, pp2.Age = 51, 52, 53
-	err = m.SaveMany([][]byte{util.JSONFromInstance(pp0), util.JSONFromInstance(pp1), util.JSONFromInstance(pp2)})
+	id, err := m.Create(util.JSONFromInstance(&Person{Name: "Foo", Age: 42}))
 	checkErr(t, err)
-	assertPersonInCollection(t, m, util.JSONFromInstance(pp0))
-	assertPersonInCollection(t, m, util.JSONFromInstance(pp1))
-	assertPersonInCollection(t, m, util.JSONFromInstance(pp2))
+	for i := 0; i < 3; i++ {
+		_, err = m.FindByID(id)
+		checkErr(t, err)
+	}
 
-	checkErr(t, m.DeleteMany([]core.InstanceID{pp0.ID, pp1.ID, pp2.ID}))
-	exist0, err := m.Has(pp0.ID)
+	tp = m.Throughput()
+	if tp.Writes != 1/time.Minute.Seconds() {
+		t.Fatalf("unexpected write rate %f", tp.Writes)
+	}
+	if tp.Reads != 3/time.Minute.Seconds() {
+		t.Fatalf("unexpected read rate %f", tp.Reads)
+	}
+	if tp.ReadLatency <= 0 || tp.WriteLatency <= 0 {
+		t.Fatalf("expected positive latencies, got %+v", tp)
+	}
+}
+
+func TestUniqueIndex(t *testing.T) {
+	t.Parallel()
+
+	db, clean := createTestDB(t)
+	defer clean()
+	c, err := db.NewCollection(CollectionConfig{
+		Name:    "Person",
+		Schema:  util.SchemaFromInstance(&Person{}, false),
+		Indexes: []Index{{Path: "Name", Unique: true}},
+	})
 	checkErr(t, err)
-	exist1, err := m.Has(pp1.ID)
+
+	foo, err := c.Create(util.JSONFromInstance(&Person{ID: "foo", Name: "Foo"}))
 	checkErr(t, err)
-	exist2, err := m.Has(pp2.ID)
+
+	_, err = c.Create(util.JSONFromInstance(&Person{ID: "bar", Name: "Foo"}))
+	var uerr *UniqueError
+	if !errors.As(err, &uerr) {
+		t.Fatalf("expected unique error, got %v", err)
+	}
+	if uerr.Index != "Name" || uerr.ID != "bar" || uerr.Conflict != foo {
+		t.Fatalf("unexpected unique error %+v", uerr)
+	}
+	if !errors.Is(err, ErrUniqueExists) {
+		t.Fatal("unique error should match ErrUniqueExists")
+	}
+
+	_, err = c.CreateMany([][]byte{
+		util.JSONFromInstance(&Person{ID: "bar", Name: "Bar"}),
+		util.JSONFromInstance(&Person{ID: "baz", Name: "Bar"}),
+	})
+	if !errors.As(err, &uerr) || uerr.ID != "baz" || uerr.Conflict != "bar" {
+		t.Fatalf("expected unique error within the transaction, got %v", err)
+	}
+	exists, err := c.Has("bar")
 	checkErr(t, err)
-	if exist0 || exist1 || exist2 {
-		t.Fatal("deleted instances shouldn't exist")
+	if exists {
+		t.Fatal("rejected transaction shouldn't create any instance")
 	}
+
+	// A value released in the same transaction can be taken
+	err = c.WriteTxn(func(txn *Txn) error {
+		if err := txn.Save(util.JSONFromInstance(&Person{ID: foo, Name: "Qux"})); err != nil {
+			return err
+		}
+		_, err := txn.Create(util.JSONFromInstance(&Person{ID: "bar", Name: "Foo"}))
+		return err
+	})
+	checkErr(t, err)
 }
 
 func TestGetInstance(t *testing.T) {
@@ -850,6 +2534,101 @@ func TestGetInstance(t *testing.T) {
 	})
 }
 
+func TestGetInstances(t *testing.T) {
+	t.Parallel()
+
+	db, clean := createTestDB(t)
+	defer clean()
+	c, err := db.NewCollection(CollectionConfig{
+		Name:   "Person",
+		Schema: util.SchemaFromInstance(&Person{}, false),
+	})
+	checkErr(t, err)
+
+	ids, err := c.CreateMany([][]byte{
+		util.JSONFromInstance(Person{ID: "p0", Name: "Foo"}),
+		util.JSONFromInstance(Person{ID: "p1", Name: "Bar"}),
+		util.JSONFromInstance(Person{ID: "p2", Name: "Baz"}),
+	})
+	checkErr(t, err)
+
+	exists, err := c.HasMany(ids)
+	checkErr(t, err)
+	if !exists {
+		t.Fatal("expected all instances to exist")
+	}
+
+	found, err := c.FindByIDs([]core.InstanceID{"p2", "p0"})
+	checkErr(t, err)
+	names := make([]string, len(found))
+	for i := range found {
+		p := &Person{}
+		util.InstanceFromJSON(found[i], p)
+		names[i] = p.Name
+	}
+	if !reflect.DeepEqual(names, []string{"Baz", "Foo"}) {
+		t.Fatalf("expected instances in the order of ids, got %v", names)
+	}
+
+	if _, err = c.FindByIDs([]core.InstanceID{"p1", "missing"}); !errors.Is(err, ErrInstanceNotFound) {
+		t.Fatalf("expected instance not found error, got %v", err)
+	}
+
+	found, missing, err := c.GetMany([]core.InstanceID{"missing", "p1", "p0", "gone"})
+	checkErr(t, err)
+	if len(found) != 2 || gjson.GetBytes(found[0], "Name").String() != "Bar" || gjson.GetBytes(found[1], "Name").String() != "Foo" {
+		t.Fatalf("expected found instances in the order of ids, got %d", len(found))
+	}
+	if !reflect.DeepEqual(missing, []core.InstanceID{"missing", "gone"}) {
+		t.Fatalf("expected missing ids, got %v", missing)
+	}
+}
+
+func TestGetConfig(t *testing.T) {
+	t.Parallel()
+	db, clean := createTestDB(t)
+	defer clean()
+	config := CollectionConfig{
+		Name:             "Person",
+		Schema:           util.SchemaFromInstance(&Person{}, false),
+		Indexes:          []Index{{Path: "Name", Unique: true}},
+		WriteValidator:   "if (writer) { return true }; return true",
+		ReadFilter:       "return instance",
+		DefaultOrderBy:   Sort{FieldPath: "Age", Desc: true},
+		ConflictStrategy: core.ConflictLastWriterWins,
+		IDStrategy:       IDSequential,
+	}
+	c, err := db.NewCollection(config)
+	checkErr(t, err)
+
+	got, err := c.GetConfig()
+	checkErr(t, err)
+	if got.Name != config.Name || !reflect.DeepEqual(got.Indexes, config.Indexes) || got.DefaultOrderBy != config.DefaultOrderBy {
+		t.Fatalf("unexpected config %+v", got)
+	}
+	if got.ConflictStrategy != config.ConflictStrategy || got.IDStrategy != config.IDStrategy {
+		t.Fatalf("unexpected config %+v", got)
+	}
+	if got.WriteValidator != config.WriteValidator || got.ReadFilter != config.ReadFilter {
+		t.Fatalf("expected validator and filter, got %q and %q", got.WriteValidator, got.ReadFilter)
+	}
+	if got.Schema.Definitions["Person"] == nil {
+		t.Fatalf("expected schema, got %+v", got.Schema)
+	}
+
+	// The config can be used to update the collection
+	got.TTLField = "Age"
+	_, err = db.UpdateCollection(got)
+	checkErr(t, err)
+	got, err = db.GetCollection("Person").GetConfig()
+	checkErr(t, err)
+	if got.TTLField != "Age" || got.ConflictStrategy != config.ConflictStrategy || got.WriteValidator != config.WriteValidator {
+		t.Fatalf("unexpected updated config %+v", got)
+	}
+	_, err = db.GetCollection("Person").Create(util.JSONFromInstance(Person{Name: "Foo", Age: 42}))
+	checkErr(t, err)
+}
+
 func TestModifiedSince(t *testing.T) {
 	t.Parallel()
 	t.Run("WithiSingleCreate", func(t *testing.T) {
@@ -939,6 +2718,82 @@ func TestModifiedSince(t *testing.T) {
 	})
 }
 
+func TestFindModifiedSince(t *testing.T) {
+	t.Parallel()
+	db, clean := createTestDB(t, WithNewTombstoneRetention(true))
+	defer clean()
+	c, err := db.NewCollection(CollectionConfig{
+		Name:   "Person",
+		Schema: util.SchemaFromInstance(&Person{}, false),
+	})
+	checkErr(t, err)
+
+	ids, err := c.CreateMany([][]byte{
+		util.JSONFromInstance(Person{ID: "a", Name: "Alice", Age: 42}),
+		util.JSONFromInstance(Person{ID: "b", Name: "Bob", Age: 33}),
+		util.JSONFromInstance(Person{ID: "c", Name: "Carl", Age: 20}),
+	})
+	checkErr(t, err)
+	since := time.Now()
+
+	checkErr(t, c.Save(util.JSONFromInstance(Person{ID: ids[0], Name: "Alice", Age: 43})))
+	_, err = c.Create(util.JSONFromInstance(Person{ID: "d", Name: "Dan", Age: 51}))
+	checkErr(t, err)
+	checkErr(t, c.Delete(ids[1]))
+
+	assertNames := func(instances [][]byte, names ...string) {
+		got := make([]string, len(instances))
+		for i := range instances {
+			p := Person{}
+			util.InstanceFromJSON(instances[i], &p)
+			got[i] = p.Name
+		}
+		if !reflect.DeepEqual(got, names) {
+			t.Fatalf("expected instances %v, got %v", names, got)
+		}
+	}
+
+	mods, err := c.FindModifiedSince(since, nil)
+	checkErr(t, err)
+	assertNames(mods.Instances, "Alice", "Dan")
+	if len(mods.Deleted) != 0 {
+		t.Fatal("deleted instances should only be returned when included")
+	}
+
+	mods, err = c.FindModifiedSince(since, Where("Age").Gt(45.0).IncludeDeleted())
+	checkErr(t, err)
+	assertNames(mods.Instances, "Dan")
+	if len(mods.Deleted) != 0 {
+		t.Fatalf("deleted instances should match the query, got %v", mods.Deleted)
+	}
+
+	mods, err = c.FindModifiedSince(since, (&Query{}).IncludeDeleted().LimitTo(1))
+	checkErr(t, err)
+	assertNames(mods.Instances, "Alice")
+	if !reflect.DeepEqual(mods.Deleted, []core.InstanceID{"b"}) {
+		t.Fatalf("expected deleted instance b, got %v", mods.Deleted)
+	}
+
+	mods, err = c.FindModifiedSince(time.Time{}, nil)
+	checkErr(t, err)
+	assertNames(mods.Instances, "Carl", "Alice", "Dan")
+
+	ec, err := db.NewCollection(CollectionConfig{
+		Name:            "EncryptedPerson",
+		Schema:          util.SchemaFromInstance(&Person{}, false),
+		EncryptedFields: []string{"Name"},
+	})
+	checkErr(t, err)
+	_, err = ec.CreateMany([][]byte{
+		util.JSONFromInstance(Person{ID: "a", Name: "Alice", Age: 42}),
+		util.JSONFromInstance(Person{ID: "b", Name: "Bob", Age: 33}),
+	})
+	checkErr(t, err)
+	mods, err = ec.FindModifiedSince(since, Where("Name").Eq("Bob"))
+	checkErr(t, err)
+	assertNames(mods.Instances, "Bob")
+}
+
 func TestVerifyInstance(t *testing.T) {
 	t.Parallel()
 	t.Run("WithoutWriteValidator", func(t *testing.T) {
@@ -989,30 +2844,169 @@ func TestVerifyInstance(t *testing.T) {
 		})
 		checkErr(t, err)
 
-		newPerson := util.JSONFromInstance(Person{Name: "Alice", Age: 42})
-		var res []core.InstanceID
-		err = c.WriteTxn(func(txn *Txn) (err error) {
-			res, err = txn.Create(newPerson)
-			return
-		})
-		checkErr(t, err)
+		newPerson := util.JSONFromInstance(Person{Name: "Alice", Age: 42})
+		var res []core.InstanceID
+		err = c.WriteTxn(func(txn *Txn) (err error) {
+			res, err = txn.Create(newPerson)
+			return
+		})
+		checkErr(t, err)
+
+		newPerson = util.JSONFromInstance(Person{ID: res[0], Name: "Alice", Age: 51})
+		err = c.WriteTxn(func(txn *Txn) (err error) {
+			err = txn.Verify(newPerson)
+			return
+		})
+		if err == nil {
+			t.Fatal("write should have been invalid")
+		}
+
+		newPerson = util.JSONFromInstance(Person{ID: res[0], Name: "Alice", Age: 43})
+		err = c.WriteTxn(func(txn *Txn) (err error) {
+			err = txn.Verify(newPerson)
+			return
+		})
+		checkErr(t, err)
+	})
+}
+
+func TestValidateInstance(t *testing.T) {
+	t.Parallel()
+	db, clean := createTestDB(t)
+	defer clean()
+	c, err := db.NewCollection(CollectionConfig{
+		Name:           "Person",
+		Schema:         util.SchemaFromInstance(&Person{}, false),
+		WriteValidator: "return event.patch.json_patch.Age <= 50",
+		IDStrategy:     IDSequential,
+	})
+	checkErr(t, err)
+
+	checkErr(t, c.Validate(util.JSONFromInstance(Person{Name: "Alice", Age: 42})))
+	if err := c.Validate(util.JSONFromInstance(Person{Name: "Alice", Age: 51})); err == nil {
+		t.Fatal("new instance should be invalid")
+	}
+	if err := c.Validate([]byte(`{"_mod": 0, "Name": "Alice", "Age": "old"}`)); !errors.Is(err, ErrInvalidSchemaInstance) {
+		t.Fatalf("expected invalid schema instance error, got %v", err)
+	}
+	n, err := c.Count(&Query{})
+	checkErr(t, err)
+	if n != 0 {
+		t.Fatalf("validated instances shouldn't be created, found %d", n)
+	}
+
+	id, err := c.Create(util.JSONFromInstance(Person{Name: "Alice", Age: 42}))
+	checkErr(t, err)
+	if id != "00000000000000000001" {
+		t.Fatalf("validation shouldn't advance the ID sequence, got %s", id)
+	}
+	checkErr(t, c.Validate(util.JSONFromInstance(Person{ID: id, Name: "Alice", Age: 43})))
+	if err := c.Validate(util.JSONFromInstance(Person{ID: id, Name: "Alice", Age: 51})); err == nil {
+		t.Fatal("saved instance should be invalid")
+	}
+	found, err := c.FindByID(id)
+	checkErr(t, err)
+	p := &Person{}
+	util.InstanceFromJSON(found, p)
+	if p.Age != 42 {
+		t.Fatalf("validated instance shouldn't be saved, got %v", p)
+	}
+}
+
+func TestPatchInstance(t *testing.T) {
+	t.Parallel()
+
+	db, clean := createTestDB(t)
+	defer clean()
+	c, err := db.NewCollection(CollectionConfig{
+		Name:   "Person",
+		Schema: util.SchemaFromInstance(&Person{}, false),
+	})
+	checkErr(t, err)
+
+	id, err := c.Create(util.JSONFromInstance(Person{Name: "Foo", Age: 42}))
+	checkErr(t, err)
+	checkErr(t, c.Patch(id, []byte(`{"Age": 43}`)))
+	found, err := c.FindByID(id)
+	checkErr(t, err)
+	p := &Person{}
+	util.InstanceFromJSON(found, p)
+	if p.Name != "Foo" || p.Age != 43 {
+		t.Fatalf("expected only the patched field to change, got %v", p)
+	}
+
+	if err := c.Patch(id, []byte(`{"Age": "old"}`)); !errors.Is(err, ErrInvalidSchemaInstance) {
+		t.Fatalf("expected invalid schema error, got %v", err)
+	}
+	if err := c.Patch(id, []byte(`{"_id": "other"}`)); !errors.Is(err, ErrInvalidPatch) {
+		t.Fatalf("expected invalid patch error, got %v", err)
+	}
+	if err := c.Patch(id, []byte(`{"Age":`)); !errors.Is(err, ErrInvalidPatch) {
+		t.Fatalf("expected invalid patch error, got %v", err)
+	}
+	if err := c.Patch("missing", []byte(`{"Age": 1}`)); !errors.Is(err, ErrInstanceNotFound) {
+		t.Fatalf("expected instance not found error, got %v", err)
+	}
+}
 
-		newPerson = util.JSONFromInstance(Person{ID: res[0], Name: "Alice", Age: 51})
-		err = c.WriteTxn(func(txn *Txn) (err error) {
-			err = txn.Verify(newPerson)
-			return
-		})
-		if err == nil {
-			t.Fatal("write should have been invalid")
+func TestUpsertInstance(t *testing.T) {
+	t.Parallel()
+
+	db, clean := createTestDB(t)
+	defer clean()
+	c, err := db.NewCollection(CollectionConfig{
+		Name:   "Person",
+		Schema: util.SchemaFromInstance(&Person{}, false),
+	})
+	checkErr(t, err)
+
+	l, err := db.Listen()
+	checkErr(t, err)
+	var types []ActionType
+	var wg sync.WaitGroup
+	wg.Add(1)
+	go func() {
+		for a := range l.Channel() {
+			types = append(types, a.Type)
 		}
+		wg.Done()
+	}()
 
-		newPerson = util.JSONFromInstance(Person{ID: res[0], Name: "Alice", Age: 43})
-		err = c.WriteTxn(func(txn *Txn) (err error) {
-			err = txn.Verify(newPerson)
-			return
-		})
-		checkErr(t, err)
+	id, err := c.Upsert(util.JSONFromInstance(Person{Name: "Foo", Age: 42}))
+	checkErr(t, err)
+	_, err = c.Upsert(util.JSONFromInstance(Person{ID: id, Name: "Foo", Age: 43}))
+	checkErr(t, err)
+	found, err := c.FindByID(id)
+	checkErr(t, err)
+	p := &Person{}
+	util.InstanceFromJSON(found, p)
+	if p.Age != 43 {
+		t.Fatalf("expected upsert to save the existing instance, got age %d", p.Age)
+	}
+
+	// Instances written earlier in the transaction are saved
+	err = c.WriteTxn(func(txn *Txn) error {
+		if _, err := txn.Upsert(util.JSONFromInstance(Person{ID: "p1", Name: "Bar"})); err != nil {
+			return err
+		}
+		_, err := txn.Upsert(util.JSONFromInstance(Person{ID: "p1", Name: "Baz"}))
+		return err
 	})
+	checkErr(t, err)
+	found, err = c.FindByID("p1")
+	checkErr(t, err)
+	util.InstanceFromJSON(found, p)
+	if p.Name != "Baz" {
+		t.Fatalf("expected the last upsert to win, got %s", p.Name)
+	}
+
+	time.Sleep(time.Millisecond * 100)
+	l.Close()
+	wg.Wait()
+	expected := []ActionType{ActionCreate, ActionSave, ActionCreate, ActionSave}
+	if !reflect.DeepEqual(types, expected) {
+		t.Fatalf("expected actions %v, got %v", expected, types)
+	}
 }
 
 func TestSaveInstance(t *testing.T) {
@@ -1101,6 +3095,41 @@ func TestSaveInstance(t *testing.T) {
 			t.Fatalf(errInvalidInstanceState)
 		}
 	})
+	t.Run("CompareMod", func(t *testing.T) {
+		t.Parallel()
+		db, clean := createTestDB(t)
+		defer clean()
+		m, err := db.NewCollection(CollectionConfig{
+			Name:   "Person",
+			Schema: util.SchemaFromInstance(&Person{}, false),
+		})
+		checkErr(t, err)
+		id, err := m.Create(util.JSONFromInstance(Person{Name: "Alice", Age: 42}))
+		checkErr(t, err)
+		instance, err := m.FindByID(id)
+		checkErr(t, err)
+		stale := &Person{}
+		util.InstanceFromJSON(instance, stale)
+
+		fresh := *stale
+		fresh.Age = 43
+		checkErr(t, m.Save(util.JSONFromInstance(fresh), WithTxnCompareMod(true)))
+		stale.Age = 44
+		if err := m.Save(util.JSONFromInstance(stale), WithTxnCompareMod(true)); !errors.Is(err, ErrConflict) {
+			t.Fatalf("expected conflict error, got %v", err)
+		}
+		instance, err = m.FindByID(id)
+		checkErr(t, err)
+		util.InstanceFromJSON(instance, stale)
+		if stale.Age != 43 {
+			t.Fatalf(errInvalidInstanceState)
+		}
+		// New instances are saved without a _mod
+		checkErr(t, m.Save(util.JSONFromInstance(Person{ID: core.NewInstanceID(), Name: "Bob"}), WithTxnCompareMod(true)))
+		if err := m.Save(util.JSONFromInstance(Person{ID: core.NewInstanceID(), Mod: 1, Name: "Carol"}), WithTxnCompareMod(true)); !errors.Is(err, ErrConflict) {
+			t.Fatalf("expected conflict error, got %v", err)
+		}
+	})
 }
 
 func TestModTagIncrement(t *testing.T) {
@@ -1213,6 +3242,63 @@ func TestDeleteInstance(t *testing.T) {
 	}
 }
 
+func TestContentHash(t *testing.T) {
+	t.Parallel()
+	db, clean := createTestDB(t)
+	defer clean()
+	c, err := db.NewCollection(CollectionConfig{
+		Name:   "Person",
+		Schema: util.SchemaFromInstance(&Person{}, false),
+	})
+	checkErr(t, err)
+
+	id, err := c.Create(util.JSONFromInstance(Person{Name: "Alice", Age: 42}))
+	checkErr(t, err)
+	h1, err := c.ContentHash(id, nil)
+	checkErr(t, err)
+
+	// Touching the instance only changes _mod, which is excluded
+	checkErr(t, c.Save(util.JSONFromInstance(Person{ID: id, Name: "Alice", Age: 42})))
+	h2, err := c.ContentHash(id, nil)
+	checkErr(t, err)
+	if h1 != h2 {
+		t.Fatalf("content hash should be stable, got %s and %s", h1, h2)
+	}
+
+	checkErr(t, c.Save(util.JSONFromInstance(Person{ID: id, Name: "Alice", Age: 43})))
+	h3, err := c.ContentHash(id, nil)
+	checkErr(t, err)
+	if h1 == h3 {
+		t.Fatal("content hash should change with content")
+	}
+	h4, err := c.ContentHash(id, []string{"Age"})
+	checkErr(t, err)
+	checkErr(t, c.Save(util.JSONFromInstance(Person{ID: id, Name: "Alice", Age: 44})))
+	h5, err := c.ContentHash(id, []string{"Age"})
+	checkErr(t, err)
+	if h4 != h5 {
+		t.Fatal("content hash should ignore excluded paths")
+	}
+
+	if _, err := c.ContentHash(core.NewInstanceID(), nil); !errors.Is(err, ErrInstanceNotFound) {
+		t.Fatalf("expected not found error, got %v", err)
+	}
+}
+
+func TestCanonicalJSON(t *testing.T) {
+	t.Parallel()
+	a, err := canonicalJSON([]byte(`{"b": 1.0, "a": {"d": [1, "x"], "c": null}}`))
+	checkErr(t, err)
+	b, err := canonicalJSON([]byte(`{"a":{"c":null,"d":[1e0,"x"]},"b":1}`))
+	checkErr(t, err)
+	if !bytes.Equal(a, b) {
+		t.Fatalf("canonical forms differ: %s != %s", a, b)
+	}
+	if string(a) != `{"a":{"c":null,"d":[1,"x"]},"b":1}` {
+		t.Fatalf("unexpected canonical form: %s", a)
+	}
+}
+
 type PersonFake struct {
 	ID   core.InstanceID `json:"_id"`
 	Name string
@@ -1258,3 +3344,366 @@ func assertPersonInCollection(t *testing.T, c *Collection, personBytes []byte) {
 		t.Fatalf(errInvalidInstanceState)
 	}
 }
+
+func TestValidationFailures(t *testing.T) {
+	t.Parallel()
+	var failures []ValidationFailure
+	db, clean := createTestDB(t, WithNewValidationMetrics(true), WithNewValidationFailureHandler(func(f ValidationFailure) {
+		failures = append(failures, f)
+	}, true))
+	defer clean()
+	c, err := db.NewCollection(CollectionConfig{
+		Name:   "Person",
+		Schema: util.SchemaFromInstance(&Person{}, false),
+	})
+	checkErr(t, err)
+
+	_, err = c.Create(util.JSONFromInstance(Person{Name: "real"}))
+	checkErr(t, err)
+	f := util.JSONFromInstance(PersonFake{Name: "fake"})
+	_, err = c.Create(f)
+	if !errors.Is(err, ErrInvalidSchemaInstance) {
+		t.Fatalf("instance should be invalid compared to schema, got: %v", err)
+	}
+	err = c.Save(f)
+	if !errors.Is(err, ErrInvalidSchemaInstance) {
+		t.Fatalf("instance should be invalid compared to schema, got: %v", err)
+	}
+
+	stats := c.GetValidationStats()
+	if stats.Total != 2 {
+		t.Fatalf("expected 2 failures, got %d", stats.Total)
+	}
+	if stats.Fields["(root)"] == 0 {
+		t.Fatalf("expected root failures, got %v", stats.Fields)
+	}
+	if len(failures) != 2 {
+		t.Fatalf("expected 2 handled failures, got %d", len(failures))
+	}
+	if failures[0].Collection != "Person" || failures[0].Instance != nil {
+		t.Fatalf("unexpected failure: %v", failures[0])
+	}
+}
+
+func TestValidationError(t *testing.T) {
+	t.Parallel()
+	db, clean := createTestDB(t)
+	defer clean()
+	c, err := db.NewCollection(CollectionConfig{
+		Name:   "Person",
+		Schema: util.SchemaFromInstance(&Person{}, false),
+	})
+	checkErr(t, err)
+
+	_, err = c.Create([]byte(`{"_id": "", "_mod": 0, "Name": "Foo", "Age": "old"}`))
+	var verr *ValidationError
+	if !errors.As(err, &verr) || !errors.Is(err, ErrInvalidSchemaInstance) {
+		t.Fatalf("expected a validation error, got %v", err)
+	}
+	if len(verr.Violations) != 1 {
+		t.Fatalf("expected 1 violation, got %v", verr.Violations)
+	}
+	if v := verr.Violations[0]; v.Pointer != "/Age" || v.Constraint != "invalid_type" || v.Value != "old" {
+		t.Fatalf("unexpected violation %+v", v)
+	}
+
+	_, err = c.Create([]byte(`{"_id": "", "_mod": 0, "Age": 42}`))
+	if !errors.As(err, &verr) || len(verr.Violations) != 1 {
+		t.Fatalf("expected a validation error, got %v", err)
+	}
+	if v := verr.Violations[0]; v.Pointer != "/Name" || v.Constraint != "required" || v.Value != nil {
+		t.Fatalf("unexpected violation %+v", v)
+	}
+}
+
+func TestFindDeleted(t *testing.T) {
+	t.Parallel()
+	db, clean := createTestDB(t, WithNewTombstoneRetention(true))
+	defer clean()
+	c, err := db.NewCollection(CollectionConfig{
+		Name:   "Person",
+		Schema: util.SchemaFromInstance(&Person{}, false),
+	})
+	checkErr(t, err)
+
+	ids, err := c.CreateMany([][]byte{
+		util.JSONFromInstance(Person{Name: "Alice", Age: 42}),
+		util.JSONFromInstance(Person{Name: "Bob", Age: 24}),
+	})
+	checkErr(t, err)
+	before := time.Now()
+	checkErr(t, c.Delete(ids[0]))
+
+	res, err := c.Find(&Query{})
+	checkErr(t, err)
+	if len(res) != 1 {
+		t.Fatalf("expected 1 instance, got %d", len(res))
+	}
+	res, err = c.Find((&Query{}).IncludeDeleted())
+	checkErr(t, err)
+	if len(res) != 2 {
+		t.Fatalf("expected 2 instances, got %d", len(res))
+	}
+	var marked int
+	for _, r := range res {
+		if ts := gjson.GetBytes(r, "_deleted"); ts.Exists() {
+			marked++
+			if ts.Int() < before.UnixNano() {
+				t.Fatalf("unexpected deletion time %d", ts.Int())
+			}
+		}
+	}
+	if marked != 1 {
+		t.Fatalf("expected 1 instance marked as deleted, got %d", marked)
+	}
+	res, err = c.Find((&Query{}).IncludeDeletedSince(time.Now()))
+	checkErr(t, err)
+	if len(res) != 1 {
+		t.Fatalf("expected instances deleted since now to be excluded, got %d", len(res))
+	}
+	deleted, err := c.FindDeleted((&Query{}).IncludeDeletedSince(before))
+	checkErr(t, err)
+	if len(deleted) != 1 {
+		t.Fatalf("expected 1 deleted instance, got %d", len(deleted))
+	}
+	deleted, err = c.FindDeleted(Where("Name").Eq("Alice"))
+	checkErr(t, err)
+	if len(deleted) != 1 || deleted[0].ID != ids[0] || deleted[0].Deleted.Before(before) {
+		t.Fatalf("unexpected deleted instances: %v", deleted)
+	}
+	p := &Person{}
+	util.InstanceFromJSON(deleted[0].Instance, p)
+	if p.Name != "Alice" || p.Age != 42 {
+		t.Fatalf(errInvalidInstanceState)
+	}
+	deleted, err = c.FindDeleted(Where("Name").Eq("Bob"))
+	checkErr(t, err)
+	if len(deleted) != 0 {
+		t.Fatalf("expected no deleted instances, got %d", len(deleted))
+	}
+
+	// Re-creating the instance removes the tombstone
+	_, err = c.Create(util.JSONFromInstance(Person{ID: ids[0], Name: "Alice", Age: 42}))
+	checkErr(t, err)
+	deleted, err = c.FindDeleted(nil)
+	checkErr(t, err)
+	if len(deleted) != 0 {
+		t.Fatalf("expected no deleted instances, got %d", len(deleted))
+	}
+}
+
+func TestCheckSchema(t *testing.T) {
+	t.Parallel()
+	db, clean := createTestDB(t)
+	defer clean()
+	c, err := db.NewCollection(CollectionConfig{
+		Name:   "Dog",
+		Schema: util.SchemaFromInstance(&Dog{}, false),
+	})
+	checkErr(t, err)
+	_, err = c.Create(util.JSONFromInstance(Dog{Name: "Rex", Comments: []Comment{}}))
+	checkErr(t, err)
+	long, err := c.Create(util.JSONFromInstance(Dog{Name: "Scooby", Comments: []Comment{}}))
+	checkErr(t, err)
+
+	newSchema := util.SchemaFromSchemaString(`{
+		"$schema": "http://json-schema.org/draft-04/schema#",
+		"type": "object",
+		"properties": {
+			"_id": {"type": "string"},
+			"Name": {"type": "string", "maxLength": 5},
+			"Comments": {"type": "array"}
+		},
+		"additionalProperties": false
+	}`)
+	failed, err := c.CheckSchema(newSchema)
+	checkErr(t, err)
+	if !reflect.DeepEqual(failed, []core.InstanceID{long}) {
+		t.Fatalf("expected failing instances %v, got %v", []core.InstanceID{long}, failed)
+	}
+	if n, err := c.Find(nil); err != nil || len(n) != 2 {
+		t.Fatal("checking a schema shouldn't change instances")
+	}
+
+	if _, err := c.CheckSchema(util.SchemaFromSchemaString(`{"type": "object"}`)); !errors.Is(err, ErrInvalidCollectionSchema) {
+		t.Fatalf("expected invalid schema error, got %v", err)
+	}
+}
+
+func TestComputedFields(t *testing.T) {
+	t.Parallel()
+	db, clean := createTestDB(t)
+	defer clean()
+	c, err := db.NewCollection(CollectionConfig{
+		Name:   "Person",
+		Schema: util.SchemaFromInstance(&Person{}, false),
+		ComputedFields: []ComputedField{
+			{Path: "Greeting", JS: "return 'Hello ' + instance.Name"},
+			{Path: "Older", Func: func(instance []byte) (interface{}, error) {
+				return gjson.GetBytes(instance, "Age").Int() + 1, nil
+			}},
+		},
+	})
+	checkErr(t, err)
+
+	id, err := c.Create(util.JSONFromInstance(Person{Name: "Foo", Age: 42}))
+	checkErr(t, err)
+	res, err := c.FindByID(id)
+	checkErr(t, err)
+	if gjson.GetBytes(res, "Greeting").String() != "Hello Foo" || gjson.GetBytes(res, "Older").Int() != 43 {
+		t.Fatalf("expected computed fields, got %s", res)
+	}
+
+	// Read instances can be saved back
+	res, err = sjson.SetBytes(res, "Name", "Bar")
+	checkErr(t, err)
+	checkErr(t, c.Save(res))
+	found, err := c.Find(&Query{})
+	checkErr(t, err)
+	if len(found) != 1 || gjson.GetBytes(found[0], "Greeting").String() != "Hello Bar" {
+		t.Fatalf("expected computed fields of the saved instance, got %s", found)
+	}
+	var stored []byte
+	err = c.ReadTxn(func(txn *Txn) error {
+		stored, err = txn.reader().Get(c.baseKey().ChildString(id.String()))
+		return err
+	})
+	checkErr(t, err)
+	if gjson.GetBytes(stored, "Greeting").Exists() {
+		t.Fatalf("expected computed fields not to be stored, got %s", stored)
+	}
+
+	_, err = db.NewCollection(CollectionConfig{
+		Name:           "Person2",
+		Schema:         util.SchemaFromInstance(&Person{}, false),
+		ComputedFields: []ComputedField{{Path: "Name", JS: "return 'Foo'"}},
+	})
+	if !errors.Is(err, ErrInvalidComputedField) {
+		t.Fatalf("expected invalid computed field error, got %v", err)
+	}
+}
+
+func TestSerializeWrites(t *testing.T) {
+	t.Parallel()
+	db, clean := createTestDB(t)
+	defer clean()
+	c, err := db.NewCollection(CollectionConfig{
+		Name:            "Person",
+		Schema:          util.SchemaFromInstance(&Person{}, false),
+		SerializeWrites: true,
+	})
+	checkErr(t, err)
+	config, err := c.GetConfig()
+	checkErr(t, err)
+	if !config.SerializeWrites {
+		t.Fatal("config should serialize writes")
+	}
+
+	waitQueue := func(n int) {
+		for c.WriteQueueLen() != n {
+			time.Sleep(time.Millisecond)
+		}
+	}
+	release := make(chan struct{})
+	errs := make(chan error, 3)
+	go func() {
+		errs <- c.WriteTxn(func(txn *Txn) error {
+			<-release
+			_, err := txn.Create(util.JSONFromInstance(&Person{ID: "0", Name: "Foo"}))
+			return err
+		})
+	}()
+	waitQueue(1)
+	var lock sync.Mutex
+	positions := make([][]int, 2)
+	for i := range positions {
+		i := i
+		go func() {
+			_, err := c.Create(util.JSONFromInstance(&Person{ID: core.InstanceID(fmt.Sprint(i + 1)), Name: "Foo"}), WithTxnWritePosition(func(ahead int) {
+				lock.Lock()
+				defer lock.Unlock()
+				positions[i] = append(positions[i], ahead)
+			}))
+			errs <- err
+		}()
+		waitQueue(i + 2)
+	}
+	close(release)
+	for i := 0; i < 3; i++ {
+		checkErr(t, <-errs)
+	}
+	if !reflect.DeepEqual(positions, [][]int{{1, 0}, {2, 1, 0}}) {
+		t.Fatalf("unexpected write positions %v", positions)
+	}
+	if n := c.WriteQueueLen(); n != 0 {
+		t.Fatalf("expected empty write queue, got %d", n)
+	}
+
+	var last int64
+	for i := 0; i < 3; i++ {
+		found, err := c.FindByID(core.InstanceID(fmt.Sprint(i)))
+		checkErr(t, err)
+		mod := gjson.GetBytes(found, modFieldName).Int()
+		if mod <= last {
+			t.Fatalf("writes should be committed in queue order")
+		}
+		last = mod
+	}
+}
+
+func TestSchemaRefs(t *testing.T) {
+	t.Parallel()
+	db, clean := createTestDB(t)
+	defer clean()
+	_, err := db.NewCollection(CollectionConfig{Name: "Person2", Schema: util.SchemaFromInstance(&Person2{}, false)})
+	checkErr(t, err)
+
+	schema := `{"$ref": "#/definitions/Kid", "definitions": {"Kid": {"type": "object", "required": ["_id"], "properties": {
+		"_id": {"type": "string"},
+		"Name": {"type": "string"},
+		"Parent": {"$ref": "Person2#/definitions/Person2"},
+		"Toys": {"$ref": "Person2#/definitions/Toys"}
+	}}}}`
+	c, err := db.NewCollection(CollectionConfig{
+		Name:    "Kid",
+		Schema:  util.SchemaFromSchemaString(schema),
+		Indexes: []Index{{Path: "Toys.Favorite"}},
+	})
+	checkErr(t, err)
+	resolved := util.SchemaFromSchemaString(string(c.GetSchema()))
+	for _, name := range []string{"Kid", "Person2.Person2", "Person2.Toys", "Person2.Comment"} {
+		if resolved.Definitions[name] == nil {
+			t.Fatalf("expected definition %s in resolved schema", name)
+		}
+	}
+	if ref := resolved.Definitions["Person2.Person2"].Properties["Toys"].Ref; ref != "#/definitions/Person2.Toys" {
+		t.Fatalf("unexpected copied definition reference %s", ref)
+	}
+
+	_, err = c.Create([]byte(`{"_id": "k1", "Name": "Foo", "Toys": {"Favorite": "ball", "Names": []}}`))
+	checkErr(t, err)
+	_, err = c.Create([]byte(`{"_id": "k2", "Name": "Bar", "Toys": {"Favorite": 1, "Names": []}}`))
+	if !errors.Is(err, ErrInvalidSchemaInstance) {
+		t.Fatalf("expected invalid schema instance error, got %v", err)
+	}
+	res, err := c.Find(Where("Toys.Favorite").Eq("ball").UseIndex("Toys.Favorite"))
+	checkErr(t, err)
+	if len(res) != 1 {
+		t.Fatalf("expected 1 indexed instance, got %d", len(res))
+	}
+
+	_, err = db.NewCollection(CollectionConfig{
+		Name:   "Orphan",
+		Schema: util.SchemaFromSchemaString(strings.ReplaceAll(schema, "Person2#", "Missing#")),
+	})
+	if !errors.Is(err, ErrInvalidSchemaRef) {
+		t.Fatalf("expected invalid schema ref error, got %v", err)
+	}
+	_, err = db.NewCollection(CollectionConfig{
+		Name:   "Orphan",
+		Schema: util.SchemaFromSchemaString(strings.ReplaceAll(schema, "definitions/Toys", "definitions/Missing")),
+	})
+	if !errors.Is(err, ErrInvalidSchemaRef) {
+		t.Fatalf("expected invalid schema ref error, got %v", err)
+	}
+}
@@ -0,0 +1,157 @@
+package db
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	"github.com/dop251/goja"
+	ds "github.com/textileio/go-datastore"
+	"github.com/tidwall/sjson"
+)
+
+// ComputeFunc computes the value of a computed field from an instance.
+type ComputeFunc func(instance []byte) (interface{}, error)
+
+// ComputedField is a field that isn't stored, but computed from instances as
+// they're read, e.g., a full name derived from first and last names.
+type ComputedField struct {
+	// Path is the path of the field in read instances. It can't be a path of
+	// the collection schema.
+	Path string `json:"path"`
+	// Func computes the value of the field.
+	Func ComputeFunc `json:"-"`
+	// JS is the body of a JavaScript function computing the value of the field
+	// from "instance", e.g., "return instance.first + ' ' + instance.last".
+	// It's used if Func is nil.
+	JS string `json:"js"`
+}
+
+var (
+	// ErrInvalidComputedField indicates a computed field has a schema path or nothing to compute it.
+	ErrInvalidComputedField = errors.New("invalid computed field")
+
+	dsComputed = dsPrefix.ChildString("computed")
+)
+
+// computedField is a computed field with its compiled JS function.
+type computedField struct {
+	ComputedField
+	fn goja.Callable
+}
+
+// setComputedFields validates, compiles and sets the computed fields of the collection.
+func (c *Collection) setComputedFields(config CollectionConfig) error {
+	c.Lock()
+	defer c.Unlock()
+	fields := make([]computedField, len(config.ComputedFields))
+	for i, f := range config.ComputedFields {
+		if f.Path == "" || f.Path == idFieldName || f.Path == modFieldName || (f.Func == nil && f.JS == "") {
+			return ErrInvalidComputedField
+		}
+		if _, err := getSchemaTypeAtPath(config.Schema, f.Path); err == nil {
+			return ErrInvalidComputedField
+		}
+		fields[i].ComputedField = f
+		if f.Func != nil {
+			continue
+		}
+		name := fmt.Sprintf("_compute%d", i)
+		obj, err := compileJSFunc([]byte(f.JS), name, "instance")
+		if err != nil {
+			return err
+		}
+		if fields[i].fn, err = loadJSFunc(c.vm, name, obj); err != nil {
+			return err
+		}
+	}
+	c.computed = fields
+	return nil
+}
+
+// computeFields sets the computed fields of an instance.
+func (c *Collection) computeFields(instance []byte) ([]byte, error) {
+	for _, f := range c.computed {
+		v, err := c.computeField(f, instance)
+		if err != nil {
+			return nil, fmt.Errorf("computing field %s: %w", f.Path, err)
+		}
+		if instance, err = sjson.SetBytes(instance, f.Path, v); err != nil {
+			return nil, err
+		}
+	}
+	return instance, nil
+}
+
+func (c *Collection) computeField(f computedField, instance []byte) (interface{}, error) {
+	if f.Func != nil {
+		return f.Func(instance)
+	}
+	c.Lock()
+	defer c.Unlock()
+	inv, err := parseJSON(c.vm, instance)
+	if err != nil {
+		return nil, err
+	}
+	res, err := f.fn(nil, inv)
+	if err != nil {
+		return nil, err
+	}
+	return res.Export(), nil
+}
+
+// withoutComputedFields removes the computed fields of an instance,
+// e.g., of a read instance that is saved back.
+func (c *Collection) withoutComputedFields(instance []byte) ([]byte, error) {
+	for _, f := range c.computed {
+		var err error
+		if instance, err = sjson.DeleteBytes(instance, f.Path); err != nil {
+			return nil, err
+		}
+	}
+	return instance, nil
+}
+
+// getComputedFields returns the computed fields of the collection.
+func (c *Collection) getComputedFields() []ComputedField {
+	if len(c.computed) == 0 {
+		return nil
+	}
+	fields := make([]ComputedField, len(c.computed))
+	for i, f := range c.computed {
+		fields[i] = f.ComputedField
+	}
+	return fields
+}
+
+// saveComputedFields persists the JS computed fields of a collection.
+// Funcs can't be persisted, see CollectionConfig.ComputedFields.
+func (d *DB) saveComputedFields(c *Collection) error {
+	var fields []ComputedField
+	for _, f := range c.computed {
+		if f.Func == nil {
+			fields = append(fields, f.ComputedField)
+		}
+	}
+	if len(fields) == 0 {
+		return d.datastore.Delete(dsComputed.ChildString(c.name))
+	}
+	v, err := json.Marshal(fields)
+	if err != nil {
+		return err
+	}
+	return d.datastore.Put(dsComputed.ChildString(c.name), v)
+}
+
+// loadComputedFields returns the persisted computed fields of a collection.
+func (d *DB) loadComputedFields(name string) ([]ComputedField, error) {
+	v, err := d.datastore.Get(dsComputed.ChildString(name))
+	if errors.Is(err, ds.ErrNotFound) {
+		return nil, nil
+	} else if err != nil {
+		return nil, err
+	}
+	var fields []ComputedField
+	err = json.Unmarshal(v, &fields)
+	return fields, err
+}
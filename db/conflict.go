@@ -0,0 +1,72 @@
+package db
+
+import (
+	"errors"
+	"strconv"
+
+	ds "github.com/textileio/go-datastore"
+	core "github.com/textileio/go-threads/core/db"
+)
+
+var (
+	// ErrInvalidConflictStrategy indicates a collection conflict strategy is unknown,
+	// lacks a resolver, or isn't supported by the db event codec.
+	ErrInvalidConflictStrategy = errors.New("invalid conflict strategy")
+
+	dsConflicts = dsPrefix.ChildString("conflict")
+	// dsApplied holds the timestamps of the last events applied to instances,
+	// see jsonpatcher.AppliedKey.
+	dsApplied = dsPrefix.ChildString("applied")
+)
+
+// validConflictPolicy validates the conflict policy of a collection config.
+func (d *DB) validConflictPolicy(config CollectionConfig) error {
+	switch config.ConflictStrategy {
+	case core.ConflictMerge:
+		return nil
+	case core.ConflictLastWriterWins:
+	case core.ConflictCustom:
+		if config.ConflictResolver == nil {
+			return ErrInvalidConflictStrategy
+		}
+	default:
+		return ErrInvalidConflictStrategy
+	}
+	if _, ok := d.eventcodec.(core.ConflictEventCodec); !ok {
+		return ErrInvalidConflictStrategy
+	}
+	return nil
+}
+
+// conflictPolicy returns the conflict policy of a collection.
+// Unknown collections use the default policy.
+func (d *DB) conflictPolicy(collection string) core.ConflictPolicy {
+	d.lock.Lock()
+	defer d.lock.Unlock()
+	c, err := d.getCollection(collection)
+	if err != nil {
+		return core.ConflictPolicy{}
+	}
+	return c.conflict
+}
+
+func (d *DB) saveConflictStrategy(c *Collection) error {
+	if c.conflict.Strategy == core.ConflictMerge {
+		return d.datastore.Delete(dsConflicts.ChildString(c.name))
+	}
+	return d.datastore.Put(dsConflicts.ChildString(c.name), []byte(strconv.Itoa(int(c.conflict.Strategy))))
+}
+
+// loadConflictStrategy returns the persisted conflict strategy of a collection.
+// Resolvers aren't persisted, so custom strategies fall back to merging until
+// the collection is updated with a resolver.
+func (d *DB) loadConflictStrategy(name string) (core.ConflictStrategy, error) {
+	v, err := d.datastore.Get(dsConflicts.ChildString(name))
+	if errors.Is(err, ds.ErrNotFound) {
+		return core.ConflictMerge, nil
+	} else if err != nil {
+		return core.ConflictMerge, err
+	}
+	s, err := strconv.Atoi(string(v))
+	return core.ConflictStrategy(s), err
+}
@@ -0,0 +1,137 @@
+package db
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	core "github.com/textileio/go-threads/core/db"
+	"github.com/textileio/go-threads/util"
+	"github.com/tidwall/gjson"
+	"github.com/tidwall/sjson"
+)
+
+// CounterValueField is the counter field of instances in counter collections,
+// see CounterCollectionConfig.
+const CounterValueField = "value"
+
+// ErrInvalidCounter indicates an instance field isn't a counter.
+var ErrInvalidCounter = errors.New("invalid counter")
+
+// Counter is a PN-counter CRDT that can be embedded in instances.
+// Each peer only changes its own increment and decrement totals, so the merge
+// patches of concurrent increments from different peers don't overlap and the
+// counter converges to the sum of all of them.
+// Counters must be changed with Increment, and rely on the default
+// core.ConflictMerge strategy of collections.
+type Counter struct {
+	// P holds the increment totals of each peer.
+	P map[string]int64 `json:"p,omitempty"`
+	// N holds the decrement totals of each peer.
+	N map[string]int64 `json:"n,omitempty"`
+}
+
+// Value returns the value of the counter.
+func (c Counter) Value() (v int64) {
+	for _, p := range c.P {
+		v += p
+	}
+	for _, n := range c.N {
+		v -= n
+	}
+	return v
+}
+
+// add adds delta to the totals of replica.
+func (c *Counter) add(replica string, delta int64) {
+	if delta > 0 {
+		if c.P == nil {
+			c.P = make(map[string]int64)
+		}
+		c.P[replica] += delta
+	} else if delta < 0 {
+		if c.N == nil {
+			c.N = make(map[string]int64)
+		}
+		c.N[replica] -= delta
+	}
+}
+
+// counterInstance is an instance of a counter collection.
+type counterInstance struct {
+	ID    string  `json:"_id"`
+	Mod   int64   `json:"_mod,omitempty"`
+	Value Counter `json:"value,omitempty"`
+}
+
+// CounterCollectionConfig returns the config of a collection of standalone
+// counters, whose instances hold a counter at CounterValueField.
+func CounterCollectionConfig(name string) CollectionConfig {
+	return CollectionConfig{
+		Name:   name,
+		Schema: util.SchemaFromInstance(&counterInstance{}, false),
+	}
+}
+
+// CounterValue returns the value of the counter at path in an instance.
+// Missing counters have a zero value.
+func CounterValue(instance []byte, path string) (int64, error) {
+	c, err := getCounter(instance, path)
+	if err != nil {
+		return 0, err
+	}
+	return c.Value(), nil
+}
+
+// getCounter returns the counter at path in an instance.
+func getCounter(instance []byte, path string) (c Counter, err error) {
+	r := gjson.GetBytes(instance, path)
+	if !r.Exists() {
+		return c, nil
+	}
+	if !r.IsObject() {
+		return c, fmt.Errorf("%w: %s isn't an object", ErrInvalidCounter, path)
+	}
+	if err := json.Unmarshal([]byte(r.Raw), &c); err != nil {
+		return c, fmt.Errorf("%w: %v", ErrInvalidCounter, err)
+	}
+	return c, nil
+}
+
+// Increment adds delta to the counter at path in the instance with the given
+// ID, and returns the new value of the counter. Negative deltas decrement it.
+// Missing counters are created.
+func (c *Collection) Increment(id core.InstanceID, path string, delta int64, opts ...TxnOption) (v int64, err error) {
+	err = c.WriteTxn(func(txn *Txn) error {
+		v, err = txn.Increment(id, path, delta)
+		return err
+	}, opts...)
+	return
+}
+
+// Increment adds delta to the counter at path in the stored instance with the
+// given ID, and saves the result in the current transaction. Like Patch,
+// changes made earlier in the transaction aren't visible to the increment.
+func (t *Txn) Increment(id core.InstanceID, path string, delta int64) (int64, error) {
+	if t.readonly {
+		return 0, ErrReadonlyTx
+	}
+	current, err := t.FindByID(id)
+	if err != nil {
+		return 0, err
+	}
+	counter, err := getCounter(current, path)
+	if err != nil {
+		return 0, err
+	}
+	counter.add(t.collection.db.connector.Net.Host().ID().String(), delta)
+	raw, err := json.Marshal(counter)
+	if err != nil {
+		return 0, err
+	}
+	updated, err := sjson.SetRawBytes(current, path, raw)
+	if err != nil {
+		return 0, fmt.Errorf("%w: %v", ErrInvalidCounter, err)
+	}
+	return counter.Value(), t.Save(updated)
+}
@@ -33,13 +33,21 @@ import (
 type operation int
 
 const (
-	eq operation = iota
-	ne           // !=
-	gt           // >
-	lt           // <
-	ge           // >=
-	le           // <=
-	fn           // func
+	eq     operation = iota
+	ne               // !=
+	gt               // >
+	lt               // <
+	ge               // >=
+	le               // <=
+	fn               // func
+	text             // text search
+	in               // in set
+	nin              // not in set
+	re               // regular expression
+	near             // geo distance
+	box              // geo bounding box
+	exists           // field presence
+	null             // null value
 )
 
 type errTypeMismatch struct {
@@ -0,0 +1,149 @@
+package db
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+
+	ds "github.com/textileio/go-datastore"
+	core "github.com/textileio/go-threads/core/db"
+	"github.com/tidwall/gjson"
+)
+
+// ErrInvalidCursor indicates a query cursor is malformed, or was returned
+// for a query with a different order or index.
+var ErrInvalidCursor = errors.New("invalid query cursor")
+
+// AfterCursor continues the query results after the position of a cursor
+// returned by FindPage. The query must have the same order and index as the
+// query the cursor was returned for.
+func (q *Query) AfterCursor(cursor string) *Query {
+	q.After = cursor
+	return q
+}
+
+// cursor is the position of a query result. Results are ordered by the
+// sort field values or the index value, if any, and then by instance ID.
+type cursor struct {
+	ID     core.InstanceID `json:"id"`
+	Sort   []Sort          `json:"sort,omitempty"`
+	Index  string          `json:"index,omitempty"`
+	Value  string          `json:"value,omitempty"`
+	Values []interface{}   `json:"values,omitempty"`
+}
+
+// positioner positions results relative to query cursors.
+type positioner struct {
+	q            *Query
+	index        Index
+	inMemorySort bool
+}
+
+// cursor returns the position of the result with the (unfiltered) value v.
+func (p positioner) cursor(v []byte) (*cursor, error) {
+	c := &cursor{
+		ID:    core.InstanceID(gjson.GetBytes(v, idFieldName).String()),
+		Sort:  p.q.sorts(),
+		Index: p.q.Index,
+	}
+	switch {
+	case p.inMemorySort:
+		for _, s := range c.Sort {
+			c.Values = append(c.Values, s.sortValue(gjson.GetBytes(v, s.FieldPath).Value()))
+		}
+	case p.q.Index != "":
+		key, err := getIndexValue(p.index, v)
+		if err != nil {
+			return nil, err
+		}
+		c.Value = key.String()
+	}
+	return c, nil
+}
+
+// after returns whether or not the result with the (unfiltered) value v comes after c.
+func (p positioner) after(c *cursor, v []byte) (bool, error) {
+	rc, err := p.cursor(v)
+	if err != nil {
+		return false, err
+	}
+	if p.inMemorySort {
+		if len(c.Values) != len(rc.Values) {
+			return false, ErrInvalidCursor
+		}
+		for i, s := range rc.Sort {
+			res, err := compare(rc.Values[i], c.Values[i])
+			if err != nil {
+				return false, fmt.Errorf("%w: %v", ErrInvalidCursor, err)
+			}
+			if s.Desc {
+				res *= -1
+			}
+			if res != 0 {
+				return res > 0, nil
+			}
+		}
+		// Ties are ordered by ascending ID
+		return rc.ID > c.ID, nil
+	}
+	res := strings.Compare(rc.Value, c.Value)
+	if res == 0 {
+		res = strings.Compare(string(rc.ID), string(c.ID))
+	}
+	// Iterating in descending ID order reverses the datastore order
+	if p.q.Sort.Desc {
+		res *= -1
+	}
+	return res > 0, nil
+}
+
+// seek returns a copy of q that seeks the datastore query close to c, or q
+// if the results are sorted in memory.
+func (p positioner) seek(c *cursor) *Query {
+	if p.inMemorySort {
+		return p.q
+	}
+	sq := *p.q
+	if p.q.Index != "" {
+		sq.Seek = core.InstanceID(strings.TrimPrefix(c.Value, "/"))
+	} else {
+		sq.Seek = c.ID
+	}
+	return &sq
+}
+
+// keysAfter returns the sorted candidate keys with an instance ID after c.
+func keysAfter(keys []ds.Key, c *cursor) []ds.Key {
+	i := sort.Search(len(keys), func(i int) bool {
+		return keys[i].Name() > string(c.ID)
+	})
+	return keys[i:]
+}
+
+func encodeCursor(c *cursor) (string, error) {
+	b, err := json.Marshal(c)
+	if err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(b), nil
+}
+
+// decodeCursor returns the cursor of q, which must match the query order and index.
+func decodeCursor(q *Query) (*cursor, error) {
+	b, err := base64.RawURLEncoding.DecodeString(q.After)
+	if err != nil {
+		return nil, ErrInvalidCursor
+	}
+	c := &cursor{}
+	if err := json.Unmarshal(b, c); err != nil || c.ID == "" {
+		return nil, ErrInvalidCursor
+	}
+	if !reflect.DeepEqual(c.Sort, q.sorts()) || c.Index != q.Index {
+		return nil, ErrInvalidCursor
+	}
+	return c, nil
+}
@@ -9,13 +9,16 @@ import (
 	"fmt"
 	"io"
 	"regexp"
+	"sort"
 	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/alecthomas/jsonschema"
 	"github.com/dop251/goja"
 	format "github.com/ipfs/go-ipld-format"
 	logging "github.com/ipfs/go-log"
+	"github.com/libp2p/go-libp2p-core/peer"
 	ma "github.com/multiformats/go-multiaddr"
 	ds "github.com/textileio/go-datastore"
 	kt "github.com/textileio/go-datastore/keytransform"
@@ -26,12 +29,14 @@ import (
 	lstore "github.com/textileio/go-threads/core/logstore"
 	"github.com/textileio/go-threads/core/net"
 	"github.com/textileio/go-threads/core/thread"
+	"github.com/textileio/go-threads/crypto/symmetric"
 	"github.com/textileio/go-threads/util"
 )
 
 const (
 	idFieldName                 = "_id"
 	modFieldName                = "_mod"
+	deletedFieldName            = "_deleted"
 	getBlockRetries             = 3
 	getBlockInitialTimeout      = time.Millisecond * 500
 	pullThreadBackgroundTimeout = time.Hour
@@ -47,15 +52,32 @@ var (
 	ErrInvalidCollectionSchema = errors.New("the collection schema _id property must be a string")
 	// ErrCannotIndexIDField indicates a custom index was specified on the ID field.
 	ErrCannotIndexIDField = errors.New("cannot create custom index on " + idFieldName)
+	// ErrReadOnlyDB indicates that local writes are rejected since the db is read-only.
+	ErrReadOnlyDB = errors.New("db is read-only")
+	// ErrDBClosed indicates the db was closed. Listeners of the db are closed
+	// with it, see ErrListener.Err.
+	ErrDBClosed = errors.New("db is closed")
+	// ErrDBDeleted indicates the db was deleted. Listeners of the db are
+	// closed with it, see ErrListener.Err.
+	ErrDBDeleted = errors.New("db was deleted")
 
 	nameRx *regexp.Regexp
 
 	dsPrefix     = ds.NewKey("/db")
 	dsName       = dsPrefix.ChildString("name")
+	dsDBMetadata = dsPrefix.ChildString("metadata")
 	dsSchemas    = dsPrefix.ChildString("schema")
 	dsIndexes    = dsPrefix.ChildString("index")
 	dsValidators = dsPrefix.ChildString("validator")
 	dsFilters    = dsPrefix.ChildString("filter")
+	dsOrders     = dsPrefix.ChildString("order")
+
+	// collectionKeys are the prefixes of the keys a collection stores under its
+	// name, e.g., its schema, and collectionKeyPrefixes the prefixes of the key
+	// ranges it stores under its name, e.g., its modification index. They're
+	// moved by RenameCollection, and deleted by DeleteCollection.
+	collectionKeys        = []ds.Key{dsSchemas, dsIndexes, dsValidators, dsFilters, dsOrders, dsVersions, dsModifiedReady, dsTTLFields, dsConflicts, dsIDStrategies, dsIDSequences, dsEncryption, dsReferences, dsComputed, dsSerialized}
+	collectionKeyPrefixes = []ds.Key{dsModified, dsTombstones, dsInstanceVersions, dsExpiry, dsApplied}
 )
 
 func init() {
@@ -69,22 +91,62 @@ func init() {
 // states. Likewise, local changes in collections registered produce events dispatched
 // externally.
 type DB struct {
+	// The records handled from the net, first for 64-bit atomic alignment
+	handledRecords uint64
+
 	io.Closer
 
 	name      string
 	connector *app.Connector
 
-	datastore  ds.TxnDatastore
-	dispatcher *dispatcher
-	eventcodec core.EventCodec
+	datastore      ds.TxnDatastore
+	dispatcher     *dispatcher
+	eventcodec     core.EventCodec
+	eventcodecName string
 
 	lock        sync.RWMutex
 	txnlock     sync.RWMutex
 	collections map[string]*Collection
+	unloaded    map[string]struct{}
 	closed      bool
+	closeErr    error
 
 	localEventsBus      *app.LocalEventsBus
 	stateChangedNotifee *stateChangedNotifee
+
+	validationMetrics bool
+	validationHandler ValidationFailureHandler
+	redactRejected    bool
+	tombstones        bool
+
+	maxBatchEntries  int
+	maxBatchBytes    int
+	strictBatchLimit bool
+	throughputWindow time.Duration
+
+	sweepStop chan struct{}
+	sweepDone chan struct{}
+	sweepOnce sync.Once
+
+	actionSeq     uint64
+	actionHistory int
+
+	maxQueryResults int
+	maxQueryScan    int
+
+	readOnly bool
+
+	keyEncryptionKey *symmetric.Key
+
+	created     time.Time
+	owner       string
+	delegates   map[string]struct{}
+	description string
+	labels      map[string]string
+
+	usage   usageTracker
+	metrics *dbMetrics
+	emit    func(ManagerEvent)
 }
 
 // NewDB creates a new DB, which will *own* ds and dispatcher for internal use.
@@ -139,15 +201,14 @@ func NewDBFromAddr(ctx context.Context, network app.Net, addr ma.Multiaddr, key
 
 // newDB is used directly by a db manager to create new dbs with the same config.
 func newDB(n app.Net, id thread.ID, opts *NewOptions) (*DB, error) {
+	var created bool
 	if opts.Datastore == nil {
 		datastore, err := newDefaultDatastore(opts.RepoPath, opts.LowMem)
 		if err != nil {
 			return nil, err
 		}
 		opts.Datastore = datastore
-	}
-	if opts.EventCodec == nil {
-		opts.EventCodec = newDefaultEventCodec()
+		created = true
 	}
 	if !managedDatastore(opts.Datastore) {
 		if opts.Debug {
@@ -159,17 +220,46 @@ func newDB(n app.Net, id thread.ID, opts *NewOptions) (*DB, error) {
 		}
 	}
 
+	store := wrapStorageErrors(opts.Datastore)
 	d := &DB{
-		datastore:           opts.Datastore,
-		dispatcher:          newDispatcher(opts.Datastore),
-		eventcodec:          opts.EventCodec,
+		datastore:           store,
+		dispatcher:          newDispatcher(store),
 		collections:         make(map[string]*Collection),
+		unloaded:            make(map[string]struct{}),
 		localEventsBus:      app.NewLocalEventsBus(),
 		stateChangedNotifee: &stateChangedNotifee{},
+		validationMetrics:   opts.ValidationMetrics,
+		validationHandler:   opts.ValidationHandler,
+		redactRejected:      opts.RedactRejected,
+		tombstones:          opts.Tombstones,
+		maxBatchEntries:     opts.MaxBatchEntries,
+		maxBatchBytes:       opts.MaxBatchBytes,
+		strictBatchLimit:    opts.StrictBatchLimit,
+		throughputWindow:    opts.ThroughputWindow,
+		sweepStop:           make(chan struct{}),
+		sweepDone:           make(chan struct{}),
+		actionHistory:       opts.ActionHistory,
+		maxQueryResults:     opts.MaxQueryResults,
+		maxQueryScan:        opts.MaxQueryScan,
+		readOnly:            opts.ReadOnly,
+		keyEncryptionKey:    opts.KeyEncryptionKey,
+	}
+	d.metrics = newDBMetrics(d, id)
+	if d.actionHistory <= 0 {
+		d.actionHistory = defaultActionHistory
+	}
+	if err := d.negotiateEventCodec(opts); err != nil {
+		if created {
+			_ = opts.Datastore.Close()
+		}
+		return nil, err
 	}
 	if err := d.loadName(); err != nil {
 		return nil, err
 	}
+	if err := d.loadActionSeq(); err != nil {
+		return nil, err
+	}
 	prevName := d.name
 	if opts.Name != "" {
 		d.name = opts.Name
@@ -179,10 +269,25 @@ func newDB(n app.Net, id thread.ID, opts *NewOptions) (*DB, error) {
 	if err := d.saveName(prevName); err != nil {
 		return nil, err
 	}
+	if err := d.loadOrigin(n, id, prevName == "", opts.Token); err != nil {
+		return nil, err
+	}
+	if err := d.loadDelegates(); err != nil {
+		return nil, err
+	}
+	if err := d.loadMetadata(); err != nil {
+		return nil, err
+	}
+	if err := d.loadQuota(opts.Quota); err != nil {
+		return nil, err
+	}
 	if err := d.reCreateCollections(); err != nil {
 		return nil, err
 	}
 	d.dispatcher.Register(d)
+	if codec, ok := d.eventcodec.(core.ConflictEventCodec); ok {
+		codec.SetConflictPolicies(d.conflictPolicy)
+	}
 
 	connector, err := n.ConnectApp(d, id)
 	if err != nil {
@@ -195,12 +300,16 @@ func newDB(n app.Net, id thread.ID, opts *NewOptions) (*DB, error) {
 			return nil, err
 		}
 	}
+	go d.sweepExpiredLoop(opts.ExpirySweepInterval)
 	return d, nil
 }
 
 // managedDatastore returns whether or not the datastore is
 // being wrapped by an external datastore.
 func managedDatastore(ds ds.Datastore) bool {
+	if sd, ok := ds.(*storageDatastore); ok {
+		ds = sd.TxnDatastore
+	}
 	_, ok := ds.(kt.KeyTransform)
 	return ok
 }
@@ -228,56 +337,220 @@ func (d *DB) loadName() error {
 	return nil
 }
 
-// reCreateCollections loads and registers schemas from the datastore.
+// DBMetadata is the descriptive metadata of a db, see Manager.UpdateDBMetadata.
+type DBMetadata struct {
+	// Name is the db name.
+	Name string `json:"name,omitempty"`
+	// Description is an optional description of the db.
+	Description string `json:"description,omitempty"`
+	// Labels are optional key/value labels of the db, e.g., an environment.
+	Labels map[string]string `json:"labels,omitempty"`
+}
+
+// getMetadata returns the db metadata.
+func (d *DB) getMetadata() DBMetadata {
+	d.lock.RLock()
+	defer d.lock.RUnlock()
+	md := DBMetadata{Name: d.name, Description: d.description}
+	if len(d.labels) > 0 {
+		md.Labels = make(map[string]string, len(d.labels))
+		for k, v := range d.labels {
+			md.Labels[k] = v
+		}
+	}
+	return md
+}
+
+// setMetadata validates and saves the db metadata.
+func (d *DB) setMetadata(md DBMetadata) error {
+	if !nameRx.MatchString(md.Name) {
+		return ErrInvalidName
+	}
+	d.lock.Lock()
+	defer d.lock.Unlock()
+	if err := d.datastore.Put(dsName, []byte(md.Name)); err != nil {
+		return err
+	}
+	if md.Description == "" && len(md.Labels) == 0 {
+		if err := d.datastore.Delete(dsDBMetadata); err != nil {
+			return err
+		}
+	} else {
+		v, err := json.Marshal(DBMetadata{Description: md.Description, Labels: md.Labels})
+		if err != nil {
+			return err
+		}
+		if err := d.datastore.Put(dsDBMetadata, v); err != nil {
+			return err
+		}
+	}
+	d.name, d.description, d.labels = md.Name, md.Description, md.Labels
+	return nil
+}
+
+// loadMetadata loads the db description and labels if present.
+func (d *DB) loadMetadata() error {
+	v, err := d.datastore.Get(dsDBMetadata)
+	if errors.Is(err, ds.ErrNotFound) {
+		return nil
+	} else if err != nil {
+		return err
+	}
+	var md DBMetadata
+	if err := json.Unmarshal(v, &md); err != nil {
+		return err
+	}
+	d.description, d.labels = md.Description, md.Labels
+	return nil
+}
+
+// reCreateCollections registers the collections persisted in the datastore.
+// Collections are hydrated on first access, so opening a db with many
+// collections doesn't load all schemas and indexes upfront.
 func (d *DB) reCreateCollections() error {
 	d.lock.Lock()
 	defer d.lock.Unlock()
 
 	results, err := d.datastore.Query(query.Query{
-		Prefix: dsSchemas.String(),
+		Prefix:   dsSchemas.String(),
+		KeysOnly: true,
 	})
 	if err != nil {
 		return err
 	}
 	defer results.Close()
 	for res := range results.Next() {
-		name := ds.RawKey(res.Key).Name()
-		schema := &jsonschema.Schema{}
-		if err := json.Unmarshal(res.Value, schema); err != nil {
-			return err
+		if res.Error != nil {
+			return res.Error
 		}
-		wv, err := d.datastore.Get(dsValidators.ChildString(name))
-		if err != nil && !errors.Is(err, ds.ErrNotFound) {
-			return err
-		}
-		rf, err := d.datastore.Get(dsFilters.ChildString(name))
-		if err != nil && !errors.Is(err, ds.ErrNotFound) {
-			return err
-		}
-		c, err := newCollection(d, CollectionConfig{
-			Name:           name,
-			Schema:         schema,
-			WriteValidator: string(wv),
-			ReadFilter:     string(rf),
-		})
-		if err != nil {
-			return err
+		d.unloaded[ds.RawKey(res.Key).Name()] = struct{}{}
+	}
+	return nil
+}
+
+// getCollection returns a registered collection by name, hydrating it from
+// the datastore if it hasn't been accessed yet. The caller must hold d.lock.
+func (d *DB) getCollection(name string) (*Collection, error) {
+	if c, ok := d.collections[name]; ok {
+		return c, nil
+	}
+	if _, ok := d.unloaded[name]; !ok {
+		return nil, ErrCollectionNotFound
+	}
+	c, err := d.loadCollection(name)
+	if err != nil {
+		return nil, err
+	}
+	delete(d.unloaded, name)
+	d.collections[name] = c
+	return c, nil
+}
+
+// hasCollection returns whether a collection is registered, hydrated or not.
+// The caller must hold d.lock.
+func (d *DB) hasCollection(name string) bool {
+	if _, ok := d.collections[name]; ok {
+		return true
+	}
+	_, ok := d.unloaded[name]
+	return ok
+}
+
+// loadCollection loads a collection's schema, write validator, read filter, and indexes from the datastore.
+func (d *DB) loadCollection(name string) (*Collection, error) {
+	sv, err := d.datastore.Get(dsSchemas.ChildString(name))
+	if err != nil {
+		return nil, err
+	}
+	schema := &jsonschema.Schema{}
+	if err := json.Unmarshal(sv, schema); err != nil {
+		return nil, err
+	}
+	wv, err := d.datastore.Get(dsValidators.ChildString(name))
+	if err != nil && !errors.Is(err, ds.ErrNotFound) {
+		return nil, err
+	}
+	rf, err := d.datastore.Get(dsFilters.ChildString(name))
+	if err != nil && !errors.Is(err, ds.ErrNotFound) {
+		return nil, err
+	}
+	var order Sort
+	ov, err := d.datastore.Get(dsOrders.ChildString(name))
+	if err == nil {
+		if err := json.Unmarshal(ov, &order); err != nil {
+			return nil, err
 		}
-		var indexes map[string]Index
-		index, err := d.datastore.Get(dsIndexes.ChildString(name))
-		if err == nil && index != nil {
-			if err := json.Unmarshal(index, &indexes); err != nil {
-				return err
-			}
+	} else if !errors.Is(err, ds.ErrNotFound) {
+		return nil, err
+	}
+	version, err := getVersionState(d.datastore, name)
+	if err != nil {
+		return nil, err
+	}
+	ttl, err := d.datastore.Get(dsTTLFields.ChildString(name))
+	if err != nil && !errors.Is(err, ds.ErrNotFound) {
+		return nil, err
+	}
+	strategy, err := d.loadConflictStrategy(name)
+	if err != nil {
+		return nil, err
+	}
+	idStrategy, err := d.loadIDStrategy(name)
+	if err != nil {
+		return nil, err
+	}
+	encrypted, key, err := d.loadFieldEncryption(name)
+	if err != nil {
+		return nil, err
+	}
+	refs, err := d.loadReferences(name)
+	if err != nil {
+		return nil, err
+	}
+	computed, err := d.loadComputedFields(name)
+	if err != nil {
+		return nil, err
+	}
+	serialized, err := d.loadSerializeWrites(name)
+	if err != nil {
+		return nil, err
+	}
+	c, err := newCollection(d, CollectionConfig{
+		Name:            name,
+		Schema:          schema,
+		WriteValidator:  string(wv),
+		ReadFilter:      string(rf),
+		DefaultOrderBy:  order,
+		Version:         version.Version,
+		TTLField:        string(ttl),
+		EncryptedFields: encrypted,
+		EncryptionKey:   key,
+		References:      refs,
+		ComputedFields:  computed,
+		SerializeWrites: serialized,
+	})
+	if err != nil {
+		return nil, err
+	}
+	c.version = version
+	c.conflict.Strategy = strategy
+	c.ids.strategy = idStrategy
+	var indexes map[string]Index
+	index, err := d.datastore.Get(dsIndexes.ChildString(name))
+	if err == nil && index != nil {
+		if err := json.Unmarshal(index, &indexes); err != nil {
+			return nil, err
 		}
-		for _, index := range indexes {
-			if index.Path != "" { // Catch bad indexes from an old bug
-				c.indexes[index.Path] = index
-			}
+	}
+	for _, index := range indexes {
+		if index.Path != "" { // Catch bad indexes from an old bug
+			c.indexes[index.Path] = index
 		}
-		d.collections[c.name] = c
 	}
-	return nil
+	if err := c.ensureModifiedIndex(); err != nil {
+		return nil, err
+	}
+	return c, nil
 }
 
 // Info wraps info about a db.
@@ -310,6 +583,10 @@ type CollectionConfig struct {
 	// Must only contain alphanumeric characters or non-consecutive hyphens, and cannot begin or end with a hyphen.
 	Name string
 	// Schema is JSON Schema used for instance validation.
+	// It can reference definitions of the schemas of other collections, e.g.,
+	// "Address#/definitions/Street", which are copied to the schema as the
+	// collection is created or updated. Later updates of the referenced
+	// collections don't apply until the collection is updated again.
 	Schema *jsonschema.Schema
 	// Indexes is a list of index configurations, which define how instances are indexed.
 	Indexes []Index
@@ -329,6 +606,72 @@ type CollectionConfig struct {
 	// Most implementation will modify and return the current instance.
 	// Note: Only the function body should be defined here.
 	ReadFilter string
+	// DefaultOrderBy is an optional sort order applied to Find queries that don't specify one.
+	// An explicit query order overrides it.
+	DefaultOrderBy Sort
+	// Version is the schema version of the collection instances.
+	// Updating a collection to a higher version applies the Migrations
+	// towards it to existing instances, see UpdateCollection.
+	Version int
+	// TTLField is an optional path to a number field holding the expiration time
+	// of instances, in Unix seconds. Expired instances are deleted by a background
+	// sweeper with regular delete events, so the deletions replicate to peers.
+	// Instances without the field don't expire.
+	TTLField string
+	// ConflictStrategy selects how writes to an instance reduced out of causal
+	// order, e.g., concurrent writes from peers, are reconciled. Defaults to
+	// merging the changed fields of every write.
+	ConflictStrategy core.ConflictStrategy
+	// ConflictResolver reconciles conflicting writes with the core.ConflictCustom strategy.
+	// Resolvers aren't persisted and must be registered again after a restart
+	// by updating the collection, until then conflicting writes are merged.
+	ConflictResolver core.ConflictResolver
+	// References declare fields holding the IDs of instances in other collections.
+	// Writes fail with ErrReferenceNotFound if a referenced instance doesn't exist,
+	// and deletes of referenced instances are blocked or cascaded. References are
+	// checked for local writes only, writes received from peers aren't checked.
+	References []Reference
+	// EncryptedFields are paths of fields that are encrypted with the collection
+	// EncryptionKey before they're stored and replicated, and decrypted as they're
+	// read. Peers without the key only see ciphertext. Encrypted fields can't be
+	// indexed, and queries, except FindModifiedSince, write validators, read filters,
+	// migrations, and listeners see their ciphertext. Existing values are encrypted
+	// as instances are saved.
+	EncryptedFields []string
+	// EncryptionKey is the key of the EncryptedFields. If nil, a random key is
+	// generated, or the key of the existing collection is kept on updates.
+	// Peers only decrypt the values of each other with the same key, so the
+	// key of replicated collections must be shared, values that can't be
+	// decrypted are read as ciphertext.
+	// The key is kept in the local datastore, see Collection.EncryptionKey, in
+	// plaintext unless the db has a key encryption key, see
+	// WithNewKeyEncryptionKey. Without one, the fields are only protected in
+	// replicated records, not at rest in the local datastore.
+	// Fields removed from EncryptedFields keep their stored ciphertext until
+	// the instances are saved with new values.
+	EncryptionKey *symmetric.Key
+	// IDStrategy selects how the IDs of instances created without one are
+	// generated. Defaults to ULIDs.
+	IDStrategy IDStrategy
+	// IDGenerator generates IDs with the IDCustom strategy. Generators aren't
+	// persisted and must be registered again after a restart by updating the
+	// collection, until then IDs are ULIDs.
+	IDGenerator IDGenerator
+	// ComputedFields are fields computed from instances as they're read, after
+	// the read filter. Queries match and sort stored values, so they can't use
+	// computed fields, and computed fields are removed from written instances.
+	// Fields with a Func aren't persisted and must be registered again after a
+	// restart by updating the collection.
+	ComputedFields []ComputedField
+	// SerializeWrites runs the local writes to the collection one at a time, in
+	// the order they're made, through a write queue. Waiting writes can follow
+	// their position with WithTxnWritePosition. Writes received from peers and
+	// writes of db transactions, see DB.WriteTxn, aren't queued.
+	SerializeWrites bool
+	// Migrations are the ordered steps that upgrade instances to Version.
+	// Each migration upgrades instances from the previous version with a
+	// migration, or the current collection version, to its own version.
+	Migrations []Migration
 }
 
 // NewCollection creates a new db collection with config.
@@ -342,9 +685,17 @@ func (d *DB) NewCollection(config CollectionConfig, opts ...Option) (*Collection
 	if err := d.connector.Validate(args.Token, false); err != nil {
 		return nil, err
 	}
-	if _, ok := d.collections[config.Name]; ok {
+	if d.hasCollection(config.Name) {
 		return nil, ErrCollectionAlreadyRegistered
 	}
+	schema, err := d.resolveSchemaRefs(config.Name, config.Schema)
+	if err != nil {
+		return nil, err
+	}
+	config.Schema = schema
+	if err := d.validReferences(config); err != nil {
+		return nil, err
+	}
 	c, err := newCollection(d, config)
 	if err != nil {
 		return nil, err
@@ -352,37 +703,135 @@ func (d *DB) NewCollection(config CollectionConfig, opts ...Option) (*Collection
 	if err := d.addIndexes(c, config.Schema, config.Indexes, opts...); err != nil {
 		return nil, err
 	}
+	if err := c.ensureModifiedIndex(); err != nil {
+		return nil, err
+	}
 	if err := d.saveCollection(c); err != nil {
 		return nil, err
 	}
+	d.emitEvent(ManagerEvent{Type: EventCollectionAdded, Collection: c.name})
 	return c, nil
 }
 
 // UpdateCollection updates an existing db collection with a new config.
 // Indexes to new paths will be created.
 // Indexes to removed paths will be dropped.
+// With WithValidatorsOnly, only the write validator and read filter are replaced,
+// in place, without rebuilding the collection.
+// With WithCompatibilityCheck, existing instances are validated against the new
+// schema first, and the update fails with a report of incompatible instances.
+// If the config has a higher Version, existing instances are migrated after the
+// update, unless WithLazyMigration is used. Lazily migrated instances are upgraded
+// as they're read, but queries match their stored values until they're
+// saved again or migrated with Collection.Migrate.
+// Migration functions aren't persisted, so pending lazy migrations must be
+// registered again after a restart by updating the collection with the same config.
 func (d *DB) UpdateCollection(config CollectionConfig, opts ...Option) (*Collection, error) {
-	d.lock.Lock()
-	defer d.lock.Unlock()
 	args := &Options{}
 	for _, opt := range opts {
 		opt(args)
 	}
+	if args.ValidatorsOnly {
+		return d.updateCollectionFuncs(config, args)
+	}
+	if args.CheckSchema {
+		if err := d.checkCompatibility(config, args); err != nil {
+			return nil, err
+		}
+	}
+	c, err := d.updateCollection(config, args, opts...)
+	if err != nil {
+		return nil, err
+	}
+	if !args.LazyMigration {
+		// The migration writes go through the collection, so d.lock must be released
+		if err := c.Migrate(opts...); err != nil {
+			return nil, err
+		}
+	}
+	return c, nil
+}
+
+// checkCompatibility validates the existing instances of a collection against
+// the config schema. It doesn't hold d.lock while reading instances, so writes
+// made concurrently with the check may not be validated.
+func (d *DB) checkCompatibility(config CollectionConfig, args *Options) error {
+	if err := d.connector.Validate(args.Token, false); err != nil {
+		return err
+	}
+	d.lock.Lock()
+	xc, err := d.getCollection(config.Name)
+	var schema *jsonschema.Schema
+	if err == nil {
+		schema, err = d.resolveSchemaRefs(config.Name, config.Schema)
+	}
+	d.lock.Unlock()
+	if err != nil {
+		return err
+	}
+	config.Schema = schema
+	if config.EncryptionKey == nil {
+		config.EncryptionKey = xc.encryption.key
+	}
+	c, err := newCollection(d, config)
+	if err != nil {
+		return err
+	}
+	if err := c.setMigrations(xc, config.Migrations); err != nil {
+		return err
+	}
+	d.txnlock.RLock()
+	defer d.txnlock.RUnlock()
+	checked, incompatible, err := c.checkInstances(config.Schema, args.CheckSchemaSample, true)
+	if err != nil {
+		return err
+	}
+	if len(incompatible) > 0 {
+		return &SchemaCompatibilityError{Checked: checked, Instances: incompatible}
+	}
+	return nil
+}
+
+func (d *DB) updateCollection(config CollectionConfig, args *Options, opts ...Option) (*Collection, error) {
+	d.lock.Lock()
+	defer d.lock.Unlock()
 	if err := d.connector.Validate(args.Token, false); err != nil {
 		return nil, err
 	}
-	xc, ok := d.collections[config.Name]
-	if !ok {
-		return nil, ErrCollectionNotFound
+	xc, err := d.getCollection(config.Name)
+	if err != nil {
+		return nil, err
+	}
+	if config.EncryptionKey == nil {
+		config.EncryptionKey = xc.encryption.key
+	}
+	schema, err := d.resolveSchemaRefs(config.Name, config.Schema)
+	if err != nil {
+		return nil, err
+	}
+	config.Schema = schema
+	if err := d.validReferences(config); err != nil {
+		return nil, err
 	}
 	c, err := newCollection(d, config)
 	if err != nil {
 		return nil, err
 	}
+	if err := c.setMigrations(xc, config.Migrations); err != nil {
+		return nil, err
+	}
 	if err := d.addIndexes(c, config.Schema, config.Indexes, opts...); err != nil {
 		return nil, err
 	}
 
+	c.validationStats = xc.validationStats
+	c.throughput = xc.throughput
+	c.hooks = xc.hooks
+	if c.writes != nil && xc.writes != nil {
+		// Writes queued to the existing collection keep their order
+		c.writes = xc.writes
+	}
+
 	// Drop indexes that are no longer requested
 	for _, index := range xc.indexes {
 		if _, ok := c.indexes[index.Path]; !ok {
@@ -410,19 +859,85 @@ func (d *DB) addIndexes(c *Collection, schema *jsonschema.Schema, indexes []Inde
 	return c.addIndex(schema, Index{Path: idFieldName, Unique: true}, opts...)
 }
 
+// updateCollectionFuncs replaces the write validator and read filter of a
+// collection in place, see WithValidatorsOnly.
+func (d *DB) updateCollectionFuncs(config CollectionConfig, args *Options) (*Collection, error) {
+	d.lock.Lock()
+	defer d.lock.Unlock()
+	if err := d.connector.Validate(args.Token, false); err != nil {
+		return nil, err
+	}
+	c, err := d.getCollection(config.Name)
+	if err != nil {
+		return nil, err
+	}
+	if err := c.setFuncs(config.WriteValidator, config.ReadFilter); err != nil {
+		return nil, err
+	}
+	if err := d.saveFuncs(c); err != nil {
+		return nil, err
+	}
+	return c, nil
+}
+
+func (d *DB) saveFuncs(c *Collection) error {
+	if wv := c.GetWriteValidator(); wv != nil {
+		if err := d.datastore.Put(dsValidators.ChildString(c.name), wv); err != nil {
+			return err
+		}
+	}
+	if rf := c.GetReadFilter(); rf != nil {
+		if err := d.datastore.Put(dsFilters.ChildString(c.name), rf); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
 func (d *DB) saveCollection(c *Collection) error {
 	if err := d.datastore.Put(dsSchemas.ChildString(c.name), c.GetSchema()); err != nil {
 		return err
 	}
-	if c.rawWriteValidator != nil {
-		if err := d.datastore.Put(dsValidators.ChildString(c.name), c.rawWriteValidator); err != nil {
+	if err := d.saveFuncs(c); err != nil {
+		return err
+	}
+	if c.defaultOrder.FieldPath != "" {
+		ov, err := json.Marshal(c.defaultOrder)
+		if err != nil {
 			return err
 		}
-	}
-	if c.rawReadFilter != nil {
-		if err := d.datastore.Put(dsFilters.ChildString(c.name), c.rawReadFilter); err != nil {
+		if err := d.datastore.Put(dsOrders.ChildString(c.name), ov); err != nil {
 			return err
 		}
+	} else if err := d.datastore.Delete(dsOrders.ChildString(c.name)); err != nil {
+		return err
+	}
+	c.Lock()
+	version := c.version
+	c.Unlock()
+	if err := putVersionState(d.datastore, c.name, version); err != nil {
+		return err
+	}
+	if err := c.ensureExpiryIndex(); err != nil {
+		return err
+	}
+	if err := d.saveConflictStrategy(c); err != nil {
+		return err
+	}
+	if err := d.saveIDStrategy(c); err != nil {
+		return err
+	}
+	if err := d.saveFieldEncryption(c); err != nil {
+		return err
+	}
+	if err := d.saveReferences(c); err != nil {
+		return err
+	}
+	if err := d.saveComputedFields(c); err != nil {
+		return err
+	}
+	if err := d.saveSerializeWrites(c); err != nil {
+		return err
 	}
 	d.collections[c.name] = c
 	return nil
@@ -439,10 +954,19 @@ func (d *DB) GetCollection(name string, opts ...Option) *Collection {
 	if err := d.connector.Validate(args.Token, true); err != nil {
 		return nil
 	}
-	return d.collections[name]
+	c, err := d.getCollection(name)
+	if err != nil {
+		if !errors.Is(err, ErrCollectionNotFound) {
+			log.Errorf("error loading collection %s: %v", name, err)
+		}
+		return nil
+	}
+	return c
 }
 
 // ListCollections returns all collections.
+// Collections that haven't been accessed yet are hydrated, use
+// ListCollectionNames to enumerate collections without loading them.
 func (d *DB) ListCollections(opts ...Option) []*Collection {
 	d.lock.Lock()
 	defer d.lock.Unlock()
@@ -453,6 +977,11 @@ func (d *DB) ListCollections(opts ...Option) []*Collection {
 	if err := d.connector.Validate(args.Token, true); err != nil {
 		return nil
 	}
+	for name := range d.unloaded {
+		if _, err := d.getCollection(name); err != nil {
+			log.Errorf("error loading collection %s: %v", name, err)
+		}
+	}
 	list := make([]*Collection, len(d.collections))
 	var i int
 	for _, c := range d.collections {
@@ -462,6 +991,28 @@ func (d *DB) ListCollections(opts ...Option) []*Collection {
 	return list
 }
 
+// ListCollectionNames returns the names of all collections without hydrating them.
+func (d *DB) ListCollectionNames(opts ...Option) []string {
+	d.lock.Lock()
+	defer d.lock.Unlock()
+	args := &Options{}
+	for _, opt := range opts {
+		opt(args)
+	}
+	if err := d.connector.Validate(args.Token, true); err != nil {
+		return nil
+	}
+	names := make([]string, 0, len(d.collections)+len(d.unloaded))
+	for name := range d.collections {
+		names = append(names, name)
+	}
+	for name := range d.unloaded {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
 // DeleteCollection deletes collection by name and drops all indexes.
 func (d *DB) DeleteCollection(name string, opts ...Option) error {
 	d.lock.Lock()
@@ -473,34 +1024,100 @@ func (d *DB) DeleteCollection(name string, opts ...Option) error {
 	if err := d.connector.Validate(args.Token, false); err != nil {
 		return err
 	}
-	c, ok := d.collections[name]
-	if !ok {
-		return ErrCollectionNotFound
+	c, err := d.getCollection(name)
+	if err != nil {
+		return err
 	}
 	txn, err := d.datastore.NewTransaction(false)
 	if err != nil {
 		return err
 	}
-	if err := txn.Delete(dsIndexes.ChildString(c.name)); err != nil {
-		return err
+	for _, prefix := range collectionKeys {
+		if err := txn.Delete(prefix.ChildString(c.name)); err != nil {
+			return err
+		}
 	}
-	if err := txn.Delete(dsSchemas.ChildString(c.name)); err != nil {
-		return err
+	for _, prefix := range collectionKeyPrefixes {
+		if err := deletePrefix(d.datastore, txn, prefix.ChildString(c.name)); err != nil {
+			return err
+		}
 	}
-	if err := txn.Delete(dsValidators.ChildString(c.name)); err != nil {
+	if err := txn.Commit(); err != nil {
 		return err
 	}
-	if err := txn.Delete(dsFilters.ChildString(c.name)); err != nil {
+	delete(d.collections, c.name)
+	return nil
+}
+
+// MoveInstance atomically moves an instance from the source collection to the destination collection.
+// The instance is validated against the destination schema and the resulting delete and create
+// events are written in a single record, so remote peers apply them together.
+func (d *DB) MoveInstance(srcCollection, dstCollection string, id core.InstanceID, opts ...TxnOption) error {
+	d.lock.Lock()
+	src, err := d.getCollection(srcCollection)
+	if err != nil {
+		d.lock.Unlock()
 		return err
 	}
-	if err := txn.Commit(); err != nil {
+	dst, err := d.getCollection(dstCollection)
+	d.lock.Unlock()
+	if err != nil {
 		return err
 	}
-	delete(d.collections, c.name)
-	return nil
+	return d.writeTxn(src, func(txn *Txn) error {
+		if err := d.connector.Validate(txn.token, false); err != nil {
+			return err
+		}
+		instance, err := d.datastore.Get(src.baseKey().ChildString(id.String()))
+		if errors.Is(err, ds.ErrNotFound) {
+			return ErrInstanceNotFound
+		}
+		if err != nil {
+			return err
+		}
+		exists, err := d.datastore.Has(dst.baseKey().ChildString(id.String()))
+		if err != nil {
+			return err
+		}
+		if exists {
+			return errCantCreateExistingInstance
+		}
+		if instance, err = src.decryptFields(instance); err != nil {
+			return err
+		}
+		instance, err = dst.withoutUndeclaredModTag(instance)
+		if err != nil {
+			return err
+		}
+		if err := dst.validInstance(instance); err != nil {
+			return err
+		}
+		if instance, err = dst.encryptFields(instance, nil); err != nil {
+			return err
+		}
+		_, instance = setModifiedTag(instance)
+		txn.actions = append(txn.actions, core.Action{
+			Type:           core.Delete,
+			InstanceID:     id,
+			CollectionName: src.name,
+		}, core.Action{
+			Type:           core.Create,
+			InstanceID:     id,
+			CollectionName: dst.name,
+			Current:        instance,
+		})
+		return nil
+	}, opts...)
 }
 
 func (d *DB) Close() error {
+	return d.close(ErrDBClosed)
+}
+
+// close closes the db once the running transactions are done. Later
+// transactions fail, and listeners are closed, with reason.
+func (d *DB) close(reason error) error {
+	d.stopSweeper()
 	d.lock.Lock()
 	defer d.lock.Unlock()
 	d.txnlock.Lock()
@@ -510,19 +1127,41 @@ func (d *DB) Close() error {
 		return nil
 	}
 	d.closed = true
+	d.closeErr = reason
 
 	d.localEventsBus.Discard()
+	d.stateChangedNotifee.close(reason)
 	if !managedDatastore(d.datastore) {
 		if err := d.datastore.Close(); err != nil {
 			return err
 		}
 	}
-	d.stateChangedNotifee.close()
+	return nil
+}
+
+// checkOpen returns why the db was closed, if it was.
+// The caller must hold d.txnlock.
+func (d *DB) checkOpen() error {
+	if d.closed {
+		return d.closeErr
+	}
 	return nil
 }
 
 func (d *DB) Reduce(events []core.Event) error {
-	codecActions, err := d.eventcodec.Reduce(events, d.datastore, baseKey, defaultIndexFunc(d))
+	indexFunc := defaultIndexFunc(d)
+	var written map[string][]instanceState
+	if d.stateChangedNotifee.needsStates() {
+		// Keep the instance states of each write for listeners
+		written = make(map[string][]instanceState)
+		next := indexFunc
+		indexFunc = func(collection string, key ds.Key, oldData, newData []byte, txn ds.Txn) error {
+			k := instanceStateKey(collection, core.InstanceID(key.Name()))
+			written[k] = append(written[k], instanceState{previous: oldData, current: newData})
+			return next(collection, key, oldData, newData, txn)
+		}
+	}
+	codecActions, err := d.eventcodec.Reduce(events, d.datastore, baseKey, indexFunc)
 	if err != nil {
 		return err
 	}
@@ -539,9 +1178,24 @@ func (d *DB) Reduce(events []core.Event) error {
 		default:
 			panic("eventcodec action not recognized")
 		}
-		actions[i] = Action{Collection: ca.Collection, Type: actionType, ID: ca.InstanceID}
+		actions[i] = Action{Collection: ca.Collection, Type: actionType, ID: ca.InstanceID, Metadata: ca.Metadata}
+	}
+	var states []instanceState
+	if written != nil {
+		// Actions of an instance are reduced in order
+		states = make([]instanceState, len(actions))
+		for i, a := range actions {
+			k := instanceStateKey(a.Collection, a.ID)
+			if len(written[k]) > 0 {
+				states[i] = written[k][0]
+				written[k] = written[k][1:]
+			}
+		}
 	}
-	d.notifyStateChanged(actions)
+	if err := d.recordActions(actions); err != nil {
+		log.Errorf("error recording actions: %v", err)
+	}
+	d.notifyStateChanged(actions, states)
 	return nil
 }
 
@@ -551,9 +1205,22 @@ func defaultIndexFunc(d *DB) func(collection string, key ds.Key, oldData, newDat
 		if c == nil {
 			return fmt.Errorf("collection (%s) not found", collection)
 		}
+		d.usage.add(instanceSizeDelta(key, oldData, newData))
 		if err := c.indexDelete(txn, key, oldData); err != nil {
 			return err
 		}
+		if err := d.updateTombstone(collection, key, oldData, newData, txn); err != nil {
+			return err
+		}
+		if err := c.updateModifiedIndex(key, oldData, newData, txn); err != nil {
+			return err
+		}
+		if err := c.updateInstanceVersion(key, newData, txn); err != nil {
+			return err
+		}
+		if err := c.updateExpiryIndex(key, oldData, newData, txn); err != nil {
+			return err
+		}
 		if newData == nil {
 			return nil
 		}
@@ -570,9 +1237,11 @@ func (d *DB) ValidateNetRecordBody(_ context.Context, body format.Node, identity
 		return nil
 	}
 	for _, e := range events {
-		c, ok := d.collections[e.Collection()]
-		if !ok {
-			return ErrCollectionNotFound
+		d.lock.Lock()
+		c, err := d.getCollection(e.Collection())
+		d.lock.Unlock()
+		if err != nil {
+			return err
 		}
 		if err := c.validWrite(identity, e); err != nil {
 			return err
@@ -586,27 +1255,37 @@ func parseJSON(vm *goja.Runtime, val []byte) (goja.Value, error) {
 }
 
 func (d *DB) HandleNetRecord(ctx context.Context, rec net.ThreadRecord, key thread.Key) error {
-	event, err := threadcbor.EventFromRecord(ctx, d.connector.Net, rec.Value())
+	atomic.AddUint64(&d.handledRecords, 1)
+	events, err := d.eventsFromRecord(ctx, rec.Value(), rec.LogID(), key)
 	if err != nil {
-		block, err := d.getBlockWithRetry(ctx, rec.Value())
+		return err
+	}
+	log.Debugf("dispatching new record: %s/%s", rec.ThreadID(), rec.LogID())
+	return d.dispatch(events)
+}
+
+// eventsFromRecord decodes the events of a record from the given log.
+func (d *DB) eventsFromRecord(ctx context.Context, rec net.Record, lid peer.ID, key thread.Key) ([]core.Event, error) {
+	event, err := threadcbor.EventFromRecord(ctx, d.connector.Net, rec)
+	if err != nil {
+		block, err := d.getBlockWithRetry(ctx, rec)
 		if err != nil {
-			return fmt.Errorf("error when getting block from record: %v", err)
+			return nil, fmt.Errorf("error when getting block from record: %v", err)
 		}
 		event, err = threadcbor.EventFromNode(block)
 		if err != nil {
-			return fmt.Errorf("error when decoding block to event: %v", err)
+			return nil, fmt.Errorf("error when decoding block to event: %v", err)
 		}
 	}
 	body, err := event.GetBody(ctx, d.connector.Net, key.Read())
 	if err != nil {
-		return fmt.Errorf("error when getting body of event on thread %s/%s: %v", d.connector.ThreadID(), rec.LogID(), err)
+		return nil, fmt.Errorf("error when getting body of event on thread %s/%s: %v", d.connector.ThreadID(), lid, err)
 	}
 	events, err := d.eventcodec.EventsFromBytes(body.RawData())
 	if err != nil {
-		return fmt.Errorf("error when unmarshaling event from bytes: %v", err)
+		return nil, fmt.Errorf("error when unmarshaling event from bytes: %v", err)
 	}
-	log.Debugf("dispatching new record: %s/%s", rec.ThreadID(), rec.LogID())
-	return d.dispatch(events)
+	return events, nil
 }
 
 // getBlockWithRetry gets a record block with exponential backoff.
@@ -630,19 +1309,34 @@ func (d *DB) getBlockWithRetry(ctx context.Context, rec net.Record) (format.Node
 func (d *DB) dispatch(events []core.Event) error {
 	d.txnlock.Lock()
 	defer d.txnlock.Unlock()
+	if err := d.checkOpen(); err != nil {
+		return err
+	}
 	return d.dispatcher.Dispatch(events)
 }
 
 func (d *DB) readTxn(c *Collection, f func(txn *Txn) error, opts ...TxnOption) error {
+	defer c.throughput.observe(false, time.Now())
 	d.txnlock.RLock()
 	defer d.txnlock.RUnlock()
+	if err := d.checkOpen(); err != nil {
+		return err
+	}
 
 	args := &TxnOptions{}
 	for _, opt := range opts {
 		opt(args)
 	}
-	txn := &Txn{collection: c, token: args.Token, readonly: true}
+	txn := &Txn{collection: c, token: args.Token, readonly: true, ctx: args.Context}
 	defer txn.Discard()
+	if args.Snapshot {
+		snapshot, err := d.datastore.NewTransaction(true)
+		if err != nil {
+			return err
+		}
+		defer snapshot.Discard()
+		txn.snapshot = snapshot
+	}
 	if err := f(txn); err != nil {
 		return err
 	}
@@ -650,17 +1344,44 @@ func (d *DB) readTxn(c *Collection, f func(txn *Txn) error, opts ...TxnOption) e
 }
 
 func (d *DB) writeTxn(c *Collection, f func(txn *Txn) error, opts ...TxnOption) error {
+	defer c.throughput.observe(true, time.Now())
+	if c.writes != nil {
+		args := &TxnOptions{}
+		for _, opt := range opts {
+			opt(args)
+		}
+		c.writes.enter(args.WritePosition)
+	}
+	txn, err := d.commitTxn(c, f, opts...)
+	if c.writes != nil {
+		c.writes.leave()
+	}
+	if err != nil {
+		return err
+	}
+	// After hooks run once the txn lock is released, so they can write to the db
+	txn.runAfterWriteHooks()
+	return nil
+}
+
+func (d *DB) commitTxn(c *Collection, f func(txn *Txn) error, opts ...TxnOption) (*Txn, error) {
 	d.txnlock.Lock()
 	defer d.txnlock.Unlock()
+	if err := d.checkOpen(); err != nil {
+		return nil, err
+	}
 
 	args := &TxnOptions{}
 	for _, opt := range opts {
 		opt(args)
 	}
-	txn := &Txn{collection: c, token: args.Token}
+	txn := &Txn{collection: c, token: args.Token, metadata: args.Metadata, compareMod: args.CompareMod, ctx: args.Context}
 	defer txn.Discard()
 	if err := f(txn); err != nil {
-		return err
+		return nil, err
+	}
+	if err := txn.Commit(); err != nil {
+		return nil, err
 	}
-	return txn.Commit()
+	return txn, nil
 }
@@ -8,17 +8,22 @@ import (
 	"reflect"
 	"strings"
 	"sync"
+	"sync/atomic"
 	"testing"
 	"time"
 
 	format "github.com/ipfs/go-ipld-format"
 	"github.com/multiformats/go-multiaddr"
+	"github.com/prometheus/client_golang/prometheus"
 	ds "github.com/textileio/go-datastore"
 	"github.com/textileio/go-threads/common"
 	"github.com/textileio/go-threads/core/app"
 	core "github.com/textileio/go-threads/core/db"
+	"github.com/textileio/go-threads/core/net"
 	"github.com/textileio/go-threads/core/thread"
+	"github.com/textileio/go-threads/jsonpatcher"
 	"github.com/textileio/go-threads/util"
+	"github.com/tidwall/gjson"
 )
 
 func TestE2EWithThreads(t *testing.T) {
@@ -208,6 +213,63 @@ func TestWithNewEventCodec(t *testing.T) {
 	checkErr(t, d.Close())
 }
 
+func TestWithNewEventCodecName(t *testing.T) {
+	t.Parallel()
+	tmpDir, err := ioutil.TempDir("", "")
+	checkErr(t, err)
+	defer os.RemoveAll(tmpDir)
+
+	var codec *mockEventCodec
+	checkErr(t, RegisterEventCodec("mock", func() core.EventCodec {
+		codec = &mockEventCodec{}
+		return codec
+	}))
+	if err := RegisterEventCodec("mock", nil); !errors.Is(err, ErrEventCodecAlreadyRegistered) {
+		t.Fatalf("expected codec already registered error, got %v", err)
+	}
+	if names := RegisteredEventCodecs(); !reflect.DeepEqual(names, []string{DefaultEventCodec, "mock"}) {
+		t.Fatalf("expected registered codecs, got %v", names)
+	}
+
+	n, err := common.DefaultNetwork(tmpDir, common.WithNetDebug(true), common.WithNetHostAddr(util.FreeLocalAddr()))
+	checkErr(t, err)
+	id := thread.NewIDV1(thread.Raw, 32)
+	if _, err := NewDB(context.Background(), n, id, WithNewRepoPath(tmpDir), WithNewEventCodecName("missing")); !errors.Is(err, ErrEventCodecNotRegistered) {
+		t.Fatalf("expected codec not registered error, got %v", err)
+	}
+	d, err := NewDB(context.Background(), n, id, WithNewRepoPath(tmpDir), WithNewEventCodecName("mock"))
+	checkErr(t, err)
+	if d.EventCodecName() != "mock" {
+		t.Fatalf("expected mock codec, got %s", d.EventCodecName())
+	}
+	info, err := d.GetDBInfo()
+	checkErr(t, err)
+	checkErr(t, n.Close())
+	checkErr(t, d.Close())
+
+	// Reopening selects the persisted codec
+	time.Sleep(time.Second * 3)
+	n, err = common.DefaultNetwork(tmpDir, common.WithNetDebug(true), common.WithNetHostAddr(util.FreeLocalAddr()))
+	checkErr(t, err)
+	defer n.Close()
+	if _, err := NewDB(context.Background(), n, id, WithNewRepoPath(tmpDir), WithNewThreadKey(info.Key), WithNewEventCodecName(DefaultEventCodec)); !errors.Is(err, ErrEventCodecMismatch) {
+		t.Fatalf("expected codec mismatch error, got %v", err)
+	}
+	d, err = NewDB(context.Background(), n, id, WithNewRepoPath(tmpDir), WithNewThreadKey(info.Key))
+	checkErr(t, err)
+	defer d.Close()
+	m, err := d.NewCollection(CollectionConfig{
+		Name:   "dummy",
+		Schema: util.SchemaFromInstance(&dummy{}, false),
+	})
+	checkErr(t, err)
+	_, err = m.Create(util.JSONFromInstance(dummy{Name: "Textile"}))
+	checkErr(t, err)
+	if d.EventCodecName() != "mock" || !codec.called {
+		t.Fatalf("expected the persisted codec to be used")
+	}
+}
+
 func TestListeners(t *testing.T) {
 	t.Parallel()
 
@@ -329,6 +391,222 @@ func TestListeners(t *testing.T) {
 	})
 }
 
+func TestListenerOrder(t *testing.T) {
+	t.Parallel()
+	d, clean := createTestDB(t)
+	defer clean()
+	c, err := d.NewCollection(CollectionConfig{
+		Name:   "dummy",
+		Schema: util.SchemaFromInstance(&dummy{}, false),
+	})
+	checkErr(t, err)
+
+	ll, err := d.ListenWithOptions(nil, WithListenerOrder(ListenPerKeyOrder, 3))
+	checkErr(t, err)
+	l, ok := ll.(PartitionedListener)
+	if !ok {
+		t.Fatal("expected a partitioned listener")
+	}
+	if len(l.Partitions()) != 3 {
+		t.Fatalf("expected 3 partitions, got %d", len(l.Partitions()))
+	}
+	var lock sync.Mutex
+	actions := make(map[core.InstanceID][]ActionType)
+	var wg sync.WaitGroup
+	for _, p := range l.Partitions() {
+		wg.Add(1)
+		go func(p <-chan Action) {
+			defer wg.Done()
+			for a := range p {
+				lock.Lock()
+				actions[a.ID] = append(actions[a.ID], a.Type)
+				lock.Unlock()
+			}
+		}(p)
+	}
+
+	var ids []core.InstanceID
+	for i := 0; i < 5; i++ {
+		id, err := c.Create(util.JSONFromInstance(dummy{Name: "Textile"}))
+		checkErr(t, err)
+		ids = append(ids, id)
+	}
+	for _, id := range ids {
+		checkErr(t, c.Save(util.JSONFromInstance(dummy{ID: id, Name: "Textile", Counter: 1})))
+		checkErr(t, c.Delete(id))
+	}
+	time.Sleep(time.Second)
+	l.Close()
+	wg.Wait()
+
+	expected := []ActionType{ActionCreate, ActionSave, ActionDelete}
+	for _, id := range ids {
+		if !reflect.DeepEqual(actions[id], expected) {
+			t.Fatalf("wrong actions for %s, expected %v, got %v", id, expected, actions[id])
+		}
+	}
+}
+
+func TestQueryListener(t *testing.T) {
+	t.Parallel()
+	d, clean := createTestDB(t)
+	defer clean()
+	c, err := d.NewCollection(CollectionConfig{
+		Name:   "dummy",
+		Schema: util.SchemaFromInstance(&dummy{}, false),
+	})
+	checkErr(t, err)
+
+	if _, err := d.Listen(ListenOption{Query: &Query{ThenSort: []Sort{{FieldPath: "Name"}}}}); err == nil {
+		t.Fatal("expected invalid query to be rejected")
+	}
+	l, err := d.Listen(ListenOption{Collection: "dummy", Query: Where("Name").Eq("open")})
+	checkErr(t, err)
+	var actions []Action
+	var wg sync.WaitGroup
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		for a := range l.Channel() {
+			actions = append(actions, withoutResumeToken(a))
+		}
+	}()
+
+	open, err := c.Create(util.JSONFromInstance(dummy{Name: "open"}))
+	checkErr(t, err)
+	closed, err := c.Create(util.JSONFromInstance(dummy{Name: "closed"}))
+	checkErr(t, err)
+	checkErr(t, c.Save(util.JSONFromInstance(dummy{ID: closed, Name: "closed", Counter: 1})))
+	// Saves of instances that stop matching are delivered
+	checkErr(t, c.Save(util.JSONFromInstance(dummy{ID: open, Name: "closed"})))
+	checkErr(t, c.Delete(closed))
+	checkErr(t, c.Delete(open))
+	time.Sleep(time.Second)
+	l.Close()
+	wg.Wait()
+
+	expected := []Action{
+		{Collection: "dummy", Type: ActionCreate, ID: open},
+		{Collection: "dummy", Type: ActionSave, ID: open},
+	}
+	if !reflect.DeepEqual(actions, expected) {
+		t.Fatalf("wrong actions detected, expected %v, got %v", expected, actions)
+	}
+}
+
+func TestResumeListener(t *testing.T) {
+	t.Parallel()
+	d, clean := createTestDB(t, WithNewActionHistory(3))
+	defer clean()
+	c, err := d.NewCollection(CollectionConfig{
+		Name:   "dummy",
+		Schema: util.SchemaFromInstance(&dummy{}, false),
+	})
+	checkErr(t, err)
+	receive := func(l Listener) Action {
+		select {
+		case a := <-l.Channel():
+			return a
+		case <-time.After(time.Second * 5):
+			t.Fatal("timed out waiting for action")
+			return Action{}
+		}
+	}
+
+	l, err := d.Listen()
+	checkErr(t, err)
+	first, err := c.Create(util.JSONFromInstance(dummy{Name: "first"}))
+	checkErr(t, err)
+	a := receive(l)
+	if a.ID != first || a.ResumeToken == 0 {
+		t.Fatalf("expected action with a resume token, got %v", a)
+	}
+	token := a.ResumeToken
+	l.Close()
+
+	// Actions done while disconnected are replayed before new ones
+	second, err := c.Create(util.JSONFromInstance(dummy{Name: "second"}))
+	checkErr(t, err)
+	checkErr(t, c.Save(util.JSONFromInstance(dummy{ID: second, Name: "second", Counter: 1})))
+	l, err = d.ListenWithOptions([]ListenOption{{Collection: "dummy"}}, WithResumeToken(token))
+	checkErr(t, err)
+	checkErr(t, c.Delete(first))
+	expected := []Action{
+		{Collection: "dummy", Type: ActionCreate, ID: second, ResumeToken: token + 1},
+		{Collection: "dummy", Type: ActionSave, ID: second, ResumeToken: token + 2},
+		{Collection: "dummy", Type: ActionDelete, ID: first, ResumeToken: token + 3},
+	}
+	for _, e := range expected {
+		if a := receive(l); !reflect.DeepEqual(a, e) {
+			t.Fatalf("wrong action detected, expected %v, got %v", e, a)
+		}
+	}
+	l.Close()
+
+	// Only the latest actions are kept
+	if _, err := d.ListenWithOptions(nil, WithResumeToken(token-1)); !errors.Is(err, ErrInvalidResumeToken) {
+		t.Fatalf("expected invalid resume token error, got %v", err)
+	}
+	if _, err := d.ListenWithOptions(nil, WithResumeToken(token+4)); !errors.Is(err, ErrInvalidResumeToken) {
+		t.Fatalf("expected invalid resume token error, got %v", err)
+	}
+}
+
+func TestListenerPayload(t *testing.T) {
+	t.Parallel()
+	d, clean := createTestDB(t)
+	defer clean()
+	c, err := d.NewCollection(CollectionConfig{
+		Name:   "dummy",
+		Schema: util.SchemaFromInstance(&dummy{}, false),
+	})
+	checkErr(t, err)
+	instances, err := d.ListenWithOptions(nil, WithListenerPayload(PayloadInstances))
+	checkErr(t, err)
+	patches, err := d.ListenWithOptions(nil, WithListenerPayload(PayloadPatch))
+	checkErr(t, err)
+	receive := func(l Listener) Action {
+		select {
+		case a := <-l.Channel():
+			return a
+		case <-time.After(time.Second * 5):
+			t.Fatal("timed out waiting for action")
+			return Action{}
+		}
+	}
+
+	id, err := c.Create(util.JSONFromInstance(dummy{Name: "Textile"}))
+	checkErr(t, err)
+	a := receive(instances)
+	if a.Previous != nil || gjson.GetBytes(a.Current, "Name").String() != "Textile" {
+		t.Fatalf("wrong create payload: %v", a)
+	}
+	if a = receive(patches); gjson.GetBytes(a.Patch, "Name").String() != "Textile" {
+		t.Fatalf("wrong create patch: %s", a.Patch)
+	}
+
+	checkErr(t, c.Save(util.JSONFromInstance(dummy{ID: id, Name: "Textile", Counter: 1})))
+	a = receive(instances)
+	if gjson.GetBytes(a.Previous, "Counter").Int() != 0 || gjson.GetBytes(a.Current, "Counter").Int() != 1 {
+		t.Fatalf("wrong save payload: %v", a)
+	}
+	a = receive(patches)
+	if gjson.GetBytes(a.Patch, "Counter").Int() != 1 || gjson.GetBytes(a.Patch, "Name").Exists() {
+		t.Fatalf("wrong save patch: %s", a.Patch)
+	}
+
+	checkErr(t, c.Delete(id))
+	a = receive(instances)
+	if gjson.GetBytes(a.Previous, "Counter").Int() != 1 || a.Current != nil {
+		t.Fatalf("wrong delete payload: %v", a)
+	}
+	if a = receive(patches); string(a.Patch) != "null" {
+		t.Fatalf("wrong delete patch: %s", a.Patch)
+	}
+	instances.Close()
+	patches.Close()
+}
+
 // runListenersComplexUseCase runs a complex db use-case, and returns
 // Actions received with the ...ListenOption provided.
 func runListenersComplexUseCase(t *testing.T, los ...ListenOption) []Action {
@@ -360,7 +638,7 @@ func runListenersComplexUseCase(t *testing.T, los ...ListenOption) []Action {
 	wg.Add(1)
 	go func() {
 		for a := range l.Channel() {
-			actions = append(actions, a)
+			actions = append(actions, withoutResumeToken(a))
 		}
 		wg.Done()
 	}()
@@ -420,12 +698,188 @@ func runListenersComplexUseCase(t *testing.T, los ...ListenOption) []Action {
 	return actions
 }
 
+func TestMoveInstance(t *testing.T) {
+	t.Parallel()
+	d, clean := createTestDB(t)
+	defer clean()
+	pending, err := d.NewCollection(CollectionConfig{
+		Name:   "Pending",
+		Schema: util.SchemaFromInstance(&dummy{}, false),
+	})
+	checkErr(t, err)
+	active, err := d.NewCollection(CollectionConfig{
+		Name:   "Active",
+		Schema: util.SchemaFromInstance(&dummy{}, false),
+	})
+	checkErr(t, err)
+	strict, err := d.NewCollection(CollectionConfig{
+		Name:   "Strict",
+		Schema: util.SchemaFromInstance(&Person{}, false),
+	})
+	checkErr(t, err)
+
+	id, err := pending.Create(util.JSONFromInstance(dummy{Name: "Textile", Counter: 1}))
+	checkErr(t, err)
+
+	l, err := d.Listen()
+	checkErr(t, err)
+	var actions []Action
+	var wg sync.WaitGroup
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		for a := range l.Channel() {
+			actions = append(actions, withoutResumeToken(a))
+		}
+	}()
+
+	checkErr(t, d.MoveInstance(pending.GetName(), active.GetName(), id))
+	if exists, err := pending.Has(id); exists || err != nil {
+		t.Fatal("instance should have been removed from source collection")
+	}
+	res, err := active.FindByID(id)
+	checkErr(t, err)
+	moved := &dummy{}
+	util.InstanceFromJSON(res, moved)
+	if moved.ID != id || moved.Name != "Textile" || moved.Counter != 1 {
+		t.Fatalf(errInvalidInstanceState)
+	}
+	time.Sleep(time.Second)
+	l.Close()
+	wg.Wait()
+	expected := []Action{
+		{Collection: "Pending", Type: ActionDelete, ID: id},
+		{Collection: "Active", Type: ActionCreate, ID: id},
+	}
+	if !reflect.DeepEqual(actions, expected) {
+		t.Fatalf("wrong actions detected, expected %v, got %v", expected, actions)
+	}
+
+	if err := d.MoveInstance(active.GetName(), strict.GetName(), id); !errors.Is(err, ErrInvalidSchemaInstance) {
+		t.Fatalf("expected invalid instance error, got %v", err)
+	}
+	if err := d.MoveInstance(pending.GetName(), active.GetName(), id); !errors.Is(err, ErrInstanceNotFound) {
+		t.Fatalf("expected not found error, got %v", err)
+	}
+	if err := d.MoveInstance(pending.GetName(), "Missing", id); !errors.Is(err, ErrCollectionNotFound) {
+		t.Fatalf("expected collection not found error, got %v", err)
+	}
+}
+
+func TestDBWriteTxn(t *testing.T) {
+	t.Parallel()
+	codec := &countingEventCodec{EventCodec: jsonpatcher.New()}
+	d, clean := createTestDB(t, WithNewEventCodec(codec))
+	defer clean()
+	dummies, err := d.NewCollection(CollectionConfig{
+		Name:   "Dummy",
+		Schema: util.SchemaFromInstance(&dummy{}, false),
+	})
+	checkErr(t, err)
+	persons, err := d.NewCollection(CollectionConfig{
+		Name:   "Person",
+		Schema: util.SchemaFromInstance(&Person{}, false),
+	})
+	checkErr(t, err)
+	old, err := dummies.Create(util.JSONFromInstance(dummy{Name: "Old"}))
+	checkErr(t, err)
+
+	var dummyID, personID core.InstanceID
+	codec.created = 0
+	checkErr(t, d.WriteTxn(func(txn *DBTxn) error {
+		dt, err := txn.Collection("Dummy")
+		if err != nil {
+			return err
+		}
+		ids, err := dt.Create(util.JSONFromInstance(dummy{Name: "Textile"}))
+		if err != nil {
+			return err
+		}
+		dummyID = ids[0]
+		if err := dt.Delete(old); err != nil {
+			return err
+		}
+		pt, err := txn.Collection("Person")
+		if err != nil {
+			return err
+		}
+		ids, err = pt.Create(util.JSONFromInstance(Person{Name: "Alice", Age: 42}))
+		if err != nil {
+			return err
+		}
+		personID = ids[0]
+		if err := pt.Commit(); err == nil {
+			t.Fatal("collection txns of a db txn shouldn't commit on their own")
+		}
+		return nil
+	}))
+	if codec.created != 1 {
+		t.Fatalf("expected a single record, got %d", codec.created)
+	}
+	if ok, err := dummies.Has(dummyID); !ok || err != nil {
+		t.Fatal("dummy should have been created")
+	}
+	if ok, err := dummies.Has(old); ok || err != nil {
+		t.Fatal("old dummy should have been deleted")
+	}
+	if ok, err := persons.Has(personID); !ok || err != nil {
+		t.Fatal("person should have been created")
+	}
+
+	// A failing write aborts the writes to all collections
+	err = d.WriteTxn(func(txn *DBTxn) error {
+		dt, err := txn.Collection("Dummy")
+		if err != nil {
+			return err
+		}
+		if _, err := dt.Create(util.JSONFromInstance(dummy{Name: "Aborted"})); err != nil {
+			return err
+		}
+		pt, err := txn.Collection("Person")
+		if err != nil {
+			return err
+		}
+		_, err = pt.Create(util.JSONFromInstance(dummy{Name: "Invalid"}))
+		return err
+	})
+	if !errors.Is(err, ErrInvalidSchemaInstance) {
+		t.Fatalf("expected invalid instance error, got %v", err)
+	}
+	if n, err := dummies.Count(&Query{}); err != nil || n != 1 {
+		t.Fatalf("expected aborted writes to be discarded, got %d instances", n)
+	}
+	err = d.WriteTxn(func(txn *DBTxn) error {
+		_, err := txn.Collection("Missing")
+		return err
+	})
+	if !errors.Is(err, ErrCollectionNotFound) {
+		t.Fatalf("expected collection not found error, got %v", err)
+	}
+}
+
+// withoutResumeToken clears the resume token of an action, which depends on
+// the actions done before it.
+func withoutResumeToken(a Action) Action {
+	a.ResumeToken = 0
+	return a
+}
+
 type dummy struct {
 	ID      core.InstanceID `json:"_id"`
 	Name    string
 	Counter int
 }
 
+type countingEventCodec struct {
+	core.EventCodec
+	created int
+}
+
+func (c *countingEventCodec) Create(actions []core.Action) ([]core.Event, format.Node, error) {
+	c.created++
+	return c.EventCodec.Create(actions)
+}
+
 type mockEventCodec struct {
 	called bool
 }
@@ -444,3 +898,556 @@ func (dec *mockEventCodec) EventsFromBytes([]byte) ([]core.Event, error) {
 	dec.called = true
 	return nil, nil
 }
+
+func TestTxnMetadata(t *testing.T) {
+	t.Parallel()
+	d, clean := createTestDB(t)
+	defer clean()
+	c, err := d.NewCollection(CollectionConfig{
+		Name:   "Dog",
+		Schema: util.SchemaFromInstance(&dummy{}, false),
+	})
+	checkErr(t, err)
+
+	l, err := d.Listen()
+	checkErr(t, err)
+	var actions []Action
+	var wg sync.WaitGroup
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		for a := range l.Channel() {
+			actions = append(actions, withoutResumeToken(a))
+		}
+	}()
+
+	md := map[string]string{"request_id": "abc", "reason": "testing"}
+	id, err := c.Create(util.JSONFromInstance(dummy{Name: "Textile"}), WithTxnMetadata(md))
+	checkErr(t, err)
+	res, err := c.FindByID(id)
+	checkErr(t, err)
+	if strings.Contains(string(res), "request_id") {
+		t.Fatal("metadata shouldn't be part of the instance")
+	}
+	time.Sleep(time.Second)
+	l.Close()
+	wg.Wait()
+	expected := []Action{{Collection: "Dog", Type: ActionCreate, ID: id, Metadata: md}}
+	if !reflect.DeepEqual(actions, expected) {
+		t.Fatalf("wrong actions detected, expected %v, got %v", expected, actions)
+	}
+}
+
+func TestLazyCollections(t *testing.T) {
+	t.Parallel()
+	tmpDir, err := ioutil.TempDir("", "")
+	checkErr(t, err)
+	defer os.RemoveAll(tmpDir)
+
+	n, err := common.DefaultNetwork(tmpDir, common.WithNetDebug(true), common.WithNetHostAddr(util.FreeLocalAddr()))
+	checkErr(t, err)
+
+	id := thread.NewIDV1(thread.Raw, 32)
+	d, err := NewDB(context.Background(), n, id, WithNewRepoPath(tmpDir))
+	checkErr(t, err)
+	for _, name := range []string{"A", "B", "C"} {
+		_, err := d.NewCollection(CollectionConfig{
+			Name:    name,
+			Schema:  util.SchemaFromInstance(&Person{}, false),
+			Indexes: []Index{{Path: "Name"}},
+		})
+		checkErr(t, err)
+	}
+	info, err := d.GetDBInfo()
+	checkErr(t, err)
+	checkErr(t, n.Close())
+	checkErr(t, d.Close())
+
+	time.Sleep(time.Second * 3)
+	n, err = common.DefaultNetwork(tmpDir, common.WithNetDebug(true), common.WithNetHostAddr(util.FreeLocalAddr()))
+	checkErr(t, err)
+	defer n.Close()
+	d, err = NewDB(context.Background(), n, id, WithNewRepoPath(tmpDir), WithNewThreadKey(info.Key))
+	checkErr(t, err)
+	defer d.Close()
+	if len(d.collections) != 0 {
+		t.Fatalf("expected no hydrated collections, got %d", len(d.collections))
+	}
+	if names := d.ListCollectionNames(); !reflect.DeepEqual(names, []string{"A", "B", "C"}) {
+		t.Fatalf("expected collection names [A B C], got %v", names)
+	}
+	if _, err := d.NewCollection(CollectionConfig{Name: "B", Schema: util.SchemaFromInstance(&Person{}, false)}); !errors.Is(err, ErrCollectionAlreadyRegistered) {
+		t.Fatalf("expected already registered error, got %v", err)
+	}
+
+	b := d.GetCollection("B")
+	if b == nil {
+		t.Fatal("collection should be loaded on first access")
+	}
+	if len(d.collections) != 1 {
+		t.Fatalf("expected one hydrated collection, got %d", len(d.collections))
+	}
+	if _, ok := b.indexes["Name"]; !ok {
+		t.Fatal("collection indexes should be loaded")
+	}
+	if cs := d.ListCollections(); len(cs) != 3 {
+		t.Fatalf("expected 3 collections, got %d", len(cs))
+	}
+}
+
+func TestReadKeyRotation(t *testing.T) {
+	t.Parallel()
+	ctx := context.Background()
+	tmpDir1, err := ioutil.TempDir("", "")
+	checkErr(t, err)
+	defer os.RemoveAll(tmpDir1)
+	n1, err := common.DefaultNetwork(tmpDir1, common.WithNetDebug(true), common.WithNetHostAddr(util.FreeLocalAddr()))
+	checkErr(t, err)
+	defer n1.Close()
+
+	id := thread.NewIDV1(thread.Raw, 32)
+	cc := CollectionConfig{
+		Name:   "dummy",
+		Schema: util.SchemaFromInstance(&dummy{}, false),
+	}
+	d1, err := NewDB(ctx, n1, id, WithNewRepoPath(tmpDir1), WithNewCollections(cc))
+	checkErr(t, err)
+	defer d1.Close()
+	c1 := d1.GetCollection("dummy")
+	old, err := c1.Create(util.JSONFromInstance(dummy{Name: "old"}))
+	checkErr(t, err)
+
+	peer1ID, err := multiaddr.NewComponent("p2p", n1.Host().ID().String())
+	checkErr(t, err)
+	threadComp, err := multiaddr.NewComponent("thread", id.String())
+	checkErr(t, err)
+	addr := n1.Host().Addrs()[0].Encapsulate(peer1ID).Encapsulate(threadComp)
+	ti, err := n1.GetThread(ctx, id)
+	checkErr(t, err)
+
+	tmpDir2, err := ioutil.TempDir("", "")
+	checkErr(t, err)
+	defer os.RemoveAll(tmpDir2)
+	n2, err := common.DefaultNetwork(tmpDir2, common.WithNetDebug(true), common.WithNetHostAddr(util.FreeLocalAddr()))
+	checkErr(t, err)
+	defer n2.Close()
+	d2, err := NewDBFromAddr(ctx, n2, addr, ti.Key, WithNewRepoPath(tmpDir2), WithNewCollections(cc), WithNewBackfillBlock(true))
+	checkErr(t, err)
+	defer d2.Close()
+	c2 := d2.GetCollection("dummy")
+
+	// Records created after the rotation are encrypted with the new key
+	key, err := n1.(net.ReadKeyRotator).RotateReadKey(ctx, id)
+	checkErr(t, err)
+	rotated, err := c1.Create(util.JSONFromInstance(dummy{Name: "rotated"}))
+	checkErr(t, err)
+	if err := n2.PullThread(ctx, id); err == nil {
+		t.Fatal("records encrypted with an unknown read key should be rejected")
+	}
+	if exists, err := c2.Has(rotated); err != nil || exists {
+		t.Fatal("instance shouldn't be synced before learning the rotated key")
+	}
+
+	// Learn the rotated key and sync again
+	_, err = n2.(net.ReadKeyRotator).RotateReadKey(ctx, id, net.WithThreadReadKey(key.Read()))
+	checkErr(t, err)
+	checkErr(t, n2.PullThread(ctx, id))
+	for _, id := range []core.InstanceID{old, rotated} {
+		if exists, err := c2.Has(id); err != nil || !exists {
+			t.Fatalf("instance %s should have been synced", id)
+		}
+	}
+}
+
+var errFailingDatastore = errors.New("disk failure")
+
+// failingDatastore fails all writes once fail is set.
+type failingDatastore struct {
+	*TxMapDatastore
+	fail int32
+}
+
+func (f *failingDatastore) NewTransaction(_ bool) (ds.Txn, error) {
+	return NewSimpleTx(f), nil
+}
+
+func (f *failingDatastore) Put(key ds.Key, value []byte) error {
+	if atomic.LoadInt32(&f.fail) == 1 {
+		return errFailingDatastore
+	}
+	return f.TxMapDatastore.Put(key, value)
+}
+
+func TestStorageErrors(t *testing.T) {
+	t.Parallel()
+	store := &failingDatastore{TxMapDatastore: NewTxMapDatastore()}
+	d, clean := createTestDB(t, func(o *NewOptions) {
+		o.Datastore = store
+	})
+	defer clean()
+	c, err := d.NewCollection(CollectionConfig{
+		Name:   "Person",
+		Schema: util.SchemaFromInstance(&Person{}, false),
+	})
+	checkErr(t, err)
+
+	_, err = c.Create([]byte(`{"name": "Foo", "age": "old"}`))
+	var se *ErrStorage
+	if err == nil || errors.As(err, &se) {
+		t.Fatalf("validation errors shouldn't be storage errors, got %v", err)
+	}
+
+	atomic.StoreInt32(&store.fail, 1)
+	_, err = c.Create(util.JSONFromInstance(Person{Name: "Foo", Age: 42}))
+	if !errors.As(err, &se) {
+		t.Fatalf("expected storage error, got %v", err)
+	}
+	if !errors.Is(err, errFailingDatastore) {
+		t.Fatalf("storage error should wrap the datastore error, got %v", se.Err)
+	}
+}
+
+func TestRebuildFromLog(t *testing.T) {
+	t.Parallel()
+	d, clean := createTestDB(t)
+	defer clean()
+	c, err := d.NewCollection(CollectionConfig{
+		Name:    "Person",
+		Schema:  util.SchemaFromInstance(&Person{}, false),
+		Indexes: []Index{{Path: "Name"}},
+	})
+	checkErr(t, err)
+
+	p1, err := c.Create(util.JSONFromInstance(Person{Name: "Foo", Age: 42}))
+	checkErr(t, err)
+	p2, err := c.Create(util.JSONFromInstance(Person{Name: "Bar", Age: 21}))
+	checkErr(t, err)
+	v, err := c.FindByID(p1)
+	checkErr(t, err)
+	person := &Person{}
+	util.InstanceFromJSON(v, person)
+	person.Age = 43
+	checkErr(t, c.Save(util.JSONFromInstance(person)))
+	checkErr(t, c.Delete(p2))
+
+	// Corrupt the materialized state
+	checkErr(t, d.datastore.Delete(c.baseKey().ChildString(p1.String())))
+	checkErr(t, d.datastore.Put(c.baseKey().ChildString("bogus"), util.JSONFromInstance(Person{ID: "bogus", Name: "Foo"})))
+
+	var done, total int
+	err = d.RebuildFromLog(context.Background(), "Person", WithRebuildProgress(func(n, t int) {
+		done, total = n, t
+	}))
+	checkErr(t, err)
+	if total != 4 || done != total {
+		t.Fatalf("expected progress over 4 records, got %d/%d", done, total)
+	}
+
+	res, err := c.Find(Where("Name").Eq("Foo").UseIndex("Name"))
+	checkErr(t, err)
+	if len(res) != 1 {
+		t.Fatalf("expected one rebuilt instance, got %d", len(res))
+	}
+	rebuilt := &Person{}
+	util.InstanceFromJSON(res[0], rebuilt)
+	if rebuilt.ID != p1 || rebuilt.Age != 43 {
+		t.Fatalf("unexpected rebuilt instance %v", rebuilt)
+	}
+	res, err = c.Find(nil)
+	checkErr(t, err)
+	if len(res) != 1 {
+		t.Fatalf("expected deleted and bogus instances to be gone, got %d instances", len(res))
+	}
+
+	if err := d.RebuildFromLog(context.Background(), "Missing"); !errors.Is(err, ErrCollectionNotFound) {
+		t.Fatalf("expected collection not found error, got %v", err)
+	}
+}
+
+func TestInstanceHistory(t *testing.T) {
+	t.Parallel()
+	d, clean := createTestDB(t)
+	defer clean()
+	c, err := d.NewCollection(CollectionConfig{
+		Name:   "Person",
+		Schema: util.SchemaFromInstance(&Person{}, false),
+	})
+	checkErr(t, err)
+
+	id, err := c.Create(util.JSONFromInstance(Person{Name: "Foo", Age: 42}))
+	checkErr(t, err)
+	_, err = c.Create(util.JSONFromInstance(Person{Name: "Bar", Age: 21}))
+	checkErr(t, err)
+	v, err := c.FindByID(id)
+	checkErr(t, err)
+	person := &Person{}
+	util.InstanceFromJSON(v, person)
+	person.Age = 43
+	checkErr(t, c.Save(util.JSONFromInstance(person)))
+	checkErr(t, c.Delete(id))
+
+	versions, err := c.History(context.Background(), id)
+	checkErr(t, err)
+	if len(versions) != 3 {
+		t.Fatalf("expected 3 versions, got %d", len(versions))
+	}
+	for i, typ := range []core.ActionType{core.Create, core.Save, core.Delete} {
+		if versions[i].Type != typ || !versions[i].Record.Defined() || versions[i].Time.IsZero() {
+			t.Fatalf("unexpected version %d: %+v", i, versions[i])
+		}
+	}
+	if gjson.GetBytes(versions[0].Instance, "Age").Int() != 42 || gjson.GetBytes(versions[1].Instance, "Age").Int() != 43 {
+		t.Fatalf("expected the written ages, got %s and %s", versions[0].Instance, versions[1].Instance)
+	}
+	if versions[2].Instance != nil {
+		t.Fatalf("expected deleted version to have no instance, got %s", versions[2].Instance)
+	}
+
+	at, err := c.FindByIDAt(context.Background(), id, versions[0].Time)
+	checkErr(t, err)
+	if gjson.GetBytes(at, "Age").Int() != 42 {
+		t.Fatalf("expected the created instance, got %s", at)
+	}
+	if _, err := c.FindByIDAt(context.Background(), id, versions[0].Time.Add(-time.Nanosecond)); !errors.Is(err, ErrInstanceNotFound) {
+		t.Fatalf("expected instance not found before its creation, got %v", err)
+	}
+	if _, err := c.FindByIDAt(context.Background(), id, versions[2].Time); !errors.Is(err, ErrInstanceNotFound) {
+		t.Fatalf("expected instance not found after its deletion, got %v", err)
+	}
+	// Reverting writes the chosen version again
+	checkErr(t, c.Revert(context.Background(), id, versions[1].Record))
+	v, err = c.FindByID(id)
+	checkErr(t, err)
+	if gjson.GetBytes(v, "Age").Int() != 43 {
+		t.Fatalf("expected the deleted instance to be restored, got %s", v)
+	}
+	checkErr(t, c.Revert(context.Background(), id, versions[0].Record))
+	v, err = c.FindByID(id)
+	checkErr(t, err)
+	if gjson.GetBytes(v, "Age").Int() != 42 {
+		t.Fatalf("expected the created version to be restored, got %s", v)
+	}
+	versions, err = c.History(context.Background(), id)
+	checkErr(t, err)
+	if len(versions) != 5 {
+		t.Fatalf("expected the reverts in the history, got %d versions", len(versions))
+	}
+	if err := c.Revert(context.Background(), "bogus", versions[0].Record); !errors.Is(err, ErrVersionNotFound) {
+		t.Fatalf("expected version not found, got %v", err)
+	}
+}
+
+func TestReadOnly(t *testing.T) {
+	t.Parallel()
+	d, clean := createTestDB(t, WithNewReadOnly(true))
+	defer clean()
+	c, err := d.NewCollection(CollectionConfig{
+		Name:   "Person",
+		Schema: util.SchemaFromInstance(&Person{}, false),
+	})
+	checkErr(t, err)
+
+	if _, err := c.Create(util.JSONFromInstance(Person{Name: "Foo", Age: 42})); !errors.Is(err, ErrReadOnlyDB) {
+		t.Fatalf("expected read-only error, got %v", err)
+	}
+	err = d.WriteTxn(func(txn *DBTxn) error {
+		ct, err := txn.Collection("Person")
+		if err != nil {
+			return err
+		}
+		_, err = ct.Create(util.JSONFromInstance(Person{Name: "Foo", Age: 42}))
+		return err
+	})
+	if !errors.Is(err, ErrReadOnlyDB) {
+		t.Fatalf("expected read-only error, got %v", err)
+	}
+
+	// Remote writes are still applied
+	id := core.NewInstanceID()
+	events, _, err := d.eventcodec.Create([]core.Action{{
+		Type: core.Create, InstanceID: id, CollectionName: "Person", Current: util.JSONFromInstance(Person{ID: id, Name: "Bar", Age: 21}),
+	}})
+	checkErr(t, err)
+	checkErr(t, d.Reduce(events))
+	res, err := c.FindByID(id)
+	checkErr(t, err)
+	if gjson.GetBytes(res, "Name").String() != "Bar" {
+		t.Fatalf("expected the remote write to be applied, got %s", res)
+	}
+	if err := c.Delete(id); !errors.Is(err, ErrReadOnlyDB) {
+		t.Fatalf("expected read-only error, got %v", err)
+	}
+}
+
+func TestMeta(t *testing.T) {
+	t.Parallel()
+	d, clean := createTestDB(t)
+	defer clean()
+
+	if _, err := d.GetMeta("theme"); !errors.Is(err, ErrMetaNotFound) {
+		t.Fatalf("expected metadata not found error, got %v", err)
+	}
+	checkErr(t, d.SetMeta("theme", []byte("dark")))
+	checkErr(t, d.SetMeta("lang", []byte("en")))
+	v, err := d.GetMeta("theme")
+	checkErr(t, err)
+	if string(v) != "dark" {
+		t.Fatalf("unexpected metadata value %s", v)
+	}
+	meta, err := d.ListMeta()
+	checkErr(t, err)
+	if !reflect.DeepEqual(meta, map[string][]byte{"theme": []byte("dark"), "lang": []byte("en")}) {
+		t.Fatalf("unexpected metadata %v", meta)
+	}
+	checkErr(t, d.DeleteMeta("theme"))
+	if _, err := d.GetMeta("theme"); !errors.Is(err, ErrMetaNotFound) {
+		t.Fatalf("expected metadata not found error, got %v", err)
+	}
+	for _, key := range []string{"", "..", "a/b"} {
+		if err := d.SetMeta(key, []byte("x")); !errors.Is(err, ErrInvalidMetaKey) {
+			t.Fatalf("expected invalid key error for %q, got %v", key, err)
+		}
+	}
+}
+
+func TestQuota(t *testing.T) {
+	t.Parallel()
+	d, clean := createTestDB(t, WithNewQuota(400))
+	defer clean()
+	c, err := d.NewCollection(CollectionConfig{
+		Name:   "Person",
+		Schema: util.SchemaFromInstance(&Person{}, false),
+	})
+	checkErr(t, err)
+
+	var ids []core.InstanceID
+	var qerr *QuotaError
+	for i := 0; i < 10; i++ {
+		id, err := c.Create(util.JSONFromInstance(Person{Name: "Foo", Age: i}))
+		if errors.As(err, &qerr) {
+			break
+		}
+		checkErr(t, err)
+		ids = append(ids, id)
+	}
+	if qerr == nil || len(ids) == 0 {
+		t.Fatalf("expected a quota error after some writes, created %d instances", len(ids))
+	}
+	if !errors.Is(qerr, ErrQuotaExceeded) || qerr.Quota != 400 || qerr.Usage+qerr.Size <= 400 {
+		t.Fatalf("unexpected quota error %v", qerr)
+	}
+	usage, err := d.GetUsage()
+	checkErr(t, err)
+	stats, err := c.Stats()
+	checkErr(t, err)
+	if usage.Bytes != stats.Bytes || usage.Quota != 400 {
+		t.Fatalf("expected usage of %d bytes, got %+v", stats.Bytes, usage)
+	}
+
+	// Deletes free space
+	checkErr(t, c.Delete(ids[0]))
+	usage2, err := d.GetUsage()
+	checkErr(t, err)
+	if usage2.Bytes >= usage.Bytes {
+		t.Fatalf("delete should decrease usage, got %d from %d", usage2.Bytes, usage.Bytes)
+	}
+
+	checkErr(t, d.SetQuota(0))
+	for i := 0; i < 3; i++ {
+		_, err := c.Create(util.JSONFromInstance(Person{Name: "Foo", Age: i}))
+		checkErr(t, err)
+	}
+}
+
+func TestMetrics(t *testing.T) {
+	t.Parallel()
+	d, clean := createTestDB(t)
+	defer clean()
+	c, err := d.NewCollection(CollectionConfig{
+		Name:   "Person",
+		Schema: util.SchemaFromInstance(&Person{}, false),
+	})
+	checkErr(t, err)
+	l, err := d.Listen()
+	checkErr(t, err)
+	defer l.Close()
+
+	ids, err := c.CreateMany([][]byte{
+		util.JSONFromInstance(Person{Name: "Foo"}),
+		util.JSONFromInstance(Person{Name: "Bar"}),
+	})
+	checkErr(t, err)
+	res, err := c.FindByID(ids[0])
+	checkErr(t, err)
+	p := &Person{}
+	util.InstanceFromJSON(res, p)
+	p.Age = 42
+	checkErr(t, c.Save(util.JSONFromInstance(p), WithTxnCompareMod(true)))
+	if err := c.Save(util.JSONFromInstance(p), WithTxnCompareMod(true)); !errors.Is(err, ErrConflict) {
+		t.Fatalf("expected conflict error, got %v", err)
+	}
+	checkErr(t, c.Delete(ids[1]))
+	_, err = c.Find(Where("Name").Eq("Foo"))
+	checkErr(t, err)
+	_, err = c.Count(nil)
+	checkErr(t, err)
+
+	reg := prometheus.NewPedanticRegistry()
+	checkErr(t, reg.Register(d.Collector()))
+	families, err := reg.Gather()
+	checkErr(t, err)
+	metric := func(name string, labels map[string]string) float64 {
+		for _, f := range families {
+			if f.GetName() != name {
+				continue
+			}
+		metrics:
+			for _, m := range f.GetMetric() {
+				for _, lp := range m.GetLabel() {
+					if v, ok := labels[lp.GetName()]; ok && v != lp.GetValue() {
+						continue metrics
+					}
+				}
+				switch {
+				case m.Counter != nil:
+					return m.GetCounter().GetValue()
+				case m.Gauge != nil:
+					return m.GetGauge().GetValue()
+				case m.Histogram != nil:
+					return float64(m.GetHistogram().GetSampleCount())
+				}
+			}
+		}
+		return 0
+	}
+	for _, m := range []struct {
+		name   string
+		labels map[string]string
+		value  float64
+	}{
+		{"threads_db_writes_total", map[string]string{"collection": "Person", "type": "create"}, 2},
+		{"threads_db_writes_total", map[string]string{"collection": "Person", "type": "save"}, 1},
+		{"threads_db_writes_total", map[string]string{"collection": "Person", "type": "delete"}, 1},
+		{"threads_db_txn_conflicts_total", map[string]string{"collection": "Person"}, 1},
+		{"threads_db_query_duration_seconds", map[string]string{"collection": "Person"}, 2},
+		// The listener channel holds one action, the others are dropped
+		{"threads_db_listener_backlog", nil, 1},
+		{"threads_db_listener_dropped_total", nil, 3},
+	} {
+		if v := metric(m.name, m.labels); v != m.value {
+			t.Errorf("expected %s %v to be %v, got %v", m.name, m.labels, m.value, v)
+		}
+	}
+
+	man, cleanMan := createTestManager(t)
+	defer cleanMan()
+	_, err = man.NewDB(context.Background(), thread.NewIDV1(thread.Raw, 32))
+	checkErr(t, err)
+	reg = prometheus.NewRegistry()
+	checkErr(t, reg.Register(man.Collector()))
+	families, err = reg.Gather()
+	checkErr(t, err)
+	if metric("threads_db_listener_backlog", nil) != 0 || len(families) == 0 {
+		t.Fatal("expected metrics of managed dbs")
+	}
+}
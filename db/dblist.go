@@ -0,0 +1,211 @@
+package db
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	ds "github.com/textileio/go-datastore"
+	"github.com/textileio/go-threads/core/app"
+	"github.com/textileio/go-threads/core/net"
+	"github.com/textileio/go-threads/core/thread"
+)
+
+var (
+	dsCreated = dsPrefix.ChildString("created")
+	dsOwner   = dsPrefix.ChildString("owner")
+
+	// dsDBIndex is the key of the db index in the shared datastore of the
+	// manager, see dbIndexEntry.
+	dsDBIndex = ds.NewKey("/dbindex")
+)
+
+// ManagedDB is a managed db listed by Manager.ListDBsPage.
+type ManagedDB struct {
+	// ID is the ID of the db thread.
+	ID thread.ID
+	// Name is the db name.
+	Name string
+	// Description is the db description.
+	Description string
+	// Labels are the db labels.
+	Labels map[string]string
+	// Created is the db creation time, or zero if the db was created before
+	// creation times were recorded.
+	Created time.Time
+}
+
+// dbIndexEntry is the entry of a db in the db index of the manager. It holds
+// what ListDBsPage filters and returns, so dbs aren't opened to be listed.
+type dbIndexEntry struct {
+	Name        string            `json:"name,omitempty"`
+	Description string            `json:"description,omitempty"`
+	Labels      map[string]string `json:"labels,omitempty"`
+	Created     int64             `json:"created,omitempty"`
+	Owner       string            `json:"owner,omitempty"`
+	Delegates   []string          `json:"delegates,omitempty"`
+}
+
+// indexDB saves the index entry of a db. It's called as the db is opened, and
+// as its metadata or delegates change.
+func (m *Manager) indexDB(id thread.ID, d *DB) error {
+	md := d.getMetadata()
+	e := dbIndexEntry{
+		Name:        md.Name,
+		Description: md.Description,
+		Labels:      md.Labels,
+		Owner:       d.owner,
+	}
+	if !d.created.IsZero() {
+		e.Created = d.created.UnixNano()
+	}
+	d.lock.RLock()
+	for identity := range d.delegates {
+		e.Delegates = append(e.Delegates, identity)
+	}
+	d.lock.RUnlock()
+	sort.Strings(e.Delegates)
+	v, err := json.Marshal(e)
+	if err != nil {
+		return err
+	}
+	return m.opts.Datastore.Put(dsDBIndex.ChildString(id.String()), v)
+}
+
+// dbIndexEntry returns the index entry of a db. Dbs stored before the index
+// existed, and not opened since, have no entry, they're opened and indexed.
+func (m *Manager) dbIndexEntry(id thread.ID) (dbIndexEntry, error) {
+	v, err := m.opts.Datastore.Get(dsDBIndex.ChildString(id.String()))
+	if errors.Is(err, ds.ErrNotFound) {
+		d, err := m.getDB(id)
+		if err != nil {
+			return dbIndexEntry{}, err
+		}
+		err = m.indexDB(id, d)
+		m.ReleaseDB(id)
+		if err != nil {
+			return dbIndexEntry{}, err
+		}
+		return m.dbIndexEntry(id)
+	} else if err != nil {
+		return dbIndexEntry{}, err
+	}
+	var e dbIndexEntry
+	if err := json.Unmarshal(v, &e); err != nil {
+		return dbIndexEntry{}, err
+	}
+	return e, nil
+}
+
+// ListDBsPage returns a page of the managed dbs that match the filter options,
+// ordered by ID, along with the cursor of the next page, see WithListDBsCursor.
+// The cursor is empty once the last page is returned. Without a limit, see
+// WithListDBsLimit, all the matching dbs are returned. Dbs are filtered with
+// the db index of the manager, so they aren't opened to be listed, see GetDB.
+func (m *Manager) ListDBsPage(ctx context.Context, opts ...ListDBsOption) ([]ManagedDB, string, error) {
+	args := &ListDBsOptions{}
+	for _, opt := range opts {
+		opt(args)
+	}
+	var owner string
+	if args.Owner != nil {
+		owner = args.Owner.String()
+	}
+
+	var ids []thread.ID
+	for _, id := range m.dbIDs() {
+		if args.Cursor == "" || id.String() > args.Cursor {
+			ids = append(ids, id)
+		}
+	}
+	sort.Slice(ids, func(i, j int) bool {
+		return ids[i].String() < ids[j].String()
+	})
+	var page []ManagedDB
+	for _, id := range ids {
+		if args.Limit > 0 && len(page) == args.Limit {
+			return page, page[len(page)-1].ID.String(), nil
+		}
+		e, err := m.dbIndexEntry(id)
+		if errors.Is(err, ErrDBNotFound) {
+			// Deleted since the IDs were listed
+			continue
+		} else if err != nil {
+			return nil, "", err
+		}
+		if owner != "" && e.Owner != owner {
+			continue
+		}
+		if ok, err := m.canAccessIndexed(id, e, args.Token); err != nil {
+			return nil, "", err
+		} else if !ok {
+			continue
+		}
+		if !strings.HasPrefix(e.Name, args.NamePrefix) {
+			continue
+		}
+		var created time.Time
+		if e.Created != 0 {
+			created = time.Unix(0, e.Created)
+		}
+		if !args.CreatedAfter.IsZero() && !created.After(args.CreatedAfter) {
+			continue
+		}
+		if !args.CreatedBefore.IsZero() && !created.Before(args.CreatedBefore) {
+			continue
+		}
+		if _, err := m.network.GetThread(ctx, id, net.WithThreadToken(args.Token)); err != nil {
+			return nil, "", err
+		}
+		page = append(page, ManagedDB{
+			ID:          id,
+			Name:        e.Name,
+			Description: e.Description,
+			Labels:      e.Labels,
+			Created:     created,
+		})
+	}
+	return page, "", nil
+}
+
+// loadOrigin loads the creation time and owner of the db. They're recorded
+// as the db is first opened, the owner being the identity of token, if any,
+// as validated by the network.
+func (d *DB) loadOrigin(n app.Net, id thread.ID, first bool, token thread.Token) error {
+	if first {
+		d.created = time.Now()
+		if err := d.datastore.Put(dsCreated, []byte(strconv.FormatInt(d.created.UnixNano(), 10))); err != nil {
+			return err
+		}
+		pk, err := n.Validate(id, token, false)
+		if err != nil {
+			return err
+		}
+		if pk != nil {
+			d.owner = pk.String()
+			return d.datastore.Put(dsOwner, []byte(d.owner))
+		}
+		return nil
+	}
+	v, err := d.datastore.Get(dsCreated)
+	if err == nil {
+		ns, err := strconv.ParseInt(string(v), 10, 64)
+		if err != nil {
+			return err
+		}
+		d.created = time.Unix(0, ns)
+	} else if !errors.Is(err, ds.ErrNotFound) {
+		return err
+	}
+	v, err = d.datastore.Get(dsOwner)
+	if err == nil {
+		d.owner = string(v)
+	} else if !errors.Is(err, ds.ErrNotFound) {
+		return err
+	}
+	return nil
+}
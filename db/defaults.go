@@ -0,0 +1,82 @@
+package db
+
+import (
+	"encoding/json"
+	"sort"
+	"strings"
+
+	"github.com/alecthomas/jsonschema"
+	"github.com/tidwall/gjson"
+	"github.com/tidwall/sjson"
+)
+
+// maxDefaultsDepth bounds the nesting of schema properties searched for
+// defaults, so recursive definitions terminate.
+const maxDefaultsDepth = 16
+
+// schemaDefault is the default value of a schema property.
+type schemaDefault struct {
+	path  string
+	value []byte
+}
+
+// schemaDefaults returns the default values of the schema properties,
+// with the defaults of parent properties before the ones of their children.
+func schemaDefaults(schema *jsonschema.Schema) ([]schemaDefault, error) {
+	var defaults []schemaDefault
+	var walk func(jt *jsonschema.Type, prefix string, depth int) error
+	walk = func(jt *jsonschema.Type, prefix string, depth int) error {
+		if depth > maxDefaultsDepth {
+			return nil
+		}
+		props, err := getSchemaTypeProperties(jt, schema.Definitions)
+		if err != nil {
+			return err
+		}
+		names := make([]string, 0, len(props))
+		for name := range props {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		for _, name := range names {
+			pth := prefix + name
+			if pth == idFieldName || pth == modFieldName {
+				continue
+			}
+			if props[name].Default != nil {
+				value, err := json.Marshal(props[name].Default)
+				if err != nil {
+					return err
+				}
+				defaults = append(defaults, schemaDefault{path: pth, value: value})
+			}
+			if err := walk(props[name], pth+".", depth+1); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+	if err := walk(schema.Type, "", 0); err != nil {
+		return nil, err
+	}
+	return defaults, nil
+}
+
+// applyDefaults sets the missing fields of an instance that have a default
+// value in the collection schema. Defaults of nested fields are only applied
+// if their parent object is present, or has a default itself.
+func (c *Collection) applyDefaults(v []byte) ([]byte, error) {
+	for _, d := range c.defaults {
+		if gjson.GetBytes(v, d.path).Exists() {
+			continue
+		}
+		if i := strings.LastIndex(d.path, "."); i >= 0 && !gjson.GetBytes(v, d.path[:i]).IsObject() {
+			continue
+		}
+		var err error
+		if v, err = sjson.SetRawBytes(v, d.path, d.value); err != nil {
+			return nil, err
+		}
+	}
+	return v, nil
+}
@@ -0,0 +1,211 @@
+package db
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+
+	ds "github.com/textileio/go-datastore"
+	"github.com/textileio/go-threads/core/thread"
+	"github.com/textileio/go-threads/crypto/symmetric"
+	"github.com/tidwall/gjson"
+	"github.com/tidwall/sjson"
+)
+
+// encryptedPrefix marks the encrypted values of instance fields.
+const encryptedPrefix = "enc:"
+
+var (
+	// ErrInvalidEncryptedField indicates an encrypted field isn't in the collection
+	// schema, is a reserved or indexed field, or is the TTL field.
+	ErrInvalidEncryptedField = errors.New("invalid encrypted field")
+	// ErrInvalidKeyEncryptionKey indicates the stored key of encrypted fields
+	// is wrapped, but the db has no key encryption key, or a different one.
+	// See WithNewKeyEncryptionKey.
+	ErrInvalidKeyEncryptionKey = errors.New("invalid key encryption key")
+
+	dsEncryption = dsPrefix.ChildString("encryption")
+)
+
+// fieldEncryption holds the encrypted fields of a collection and their key.
+type fieldEncryption struct {
+	fields []string
+	key    *symmetric.Key
+}
+
+// encryptionState is the persisted field encryption of a collection. Key is
+// encrypted with the key encryption key of the db if wrapped.
+type encryptionState struct {
+	Fields  []string `json:"fields"`
+	Key     []byte   `json:"key"`
+	Wrapped bool     `json:"wrapped,omitempty"`
+}
+
+// newFieldEncryption validates the encrypted fields of a collection config,
+// and returns their encryption. A key is generated if the config has none.
+func newFieldEncryption(config CollectionConfig) (fieldEncryption, error) {
+	if len(config.EncryptedFields) == 0 {
+		// The key of previously encrypted fields is kept
+		return fieldEncryption{key: config.EncryptionKey}, nil
+	}
+	for _, pth := range config.EncryptedFields {
+		if pth == idFieldName || pth == modFieldName || pth == config.TTLField {
+			return fieldEncryption{}, ErrInvalidEncryptedField
+		}
+		if _, err := getSchemaTypeAtPath(config.Schema, pth); err != nil {
+			return fieldEncryption{}, ErrInvalidEncryptedField
+		}
+		for _, index := range config.Indexes {
+			for _, f := range index.fields() {
+				if f == pth {
+					return fieldEncryption{}, ErrInvalidEncryptedField
+				}
+			}
+		}
+	}
+	key := config.EncryptionKey
+	if key == nil {
+		var err error
+		if key, err = symmetric.NewRandom(); err != nil {
+			return fieldEncryption{}, err
+		}
+	}
+	return fieldEncryption{fields: config.EncryptedFields, key: key}, nil
+}
+
+// EncryptionKey returns the key of the encrypted fields of the collection,
+// or nil if the collection has none. See CollectionConfig.EncryptedFields.
+func (c *Collection) EncryptionKey() *symmetric.Key {
+	return c.encryption.key
+}
+
+// encryptFields encrypts the encrypted fields of an instance. Fields that are
+// unchanged from the previous stored instance keep their previous ciphertext,
+// so saving an instance doesn't rewrite them.
+func (c *Collection) encryptFields(v, previous []byte) ([]byte, error) {
+	for _, pth := range c.encryption.fields {
+		res := gjson.GetBytes(v, pth)
+		if !res.Exists() || isEncryptedValue(res) {
+			continue
+		}
+		if previous != nil {
+			if prev := gjson.GetBytes(previous, pth); isEncryptedValue(prev) {
+				if plaintext, err := c.decryptValue(prev.Str); err == nil && bytes.Equal(plaintext, []byte(res.Raw)) {
+					if v, err = sjson.SetBytes(v, pth, prev.Str); err != nil {
+						return nil, err
+					}
+					continue
+				}
+			}
+		}
+		ciphertext, err := c.encryption.key.Encrypt([]byte(res.Raw))
+		if err != nil {
+			return nil, err
+		}
+		encoded := encryptedPrefix + base64.StdEncoding.EncodeToString(ciphertext)
+		if v, err = sjson.SetBytes(v, pth, encoded); err != nil {
+			return nil, err
+		}
+	}
+	return v, nil
+}
+
+// decryptFields decrypts the encrypted fields of an instance. Values that
+// were stored before their field was encrypted are returned as is, and so
+// are values that can't be decrypted with the collection key, e.g., values
+// written by peers with another key.
+func (c *Collection) decryptFields(v []byte) ([]byte, error) {
+	for _, pth := range c.encryption.fields {
+		res := gjson.GetBytes(v, pth)
+		if !isEncryptedValue(res) {
+			continue
+		}
+		plaintext, err := c.decryptValue(res.Str)
+		if err != nil {
+			log.Debugf("field %s of collection %s can't be decrypted: %v", pth, c.name, err)
+			continue
+		}
+		if v, err = sjson.SetRawBytes(v, pth, plaintext); err != nil {
+			return nil, err
+		}
+	}
+	return v, nil
+}
+
+func (c *Collection) decryptValue(encoded string) ([]byte, error) {
+	ciphertext, err := base64.StdEncoding.DecodeString(strings.TrimPrefix(encoded, encryptedPrefix))
+	if err != nil {
+		return nil, err
+	}
+	return c.encryption.key.Decrypt(ciphertext)
+}
+
+// isEncryptedValue returns whether a field value is encrypted.
+func isEncryptedValue(res gjson.Result) bool {
+	return res.Type == gjson.String && strings.HasPrefix(res.Str, encryptedPrefix)
+}
+
+// readInstance filters an instance against the identity and the read filter,
+// and decrypts its encrypted fields.
+func (c *Collection) readInstance(identity thread.PubKey, instance []byte) ([]byte, error) {
+	instance, err := c.filterRead(identity, instance)
+	if err != nil || instance == nil {
+		return instance, err
+	}
+	if instance, err = c.decryptFields(instance); err != nil {
+		return nil, err
+	}
+	return c.computeFields(instance)
+}
+
+func (d *DB) saveFieldEncryption(c *Collection) error {
+	if c.encryption.key == nil {
+		return d.datastore.Delete(dsEncryption.ChildString(c.name))
+	}
+	state := encryptionState{Fields: c.encryption.fields, Key: c.encryption.key.Bytes()}
+	if d.keyEncryptionKey != nil {
+		wrapped, err := d.keyEncryptionKey.Encrypt(state.Key)
+		if err != nil {
+			return err
+		}
+		state.Key, state.Wrapped = wrapped, true
+	}
+	v, err := json.Marshal(state)
+	if err != nil {
+		return err
+	}
+	return d.datastore.Put(dsEncryption.ChildString(c.name), v)
+}
+
+// loadFieldEncryption returns the persisted encrypted fields of a collection and their key.
+// Keys stored before the db had a key encryption key are wrapped as they're saved again.
+func (d *DB) loadFieldEncryption(name string) ([]string, *symmetric.Key, error) {
+	v, err := d.datastore.Get(dsEncryption.ChildString(name))
+	if errors.Is(err, ds.ErrNotFound) {
+		return nil, nil, nil
+	} else if err != nil {
+		return nil, nil, err
+	}
+	var state encryptionState
+	if err := json.Unmarshal(v, &state); err != nil {
+		return nil, nil, err
+	}
+	if state.Wrapped {
+		if d.keyEncryptionKey == nil {
+			return nil, nil, fmt.Errorf("%w: collection %s", ErrInvalidKeyEncryptionKey, name)
+		}
+		unwrapped, err := d.keyEncryptionKey.Decrypt(state.Key)
+		if err != nil {
+			return nil, nil, fmt.Errorf("%w: collection %s", ErrInvalidKeyEncryptionKey, name)
+		}
+		state.Key = unwrapped
+	}
+	key, err := symmetric.FromBytes(state.Key)
+	if err != nil {
+		return nil, nil, err
+	}
+	return state.Fields, key, nil
+}
@@ -0,0 +1,119 @@
+package db
+
+import (
+	"context"
+	"time"
+
+	"github.com/textileio/go-threads/core/thread"
+)
+
+const (
+	// managerEventBusCapacity is the buffer size of manager event subscriptions.
+	managerEventBusCapacity = 64
+	// managerEventTimeout is how long an event waits for a subscriber that
+	// fell behind before it's dropped.
+	managerEventTimeout = 100 * time.Millisecond
+)
+
+// ManagerEventType is the type of a manager event.
+type ManagerEventType int
+
+const (
+	// EventDBCreated is emitted as a db is created, or joined from an address.
+	EventDBCreated ManagerEventType = iota + 1
+	// EventDBOpened is emitted as an existing db is hydrated, see WithNewLazyDBs.
+	// Dbs opened as the manager starts are opened before it's returned, so
+	// they aren't emitted.
+	EventDBOpened
+	// EventDBDeleted is emitted as a db is deleted.
+	EventDBDeleted
+	// EventCollectionAdded is emitted as a collection is added to a db.
+	EventCollectionAdded
+	// EventQuotaExceeded is emitted as a write fails because it would exceed
+	// the storage quota of a db, see WithNewQuota.
+	EventQuotaExceeded
+)
+
+func (t ManagerEventType) String() string {
+	switch t {
+	case EventDBCreated:
+		return "db created"
+	case EventDBOpened:
+		return "db opened"
+	case EventDBDeleted:
+		return "db deleted"
+	case EventCollectionAdded:
+		return "collection added"
+	case EventQuotaExceeded:
+		return "quota exceeded"
+	default:
+		return "unknown"
+	}
+}
+
+// ManagerEvent is a lifecycle event of a managed db.
+type ManagerEvent struct {
+	// Type is the event type.
+	Type ManagerEventType
+	// DB is the ID of the db thread.
+	DB thread.ID
+	// Collection is the name of the added collection, with EventCollectionAdded.
+	Collection string
+	// Quota is the quota error of the failed write, with EventQuotaExceeded.
+	Quota *QuotaError
+	// Time is the time of the event.
+	Time time.Time
+}
+
+// Subscribe returns a channel of the lifecycle events of the managed dbs,
+// which is closed when ctx is cancelled or the manager is closed. Events
+// aren't scoped to the identities that can access the dbs, see
+// WithNewScopedDBs, so they shouldn't be forwarded to untrusted clients.
+// Events are dropped if the subscriber falls behind, so that it doesn't
+// block the dbs.
+func (m *Manager) Subscribe(ctx context.Context) <-chan ManagerEvent {
+	listener := m.bus.Listen()
+	channel := make(chan ManagerEvent)
+	go func() {
+		defer close(channel)
+		defer listener.Discard()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case v, ok := <-listener.Channel():
+				if !ok {
+					return
+				}
+				select {
+				case channel <- v.(ManagerEvent):
+				case <-ctx.Done():
+					return
+				}
+			}
+		}
+	}()
+	return channel
+}
+
+// emit sends an event to the subscribers of the manager.
+func (m *Manager) emit(e ManagerEvent) {
+	e.Time = time.Now()
+	if err := m.bus.SendWithTimeout(e, managerEventTimeout); err != nil {
+		log.Warnf("dropped manager event %s of %s: %v", e.Type, e.DB, err)
+	}
+}
+
+// watch makes a managed db emit its events to the manager. It must be called
+// before the db is published to the manager.
+func (m *Manager) watch(d *DB) {
+	d.emit = m.emit
+}
+
+// emitEvent sends an event to the manager of the db, if it has one.
+func (d *DB) emitEvent(e ManagerEvent) {
+	if d.emit != nil {
+		e.DB = d.connector.ThreadID()
+		d.emit(e)
+	}
+}
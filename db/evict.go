@@ -0,0 +1,138 @@
+package db
+
+import (
+	"errors"
+
+	"github.com/textileio/go-threads/core/thread"
+)
+
+// ErrDBEvicted indicates a db was closed to bound the open dbs of its
+// manager, see WithNewMaxOpenDBs. It's reopened by getting it again from the
+// manager.
+var ErrDBEvicted = errors.New("db was evicted from the open dbs")
+
+// touchDB marks a db as the most recently used one.
+// The caller must hold m.lock.
+func (m *Manager) touchDB(id thread.ID) {
+	if m.opts.MaxOpenDBs <= 0 {
+		return
+	}
+	m.useSeq++
+	m.used[id] = m.useSeq
+}
+
+// acquireDB counts a handle of an open db returned by the manager, which
+// keeps the db from being evicted until it's released with ReleaseDB.
+// The caller must hold m.lock.
+func (m *Manager) acquireDB(id thread.ID) {
+	m.refs[id]++
+}
+
+// ReleaseDB releases a db returned by the manager, e.g., by GetDB or NewDB,
+// once the caller is done with it. Dbs with unreleased handles aren't
+// evicted, see WithNewMaxOpenDBs. The db must not be used after it's
+// released, it's gotten again from the manager instead.
+func (m *Manager) ReleaseDB(id thread.ID) {
+	m.lock.Lock()
+	if m.refs[id] > 0 {
+		m.refs[id]--
+	}
+	if m.refs[id] == 0 {
+		delete(m.refs, id)
+	}
+	evicted := m.evictIdleDBs(thread.Undef)
+	m.lock.Unlock()
+	m.closeEvicted(evicted)
+}
+
+// evictIdleDBs evicts the least recently used idle dbs, i.e., dbs without
+// handles or listeners, until at most MaxOpenDBs are open, except for keep.
+// Dbs stay open over the limit if they're all in use, or fail to be evicted.
+// The evicted dbs are returned to be closed with closeEvicted once m.lock is
+// released.
+// The caller must hold m.lock.
+func (m *Manager) evictIdleDBs(keep thread.ID) map[thread.ID]*DB {
+	evicted := make(map[thread.ID]*DB)
+	failed := make(map[thread.ID]struct{})
+	for m.opts.MaxOpenDBs > 0 && len(m.dbs) > m.opts.MaxOpenDBs {
+		var (
+			lru   thread.ID
+			least uint64
+			found bool
+		)
+		for id, d := range m.dbs {
+			if _, ok := failed[id]; ok || id == keep || m.refs[id] > 0 || d.stateChangedNotifee.listening() {
+				continue
+			}
+			if seq := m.used[id]; !found || seq < least {
+				lru, least, found = id, seq, true
+			}
+		}
+		if !found {
+			log.Debugf("%d dbs are open, but none is idle", len(m.dbs))
+			break
+		}
+		d, err := m.evictDB(lru)
+		if err != nil {
+			log.Errorf("error evicting db %s: %v", lru, err)
+			// Dbs that fail to be evicted may stay open
+			failed[lru] = struct{}{}
+			continue
+		}
+		evicted[lru] = d
+	}
+	return evicted
+}
+
+// evictDB removes an open db from the manager, which hydrates it again as
+// it's used next, as with WithNewLazyDBs. The db isn't hydrated again until
+// it's closed by closeEvicted.
+// The caller must hold m.lock.
+func (m *Manager) evictDB(id thread.ID) (*DB, error) {
+	d := m.dbs[id]
+	// Records received after the db is evicted hydrate it
+	if _, err := m.network.ConnectApp(&lazyDB{m: m, id: id}, id); err != nil {
+		return nil, err
+	}
+	delete(m.dbs, id)
+	delete(m.used, id)
+	m.unloaded[id] = struct{}{}
+	m.closing[id] = make(chan struct{})
+	return d, nil
+}
+
+// closeEvicted closes the dbs evicted by evictIdleDBs.
+// The caller must not hold m.lock.
+func (m *Manager) closeEvicted(evicted map[thread.ID]*DB) {
+	for id, d := range evicted {
+		if err := d.close(ErrDBEvicted); err != nil {
+			log.Errorf("error closing evicted db %s: %v", id, err)
+		}
+		m.lock.Lock()
+		close(m.closing[id])
+		delete(m.closing, id)
+		m.lock.Unlock()
+	}
+}
+
+// lockForHydration locks m.lock once the evicted db id, or all evicted dbs
+// if id is undefined, are closed, so they can be hydrated again.
+func (m *Manager) lockForHydration(id thread.ID) {
+	for {
+		m.lock.Lock()
+		var closing chan struct{}
+		if id.Defined() {
+			closing = m.closing[id]
+		} else {
+			for _, c := range m.closing {
+				closing = c
+				break
+			}
+		}
+		if closing == nil {
+			return
+		}
+		m.lock.Unlock()
+		<-closing
+	}
+}
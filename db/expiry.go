@@ -0,0 +1,211 @@
+package db
+
+import (
+	"errors"
+	"strconv"
+	"time"
+
+	ds "github.com/textileio/go-datastore"
+	"github.com/textileio/go-datastore/query"
+	core "github.com/textileio/go-threads/core/db"
+	"github.com/tidwall/gjson"
+)
+
+const (
+	// defaultExpirySweepInterval is the default interval between expired instance sweeps.
+	defaultExpirySweepInterval = time.Minute
+	// expirySweepBatchSize is the maximum number of instances deleted per transaction.
+	expirySweepBatchSize = 100
+)
+
+var (
+	// ErrInvalidTTLField indicates the TTL field of a collection isn't a number in the schema.
+	ErrInvalidTTLField = errors.New("ttl field must be a number in the collection schema")
+
+	dsExpiry    = dsPrefix.ChildString("expiry")
+	dsTTLFields = dsPrefix.ChildString("ttl")
+)
+
+// expiryKey returns the expiration index key of an instance.
+func expiryKey(collection string, expiry int64, id core.InstanceID) ds.Key {
+	return dsExpiry.ChildString(collection).ChildString(formatModTime(expiry)).ChildString(id.String())
+}
+
+// getExpiry returns the expiration time at field of an instance, if any.
+func getExpiry(field string, data []byte) (int64, bool) {
+	r := gjson.GetBytes(data, field)
+	if r.Type != gjson.Number || r.Int() < 0 {
+		return 0, false
+	}
+	return r.Int(), true
+}
+
+// updateExpiryIndex keeps the expiration index of an instance up-to-date.
+// Instances without an expiration time aren't indexed.
+func (c *Collection) updateExpiryIndex(key ds.Key, oldData, newData []byte, txn ds.Txn) error {
+	if c.ttlField == "" {
+		return nil
+	}
+	id := core.InstanceID(key.Name())
+	if oldData != nil {
+		if expiry, ok := getExpiry(c.ttlField, oldData); ok {
+			if err := txn.Delete(expiryKey(c.name, expiry, id)); err != nil {
+				return err
+			}
+		}
+	}
+	if newData != nil {
+		if expiry, ok := getExpiry(c.ttlField, newData); ok {
+			if err := txn.Put(expiryKey(c.name, expiry, id), nil); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// ensureExpiryIndex re-indexes the expiration times of existing instances
+// if the TTL field of the collection changed. The caller must hold d.lock.
+func (c *Collection) ensureExpiryIndex() error {
+	field, err := c.db.datastore.Get(dsTTLFields.ChildString(c.name))
+	if err != nil && !errors.Is(err, ds.ErrNotFound) {
+		return err
+	}
+	if string(field) == c.ttlField {
+		return nil
+	}
+	txn, err := c.db.datastore.NewTransaction(false)
+	if err != nil {
+		return err
+	}
+	defer txn.Discard()
+	if err := c.deleteExpiryIndex(txn); err != nil {
+		return err
+	}
+	if c.ttlField != "" {
+		results, err := c.db.datastore.Query(query.Query{Prefix: c.baseKey().String()})
+		if err != nil {
+			return err
+		}
+		defer results.Close()
+		for res := range results.Next() {
+			if res.Error != nil {
+				return res.Error
+			}
+			if err := c.updateExpiryIndex(ds.RawKey(res.Key), nil, res.Value, txn); err != nil {
+				return err
+			}
+		}
+		if err := txn.Put(dsTTLFields.ChildString(c.name), []byte(c.ttlField)); err != nil {
+			return err
+		}
+	}
+	return txn.Commit()
+}
+
+// deleteExpiryIndex removes the expiration index of a collection.
+func (c *Collection) deleteExpiryIndex(txn ds.Txn) error {
+	if err := deletePrefix(c.db.datastore, txn, dsExpiry.ChildString(c.name)); err != nil {
+		return err
+	}
+	return txn.Delete(dsTTLFields.ChildString(c.name))
+}
+
+// expired returns the IDs of at most limit instances that expired by now.
+func (c *Collection) expired(now time.Time, limit int) ([]core.InstanceID, error) {
+	prefix := dsExpiry.ChildString(c.name)
+	results, err := c.db.datastore.Query(query.Query{
+		Prefix:   prefix.String(),
+		Orders:   []query.Order{query.OrderByKey{}},
+		KeysOnly: true,
+	})
+	if err != nil {
+		return nil, err
+	}
+	defer results.Close()
+	var ids []core.InstanceID
+	for res := range results.Next() {
+		if res.Error != nil {
+			return nil, res.Error
+		}
+		key := ds.RawKey(res.Key)
+		expiry, err := strconv.ParseInt(key.Parent().Name(), 10, 64)
+		if err != nil {
+			return nil, err
+		}
+		if expiry > now.Unix() || len(ids) >= limit {
+			break
+		}
+		ids = append(ids, core.InstanceID(key.Name()))
+	}
+	return ids, nil
+}
+
+// sweepExpired deletes the expired instances of the collection with delete
+// events, so the deletions replicate to peers.
+func (c *Collection) sweepExpired(now time.Time) error {
+	for {
+		ids, err := c.expired(now, expirySweepBatchSize)
+		if err != nil || len(ids) == 0 {
+			return err
+		}
+		deleted, err := c.DeleteMany(ids)
+		if err != nil || len(deleted) == 0 {
+			return err
+		}
+	}
+}
+
+// expiringCollections returns the collections with a TTL field, hydrating them if needed.
+func (d *DB) expiringCollections() []*Collection {
+	d.lock.Lock()
+	defer d.lock.Unlock()
+	var cs []*Collection
+	for name := range d.unloaded {
+		if ok, err := d.datastore.Has(dsTTLFields.ChildString(name)); err != nil || !ok {
+			continue
+		}
+		if _, err := d.getCollection(name); err != nil {
+			log.Errorf("error loading collection %s: %v", name, err)
+		}
+	}
+	for _, c := range d.collections {
+		if c.ttlField != "" {
+			cs = append(cs, c)
+		}
+	}
+	return cs
+}
+
+// sweepExpiredLoop periodically deletes expired instances until the db is closed.
+func (d *DB) sweepExpiredLoop(interval time.Duration) {
+	defer close(d.sweepDone)
+	if d.readOnly {
+		return
+	}
+	if interval <= 0 {
+		interval = defaultExpirySweepInterval
+	}
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-d.sweepStop:
+			return
+		case now := <-ticker.C:
+			for _, c := range d.expiringCollections() {
+				if err := c.sweepExpired(now); err != nil {
+					log.Errorf("error deleting expired instances of %s: %v", c.name, err)
+				}
+			}
+		}
+	}
+}
+
+// stopSweeper stops the expired instance sweeper and waits for it to return.
+func (d *DB) stopSweeper() {
+	d.sweepOnce.Do(func() {
+		close(d.sweepStop)
+		<-d.sweepDone
+	})
+}
@@ -0,0 +1,146 @@
+package db
+
+import "fmt"
+
+// ScanStrategy is how a query finds its candidate instances.
+type ScanStrategy int
+
+const (
+	// ScanCollection reads every instance of the collection.
+	ScanCollection ScanStrategy = iota
+	// ScanIndex reads the instances of the matching values of the index hinted
+	// by the query, see Query.UseIndex.
+	ScanIndex
+	// ScanTextIndex reads the instances containing the terms of the query text
+	// searches, using text indexes.
+	ScanTextIndex
+	// ScanGeoIndex reads the instances in the areas of the query geo criteria,
+	// using geo indexes.
+	ScanGeoIndex
+)
+
+// SortStrategy is how a query orders its results.
+type SortStrategy int
+
+const (
+	// SortIDOrder streams results in ID order, as read from the datastore.
+	SortIDOrder SortStrategy = iota
+	// SortIndexOrder streams results in the order of the hinted index values.
+	SortIndexOrder
+	// SortInMemory collects all matching instances and sorts them in memory.
+	SortInMemory
+)
+
+// QueryPlan describes how a query runs, see Txn.Explain.
+type QueryPlan struct {
+	// Scan is how the query finds its candidate instances.
+	Scan ScanStrategy
+	// Index is the path of the index used to find candidate instances,
+	// or empty if the query scans the collection.
+	Index string
+	// Candidates is the number of instances the query reads and matches against
+	// its criteria, as of the explained transaction. With ScanIndex, it's the
+	// number of instances of the matching index values.
+	Candidates int
+	// Sort is how the query orders its results.
+	Sort SortStrategy
+	// Limited indicates that the scan stops as soon as the query limit is reached.
+	Limited bool
+}
+
+// Explain returns the plan of q without running it, i.e., which index, if
+// any, it uses to find candidate instances, how many candidates it reads,
+// and how it orders its results. Index values are scanned to count the
+// candidates, but instances aren't read.
+func (t *Txn) Explain(q *Query) (*QueryPlan, error) {
+	if err := t.collection.db.connector.Validate(t.token, true); err != nil {
+		return nil, err
+	}
+	if q == nil {
+		q = &Query{}
+	}
+	if err := q.Validate(); err != nil {
+		return nil, fmt.Errorf("invalid query: %s", err)
+	}
+	if q.Sort.FieldPath == "" && t.collection.defaultOrder.FieldPath != "" {
+		dq := *q
+		dq.Sort = t.collection.defaultOrder
+		q = &dq
+	}
+	if q.Index != "" {
+		if err := t.collection.checkIndexHint(q); err != nil {
+			return nil, err
+		}
+	}
+	// Mirrors the strategy of Txn.iterate
+	inMemorySort := (q.Sort.FieldPath != "" && q.Sort.FieldPath != idFieldName) || q.WithDeleted
+	plan := &QueryPlan{Limited: !inMemorySort && q.Limit > 0}
+	txn, err := t.readTxn()
+	if err != nil {
+		return nil, fmt.Errorf("error building internal query: %v", err)
+	}
+	defer txn.Discard()
+	keys, ok, err := t.collection.textCandidates(txn, q)
+	if err != nil {
+		return nil, err
+	}
+	if ok {
+		plan.Scan, plan.Index = ScanTextIndex, t.collection.indexUsedBy(q, TextSearch)
+	} else {
+		if keys, ok, err = t.collection.geoCandidates(txn, q); err != nil {
+			return nil, err
+		}
+		if ok {
+			plan.Scan, plan.Index = ScanGeoIndex, t.collection.indexUsedBy(q, Near, WithinBox)
+		}
+	}
+	switch {
+	case ok:
+		plan.Candidates = len(keys)
+	case q.Index != "":
+		plan.Scan, plan.Index = ScanIndex, q.Index
+		iter := newIterator(txn, t.collection.baseKey(), &Query{Ands: q.Ands, Ors: q.Ors, Index: q.Index}, t.collection.indexes[q.Index])
+		defer iter.Close()
+		for {
+			keys, err := iter.nextKeys()
+			if err != nil {
+				return nil, err
+			}
+			if len(keys) == 0 {
+				break
+			}
+			plan.Candidates += len(keys)
+		}
+	default:
+		keys, err := listKeys(txn, t.collection.baseKey())
+		if err != nil {
+			return nil, err
+		}
+		plan.Candidates = len(keys)
+	}
+	switch {
+	case inMemorySort:
+		plan.Sort = SortInMemory
+	case plan.Scan == ScanIndex:
+		plan.Sort = SortIndexOrder
+	default:
+		plan.Sort = SortIDOrder
+	}
+	return plan, nil
+}
+
+// indexUsedBy returns the path of the first index used by the criteria of q
+// with one of the given operations.
+func (c *Collection) indexUsedBy(q *Query, ops ...Operation) string {
+	for _, a := range q.Ands {
+		for _, op := range ops {
+			if a.Operation != op {
+				continue
+			}
+			if index := c.indexes[a.FieldPath]; index.Text || index.Geo {
+				return a.FieldPath
+			}
+		}
+	}
+	return ""
+}
@@ -0,0 +1,35 @@
+package db
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"io"
+)
+
+// Export writes all instances of the collection to w as JSON Lines, i.e.,
+// one compact JSON instance per line, in ID order. Instances are streamed from
+// a snapshot of the collection, so the export is consistent without blocking
+// concurrent writes from peers.
+func (c *Collection) Export(w io.Writer, opts ...TxnOption) error {
+	opts = append([]TxnOption{WithTxnSnapshot(true)}, opts...)
+	bw := bufio.NewWriter(w)
+	var line bytes.Buffer
+	err := c.ReadTxn(func(txn *Txn) error {
+		// Exports aren't bound by the query limits of the db
+		_, err := txn.iterate(&Query{}, queryLimits{}, func(_ MarshaledResult, instance []byte) error {
+			line.Reset()
+			if err := json.Compact(&line, instance); err != nil {
+				return err
+			}
+			line.WriteByte('\n')
+			_, err := bw.Write(line.Bytes())
+			return err
+		})
+		return err
+	}, opts...)
+	if err != nil {
+		return err
+	}
+	return bw.Flush()
+}
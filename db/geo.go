@@ -0,0 +1,307 @@
+package db
+
+import (
+	"errors"
+	"fmt"
+	"math"
+
+	ds "github.com/textileio/go-datastore"
+	"github.com/textileio/go-datastore/query"
+	"github.com/tidwall/gjson"
+)
+
+const (
+	// earthRadius is the mean radius of the earth in meters.
+	earthRadius = 6371008.8
+	// geoIndexPrecision is the geohash length of indexed points, about 19m x 19m.
+	geoIndexPrecision = 8
+	// geoMaxCells is the maximum number of geohash cells scanned to cover a query area.
+	geoMaxCells = 32
+	// geohashAlphabet is the geohash base32 alphabet.
+	geohashAlphabet = "0123456789bcdefghjkmnpqrstuvwxyz"
+)
+
+// ErrInvalidGeoIndex indicates a geo index isn't on a single array field, or is unique or case-insensitive.
+var ErrInvalidGeoIndex = errors.New("geo indexes must be on a single [lng, lat] array field and can't be unique or case-insensitive")
+
+// GeoPoint is a location in degrees. Instances store points as [lng, lat] arrays.
+type GeoPoint struct {
+	Lng float64
+	Lat float64
+}
+
+// Near is a geospatial operator against a [lng, lat] field. It matches
+// points within radius meters of point. Queries are enhanced by a geo
+// index on the field, see Index.Geo.
+func (c *Criterion) Near(point GeoPoint, radius float64) *Query {
+	return c.createsetcriterion(Near, []interface{}{point.Lng, point.Lat, radius})
+}
+
+// WithinBox is a geospatial operator against a [lng, lat] field. It matches
+// points in the box with the given south-west and north-east corners. Boxes
+// crossing the antimeridian have a min longitude greater than their max.
+func (c *Criterion) WithinBox(min, max GeoPoint) *Query {
+	return c.createsetcriterion(WithinBox, []interface{}{min.Lng, min.Lat, max.Lng, max.Lat})
+}
+
+// geoBox is an area bounded by longitudes and latitudes.
+type geoBox struct {
+	min, max GeoPoint
+}
+
+// geoArgs returns the float arguments of a geospatial criterion.
+func (c *Criterion) geoArgs() ([]float64, error) {
+	n := 3
+	if c.Operation == WithinBox {
+		n = 4
+	}
+	if len(c.Values) != n {
+		return nil, fmt.Errorf("geospatial criterion should have %d values", n)
+	}
+	args := make([]float64, n)
+	for i, v := range c.Values {
+		if v.Float == nil {
+			return nil, fmt.Errorf("geospatial criterion values should be numbers")
+		}
+		args[i] = *v.Float
+	}
+	return args, nil
+}
+
+// geoMatch returns whether or not the point value matches the criterion.
+func (c *Criterion) geoMatch(value interface{}) (bool, error) {
+	p, ok := geoPointFromValue(value)
+	if !ok {
+		return false, &errTypeMismatch{value, c.Values}
+	}
+	args, err := c.geoArgs()
+	if err != nil {
+		return false, err
+	}
+	if c.Operation == Near {
+		return geoDistance(p, GeoPoint{Lng: args[0], Lat: args[1]}) <= args[2], nil
+	}
+	b := geoBox{min: GeoPoint{Lng: args[0], Lat: args[1]}, max: GeoPoint{Lng: args[2], Lat: args[3]}}
+	for _, b := range b.split() {
+		if p.Lng >= b.min.Lng && p.Lng <= b.max.Lng && p.Lat >= b.min.Lat && p.Lat <= b.max.Lat {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+// geoPointFromValue returns the point of a [lng, lat] value.
+func geoPointFromValue(value interface{}) (GeoPoint, bool) {
+	arr, ok := value.([]interface{})
+	if !ok || len(arr) != 2 {
+		return GeoPoint{}, false
+	}
+	lng, ok1 := arr[0].(float64)
+	lat, ok2 := arr[1].(float64)
+	if !ok1 || !ok2 || lng < -180 || lng > 180 || lat < -90 || lat > 90 {
+		return GeoPoint{}, false
+	}
+	return GeoPoint{Lng: lng, Lat: lat}, true
+}
+
+// geoDistance returns the great-circle distance between two points in meters.
+func geoDistance(a, b GeoPoint) float64 {
+	lat1, lat2 := a.Lat*math.Pi/180, b.Lat*math.Pi/180
+	dLat := lat2 - lat1
+	dLng := (b.Lng - a.Lng) * math.Pi / 180
+	h := math.Sin(dLat/2)*math.Sin(dLat/2) + math.Cos(lat1)*math.Cos(lat2)*math.Sin(dLng/2)*math.Sin(dLng/2)
+	return 2 * earthRadius * math.Asin(math.Min(1, math.Sqrt(h)))
+}
+
+// geoBoxAround returns a box containing the circle of radius meters around p.
+func geoBoxAround(p GeoPoint, radius float64) geoBox {
+	dLat := radius / earthRadius * 180 / math.Pi
+	b := geoBox{
+		min: GeoPoint{Lng: -180, Lat: math.Max(-90, p.Lat-dLat)},
+		max: GeoPoint{Lng: 180, Lat: math.Min(90, p.Lat+dLat)},
+	}
+	if cos := math.Cos(math.Max(math.Abs(b.min.Lat), math.Abs(b.max.Lat)) * math.Pi / 180); cos > 0 {
+		if dLng := dLat / cos; dLng < 180 {
+			b.min.Lng = wrapLng(p.Lng - dLng)
+			b.max.Lng = wrapLng(p.Lng + dLng)
+		}
+	}
+	return b
+}
+
+func wrapLng(lng float64) float64 {
+	if lng < -180 {
+		return lng + 360
+	}
+	if lng > 180 {
+		return lng - 360
+	}
+	return lng
+}
+
+// split splits a box crossing the antimeridian in two.
+func (b geoBox) split() []geoBox {
+	if b.min.Lng <= b.max.Lng {
+		return []geoBox{b}
+	}
+	return []geoBox{
+		{min: b.min, max: GeoPoint{Lng: 180, Lat: b.max.Lat}},
+		{min: GeoPoint{Lng: -180, Lat: b.min.Lat}, max: b.max},
+	}
+}
+
+// geohash returns the geohash of p with the given length.
+func geohash(p GeoPoint, precision int) string {
+	lngRange := [2]float64{-180, 180}
+	latRange := [2]float64{-90, 90}
+	hash := make([]byte, precision)
+	even := true
+	for i := range hash {
+		var ch int
+		for bit := 4; bit >= 0; bit-- {
+			r, v := &latRange, p.Lat
+			if even {
+				r, v = &lngRange, p.Lng
+			}
+			if mid := (r[0] + r[1]) / 2; v >= mid {
+				ch |= 1 << bit
+				r[0] = mid
+			} else {
+				r[1] = mid
+			}
+			even = !even
+		}
+		hash[i] = geohashAlphabet[ch]
+	}
+	return string(hash)
+}
+
+// geohashCellSize returns the width and height in degrees of geohash cells with the given length.
+func geohashCellSize(precision int) (float64, float64) {
+	lngBits := (5*precision + 1) / 2
+	latBits := 5 * precision / 2
+	return 360 / math.Pow(2, float64(lngBits)), 180 / math.Pow(2, float64(latBits))
+}
+
+// geohashCells returns the geohashes of the cells covering b, with the longest
+// length shorter than indexed geohashes that needs at most geoMaxCells cells.
+func geohashCells(b geoBox) []string {
+	for precision := geoIndexPrecision - 1; precision > 0; precision-- {
+		w, h := geohashCellSize(precision)
+		var count float64
+		for _, sb := range b.split() {
+			cols := math.Floor((sb.max.Lng+180)/w) - math.Floor((sb.min.Lng+180)/w) + 1
+			rows := math.Floor((sb.max.Lat+90)/h) - math.Floor((sb.min.Lat+90)/h) + 1
+			count += cols * rows
+		}
+		if count > geoMaxCells && precision > 1 {
+			continue
+		}
+		seen := make(map[string]struct{})
+		var cells []string
+		for _, sb := range b.split() {
+			for lat := math.Floor((sb.min.Lat+90)/h)*h - 90; lat <= sb.max.Lat; lat += h {
+				for lng := math.Floor((sb.min.Lng+180)/w)*w - 180; lng <= sb.max.Lng; lng += w {
+					center := GeoPoint{Lng: math.Min(lng+w/2, 180), Lat: math.Min(lat+h/2, 90)}
+					hash := geohash(center, precision)
+					if _, ok := seen[hash]; !ok {
+						seen[hash] = struct{}{}
+						cells = append(cells, hash)
+					}
+				}
+			}
+		}
+		return cells
+	}
+	return nil
+}
+
+// geohashKey returns a key with a namespace per geohash character,
+// so the cells containing a point can be found with prefix queries.
+func geohashKey(hash string) ds.Key {
+	k := ds.RawKey("/")
+	for _, ch := range hash {
+		k = k.ChildString(string(ch))
+	}
+	return k
+}
+
+// geoIndexValue returns the index value of the point at field.
+func geoIndexValue(field string, input []byte) (ds.Key, error) {
+	res := gjson.GetBytes(input, field)
+	p, ok := geoPointFromValue(res.Value())
+	if !ok {
+		return ds.Key{}, ErrNotIndexable
+	}
+	return geohashKey(geohash(p, geoIndexPrecision)), nil
+}
+
+// geoCandidates returns the keys of the instances in the geohash cells covering
+// the areas of geospatial criteria in q, using geo indexes. Results are ordered by key.
+// If q can't be served by geo indexes, ok is false.
+func (c *Collection) geoCandidates(txn ds.Txn, q *Query) (keys []ds.Key, ok bool, err error) {
+	if q.Index != "" || q.Seek != "" || len(q.Ors) > 0 {
+		return nil, false, nil
+	}
+	var candidates keyList
+	for _, a := range q.Ands {
+		if (a.Operation != Near && a.Operation != WithinBox) || !c.indexes[a.FieldPath].Geo {
+			continue
+		}
+		args, err := a.geoArgs()
+		if err != nil {
+			return nil, false, err
+		}
+		var box geoBox
+		if a.Operation == Near {
+			box = geoBoxAround(GeoPoint{Lng: args[0], Lat: args[1]}, args[2])
+		} else {
+			box = geoBox{min: GeoPoint{Lng: args[0], Lat: args[1]}, max: GeoPoint{Lng: args[2], Lat: args[3]}}
+		}
+		var inArea keyList
+		for _, cell := range geohashCells(box) {
+			if err := c.geoCellKeys(txn, a.FieldPath, cell, &inArea); err != nil {
+				return nil, false, err
+			}
+		}
+		if !ok {
+			candidates, ok = inArea, true
+		} else {
+			candidates = intersectKeys(candidates, inArea)
+		}
+		if len(candidates) == 0 {
+			return nil, true, nil
+		}
+	}
+	if !ok {
+		return nil, false, nil
+	}
+	keys = make([]ds.Key, len(candidates))
+	for i, k := range candidates {
+		keys[i] = ds.RawKey(string(k))
+	}
+	return keys, true, nil
+}
+
+// geoCellKeys adds the keys of the instances indexed in a geohash cell to keys.
+func (c *Collection) geoCellKeys(txn ds.Txn, field, cell string, keys *keyList) error {
+	prefix := c.indexKey(field, geohashKey(cell))
+	results, err := txn.Query(query.Query{Prefix: prefix.String()})
+	if err != nil {
+		return err
+	}
+	defer results.Close()
+	for res := range results.Next() {
+		if res.Error != nil {
+			return res.Error
+		}
+		held := make(keyList, 0)
+		if err := DefaultDecode(res.Value, &held); err != nil {
+			return err
+		}
+		for _, k := range held {
+			keys.add(ds.RawKey(string(k)))
+		}
+	}
+	return nil
+}
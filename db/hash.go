@@ -0,0 +1,121 @@
+package db
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strconv"
+
+	core "github.com/textileio/go-threads/core/db"
+	"github.com/tidwall/sjson"
+)
+
+// ContentHash returns a deterministic hex-encoded SHA-256 hash of an instance's content.
+// The instance is canonicalized (object keys sorted, no insignificant whitespace,
+// numbers in shortest form) before hashing, so the result only changes when the
+// content does. The protected _mod field is always excluded, along with any
+// additional excludePaths given in dot syntax, e.g., "meta.updated".
+func (c *Collection) ContentHash(id core.InstanceID, excludePaths []string, opts ...TxnOption) (string, error) {
+	instance, err := c.FindByID(id, opts...)
+	if err != nil {
+		return "", err
+	}
+	for _, pth := range append([]string{modFieldName}, excludePaths...) {
+		instance, err = sjson.DeleteBytes(instance, pth)
+		if err != nil {
+			return "", fmt.Errorf("excluding path %s: %v", pth, err)
+		}
+	}
+	canonical, err := canonicalJSON(instance)
+	if err != nil {
+		return "", err
+	}
+	sum := sha256.Sum256(canonical)
+	return hex.EncodeToString(sum[:]), nil
+}
+
+// canonicalJSON re-encodes a JSON document in a canonical form that does not
+// depend on the encoding/json implementation details.
+func canonicalJSON(v []byte) ([]byte, error) {
+	dec := json.NewDecoder(bytes.NewReader(v))
+	dec.UseNumber()
+	var doc interface{}
+	if err := dec.Decode(&doc); err != nil {
+		return nil, fmt.Errorf("unmarshaling json instance: %v", err)
+	}
+	var buf bytes.Buffer
+	if err := writeCanonical(&buf, doc); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+func writeCanonical(buf *bytes.Buffer, v interface{}) error {
+	switch t := v.(type) {
+	case nil:
+		buf.WriteString("null")
+	case bool:
+		buf.WriteString(strconv.FormatBool(t))
+	case json.Number:
+		f, err := t.Float64()
+		if err != nil {
+			return err
+		}
+		buf.WriteString(strconv.FormatFloat(f, 'g', -1, 64))
+	case string:
+		writeCanonicalString(buf, t)
+	case []interface{}:
+		buf.WriteByte('[')
+		for i, e := range t {
+			if i > 0 {
+				buf.WriteByte(',')
+			}
+			if err := writeCanonical(buf, e); err != nil {
+				return err
+			}
+		}
+		buf.WriteByte(']')
+	case map[string]interface{}:
+		keys := make([]string, 0, len(t))
+		for k := range t {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+		buf.WriteByte('{')
+		for i, k := range keys {
+			if i > 0 {
+				buf.WriteByte(',')
+			}
+			writeCanonicalString(buf, k)
+			buf.WriteByte(':')
+			if err := writeCanonical(buf, t[k]); err != nil {
+				return err
+			}
+		}
+		buf.WriteByte('}')
+	default:
+		return fmt.Errorf("unsupported json type %T", v)
+	}
+	return nil
+}
+
+// writeCanonicalString writes s as a JSON string, escaping only what is required.
+func writeCanonicalString(buf *bytes.Buffer, s string) {
+	buf.WriteByte('"')
+	for _, r := range s {
+		switch {
+		case r == '"':
+			buf.WriteString(`\"`)
+		case r == '\\':
+			buf.WriteString(`\\`)
+		case r < 0x20:
+			buf.WriteString(fmt.Sprintf(`\u%04x`, r))
+		default:
+			buf.WriteRune(r)
+		}
+	}
+	buf.WriteByte('"')
+}
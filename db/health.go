@@ -0,0 +1,89 @@
+package db
+
+import (
+	"errors"
+	"time"
+
+	ds "github.com/textileio/go-datastore"
+)
+
+// ErrNetworkUnavailable indicates the host of the manager network isn't
+// listening on any address, e.g., because it's closed.
+var ErrNetworkUnavailable = errors.New("network host isn't listening")
+
+var dsManagerHealthKey = ds.NewKey("/health")
+
+// HealthReport is the health of a manager, see Manager.Health.
+type HealthReport struct {
+	// Time is the time of the report.
+	Time time.Time
+	// Datastore is the error of probing the datastore, and the datastores of
+	// the isolated dbs, see WithNewIsolatedDBs, or nil if they're healthy.
+	Datastore error
+	// Network is the error of the network host, or nil if it's listening.
+	Network error
+	// Peers is the number of peers the network host is connected to.
+	Peers int
+	// DBs is the number of open dbs.
+	DBs int
+	// UnloadedDBs is the number of dbs that aren't hydrated yet, see
+	// WithNewLazyDBs.
+	UnloadedDBs int
+	// PendingMigrations is the number of collections of the open dbs whose
+	// lazy migration isn't done, see WithLazyMigration.
+	PendingMigrations int
+	// ListenerBacklog is the number of actions waiting to be received by the
+	// listeners of the open dbs.
+	ListenerBacklog int
+	// Maintenance indicates a maintenance run is in progress, see
+	// Manager.RunMaintenance.
+	Maintenance bool
+}
+
+// Ready returns whether the manager can serve requests, i.e., its datastores
+// and network are healthy. Pending background work doesn't affect readiness.
+func (r HealthReport) Ready() bool {
+	return r.Datastore == nil && r.Network == nil
+}
+
+// Health reports the health of the manager, e.g., for the liveness and
+// readiness probes of orchestrators. Datastores are probed with a read, so
+// it's cheap enough to be called often. Dbs aren't hydrated by the report.
+func (m *Manager) Health() HealthReport {
+	report := HealthReport{Time: time.Now()}
+	m.lock.Lock()
+	dbs := make([]*DB, 0, len(m.dbs))
+	for _, d := range m.dbs {
+		dbs = append(dbs, d)
+	}
+	report.DBs = len(m.dbs)
+	report.UnloadedDBs = len(m.unloaded)
+	m.lock.Unlock()
+
+	if _, err := m.opts.Datastore.Has(dsManagerHealthKey); err != nil {
+		report.Datastore = err
+	}
+	for _, d := range dbs {
+		if report.Datastore == nil && !managedDatastore(d.datastore) {
+			if _, err := d.datastore.Has(dsManagerHealthKey); err != nil {
+				report.Datastore = err
+			}
+		}
+		d.lock.Lock()
+		for _, c := range d.collections {
+			if c.pendingMigration() {
+				report.PendingMigrations++
+			}
+		}
+		d.lock.Unlock()
+		report.ListenerBacklog += d.stateChangedNotifee.backlog()
+	}
+
+	if h := m.network.Host(); h == nil || len(h.Network().ListenAddresses()) == 0 {
+		report.Network = ErrNetworkUnavailable
+	} else {
+		report.Peers = len(h.Network().Peers())
+	}
+	report.Maintenance = len(m.maintenanceLock) > 0
+	return report
+}
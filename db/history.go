@@ -0,0 +1,228 @@
+package db
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"errors"
+	"sort"
+	"time"
+
+	"github.com/ipfs/go-cid"
+	"github.com/libp2p/go-libp2p-core/peer"
+	ds "github.com/textileio/go-datastore"
+	core "github.com/textileio/go-threads/core/db"
+	"github.com/textileio/go-threads/core/thread"
+	"github.com/textileio/go-threads/hlc"
+	"github.com/tidwall/gjson"
+	"github.com/tidwall/sjson"
+)
+
+// ErrVersionNotFound indicates a record didn't write a version of an instance.
+var ErrVersionNotFound = errors.New("instance version not found")
+
+// InstanceVersion is a version of an instance, as written by an event of the thread log.
+type InstanceVersion struct {
+	// Type is the type of the write.
+	Type core.ActionType
+	// Instance is the instance after the write, or nil if it was deleted.
+	Instance []byte
+	// Author is the identity of the writer, or nil if the record has none.
+	Author thread.PubKey
+	// Log is the ID of the log containing the write.
+	Log peer.ID
+	// Record is the ID of the record containing the write.
+	Record cid.Cid
+	// Time is the time of the write event.
+	Time time.Time
+}
+
+// historyEvent is an event of an instance along with its record.
+type historyEvent struct {
+	event  core.Event
+	record logRecord
+}
+
+// History returns the versions of an instance written by the events of the
+// thread log, in the causal order of the events. The versions are rebuilt by
+// replaying the events, so writes dropped or resolved by the conflict strategy
+// of the collection are reflected, and custom conflict resolvers are called.
+// Versions hidden by the read filter are omitted. The whole thread log is
+// read, so History is intended to audit instances rather than for frequent use.
+// Records encrypted with a retired read key can't be replayed.
+func (c *Collection) History(ctx context.Context, id core.InstanceID, opts ...TxnOption) ([]InstanceVersion, error) {
+	args := &TxnOptions{}
+	for _, opt := range opts {
+		opt(args)
+	}
+	if err := c.db.connector.Validate(args.Token, true); err != nil {
+		return nil, err
+	}
+	pk, err := args.Token.PubKey()
+	if err != nil {
+		return nil, err
+	}
+	stored, err := c.history(ctx, id, args.Token)
+	if err != nil {
+		return nil, err
+	}
+	versions := make([]InstanceVersion, 0, len(stored))
+	for _, v := range stored {
+		if v.Instance != nil {
+			if v.Instance, err = c.readInstance(pk, v.Instance); err != nil {
+				return nil, err
+			}
+			if v.Instance == nil {
+				continue
+			}
+		}
+		versions = append(versions, v)
+	}
+	return versions, nil
+}
+
+// history returns the versions of an instance with their stored values, i.e.,
+// before the read filter and with encrypted fields.
+func (c *Collection) history(ctx context.Context, id core.InstanceID, token thread.Token) ([]InstanceVersion, error) {
+	d := c.db
+	info, records, err := d.threadRecords(ctx, token)
+	if err != nil {
+		return nil, err
+	}
+	var events []historyEvent
+	for _, r := range records {
+		revents, err := d.eventsFromRecord(ctx, r.rec, r.logID, info.Key)
+		if err != nil {
+			return nil, err
+		}
+		for _, e := range revents {
+			if e.Collection() == c.name && e.InstanceID() == id {
+				events = append(events, historyEvent{event: e, record: r})
+			}
+		}
+	}
+	sort.SliceStable(events, func(i, j int) bool {
+		return bytes.Compare(events[i].event.Time(), events[j].event.Time()) < 0
+	})
+
+	// Events are replayed one at a time in a scratch datastore
+	store := NewTxMapDatastore()
+	noIndex := func(string, ds.Key, []byte, []byte, ds.Txn) error { return nil }
+	key := baseKey.ChildString(c.name).ChildString(id.String())
+	var versions []InstanceVersion
+	for _, he := range events {
+		actions, err := d.eventcodec.Reduce([]core.Event{he.event}, store, baseKey, noIndex)
+		if err != nil {
+			return nil, err
+		}
+		if len(actions) == 0 {
+			// The write was dropped by the conflict strategy
+			continue
+		}
+		v := InstanceVersion{
+			Type:   actions[len(actions)-1].Type,
+			Log:    he.record.logID,
+			Record: he.record.rec.Cid(),
+			Time:   eventTime(he.event),
+		}
+		if b := he.record.rec.PubKey(); len(b) > 0 {
+			author := &thread.Libp2pPubKey{}
+			if err := author.UnmarshalBinary(b); err == nil {
+				v.Author = author
+			}
+		}
+		if v.Type != core.Delete {
+			if v.Instance, err = store.Get(key); err != nil {
+				return nil, err
+			}
+		}
+		versions = append(versions, v)
+	}
+	return versions, nil
+}
+
+// FindByIDAt finds an instance by its ID as it was at the given time, i.e.,
+// its latest version written at or before t, see History. If the instance
+// didn't exist or was deleted at t, returns ErrInstanceNotFound.
+func (c *Collection) FindByIDAt(ctx context.Context, id core.InstanceID, t time.Time, opts ...TxnOption) ([]byte, error) {
+	versions, err := c.History(ctx, id, opts...)
+	if err != nil {
+		return nil, err
+	}
+	var instance []byte
+	for _, v := range versions {
+		if v.Time.After(t) {
+			break
+		}
+		instance = v.Instance
+	}
+	if instance == nil {
+		return nil, ErrInstanceNotFound
+	}
+	return instance, nil
+}
+
+// Revert restores an instance to its version written by the given record, see
+// History, with a new write. Fields the read filter hides are restored too.
+// Reverting to a deleted version deletes the instance, if it exists. If the
+// record wrote several versions of the instance, the last one is restored.
+// Returns ErrVersionNotFound if the record didn't write the instance.
+func (c *Collection) Revert(ctx context.Context, id core.InstanceID, record cid.Cid, opts ...TxnOption) error {
+	args := &TxnOptions{}
+	for _, opt := range opts {
+		opt(args)
+	}
+	if err := c.db.connector.Validate(args.Token, false); err != nil {
+		return err
+	}
+	versions, err := c.history(ctx, id, args.Token)
+	if err != nil {
+		return err
+	}
+	var version *InstanceVersion
+	for i := range versions {
+		if versions[i].Record.Equals(record) {
+			version = &versions[i]
+		}
+	}
+	if version == nil {
+		return ErrVersionNotFound
+	}
+	var instance []byte
+	if version.Instance != nil {
+		// Instances are validated and encrypted again as they're written
+		if instance, err = c.decryptFields(version.Instance); err != nil {
+			return err
+		}
+	}
+	return c.WriteTxn(func(txn *Txn) error {
+		current, err := c.db.datastore.Get(c.baseKey().ChildString(id.String()))
+		if errors.Is(err, ds.ErrNotFound) {
+			if instance == nil {
+				return nil
+			}
+			_, err = txn.Create(instance)
+			return err
+		} else if err != nil {
+			return err
+		}
+		if instance == nil {
+			return txn.Delete(id)
+		}
+		// Compare-and-swap saves expect the current modification time
+		if instance, err = sjson.SetBytes(instance, modFieldName, gjson.GetBytes(current, modFieldName).Int()); err != nil {
+			return err
+		}
+		return txn.Save(instance)
+	}, opts...)
+}
+
+// eventTime returns the time of an event, whose timestamp is encoded
+// in big endian nanoseconds.
+func eventTime(e core.Event) time.Time {
+	b := e.Time()
+	if len(b) != 8 {
+		return time.Time{}
+	}
+	return hlc.Timestamp(binary.BigEndian.Uint64(b)).Time()
+}
@@ -0,0 +1,124 @@
+package db
+
+import (
+	"errors"
+	"sync"
+
+	ds "github.com/textileio/go-datastore"
+	core "github.com/textileio/go-threads/core/db"
+)
+
+// WriteEvent describes a write to an instance, see AddBeforeWriteHook.
+type WriteEvent struct {
+	// Collection is the name of the written collection.
+	Collection string
+	// Type is the type of the write.
+	Type ActionType
+	// ID is the instance ID.
+	ID core.InstanceID
+	// Previous is the stored instance before the transaction, nil if it didn't exist.
+	Previous []byte
+	// Current is the instance after the write, nil if it's deleted.
+	Current []byte
+}
+
+// BeforeWriteHook is called with each write of a transaction before it commits.
+// Writes made to txn are part of the same transaction, and returning an error
+// aborts the transaction.
+type BeforeWriteHook func(txn *Txn, e WriteEvent) error
+
+// AfterWriteHook is called with each write of a transaction after it commits.
+type AfterWriteHook func(e WriteEvent)
+
+// writeHooks are the write hooks of a collection.
+type writeHooks struct {
+	sync.Mutex
+	before []BeforeWriteHook
+	after  []AfterWriteHook
+}
+
+// hookedWrite is a write event along with the collection it was made to.
+type hookedWrite struct {
+	c *Collection
+	e WriteEvent
+}
+
+// AddBeforeWriteHook registers a hook that runs inside local write transactions
+// of the collection before they commit, e.g., to validate or derive data.
+// Hooks aren't persisted and don't run for writes received from peers.
+// Hooks are kept when the collection is updated or renamed.
+func (c *Collection) AddBeforeWriteHook(hook BeforeWriteHook) {
+	c.hooks.Lock()
+	defer c.hooks.Unlock()
+	c.hooks.before = append(c.hooks.before, hook)
+}
+
+// AddAfterWriteHook registers a hook that runs after local write transactions
+// of the collection commit, e.g., to denormalize data into other collections.
+// Hooks run once the transaction released its locks, so they can write to the db.
+// Hooks aren't persisted and don't run for writes received from peers.
+func (c *Collection) AddAfterWriteHook(hook AfterWriteHook) {
+	c.hooks.Lock()
+	defer c.hooks.Unlock()
+	c.hooks.after = append(c.hooks.after, hook)
+}
+
+// collectionOf returns the collection written by an action of the transaction.
+func (t *Txn) collectionOf(a core.Action) (*Collection, error) {
+	if a.CollectionName == t.collection.name {
+		return t.collection, nil
+	}
+	d := t.collection.db
+	d.lock.Lock()
+	defer d.lock.Unlock()
+	return d.getCollection(a.CollectionName)
+}
+
+// runBeforeWriteHooks calls the before write hooks with each action of the
+// transaction, including the actions added by hooks.
+func (t *Txn) runBeforeWriteHooks() error {
+	for i := 0; i < len(t.actions); i++ {
+		a := t.actions[i]
+		c, err := t.collectionOf(a)
+		if err != nil {
+			return err
+		}
+		e := WriteEvent{Collection: c.name, ID: a.InstanceID, Current: a.Current}
+		switch a.Type {
+		case core.Create:
+			e.Type = ActionCreate
+		case core.Save:
+			e.Type = ActionSave
+		case core.Delete:
+			e.Type = ActionDelete
+		}
+		if e.Type != ActionCreate {
+			e.Previous, err = c.db.datastore.Get(c.baseKey().ChildString(a.InstanceID.String()))
+			if err != nil && !errors.Is(err, ds.ErrNotFound) {
+				return err
+			}
+		}
+		c.hooks.Lock()
+		before := c.hooks.before
+		c.hooks.Unlock()
+		for _, hook := range before {
+			if err := hook(t, e); err != nil {
+				return err
+			}
+		}
+		t.written = append(t.written, hookedWrite{c: c, e: e})
+	}
+	return nil
+}
+
+// runAfterWriteHooks calls the after write hooks with each write of a committed transaction.
+func (t *Txn) runAfterWriteHooks() {
+	for _, w := range t.written {
+		w.c.hooks.Lock()
+		after := w.c.hooks.after
+		w.c.hooks.Unlock()
+		for _, hook := range after {
+			hook(w.e)
+		}
+	}
+}
@@ -0,0 +1,161 @@
+package db
+
+import (
+	"crypto/rand"
+	"encoding/binary"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"strconv"
+	"sync"
+	"time"
+
+	ds "github.com/textileio/go-datastore"
+	core "github.com/textileio/go-threads/core/db"
+)
+
+// IDStrategy selects how the IDs of instances created without one are generated.
+type IDStrategy int
+
+const (
+	// IDULID generates lower case ULIDs, which sort by creation time.
+	IDULID IDStrategy = iota
+	// IDUUIDv7 generates version 7 UUIDs, which sort by creation time.
+	IDUUIDv7
+	// IDSequential generates increasing zero-padded decimal numbers, which sort
+	// by creation order. The sequence is local to the peer, so it's only suited
+	// to collections whose instances are created by a single peer.
+	IDSequential
+	// IDCustom generates IDs with an IDGenerator.
+	IDCustom
+)
+
+// IDGenerator generates the IDs of instances with the IDCustom strategy.
+// Generated IDs must be unique, non-empty, and valid datastore key names.
+type IDGenerator func() core.InstanceID
+
+var (
+	// ErrInvalidIDStrategy indicates a collection ID strategy is unknown or lacks a generator.
+	ErrInvalidIDStrategy = errors.New("invalid ID strategy")
+
+	dsIDStrategies = dsPrefix.ChildString("idstrategy")
+	dsIDSequences  = dsPrefix.ChildString("idsequence")
+)
+
+// idGenerator generates the IDs of a collection.
+type idGenerator struct {
+	strategy IDStrategy
+	custom   IDGenerator
+
+	// The last sequential ID, loaded from the datastore on first use
+	lock   sync.Mutex
+	seq    uint64
+	loaded bool
+}
+
+// validIDStrategy validates the ID strategy of a collection config.
+func validIDStrategy(config CollectionConfig) error {
+	switch config.IDStrategy {
+	case IDULID, IDUUIDv7, IDSequential:
+		return nil
+	case IDCustom:
+		if config.IDGenerator == nil {
+			return ErrInvalidIDStrategy
+		}
+		return nil
+	default:
+		return ErrInvalidIDStrategy
+	}
+}
+
+// newInstanceID generates the ID of a new instance of the collection.
+// Custom strategies without a generator, e.g, after a restart, fall back to ULIDs.
+func (c *Collection) newInstanceID() (core.InstanceID, error) {
+	g := c.ids
+	switch g.strategy {
+	case IDUUIDv7:
+		return newUUIDv7()
+	case IDSequential:
+		return c.nextSequentialID()
+	case IDCustom:
+		if g.custom != nil {
+			id := g.custom()
+			if id == core.EmptyInstanceID {
+				return core.EmptyInstanceID, fmt.Errorf("%w: generated empty ID", ErrInvalidIDStrategy)
+			}
+			return id, nil
+		}
+	}
+	return core.NewInstanceID(), nil
+}
+
+// nextSequentialID increments and persists the ID sequence of the collection.
+// IDs of transactions that aren't committed are skipped.
+func (c *Collection) nextSequentialID() (core.InstanceID, error) {
+	g := c.ids
+	g.lock.Lock()
+	defer g.lock.Unlock()
+	key := dsIDSequences.ChildString(c.name)
+	if !g.loaded {
+		v, err := c.db.datastore.Get(key)
+		if err == nil {
+			if g.seq, err = strconv.ParseUint(string(v), 10, 64); err != nil {
+				return core.EmptyInstanceID, err
+			}
+		} else if !errors.Is(err, ds.ErrNotFound) {
+			return core.EmptyInstanceID, err
+		}
+		g.loaded = true
+	}
+	seq := g.seq + 1
+	if err := c.db.datastore.Put(key, []byte(strconv.FormatUint(seq, 10))); err != nil {
+		return core.EmptyInstanceID, err
+	}
+	g.seq = seq
+	return core.InstanceID(fmt.Sprintf("%020d", seq)), nil
+}
+
+// newUUIDv7 returns a random version 7 UUID, see RFC 9562.
+func newUUIDv7() (core.InstanceID, error) {
+	var u [16]byte
+	if _, err := rand.Read(u[6:]); err != nil {
+		return core.EmptyInstanceID, err
+	}
+	var ms [8]byte
+	binary.BigEndian.PutUint64(ms[:], uint64(time.Now().UnixNano()/int64(time.Millisecond)))
+	copy(u[:6], ms[2:])
+	u[6] = u[6]&0x0f | 0x70 // Version 7
+	u[8] = u[8]&0x3f | 0x80 // RFC 9562 variant
+	b := make([]byte, 36)
+	hex.Encode(b, u[:4])
+	b[8] = '-'
+	hex.Encode(b[9:], u[4:6])
+	b[13] = '-'
+	hex.Encode(b[14:], u[6:8])
+	b[18] = '-'
+	hex.Encode(b[19:], u[8:10])
+	b[23] = '-'
+	hex.Encode(b[24:], u[10:])
+	return core.InstanceID(b), nil
+}
+
+func (d *DB) saveIDStrategy(c *Collection) error {
+	if c.ids.strategy == IDULID {
+		return d.datastore.Delete(dsIDStrategies.ChildString(c.name))
+	}
+	return d.datastore.Put(dsIDStrategies.ChildString(c.name), []byte(strconv.Itoa(int(c.ids.strategy))))
+}
+
+// loadIDStrategy returns the persisted ID strategy of a collection.
+// Generators aren't persisted, so custom strategies fall back to ULIDs until
+// the collection is updated with a generator.
+func (d *DB) loadIDStrategy(name string) (IDStrategy, error) {
+	v, err := d.datastore.Get(dsIDStrategies.ChildString(name))
+	if errors.Is(err, ds.ErrNotFound) {
+		return IDULID, nil
+	} else if err != nil {
+		return IDULID, err
+	}
+	s, err := strconv.Atoi(string(v))
+	return IDStrategy(s), err
+}
@@ -0,0 +1,185 @@
+package db
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+
+	"github.com/alecthomas/jsonschema"
+	core "github.com/textileio/go-threads/core/db"
+	"github.com/tidwall/sjson"
+)
+
+// ImportFormat is the input format of Collection.Import.
+type ImportFormat int
+
+const (
+	// ImportJSONLines reads one JSON instance per line, as written by Export.
+	// Blank lines are skipped.
+	ImportJSONLines ImportFormat = iota
+	// ImportCSV reads one instance per record, with a header row of field
+	// paths, e.g., "name" or "address.city". Values are converted to the type
+	// of the field in the collection schema, and empty values are omitted.
+	ImportCSV
+)
+
+var (
+	// ErrInvalidImportFormat indicates an unknown import format.
+	ErrInvalidImportFormat = errors.New("invalid import format")
+	// ErrInvalidImportRecord indicates an import record can't be parsed.
+	ErrInvalidImportRecord = errors.New("invalid import record")
+)
+
+// Import creates the instances read from r in the given format. Like with
+// CreateMany, instances are created in sub-batches as split by the db batch limit
+// and WithTxnChunkSize, and WithTxnBatchProgress reports the progress. The IDs of
+// the created instances are returned in input order. If a record can't be parsed
+// or created, a *BatchError identifying it is returned, along with the IDs created
+// by previous sub-batches.
+// With WithTxnDryRun, instances are only validated, and no IDs are returned.
+func (c *Collection) Import(r io.Reader, format ImportFormat, opts ...TxnOption) ([]core.InstanceID, error) {
+	var vs [][]byte
+	var err error
+	switch format {
+	case ImportJSONLines:
+		vs, err = readJSONLines(r)
+	case ImportCSV:
+		vs, err = c.readCSV(r)
+	default:
+		return nil, ErrInvalidImportFormat
+	}
+	if err != nil {
+		return nil, err
+	}
+	args := &TxnOptions{}
+	for _, opt := range opts {
+		opt(args)
+	}
+	if args.DryRun {
+		return nil, c.verifyImport(vs, args.BatchProgress)
+	}
+	return c.CreateMany(vs, opts...)
+}
+
+// verifyImport validates instances as they would be created by an import.
+func (c *Collection) verifyImport(vs [][]byte, progress BatchProgressFunc) error {
+	txn := &Txn{collection: c}
+	defer txn.Discard()
+	seen := make(map[core.InstanceID]struct{}, len(vs))
+	for i, v := range vs {
+		id, _ := getInstanceID(v)
+		if _, ok := seen[id]; ok {
+			return &BatchError{Index: i, ID: id, Err: errCantCreateExistingInstance}
+		}
+		res, err := txn.Create(v)
+		if err != nil {
+			return &BatchError{Index: i, ID: id, Err: err}
+		}
+		seen[res[0]] = struct{}{}
+	}
+	if progress != nil {
+		progress(len(vs), len(vs))
+	}
+	return nil
+}
+
+// readJSONLines reads JSON instances, one per line.
+func readJSONLines(r io.Reader) ([][]byte, error) {
+	br := bufio.NewReader(r)
+	var vs [][]byte
+	for {
+		line, err := br.ReadBytes('\n')
+		if err != nil && err != io.EOF {
+			return nil, err
+		}
+		if line = bytes.TrimSpace(line); len(line) > 0 {
+			if !json.Valid(line) || line[0] != '{' {
+				return nil, &BatchError{Index: len(vs), Err: ErrInvalidImportRecord}
+			}
+			vs = append(vs, line)
+		}
+		if err == io.EOF {
+			return vs, nil
+		}
+	}
+}
+
+// readCSV reads instances from CSV records, see ImportCSV.
+func (c *Collection) readCSV(r io.Reader) ([][]byte, error) {
+	schema := &jsonschema.Schema{}
+	if err := json.Unmarshal(c.GetSchema(), schema); err != nil {
+		return nil, err
+	}
+	cr := csv.NewReader(r)
+	header, err := cr.Read()
+	if err == io.EOF {
+		return nil, nil
+	} else if err != nil {
+		return nil, err
+	}
+	types := make([]string, len(header))
+	for i, pth := range header {
+		if jt, err := getSchemaTypeAtPath(schema, pth); err == nil {
+			types[i] = jt.Type
+		}
+	}
+	var vs [][]byte
+	for {
+		record, err := cr.Read()
+		if err == io.EOF {
+			return vs, nil
+		} else if err != nil {
+			return nil, &BatchError{Index: len(vs), Err: fmt.Errorf("%w: %v", ErrInvalidImportRecord, err)}
+		}
+		v := []byte("{}")
+		for i, value := range record {
+			if value == "" {
+				continue
+			}
+			raw, err := csvValue(types[i], value)
+			if err != nil {
+				return nil, &BatchError{Index: len(vs), Err: fmt.Errorf("%w: field %s: %v", ErrInvalidImportRecord, header[i], err)}
+			}
+			if v, err = sjson.SetRawBytes(v, header[i], raw); err != nil {
+				return nil, &BatchError{Index: len(vs), Err: fmt.Errorf("%w: field %s: %v", ErrInvalidImportRecord, header[i], err)}
+			}
+		}
+		vs = append(vs, v)
+	}
+}
+
+// csvValue returns the raw JSON of a CSV value for the given schema type.
+// Values of unknown fields are kept as strings.
+func csvValue(typ, value string) ([]byte, error) {
+	switch typ {
+	case "integer":
+		if _, err := strconv.ParseInt(value, 10, 64); err != nil {
+			return nil, err
+		}
+		return []byte(value), nil
+	case "number":
+		f, err := strconv.ParseFloat(value, 64)
+		if err != nil {
+			return nil, err
+		}
+		return json.Marshal(f)
+	case "boolean":
+		b, err := strconv.ParseBool(value)
+		if err != nil {
+			return nil, err
+		}
+		return json.Marshal(b)
+	case "object", "array":
+		if !json.Valid([]byte(value)) {
+			return nil, errors.New("invalid json value")
+		}
+		return []byte(value), nil
+	default:
+		return json.Marshal(value)
+	}
+}
@@ -6,14 +6,18 @@ package db
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
 	"errors"
 	"fmt"
+	"reflect"
 	"sort"
+	"strings"
 
 	"github.com/alecthomas/jsonschema"
 	ds "github.com/textileio/go-datastore"
 	"github.com/textileio/go-datastore/query"
+	core "github.com/textileio/go-threads/core/db"
 	"github.com/tidwall/gjson"
 	"github.com/tidwall/sjson"
 )
@@ -32,17 +36,65 @@ var (
 	ErrCantCreateUniqueIndex = errors.New("can't create unique index (duplicate instances exist)")
 	// ErrIndexNotFound indicates a requested index was not found.
 	ErrIndexNotFound = errors.New("index not found")
+	// ErrIndexNotUsable indicates the index hinted by a query can't serve it.
+	ErrIndexNotUsable = errors.New("index can't serve the query")
 
 	indexPrefix = ds.NewKey("_index")
 	indexTypes  = []string{"string", "number", "integer", "boolean"}
 )
 
+// UniqueError indicates that a write would result in a unique constraint violation.
+// It matches ErrUniqueExists with errors.Is.
+type UniqueError struct {
+	// Collection is the name of the collection.
+	Collection string
+	// Index is the path of the violated unique index.
+	Index string
+	// ID is the instance ID of the rejected write.
+	ID core.InstanceID
+	// Conflict is the instance ID that already holds the value.
+	Conflict core.InstanceID
+}
+
+func (e *UniqueError) Error() string {
+	return fmt.Sprintf("%s: instance %s has the same %s as instance %s in collection %s",
+		ErrUniqueExists, e.ID, e.Index, e.Conflict, e.Collection)
+}
+
+func (e *UniqueError) Is(target error) bool {
+	return target == ErrUniqueExists
+}
+
 // Index defines an index.
 type Index struct {
 	// Path to the field to index in dot syntax, e.g., "name.last" or "age".
 	Path string `json:"path"`
 	// Unique indicates that only one instance should exist per field value.
+	// Writes violating the constraint fail with a *UniqueError.
 	Unique bool `json:"unique,omitempty"`
+	// Fields are the paths of a compound index in order, e.g., ["lastName", "firstName"].
+	// Path defaults to the fields joined with commas, and is used to refer to the index.
+	// Instances missing any of the fields aren't indexed.
+	Fields []string `json:"fields,omitempty"`
+	// Text indicates a full-text index over the words of a string field,
+	// which enhances TextSearch criteria on the field.
+	Text bool `json:"text,omitempty"`
+	// Geo indicates a geospatial index over a [lng, lat] array field,
+	// which enhances Near and WithinBox criteria on the field.
+	Geo bool `json:"geo,omitempty"`
+	// CaseInsensitive indicates that string values are indexed in lower case,
+	// so the index serves case-insensitive criteria, and unique constraints
+	// ignore case. It can't be combined with Text or Geo. Changing it on an
+	// existing index requires Collection.Reindex.
+	CaseInsensitive bool `json:"caseInsensitive,omitempty"`
+}
+
+// fields returns the paths indexed by the index.
+func (i Index) fields() []string {
+	if len(i.Fields) > 0 {
+		return i.Fields
+	}
+	return []string{i.Path}
 }
 
 // GetIndexes returns the current indexes.
@@ -61,14 +113,239 @@ func (c *Collection) GetIndexes() []Index {
 	return indexes
 }
 
-// addIndex creates a new index based on path.
+// ListIndexes returns the current indexes, sorted by path.
+func (c *Collection) ListIndexes() []Index {
+	indexes := c.GetIndexes()
+	sort.Slice(indexes, func(i, j int) bool {
+		return indexes[i].Path < indexes[j].Path
+	})
+	return indexes
+}
+
+// CreateIndex adds an index to the collection and builds it from the existing
+// instances in chunks, like Reindex. An existing index at the same path is
+// dropped first if it differs. The index is maintained by writes as soon as
+// it's added, but queries using it may miss instances until it's built. If the
+// build fails or ctx is cancelled, the index is dropped. Collection updates
+// drop indexes their config doesn't list, including created ones.
+func (c *Collection) CreateIndex(ctx context.Context, index Index, opts ...ReindexOption) error {
+	args := &ReindexOptions{ChunkSize: defaultReindexChunkSize}
+	for _, opt := range opts {
+		opt(args)
+	}
+	if args.ChunkSize <= 0 {
+		args.ChunkSize = defaultReindexChunkSize
+	}
+	if err := c.db.connector.Validate(args.Token, false); err != nil {
+		return err
+	}
+	if index.Path == idFieldName {
+		return ErrCannotIndexIDField
+	}
+	schema := &jsonschema.Schema{}
+	if err := json.Unmarshal(c.GetSchema(), schema); err != nil {
+		return err
+	}
+	c.db.lock.Lock()
+	cur, err := c.db.getCollection(c.name)
+	c.db.lock.Unlock()
+	if err != nil {
+		return err
+	}
+	index, ok, err := cur.prepareIndex(schema, index, WithToken(args.Token))
+	if err != nil || !ok {
+		return err
+	}
+	if err := c.DropIndex(index.Path, WithToken(args.Token)); err != nil && !errors.Is(err, ErrIndexNotFound) {
+		return err
+	}
+	if err := c.db.withIndexLock(c.name, func(c *Collection) error {
+		indexes := make(map[string]Index, len(c.indexes)+1)
+		for pth, x := range c.indexes {
+			indexes[pth] = x
+		}
+		indexes[index.Path] = index
+		c.indexes = indexes
+		return c.saveIndexes()
+	}); err != nil {
+		return err
+	}
+
+	keys, err := listKeys(c.db.datastore, c.baseKey())
+	if err == nil {
+		err = c.buildIndex(ctx, index.Path, keys, args)
+	}
+	if err != nil {
+		if derr := c.DropIndex(index.Path, WithToken(args.Token)); derr != nil {
+			log.Errorf("error dropping index %s of %s: %v", index.Path, c.name, derr)
+		}
+		if errors.Is(err, ErrUniqueExists) {
+			return ErrCantCreateUniqueIndex
+		}
+		return err
+	}
+	return nil
+}
+
+// buildIndex adds the values of an index for the instances with the given keys.
+func (c *Collection) buildIndex(ctx context.Context, pth string, keys []ds.Key, args *ReindexOptions) error {
+	build := func(c *Collection, txn ds.Txn, key ds.Key) error {
+		index, ok := c.indexes[pth]
+		if !ok {
+			return ErrIndexNotFound
+		}
+		v, err := txn.Get(key)
+		if err == ds.ErrNotFound {
+			return nil
+		} else if err != nil {
+			return err
+		}
+		return c.indexUpdate(pth, index, txn, key, v, false)
+	}
+	for start := 0; start < len(keys); start += args.ChunkSize {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		end := start + args.ChunkSize
+		if end > len(keys) {
+			end = len(keys)
+		}
+		if err := c.reindexChunk(keys[start:end], build); err != nil {
+			return err
+		}
+		if args.Progress != nil {
+			args.Progress(end, len(keys))
+		}
+	}
+	return nil
+}
+
+// DropIndex drops the index at path and deletes its values.
+// Returns ErrIndexNotFound if the collection has no index at path.
+func (c *Collection) DropIndex(pth string, opts ...Option) error {
+	args := &Options{}
+	for _, opt := range opts {
+		opt(args)
+	}
+	if err := c.db.connector.Validate(args.Token, false); err != nil {
+		return err
+	}
+	if pth == idFieldName {
+		return ErrCannotIndexIDField
+	}
+	return c.db.withIndexLock(c.name, func(c *Collection) error {
+		if _, ok := c.indexes[pth]; !ok {
+			return ErrIndexNotFound
+		}
+		indexes := make(map[string]Index, len(c.indexes))
+		for p, x := range c.indexes {
+			if p != pth {
+				indexes[p] = x
+			}
+		}
+		c.indexes = indexes
+		if err := c.saveIndexes(); err != nil {
+			return err
+		}
+		txn, err := c.db.datastore.NewTransaction(false)
+		if err != nil {
+			return err
+		}
+		defer txn.Discard()
+		if err := deletePrefix(c.db.datastore, txn, indexPrefix.Child(c.baseKey()).ChildString(pth)); err != nil {
+			return err
+		}
+		return txn.Commit()
+	})
+}
+
+// withIndexLock calls fn with the current collection of the given name, while
+// reads and writes are blocked, so fn can replace the collection indexes.
+func (d *DB) withIndexLock(name string, fn func(c *Collection) error) error {
+	d.txnlock.Lock()
+	defer d.txnlock.Unlock()
+	d.dispatcher.Lock().Lock()
+	defer d.dispatcher.Lock().Unlock()
+	d.lock.Lock()
+	defer d.lock.Unlock()
+	c, err := d.getCollection(name)
+	if err != nil {
+		return err
+	}
+	return fn(c)
+}
+
+// checkIndexHint returns an error if the index hinted by q doesn't exist or can't serve q.
+func (c *Collection) checkIndexHint(q *Query) error {
+	index, ok := c.indexes[q.Index]
+	if !ok || q.Index == idFieldName {
+		return ErrIndexNotFound
+	}
+	if index.Text || index.Geo {
+		// Text and geo indexes are used automatically by their criteria
+		return ErrIndexNotUsable
+	}
+	if !indexServes(q, index) {
+		return ErrIndexNotUsable
+	}
+	return nil
+}
+
+// indexServes returns whether or not an index can serve q.
+// Only indexed values are visible when iterating an index, so every condition
+// must be on one of the fields, and must ignore case on string values if the
+// index does. Instances without a value at the fields aren't indexed, so every
+// branch of q needs at least one condition to exclude them.
+func indexServes(q *Query, index Index) bool {
+	if len(q.Ands) == 0 {
+		return false
+	}
+	for _, c := range q.Ands {
+		if c.Operation == Exists || c.Operation == IsNull {
+			// Null and missing values aren't indexed
+			return false
+		}
+		if index.CaseInsensitive && !c.CaseInsensitive && c.hasStringValue() {
+			return false
+		}
+		var ok bool
+		for _, f := range index.fields() {
+			if c.FieldPath == f {
+				ok = true
+				break
+			}
+		}
+		if !ok {
+			return false
+		}
+	}
+	for _, o := range q.Ors {
+		if !indexServes(o, index) {
+			return false
+		}
+	}
+	return true
+}
+
+// addIndex creates a new index based on path, or on fields for compound indexes.
 // Use dot syntax to reach nested fields, e.g., "name.last".
 // The field at path must be one of the supported JSON Schema types: string, number, integer, or boolean
 // Set unique to true if you want a unique constraint on path.
 // Adding an index will override any overlapping index values if they already exist.
-// @note: This does NOT currently build the index. If items have been added prior to adding
-// a new index, they will NOT be indexed a posteriori.
+// @note: This does NOT build the index. If items have been added prior to adding
+// a new index, they are only indexed a posteriori by Collection.Reindex.
 func (c *Collection) addIndex(schema *jsonschema.Schema, index Index, opts ...Option) error {
+	index, ok, err := c.prepareIndex(schema, index, opts...)
+	if err != nil || !ok {
+		return err
+	}
+	c.indexes[index.Path] = index
+	return c.saveIndexes()
+}
+
+// prepareIndex validates an index, see addIndex, and returns it with its
+// default path. It returns false if the index already exists.
+func (c *Collection) prepareIndex(schema *jsonschema.Schema, index Index, opts ...Option) (Index, bool, error) {
 	args := &Options{}
 	for _, opt := range opts {
 		opt(args)
@@ -77,53 +354,79 @@ func (c *Collection) addIndex(schema *jsonschema.Schema, index Index, opts ...Op
 	// Don't allow the default index to be overwritten
 	if index.Path == idFieldName {
 		if _, ok := c.indexes[idFieldName]; ok {
-			return nil
+			return index, false, nil
 		}
 	}
 
-	// Validate path and type.
-	jt, err := getSchemaTypeAtPath(schema, index.Path)
-	if err != nil {
-		return err
+	if index.Text && (index.Unique || len(index.Fields) > 0) {
+		return index, false, ErrInvalidTextIndex
 	}
-	var valid bool
-	for _, t := range indexTypes {
-		if jt.Type == t {
-			valid = true
-			break
-		}
+	if index.Geo && (index.Unique || index.Text || len(index.Fields) > 0) {
+		return index, false, ErrInvalidGeoIndex
+	}
+	if index.CaseInsensitive && index.Text {
+		return index, false, ErrInvalidTextIndex
 	}
-	if !valid {
-		return ErrNotIndexable
+	if index.CaseInsensitive && index.Geo {
+		return index, false, ErrInvalidGeoIndex
+	}
+	if len(index.Fields) > 0 && index.Path == "" {
+		index.Path = strings.Join(index.Fields, ",")
+	}
+
+	// Validate paths and types.
+	for _, pth := range index.fields() {
+		jt, err := getSchemaTypeAtPath(schema, pth)
+		if err != nil {
+			return index, false, err
+		}
+		if index.Geo {
+			if jt.Type != "array" {
+				return index, false, ErrInvalidGeoIndex
+			}
+			continue
+		}
+		var valid bool
+		for _, t := range indexTypes {
+			if jt.Type == t {
+				valid = true
+				break
+			}
+		}
+		if !valid {
+			return index, false, ErrNotIndexable
+		}
+		if index.Text && jt.Type != "string" {
+			return index, false, ErrInvalidTextIndex
+		}
 	}
 
 	// Skip if nothing to do
-	if x, ok := c.indexes[index.Path]; ok && index.Unique == x.Unique {
-		return nil
+	if x, ok := c.indexes[index.Path]; ok && reflect.DeepEqual(index, x) {
+		return index, false, nil
 	}
 
 	// Ensure collection does not contain multiple instances with the same value at path
 	if index.Unique && index.Path != idFieldName {
-		vals := make(map[interface{}]struct{})
+		vals := make(map[ds.Key]struct{})
 		all, err := c.Find(&Query{}, WithTxnToken(args.Token))
 		if err != nil {
-			return err
+			return index, false, err
 		}
 		for _, i := range all {
-			res := gjson.GetBytes(i, index.Path)
-			if !res.Exists() {
+			val, err := getIndexValue(index, i)
+			if err != nil {
 				continue
 			}
-			if _, ok := vals[res.Value()]; ok {
-				return ErrCantCreateUniqueIndex
+			if _, ok := vals[val]; ok {
+				return index, false, ErrCantCreateUniqueIndex
 			} else {
-				vals[res.Value()] = struct{}{}
+				vals[val] = struct{}{}
 			}
 		}
 	}
 
-	c.indexes[index.Path] = index
-	return c.saveIndexes()
+	return index, true, nil
 }
 
 // dropIndex drops the index at path.
@@ -170,24 +473,52 @@ func (c *Collection) indexDelete(tx ds.Txn, key ds.Key, originalData []byte) err
 
 // indexUpdate adds or removes a specific index on an item.
 func (c *Collection) indexUpdate(field string, index Index, tx ds.Txn, key ds.Key, input []byte, delete bool) error {
-	valueKey, err := getIndexValue(field, input)
+	valueKeys, err := indexValues(field, index, input)
+	if err != nil {
+		return err
+	}
+	for _, valueKey := range valueKeys {
+		if err := c.indexValueUpdate(field, index, tx, key, valueKey, delete); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// indexValues returns the index values of an item, if any.
+func indexValues(field string, index Index, input []byte) ([]ds.Key, error) {
+	if index.Text {
+		// Text indexes have a value per term
+		var valueKeys []ds.Key
+		for _, term := range textTokens(gjson.GetBytes(input, field).String()) {
+			valueKeys = append(valueKeys, ds.NewKey(term))
+		}
+		return valueKeys, nil
+	}
+	var valueKey ds.Key
+	var err error
+	if index.Geo {
+		valueKey, err = geoIndexValue(field, input)
+	} else {
+		valueKey, err = getIndexValue(index, input)
+	}
 	if err != nil {
 		if errors.Is(err, ErrNotIndexable) {
-			return nil
+			return nil, nil
 		}
-		return err
+		return nil, err
 	}
+	return []ds.Key{valueKey}, nil
+}
 
-	indexKey := indexPrefix.Child(c.baseKey()).ChildString(field).ChildString(valueKey.String()[1:])
+// indexValueUpdate adds or removes an item from the keys of an index value.
+func (c *Collection) indexValueUpdate(field string, index Index, tx ds.Txn, key, valueKey ds.Key, delete bool) error {
+	indexKey := c.indexKey(field, valueKey)
 	data, err := tx.Get(indexKey)
 	if err != nil && err != ds.ErrNotFound {
 		return err
 	}
-	if err != ds.ErrNotFound {
-		if index.Unique && !delete {
-			return ErrUniqueExists
-		}
-	}
+	exists := err == nil
 
 	indexValue := make(keyList, 0)
 	if data != nil {
@@ -196,6 +527,13 @@ func (c *Collection) indexUpdate(field string, index Index, tx ds.Txn, key ds.Ke
 			return err
 		}
 	}
+	if exists && index.Unique && !delete {
+		if indexValue.in(key) {
+			// already indexed
+			return nil
+		}
+		return ErrUniqueExists
+	}
 	if delete {
 		indexValue.remove(key)
 	} else {
@@ -211,13 +549,92 @@ func (c *Collection) indexUpdate(field string, index Index, tx ds.Txn, key ds.Ke
 	return tx.Put(indexKey, val)
 }
 
-// getIndexValue returns the result of a field search on input.
-func getIndexValue(field string, input []byte) (ds.Key, error) {
-	result := gjson.GetBytes(input, field)
-	if !result.Exists() {
-		return ds.Key{}, ErrNotIndexable
+// indexKey returns the key of an index value.
+func (c *Collection) indexKey(path string, value ds.Key) ds.Key {
+	return indexPrefix.Child(c.baseKey()).ChildString(path).ChildString(value.String()[1:])
+}
+
+// checkUnique returns a *UniqueError if the actions of the transaction would
+// violate a unique index, before any event is created.
+func (t *Txn) checkUnique() error {
+	d := t.collection.db
+	touched := make(map[string]map[core.InstanceID]struct{})
+	for _, a := range t.actions {
+		if touched[a.CollectionName] == nil {
+			touched[a.CollectionName] = make(map[core.InstanceID]struct{})
+		}
+		touched[a.CollectionName][a.InstanceID] = struct{}{}
+	}
+	txn, err := d.datastore.NewTransaction(true)
+	if err != nil {
+		return err
+	}
+	defer txn.Discard()
+	pending := make(map[ds.Key]core.InstanceID)
+	for _, a := range t.actions {
+		if a.Type != core.Create && a.Type != core.Save {
+			continue
+		}
+		c := t.collection
+		if a.CollectionName != c.name {
+			if c = d.GetCollection(a.CollectionName); c == nil {
+				continue
+			}
+		}
+		for _, index := range c.indexes {
+			if !index.Unique || index.Path == idFieldName {
+				continue
+			}
+			val, err := getIndexValue(index, a.Current)
+			if errors.Is(err, ErrNotIndexable) {
+				continue
+			} else if err != nil {
+				return err
+			}
+			key := c.indexKey(index.Path, val)
+			if id, ok := pending[key]; ok && id != a.InstanceID {
+				return &UniqueError{Collection: c.name, Index: index.Path, ID: a.InstanceID, Conflict: id}
+			}
+			pending[key] = a.InstanceID
+			data, err := txn.Get(key)
+			if errors.Is(err, ds.ErrNotFound) {
+				continue
+			} else if err != nil {
+				return err
+			}
+			held := make(keyList, 0)
+			if err := DefaultDecode(data, &held); err != nil {
+				return err
+			}
+			for _, k := range held {
+				id := core.InstanceID(ds.RawKey(string(k)).Name())
+				// Instances written by this transaction are checked by their new values
+				if _, ok := touched[c.name][id]; ok {
+					continue
+				}
+				return &UniqueError{Collection: c.name, Index: index.Path, ID: a.InstanceID, Conflict: id}
+			}
+		}
 	}
-	return ds.NewKey(result.String()), nil
+	return nil
+}
+
+// getIndexValue returns the result of the index field searches on input.
+// Compound index values are a key with one namespace per field.
+func getIndexValue(index Index, input []byte) (ds.Key, error) {
+	fields := index.fields()
+	values := make([]string, len(fields))
+	for i, f := range fields {
+		result := gjson.GetBytes(input, f)
+		if !result.Exists() {
+			return ds.Key{}, ErrNotIndexable
+		}
+		values[i] = result.String()
+		if index.CaseInsensitive && result.Type == gjson.String {
+			values[i] = strings.ToLower(values[i])
+		}
+	}
+	return ds.NewKey(strings.Join(values, "/")), nil
 }
 
 // keyList is a slice of unique, sorted keys([]byte) such as what an index points to
@@ -266,18 +683,43 @@ type MarshaledResult struct {
 }
 
 type iterator struct {
-	nextKeys func() ([]ds.Key, error)
-	txn      ds.Txn
-	query    *Query
-	err      error
-	keyCache []ds.Key
-	iter     query.Results
+	nextKeys  func() ([]ds.Key, error)
+	txn       ds.Txn
+	query     *Query
+	err       error
+	keyCache  []ds.Key
+	iter      query.Results
+	keyed     bool
+	matchKeys bool
+	// ctx stops the iteration once it's done, if set.
+	ctx context.Context
+	// maxScan is the maximum number of instances read, or zero for no maximum.
+	maxScan int
+	scanned int
 }
 
-func newIterator(txn ds.Txn, baseKey ds.Key, q *Query) *iterator {
+// newKeysIterator returns an iterator over the instances at keys that match q.
+func newKeysIterator(txn ds.Txn, q *Query, keys []ds.Key) *iterator {
+	i := &iterator{
+		txn:       txn,
+		query:     q,
+		iter:      query.ResultsWithEntries(query.Query{}, nil),
+		keyed:     true,
+		matchKeys: true,
+	}
+	i.nextKeys = func() ([]ds.Key, error) {
+		next := keys
+		keys = nil
+		return next, nil
+	}
+	return i
+}
+
+func newIterator(txn ds.Txn, baseKey ds.Key, q *Query, index Index) *iterator {
 	i := &iterator{
 		txn:   txn,
 		query: q,
+		keyed: q.Index != "",
 	}
 	var prefix ds.Key
 	if q.Index == "" {
@@ -286,10 +728,9 @@ func newIterator(txn ds.Txn, baseKey ds.Key, q *Query) *iterator {
 		prefix = indexPrefix.Child(baseKey).ChildString(q.Index)
 	}
 
+	// Limit and skip are applied by the caller to matching results
 	dsq := query.Query{
 		Prefix: prefix.String(),
-		Limit:  q.Limit,
-		Offset: q.Skip,
 	}
 	if q.Sort.FieldPath == idFieldName {
 		if q.Sort.Desc {
@@ -300,6 +741,9 @@ func newIterator(txn ds.Txn, baseKey ds.Key, q *Query) *iterator {
 	}
 	if q.Seek != "" {
 		dsq.SeekPrefix = prefix.Child(ds.NewKey(string(q.Seek))).String()
+	} else if q.Sort.FieldPath == idFieldName && q.Sort.Desc {
+		// Reverse iteration starts at the seek key, so seek past the last key
+		dsq.SeekPrefix = prefix.String() + "/\xff"
 	}
 	i.iter, i.err = txn.Query(dsq)
 
@@ -312,29 +756,30 @@ func newIterator(txn ds.Txn, baseKey ds.Key, q *Query) *iterator {
 	}
 
 	// indexed field, get keys from index
-	first := true
 	i.nextKeys = func() ([]ds.Key, error) {
 		var nKeys []ds.Key
 		for len(nKeys) < iteratorKeyMinCacheSize {
 			result, ok := i.iter.NextSync()
 			if !ok {
-				if first {
-					return nil, ErrIndexNotFound
-				}
 				return nKeys, result.Error
 			}
-			first = false
-			// result.Key contains the indexed value, extract here first
-			key := ds.RawKey(result.Key)
-			base := prefix.Name()
-			name := key.Name()
-			val := gjson.Parse(name).Value()
-			if val == nil {
-				val = name
+			// result.Key contains the indexed values, extract here first
+			fields := index.fields()
+			names := ds.RawKey(result.Key).Namespaces()
+			if len(names) < len(fields) {
+				return nil, fmt.Errorf("malformed index key %s", result.Key)
 			}
-			doc, err := sjson.Set("", base, val)
-			if err != nil {
-				return nil, err
+			names = names[len(names)-len(fields):]
+			doc := "{}"
+			var err error
+			for j, name := range names {
+				val := gjson.Parse(name).Value()
+				if val == nil {
+					val = name
+				}
+				if doc, err = sjson.Set(doc, fields[j], val); err != nil {
+					return nil, err
+				}
 			}
 			value := make(map[string]interface{})
 			if err := json.Unmarshal([]byte(doc), &value); err != nil {
@@ -362,10 +807,13 @@ func newIterator(txn ds.Txn, baseKey ds.Key, q *Query) *iterator {
 // NextSync returns the next key value that matches the iterators criteria
 // If there is an error, ok is false and result.Error() will return the error
 func (i *iterator) NextSync() (MarshaledResult, bool) {
-	if i.query.Index == "" {
+	if !i.keyed {
 		value := MarshaledResult{}
 		var ok bool
 		for res := range i.iter.Next() {
+			if err := i.scan(); err != nil {
+				return MarshaledResult{Result: query.Result{Error: err}}, false
+			}
 			val := make(map[string]interface{})
 			if value.Error = json.Unmarshal(res.Value, &val); value.Error != nil {
 				break
@@ -383,47 +831,100 @@ func (i *iterator) NextSync() (MarshaledResult, bool) {
 		}
 		return value, ok
 	}
-	if len(i.keyCache) == 0 {
-		newKeys, err := i.nextKeys()
+	for {
+		if err := i.ctxErr(); err != nil {
+			return MarshaledResult{Result: query.Result{Error: err}}, false
+		}
+		if len(i.keyCache) == 0 {
+			newKeys, err := i.nextKeys()
+			if err != nil {
+				return MarshaledResult{
+					Result: query.Result{
+						Entry: query.Entry{},
+						Error: err,
+					},
+				}, false
+			}
+
+			if len(newKeys) == 0 {
+				return MarshaledResult{
+					Result: query.Result{
+						Entry: query.Entry{},
+						Error: nil,
+					},
+				}, false
+			}
+			i.keyCache = append(i.keyCache, newKeys...)
+		}
+
+		key := i.keyCache[0]
+		i.keyCache = i.keyCache[1:]
+		if err := i.scan(); err != nil {
+			return MarshaledResult{Result: query.Result{Error: err}}, false
+		}
+
+		value, err := i.txn.Get(key)
 		if err != nil {
 			return MarshaledResult{
 				Result: query.Result{
 					Entry: query.Entry{},
 					Error: err,
-				},
-			}, false
+				}}, false
 		}
-
-		if len(newKeys) == 0 {
+		// Marshaled values are needed for sorting
+		val := make(map[string]interface{})
+		if err := json.Unmarshal(value, &val); err != nil {
 			return MarshaledResult{
 				Result: query.Result{
 					Entry: query.Entry{},
-					Error: nil,
-				},
-			}, false
+					Error: err,
+				}}, false
+		}
+		if i.matchKeys {
+			ok, err := i.query.match(val)
+			if err != nil {
+				return MarshaledResult{
+					Result: query.Result{
+						Entry: query.Entry{},
+						Error: err,
+					}}, false
+			}
+			if !ok {
+				continue
+			}
 		}
-		i.keyCache = append(i.keyCache, newKeys...)
-	}
-
-	key := i.keyCache[0]
-	i.keyCache = i.keyCache[1:]
-
-	value, err := i.txn.Get(key)
-	if err != nil {
 		return MarshaledResult{
 			Result: query.Result{
-				Entry: query.Entry{},
-				Error: err,
-			}}, false
-	}
-	return MarshaledResult{
-		Result: query.Result{
-			Entry: query.Entry{
-				Key:   key.String(),
-				Value: value,
+				Entry: query.Entry{
+					Key:   key.String(),
+					Value: value,
+				},
+				Error: nil,
 			},
-			Error: nil,
-		}}, true
+			MarshaledValue: val,
+		}, true
+	}
+}
+
+// ctxErr returns the error of the iterator context, if it's done.
+func (i *iterator) ctxErr() error {
+	if i.ctx == nil {
+		return nil
+	}
+	return i.ctx.Err()
+}
+
+// scan accounts for an instance read by the iterator, returning an error
+// if the iterator context is done or the instance exceeds the maximum.
+func (i *iterator) scan() error {
+	if err := i.ctxErr(); err != nil {
+		return err
+	}
+	i.scanned++
+	if i.maxScan > 0 && i.scanned > i.maxScan {
+		return &QueryLimitError{Scan: true, Max: i.maxScan}
+	}
+	return nil
 }
 
 func (i *iterator) Close() {
@@ -0,0 +1,89 @@
+package db
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+
+	ds "github.com/textileio/go-datastore"
+	"github.com/textileio/go-datastore/query"
+	"github.com/textileio/go-threads/core/thread"
+)
+
+// isolatedDBsPath is the directory of the repo holding the datastores of
+// isolated dbs, see WithNewIsolatedDBs.
+const isolatedDBsPath = "dbs"
+
+// isolatedDBPath returns the repo directory of an isolated db.
+func isolatedDBPath(repoPath string, id thread.ID) string {
+	return filepath.Join(repoPath, isolatedDBsPath, id.String())
+}
+
+// isolatedDB returns whether a db of the manager has its own datastore. Dbs
+// whose directory exists are isolated, and so are new dbs with
+// WithNewIsolatedDBs. Dbs already stored in the shared datastore stay there.
+func isolatedDB(base *NewOptions, id thread.ID) (bool, error) {
+	if _, err := os.Stat(isolatedDBPath(base.RepoPath, id)); err == nil {
+		return true, nil
+	} else if !os.IsNotExist(err) {
+		return false, err
+	}
+	if !base.IsolatedDBs {
+		return false, nil
+	}
+	results, err := base.Datastore.Query(query.Query{
+		Prefix:   dsManagerBaseKey.ChildString(id.String()).String(),
+		KeysOnly: true,
+		Limit:    1,
+	})
+	if err != nil {
+		return false, err
+	}
+	defer results.Close()
+	for res := range results.Next() {
+		if res.Error != nil {
+			return false, res.Error
+		}
+		return false, nil
+	}
+	return true, nil
+}
+
+// isolatedDBIDs returns the IDs of the isolated dbs stored in the repo.
+// Directories that aren't named by a thread ID are skipped.
+func isolatedDBIDs(repoPath string) ([]thread.ID, error) {
+	entries, err := ioutil.ReadDir(filepath.Join(repoPath, isolatedDBsPath))
+	if os.IsNotExist(err) {
+		return nil, nil
+	} else if err != nil {
+		return nil, err
+	}
+	var ids []thread.ID
+	for _, e := range entries {
+		if !e.IsDir() {
+			continue
+		}
+		id, err := thread.Decode(e.Name())
+		if err != nil {
+			continue
+		}
+		ids = append(ids, id)
+	}
+	return ids, nil
+}
+
+// collectGarbage garbage collects a datastore, if it supports it, and returns
+// whether it did.
+func collectGarbage(store ds.Datastore) (bool, error) {
+	if sd, ok := store.(*storageDatastore); ok {
+		store = sd.TxnDatastore
+	}
+	gc, ok := store.(ds.GCDatastore)
+	if !ok {
+		return false, nil
+	}
+	if err := gc.CollectGarbage(); err != nil {
+		return false, err
+	}
+	return true, nil
+}
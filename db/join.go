@@ -0,0 +1,302 @@
+package db
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	ma "github.com/multiformats/go-multiaddr"
+	"github.com/textileio/go-threads/broadcast"
+	"github.com/textileio/go-threads/core/net"
+	"github.com/textileio/go-threads/core/thread"
+)
+
+// ErrJoinNotFound indicates the manager isn't joining the specified db, and
+// didn't join it with NewDBFromAddrAsync.
+var ErrJoinNotFound = errors.New("db join not found")
+
+// joinProgressInterval is how often the progress of a join is sent to its
+// subscribers while the thread is pulled.
+var joinProgressInterval = time.Second
+
+// JoinState is the state of a db join, see NewDBFromAddrAsync.
+type JoinState int
+
+const (
+	// JoinAdding is the state of a join getting the thread from its address.
+	JoinAdding JoinState = iota + 1
+	// JoinPulling is the state of a join pulling the records of the thread.
+	JoinPulling
+	// JoinDone is the state of a join whose initial pull completed.
+	JoinDone
+	// JoinFailed is the state of a join that failed, see JoinProgress.Err.
+	JoinFailed
+)
+
+func (s JoinState) String() string {
+	switch s {
+	case JoinAdding:
+		return "adding"
+	case JoinPulling:
+		return "pulling"
+	case JoinDone:
+		return "done"
+	case JoinFailed:
+		return "failed"
+	default:
+		return "unknown"
+	}
+}
+
+// JoinProgress is the progress of a db join.
+type JoinProgress struct {
+	// DB is the ID of the db thread.
+	DB thread.ID
+	// State is the state of the join.
+	State JoinState
+	// Logs is the number of logs of the thread discovered so far.
+	Logs int
+	// Records is the number of records fetched and handled by the db so far.
+	Records int
+	// Collections is the number of collections hydrated in the db.
+	Collections int
+	// Err is why the join failed, with JoinFailed.
+	Err error
+	// Started is the start time of the join.
+	Started time.Time
+	// Updated is the time of the last update of the progress.
+	Updated time.Time
+}
+
+// Done returns whether the join is over, successfully or not.
+func (p JoinProgress) Done() bool {
+	return p.State == JoinDone || p.State == JoinFailed
+}
+
+// Join is a db join started with NewDBFromAddrAsync.
+type Join struct {
+	m     *Manager
+	token thread.Token
+
+	lock     sync.Mutex
+	progress JoinProgress
+	db       *DB
+	bus      *broadcast.Broadcaster
+	cancel   context.CancelFunc
+	done     chan struct{}
+}
+
+// NewDBFromAddrAsync starts joining a db from address, as NewDBFromAddr, but
+// returns as soon as the join is started, instead of blocking until the thread
+// is added and its records are pulled. The progress of the join can be polled
+// with Join.Progress or Manager.JoinProgress, or subscribed to with
+// Join.Subscribe. The db is added to the manager once the thread is added,
+// while its records are still pulled, and creating it again before then fails
+// with ErrDBExists. WithNewManagedBackfillBlock doesn't apply, the join is
+// done once the initial pull completes.
+func (m *Manager) NewDBFromAddrAsync(addr ma.Multiaddr, key thread.Key, opts ...NewManagedOption) (*Join, error) {
+	id, err := thread.FromAddr(addr)
+	if err != nil {
+		return nil, err
+	}
+	args := &NewManagedOptions{}
+	for _, opt := range opts {
+		opt(args)
+	}
+	if args.Name != "" && !nameRx.MatchString(args.Name) { // Pre-check name
+		return nil, ErrInvalidName
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), pullThreadBackgroundTimeout)
+	now := time.Now()
+	j := &Join{
+		m:     m,
+		token: args.Token,
+		progress: JoinProgress{
+			DB:      id,
+			State:   JoinAdding,
+			Started: now,
+			Updated: now,
+		},
+		bus:    broadcast.NewBroadcaster(managerEventBusCapacity),
+		cancel: cancel,
+		done:   make(chan struct{}),
+	}
+	m.lock.Lock()
+	if m.hasDBLocked(id) {
+		m.lock.Unlock()
+		cancel()
+		return nil, ErrDBExists
+	}
+	m.joins[id] = j
+	m.lock.Unlock()
+
+	go j.run(ctx, addr, key, args)
+	return j, nil
+}
+
+// JoinProgress returns the progress of a db join started with
+// NewDBFromAddrAsync, until the db is deleted.
+func (m *Manager) JoinProgress(id thread.ID) (JoinProgress, error) {
+	m.lock.Lock()
+	j, ok := m.joins[id]
+	m.lock.Unlock()
+	if !ok {
+		return JoinProgress{}, ErrJoinNotFound
+	}
+	return j.Progress(), nil
+}
+
+// run adds the thread of the join, and then pulls its records.
+func (j *Join) run(ctx context.Context, addr ma.Multiaddr, key thread.Key, args *NewManagedOptions) {
+	defer close(j.done)
+	defer j.cancel()
+	d, err := j.m.addDBFromAddr(ctx, addr, key, args)
+	if err != nil {
+		j.finish(err)
+		return
+	}
+	defer j.m.ReleaseDB(j.progress.DB)
+	j.lock.Lock()
+	j.db = d
+	j.lock.Unlock()
+	j.update(JoinPulling)
+
+	pulled := make(chan error, 1)
+	go func() {
+		pulled <- j.m.network.PullThread(ctx, j.progress.DB, net.WithThreadToken(j.token))
+	}()
+	ticker := time.NewTicker(joinProgressInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case err := <-pulled:
+			j.finish(err)
+			return
+		case <-ticker.C:
+			j.update(JoinPulling)
+		}
+	}
+}
+
+// update refreshes the progress of the join, and sends it to the subscribers.
+func (j *Join) update(state JoinState) {
+	j.updateWith(state, nil)
+}
+
+// finish marks the join as done, or as failed with err.
+func (j *Join) finish(err error) {
+	if err != nil {
+		log.Errorf("error joining db %s: %v", j.progress.DB, err)
+		j.updateWith(JoinFailed, err)
+	} else {
+		j.updateWith(JoinDone, nil)
+	}
+	j.bus.Discard()
+}
+
+func (j *Join) updateWith(state JoinState, err error) {
+	j.lock.Lock()
+	defer j.lock.Unlock()
+	if j.db != nil {
+		if info, err := j.m.network.GetThread(context.Background(), j.progress.DB, net.WithThreadToken(j.token)); err == nil {
+			j.progress.Logs = len(info.Logs)
+		}
+		j.progress.Records = int(atomic.LoadUint64(&j.db.handledRecords))
+		j.db.lock.Lock()
+		j.progress.Collections = len(j.db.collections) + len(j.db.unloaded)
+		j.db.lock.Unlock()
+	}
+	j.progress.State = state
+	j.progress.Err = err
+	j.progress.Updated = time.Now()
+	if err := j.bus.SendWithTimeout(j.progress, managerEventTimeout); err != nil {
+		log.Warnf("dropped progress of db join %s: %v", j.progress.DB, err)
+	}
+}
+
+// Progress returns the current progress of the join.
+func (j *Join) Progress() JoinProgress {
+	j.lock.Lock()
+	defer j.lock.Unlock()
+	return j.progress
+}
+
+// Subscribe returns a channel of the progress of the join, starting with the
+// current progress. It's closed once the join is done, after sending its last
+// progress, or when ctx is cancelled. Updates are dropped if the subscriber
+// falls behind, but the last progress is always sent.
+func (j *Join) Subscribe(ctx context.Context) <-chan JoinProgress {
+	j.lock.Lock()
+	listener := j.bus.Listen()
+	current := j.progress
+	j.lock.Unlock()
+	channel := make(chan JoinProgress)
+	go func() {
+		defer close(channel)
+		defer listener.Discard()
+		last := current
+		select {
+		case channel <- current:
+		case <-ctx.Done():
+			return
+		}
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case v, ok := <-listener.Channel():
+				if !ok {
+					// Send the last progress if it was dropped
+					if p := j.Progress(); !p.Updated.Equal(last.Updated) {
+						select {
+						case channel <- p:
+						case <-ctx.Done():
+						}
+					}
+					return
+				}
+				last = v.(JoinProgress)
+				select {
+				case channel <- last:
+				case <-ctx.Done():
+					return
+				}
+			}
+		}
+	}()
+	return channel
+}
+
+// Wait blocks until the join is done, and returns the joined db, or why the
+// join failed. The db is released with Manager.ReleaseDB.
+func (j *Join) Wait(ctx context.Context) (*DB, error) {
+	select {
+	case <-j.done:
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+	j.lock.Lock()
+	err := j.progress.Err
+	j.lock.Unlock()
+	if err != nil {
+		return nil, err
+	}
+	return j.m.getDB(j.progress.DB)
+}
+
+// stopJoins cancels the running db joins and waits for them to return.
+func (m *Manager) stopJoins() {
+	m.lock.Lock()
+	joins := make([]*Join, 0, len(m.joins))
+	for _, j := range m.joins {
+		joins = append(joins, j)
+	}
+	m.lock.Unlock()
+	for _, j := range joins {
+		j.cancel()
+		<-j.done
+	}
+}
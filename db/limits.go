@@ -0,0 +1,43 @@
+package db
+
+import (
+	"errors"
+	"fmt"
+)
+
+// ErrQueryLimitExceeded indicates a query exceeds a limit of the db.
+var ErrQueryLimitExceeded = errors.New("query limit exceeded")
+
+// QueryLimitError indicates a query exceeds the maximum number of results or
+// of scanned instances of the db, see WithNewMaxQueryResults and WithNewMaxQueryScan.
+// It matches ErrQueryLimitExceeded with errors.Is.
+type QueryLimitError struct {
+	// Scan indicates the scanned instances exceed the maximum, instead of the results.
+	Scan bool
+	// Max is the exceeded maximum.
+	Max int
+}
+
+func (e *QueryLimitError) Error() string {
+	what := "results"
+	if e.Scan {
+		what = "scanned instances"
+	}
+	return fmt.Sprintf("%s: more than %d %s, refine the query", ErrQueryLimitExceeded, e.Max, what)
+}
+
+func (e *QueryLimitError) Is(target error) bool {
+	return target == ErrQueryLimitExceeded
+}
+
+// queryLimits bound the results and the scanned instances of a query.
+// Zero values are unbounded.
+type queryLimits struct {
+	results int
+	scan    int
+}
+
+// queryLimits returns the query limits of the db.
+func (d *DB) queryLimits() queryLimits {
+	return queryLimits{results: d.maxQueryResults, scan: d.maxQueryScan}
+}
@@ -1,9 +1,12 @@
 package db
 
 import (
+	"encoding/json"
 	"fmt"
+	"hash/fnv"
 	"sync"
 
+	jsonpatch "github.com/evanphx/json-patch"
 	"github.com/ipfs/go-ipld-format"
 	"github.com/textileio/go-threads/core/app"
 	core "github.com/textileio/go-threads/core/db"
@@ -14,23 +17,89 @@ import (
 // defined filters. The DB *won't* wait for slow receivers, so if the
 // channel is full, the action will be dropped.
 func (d *DB) Listen(los ...ListenOption) (Listener, error) {
+	return d.ListenWithOptions(los)
+}
+
+// ListenWithOptions returns a Listener which notifies about actions applying the
+// defined filters, configured with opts. See ListenOrder for the delivery guarantees
+// of each ordering mode, and PartitionedListener to consume its partitions.
+func (d *DB) ListenWithOptions(los []ListenOption, opts ...ListenerOption) (Listener, error) {
 	d.txnlock.Lock()
 	defer d.txnlock.Unlock()
 	if d.closed {
-		return nil, fmt.Errorf("can't listen on closed DB")
+		return nil, fmt.Errorf("can't listen on closed DB: %w", d.closeErr)
 	}
 
+	for _, lo := range los {
+		if lo.Query == nil {
+			continue
+		}
+		if err := lo.Query.Validate(); err != nil {
+			return nil, fmt.Errorf("invalid query: %s", err)
+		}
+	}
+	args := &ListenerOptions{}
+	for _, opt := range opts {
+		opt(args)
+	}
+	partitions := 1
+	if args.Order == ListenPerKeyOrder {
+		partitions = args.Partitions
+		if partitions < 1 {
+			partitions = defaultListenerPartitions
+		}
+	}
 	sl := &listener{
 		scn:     d.stateChangedNotifee,
+		payload: args.Payload,
 		filters: los,
-		c:       make(chan Action, 1),
+		cs:      make([]chan Action, partitions),
+		done:    make(chan struct{}),
+	}
+	for i := range sl.cs {
+		sl.cs[i] = make(chan Action, 1)
+	}
+	if args.ResumeToken == nil {
+		d.stateChangedNotifee.addListener(sl)
+		return sl, nil
+	}
+
+	// Actions are recorded under the dispatcher lock, so none are missed
+	// or replayed twice between the replay and the listener registration
+	d.dispatcher.Lock().Lock()
+	defer d.dispatcher.Lock().Unlock()
+	actions, err := d.actionsSince(*args.ResumeToken)
+	if err != nil {
+		return nil, err
+	}
+	for _, a := range actions {
+		state := d.currentState(a)
+		if sl.evaluate(a, state) {
+			sl.backlog = append(sl.backlog, sl.withPayload(a, state))
+		}
 	}
+	sl.replaying = true
+	sl.replayed.Add(1)
+	go sl.replay()
 	d.stateChangedNotifee.addListener(sl)
 	return sl, nil
 }
 
-func (d *DB) notifyStateChanged(actions []Action) {
-	d.stateChangedNotifee.notify(actions)
+// currentState returns the current state of the instance of a replayed action.
+// Only query filters see the current state as the previous one.
+func (d *DB) currentState(a Action) instanceState {
+	v, err := d.datastore.Get(baseKey.ChildString(a.Collection).ChildString(a.ID.String()))
+	if err != nil {
+		return instanceState{}
+	}
+	return instanceState{current: v, replayed: true}
+}
+
+func (d *DB) notifyStateChanged(actions []Action, states []instanceState) {
+	d.metrics.observeWrites(actions)
+	if dropped := d.stateChangedNotifee.notify(actions, states); dropped > 0 {
+		d.metrics.dropped.Add(float64(dropped))
+	}
 }
 
 func (d *DB) notifyTxnEvents(node format.Node, token thread.Token) error {
@@ -60,19 +129,127 @@ type Action struct {
 	Collection string
 	Type       ActionType
 	ID         core.InstanceID
+	// Metadata is the metadata of the transaction in which the action was done.
+	Metadata map[string]string
+	// ResumeToken increases with each action of the db. Listeners can resume
+	// after it with WithResumeToken.
+	ResumeToken uint64
+	// Previous is the instance before the action, see WithListenerPayload.
+	Previous []byte `json:",omitempty"`
+	// Current is the instance after the action, see WithListenerPayload.
+	Current []byte `json:",omitempty"`
+	// Patch is the JSON merge patch from Previous to Current, see WithListenerPayload.
+	Patch []byte `json:",omitempty"`
 }
 
 type ListenOption struct {
 	Type       ListenActionType
 	Collection string
 	ID         core.InstanceID
+	// Query optionally restricts actions to instances matching it. Creates
+	// match on the new instance, deletes on the deleted instance, and saves
+	// on either, so listeners learn when instances stop matching.
+	Query *Query
+}
+
+// ListenOrder is the ordering guarantee of actions delivered to a listener.
+type ListenOrder int
+
+const (
+	// ListenGlobalOrder delivers all actions over a single channel in the
+	// order they were applied to the db.
+	ListenGlobalOrder ListenOrder = iota
+	// ListenPerKeyOrder delivers actions over multiple partitions, where all
+	// actions of a given instance (collection and ID) go to the same partition.
+	// Actions of the same instance are delivered in the order they were applied,
+	// but there's no ordering guarantee between actions of different instances.
+	// Partitions can be consumed in parallel, see PartitionedListener.
+	ListenPerKeyOrder
+)
+
+const defaultListenerPartitions = 4
+
+// ListenerOptions defines options for a listener.
+type ListenerOptions struct {
+	Order       ListenOrder
+	Partitions  int
+	ResumeToken *uint64
+	Payload     ListenPayload
+}
+
+// ListenPayload selects the instance data included in listened actions.
+type ListenPayload int
+
+const (
+	// PayloadNone includes no instance data.
+	PayloadNone ListenPayload = iota
+	// PayloadInstances includes the previous and current instances.
+	// Previous is nil for creates, and Current is nil for deletes.
+	PayloadInstances
+	// PayloadPatch includes the JSON merge patch from the previous to the
+	// current instance, which is null for deletes.
+	PayloadPatch
+)
+
+// ListenerOption specifies a listener option.
+type ListenerOption func(*ListenerOptions)
+
+// WithListenerOrder sets the ordering guarantee of the listener.
+// When using ListenPerKeyOrder, partitions sets the number of partitions,
+// defaulting to 4 if zero.
+func WithListenerOrder(order ListenOrder, partitions int) ListenerOption {
+	return func(o *ListenerOptions) {
+		o.Order = order
+		o.Partitions = partitions
+	}
+}
+
+// WithListenerPayload includes instance data in the listened actions, so
+// listeners don't have to fetch and diff instances themselves.
+// Replayed actions, see WithResumeToken, only include the current instance.
+func WithListenerPayload(payload ListenPayload) ListenerOption {
+	return func(o *ListenerOptions) {
+		o.Payload = payload
+	}
+}
+
+// WithResumeToken replays the actions after the one with the given resume
+// token before the listener receives new actions, e.g., to catch up on actions
+// missed while disconnected. Query filters of replayed actions are matched
+// against the current state of instances.
+// Returns ErrInvalidResumeToken if the action is no longer kept by the db.
+func WithResumeToken(token uint64) ListenerOption {
+	return func(o *ListenerOptions) {
+		o.ResumeToken = &token
+	}
 }
 
 type Listener interface {
+	// Channel returns a channel with all the listened actions.
+	// With ListenPerKeyOrder, this merges all partitions, preserving per-instance order.
 	Channel() <-chan Action
 	Close()
 }
 
+// ErrListener is a Listener that reports why its channels were closed.
+// Listeners returned by Listen and ListenWithOptions implement it.
+type ErrListener interface {
+	Listener
+	// Err returns why the listener channels were closed, e.g., ErrDBDeleted
+	// if the db was deleted, or nil if the listener was closed with Close.
+	Err() error
+}
+
+// PartitionedListener is a Listener whose partitions can be consumed
+// separately, e.g., in parallel with ListenPerKeyOrder. Listeners returned by
+// ListenWithOptions implement it.
+type PartitionedListener interface {
+	Listener
+	// Partitions returns the channels of each partition.
+	// With ListenGlobalOrder, there's a single partition.
+	Partitions() []<-chan Action
+}
+
 type stateChangedNotifee struct {
 	lock      sync.Mutex
 	listeners []*listener
@@ -80,24 +257,107 @@ type stateChangedNotifee struct {
 
 type listener struct {
 	scn     *stateChangedNotifee
+	payload ListenPayload
 	filters []ListenOption
-	c       chan Action
+	cs      []chan Action
+
+	mergeOnce sync.Once
+	merged    chan Action
+
+	// Replayed actions, followed by new actions received during the replay
+	lock      sync.Mutex
+	backlog   []Action
+	replaying bool
+	replayed  sync.WaitGroup
+	done      chan struct{}
+	closeOnce sync.Once
+	err       error
 }
 
-var _ Listener = (*listener)(nil)
+var _ ErrListener = (*listener)(nil)
 
-func (scn *stateChangedNotifee) notify(actions []Action) {
-	for _, a := range actions {
+// instanceState is the state of an instance before and after an action,
+// used to evaluate listener queries and build action payloads.
+type instanceState struct {
+	previous []byte
+	current  []byte
+	// replayed states only hold the current instance, which is matched by
+	// query filters of any action type.
+	replayed bool
+}
+
+// instanceStateKey returns the key of an instance in the states kept by Reduce.
+func instanceStateKey(collection string, id core.InstanceID) string {
+	return collection + "/" + id.String()
+}
+
+// needsStates returns whether or not a listener filters actions by query,
+// or receives action payloads.
+func (scn *stateChangedNotifee) needsStates() bool {
+	scn.lock.Lock()
+	defer scn.lock.Unlock()
+	for _, l := range scn.listeners {
+		if l.payload != PayloadNone {
+			return true
+		}
+		for _, f := range l.filters {
+			if f.Query != nil {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// notify delivers actions to listeners. states are the instance states of
+// each action, if any listener needs them. It returns the number of actions
+// dropped because a listener channel was full.
+func (scn *stateChangedNotifee) notify(actions []Action, states []instanceState) (dropped int) {
+	for i, a := range actions {
+		var state instanceState
+		if states != nil {
+			state = states[i]
+		}
 		for _, l := range scn.listeners {
-			if l.evaluate(a) {
+			if l.evaluate(a, state) {
+				a := l.withPayload(a, state)
+				if l.queue(a) {
+					continue
+				}
 				select {
-				case l.c <- a:
+				case l.partition(a) <- a:
 				default:
 					log.Warnf("dropped action %v for reducer with filters %v", a, l.filters)
+					dropped++
 				}
 			}
 		}
 	}
+	return dropped
+}
+
+// listening returns whether the db has open listeners.
+func (scn *stateChangedNotifee) listening() bool {
+	scn.lock.Lock()
+	defer scn.lock.Unlock()
+	return len(scn.listeners) > 0
+}
+
+// backlog returns the number of actions waiting to be received by listeners,
+// in their channels or replay backlogs.
+func (scn *stateChangedNotifee) backlog() int {
+	scn.lock.Lock()
+	defer scn.lock.Unlock()
+	var n int
+	for _, l := range scn.listeners {
+		l.lock.Lock()
+		n += len(l.backlog)
+		l.lock.Unlock()
+		for _, c := range l.cs {
+			n += len(c)
+		}
+	}
+	return n
 }
 
 func (scn *stateChangedNotifee) addListener(sl *listener) {
@@ -120,29 +380,126 @@ func (scn *stateChangedNotifee) remove(sl *listener) bool {
 	return false
 }
 
-func (scn *stateChangedNotifee) close() {
+// close closes the listeners with reason, see ErrListener.Err.
+func (scn *stateChangedNotifee) close(reason error) {
+	// Listeners remove themselves as they're closed
 	scn.lock.Lock()
-	defer scn.lock.Unlock()
-	for i := range scn.listeners {
-		scn.listeners[i].Close()
+	listeners := append([]*listener(nil), scn.listeners...)
+	scn.lock.Unlock()
+	for _, l := range listeners {
+		l.closeWith(reason)
 	}
 }
 
 // Channel returns an unbuffered channel to receive
 // db change notifications
 func (sl *listener) Channel() <-chan Action {
-	return sl.c
+	if len(sl.cs) == 1 {
+		return sl.cs[0]
+	}
+	sl.mergeOnce.Do(func() {
+		sl.merged = make(chan Action)
+		var wg sync.WaitGroup
+		wg.Add(len(sl.cs))
+		for _, c := range sl.cs {
+			go func(c chan Action) {
+				defer wg.Done()
+				for a := range c {
+					sl.merged <- a
+				}
+			}(c)
+		}
+		go func() {
+			wg.Wait()
+			close(sl.merged)
+		}()
+	})
+	return sl.merged
+}
+
+// Partitions returns the channels of each listener partition.
+func (sl *listener) Partitions() []<-chan Action {
+	cs := make([]<-chan Action, len(sl.cs))
+	for i := range sl.cs {
+		cs[i] = sl.cs[i]
+	}
+	return cs
 }
 
 // Close indicates that no further notifications will be received
 // and ready for being garbage collected
 func (sl *listener) Close() {
+	sl.closeWith(nil)
+}
+
+// Err returns why the listener channels were closed.
+func (sl *listener) Err() error {
+	sl.lock.Lock()
+	defer sl.lock.Unlock()
+	return sl.err
+}
+
+// closeWith closes the listener channels with reason, see Err.
+func (sl *listener) closeWith(reason error) {
 	if ok := sl.scn.remove(sl); ok {
-		close(sl.c)
+		sl.lock.Lock()
+		sl.err = reason
+		sl.lock.Unlock()
+		sl.closeOnce.Do(func() { close(sl.done) })
+		sl.replayed.Wait()
+		for _, c := range sl.cs {
+			close(c)
+		}
+	}
+}
+
+// queue adds an action to the backlog if the listener is replaying actions.
+func (sl *listener) queue(a Action) bool {
+	sl.lock.Lock()
+	defer sl.lock.Unlock()
+	if !sl.replaying {
+		return false
+	}
+	sl.backlog = append(sl.backlog, a)
+	return true
+}
+
+// replay delivers the backlog until it's empty. Unlike new actions,
+// replayed actions aren't dropped if the channel is full.
+func (sl *listener) replay() {
+	defer sl.replayed.Done()
+	for {
+		sl.lock.Lock()
+		backlog := sl.backlog
+		sl.backlog = nil
+		if len(backlog) == 0 {
+			sl.replaying = false
+			sl.lock.Unlock()
+			return
+		}
+		sl.lock.Unlock()
+		for _, a := range backlog {
+			select {
+			case sl.partition(a) <- a:
+			case <-sl.done:
+				return
+			}
+		}
 	}
 }
 
-func (sl *listener) evaluate(a Action) bool {
+// partition returns the channel for an action.
+// Actions of the same instance always map to the same partition.
+func (sl *listener) partition(a Action) chan Action {
+	if len(sl.cs) == 1 {
+		return sl.cs[0]
+	}
+	h := fnv.New32a()
+	_, _ = h.Write([]byte(a.Collection + "/" + a.ID.String()))
+	return sl.cs[h.Sum32()%uint32(len(sl.cs))]
+}
+
+func (sl *listener) evaluate(a Action, state instanceState) bool {
 	if len(sl.filters) == 0 {
 		return true
 	}
@@ -172,7 +529,62 @@ func (sl *listener) evaluate(a Action) bool {
 		if f.ID != core.EmptyInstanceID && f.ID != a.ID {
 			continue
 		}
+
+		if f.Query != nil && !matchState(f.Query, a.Type, state) {
+			continue
+		}
 		return true
 	}
 	return false
 }
+
+// matchState returns whether or not the instance state of an action matches q.
+func matchState(q *Query, t ActionType, state instanceState) bool {
+	var candidates [][]byte
+	switch {
+	case state.replayed:
+		candidates = [][]byte{state.current}
+	case t == ActionCreate:
+		candidates = [][]byte{state.current}
+	case t == ActionSave:
+		candidates = [][]byte{state.current, state.previous}
+	case t == ActionDelete:
+		candidates = [][]byte{state.previous}
+	}
+	for _, data := range candidates {
+		if data == nil {
+			continue
+		}
+		val := make(map[string]interface{})
+		if err := json.Unmarshal(data, &val); err != nil {
+			continue
+		}
+		if ok, err := q.match(val); err == nil && ok {
+			return true
+		}
+	}
+	return false
+}
+
+// withPayload returns a with the instance data selected by the listener.
+func (sl *listener) withPayload(a Action, state instanceState) Action {
+	switch sl.payload {
+	case PayloadInstances:
+		a.Previous, a.Current = state.previous, state.current
+	case PayloadPatch:
+		switch {
+		case state.current == nil:
+			a.Patch = []byte("null")
+		case state.previous == nil:
+			a.Patch = state.current
+		default:
+			patch, err := jsonpatch.CreateMergePatch(state.previous, state.current)
+			if err != nil {
+				log.Errorf("error creating action patch: %v", err)
+				return a
+			}
+			a.Patch = patch
+		}
+	}
+	return a
+}
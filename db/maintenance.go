@@ -0,0 +1,158 @@
+package db
+
+import (
+	"context"
+	"time"
+
+	ds "github.com/textileio/go-datastore"
+	"github.com/textileio/go-threads/core/thread"
+)
+
+// MaintenanceReport is the outcome of a maintenance run, see RunMaintenance.
+type MaintenanceReport struct {
+	// Started is the start time of the run.
+	Started time.Time
+	// Duration is the duration of the run.
+	Duration time.Duration
+	// DBs is the number of maintained dbs.
+	DBs int
+	// Collections is the number of collections whose indexes were cleaned up.
+	Collections int
+	// GC indicates the value log of the datastore was garbage collected, and
+	// of the datastores of the isolated dbs, see WithNewIsolatedDBs. It's false
+	// if the datastores don't support garbage collection.
+	GC bool
+}
+
+// RunMaintenance runs the maintenance of the managed dbs now, regardless of
+// the maintenance window, see WithNewMaintenance. The indexes of each
+// collection are cleaned up with Collection.Reindex, and then the value log
+// of the datastore, and of each hydrated isolated db, is garbage collected,
+// if it supports it, e.g., badger.
+// Dbs that aren't hydrated yet, see WithNewLazyDBs, aren't written until
+// they're hydrated, so they're skipped. A failing collection doesn't stop the
+// run, the first error is returned with the report once the run is done.
+// Runs don't overlap, a run waits for the current one to return first.
+func (m *Manager) RunMaintenance(ctx context.Context) (MaintenanceReport, error) {
+	select {
+	case m.maintenanceLock <- struct{}{}:
+	case <-ctx.Done():
+		return MaintenanceReport{}, ctx.Err()
+	}
+	defer func() { <-m.maintenanceLock }()
+	return m.runMaintenance(ctx)
+}
+
+func (m *Manager) runMaintenance(ctx context.Context) (report MaintenanceReport, err error) {
+	report.Started = time.Now()
+	defer func() { report.Duration = time.Since(report.Started) }()
+	m.lock.Lock()
+	dbs := make(map[thread.ID]*DB, len(m.dbs))
+	for id, d := range m.dbs {
+		m.acquireDB(id)
+		dbs[id] = d
+	}
+	m.lock.Unlock()
+	defer m.releaseDBs(dbs)
+
+	var first error
+	for _, d := range dbs {
+		for _, c := range d.ListCollections() {
+			if err := c.Reindex(ctx); err != nil {
+				if ctx.Err() != nil {
+					return report, ctx.Err()
+				}
+				log.Errorf("error cleaning up indexes of %s: %v", c.name, err)
+				if first == nil {
+					first = err
+				}
+				continue
+			}
+			report.Collections++
+		}
+		report.DBs++
+	}
+	stores := []ds.Datastore{m.opts.Datastore}
+	for _, d := range dbs {
+		if !managedDatastore(d.datastore) {
+			stores = append(stores, d.datastore)
+		}
+	}
+	for _, store := range stores {
+		collected, err := collectGarbage(store)
+		if err != nil {
+			log.Errorf("error collecting garbage: %v", err)
+			if first == nil {
+				first = err
+			}
+			continue
+		}
+		report.GC = report.GC || collected
+	}
+	return report, first
+}
+
+// maintenanceLoop runs the maintenance of the managed dbs every interval
+// within the maintenance window, until the manager is closed.
+func (m *Manager) maintenanceLoop(interval time.Duration) {
+	defer close(m.maintenanceDone)
+	if interval <= 0 {
+		return
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go func() {
+		select {
+		case <-m.maintenanceStop:
+			cancel()
+		case <-ctx.Done():
+		}
+	}()
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-m.maintenanceStop:
+			return
+		case now := <-ticker.C:
+			if !inMaintenanceWindow(now, m.opts.MaintenanceStart, m.opts.MaintenanceEnd) {
+				continue
+			}
+			select {
+			case m.maintenanceLock <- struct{}{}:
+			default:
+				// A run requested with RunMaintenance is in progress
+				continue
+			}
+			report, err := m.runMaintenance(ctx)
+			<-m.maintenanceLock
+			if err != nil {
+				log.Errorf("error running maintenance: %v", err)
+				continue
+			}
+			log.Debugf("maintained %d dbs in %s", report.DBs, report.Duration)
+		}
+	}
+}
+
+// inMaintenanceWindow returns whether t is within the maintenance window
+// starting and ending at the given offsets from local midnight. A window
+// ending before it starts spans midnight, and an empty window is always open.
+func inMaintenanceWindow(t time.Time, start, end time.Duration) bool {
+	if start == end {
+		return true
+	}
+	offset := time.Duration(t.Hour())*time.Hour + time.Duration(t.Minute())*time.Minute + time.Duration(t.Second())*time.Second
+	if start < end {
+		return offset >= start && offset < end
+	}
+	return offset >= start || offset < end
+}
+
+// stopMaintenance stops the maintenance scheduler and waits for it to return.
+func (m *Manager) stopMaintenance() {
+	m.maintenanceOnce.Do(func() {
+		close(m.maintenanceStop)
+		<-m.maintenanceDone
+	})
+}
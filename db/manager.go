@@ -3,14 +3,19 @@ package db
 import (
 	"context"
 	"errors"
+	"fmt"
 	"io"
+	"os"
 	"strings"
+	"sync"
 
+	format "github.com/ipfs/go-ipld-format"
 	logging "github.com/ipfs/go-log"
 	ma "github.com/multiformats/go-multiaddr"
 	ds "github.com/textileio/go-datastore"
 	kt "github.com/textileio/go-datastore/keytransform"
 	"github.com/textileio/go-datastore/query"
+	"github.com/textileio/go-threads/broadcast"
 	"github.com/textileio/go-threads/core/app"
 	"github.com/textileio/go-threads/core/net"
 	"github.com/textileio/go-threads/core/thread"
@@ -22,6 +27,8 @@ var (
 	ErrDBNotFound = errors.New("db not found")
 	// ErrDBExists indicates that the specified db alrady exists in the manager.
 	ErrDBExists = errors.New("db already exists")
+	// ErrDBNameAmbiguous indicates that several dbs of the manager have the specified name.
+	ErrDBNameAmbiguous = errors.New("several dbs have the name")
 
 	dsManagerBaseKey = ds.NewKey("/manager")
 )
@@ -32,10 +39,32 @@ type Manager struct {
 	opts *NewOptions
 
 	network app.Net
-	dbs     map[thread.ID]*DB
+
+	// The dbs that aren't hydrated yet, see WithNewLazyDBs
+	lock     sync.Mutex
+	dbs      map[thread.ID]*DB
+	unloaded map[thread.ID]struct{}
+
+	// The dbs joined with NewDBFromAddrAsync
+	joins map[thread.ID]*Join
+
+	// The last use of each open db, the handles of open dbs, and the evicted
+	// dbs being closed, see WithNewMaxOpenDBs
+	used    map[thread.ID]uint64
+	useSeq  uint64
+	refs    map[thread.ID]int
+	closing map[thread.ID]chan struct{}
+
+	bus *broadcast.Broadcaster
+
+	maintenanceLock chan struct{}
+	maintenanceStop chan struct{}
+	maintenanceDone chan struct{}
+	maintenanceOnce sync.Once
 }
 
 // NewManager hydrates and starts dbs from prefixes.
+// With WithNewLazyDBs, dbs are only hydrated as they're first used.
 func NewManager(network app.Net, opts ...NewOption) (*Manager, error) {
 	options := &NewOptions{}
 	for _, opt := range opts {
@@ -58,33 +87,35 @@ func NewManager(network app.Net, opts ...NewOption) (*Manager, error) {
 	}
 
 	m := &Manager{
-		opts:    options,
-		network: network,
-		dbs:     make(map[thread.ID]*DB),
+		opts:     options,
+		network:  network,
+		dbs:      make(map[thread.ID]*DB),
+		unloaded: make(map[thread.ID]struct{}),
+		joins:    make(map[thread.ID]*Join),
+		used:     make(map[thread.ID]uint64),
+		refs:     make(map[thread.ID]int),
+		closing:  make(map[thread.ID]chan struct{}),
+		bus:      broadcast.NewBroadcaster(managerEventBusCapacity),
+
+		maintenanceLock: make(chan struct{}, 1),
+		maintenanceStop: make(chan struct{}),
+		maintenanceDone: make(chan struct{}),
 	}
 
-	results, err := m.opts.Datastore.Query(query.Query{
-		Prefix:   dsManagerBaseKey.String(),
-		KeysOnly: true,
-	})
+	ids, err := m.storedDBIDs()
 	if err != nil {
 		return nil, err
 	}
-	defer results.Close()
 	invalids := make(map[thread.ID]struct{})
-	for res := range results.Next() {
-		parts := strings.Split(ds.RawKey(res.Key).String(), "/")
-		if len(parts) < 3 {
-			continue
-		}
-		id, err := thread.Decode(parts[2])
-		if err != nil {
-			continue
-		}
-		if _, ok := m.dbs[id]; ok {
-			continue
-		}
-		if _, ok := invalids[id]; ok {
+	for _, id := range ids {
+		if m.opts.LazyDBs || m.opts.MaxOpenDBs > 0 {
+			// Records received before the db is hydrated hydrate it
+			if _, err := m.network.ConnectApp(&lazyDB{m: m, id: id}, id); err != nil {
+				log.Errorf("unable to reload db %s: %s (marked for deletion)", id, err)
+				invalids[id] = struct{}{}
+				continue
+			}
+			m.unloaded[id] = struct{}{}
 			continue
 		}
 		opts, err := getDBOptions(id, m.opts, "")
@@ -97,6 +128,10 @@ func NewManager(network app.Net, opts ...NewOption) (*Manager, error) {
 			invalids[id] = struct{}{}
 			continue
 		}
+		if err := m.indexDB(id, s); err != nil {
+			return nil, err
+		}
+		m.watch(s)
 		m.dbs[id] = s
 	}
 
@@ -106,17 +141,60 @@ func NewManager(network app.Net, opts ...NewOption) (*Manager, error) {
 			return nil, err
 		}
 	}
+	go m.maintenanceLoop(m.opts.MaintenanceInterval)
 	return m, nil
 }
 
+// storedDBIDs returns the IDs of the dbs stored in the shared datastore, and
+// of the isolated dbs stored in the repo, see WithNewIsolatedDBs.
+func (m *Manager) storedDBIDs() ([]thread.ID, error) {
+	results, err := m.opts.Datastore.Query(query.Query{
+		Prefix:   dsManagerBaseKey.String(),
+		KeysOnly: true,
+	})
+	if err != nil {
+		return nil, err
+	}
+	defer results.Close()
+	var ids []thread.ID
+	seen := make(map[thread.ID]struct{})
+	for res := range results.Next() {
+		parts := strings.Split(ds.RawKey(res.Key).String(), "/")
+		if len(parts) < 3 {
+			continue
+		}
+		id, err := thread.Decode(parts[2])
+		if err != nil {
+			continue
+		}
+		if _, ok := seen[id]; ok {
+			continue
+		}
+		seen[id] = struct{}{}
+		ids = append(ids, id)
+	}
+	isolated, err := isolatedDBIDs(m.opts.RepoPath)
+	if err != nil {
+		return nil, err
+	}
+	for _, id := range isolated {
+		if _, ok := seen[id]; !ok {
+			seen[id] = struct{}{}
+			ids = append(ids, id)
+		}
+	}
+	return ids, nil
+}
+
 // GetToken provides access to thread network tokens.
 func (m *Manager) GetToken(ctx context.Context, identity thread.Identity) (thread.Token, error) {
 	return m.network.GetToken(ctx, identity)
 }
 
 // NewDB creates a new db and prefixes its datastore with base key.
+// The db is released with ReleaseDB.
 func (m *Manager) NewDB(ctx context.Context, id thread.ID, opts ...NewManagedOption) (*DB, error) {
-	if _, ok := m.dbs[id]; ok {
+	if m.hasDB(id) {
 		return nil, ErrDBExists
 	}
 	args := &NewManagedOptions{}
@@ -135,11 +213,28 @@ func (m *Manager) NewDB(ctx context.Context, id thread.ID, opts ...NewManagedOpt
 	if err != nil {
 		return nil, err
 	}
+	dbOpts.Token = args.Token
 	db, err := newDB(m.network, id, dbOpts)
 	if err != nil {
 		return nil, err
 	}
+	if args.Description != "" || len(args.Labels) > 0 {
+		if err := db.setMetadata(DBMetadata{Name: db.name, Description: args.Description, Labels: args.Labels}); err != nil {
+			return nil, err
+		}
+	}
+	if err := m.indexDB(id, db); err != nil {
+		return nil, err
+	}
+	m.watch(db)
+	m.lock.Lock()
 	m.dbs[id] = db
+	m.acquireDB(id)
+	m.touchDB(id)
+	evicted := m.evictIdleDBs(id)
+	m.lock.Unlock()
+	m.closeEvicted(evicted)
+	m.emit(ManagerEvent{Type: EventDBCreated, DB: id})
 	return db, nil
 }
 
@@ -151,7 +246,7 @@ func (m *Manager) NewDBFromAddr(ctx context.Context, addr ma.Multiaddr, key thre
 	if err != nil {
 		return nil, err
 	}
-	if _, ok := m.dbs[id]; ok {
+	if m.hasDB(id) {
 		return nil, ErrDBExists
 	}
 	args := &NewManagedOptions{}
@@ -162,19 +257,10 @@ func (m *Manager) NewDBFromAddr(ctx context.Context, addr ma.Multiaddr, key thre
 		return nil, ErrInvalidName
 	}
 
-	if _, err := m.network.AddThread(ctx, addr, net.WithThreadKey(key), net.WithLogKey(args.LogKey), net.WithNewThreadToken(args.Token)); err != nil {
-		return nil, err
-	}
-
-	dbOpts, err := getDBOptions(id, m.opts, args.Name, args.Collections...)
-	if err != nil {
-		return nil, err
-	}
-	db, err := newDB(m.network, id, dbOpts)
+	db, err := m.addDBFromAddr(ctx, addr, key, args)
 	if err != nil {
 		return nil, err
 	}
-	m.dbs[id] = db
 
 	if args.Block {
 		if err = m.network.PullThread(ctx, id, net.WithThreadToken(args.Token)); err != nil {
@@ -192,24 +278,71 @@ func (m *Manager) NewDBFromAddr(ctx context.Context, addr ma.Multiaddr, key thre
 	return db, nil
 }
 
-// ListDBs returns a list of all dbs.
+// addDBFromAddr adds the thread of a db from address, and creates the db,
+// without pulling the thread.
+func (m *Manager) addDBFromAddr(ctx context.Context, addr ma.Multiaddr, key thread.Key, args *NewManagedOptions) (*DB, error) {
+	ti, err := m.network.AddThread(ctx, addr, net.WithThreadKey(key), net.WithLogKey(args.LogKey), net.WithNewThreadToken(args.Token))
+	if err != nil {
+		return nil, err
+	}
+	id := ti.ID
+
+	dbOpts, err := getDBOptions(id, m.opts, args.Name, args.Collections...)
+	if err != nil {
+		return nil, err
+	}
+	dbOpts.Token = args.Token
+	db, err := newDB(m.network, id, dbOpts)
+	if err != nil {
+		return nil, err
+	}
+	if args.Description != "" || len(args.Labels) > 0 {
+		if err := db.setMetadata(DBMetadata{Name: db.name, Description: args.Description, Labels: args.Labels}); err != nil {
+			return nil, err
+		}
+	}
+	if err := m.indexDB(id, db); err != nil {
+		return nil, err
+	}
+	m.watch(db)
+	m.lock.Lock()
+	m.dbs[id] = db
+	m.acquireDB(id)
+	m.touchDB(id)
+	evicted := m.evictIdleDBs(id)
+	m.lock.Unlock()
+	m.closeEvicted(evicted)
+	m.emit(ManagerEvent{Type: EventDBCreated, DB: id})
+	return db, nil
+}
+
+// ListDBs returns a list of all dbs. See ListDBsPage to list them in pages.
+// Each db is released with ReleaseDB.
 func (m *Manager) ListDBs(ctx context.Context, opts ...ManagedOption) (map[thread.ID]*DB, error) {
 	args := &ManagedOptions{}
 	for _, opt := range opts {
 		opt(args)
 	}
 
-	dbs := make(map[thread.ID]*DB)
-	for id, db := range m.dbs {
-		if _, err := m.network.GetThread(ctx, id, net.WithThreadToken(args.Token)); err != nil {
+	dbs := m.loadDBs()
+	for id, db := range dbs {
+		ok, err := m.canAccess(id, db, args.Token)
+		if err == nil && ok {
+			_, err = m.network.GetThread(ctx, id, net.WithThreadToken(args.Token))
+		}
+		if err != nil {
+			m.releaseDBs(dbs)
 			return nil, err
 		}
-		dbs[id] = db
+		if !ok {
+			delete(dbs, id)
+			m.ReleaseDB(id)
+		}
 	}
 	return dbs, nil
 }
 
-// GetDB returns a db by id.
+// GetDB returns a db by id. The db is released with ReleaseDB.
 func (m *Manager) GetDB(ctx context.Context, id thread.ID, opts ...ManagedOption) (*DB, error) {
 	args := &ManagedOptions{}
 	for _, opt := range opts {
@@ -218,11 +351,61 @@ func (m *Manager) GetDB(ctx context.Context, id thread.ID, opts ...ManagedOption
 	if _, err := m.network.GetThread(ctx, id, net.WithThreadToken(args.Token)); err != nil {
 		return nil, err
 	}
-	db, ok := m.dbs[id]
-	if !ok {
+	return m.getAccessibleDB(id, args.Token)
+}
+
+// GetDBByName returns a db by name. Names aren't unique, so if several
+// dbs have the name, returns ErrDBNameAmbiguous.
+func (m *Manager) GetDBByName(ctx context.Context, name string, opts ...ManagedOption) (*DB, error) {
+	args := &ManagedOptions{}
+	for _, opt := range opts {
+		opt(args)
+	}
+	var found thread.ID
+	dbs := m.loadDBs()
+	defer m.releaseDBs(dbs)
+	for id, db := range dbs {
+		if db.getMetadata().Name != name {
+			continue
+		}
+		if ok, err := m.canAccess(id, db, args.Token); err != nil {
+			return nil, err
+		} else if !ok {
+			continue
+		}
+		if found.Defined() {
+			return nil, ErrDBNameAmbiguous
+		}
+		found = id
+	}
+	if !found.Defined() {
 		return nil, ErrDBNotFound
 	}
-	return db, nil
+	return m.GetDB(ctx, found, opts...)
+}
+
+// GetDBMetadata returns the name, description, and labels of a db.
+func (m *Manager) GetDBMetadata(ctx context.Context, id thread.ID, opts ...ManagedOption) (DBMetadata, error) {
+	db, err := m.GetDB(ctx, id, opts...)
+	if err != nil {
+		return DBMetadata{}, err
+	}
+	defer m.ReleaseDB(id)
+	return db.getMetadata(), nil
+}
+
+// UpdateDBMetadata replaces the name, description, and labels of a db.
+// The metadata is local to the manager, it doesn't replicate to peers.
+func (m *Manager) UpdateDBMetadata(ctx context.Context, id thread.ID, md DBMetadata, opts ...ManagedOption) error {
+	db, err := m.GetDB(ctx, id, opts...)
+	if err != nil {
+		return err
+	}
+	defer m.ReleaseDB(id)
+	if err := db.setMetadata(md); err != nil {
+		return err
+	}
+	return m.indexDB(id, db)
 }
 
 // DeleteDB deletes a db by id.
@@ -234,12 +417,15 @@ func (m *Manager) DeleteDB(ctx context.Context, id thread.ID, opts ...ManagedOpt
 	if _, err := m.network.GetThread(ctx, id, net.WithThreadToken(args.Token)); err != nil {
 		return err
 	}
-	db, ok := m.dbs[id]
-	if !ok {
-		return ErrDBNotFound
+	db, err := m.getAccessibleDB(id, args.Token)
+	if err != nil {
+		return err
 	}
+	defer m.ReleaseDB(id)
 
-	if err := db.Close(); err != nil {
+	// Running transactions are done, and listeners are closed, before the
+	// storage is torn down
+	if err := db.close(ErrDBDeleted); err != nil {
 		return err
 	}
 	if err := m.network.DeleteThread(ctx, id, net.WithThreadToken(args.Token), net.WithAPIToken(db.connector.Token())); err != nil {
@@ -254,11 +440,156 @@ func (m *Manager) DeleteDB(ctx context.Context, id thread.ID, opts ...ManagedOpt
 		return err
 	}
 
+	m.lock.Lock()
 	delete(m.dbs, id)
+	delete(m.joins, id)
+	delete(m.used, id)
+	delete(m.refs, id)
+	m.lock.Unlock()
+	m.emit(ManagerEvent{Type: EventDBDeleted, DB: id})
 	return nil
 }
 
+// hasDB returns whether a db exists, hydrated or not, or is being joined.
+func (m *Manager) hasDB(id thread.ID) bool {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+	return m.hasDBLocked(id)
+}
+
+// hasDBLocked is hasDB for callers holding m.lock.
+func (m *Manager) hasDBLocked(id thread.ID) bool {
+	if _, ok := m.dbs[id]; ok {
+		return true
+	}
+	if _, ok := m.unloaded[id]; ok {
+		return true
+	}
+	j, ok := m.joins[id]
+	return ok && j.Progress().State == JoinAdding
+}
+
+// getDB returns a db by id, hydrating it if needed. The db is released
+// with ReleaseDB.
+func (m *Manager) getDB(id thread.ID) (*DB, error) {
+	m.lockForHydration(id)
+	db, err := m.hydrateDB(id)
+	if err != nil {
+		m.lock.Unlock()
+		return nil, err
+	}
+	m.acquireDB(id)
+	m.touchDB(id)
+	evicted := m.evictIdleDBs(id)
+	m.lock.Unlock()
+	m.closeEvicted(evicted)
+	return db, nil
+}
+
+// hydrateDB returns a db by id, hydrating it if needed.
+// The caller must hold m.lock.
+func (m *Manager) hydrateDB(id thread.ID) (*DB, error) {
+	if db, ok := m.dbs[id]; ok {
+		return db, nil
+	}
+	if _, ok := m.unloaded[id]; !ok {
+		return nil, ErrDBNotFound
+	}
+	opts, err := getDBOptions(id, m.opts, "")
+	if err != nil {
+		return nil, err
+	}
+	db, err := newDB(m.network, id, opts)
+	if err != nil {
+		return nil, fmt.Errorf("hydrating db %s: %w", id, err)
+	}
+	if err := m.indexDB(id, db); err != nil {
+		return nil, err
+	}
+	m.watch(db)
+	delete(m.unloaded, id)
+	m.dbs[id] = db
+	m.emit(ManagerEvent{Type: EventDBOpened, DB: id})
+	return db, nil
+}
+
+// dbIDs returns the IDs of all dbs, hydrated or not.
+func (m *Manager) dbIDs() []thread.ID {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+	ids := make([]thread.ID, 0, len(m.dbs)+len(m.unloaded))
+	for id := range m.dbs {
+		ids = append(ids, id)
+	}
+	for id := range m.unloaded {
+		ids = append(ids, id)
+	}
+	return ids
+}
+
+// loadDBs hydrates all dbs and returns them. They're released with
+// releaseDBs.
+// Dbs that fail to hydrate are logged and skipped.
+func (m *Manager) loadDBs() map[thread.ID]*DB {
+	m.lockForHydration(thread.Undef)
+	defer m.lock.Unlock()
+	for id := range m.unloaded {
+		if _, err := m.hydrateDB(id); err != nil {
+			log.Errorf("error loading db %s: %v", id, err)
+		}
+	}
+	dbs := make(map[thread.ID]*DB, len(m.dbs))
+	for id, db := range m.dbs {
+		m.acquireDB(id)
+		dbs[id] = db
+	}
+	return dbs
+}
+
+// releaseDBs releases dbs returned by the manager, see ReleaseDB.
+func (m *Manager) releaseDBs(dbs map[thread.ID]*DB) {
+	for id := range dbs {
+		m.ReleaseDB(id)
+	}
+}
+
+// lazyDB is connected to the thread of a db that isn't hydrated, and hydrates
+// it to handle records received from the net.
+type lazyDB struct {
+	m  *Manager
+	id thread.ID
+}
+
+var _ app.App = (*lazyDB)(nil)
+
+func (l *lazyDB) ValidateNetRecordBody(ctx context.Context, body format.Node, identity thread.PubKey) error {
+	db, err := l.m.getDB(l.id)
+	if err != nil {
+		return err
+	}
+	defer l.m.ReleaseDB(l.id)
+	return db.ValidateNetRecordBody(ctx, body, identity)
+}
+
+func (l *lazyDB) HandleNetRecord(ctx context.Context, rec net.ThreadRecord, key thread.Key) error {
+	db, err := l.m.getDB(l.id)
+	if err != nil {
+		return err
+	}
+	defer l.m.ReleaseDB(l.id)
+	return db.HandleNetRecord(ctx, rec, key)
+}
+
+// deleteThreadNamespace deletes the keys of a db from the shared datastore,
+// including its index entry, and the directory of its datastore if it's
+// isolated.
 func (m *Manager) deleteThreadNamespace(id thread.ID) error {
+	if err := os.RemoveAll(isolatedDBPath(m.opts.RepoPath, id)); err != nil {
+		return err
+	}
+	if err := m.opts.Datastore.Delete(dsDBIndex.ChildString(id.String())); err != nil {
+		return err
+	}
 	pre := dsManagerBaseKey.ChildString(id.String())
 	q := query.Query{Prefix: pre.String(), KeysOnly: true}
 	results, err := m.opts.Datastore.Query(q)
@@ -281,30 +612,71 @@ func (m *Manager) Net() net.Net {
 
 // Close all dbs.
 func (m *Manager) Close() error {
+	m.stopMaintenance()
+	m.stopJoins()
+	m.lockForHydration(thread.Undef)
+	defer m.lock.Unlock()
 	for _, s := range m.dbs {
 		if err := s.Close(); err != nil {
 			log.Error("error when closing manager datastore: %v", err)
 		}
 	}
+	m.bus.Discard()
 	return m.opts.Datastore.Close()
 }
 
 // getDBOptions copies the manager's base config,
-// wraps the datastore with an id prefix,
+// wraps the datastore with an id prefix, or leaves it to the db
+// if the db is isolated, see WithNewIsolatedDBs,
 // and merges specified collection configs with those from base
 func getDBOptions(id thread.ID, base *NewOptions, name string, collections ...CollectionConfig) (*NewOptions, error) {
 	if err := id.Validate(); err != nil {
 		return nil, err
 	}
-	return &NewOptions{
-		Name:     name,
-		RepoPath: base.RepoPath,
-		Datastore: wrapTxnDatastore(base.Datastore, kt.PrefixTransform{
+	isolated, err := isolatedDB(base, id)
+	if err != nil {
+		return nil, err
+	}
+	repoPath := base.RepoPath
+	var store ds.TxnDatastore
+	if isolated {
+		// The db creates, and closes, its own datastore in its directory
+		repoPath = isolatedDBPath(base.RepoPath, id)
+	} else {
+		store = wrapTxnDatastore(base.Datastore, kt.PrefixTransform{
 			Prefix: dsManagerBaseKey.ChildString(id.String()),
-		}),
-		Collections: append(base.Collections, collections...),
-		EventCodec:  base.EventCodec,
-		LowMem:      base.LowMem,
-		Debug:       base.Debug,
+		})
+	}
+	return &NewOptions{
+		Name:           name,
+		RepoPath:       repoPath,
+		Datastore:      store,
+		Collections:    append(base.Collections, collections...),
+		EventCodec:     base.EventCodec,
+		EventCodecName: base.EventCodecName,
+		LowMem:         base.LowMem,
+		Debug:          base.Debug,
+
+		KeyEncryptionKey: base.KeyEncryptionKey,
+
+		ValidationMetrics: base.ValidationMetrics,
+		ValidationHandler: base.ValidationHandler,
+		RedactRejected:    base.RedactRejected,
+		Tombstones:        base.Tombstones,
+
+		MaxBatchEntries:  base.MaxBatchEntries,
+		MaxBatchBytes:    base.MaxBatchBytes,
+		StrictBatchLimit: base.StrictBatchLimit,
+		ThroughputWindow: base.ThroughputWindow,
+
+		ExpirySweepInterval: base.ExpirySweepInterval,
+
+		ActionHistory: base.ActionHistory,
+
+		MaxQueryResults: base.MaxQueryResults,
+		MaxQueryScan:    base.MaxQueryScan,
+
+		ReadOnly: base.ReadOnly,
+		Quota:    base.Quota,
 	}, nil
 }
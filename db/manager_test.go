@@ -1,16 +1,19 @@
 package db
 
 import (
+	"bytes"
 	"context"
 	"crypto/rand"
 	"errors"
 	"io/ioutil"
 	"os"
+	"reflect"
 	"testing"
 	"time"
 
 	"github.com/libp2p/go-libp2p-core/crypto"
 	"github.com/textileio/go-threads/common"
+	"github.com/textileio/go-threads/core/app"
 	lstore "github.com/textileio/go-threads/core/logstore"
 	"github.com/textileio/go-threads/core/thread"
 	"github.com/textileio/go-threads/util"
@@ -204,6 +207,974 @@ func TestManager_GetDB(t *testing.T) {
 	})
 }
 
+func TestManager_ListDBsPage(t *testing.T) {
+	t.Parallel()
+	ctx := context.Background()
+	man, clean := createTestManager(t)
+	defer clean()
+
+	sk, _, err := crypto.GenerateEd25519Key(rand.Reader)
+	checkErr(t, err)
+	identity := thread.NewLibp2pIdentity(sk)
+	tok, err := man.GetToken(ctx, identity)
+	checkErr(t, err)
+	_, err = man.NewDB(ctx, thread.NewIDV1(thread.Raw, 32), WithNewManagedName("app-a"), WithNewManagedToken(tok))
+	checkErr(t, err)
+	_, err = man.NewDB(ctx, thread.NewIDV1(thread.Raw, 32), WithNewManagedName("app-b"))
+	checkErr(t, err)
+	_, err = man.NewDB(ctx, thread.NewIDV1(thread.Raw, 32), WithNewManagedName("other"))
+	checkErr(t, err)
+
+	page, cursor, err := man.ListDBsPage(ctx, WithListDBsLimit(2))
+	checkErr(t, err)
+	if len(page) != 2 || cursor == "" {
+		t.Fatalf("expected a first page of 2 dbs, got %d", len(page))
+	}
+	next, cursor, err := man.ListDBsPage(ctx, WithListDBsLimit(2), WithListDBsCursor(cursor))
+	checkErr(t, err)
+	if len(next) != 1 || cursor != "" {
+		t.Fatalf("expected a last page of 1 db, got %d", len(next))
+	}
+	all := append(page, next...)
+	for i := 1; i < len(all); i++ {
+		if all[i-1].ID.String() >= all[i].ID.String() {
+			t.Fatal("dbs should be listed by ID")
+		}
+	}
+
+	named, _, err := man.ListDBsPage(ctx, WithListDBsNamePrefix("app-"))
+	checkErr(t, err)
+	if len(named) != 2 {
+		t.Fatalf("expected 2 dbs with name prefix, got %d", len(named))
+	}
+	owned, _, err := man.ListDBsPage(ctx, WithListDBsOwner(identity.GetPublic()))
+	checkErr(t, err)
+	if len(owned) != 1 || owned[0].Name != "app-a" {
+		t.Fatalf("expected the db of the owner, got %v", owned)
+	}
+
+	var first, last time.Time
+	for _, d := range all {
+		if d.Created.IsZero() {
+			t.Fatalf("db %s should have a creation time", d.Name)
+		}
+		if first.IsZero() || d.Created.Before(first) {
+			first = d.Created
+		}
+		if d.Created.After(last) {
+			last = d.Created
+		}
+	}
+	after, _, err := man.ListDBsPage(ctx, WithListDBsCreatedAfter(first))
+	checkErr(t, err)
+	before, _, err := man.ListDBsPage(ctx, WithListDBsCreatedBefore(last))
+	checkErr(t, err)
+	if len(after) != 2 || len(before) != 2 {
+		t.Fatalf("expected 2 dbs created after and before, got %d and %d", len(after), len(before))
+	}
+}
+
+func TestManager_DBMetadata(t *testing.T) {
+	t.Parallel()
+	ctx := context.Background()
+	dir, err := ioutil.TempDir("", "")
+	checkErr(t, err)
+	defer func() {
+		_ = os.RemoveAll(dir)
+	}()
+	n, err := common.DefaultNetwork(dir, common.WithNetDebug(true), common.WithNetHostAddr(util.FreeLocalAddr()))
+	checkErr(t, err)
+	man, err := NewManager(n, WithNewRepoPath(dir), WithNewDebug(true))
+	checkErr(t, err)
+
+	id := thread.NewIDV1(thread.Raw, 32)
+	labels := map[string]string{"env": "prod"}
+	_, err = man.NewDB(ctx, id, WithNewManagedName("orders"), WithNewManagedDescription("Customer orders"), WithNewManagedLabels(labels))
+	checkErr(t, err)
+	_, err = man.NewDB(ctx, thread.NewIDV1(thread.Raw, 32))
+	checkErr(t, err)
+	_, err = man.NewDB(ctx, thread.NewIDV1(thread.Raw, 32))
+	checkErr(t, err)
+
+	md, err := man.GetDBMetadata(ctx, id)
+	checkErr(t, err)
+	if md.Name != "orders" || md.Description != "Customer orders" || !reflect.DeepEqual(md.Labels, labels) {
+		t.Fatalf("unexpected db metadata %+v", md)
+	}
+	if _, err := man.GetDBByName(ctx, "unnamed"); !errors.Is(err, ErrDBNameAmbiguous) {
+		t.Fatalf("expected ambiguous name error, got %v", err)
+	}
+	if _, err := man.GetDBByName(ctx, "missing"); !errors.Is(err, ErrDBNotFound) {
+		t.Fatalf("expected db not found error, got %v", err)
+	}
+	if err := man.UpdateDBMetadata(ctx, id, DBMetadata{Name: "bad name"}); !errors.Is(err, ErrInvalidName) {
+		t.Fatalf("expected invalid name error, got %v", err)
+	}
+	checkErr(t, man.UpdateDBMetadata(ctx, id, DBMetadata{Name: "archive", Description: "Old orders"}))
+
+	checkErr(t, man.Close())
+	checkErr(t, n.Close())
+	n, err = common.DefaultNetwork(dir, common.WithNetDebug(true), common.WithNetHostAddr(util.FreeLocalAddr()))
+	checkErr(t, err)
+	man, err = NewManager(n, WithNewRepoPath(dir), WithNewDebug(true))
+	checkErr(t, err)
+	defer func() {
+		checkErr(t, man.Close())
+		checkErr(t, n.Close())
+	}()
+
+	db, err := man.GetDBByName(ctx, "archive")
+	checkErr(t, err)
+	md = db.getMetadata()
+	if md.Description != "Old orders" || md.Labels != nil {
+		t.Fatalf("unexpected db metadata after restart %+v", md)
+	}
+}
+
+func TestManager_LazyDBs(t *testing.T) {
+	t.Parallel()
+	ctx := context.Background()
+	dir, err := ioutil.TempDir("", "")
+	checkErr(t, err)
+	defer func() {
+		_ = os.RemoveAll(dir)
+	}()
+	n, err := common.DefaultNetwork(dir, common.WithNetDebug(true), common.WithNetHostAddr(util.FreeLocalAddr()))
+	checkErr(t, err)
+	man, err := NewManager(n, WithNewRepoPath(dir), WithNewDebug(true))
+	checkErr(t, err)
+
+	id1, id2 := thread.NewIDV1(thread.Raw, 32), thread.NewIDV1(thread.Raw, 32)
+	db, err := man.NewDB(ctx, id1)
+	checkErr(t, err)
+	_, err = man.NewDB(ctx, id2)
+	checkErr(t, err)
+	collection, err := db.NewCollection(CollectionConfig{Name: "Person", Schema: util.SchemaFromSchemaString(jsonSchema)})
+	checkErr(t, err)
+	_, err = collection.Create([]byte(`{"_id": "", "name": "foo", "age": 21}`))
+	checkErr(t, err)
+	checkErr(t, man.Close())
+	checkErr(t, n.Close())
+
+	n, err = common.DefaultNetwork(dir, common.WithNetDebug(true), common.WithNetHostAddr(util.FreeLocalAddr()))
+	checkErr(t, err)
+	man, err = NewManager(n, WithNewRepoPath(dir), WithNewDebug(true), WithNewLazyDBs(true))
+	checkErr(t, err)
+	defer func() {
+		checkErr(t, man.Close())
+		checkErr(t, n.Close())
+	}()
+	if len(man.dbs) != 0 || len(man.unloaded) != 2 {
+		t.Fatalf("expected 2 unloaded dbs, got %d hydrated and %d unloaded", len(man.dbs), len(man.unloaded))
+	}
+	if _, err := man.NewDB(ctx, id1); !errors.Is(err, ErrDBExists) {
+		t.Fatalf("expected db exists error, got %v", err)
+	}
+
+	db, err = man.GetDB(ctx, id1)
+	checkErr(t, err)
+	if len(man.dbs) != 1 || len(man.unloaded) != 1 {
+		t.Fatal("only the requested db should be hydrated")
+	}
+	collection = db.GetCollection("Person")
+	if collection == nil {
+		t.Fatal("collection was not hydrated")
+	}
+	res, err := collection.Find(&Query{})
+	checkErr(t, err)
+	if len(res) != 1 {
+		t.Fatalf("expected 1 instance, got %d", len(res))
+	}
+
+	page, _, err := man.ListDBsPage(ctx)
+	checkErr(t, err)
+	if len(page) != 2 || len(man.unloaded) != 1 {
+		t.Fatal("listing db pages shouldn't hydrate indexed dbs")
+	}
+	// Dbs stored before the db index are hydrated to be indexed
+	checkErr(t, man.opts.Datastore.Delete(dsDBIndex.ChildString(id2.String())))
+	page, _, err = man.ListDBsPage(ctx)
+	checkErr(t, err)
+	if len(page) != 2 || len(man.unloaded) != 0 {
+		t.Fatal("listing db pages should hydrate dbs that aren't indexed")
+	}
+
+	dbs, err := man.ListDBs(ctx)
+	checkErr(t, err)
+	if len(dbs) != 2 || len(man.unloaded) != 0 {
+		t.Fatal("listing dbs should hydrate them")
+	}
+}
+
+func TestManager_CloneDB(t *testing.T) {
+	t.Parallel()
+	ctx := context.Background()
+	man, clean := createTestManager(t)
+	defer clean()
+
+	srcID := thread.NewIDV1(thread.Raw, 32)
+	src, err := man.NewDB(ctx, srcID)
+	checkErr(t, err)
+	// Created before the collection it references, so the clone has to reorder them
+	_, err = src.NewCollection(CollectionConfig{
+		Name:       "Person",
+		Schema:     util.SchemaFromInstance(&Person{}, false),
+		IDStrategy: IDSequential,
+	})
+	checkErr(t, err)
+	_, err = src.NewCollection(CollectionConfig{
+		Name:       "Account",
+		Schema:     util.SchemaFromInstance(&Session{}, false),
+		References: []Reference{{Path: "User", Collection: "Person"}},
+	})
+	checkErr(t, err)
+	_, err = src.NewCollection(CollectionConfig{
+		Name:            "Secret",
+		Schema:          util.SchemaFromInstance(&Person{}, false),
+		EncryptedFields: []string{"Name"},
+	})
+	checkErr(t, err)
+	pids, err := src.GetCollection("Person").CreateMany([][]byte{
+		util.JSONFromInstance(Person{Name: "Alice", Age: 30}),
+		util.JSONFromInstance(Person{Name: "Bob", Age: 40}),
+	})
+	checkErr(t, err)
+	sid, err := src.GetCollection("Account").Create(util.JSONFromInstance(Session{User: pids[0].String()}))
+	checkErr(t, err)
+	secretID, err := src.GetCollection("Secret").Create(util.JSONFromInstance(Person{Name: "hidden"}))
+	checkErr(t, err)
+
+	dst, err := man.CloneDB(ctx, srcID, WithNewManagedName("clone"))
+	checkErr(t, err)
+	dstInfo, err := dst.GetDBInfo()
+	checkErr(t, err)
+	srcInfo, err := src.GetDBInfo()
+	checkErr(t, err)
+	if dstInfo.Name != "clone" || dstInfo.Key.String() == srcInfo.Key.String() {
+		t.Fatalf("expected clone to be named and have new keys, got %+v", dstInfo)
+	}
+	if _, err := man.CloneDB(ctx, thread.NewIDV1(thread.Raw, 32)); !errors.Is(err, lstore.ErrThreadNotFound) {
+		t.Fatalf("expected thread not found error, got %v", err)
+	}
+
+	persons := dst.GetCollection("Person")
+	if persons == nil {
+		t.Fatal("expected cloned collection")
+	}
+	for i, name := range []string{"Alice", "Bob"} {
+		res, err := persons.FindByID(pids[i])
+		checkErr(t, err)
+		p := &Person{}
+		util.InstanceFromJSON(res, p)
+		if p.Name != name {
+			t.Fatalf("expected cloned instance %s, got %s", name, p.Name)
+		}
+	}
+	if ok, err := dst.GetCollection("Account").Has(sid); err != nil || !ok {
+		t.Fatalf("expected cloned referencing instance, got %v %v", ok, err)
+	}
+	res, err := dst.GetCollection("Secret").FindByID(secretID)
+	checkErr(t, err)
+	secret := &Person{}
+	util.InstanceFromJSON(res, secret)
+	if secret.Name != "hidden" {
+		t.Fatalf("expected decrypted cloned field, got %s", secret.Name)
+	}
+	if reflect.DeepEqual(dst.GetCollection("Secret").EncryptionKey(), src.GetCollection("Secret").EncryptionKey()) {
+		t.Fatal("expected clone to have a new encryption key")
+	}
+
+	// The ID sequence is cloned, and writes don't affect the source
+	id, err := persons.Create(util.JSONFromInstance(Person{Name: "Carol"}))
+	checkErr(t, err)
+	if id <= pids[1] {
+		t.Fatalf("expected ID after %s, got %s", pids[1], id)
+	}
+	if ok, err := src.GetCollection("Person").Has(id); err != nil || ok {
+		t.Fatalf("expected source not to have cloned write, got %v %v", ok, err)
+	}
+}
+
+func TestManager_ExportImportDB(t *testing.T) {
+	t.Parallel()
+	ctx := context.Background()
+	man, clean := createTestManager(t)
+	defer clean()
+	other, cleanOther := createTestManager(t)
+	defer cleanOther()
+
+	id := thread.NewIDV1(thread.Raw, 32)
+	src, err := man.NewDB(ctx, id, WithNewManagedName("orders"), WithNewManagedLabels(map[string]string{"env": "prod"}))
+	checkErr(t, err)
+	_, err = src.NewCollection(CollectionConfig{
+		Name:            "Person",
+		Schema:          util.SchemaFromInstance(&Person{}, false),
+		Indexes:         []Index{{Path: "Age"}},
+		EncryptedFields: []string{"Name"},
+		IDStrategy:      IDSequential,
+	})
+	checkErr(t, err)
+	_, err = src.NewCollection(CollectionConfig{
+		Name:       "Account",
+		Schema:     util.SchemaFromInstance(&Session{}, false),
+		References: []Reference{{Path: "User", Collection: "Person"}},
+	})
+	checkErr(t, err)
+	pid, err := src.GetCollection("Person").Create(util.JSONFromInstance(Person{Name: "Alice", Age: 30}))
+	checkErr(t, err)
+	sid, err := src.GetCollection("Account").Create(util.JSONFromInstance(Session{User: pid.String()}))
+	checkErr(t, err)
+
+	var buf bytes.Buffer
+	checkErr(t, man.ExportDB(ctx, id, &buf, WithExportThreadKey(true)))
+	archive := buf.Bytes()
+	// Writes after the export aren't archived
+	_, err = src.GetCollection("Person").Create(util.JSONFromInstance(Person{Name: "Bob"}))
+	checkErr(t, err)
+
+	if _, err := other.ImportDB(ctx, bytes.NewReader([]byte(`{"version":0}`))); !errors.Is(err, ErrInvalidArchive) {
+		t.Fatalf("expected invalid archive error, got %v", err)
+	}
+	if _, err := other.ImportDB(ctx, bytes.NewReader(archive[:len(archive)-10])); !errors.Is(err, ErrInvalidArchive) {
+		t.Fatalf("expected invalid archive error, got %v", err)
+	}
+	if _, err := other.GetDB(ctx, id); err == nil {
+		t.Fatal("expected partial restore to be deleted")
+	}
+	dst, err := other.ImportDB(ctx, bytes.NewReader(archive))
+	checkErr(t, err)
+	srcInfo, err := src.GetDBInfo()
+	checkErr(t, err)
+	dstInfo, err := dst.GetDBInfo()
+	checkErr(t, err)
+	if dstInfo.Name != "orders" || dstInfo.Key.String() != srcInfo.Key.String() {
+		t.Fatalf("expected restored name and key, got %+v", dstInfo)
+	}
+	md, err := other.GetDBMetadata(ctx, id)
+	checkErr(t, err)
+	if md.Labels["env"] != "prod" {
+		t.Fatalf("expected restored labels, got %v", md.Labels)
+	}
+
+	persons := dst.GetCollection("Person")
+	res, err := persons.Find(Where("Age").Eq(float64(30)))
+	checkErr(t, err)
+	if len(res) != 1 {
+		t.Fatalf("expected 1 restored instance, got %d", len(res))
+	}
+	p := &Person{}
+	util.InstanceFromJSON(res[0], p)
+	if p.ID != pid || p.Name != "Alice" {
+		t.Fatalf("unexpected restored instance %+v", p)
+	}
+	if ok, err := dst.GetCollection("Account").Has(sid); err != nil || !ok {
+		t.Fatalf("expected restored referencing instance, got %v %v", ok, err)
+	}
+	if n, err := persons.Count(nil); err != nil || n != 1 {
+		t.Fatalf("expected 1 restored person, got %d %v", n, err)
+	}
+	next, err := persons.Create(util.JSONFromInstance(Person{Name: "Carol"}))
+	checkErr(t, err)
+	if next <= pid {
+		t.Fatalf("expected ID after %s, got %s", pid, next)
+	}
+
+	// Without the thread key, the restored db has new keys
+	buf.Reset()
+	checkErr(t, man.ExportDB(ctx, id, &buf))
+	checkErr(t, man.DeleteDB(ctx, id))
+	dst, err = man.ImportDB(ctx, &buf)
+	checkErr(t, err)
+	dstInfo, err = dst.GetDBInfo()
+	checkErr(t, err)
+	if dstInfo.Key.String() == srcInfo.Key.String() {
+		t.Fatal("expected restored db to have new keys")
+	}
+	if n, err := dst.GetCollection("Person").Count(nil); err != nil || n != 2 {
+		t.Fatalf("expected 2 restored persons, got %d %v", n, err)
+	}
+}
+
+func TestManager_Maintenance(t *testing.T) {
+	t.Parallel()
+	ctx := context.Background()
+
+	t.Run("Window", func(t *testing.T) {
+		t.Parallel()
+		at := func(hour int) time.Time {
+			return time.Date(2020, 1, 1, hour, 30, 0, 0, time.Local)
+		}
+		for _, w := range []struct {
+			start, end time.Duration
+			hour       int
+			open       bool
+		}{
+			{0, 0, 12, true},
+			{2 * time.Hour, 5 * time.Hour, 3, true},
+			{2 * time.Hour, 5 * time.Hour, 5, false},
+			{22 * time.Hour, 4 * time.Hour, 23, true},
+			{22 * time.Hour, 4 * time.Hour, 1, true},
+			{22 * time.Hour, 4 * time.Hour, 12, false},
+		} {
+			if open := inMaintenanceWindow(at(w.hour), w.start, w.end); open != w.open {
+				t.Fatalf("expected window %s-%s at %d:30 to be open %v", w.start, w.end, w.hour, w.open)
+			}
+		}
+	})
+
+	// addStaleIndexValue adds a value of a dropped index to a collection,
+	// and returns a function reporting whether it still exists.
+	addStaleIndexValue := func(t *testing.T, man *Manager) func() bool {
+		d, err := man.NewDB(ctx, thread.NewIDV1(thread.Raw, 32))
+		checkErr(t, err)
+		c, err := d.NewCollection(CollectionConfig{
+			Name:    "Person",
+			Schema:  util.SchemaFromInstance(&Person{}, false),
+			Indexes: []Index{{Path: "Name"}},
+		})
+		checkErr(t, err)
+		_, err = c.Create(util.JSONFromInstance(Person{Name: "Foo"}))
+		checkErr(t, err)
+		key := indexPrefix.Child(c.baseKey()).ChildString("Dropped").ChildString("Foo")
+		v, err := DefaultEncode(keyList{})
+		checkErr(t, err)
+		checkErr(t, d.datastore.Put(key, v))
+		return func() bool {
+			ok, err := d.datastore.Has(key)
+			checkErr(t, err)
+			return ok
+		}
+	}
+
+	t.Run("OnDemand", func(t *testing.T) {
+		t.Parallel()
+		man, clean := createTestManager(t)
+		defer clean()
+		stale := addStaleIndexValue(t, man)
+		report, err := man.RunMaintenance(ctx)
+		checkErr(t, err)
+		if report.DBs != 1 || report.Collections != 1 || !report.GC || report.Started.IsZero() {
+			t.Fatalf("unexpected maintenance report %+v", report)
+		}
+		if stale() {
+			t.Fatal("expected stale index value to be removed")
+		}
+	})
+
+	t.Run("Scheduled", func(t *testing.T) {
+		t.Parallel()
+		now := time.Now()
+		start := time.Duration(now.Hour()) * time.Hour
+		closed, clean := createTestManager(t, WithNewMaintenance(10*time.Millisecond), WithNewMaintenanceWindow(start+2*time.Hour, start+3*time.Hour))
+		defer clean()
+		open, cleanOpen := createTestManager(t, WithNewMaintenance(10*time.Millisecond))
+		defer cleanOpen()
+		outside, inside := addStaleIndexValue(t, closed), addStaleIndexValue(t, open)
+		deadline := time.Now().Add(5 * time.Second)
+		for inside() {
+			if time.Now().After(deadline) {
+				t.Fatal("expected scheduled maintenance to remove stale index value")
+			}
+			time.Sleep(10 * time.Millisecond)
+		}
+		if !outside() && time.Now().Hour() == now.Hour() {
+			t.Fatal("expected no maintenance outside of the window")
+		}
+	})
+}
+
+func TestManager_ScopedDBs(t *testing.T) {
+	t.Parallel()
+	ctx := context.Background()
+	man, clean := createTestManager(t, WithNewScopedDBs(true))
+	defer clean()
+
+	newIdentity := func() (thread.Token, thread.PubKey) {
+		sk, _, err := crypto.GenerateEd25519Key(rand.Reader)
+		checkErr(t, err)
+		identity := thread.NewLibp2pIdentity(sk)
+		tok, err := man.GetToken(ctx, identity)
+		checkErr(t, err)
+		return tok, identity.GetPublic()
+	}
+	owner, ownerKey := newIdentity()
+	other, otherKey := newIdentity()
+
+	id := thread.NewIDV1(thread.Raw, 32)
+	_, err := man.NewDB(ctx, id, WithNewManagedToken(owner))
+	checkErr(t, err)
+	open := thread.NewIDV1(thread.Raw, 32)
+	_, err = man.NewDB(ctx, open)
+	checkErr(t, err)
+
+	_, err = man.GetDB(ctx, id, WithManagedToken(owner))
+	checkErr(t, err)
+	for _, tok := range []thread.Token{other, ""} {
+		if _, err := man.GetDB(ctx, id, WithManagedToken(tok)); !errors.Is(err, ErrDBNotFound) {
+			t.Fatalf("expected db not found error, got %v", err)
+		}
+	}
+	_, err = man.GetDB(ctx, open, WithManagedToken(other))
+	checkErr(t, err)
+	dbs, err := man.ListDBs(ctx, WithManagedToken(other))
+	checkErr(t, err)
+	if _, ok := dbs[id]; ok || len(dbs) != 1 {
+		t.Fatalf("expected only the unscoped db to be listed, got %d dbs", len(dbs))
+	}
+	page, _, err := man.ListDBsPage(ctx, WithListDBsToken(owner))
+	checkErr(t, err)
+	if len(page) != 2 {
+		t.Fatalf("expected owner to list 2 dbs, got %d", len(page))
+	}
+	// Tokens of the owner identity issued by another host are rejected
+	issuer, _, err := crypto.GenerateEd25519Key(rand.Reader)
+	checkErr(t, err)
+	forged, err := thread.NewToken(issuer, ownerKey)
+	checkErr(t, err)
+	if _, _, err := man.ListDBsPage(ctx, WithListDBsToken(forged)); !errors.Is(err, thread.ErrInvalidToken) {
+		t.Fatalf("expected invalid token error, got %v", err)
+	}
+	if err := man.DeleteDB(ctx, id, WithManagedToken(other)); !errors.Is(err, ErrDBNotFound) {
+		t.Fatalf("expected db not found error, got %v", err)
+	}
+	if err := man.AddDBDelegate(ctx, open, otherKey, WithManagedToken(other)); !errors.Is(err, ErrNotDBOwner) {
+		t.Fatalf("expected not owner error, got %v", err)
+	}
+
+	checkErr(t, man.AddDBDelegate(ctx, id, otherKey, WithManagedToken(owner)))
+	_, err = man.GetDB(ctx, id, WithManagedToken(other))
+	checkErr(t, err)
+	if page, _, err = man.ListDBsPage(ctx, WithListDBsToken(other)); err != nil || len(page) != 2 {
+		t.Fatalf("expected delegate to list 2 dbs, got %d: %v", len(page), err)
+	}
+	if err := man.AddDBDelegate(ctx, id, otherKey, WithManagedToken(other)); !errors.Is(err, ErrNotDBOwner) {
+		t.Fatalf("expected not owner error, got %v", err)
+	}
+	delegates, err := man.ListDBDelegates(ctx, id, WithManagedToken(owner))
+	checkErr(t, err)
+	if len(delegates) != 1 || !delegates[0].Equals(otherKey) {
+		t.Fatalf("unexpected delegates %v", delegates)
+	}
+	checkErr(t, man.RemoveDBDelegate(ctx, id, otherKey, WithManagedToken(owner)))
+	if _, err := man.GetDB(ctx, id, WithManagedToken(other)); !errors.Is(err, ErrDBNotFound) {
+		t.Fatalf("expected db not found error after revocation, got %v", err)
+	}
+	if page, _, err = man.ListDBsPage(ctx, WithListDBsToken(other)); err != nil || len(page) != 1 {
+		t.Fatalf("expected revoked delegate to list 1 db, got %d: %v", len(page), err)
+	}
+	checkErr(t, man.DeleteDB(ctx, id, WithManagedToken(owner)))
+}
+
+func TestManager_Events(t *testing.T) {
+	t.Parallel()
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	dir, err := ioutil.TempDir("", "")
+	checkErr(t, err)
+	defer func() {
+		_ = os.RemoveAll(dir)
+	}()
+	n, err := common.DefaultNetwork(dir, common.WithNetDebug(true), common.WithNetHostAddr(util.FreeLocalAddr()))
+	checkErr(t, err)
+	man, err := NewManager(n, WithNewRepoPath(dir), WithNewDebug(true), WithNewLazyDBs(true), WithNewQuota(300))
+	checkErr(t, err)
+
+	expect := func(events <-chan ManagerEvent, typ ManagerEventType, id thread.ID) ManagerEvent {
+		select {
+		case e := <-events:
+			if e.Type != typ || !e.DB.Equals(id) || e.Time.IsZero() {
+				t.Fatalf("expected %s event of %s, got %s event of %s", typ, id, e.Type, e.DB)
+			}
+			return e
+		case <-time.After(5 * time.Second):
+			t.Fatalf("expected %s event", typ)
+		}
+		return ManagerEvent{}
+	}
+
+	events := man.Subscribe(ctx)
+	id := thread.NewIDV1(thread.Raw, 32)
+	d, err := man.NewDB(ctx, id)
+	checkErr(t, err)
+	expect(events, EventDBCreated, id)
+	c, err := d.NewCollection(CollectionConfig{
+		Name:   "Person",
+		Schema: util.SchemaFromInstance(&Person{}, false),
+	})
+	checkErr(t, err)
+	if e := expect(events, EventCollectionAdded, id); e.Collection != "Person" {
+		t.Fatalf("expected collection added event of Person, got %s", e.Collection)
+	}
+	for {
+		if _, err := c.Create(util.JSONFromInstance(Person{Name: "Foo"})); errors.Is(err, ErrQuotaExceeded) {
+			break
+		} else {
+			checkErr(t, err)
+		}
+	}
+	if e := expect(events, EventQuotaExceeded, id); e.Collection != "Person" || e.Quota == nil || e.Quota.Quota != 300 {
+		t.Fatalf("unexpected quota exceeded event %+v", e)
+	}
+	deleted := thread.NewIDV1(thread.Raw, 32)
+	_, err = man.NewDB(ctx, deleted)
+	checkErr(t, err)
+	expect(events, EventDBCreated, deleted)
+	checkErr(t, man.DeleteDB(ctx, deleted))
+	expect(events, EventDBDeleted, deleted)
+
+	checkErr(t, man.Close())
+	checkErr(t, n.Close())
+	if _, ok := <-events; ok {
+		t.Fatal("expected events to be closed with the manager")
+	}
+	n, err = common.DefaultNetwork(dir, common.WithNetDebug(true), common.WithNetHostAddr(util.FreeLocalAddr()))
+	checkErr(t, err)
+	man, err = NewManager(n, WithNewRepoPath(dir), WithNewDebug(true), WithNewLazyDBs(true))
+	checkErr(t, err)
+	defer func() {
+		checkErr(t, man.Close())
+		checkErr(t, n.Close())
+	}()
+	subCtx, subCancel := context.WithCancel(ctx)
+	events = man.Subscribe(subCtx)
+	_, err = man.GetDB(ctx, id)
+	checkErr(t, err)
+	expect(events, EventDBOpened, id)
+	subCancel()
+	for range events {
+	}
+}
+
+func TestManager_DeleteDBWithListeners(t *testing.T) {
+	t.Parallel()
+	ctx := context.Background()
+	man, clean := createTestManager(t)
+	defer clean()
+
+	id := thread.NewIDV1(thread.Raw, 32)
+	d, err := man.NewDB(ctx, id)
+	checkErr(t, err)
+	c, err := d.NewCollection(CollectionConfig{
+		Name:   "Person",
+		Schema: util.SchemaFromInstance(&Person{}, false),
+	})
+	checkErr(t, err)
+	l, err := d.Listen()
+	checkErr(t, err)
+	merged, err := d.Listen()
+	checkErr(t, err)
+	actions := merged.Channel()
+	_, err = c.Create(util.JSONFromInstance(Person{Name: "Foo"}))
+	checkErr(t, err)
+
+	deleted := make(chan error)
+	go func() {
+		deleted <- man.DeleteDB(ctx, id)
+	}()
+	select {
+	case err := <-deleted:
+		checkErr(t, err)
+	case <-time.After(10 * time.Second):
+		t.Fatal("expected db to be deleted with active listeners")
+	}
+	for range l.Channel() {
+	}
+	for range actions {
+	}
+	lErr, mergedErr := l.(ErrListener).Err(), merged.(ErrListener).Err()
+	if !errors.Is(lErr, ErrDBDeleted) || !errors.Is(mergedErr, ErrDBDeleted) {
+		t.Fatalf("expected listeners to be closed with db deleted error, got %v and %v", lErr, mergedErr)
+	}
+	l.Close()
+	if _, err := c.Create(util.JSONFromInstance(Person{Name: "Bar"})); !errors.Is(err, ErrDBDeleted) {
+		t.Fatalf("expected db deleted error, got %v", err)
+	}
+	if _, err := c.Find(nil); !errors.Is(err, ErrDBDeleted) {
+		t.Fatalf("expected db deleted error, got %v", err)
+	}
+	if _, err := d.Listen(); !errors.Is(err, ErrDBDeleted) {
+		t.Fatalf("expected db deleted error, got %v", err)
+	}
+
+	// Listeners closed by their owner report no error
+	d, err = man.NewDB(ctx, thread.NewIDV1(thread.Raw, 32))
+	checkErr(t, err)
+	l, err = d.Listen()
+	checkErr(t, err)
+	l.Close()
+	if err := l.(ErrListener).Err(); err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	checkErr(t, d.Close())
+	if err := d.ReadTxn(func(*DBTxn) error { return nil }); !errors.Is(err, ErrDBClosed) {
+		t.Fatalf("expected db closed error, got %v", err)
+	}
+}
+
+func TestManager_IsolatedDBs(t *testing.T) {
+	t.Parallel()
+	ctx := context.Background()
+	dir, err := ioutil.TempDir("", "")
+	checkErr(t, err)
+	defer func() {
+		_ = os.RemoveAll(dir)
+	}()
+	n, err := common.DefaultNetwork(dir, common.WithNetDebug(true), common.WithNetHostAddr(util.FreeLocalAddr()))
+	checkErr(t, err)
+	man, err := NewManager(n, WithNewRepoPath(dir), WithNewDebug(true))
+	checkErr(t, err)
+	shared := thread.NewIDV1(thread.Raw, 32)
+	_, err = man.NewDB(ctx, shared)
+	checkErr(t, err)
+	checkErr(t, man.Close())
+	checkErr(t, n.Close())
+
+	n, err = common.DefaultNetwork(dir, common.WithNetDebug(true), common.WithNetHostAddr(util.FreeLocalAddr()))
+	checkErr(t, err)
+	man, err = NewManager(n, WithNewRepoPath(dir), WithNewDebug(true), WithNewIsolatedDBs(true))
+	checkErr(t, err)
+	isolated := thread.NewIDV1(thread.Raw, 32)
+	db, err := man.NewDB(ctx, isolated)
+	checkErr(t, err)
+	collection, err := db.NewCollection(CollectionConfig{Name: "Person", Schema: util.SchemaFromSchemaString(jsonSchema)})
+	checkErr(t, err)
+	_, err = collection.Create([]byte(`{"_id": "", "name": "foo", "age": 21}`))
+	checkErr(t, err)
+	if _, err := os.Stat(isolatedDBPath(dir, isolated)); err != nil {
+		t.Fatalf("expected isolated db directory: %v", err)
+	}
+	if _, err := os.Stat(isolatedDBPath(dir, shared)); !os.IsNotExist(err) {
+		t.Fatal("existing db should stay in the shared datastore")
+	}
+	if ok, err := isolatedDB(man.opts, shared); err != nil || ok {
+		t.Fatalf("existing db should not be isolated, got %v %v", ok, err)
+	}
+	report, err := man.RunMaintenance(ctx)
+	checkErr(t, err)
+	if report.DBs != 2 || !report.GC {
+		t.Fatalf("unexpected maintenance report %+v", report)
+	}
+	checkErr(t, man.Close())
+	checkErr(t, n.Close())
+
+	// Isolated dbs are found without the option
+	n, err = common.DefaultNetwork(dir, common.WithNetDebug(true), common.WithNetHostAddr(util.FreeLocalAddr()))
+	checkErr(t, err)
+	man, err = NewManager(n, WithNewRepoPath(dir), WithNewDebug(true))
+	checkErr(t, err)
+	defer func() {
+		checkErr(t, man.Close())
+		checkErr(t, n.Close())
+	}()
+	dbs, err := man.ListDBs(ctx)
+	checkErr(t, err)
+	if len(dbs) != 2 {
+		t.Fatalf("expected 2 dbs, got %d", len(dbs))
+	}
+	res, err := dbs[isolated].GetCollection("Person").Find(&Query{})
+	checkErr(t, err)
+	if len(res) != 1 {
+		t.Fatalf("expected 1 instance, got %d", len(res))
+	}
+
+	checkErr(t, man.DeleteDB(ctx, isolated))
+	if _, err := os.Stat(isolatedDBPath(dir, isolated)); !os.IsNotExist(err) {
+		t.Fatal("expected isolated db directory to be removed")
+	}
+	if _, err := man.GetDB(ctx, shared); err != nil {
+		t.Fatalf("deleting an isolated db should not affect the others: %v", err)
+	}
+}
+
+func TestManager_Health(t *testing.T) {
+	t.Parallel()
+	ctx := context.Background()
+	dir, err := ioutil.TempDir("", "")
+	checkErr(t, err)
+	defer func() {
+		_ = os.RemoveAll(dir)
+	}()
+	n, err := common.DefaultNetwork(dir, common.WithNetDebug(true), common.WithNetHostAddr(util.FreeLocalAddr()))
+	checkErr(t, err)
+	man, err := NewManager(n, WithNewRepoPath(dir), WithNewDebug(true), WithNewLazyDBs(true))
+	checkErr(t, err)
+
+	report := man.Health()
+	if !report.Ready() || report.DBs != 0 || report.Time.IsZero() {
+		t.Fatalf("unexpected health report %+v", report)
+	}
+	d, err := man.NewDB(ctx, thread.NewIDV1(thread.Raw, 32))
+	checkErr(t, err)
+	c, err := d.NewCollection(CollectionConfig{Name: "Person", Schema: util.SchemaFromInstance(&Person{}, false)})
+	checkErr(t, err)
+	l, err := d.Listen()
+	checkErr(t, err)
+	defer l.Close()
+	_, err = c.Create(util.JSONFromInstance(Person{Name: "Foo"}))
+	checkErr(t, err)
+	report = man.Health()
+	if !report.Ready() || report.DBs != 1 || report.ListenerBacklog != 1 {
+		t.Fatalf("unexpected health report %+v", report)
+	}
+
+	checkErr(t, n.Close())
+	report = man.Health()
+	if report.Ready() || !errors.Is(report.Network, ErrNetworkUnavailable) {
+		t.Fatalf("expected unavailable network, got %+v", report)
+	}
+	checkErr(t, man.Close())
+	if report = man.Health(); report.Datastore == nil {
+		t.Fatal("expected datastore error after close")
+	}
+}
+
+func TestManager_MaxOpenDBs(t *testing.T) {
+	t.Parallel()
+	ctx := context.Background()
+	man, clean := createTestManager(t, WithNewMaxOpenDBs(2))
+	defer clean()
+
+	id1, id2, id3 := thread.NewIDV1(thread.Raw, 32), thread.NewIDV1(thread.Raw, 32), thread.NewIDV1(thread.Raw, 32)
+	d1, err := man.NewDB(ctx, id1)
+	checkErr(t, err)
+	c, err := d1.NewCollection(CollectionConfig{Name: "Person", Schema: util.SchemaFromInstance(&Person{}, false)})
+	checkErr(t, err)
+	_, err = c.Create(util.JSONFromInstance(Person{Name: "Foo"}))
+	checkErr(t, err)
+	d2, err := man.NewDB(ctx, id2)
+	checkErr(t, err)
+	l, err := d2.Listen()
+	checkErr(t, err)
+	defer l.Close()
+	man.ReleaseDB(id2)
+	_, err = man.NewDB(ctx, id3)
+	checkErr(t, err)
+
+	isOpen := func(id thread.ID) bool {
+		man.lock.Lock()
+		defer man.lock.Unlock()
+		_, ok := man.dbs[id]
+		return ok
+	}
+	// Dbs with unreleased handles aren't evicted
+	if !isOpen(id1) || !isOpen(id2) || !isOpen(id3) {
+		t.Fatal("expected dbs in use to stay open")
+	}
+	if _, err := c.Find(&Query{}); err != nil {
+		t.Fatalf("expected db in use to stay usable: %v", err)
+	}
+	man.ReleaseDB(id1)
+	if isOpen(id1) || !isOpen(id2) || !isOpen(id3) {
+		t.Fatal("expected the released db to be evicted")
+	}
+	if _, err := c.Find(&Query{}); !errors.Is(err, ErrDBEvicted) {
+		t.Fatalf("expected evicted db error, got %v", err)
+	}
+	man.ReleaseDB(id3)
+
+	// Dbs with listeners aren't idle
+	d1, err = man.GetDB(ctx, id1)
+	checkErr(t, err)
+	if !isOpen(id1) || !isOpen(id2) || isOpen(id3) {
+		t.Fatal("expected the least recently used idle db to be evicted")
+	}
+	res, err := d1.GetCollection("Person").Find(&Query{})
+	checkErr(t, err)
+	if len(res) != 1 {
+		t.Fatalf("expected 1 instance after reopening, got %d", len(res))
+	}
+	if _, err := man.GetDB(ctx, id3); err != nil {
+		t.Fatalf("expected evicted db to reopen: %v", err)
+	}
+	if !isOpen(id1) || !isOpen(id2) || !isOpen(id3) {
+		t.Fatal("expected dbs in use to stay open")
+	}
+	man.ReleaseDB(id1)
+	man.ReleaseDB(id3)
+
+	// Dbs that fail to be evicted stay open
+	man.lock.Lock()
+	network := man.network
+	man.network = connectFailingNet{Net: network}
+	man.opts.MaxOpenDBs = 1
+	done := make(chan map[thread.ID]*DB)
+	go func() {
+		done <- man.evictIdleDBs(thread.Undef)
+	}()
+	select {
+	case evicted := <-done:
+		if len(evicted) != 0 {
+			man.lock.Unlock()
+			t.Fatalf("expected no db to be evicted, got %d", len(evicted))
+		}
+	case <-time.After(5 * time.Second):
+		man.lock.Unlock()
+		t.Fatal("expected eviction to give up on dbs that fail to be evicted")
+	}
+	man.network = network
+	man.opts.MaxOpenDBs = 2
+	man.lock.Unlock()
+	if !isOpen(id2) || !isOpen(id3) {
+		t.Fatal("expected dbs that failed to be evicted to stay open")
+	}
+}
+
+// connectFailingNet is a network whose app connections fail.
+type connectFailingNet struct {
+	app.Net
+}
+
+func (n connectFailingNet) ConnectApp(app.App, thread.ID) (*app.Connector, error) {
+	return nil, errors.New("connect failed")
+}
+
+func TestManager_NewDBFromAddrAsync(t *testing.T) {
+	t.Parallel()
+	ctx := context.Background()
+	man1, clean1 := createTestManager(t)
+	defer clean1()
+	man2, clean2 := createTestManager(t)
+	defer clean2()
+
+	id := thread.NewIDV1(thread.Raw, 32)
+	cc := CollectionConfig{Name: "Person", Schema: util.SchemaFromInstance(&Person{}, false)}
+	d1, err := man1.NewDB(ctx, id, WithNewManagedCollections(cc))
+	checkErr(t, err)
+	for _, name := range []string{"Foo", "Bar"} {
+		_, err = d1.GetCollection("Person").Create(util.JSONFromInstance(Person{Name: name}))
+		checkErr(t, err)
+	}
+	info, err := d1.GetDBInfo()
+	checkErr(t, err)
+
+	join, err := man2.NewDBFromAddrAsync(info.Addrs[0], info.Key, WithNewManagedCollections(cc))
+	checkErr(t, err)
+	if _, err := man2.NewDBFromAddr(ctx, info.Addrs[0], info.Key); !errors.Is(err, ErrDBExists) {
+		t.Fatalf("expected db exists error while joining, got %v", err)
+	}
+	var last JoinProgress
+	for p := range join.Subscribe(ctx) {
+		last = p
+	}
+	// The logs of both peers are discovered
+	if last.State != JoinDone || last.Logs != 2 || last.Records != 2 || last.Collections != 1 {
+		t.Fatalf("unexpected join progress %+v", last)
+	}
+	if p, err := man2.JoinProgress(id); err != nil || p.State != JoinDone {
+		t.Fatalf("expected done join progress, got %+v %v", p, err)
+	}
+	d2, err := join.Wait(ctx)
+	checkErr(t, err)
+	res, err := d2.GetCollection("Person").Find(&Query{})
+	checkErr(t, err)
+	if len(res) != 2 {
+		t.Fatalf("expected 2 joined instances, got %d", len(res))
+	}
+	if _, err := man2.JoinProgress(thread.NewIDV1(thread.Raw, 32)); !errors.Is(err, ErrJoinNotFound) {
+		t.Fatalf("expected join not found error, got %v", err)
+	}
+}
+
 func TestManager_DeleteDB(t *testing.T) {
 	t.Parallel()
 	ctx := context.Background()
@@ -232,12 +1203,12 @@ func TestManager_DeleteDB(t *testing.T) {
 	}
 }
 
-func createTestManager(t *testing.T) (*Manager, func()) {
+func createTestManager(t *testing.T, opts ...NewOption) (*Manager, func()) {
 	dir, err := ioutil.TempDir("", "")
 	checkErr(t, err)
 	n, err := common.DefaultNetwork(dir, common.WithNetDebug(true), common.WithNetHostAddr(util.FreeLocalAddr()))
 	checkErr(t, err)
-	m, err := NewManager(n, WithNewRepoPath(dir), WithNewDebug(true))
+	m, err := NewManager(n, append([]NewOption{WithNewRepoPath(dir), WithNewDebug(true)}, opts...)...)
 	checkErr(t, err)
 	return m, func() {
 		if err := n.Close(); err != nil {
@@ -0,0 +1,90 @@
+package db
+
+import (
+	"errors"
+	"strings"
+
+	ds "github.com/textileio/go-datastore"
+	"github.com/textileio/go-datastore/query"
+)
+
+var (
+	// ErrMetaNotFound indicates a db metadata key isn't set.
+	ErrMetaNotFound = errors.New("db metadata key not found")
+	// ErrInvalidMetaKey indicates a db metadata key is empty, or isn't a valid
+	// datastore key name, e.g., contains "/".
+	ErrInvalidMetaKey = errors.New("invalid db metadata key")
+
+	dsMeta = dsPrefix.ChildString("meta")
+)
+
+// SetMeta sets the value of a db metadata key, e.g., an app setting.
+// Metadata is kept in the local datastore alongside the collections,
+// but it isn't part of the thread, so it doesn't replicate to peers, and
+// it can be set in read-only dbs.
+func (d *DB) SetMeta(key string, value []byte, opts ...Option) error {
+	if err := d.validMetaKey(key, false, opts); err != nil {
+		return err
+	}
+	return d.datastore.Put(dsMeta.ChildString(key), value)
+}
+
+// GetMeta returns the value of a db metadata key.
+// If the key isn't set, returns ErrMetaNotFound.
+func (d *DB) GetMeta(key string, opts ...Option) ([]byte, error) {
+	if err := d.validMetaKey(key, true, opts); err != nil {
+		return nil, err
+	}
+	v, err := d.datastore.Get(dsMeta.ChildString(key))
+	if errors.Is(err, ds.ErrNotFound) {
+		return nil, ErrMetaNotFound
+	}
+	return v, err
+}
+
+// DeleteMeta deletes a db metadata key. It doesn't fail if the key isn't set.
+func (d *DB) DeleteMeta(key string, opts ...Option) error {
+	if err := d.validMetaKey(key, false, opts); err != nil {
+		return err
+	}
+	return d.datastore.Delete(dsMeta.ChildString(key))
+}
+
+// ListMeta returns the db metadata keys and values.
+func (d *DB) ListMeta(opts ...Option) (map[string][]byte, error) {
+	args := &Options{}
+	for _, opt := range opts {
+		opt(args)
+	}
+	if err := d.connector.Validate(args.Token, true); err != nil {
+		return nil, err
+	}
+	res, err := d.datastore.Query(query.Query{Prefix: dsMeta.String()})
+	if err != nil {
+		return nil, err
+	}
+	defer res.Close()
+	meta := make(map[string][]byte)
+	for r := range res.Next() {
+		if r.Error != nil {
+			return nil, r.Error
+		}
+		meta[ds.RawKey(r.Key).Name()] = r.Value
+	}
+	return meta, nil
+}
+
+// validMetaKey validates a metadata key and the token of the operation.
+func (d *DB) validMetaKey(key string, read bool, opts []Option) error {
+	args := &Options{}
+	for _, opt := range opts {
+		opt(args)
+	}
+	if err := d.connector.Validate(args.Token, read); err != nil {
+		return err
+	}
+	if key == "" || key == "." || key == ".." || strings.Contains(key, "/") {
+		return ErrInvalidMetaKey
+	}
+	return nil
+}
@@ -0,0 +1,131 @@
+package db
+
+import (
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/textileio/go-threads/core/thread"
+)
+
+const metricsNamespace = "threads_db"
+
+// dbMetrics instruments a db with Prometheus metrics, labeled by db thread ID.
+type dbMetrics struct {
+	writes    *prometheus.CounterVec
+	queries   *prometheus.HistogramVec
+	conflicts *prometheus.CounterVec
+	dropped   prometheus.Counter
+	backlog   prometheus.GaugeFunc
+}
+
+func newDBMetrics(d *DB, id thread.ID) *dbMetrics {
+	labels := prometheus.Labels{"db": id.String()}
+	return &dbMetrics{
+		writes: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace:   metricsNamespace,
+			Name:        "writes_total",
+			Help:        "Instance writes applied to the db, local and from peers.",
+			ConstLabels: labels,
+		}, []string{"collection", "type"}),
+		queries: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Namespace:   metricsNamespace,
+			Name:        "query_duration_seconds",
+			Help:        "Latency of the queries of the db.",
+			ConstLabels: labels,
+			Buckets:     prometheus.ExponentialBuckets(0.0001, 4, 10),
+		}, []string{"collection"}),
+		conflicts: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace:   metricsNamespace,
+			Name:        "txn_conflicts_total",
+			Help:        "Transactions that failed with ErrConflict.",
+			ConstLabels: labels,
+		}, []string{"collection"}),
+		dropped: prometheus.NewCounter(prometheus.CounterOpts{
+			Namespace:   metricsNamespace,
+			Name:        "listener_dropped_total",
+			Help:        "Actions dropped because a listener channel was full.",
+			ConstLabels: labels,
+		}),
+		backlog: prometheus.NewGaugeFunc(prometheus.GaugeOpts{
+			Namespace:   metricsNamespace,
+			Name:        "listener_backlog",
+			Help:        "Actions waiting to be received by the listeners of the db.",
+			ConstLabels: labels,
+		}, func() float64 {
+			return float64(d.stateChangedNotifee.backlog())
+		}),
+	}
+}
+
+func (m *dbMetrics) collectors() []prometheus.Collector {
+	return []prometheus.Collector{m.writes, m.queries, m.conflicts, m.dropped, m.backlog}
+}
+
+// Describe implements prometheus.Collector.
+func (m *dbMetrics) Describe(ch chan<- *prometheus.Desc) {
+	for _, c := range m.collectors() {
+		c.Describe(ch)
+	}
+}
+
+// Collect implements prometheus.Collector.
+func (m *dbMetrics) Collect(ch chan<- prometheus.Metric) {
+	for _, c := range m.collectors() {
+		c.Collect(ch)
+	}
+}
+
+// actionTypeLabels are the type labels of written actions.
+var actionTypeLabels = map[ActionType]string{
+	ActionCreate: "create",
+	ActionSave:   "save",
+	ActionDelete: "delete",
+}
+
+// observeWrites counts the actions applied to the db.
+func (m *dbMetrics) observeWrites(actions []Action) {
+	for _, a := range actions {
+		m.writes.WithLabelValues(a.Collection, actionTypeLabels[a.Type]).Inc()
+	}
+}
+
+// observeQuery records the latency of a query of collection since start.
+func (m *dbMetrics) observeQuery(collection string, start time.Time) {
+	m.queries.WithLabelValues(collection).Observe(time.Since(start).Seconds())
+}
+
+// Collector returns a Prometheus collector of the metrics of the db, e.g., to
+// register it with prometheus.MustRegister. Metrics are labeled with the db
+// thread ID. Dbs of a manager are collected with Manager.Collector.
+func (d *DB) Collector() prometheus.Collector {
+	return d.metrics
+}
+
+// managerCollector collects the metrics of the dbs of a manager.
+type managerCollector struct {
+	m *Manager
+}
+
+// Describe implements prometheus.Collector. Dbs are created and deleted while
+// the collector is registered, so it's unchecked and doesn't describe them.
+func (c managerCollector) Describe(chan<- *prometheus.Desc) {}
+
+// Collect implements prometheus.Collector.
+func (c managerCollector) Collect(ch chan<- prometheus.Metric) {
+	c.m.lock.Lock()
+	dbs := make([]*DB, 0, len(c.m.dbs))
+	for _, d := range c.m.dbs {
+		dbs = append(dbs, d)
+	}
+	c.m.lock.Unlock()
+	for _, d := range dbs {
+		d.metrics.Collect(ch)
+	}
+}
+
+// Collector returns a Prometheus collector of the metrics of the managed dbs,
+// see DB.Collector. Dbs that aren't hydrated yet, see WithNewLazyDBs, aren't
+// collected until they're used.
+func (m *Manager) Collector() prometheus.Collector {
+	return managerCollector{m: m}
+}
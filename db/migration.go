@@ -0,0 +1,294 @@
+package db
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+	"strconv"
+
+	ds "github.com/textileio/go-datastore"
+	"github.com/textileio/go-datastore/query"
+	core "github.com/textileio/go-threads/core/db"
+)
+
+const migrationBatchSize = 100
+
+var (
+	// ErrInvalidMigration indicates that a collection update has invalid
+	// migrations, or lowers the schema version.
+	ErrInvalidMigration = errors.New("invalid migration")
+	// ErrMigrationNotRegistered indicates that an instance needs a lazy migration
+	// that isn't registered, e.g., after a restart. Register it again with
+	// UpdateCollection.
+	ErrMigrationNotRegistered = errors.New("migration not registered")
+
+	dsVersions         = dsPrefix.ChildString("version")
+	dsInstanceVersions = dsPrefix.ChildString("instversion")
+)
+
+// MigrateFunc rewrites an instance to conform to a new schema version.
+type MigrateFunc func(instance []byte) ([]byte, error)
+
+// Migration upgrades instances from the previous schema version to Version.
+type Migration struct {
+	Version int
+	Migrate MigrateFunc
+}
+
+// versionState is the persisted schema version state of a collection.
+type versionState struct {
+	// Version is the current schema version.
+	Version int
+	// Migrated is the version all instances without an instance version are at.
+	Migrated int
+	// Steps are the versions with a migration.
+	Steps []int
+}
+
+// GetVersion returns the current collection schema version.
+func (c *Collection) GetVersion() int {
+	c.Lock()
+	defer c.Unlock()
+	return c.version.Version
+}
+
+// setMigrations validates and registers migrations towards the collection
+// version on top of the state of xc, the collection being updated.
+func (c *Collection) setMigrations(xc *Collection, migrations []Migration) error {
+	xc.Lock()
+	old, fns := xc.version, xc.migrations
+	xc.Unlock()
+	if c.version.Version < old.Version {
+		return fmt.Errorf("%w: schema version can't go from %d to %d", ErrInvalidMigration, old.Version, c.version.Version)
+	}
+	c.version.Migrated = old.Migrated
+	c.migrations = make(map[int]MigrateFunc)
+	steps := make(map[int]struct{})
+	for _, s := range old.Steps {
+		if s > old.Migrated {
+			steps[s] = struct{}{}
+			if fn, ok := fns[s]; ok {
+				c.migrations[s] = fn
+			}
+		}
+	}
+	for _, m := range migrations {
+		if m.Version <= 0 || m.Version > c.version.Version || m.Migrate == nil {
+			return fmt.Errorf("%w: migration to version %d", ErrInvalidMigration, m.Version)
+		}
+		if m.Version <= old.Migrated {
+			// Already applied to all instances
+			continue
+		}
+		if m.Version <= old.Version {
+			// Registers a pending migration again, e.g., after a restart
+			if _, ok := steps[m.Version]; ok {
+				c.migrations[m.Version] = m.Migrate
+			}
+			continue
+		}
+		if _, ok := steps[m.Version]; ok {
+			return fmt.Errorf("%w: duplicate migration to version %d", ErrInvalidMigration, m.Version)
+		}
+		steps[m.Version] = struct{}{}
+		c.migrations[m.Version] = m.Migrate
+	}
+	c.version.Steps = make([]int, 0, len(steps))
+	for s := range steps {
+		c.version.Steps = append(c.version.Steps, s)
+	}
+	sort.Ints(c.version.Steps)
+	if len(c.version.Steps) == 0 {
+		// Nothing to migrate
+		c.version.Migrated = c.version.Version
+	}
+	return nil
+}
+
+// pendingMigration returns whether or not some instances may be at an older version.
+func (c *Collection) pendingMigration() bool {
+	c.Lock()
+	defer c.Unlock()
+	return c.version.Migrated < c.version.Version
+}
+
+// instanceVersionKey returns the key of the version of the instance at key.
+func (c *Collection) instanceVersionKey(key ds.Key) ds.Key {
+	return dsInstanceVersions.ChildString(c.name).ChildString(key.Name())
+}
+
+// updateInstanceVersion records the version of an instance written while
+// a migration is pending.
+func (c *Collection) updateInstanceVersion(key ds.Key, newData []byte, txn ds.Txn) error {
+	if newData == nil {
+		return txn.Delete(c.instanceVersionKey(key))
+	}
+	c.Lock()
+	pending, version := c.version.Migrated < c.version.Version, c.version.Version
+	c.Unlock()
+	if !pending {
+		return nil
+	}
+	return txn.Put(c.instanceVersionKey(key), []byte(strconv.Itoa(version)))
+}
+
+// migrateInstance applies the pending migrations of the instance at key to v.
+func (c *Collection) migrateInstance(key ds.Key, v []byte) ([]byte, error) {
+	c.Lock()
+	state, fns := c.version, c.migrations
+	c.Unlock()
+	if state.Migrated >= state.Version {
+		return v, nil
+	}
+	from := state.Migrated
+	iv, err := c.db.datastore.Get(c.instanceVersionKey(key))
+	if err == nil {
+		if from, err = strconv.Atoi(string(iv)); err != nil {
+			return nil, err
+		}
+	} else if !errors.Is(err, ds.ErrNotFound) {
+		return nil, err
+	}
+	for _, step := range state.Steps {
+		if step <= from || step > state.Version {
+			continue
+		}
+		fn, ok := fns[step]
+		if !ok {
+			return nil, fmt.Errorf("%w: version %d of %s", ErrMigrationNotRegistered, step, c.name)
+		}
+		if v, err = fn(v); err != nil {
+			return nil, fmt.Errorf("migrating instance %s to version %d: %w", key.Name(), step, err)
+		}
+	}
+	return v, nil
+}
+
+// migrateResult applies the pending migrations of a query result.
+func (c *Collection) migrateResult(res MarshaledResult) (MarshaledResult, error) {
+	v, err := c.migrateInstance(ds.RawKey(res.Key), res.Value)
+	if err != nil {
+		return res, err
+	}
+	if bytes.Equal(v, res.Value) {
+		return res, nil
+	}
+	// Marshaled values are needed for sorting
+	val := make(map[string]interface{})
+	if err := json.Unmarshal(v, &val); err != nil {
+		return res, err
+	}
+	res.Value = v
+	res.MarshaledValue = val
+	return res, nil
+}
+
+// Migrate eagerly migrates the instances of the collection that are at an older
+// schema version, see CollectionConfig.Version. Instances are saved in batches,
+// so a failure leaves the instances of previous batches migrated.
+func (c *Collection) Migrate(opts ...Option) error {
+	args := &Options{}
+	for _, opt := range opts {
+		opt(args)
+	}
+	if err := c.db.connector.Validate(args.Token, false); err != nil {
+		return err
+	}
+	if !c.pendingMigration() {
+		return nil
+	}
+
+	results, err := c.db.datastore.Query(query.Query{Prefix: c.baseKey().String(), KeysOnly: true})
+	if err != nil {
+		return err
+	}
+	var ids []core.InstanceID
+	for res := range results.Next() {
+		if res.Error != nil {
+			results.Close()
+			return res.Error
+		}
+		ids = append(ids, core.InstanceID(ds.RawKey(res.Key).Name()))
+	}
+	results.Close()
+
+	for start := 0; start < len(ids); start += migrationBatchSize {
+		end := start + migrationBatchSize
+		if end > len(ids) {
+			end = len(ids)
+		}
+		err := c.WriteTxn(func(txn *Txn) error {
+			for _, id := range ids[start:end] {
+				key := c.baseKey().ChildString(id.String())
+				v, err := c.db.datastore.Get(key)
+				if errors.Is(err, ds.ErrNotFound) {
+					continue
+				} else if err != nil {
+					return err
+				}
+				migrated, err := c.migrateInstance(key, v)
+				if err != nil {
+					return err
+				}
+				if bytes.Equal(migrated, v) {
+					continue
+				}
+				if err := txn.Save(migrated); err != nil {
+					return fmt.Errorf("saving migrated instance %s: %w", id, err)
+				}
+			}
+			return nil
+		}, WithTxnToken(args.Token))
+		if err != nil {
+			return err
+		}
+		if args.MigrationProgress != nil {
+			args.MigrationProgress(end, len(ids))
+		}
+	}
+
+	c.Lock()
+	state := c.version
+	state.Migrated = state.Version
+	c.Unlock()
+	txn, err := c.db.datastore.NewTransaction(false)
+	if err != nil {
+		return err
+	}
+	defer txn.Discard()
+	if err := putVersionState(txn, c.name, state); err != nil {
+		return err
+	}
+	if err := deletePrefix(c.db.datastore, txn, dsInstanceVersions.ChildString(c.name)); err != nil {
+		return err
+	}
+	if err := txn.Commit(); err != nil {
+		return err
+	}
+	c.Lock()
+	c.version = state
+	c.Unlock()
+	return nil
+}
+
+func putVersionState(w ds.Write, name string, state versionState) error {
+	sv, err := json.Marshal(state)
+	if err != nil {
+		return err
+	}
+	return w.Put(dsVersions.ChildString(name), sv)
+}
+
+func getVersionState(r ds.Read, name string) (versionState, error) {
+	var state versionState
+	sv, err := r.Get(dsVersions.ChildString(name))
+	if errors.Is(err, ds.ErrNotFound) {
+		return state, nil
+	} else if err != nil {
+		return state, err
+	}
+	err = json.Unmarshal(sv, &state)
+	return state, err
+}
@@ -0,0 +1,225 @@
+package db
+
+import (
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"time"
+
+	ds "github.com/textileio/go-datastore"
+	"github.com/textileio/go-datastore/query"
+	core "github.com/textileio/go-threads/core/db"
+)
+
+var (
+	dsModified      = dsPrefix.ChildString("modified")
+	dsModifiedReady = dsPrefix.ChildString("modified-ready")
+)
+
+// Modified are the changes made to a collection since a point in time.
+type Modified struct {
+	// Instances are the created or updated instances, ordered by modification time.
+	Instances [][]byte
+	// Deleted are the IDs of the deleted instances, ordered by ID.
+	// Only available if the query includes deleted instances and the
+	// db was created with WithNewTombstoneRetention.
+	Deleted []core.InstanceID
+}
+
+// modifiedKey returns the modification index key of an instance.
+func modifiedKey(collection string, mod int64, id core.InstanceID) ds.Key {
+	return dsModified.ChildString(collection).ChildString(formatModTime(mod)).ChildString(id.String())
+}
+
+// formatModTime zero-pads a modification time so index keys sort chronologically.
+func formatModTime(mod int64) string {
+	return fmt.Sprintf("%019d", mod)
+}
+
+// updateModifiedIndex keeps the modification index of an instance up-to-date.
+// Instances without a modification time aren't indexed.
+func (c *Collection) updateModifiedIndex(key ds.Key, oldData, newData []byte, txn ds.Txn) error {
+	id := core.InstanceID(key.Name())
+	if oldData != nil {
+		if mod, err := getModifiedTag(oldData); err == nil {
+			if err := txn.Delete(modifiedKey(c.name, mod, id)); err != nil {
+				return err
+			}
+		}
+	}
+	if newData != nil {
+		if mod, err := getModifiedTag(newData); err == nil {
+			if err := txn.Put(modifiedKey(c.name, mod, id), nil); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// ensureModifiedIndex indexes the modification times of existing instances
+// if the collection wasn't indexed yet. The caller must hold d.lock.
+func (c *Collection) ensureModifiedIndex() error {
+	ready, err := c.db.datastore.Has(dsModifiedReady.ChildString(c.name))
+	if err != nil || ready {
+		return err
+	}
+	txn, err := c.db.datastore.NewTransaction(false)
+	if err != nil {
+		return err
+	}
+	defer txn.Discard()
+	results, err := c.db.datastore.Query(query.Query{Prefix: c.baseKey().String()})
+	if err != nil {
+		return err
+	}
+	defer results.Close()
+	for res := range results.Next() {
+		if res.Error != nil {
+			return res.Error
+		}
+		if err := c.updateModifiedIndex(ds.RawKey(res.Key), nil, res.Value, txn); err != nil {
+			return err
+		}
+	}
+	if err := txn.Put(dsModifiedReady.ChildString(c.name), nil); err != nil {
+		return err
+	}
+	return txn.Commit()
+}
+
+// deleteModifiedIndex removes the modification index of a collection.
+func (c *Collection) deleteModifiedIndex(txn ds.Txn) error {
+	results, err := c.db.datastore.Query(query.Query{
+		Prefix:   dsModified.ChildString(c.name).String(),
+		KeysOnly: true,
+	})
+	if err != nil {
+		return err
+	}
+	defer results.Close()
+	for res := range results.Next() {
+		if res.Error != nil {
+			return res.Error
+		}
+		if err := txn.Delete(ds.RawKey(res.Key)); err != nil {
+			return err
+		}
+	}
+	return txn.Delete(dsModifiedReady.ChildString(c.name))
+}
+
+// FindModifiedSince returns the instances matching q that were created or
+// updated since a point in time, based on their modification time.
+// Results are ordered by modification time, so the query sort order is ignored.
+// The IDs of deleted instances are also returned if q includes deleted instances.
+// Unlike ModifiedSince, which returns the IDs of the instances touched since
+// a dispatcher timestamp, it returns the instances themselves, so a mirror
+// doesn't have to fetch them. Criteria match the returned instances, e.g.,
+// their decrypted fields, see EncryptedFields, and migrated values.
+func (c *Collection) FindModifiedSince(since time.Time, q *Query, opts ...TxnOption) (modified Modified, err error) {
+	err = c.ReadTxn(func(txn *Txn) error {
+		modified, err = txn.FindModifiedSince(since, q)
+		return err
+	}, opts...)
+	return
+}
+
+// FindModifiedSince returns the instances matching q that were created or
+// updated since a point in time in the current txn scope.
+func (t *Txn) FindModifiedSince(since time.Time, q *Query) (Modified, error) {
+	if err := t.collection.db.connector.Validate(t.token, true); err != nil {
+		return Modified{}, err
+	}
+	if q == nil {
+		q = &Query{}
+	}
+	if err := q.Validate(); err != nil {
+		return Modified{}, fmt.Errorf("invalid query: %s", err)
+	}
+	pk, err := t.token.PubKey()
+	if err != nil {
+		return Modified{}, err
+	}
+
+	c := t.collection
+	min := since.UnixNano()
+	prefix := dsModified.ChildString(c.name)
+	results, err := t.reader().Query(query.Query{
+		Prefix:     prefix.String(),
+		SeekPrefix: prefix.ChildString(formatModTime(min)).String(),
+		Orders:     []query.Order{query.OrderByKey{}},
+		KeysOnly:   true,
+	})
+	if err != nil {
+		return Modified{}, err
+	}
+	defer results.Close()
+
+	var res Modified
+	var skipped int
+	for r := range results.Next() {
+		if q.Limit > 0 && len(res.Instances) >= q.Limit {
+			break
+		}
+		if r.Error != nil {
+			return Modified{}, r.Error
+		}
+		key := ds.RawKey(r.Key)
+		mod, err := strconv.ParseInt(key.Parent().Name(), 10, 64)
+		if err != nil {
+			return Modified{}, err
+		}
+		if mod < min { // Not every datastore supports seeking
+			continue
+		}
+		instanceKey := c.baseKey().ChildString(key.Name())
+		v, err := t.reader().Get(instanceKey)
+		if err == ds.ErrNotFound {
+			continue
+		} else if err != nil {
+			return Modified{}, err
+		}
+		// Criteria match the instances as they're returned, i.e., migrated
+		// and with their encrypted fields decrypted
+		if v, err = c.migrateInstance(instanceKey, v); err != nil {
+			return Modified{}, err
+		}
+		v, err = c.readInstance(pk, v)
+		if err != nil {
+			return Modified{}, err
+		}
+		if v == nil {
+			continue
+		}
+		val := make(map[string]interface{})
+		if err := json.Unmarshal(v, &val); err != nil {
+			return Modified{}, err
+		}
+		ok, err := q.match(val)
+		if err != nil {
+			return Modified{}, err
+		}
+		if !ok {
+			continue
+		}
+		if skipped < q.Skip {
+			skipped++
+			continue
+		}
+		res.Instances = append(res.Instances, v)
+	}
+
+	if q.WithDeleted {
+		err = c.iterateDeleted(t.reader(), pk, q, func(d DeletedInstance, _ map[string]interface{}) bool {
+			if !d.Deleted.Before(since) {
+				res.Deleted = append(res.Deleted, d.ID)
+			}
+			return true
+		})
+		if err != nil {
+			return Modified{}, err
+		}
+	}
+	return res, nil
+}
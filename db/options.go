@@ -1,8 +1,10 @@
 package db
 
 import (
+	"context"
 	"os"
 	"path/filepath"
+	"time"
 
 	"github.com/dgraph-io/badger/options"
 	"github.com/libp2p/go-libp2p-core/crypto"
@@ -10,17 +12,13 @@ import (
 	badger "github.com/textileio/go-ds-badger"
 	core "github.com/textileio/go-threads/core/db"
 	"github.com/textileio/go-threads/core/thread"
-	"github.com/textileio/go-threads/jsonpatcher"
+	"github.com/textileio/go-threads/crypto/symmetric"
 )
 
 const (
 	defaultDatastorePath = "eventstore"
 )
 
-func newDefaultEventCodec() core.EventCodec {
-	return jsonpatcher.New()
-}
-
 func newDefaultDatastore(repoPath string, lowMem bool) (ds.TxnDatastore, error) {
 	path := filepath.Join(repoPath, defaultDatastorePath)
 	if err := os.MkdirAll(path, os.ModePerm); err != nil {
@@ -35,17 +33,50 @@ func newDefaultDatastore(repoPath string, lowMem bool) (ds.TxnDatastore, error)
 
 // NewOptions defines options for creating a new db.
 type NewOptions struct {
-	Name        string
-	RepoPath    string
-	Token       thread.Token
-	Datastore   ds.TxnDatastore
-	Collections []CollectionConfig
-	Block       bool
-	EventCodec  core.EventCodec
-	LowMem      bool
-	Debug       bool
-	ThreadKey   thread.Key
-	LogKey      crypto.Key
+	Name           string
+	RepoPath       string
+	Token          thread.Token
+	Datastore      ds.TxnDatastore
+	Collections    []CollectionConfig
+	Block          bool
+	EventCodec     core.EventCodec
+	EventCodecName string
+	LowMem         bool
+	Debug          bool
+	ThreadKey      thread.Key
+	LogKey         crypto.Key
+
+	KeyEncryptionKey *symmetric.Key
+
+	ValidationMetrics bool
+	ValidationHandler ValidationFailureHandler
+	RedactRejected    bool
+	Tombstones        bool
+
+	MaxBatchEntries  int
+	MaxBatchBytes    int
+	StrictBatchLimit bool
+	ThroughputWindow time.Duration
+
+	ExpirySweepInterval time.Duration
+
+	ActionHistory int
+
+	MaxQueryResults int
+	MaxQueryScan    int
+
+	ReadOnly bool
+
+	LazyDBs     bool
+	ScopedDBs   bool
+	IsolatedDBs bool
+	MaxOpenDBs  int
+
+	Quota int64
+
+	MaintenanceInterval time.Duration
+	MaintenanceStart    time.Duration
+	MaintenanceEnd      time.Duration
 }
 
 // NewOption specifies a new db option.
@@ -89,6 +120,17 @@ func WithNewLogKey(key crypto.Key) NewOption {
 	}
 }
 
+// WithNewKeyEncryptionKey wraps the keys of encrypted fields, see
+// CollectionConfig.EncryptedFields, with key before they're stored in the
+// datastore, so encrypted fields are also protected at rest. The key isn't
+// stored, and must be provided every time the db is opened, or collections
+// with encrypted fields fail to load with ErrInvalidKeyEncryptionKey.
+func WithNewKeyEncryptionKey(key *symmetric.Key) NewOption {
+	return func(o *NewOptions) {
+		o.KeyEncryptionKey = key
+	}
+}
+
 // WithNewCollections is used to specify collections that
 // will be created.
 func WithNewCollections(cs ...CollectionConfig) NewOption {
@@ -115,6 +157,15 @@ func WithNewEventCodec(ec core.EventCodec) NewOption {
 	}
 }
 
+// WithNewEventCodecName selects the registered event codec with the given name,
+// see RegisterEventCodec. Defaults to the codec the db was created with,
+// or DefaultEventCodec for new dbs.
+func WithNewEventCodecName(name string) NewOption {
+	return func(o *NewOptions) {
+		o.EventCodecName = name
+	}
+}
+
 // WithNewLowMem specifies whether or not to use low memory settings.
 func WithNewLowMem(low bool) NewOption {
 	return func(o *NewOptions) {
@@ -129,9 +180,188 @@ func WithNewDebug(enable bool) NewOption {
 	}
 }
 
+// WithNewValidationMetrics enables per-collection counting of schema validation failures.
+// See Collection.GetValidationStats.
+func WithNewValidationMetrics(enable bool) NewOption {
+	return func(o *NewOptions) {
+		o.ValidationMetrics = enable
+	}
+}
+
+// WithNewValidationFailureHandler sets a handler that is called with each write
+// rejected by a collection schema. If redact is true, the rejected instance is
+// not included in the failure.
+func WithNewValidationFailureHandler(h ValidationFailureHandler, redact bool) NewOption {
+	return func(o *NewOptions) {
+		o.ValidationHandler = h
+		o.RedactRejected = redact
+	}
+}
+
+// WithNewTombstoneRetention specifies whether or not to retain the last state of
+// deleted instances, making them available to Collection.FindDeleted.
+func WithNewTombstoneRetention(enable bool) NewOption {
+	return func(o *NewOptions) {
+		o.Tombstones = enable
+	}
+}
+
+// WithNewBatchLimit sets the maximum number of instances and total bytes of
+// a batch operation, e.g., Collection.CreateMany, applied in a single transaction.
+// Larger batches are split into sequential sub-batches, unless the db was created
+// with WithNewStrictBatchLimit. A zero value means no limit.
+func WithNewBatchLimit(maxEntries, maxBytes int) NewOption {
+	return func(o *NewOptions) {
+		o.MaxBatchEntries = maxEntries
+		o.MaxBatchBytes = maxBytes
+	}
+}
+
+// WithNewStrictBatchLimit specifies whether or not batch operations exceeding the
+// batch limit fail with ErrBatchTooLarge instead of being split into sub-batches.
+func WithNewStrictBatchLimit(enable bool) NewOption {
+	return func(o *NewOptions) {
+		o.StrictBatchLimit = enable
+	}
+}
+
+// WithNewThroughputWindow sets the rolling window collection throughput stats
+// are computed over. Defaults to one minute. See Collection.Throughput.
+func WithNewThroughputWindow(window time.Duration) NewOption {
+	return func(o *NewOptions) {
+		o.ThroughputWindow = window
+	}
+}
+
+// WithNewExpirySweepInterval sets the interval between sweeps of expired
+// instances. Defaults to one minute. See CollectionConfig.TTLField.
+func WithNewExpirySweepInterval(interval time.Duration) NewOption {
+	return func(o *NewOptions) {
+		o.ExpirySweepInterval = interval
+	}
+}
+
+// WithNewActionHistory sets the number of latest actions kept for resuming
+// listeners, see WithResumeToken. Defaults to 10000.
+func WithNewActionHistory(size int) NewOption {
+	return func(o *NewOptions) {
+		o.ActionHistory = size
+	}
+}
+
+// WithNewMaxQueryResults sets the maximum number of results of Find and FindPage
+// queries. Queries with more results fail with a *QueryLimitError, unless they
+// are limited to at most the maximum, see Query.LimitTo. Defaults to no maximum.
+func WithNewMaxQueryResults(max int) NewOption {
+	return func(o *NewOptions) {
+		o.MaxQueryResults = max
+	}
+}
+
+// WithNewMaxQueryScan sets the maximum number of instances read by a query, e.g.,
+// to bound unindexed queries on large collections. Queries scanning more fail with
+// a *QueryLimitError. Exports aren't bound by it. Defaults to no maximum.
+func WithNewMaxQueryScan(max int) NewOption {
+	return func(o *NewOptions) {
+		o.MaxQueryScan = max
+	}
+}
+
+// WithNewReadOnly makes the db reject local writes with ErrReadOnlyDB, while
+// records of remote peers are still applied, e.g., for mirrors of a thread that
+// must never originate events. Expired instances aren't swept, since deleting
+// them would originate events. Collections can still be managed.
+func WithNewReadOnly(enable bool) NewOption {
+	return func(o *NewOptions) {
+		o.ReadOnly = enable
+	}
+}
+
+// WithNewLazyDBs makes a db manager only hydrate its dbs, i.e., load their
+// collections and indexes, as they're first used, instead of on startup.
+// Records received from peers hydrate the db of their thread.
+// Listing all dbs with Manager.ListDBs hydrates them.
+func WithNewLazyDBs(enable bool) NewOption {
+	return func(o *NewOptions) {
+		o.LazyDBs = enable
+	}
+}
+
+// WithNewScopedDBs scopes the dbs of a manager created with a token to its
+// identity. Scoped dbs can only be listed, gotten, and deleted with tokens of
+// their owner, and of the identities it delegates to, see
+// Manager.AddDBDelegate. Other identities get ErrDBNotFound. Dbs created
+// without a token, or before they were scoped, can be accessed by any token.
+func WithNewScopedDBs(enable bool) NewOption {
+	return func(o *NewOptions) {
+		o.ScopedDBs = enable
+	}
+}
+
+// WithNewIsolatedDBs makes a db manager store each new db in its own badger
+// datastore, under the dbs directory of the repo path, instead of the shared
+// datastore of the manager. Isolated dbs can be backed up, moved, or deleted
+// at the filesystem level without affecting the others. The directory of a db
+// is named by its thread ID, and is removed when the db is deleted. Existing
+// dbs stay in the shared datastore, and isolated dbs are still found when the
+// option is disabled.
+func WithNewIsolatedDBs(enable bool) NewOption {
+	return func(o *NewOptions) {
+		o.IsolatedDBs = enable
+	}
+}
+
+// WithNewMaxOpenDBs bounds the dbs a db manager keeps open. As a db is used
+// with more dbs open, the least recently used idle dbs, i.e., without
+// listeners or unreleased handles, are closed, and they're hydrated again on
+// their next use, or as records of their thread are received. Each db
+// returned by the manager, e.g., by Manager.GetDB, is a handle that keeps it
+// open until it's released with Manager.ReleaseDB, so dbs should be gotten
+// from the manager as they're used, and released after. Dbs are hydrated as
+// with WithNewLazyDBs. Defaults to no limit.
+func WithNewMaxOpenDBs(max int) NewOption {
+	return func(o *NewOptions) {
+		o.MaxOpenDBs = max
+	}
+}
+
+// WithNewQuota sets the storage quota of a db, or of each db of a manager, in
+// bytes of instances. Local writes that would exceed it fail with a
+// *QuotaError, while records of remote peers are still applied. The quota of a
+// db can be changed with DB.SetQuota. Defaults to no quota.
+func WithNewQuota(bytes int64) NewOption {
+	return func(o *NewOptions) {
+		o.Quota = bytes
+	}
+}
+
+// WithNewMaintenance makes a manager run the maintenance of its dbs every
+// interval, see Manager.RunMaintenance. Disabled by default.
+func WithNewMaintenance(interval time.Duration) NewOption {
+	return func(o *NewOptions) {
+		o.MaintenanceInterval = interval
+	}
+}
+
+// WithNewMaintenanceWindow restricts the scheduled maintenance of a manager to
+// quiet hours, from start to end, given as offsets from local midnight, e.g.,
+// 2*time.Hour and 5*time.Hour. A window ending before it starts spans midnight.
+// By default, maintenance runs at any time.
+func WithNewMaintenanceWindow(start, end time.Duration) NewOption {
+	return func(o *NewOptions) {
+		o.MaintenanceStart = start
+		o.MaintenanceEnd = end
+	}
+}
+
 // Options defines options for interacting with a db.
 type Options struct {
-	Token thread.Token
+	Token             thread.Token
+	LazyMigration     bool
+	MigrationProgress BatchProgressFunc
+	CheckSchema       bool
+	CheckSchemaSample int
+	ValidatorsOnly    bool
 }
 
 // Option specifies a db option.
@@ -144,9 +374,55 @@ func WithToken(t thread.Token) Option {
 	}
 }
 
+// WithLazyMigration makes a collection update skip migrating existing instances
+// to the new schema version. Instances are migrated as they're read instead.
+func WithLazyMigration(lazy bool) Option {
+	return func(o *Options) {
+		o.LazyMigration = lazy
+	}
+}
+
+// WithMigrationProgress sets a function that is called after each batch of
+// migrated instances with the number of instances done and the total.
+func WithMigrationProgress(f BatchProgressFunc) Option {
+	return func(o *Options) {
+		o.MigrationProgress = f
+	}
+}
+
+// WithCompatibilityCheck makes a collection update validate existing instances
+// against the new schema, after applying pending migrations, and fail with a
+// *SchemaCompatibilityError if some don't conform. If sample is positive, only
+// the first sample instances in ID order are checked.
+func WithCompatibilityCheck(sample int) Option {
+	return func(o *Options) {
+		o.CheckSchema = true
+		o.CheckSchemaSample = sample
+	}
+}
+
+// WithValidatorsOnly makes a collection update only replace the write validator
+// and read filter of the collection with those of the config, in place, so the
+// collection keeps serving reads and writes and stored instances and indexes are
+// left untouched. Other fields of the config are ignored.
+func WithValidatorsOnly(only bool) Option {
+	return func(o *Options) {
+		o.ValidatorsOnly = only
+	}
+}
+
 // TxnOptions defines options for a transaction.
 type TxnOptions struct {
-	Token thread.Token
+	Token           thread.Token
+	Metadata        map[string]string
+	BatchProgress   BatchProgressFunc
+	ChunkSize       int
+	Snapshot        bool
+	CompareMod      bool
+	DryRun          bool
+	ContinueOnError bool
+	WritePosition   WritePositionFunc
+	Context         context.Context
 }
 
 // TxnOption specifies a transaction option.
@@ -159,9 +435,92 @@ func WithTxnToken(t thread.Token) TxnOption {
 	}
 }
 
+// WithTxnMetadata attaches metadata to a write transaction, e.g., a request ID or
+// the reason for the change. Metadata is recorded with the transaction events
+// and exposed to listeners, but it's not part of the instances.
+func WithTxnMetadata(md map[string]string) TxnOption {
+	return func(o *TxnOptions) {
+		o.Metadata = md
+	}
+}
+
+// WithTxnSnapshot pins a snapshot of the db for the duration of a read
+// transaction, so its reads don't observe writes made in the meantime,
+// e.g., by events received from peers.
+func WithTxnSnapshot(enabled bool) TxnOption {
+	return func(o *TxnOptions) {
+		o.Snapshot = enabled
+	}
+}
+
+// WithTxnCompareMod enables compare-and-swap saves in a write transaction.
+// The _mod of saved instances must match the one of the stored instances,
+// otherwise the save fails with ErrConflict. This allows safe read-modify-write
+// of instances without locking. Plain saves of new instances omit _mod.
+func WithTxnCompareMod(enabled bool) TxnOption {
+	return func(o *TxnOptions) {
+		o.CompareMod = enabled
+	}
+}
+
+// WithTxnContext sets a context for the reads of the transaction. Once the
+// context is done, reads fail with its error, and scans of running queries
+// stop, releasing their datastore iterators.
+func WithTxnContext(ctx context.Context) TxnOption {
+	return func(o *TxnOptions) {
+		o.Context = ctx
+	}
+}
+
+// WithTxnDryRun makes an import only validate its instances, without
+// creating them. See Collection.Import.
+func WithTxnDryRun(enabled bool) TxnOption {
+	return func(o *TxnOptions) {
+		o.DryRun = enabled
+	}
+}
+
+// WithTxnContinueOnError makes CreateMany and SaveMany skip instances that
+// fail, e.g., schema validation, instead of aborting the batch. The other
+// instances are written, and a *PartialBatchError reports the result of each
+// instance. Errors of the transaction commit still fail the whole sub-batch.
+func WithTxnContinueOnError(enabled bool) TxnOption {
+	return func(o *TxnOptions) {
+		o.ContinueOnError = enabled
+	}
+}
+
+// WithTxnWritePosition sets a function that follows the position of a write
+// in the write queue of a collection with serialized writes, see
+// CollectionConfig.SerializeWrites.
+func WithTxnWritePosition(f WritePositionFunc) TxnOption {
+	return func(o *TxnOptions) {
+		o.WritePosition = f
+	}
+}
+
+// WithTxnChunkSize splits batch operations into sequential sub-batches of at
+// most size instances, on top of the db batch limit. Like with the batch limit,
+// each sub-batch is atomic, but the batch as a whole isn't.
+func WithTxnChunkSize(size int) TxnOption {
+	return func(o *TxnOptions) {
+		o.ChunkSize = size
+	}
+}
+
+// WithTxnBatchProgress sets a function that is called after each committed
+// sub-batch of a batch operation. See WithNewBatchLimit.
+func WithTxnBatchProgress(f BatchProgressFunc) TxnOption {
+	return func(o *TxnOptions) {
+		o.BatchProgress = f
+	}
+}
+
 // NewManagedOptions defines options for creating a new managed db.
 type NewManagedOptions struct {
 	Name        string
+	Description string
+	Labels      map[string]string
 	Token       thread.Token
 	Collections []CollectionConfig
 	Block       bool
@@ -179,6 +538,20 @@ func WithNewManagedName(name string) NewManagedOption {
 	}
 }
 
+// WithNewManagedDescription assigns a description to a new managed db.
+func WithNewManagedDescription(description string) NewManagedOption {
+	return func(o *NewManagedOptions) {
+		o.Description = description
+	}
+}
+
+// WithNewManagedLabels assigns key/value labels to a new managed db.
+func WithNewManagedLabels(labels map[string]string) NewManagedOption {
+	return func(o *NewManagedOptions) {
+		o.Labels = labels
+	}
+}
+
 // WithNewManagedToken provides authorization for creating a new managed db.
 func WithNewManagedToken(t thread.Token) NewManagedOption {
 	return func(o *NewManagedOptions) {
@@ -235,3 +608,91 @@ func WithManagedToken(t thread.Token) ManagedOption {
 		o.Token = t
 	}
 }
+
+// ListDBsOptions defines options for listing managed dbs.
+type ListDBsOptions struct {
+	Token         thread.Token
+	Owner         thread.PubKey
+	NamePrefix    string
+	CreatedAfter  time.Time
+	CreatedBefore time.Time
+	Limit         int
+	Cursor        string
+}
+
+// ListDBsOption specifies a managed db listing option.
+type ListDBsOption func(*ListDBsOptions)
+
+// WithListDBsToken provides authorization for listing managed dbs.
+func WithListDBsToken(t thread.Token) ListDBsOption {
+	return func(o *ListDBsOptions) {
+		o.Token = t
+	}
+}
+
+// WithListDBsOwner only lists the dbs created with a token of the identity.
+func WithListDBsOwner(owner thread.PubKey) ListDBsOption {
+	return func(o *ListDBsOptions) {
+		o.Owner = owner
+	}
+}
+
+// WithListDBsNamePrefix only lists the dbs whose name starts with prefix.
+func WithListDBsNamePrefix(prefix string) ListDBsOption {
+	return func(o *ListDBsOptions) {
+		o.NamePrefix = prefix
+	}
+}
+
+// WithListDBsCreatedAfter only lists the dbs created after t.
+// Dbs created before creation times were recorded are excluded.
+func WithListDBsCreatedAfter(t time.Time) ListDBsOption {
+	return func(o *ListDBsOptions) {
+		o.CreatedAfter = t
+	}
+}
+
+// WithListDBsCreatedBefore only lists the dbs created before t.
+func WithListDBsCreatedBefore(t time.Time) ListDBsOption {
+	return func(o *ListDBsOptions) {
+		o.CreatedBefore = t
+	}
+}
+
+// WithListDBsLimit limits a page of listed dbs to limit dbs.
+func WithListDBsLimit(limit int) ListDBsOption {
+	return func(o *ListDBsOptions) {
+		o.Limit = limit
+	}
+}
+
+// WithListDBsCursor lists the page of dbs after the cursor returned with the previous page.
+func WithListDBsCursor(cursor string) ListDBsOption {
+	return func(o *ListDBsOptions) {
+		o.Cursor = cursor
+	}
+}
+
+// ExportOptions defines options for exporting managed dbs.
+type ExportOptions struct {
+	Token     thread.Token
+	ThreadKey bool
+}
+
+// ExportOption specifies a managed db export option.
+type ExportOption func(*ExportOptions)
+
+// WithExportToken provides authorization for exporting managed dbs.
+func WithExportToken(t thread.Token) ExportOption {
+	return func(o *ExportOptions) {
+		o.Token = t
+	}
+}
+
+// WithExportThreadKey includes the thread key in the archive of an exported db,
+// so it's restored as the same thread, which peers can keep replicating.
+func WithExportThreadKey(include bool) ExportOption {
+	return func(o *ExportOptions) {
+		o.ThreadKey = include
+	}
+}
@@ -0,0 +1,145 @@
+package db
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+
+	core "github.com/textileio/go-threads/core/db"
+	"github.com/tidwall/gjson"
+	"github.com/tidwall/sjson"
+)
+
+// ErrInvalidSet indicates an instance field isn't an observed-remove set.
+var ErrInvalidSet = errors.New("invalid set")
+
+// ORSet is an observed-remove set CRDT of strings that can be embedded in
+// instances. Each added element is keyed by a unique tag, and removing an
+// element only deletes the tags observed by the remover. The merge patches of
+// concurrent adds and removes from different peers don't overlap, and an
+// element that is concurrently added and removed stays in the set.
+// Sets must be changed with AddToSet and RemoveFromSet, and rely on the
+// default core.ConflictMerge strategy of collections.
+type ORSet map[string]string
+
+// Values returns the sorted distinct elements of the set.
+func (s ORSet) Values() []string {
+	seen := make(map[string]struct{})
+	values := make([]string, 0, len(s))
+	for _, v := range s {
+		if _, ok := seen[v]; !ok {
+			seen[v] = struct{}{}
+			values = append(values, v)
+		}
+	}
+	sort.Strings(values)
+	return values
+}
+
+// Contains returns whether or not the set contains v.
+func (s ORSet) Contains(v string) bool {
+	for _, e := range s {
+		if e == v {
+			return true
+		}
+	}
+	return false
+}
+
+// add adds v to the set with a new tag, replacing its observed tags.
+func (s ORSet) add(v string) {
+	s.remove(v)
+	s[core.NewInstanceID().String()] = v
+}
+
+// remove deletes the observed tags of v from the set.
+func (s ORSet) remove(v string) {
+	for tag, e := range s {
+		if e == v {
+			delete(s, tag)
+		}
+	}
+}
+
+// SetValues returns the elements of the set at path in an instance.
+// Missing sets are empty.
+func SetValues(instance []byte, path string) ([]string, error) {
+	s, err := getORSet(instance, path)
+	if err != nil {
+		return nil, err
+	}
+	return s.Values(), nil
+}
+
+// getORSet returns the set at path in an instance.
+func getORSet(instance []byte, path string) (ORSet, error) {
+	s := make(ORSet)
+	r := gjson.GetBytes(instance, path)
+	if !r.Exists() {
+		return s, nil
+	}
+	if !r.IsObject() {
+		return nil, fmt.Errorf("%w: %s isn't an object", ErrInvalidSet, path)
+	}
+	if err := json.Unmarshal([]byte(r.Raw), &s); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidSet, err)
+	}
+	return s, nil
+}
+
+// AddToSet adds values to the set at path in the instance with the given ID.
+// Missing sets are created.
+func (c *Collection) AddToSet(id core.InstanceID, path string, values []string, opts ...TxnOption) error {
+	return c.WriteTxn(func(txn *Txn) error {
+		return txn.AddToSet(id, path, values...)
+	}, opts...)
+}
+
+// RemoveFromSet removes values from the set at path in the instance with the given ID.
+func (c *Collection) RemoveFromSet(id core.InstanceID, path string, values []string, opts ...TxnOption) error {
+	return c.WriteTxn(func(txn *Txn) error {
+		return txn.RemoveFromSet(id, path, values...)
+	}, opts...)
+}
+
+// AddToSet adds values to the set at path in the stored instance with the
+// given ID, and saves the result in the current transaction. Like Patch,
+// changes made earlier in the transaction aren't visible to the update.
+func (t *Txn) AddToSet(id core.InstanceID, path string, values ...string) error {
+	return t.updateSet(id, path, values, ORSet.add)
+}
+
+// RemoveFromSet removes values from the set at path in the stored instance
+// with the given ID, and saves the result in the current transaction. Like
+// Patch, changes made earlier in the transaction aren't visible to the update.
+func (t *Txn) RemoveFromSet(id core.InstanceID, path string, values ...string) error {
+	return t.updateSet(id, path, values, ORSet.remove)
+}
+
+// updateSet applies fn with each value to the set at path in an instance.
+func (t *Txn) updateSet(id core.InstanceID, path string, values []string, fn func(ORSet, string)) error {
+	if t.readonly {
+		return ErrReadonlyTx
+	}
+	current, err := t.FindByID(id)
+	if err != nil {
+		return err
+	}
+	set, err := getORSet(current, path)
+	if err != nil {
+		return err
+	}
+	for _, v := range values {
+		fn(set, v)
+	}
+	raw, err := json.Marshal(set)
+	if err != nil {
+		return err
+	}
+	updated, err := sjson.SetRawBytes(current, path, raw)
+	if err != nil {
+		return fmt.Errorf("%w: %v", ErrInvalidSet, err)
+	}
+	return t.Save(updated)
+}
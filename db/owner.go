@@ -0,0 +1,186 @@
+package db
+
+import (
+	"context"
+	"errors"
+	"sort"
+
+	ds "github.com/textileio/go-datastore"
+	"github.com/textileio/go-datastore/query"
+	"github.com/textileio/go-threads/core/thread"
+)
+
+// ErrNotDBOwner indicates an identity that doesn't own a db tried to manage
+// its delegates.
+var ErrNotDBOwner = errors.New("identity doesn't own the db")
+
+var dsDelegates = dsPrefix.ChildString("delegate")
+
+// loadDelegates loads the identities the owner of the db delegated access to.
+func (d *DB) loadDelegates() error {
+	res, err := d.datastore.Query(query.Query{Prefix: dsDelegates.String(), KeysOnly: true})
+	if err != nil {
+		return err
+	}
+	defer res.Close()
+	d.delegates = make(map[string]struct{})
+	for r := range res.Next() {
+		if r.Error != nil {
+			return r.Error
+		}
+		d.delegates[ds.RawKey(r.Key).Name()] = struct{}{}
+	}
+	return nil
+}
+
+// canAccess returns whether the identity of token can access the db. With
+// WithNewScopedDBs, dbs created with a token can only be accessed by its
+// identity and the identities it delegates to. Other dbs can be accessed by
+// any token.
+func (m *Manager) canAccess(id thread.ID, d *DB, token thread.Token) (bool, error) {
+	return m.canAccessOwned(id, d.owner, func(identity string) bool {
+		d.lock.RLock()
+		defer d.lock.RUnlock()
+		_, ok := d.delegates[identity]
+		return ok
+	}, token)
+}
+
+// canAccessIndexed is canAccess for the index entry of a db.
+func (m *Manager) canAccessIndexed(id thread.ID, e dbIndexEntry, token thread.Token) (bool, error) {
+	return m.canAccessOwned(id, e.Owner, func(identity string) bool {
+		i := sort.SearchStrings(e.Delegates, identity)
+		return i < len(e.Delegates) && e.Delegates[i] == identity
+	}, token)
+}
+
+// canAccessOwned returns whether the identity of token can access a db of
+// owner, or whose owner delegated access to it. The token is validated by
+// the network, so its identity can't be forged.
+func (m *Manager) canAccessOwned(id thread.ID, owner string, delegated func(identity string) bool, token thread.Token) (bool, error) {
+	if !m.opts.ScopedDBs || owner == "" {
+		return true, nil
+	}
+	pk, err := m.network.Validate(id, token, true)
+	if err != nil {
+		return false, err
+	}
+	if pk == nil {
+		return false, nil
+	}
+	identity := pk.String()
+	return identity == owner || delegated(identity), nil
+}
+
+// getAccessibleDB returns a managed db, or ErrDBNotFound if the identity of
+// token can't access it, so dbs of other identities aren't disclosed.
+// The db is released with ReleaseDB.
+func (m *Manager) getAccessibleDB(id thread.ID, token thread.Token) (*DB, error) {
+	d, err := m.getDB(id)
+	if err != nil {
+		return nil, err
+	}
+	ok, err := m.canAccess(id, d, token)
+	if err == nil && !ok {
+		err = ErrDBNotFound
+	}
+	if err != nil {
+		m.ReleaseDB(id)
+		return nil, err
+	}
+	return d, nil
+}
+
+// ownedDB returns a managed db if the identity of token owns it.
+// The db is released with ReleaseDB.
+func (m *Manager) ownedDB(ctx context.Context, id thread.ID, token thread.Token) (*DB, error) {
+	d, err := m.GetDB(ctx, id, WithManagedToken(token))
+	if err != nil {
+		return nil, err
+	}
+	pk, err := m.network.Validate(id, token, false)
+	if err == nil && (pk == nil || d.owner == "" || pk.String() != d.owner) {
+		err = ErrNotDBOwner
+	}
+	if err != nil {
+		m.ReleaseDB(id)
+		return nil, err
+	}
+	return d, nil
+}
+
+// AddDBDelegate gives delegate access to a db scoped to its owner, see
+// WithNewScopedDBs. Only the owner of the db can add delegates, and delegates
+// can't delegate access further.
+func (m *Manager) AddDBDelegate(ctx context.Context, id thread.ID, delegate thread.PubKey, opts ...ManagedOption) error {
+	args := &ManagedOptions{}
+	for _, opt := range opts {
+		opt(args)
+	}
+	d, err := m.ownedDB(ctx, id, args.Token)
+	if err != nil {
+		return err
+	}
+	defer m.ReleaseDB(id)
+	identity := delegate.String()
+	d.lock.Lock()
+	if err := d.datastore.Put(dsDelegates.ChildString(identity), nil); err != nil {
+		d.lock.Unlock()
+		return err
+	}
+	d.delegates[identity] = struct{}{}
+	d.lock.Unlock()
+	return m.indexDB(id, d)
+}
+
+// RemoveDBDelegate revokes the access of delegate to a db, see AddDBDelegate.
+func (m *Manager) RemoveDBDelegate(ctx context.Context, id thread.ID, delegate thread.PubKey, opts ...ManagedOption) error {
+	args := &ManagedOptions{}
+	for _, opt := range opts {
+		opt(args)
+	}
+	d, err := m.ownedDB(ctx, id, args.Token)
+	if err != nil {
+		return err
+	}
+	defer m.ReleaseDB(id)
+	identity := delegate.String()
+	d.lock.Lock()
+	if err := d.datastore.Delete(dsDelegates.ChildString(identity)); err != nil {
+		d.lock.Unlock()
+		return err
+	}
+	delete(d.delegates, identity)
+	d.lock.Unlock()
+	return m.indexDB(id, d)
+}
+
+// ListDBDelegates returns the identities the owner of a db delegated access
+// to, see AddDBDelegate.
+func (m *Manager) ListDBDelegates(ctx context.Context, id thread.ID, opts ...ManagedOption) ([]thread.PubKey, error) {
+	args := &ManagedOptions{}
+	for _, opt := range opts {
+		opt(args)
+	}
+	d, err := m.ownedDB(ctx, id, args.Token)
+	if err != nil {
+		return nil, err
+	}
+	defer m.ReleaseDB(id)
+	d.lock.RLock()
+	identities := make([]string, 0, len(d.delegates))
+	for identity := range d.delegates {
+		identities = append(identities, identity)
+	}
+	d.lock.RUnlock()
+	sort.Strings(identities)
+	delegates := make([]thread.PubKey, len(identities))
+	for i, identity := range identities {
+		pk := &thread.Libp2pPubKey{}
+		if err := pk.UnmarshalString(identity); err != nil {
+			return nil, err
+		}
+		delegates[i] = pk
+	}
+	return delegates, nil
+}
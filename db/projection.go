@@ -0,0 +1,120 @@
+package db
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/alecthomas/jsonschema"
+	"github.com/tidwall/gjson"
+	"github.com/tidwall/sjson"
+	"github.com/xeipuuv/gojsonschema"
+)
+
+// ErrProjectionMismatch indicates that the selected fields of a query don't
+// match its projection schema, or that a projected result doesn't conform to it.
+var ErrProjectionMismatch = errors.New("query selection doesn't match the projection")
+
+// Select starts a query whose results are restricted to the given field paths.
+// Over the API, only the selected fields are sent back to the client.
+func Select(paths ...string) *Query {
+	return (&Query{}).SelectFields(paths...)
+}
+
+// SelectFields restricts the query results to the given field paths.
+func (q *Query) SelectFields(paths ...string) *Query {
+	q.Select = append(q.Select, paths...)
+	return q
+}
+
+// ProjectTo validates the query results against a projection schema, e.g.,
+// the schema of a list-view struct obtained with util.SchemaFromInstance.
+// If no fields are selected, the top-level properties of the schema are selected.
+func (q *Query) ProjectTo(schema *jsonschema.Schema) *Query {
+	q.Projection = schema
+	return q
+}
+
+// projector shapes query results to the selected fields.
+type projector struct {
+	paths  []string
+	schema *gojsonschema.Schema
+}
+
+// newProjector returns a projector for the query selection, or nil if the
+// query doesn't select or project.
+func newProjector(q *Query) (*projector, error) {
+	if len(q.Select) == 0 && q.Projection == nil {
+		return nil, nil
+	}
+	p := &projector{paths: q.Select}
+	if q.Projection == nil {
+		return p, nil
+	}
+
+	props, err := getSchemaTypeProperties(q.Projection.Type, q.Projection.Definitions)
+	if err != nil {
+		return nil, err
+	}
+	if len(p.paths) == 0 {
+		for name := range props {
+			p.paths = append(p.paths, name)
+		}
+		sort.Strings(p.paths)
+	}
+	selected := make(map[string]struct{})
+	for _, pth := range p.paths {
+		if _, err := getSchemaTypeAtPath(q.Projection, pth); err != nil {
+			return nil, fmt.Errorf("%w: selected field %s isn't in the projection schema", ErrProjectionMismatch, pth)
+		}
+		selected[strings.Split(pth, ".")[0]] = struct{}{}
+	}
+	for name := range props {
+		if _, ok := selected[name]; !ok {
+			return nil, fmt.Errorf("%w: projection field %s isn't selected", ErrProjectionMismatch, name)
+		}
+	}
+
+	sb, err := json.Marshal(q.Projection)
+	if err != nil {
+		return nil, err
+	}
+	p.schema, err = gojsonschema.NewSchema(gojsonschema.NewBytesLoader(sb))
+	if err != nil {
+		return nil, err
+	}
+	return p, nil
+}
+
+// project returns the selected fields of v. Selected fields missing from v
+// are left out of the result.
+func (p *projector) project(v []byte) ([]byte, error) {
+	res := []byte("{}")
+	for _, pth := range p.paths {
+		r := gjson.GetBytes(v, pth)
+		if !r.Exists() {
+			continue
+		}
+		var err error
+		if res, err = sjson.SetRawBytes(res, pth, []byte(r.Raw)); err != nil {
+			return nil, err
+		}
+	}
+	if p.schema == nil {
+		return res, nil
+	}
+	r, err := p.schema.Validate(gojsonschema.NewBytesLoader(res))
+	if err != nil {
+		return nil, err
+	}
+	if errs := r.Errors(); len(errs) > 0 {
+		msgs := make([]string, len(errs))
+		for i, e := range errs {
+			msgs[i] = e.Field() + ": " + e.Description()
+		}
+		return nil, fmt.Errorf("%w: %s", ErrProjectionMismatch, strings.Join(msgs, "; "))
+	}
+	return res, nil
+}
@@ -1,24 +1,36 @@
 package db
 
 import (
+	"encoding/json"
 	"errors"
 	"fmt"
 	"reflect"
+	"regexp"
 	"sort"
 	"strings"
+	"time"
 
+	"github.com/alecthomas/jsonschema"
+	"github.com/textileio/go-datastore/query"
 	core "github.com/textileio/go-threads/core/db"
+	"github.com/tidwall/sjson"
 )
 
 // Query is a json-seriable query representation.
 type Query struct {
-	Ands  []*Criterion
-	Ors   []*Query
-	Sort  Sort
-	Seek  core.InstanceID
-	Limit int
-	Skip  int
-	Index string
+	Ands         []*Criterion
+	Ors          []*Query
+	Sort         Sort
+	ThenSort     []Sort
+	Seek         core.InstanceID
+	Limit        int
+	Skip         int
+	After        string
+	Index        string
+	WithDeleted  bool
+	DeletedSince int64
+	Select       []string
+	Projection   *jsonschema.Schema
 }
 
 // Criterion represents a restriction on a field.
@@ -26,7 +38,13 @@ type Criterion struct {
 	FieldPath string
 	Operation Operation
 	Value     Value
-	query     *Query
+	Values    []Value
+	// CaseInsensitive compares string values ignoring case.
+	CaseInsensitive bool
+	query           *Query
+	// regexp is the Matches pattern compiled by Validate, so it's compiled
+	// once per query execution instead of once per matched instance.
+	regexp *regexp.Regexp
 }
 
 // Value models a single value in JSON.
@@ -41,6 +59,15 @@ func (q *Query) Validate() error {
 	if q == nil {
 		return nil
 	}
+	if q.After != "" && q.Seek != "" {
+		return errors.New("query can't both seek and continue after a cursor")
+	}
+	if len(q.ThenSort) > 0 && q.Sort.FieldPath == "" {
+		return errors.New("query can't have secondary orders without a primary order")
+	}
+	if q.After != "" && q.WithDeleted {
+		return errors.New("cursors can't be used with deleted instances")
+	}
 	for _, a := range q.Ands {
 		if err := a.Validate(); err != nil {
 			return err
@@ -59,14 +86,53 @@ func (c *Criterion) Validate() error {
 	if c == nil {
 		return nil
 	}
+	if c.Operation == In || c.Operation == NotIn {
+		for _, v := range c.Values {
+			if err := v.validate(); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+	if c.Operation == Near || c.Operation == WithinBox {
+		_, err := c.geoArgs()
+		return err
+	}
+	if err := c.Value.validate(); err != nil {
+		return err
+	}
+	if c.Operation == Matches {
+		if c.Value.String == nil {
+			return fmt.Errorf("regular expression should be a string")
+		}
+		r, err := c.compileRegexp()
+		if err != nil {
+			return err
+		}
+		c.regexp = r
+	}
+	return nil
+}
+
+// compileRegexp compiles the Matches pattern of the criterion.
+func (c *Criterion) compileRegexp() (*regexp.Regexp, error) {
+	pattern := *c.Value.String
+	if c.CaseInsensitive {
+		pattern = "(?i)" + pattern
+	}
+	return regexp.Compile(pattern)
+}
+
+// validate validates a single value.
+func (v Value) validate() error {
 	noNil := 0
-	if c.Value.Bool != nil {
+	if v.Bool != nil {
 		noNil++
 	}
-	if c.Value.String != nil {
+	if v.String != nil {
 		noNil++
 	}
-	if c.Value.Float != nil {
+	if v.Float != nil {
 		noNil++
 	}
 	if noNil != 1 {
@@ -79,6 +145,8 @@ func (c *Criterion) Validate() error {
 type Sort struct {
 	FieldPath string
 	Desc      bool
+	// CaseInsensitive orders string values ignoring case.
+	CaseInsensitive bool
 }
 
 // Operation models comparison operators.
@@ -97,6 +165,22 @@ const (
 	Ge = Operation(ge)
 	// Le is "less than or equal to"
 	Le = Operation(le)
+	// TextSearch is "contains all of the terms"
+	TextSearch = Operation(text)
+	// In is "equals one of"
+	In = Operation(in)
+	// NotIn is "equals none of"
+	NotIn = Operation(nin)
+	// Matches is "matches the regular expression"
+	Matches = Operation(re)
+	// Near is "within a distance of"
+	Near = Operation(near)
+	// WithinBox is "within the bounding box"
+	WithinBox = Operation(box)
+	// Exists is "has the field", even if it's null
+	Exists = Operation(exists)
+	// IsNull is "has the field set to null"
+	IsNull = Operation(null)
 )
 
 var (
@@ -153,6 +237,10 @@ func (q *Query) And(field string) *Criterion {
 }
 
 // UseIndex specifies the index to use when running this query.
+// The hint is enforced rather than advisory: Find returns ErrIndexNotFound if
+// the collection has no index at path, and ErrIndexNotUsable if the index can't
+// serve the query instead of falling back to a scan. An index can serve a query
+// when every condition of each of its Or branches is on the indexed path.
 func (q *Query) UseIndex(path string) *Query {
 	q.Index = path
 	return q
@@ -167,37 +255,72 @@ func (q *Query) Or(orQuery *Query) *Query {
 }
 
 // OrderBy specifies ascending order for the query results.
-// On multiple calls, only the last one is considered.
+// On multiple calls, results are ordered by each field in turn,
+// with ties broken by ascending ID.
 func (q *Query) OrderBy(field string) *Query {
-	q.Sort.FieldPath = field
-	q.Sort.Desc = false
-	return q
+	return q.addSort(field, false)
 }
 
 // OrderByDesc specifies descending order for the query results.
-// On multiple calls, only the last one is considered.
+// On multiple calls, results are ordered by each field in turn,
+// with ties broken by ascending ID.
 func (q *Query) OrderByDesc(field string) *Query {
-	q.Sort.FieldPath = field
-	q.Sort.Desc = true
-	return q
+	return q.addSort(field, true)
 }
 
 // OrderByID specifies ascending ID order for the query results.
-// On multiple calls, only the last one is considered.
+// Calls after ordering by the ID have no effect.
 func (q *Query) OrderByID() *Query {
-	q.Sort.FieldPath = idFieldName
-	q.Sort.Desc = false
-	return q
+	return q.addSort(idFieldName, false)
 }
 
 // OrderByIDDesc specifies descending ID order for the query results.
-// On multiple calls, only the last one is considered.
+// Calls after ordering by the ID have no effect.
 func (q *Query) OrderByIDDesc() *Query {
-	q.Sort.FieldPath = idFieldName
-	q.Sort.Desc = true
+	return q.addSort(idFieldName, true)
+}
+
+// OrderByInsensitive specifies ascending order for the query results,
+// ignoring the case of string values. See OrderBy.
+func (q *Query) OrderByInsensitive(field string) *Query {
+	return q.addSortOrder(Sort{FieldPath: field, CaseInsensitive: true})
+}
+
+// OrderByDescInsensitive specifies descending order for the query results,
+// ignoring the case of string values. See OrderByDesc.
+func (q *Query) OrderByDescInsensitive(field string) *Query {
+	return q.addSortOrder(Sort{FieldPath: field, Desc: true, CaseInsensitive: true})
+}
+
+// addSort adds an order to the query, after any existing orders.
+func (q *Query) addSort(field string, desc bool) *Query {
+	return q.addSortOrder(Sort{FieldPath: field, Desc: desc})
+}
+
+func (q *Query) addSortOrder(s Sort) *Query {
+	if q.Sort.FieldPath == "" {
+		q.Sort = s
+	} else {
+		q.ThenSort = append(q.ThenSort, s)
+	}
 	return q
 }
 
+// sorts returns the orders of the query, up to the first order by ID.
+func (q *Query) sorts() []Sort {
+	if q.Sort.FieldPath == "" {
+		return nil
+	}
+	sorts := []Sort{q.Sort}
+	for _, s := range q.ThenSort {
+		if sorts[len(sorts)-1].FieldPath == idFieldName {
+			break
+		}
+		sorts = append(sorts, s)
+	}
+	return sorts
+}
+
 // SeekID seeks to the given ID before returning query results.
 func (q *Query) SeekID(id core.InstanceID) *Query {
 	q.Seek = id
@@ -205,6 +328,9 @@ func (q *Query) SeekID(id core.InstanceID) *Query {
 }
 
 // LimitTo sets the maximum number of results.
+// Unless the query is sorted by a field other than the ID, the query stops
+// scanning instances as soon as the limit is reached. Sorting by other fields
+// happens in memory, so all matching instances must be scanned first.
 func (q *Query) LimitTo(limit int) *Query {
 	q.Limit = limit
 	return q
@@ -248,6 +374,54 @@ func (c *Criterion) Le(value interface{}) *Query {
 	return c.createcriterion(Le, value)
 }
 
+// EqInsensitive is an equality operator against a string field, ignoring case.
+func (c *Criterion) EqInsensitive(value string) *Query {
+	return c.Insensitive().Eq(value)
+}
+
+// Insensitive makes the following operator compare string values ignoring case,
+// e.g., Where("name").Insensitive().In("alice", "bob"). Queries can use an index
+// on the field only if it's also case-insensitive, see Index.CaseInsensitive.
+func (c *Criterion) Insensitive() *Criterion {
+	c.CaseInsensitive = true
+	return c
+}
+
+// Matches is a regular expression operator against a string field.
+// The pattern uses RE2 syntax, see https://golang.org/s/re2syntax.
+func (c *Criterion) Matches(pattern string) *Query {
+	return c.createcriterion(Matches, pattern)
+}
+
+// In is a set membership operator against a field
+func (c *Criterion) In(values ...interface{}) *Query {
+	return c.createsetcriterion(In, values)
+}
+
+// NotIn is a set exclusion operator against a field
+func (c *Criterion) NotIn(values ...interface{}) *Query {
+	return c.createsetcriterion(NotIn, values)
+}
+
+// Exists is a presence operator against a field. It matches instances that
+// have the field, even if it's null or a zero value. Unlike other operators,
+// it doesn't fail on instances without the field.
+func (c *Criterion) Exists() *Query {
+	return c.createcriterion(Exists, true)
+}
+
+// NotExists is the negation of Exists, matching instances without the field.
+func (c *Criterion) NotExists() *Query {
+	return c.createcriterion(Exists, false)
+}
+
+// IsNull is a null operator against a field. It matches instances that have
+// the field set to null, but not instances without the field, or with a zero value.
+// Unlike other operators, it doesn't fail on instances without the field.
+func (c *Criterion) IsNull() *Query {
+	return c.createcriterion(IsNull, true)
+}
+
 func createValue(value interface{}) Value {
 	s, ok := value.(string)
 	if ok {
@@ -276,6 +450,19 @@ func createValue(value interface{}) Value {
 	return Value{}
 }
 
+func (c *Criterion) createsetcriterion(op Operation, values []interface{}) *Query {
+	c.Operation = op
+	c.Values = make([]Value, len(values))
+	for i, v := range values {
+		c.Values[i] = createValue(v)
+	}
+	if c.query == nil {
+		c.query = &Query{}
+	}
+	c.query.Ands = append(c.query.Ands, c)
+	return c.query
+}
+
 func (c *Criterion) createcriterion(op Operation, value interface{}) *Query {
 	c.Operation = op
 	c.Value = createValue(value)
@@ -288,79 +475,402 @@ func (c *Criterion) createcriterion(op Operation, value interface{}) *Query {
 
 // Find queries for instances by Query
 func (t *Txn) Find(q *Query) ([][]byte, error) {
+	res, _, err := t.find(q)
+	return res, err
+}
+
+// FindPage queries for a page of instances by Query, with a maximum of
+// q.Limit instances. If the page is full, the returned cursor continues the
+// results after the page, see Query.AfterCursor. Unless the query is sorted
+// by a field other than the ID, continuing doesn't re-scan earlier pages.
+func (t *Txn) FindPage(q *Query) (instances [][]byte, cursor string, err error) {
+	return t.find(q)
+}
+
+// Count returns the number of instances matching the criteria of q. Ordering,
+// paging, and selection in q are ignored. Instances aren't returned, and if q
+// uses an index and the collection has no read filter, they aren't read at all.
+func (t *Txn) Count(q *Query) (int, error) {
+	defer t.collection.db.metrics.observeQuery(t.collection.name, time.Now())
 	if err := t.collection.db.connector.Validate(t.token, true); err != nil {
-		return nil, err
+		return 0, err
 	}
 	if q == nil {
 		q = &Query{}
 	}
 	if err := q.Validate(); err != nil {
-		return nil, fmt.Errorf("invalid query: %s", err)
+		return 0, fmt.Errorf("invalid query: %s", err)
 	}
-	txn, err := t.collection.db.datastore.NewTransaction(true)
+	if q.Index != "" {
+		if err := t.collection.checkIndexHint(q); err != nil {
+			return 0, err
+		}
+	}
+	cq := &Query{Ands: q.Ands, Ors: q.Ors, Index: q.Index}
+	txn, err := t.readTxn()
 	if err != nil {
-		return nil, fmt.Errorf("error building internal query: %v", err)
+		return 0, fmt.Errorf("error building internal query: %v", err)
 	}
 	defer txn.Discard()
-	iter := newIterator(txn, t.collection.baseKey(), q)
+	var iter *iterator
+	keys, ok, err := t.collection.textCandidates(txn, cq)
+	if err != nil {
+		return 0, err
+	}
+	if !ok {
+		if keys, ok, err = t.collection.geoCandidates(txn, cq); err != nil {
+			return 0, err
+		}
+	}
+	if ok {
+		iter = newKeysIterator(txn, cq, keys)
+	} else {
+		iter = newIterator(txn, t.collection.baseKey(), cq, t.collection.indexes[cq.Index])
+	}
+	iter.ctx = t.ctx
+	iter.maxScan = t.collection.db.maxQueryScan
 	defer iter.Close()
 
+	var count int
+	if !iter.matchKeys && iter.keyed && !t.collection.hasReadFilter() {
+		// Index entries list the keys of all their matching instances
+		for {
+			if err := t.checkContext(); err != nil {
+				return 0, err
+			}
+			keys, err := iter.nextKeys()
+			if err != nil {
+				return 0, err
+			}
+			if len(keys) == 0 {
+				return count, nil
+			}
+			count += len(keys)
+		}
+	}
 	pk, err := t.token.PubKey()
 	if err != nil {
-		return nil, err
+		return 0, err
+	}
+	for {
+		res, ok := iter.NextSync()
+		if !ok {
+			if res.Error != nil {
+				return 0, res.Error
+			}
+			return count, nil
+		}
+		if t.collection.hasReadFilter() {
+			if res.Value, err = t.collection.filterRead(pk, res.Value); err != nil {
+				return 0, err
+			}
+			if res.Value == nil {
+				continue
+			}
+		}
+		count++
+	}
+}
+
+// FindIterate queries for instances by Query, calling fn with each instance
+// in order. Unless the query is sorted by a field other than the ID, instances
+// are streamed from the datastore instead of being collected in memory first.
+// If fn returns an error, the iteration stops and the error is returned.
+func (t *Txn) FindIterate(q *Query, fn func(instance []byte) error) error {
+	_, err := t.iterate(q, queryLimits{scan: t.collection.db.maxQueryScan}, func(_ MarshaledResult, instance []byte) error {
+		return fn(instance)
+	})
+	return err
+}
+
+func (t *Txn) find(q *Query) ([][]byte, string, error) {
+	var res [][]byte
+	var last MarshaledResult
+	pos, err := t.iterate(q, t.collection.db.queryLimits(), func(r MarshaledResult, instance []byte) error {
+		res = append(res, instance)
+		last = r
+		return nil
+	})
+	if err != nil {
+		return nil, "", err
+	}
+	var next string
+	if pos.q.Limit > 0 && len(res) == pos.q.Limit {
+		// Cursors are positioned with unfiltered values, like the sort
+		v, err := json.Marshal(last.MarshaledValue)
+		if err != nil {
+			return nil, "", err
+		}
+		c, err := pos.cursor(v)
+		if err != nil {
+			return nil, "", err
+		}
+		if next, err = encodeCursor(c); err != nil {
+			return nil, "", err
+		}
+	}
+	return res, next, nil
+}
+
+// iterate runs q within limits, calling fn with each result and its read-filtered
+// and projected instance. It returns the positioner of the query results.
+func (t *Txn) iterate(q *Query, limits queryLimits, fn func(res MarshaledResult, instance []byte) error) (positioner, error) {
+	defer t.collection.db.metrics.observeQuery(t.collection.name, time.Now())
+	if err := t.collection.db.connector.Validate(t.token, true); err != nil {
+		return positioner{}, err
+	}
+	if q == nil {
+		q = &Query{}
+	}
+	if err := q.Validate(); err != nil {
+		return positioner{}, fmt.Errorf("invalid query: %s", err)
+	}
+	if q.Sort.FieldPath == "" && t.collection.defaultOrder.FieldPath != "" {
+		dq := *q
+		dq.Sort = t.collection.defaultOrder
+		q = &dq
+	}
+	if q.Index != "" {
+		if err := t.collection.checkIndexHint(q); err != nil {
+			return positioner{}, err
+		}
+	}
+	proj, err := newProjector(q)
+	if err != nil {
+		return positioner{}, err
+	}
+	// Without an in-memory sort, results come out of the iterator in their final
+	// order, so skip and limit can be applied while scanning and the scan can stop
+	// as soon as the limit is reached. Otherwise, all matches must be collected.
+	inMemorySort := (q.Sort.FieldPath != "" && q.Sort.FieldPath != idFieldName) || q.WithDeleted
+	pos := positioner{q: q, index: t.collection.indexes[q.Index], inMemorySort: inMemorySort}
+	var after *cursor
+	if q.After != "" {
+		if after, err = decodeCursor(q); err != nil {
+			return pos, err
+		}
+	}
+	txn, err := t.readTxn()
+	if err != nil {
+		return pos, fmt.Errorf("error building internal query: %v", err)
+	}
+	defer txn.Discard()
+	var iter *iterator
+	keys, ok, err := t.collection.textCandidates(txn, q)
+	if err != nil {
+		return pos, err
+	}
+	if !ok {
+		if keys, ok, err = t.collection.geoCandidates(txn, q); err != nil {
+			return pos, err
+		}
+	}
+	if ok {
+		if after != nil {
+			keys = keysAfter(keys, after)
+		}
+		iter = newKeysIterator(txn, q, keys)
+	} else {
+		iq := q
+		if after != nil {
+			iq = pos.seek(after)
+		}
+		iter = newIterator(txn, t.collection.baseKey(), iq, t.collection.indexes[q.Index])
+	}
+	iter.ctx = t.ctx
+	iter.maxScan = limits.scan
+	defer iter.Close()
+
+	pk, err := t.token.PubKey()
+	if err != nil {
+		return pos, err
+	}
+	emit := func(res MarshaledResult) error {
+		instance := res.Value
+		if proj != nil {
+			if instance, err = proj.project(instance); err != nil {
+				return err
+			}
+		}
+		return fn(res, instance)
 	}
 	var values []MarshaledResult
+	var skipped, emitted int
+	pending := t.collection.pendingMigration()
 	for {
+		if !inMemorySort && q.Limit > 0 && emitted >= q.Limit {
+			break
+		}
 		res, ok := iter.NextSync()
 		if !ok {
+			if err := t.checkContext(); err != nil {
+				return pos, err
+			}
+			var lerr *QueryLimitError
+			if errors.As(res.Error, &lerr) {
+				return pos, res.Error
+			}
 			break
 		}
-		res.Value, err = t.collection.filterRead(pk, res.Value)
+		if after != nil {
+			if ok, err := pos.after(after, res.Value); err != nil {
+				return pos, err
+			} else if !ok {
+				continue
+			}
+		}
+		if pending {
+			if res, err = t.collection.migrateResult(res); err != nil {
+				return pos, err
+			}
+		}
+		res.Value, err = t.collection.readInstance(pk, res.Value)
 		if err != nil {
-			return nil, err
+			return pos, err
+		}
+		if res.Value == nil {
+			continue
 		}
-		if res.Value != nil {
+		if inMemorySort {
 			values = append(values, res)
+			continue
+		}
+		if skipped < q.Skip {
+			skipped++
+			continue
+		}
+		if limits.results > 0 && emitted >= limits.results {
+			return pos, &QueryLimitError{Max: limits.results}
+		}
+		if err := emit(res); err != nil {
+			return pos, err
 		}
+		emitted++
+	}
+	if !inMemorySort {
+		return pos, nil
 	}
 
-	if q.Sort.FieldPath != "" && q.Sort.FieldPath != idFieldName {
-		var wrongField, cantCompare bool
-		sort.Slice(values, func(i, j int) bool {
-			fieldI, err := traverseFieldPathMap(values[i].MarshaledValue, q.Sort.FieldPath)
+	if q.WithDeleted {
+		var markErr error
+		err = t.collection.iterateDeleted(t.reader(), pk, q, func(d DeletedInstance, val map[string]interface{}) bool {
+			// Deleted instances are marked with their deletion time
+			instance, err := sjson.SetBytes(d.Instance, deletedFieldName, d.Deleted.UnixNano())
 			if err != nil {
-				wrongField = true
+				markErr = err
 				return false
 			}
-			fieldJ, err := traverseFieldPathMap(values[j].MarshaledValue, q.Sort.FieldPath)
-			if err != nil {
-				wrongField = true
-				return false
-			}
-			res, err := compare(fieldI.Interface(), fieldJ.Interface())
+			values = append(values, MarshaledResult{
+				Result:         query.Result{Entry: query.Entry{Value: instance}},
+				MarshaledValue: val,
+			})
+			return true
+		})
+		if err != nil {
+			return pos, err
+		}
+		if markErr != nil {
+			return pos, markErr
+		}
+	}
+
+	if q.Sort.FieldPath != "" && q.Sort.FieldPath != idFieldName {
+		sorts := q.sorts()
+		var sortErr error
+		sort.Slice(values, func(i, j int) bool {
+			res, err := compareSorted(values[i].MarshaledValue, values[j].MarshaledValue, sorts)
 			if err != nil {
-				cantCompare = true
+				sortErr = err
 				return false
 			}
-			if q.Sort.Desc {
-				res *= -1
+			if res == 0 {
+				// Ties are ordered by instance key, so cursors have a stable position
+				res = strings.Compare(values[i].Key, values[j].Key)
 			}
 			return res < 0
 		})
-		if wrongField {
-			return nil, ErrInvalidSortingField
+		if errors.Is(sortErr, ErrInvalidSortingField) {
+			return pos, sortErr
 		}
-		if cantCompare {
+		if sortErr != nil {
 			panic("can't compare while sorting")
 		}
 	}
 
-	res := make([][]byte, len(values))
-	for i := range values {
-		res[i] = values[i].Value
+	window := applySkipLimit(values, q.Skip, q.Limit)
+	if limits.results > 0 && len(window) > limits.results {
+		return pos, &QueryLimitError{Max: limits.results}
 	}
+	for _, res := range window {
+		if err := emit(res); err != nil {
+			return pos, err
+		}
+	}
+	return pos, nil
+}
 
-	return res, nil
+// compareSorted compares two instances by each of the sort orders in turn.
+func compareSorted(a, b map[string]interface{}, sorts []Sort) (int, error) {
+	for _, s := range sorts {
+		fieldA, err := traverseFieldPathMap(a, s.FieldPath)
+		if err != nil {
+			return 0, ErrInvalidSortingField
+		}
+		fieldB, err := traverseFieldPathMap(b, s.FieldPath)
+		if err != nil {
+			return 0, ErrInvalidSortingField
+		}
+		res, err := compare(s.sortValue(fieldA.Interface()), s.sortValue(fieldB.Interface()))
+		if err != nil {
+			return 0, err
+		}
+		if s.Desc {
+			res *= -1
+		}
+		if res != 0 {
+			return res, nil
+		}
+	}
+	return 0, nil
+}
+
+// sortValue returns the value of a field that is ordered by the sort.
+func (s Sort) sortValue(v interface{}) interface{} {
+	if s.CaseInsensitive {
+		return foldCase(v)
+	}
+	return v
+}
+
+// foldCase returns the lower case of string values, and other values as is.
+func foldCase(v interface{}) interface{} {
+	if s, ok := v.(string); ok {
+		return strings.ToLower(s)
+	}
+	return v
+}
+
+// foldValue returns a criterion value with the lower case of its string.
+func foldValue(v Value) Value {
+	if v.String == nil {
+		return v
+	}
+	s := strings.ToLower(*v.String)
+	return Value{String: &s}
+}
+
+// applySkipLimit returns the window of values after skipping skip results, limited to limit results.
+func applySkipLimit(values []MarshaledResult, skip, limit int) []MarshaledResult {
+	if skip > 0 {
+		if skip >= len(values) {
+			return nil
+		}
+		values = values[skip:]
+	}
+	if limit > 0 && limit < len(values) {
+		values = values[:limit]
+	}
+	return values
 }
 
 func (q *Query) match(v map[string]interface{}) (bool, error) {
@@ -370,13 +880,17 @@ func (q *Query) match(v map[string]interface{}) (bool, error) {
 
 	andOk := true
 	for _, c := range q.Ands {
-		fieldRes, err := traverseFieldPathMap(v, c.FieldPath)
-		if err != nil {
-			return false, err
-		}
-		ok, err := c.match(fieldRes)
-		if err != nil {
-			return false, err
+		var ok bool
+		if c.Operation == Exists || c.Operation == IsNull {
+			ok = c.matchPresence(v)
+		} else {
+			fieldRes, err := traverseFieldPathMap(v, c.FieldPath)
+			if err != nil {
+				return false, err
+			}
+			if ok, err = c.match(fieldRes); err != nil {
+				return false, err
+			}
 		}
 		andOk = andOk && ok
 		if !andOk {
@@ -436,8 +950,54 @@ func compareValue(value interface{}, critVal Value) (int, error) {
 }
 
 func (c *Criterion) match(value reflect.Value) (bool, error) {
-	valueInterface := value.Interface()
-	result, err := compareValue(valueInterface, c.Value)
+	var valueInterface interface{}
+	if value.IsValid() { // Null values are invalid
+		valueInterface = value.Interface()
+	}
+	critVal := c.Value
+	if c.CaseInsensitive {
+		valueInterface = foldCase(valueInterface)
+		critVal = foldValue(critVal)
+	}
+	if c.Operation == TextSearch {
+		s, ok := valueInterface.(string)
+		if !ok || c.Value.String == nil {
+			return false, &errTypeMismatch{valueInterface, c.Value}
+		}
+		return textMatch(s, *c.Value.String), nil
+	}
+	if c.Operation == Near || c.Operation == WithinBox {
+		return c.geoMatch(valueInterface)
+	}
+	if c.Operation == Matches {
+		s, ok := valueInterface.(string)
+		if !ok || c.Value.String == nil {
+			return false, &errTypeMismatch{valueInterface, c.Value}
+		}
+		r := c.regexp
+		if r == nil {
+			var err error
+			if r, err = c.compileRegexp(); err != nil {
+				return false, err
+			}
+		}
+		return r.MatchString(s), nil
+	}
+	if c.Operation == In || c.Operation == NotIn {
+		var found bool
+		for _, v := range c.Values {
+			if c.CaseInsensitive {
+				v = foldValue(v)
+			}
+			// Values of other types are never equal
+			if result, err := compareValue(valueInterface, v); err == nil && result == 0 {
+				found = true
+				break
+			}
+		}
+		return found == (c.Operation == In), nil
+	}
+	result, err := compareValue(valueInterface, critVal)
 	if err != nil {
 		return false, err
 	}
@@ -460,6 +1020,34 @@ func (c *Criterion) match(value reflect.Value) (bool, error) {
 
 }
 
+// hasStringValue returns whether the criterion compares against a string value.
+func (c *Criterion) hasStringValue() bool {
+	if c.Value.String != nil {
+		return true
+	}
+	for _, v := range c.Values {
+		if v.String != nil {
+			return true
+		}
+	}
+	return false
+}
+
+// matchPresence matches the Exists and IsNull operators against an instance.
+// A false criterion value negates the match, e.g., Exists with false matches
+// instances without the field, and IsNull with false the ones that lack the
+// field or have a value.
+func (c *Criterion) matchPresence(v map[string]interface{}) bool {
+	value, err := traverseFieldPathMap(v, c.FieldPath)
+	var ok bool
+	if c.Operation == Exists {
+		ok = err == nil
+	} else {
+		ok = err == nil && !value.IsValid()
+	}
+	return ok == (c.Value.Bool == nil || *c.Value.Bool)
+}
+
 func traverseFieldPathMap(value map[string]interface{}, fieldPath string) (reflect.Value, error) {
 	fields := strings.Split(fieldPath, ".")
 
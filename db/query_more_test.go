@@ -89,6 +89,11 @@ var (
 
 		{name: "SortAllAscFloat", query: OrderBy("Meta.Rating"), resIdx: []int{0, 1, 2, 3, 4}, ordered: true},
 		{name: "SortAllDescFloat", query: OrderByDesc("Meta.Rating"), resIdx: []int{4, 3, 2, 1, 0}, ordered: true},
+
+		{name: "SortAscIntLimit", query: OrderBy("Meta.TotalReads").LimitTo(2), resIdx: []int{0, 1}, ordered: true},
+		{name: "SortDescIntSkipLimit", query: OrderByDesc("Meta.TotalReads").SkipNum(1).LimitTo(2), resIdx: []int{3, 2}, ordered: true},
+		{name: "FilterLimitLastMatch", query: Where("Author").Eq("Author3").LimitTo(1), resIdx: []int{4}},
+		{name: "FilterSkipAll", query: Where("Author").Eq("Author2").SkipNum(1), resIdx: []int{}},
 	}
 )
 
@@ -134,6 +139,28 @@ func TestCollectionQuery(t *testing.T) {
 	}
 }
 
+func TestQueryLimitAfterMatch(t *testing.T) {
+	t.Parallel()
+	c, _, clean := createCollectionWithData(t)
+	defer clean()
+
+	all, err := c.Find(Where("Author").Eq("Author1").OrderByID())
+	checkErr(t, err)
+	if len(all) != 3 {
+		t.Fatalf("expected 3 results, got %d", len(all))
+	}
+	limited, err := c.Find(Where("Author").Eq("Author1").OrderByID().LimitTo(2))
+	checkErr(t, err)
+	if !reflect.DeepEqual(all[:2], limited) {
+		t.Fatal("limited results should be the first matches")
+	}
+	skipped, err := c.Find(Where("Author").Eq("Author1").OrderByID().SkipNum(2).LimitTo(2))
+	checkErr(t, err)
+	if !reflect.DeepEqual(all[2:], skipped) {
+		t.Fatal("skipped results should be the remaining matches")
+	}
+}
+
 func TestInvalidSortField(t *testing.T) {
 	t.Parallel()
 
@@ -1,6 +1,8 @@
 package db
 
 import (
+	"context"
+	"errors"
 	"reflect"
 	"sort"
 	"strings"
@@ -243,7 +245,61 @@ var (
 			query:   Where("Meta.Rating").Gt(&ratingMid).OrderByDesc("Meta.TotalReads"),
 			ordered: true,
 		},
+		{
+			name:    "AllOrderedAuthorThenTotalReadsDesc",
+			resIdx:  []int{1, 0, 2, 3},
+			query:   OrderBy("Author").OrderByDesc("Meta.TotalReads"),
+			ordered: true,
+		},
+		{
+			name:    "AllOrderedAuthorDescThenTitle",
+			resIdx:  []int{3, 2, 0, 1},
+			query:   OrderByDesc("Author").OrderBy("Title"),
+			ordered: true,
+		},
+		// Set membership
+		{
+			name:   "InAuthors",
+			resIdx: []int{0, 1, 3},
+			query:  Where("Author").In("Author1", "Author3"),
+		},
+		{
+			name:   "NotInAuthors",
+			resIdx: []int{2},
+			query:  Where("Author").NotIn("Author1", "Author3"),
+		},
+		{
+			name:   "InTotalReadsMixedTypes",
+			resIdx: []int{0, 1},
+			query:  Where("Meta.TotalReads").In(totreadEq1, "150", totreadEq2),
+		},
+		{
+			name:   "InEmpty",
+			resIdx: []int{},
+			query:  Where("Author").In(),
+		},
+		// Regular expressions
+		{
+			name:   "MatchesTitle",
+			resIdx: []int{0, 2},
+			query:  Where("Title").Matches("^Title[13]$"),
+		},
+		{
+			name:   "MatchesAuthorCaseInsensitive",
+			resIdx: []int{2},
+			query:  Where("Author").Matches("(?i)author2"),
+		},
+		{
+			name:   "MatchesTitleUseIndex",
+			resIdx: []int{1, 3},
+			query:  Where("Title").Matches("[24]").UseIndex("Title"),
+		},
 		// Indexing
+		{
+			name:   "InTitlesUseIndex",
+			resIdx: []int{0, 2},
+			query:  Where("Title").In(title0, title3).UseIndex("Title"),
+		},
 		{
 			name:   "EqTitle1OrTitle3UseIndex",
 			resIdx: []int{0, 2},
@@ -254,11 +310,6 @@ var (
 			resIdx: []int{0, 1, 2, 3},
 			query:  Where("Meta.TotalReads").Ge(&totreadMin).UseIndex("Meta.TotalReads"),
 		},
-		{
-			name:   "InvalidIndex",
-			resIdx: []int{},
-			query:  Where("Meta.TotalReads").Ge(&totreadMin).UseIndex("Not.Valid.Path"),
-		},
 	}
 )
 
@@ -305,6 +356,861 @@ func TestQuery(t *testing.T) {
 	}
 }
 
+func TestQueryInvalidPattern(t *testing.T) {
+	c, _, clean := createCollectionWithJSONData(t)
+	defer clean()
+	if _, err := c.Find(Where("Title").Matches("(")); err == nil {
+		t.Fatal("expected invalid pattern error")
+	}
+}
+
+func TestQueryPresence(t *testing.T) {
+	t.Parallel()
+	d, clean := createTestDB(t)
+	defer clean()
+	c, err := d.NewCollection(CollectionConfig{
+		Name: "Note",
+		Schema: util.SchemaFromSchemaString(`{
+			"type": "object",
+			"properties": {"_id": {"type": "string"}, "Name": {"type": "string"}, "Note": {}}
+		}`),
+	})
+	checkErr(t, err)
+	for _, v := range []string{
+		`{"Name": "missing"}`,
+		`{"Name": "null", "Note": null}`,
+		`{"Name": "zero", "Note": ""}`,
+		`{"Name": "value", "Note": "foo"}`,
+	} {
+		_, err := c.Create([]byte(v))
+		checkErr(t, err)
+	}
+	for _, tc := range []struct {
+		name  string
+		query *Query
+		names []string
+	}{
+		{name: "Exists", query: Where("Note").Exists(), names: []string{"null", "value", "zero"}},
+		{name: "NotExists", query: Where("Note").NotExists(), names: []string{"missing"}},
+		{name: "IsNull", query: Where("Note").IsNull(), names: []string{"null"}},
+		{name: "NestedMissing", query: Where("Note.Foo").NotExists(), names: []string{"missing", "null", "value", "zero"}},
+		{name: "And", query: Where("Note").Exists().And("Name").Gt("v"), names: []string{"value", "zero"}},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			res, err := c.Find(tc.query)
+			checkErr(t, err)
+			var names []string
+			for _, r := range res {
+				v := &struct{ Name string }{}
+				util.InstanceFromJSON(r, v)
+				names = append(names, v.Name)
+			}
+			sort.Strings(names)
+			if !reflect.DeepEqual(names, tc.names) {
+				t.Fatalf("expected %v, got %v", tc.names, names)
+			}
+		})
+	}
+	// Null values don't match comparisons
+	res, err := c.Find(Where("Note").IsNull().And("Note").Eq("foo"))
+	checkErr(t, err)
+	if len(res) != 0 {
+		t.Fatalf("expected no null value to equal a string, got %d", len(res))
+	}
+}
+
+func TestQueryCaseInsensitive(t *testing.T) {
+	t.Parallel()
+	d, clean := createTestDB(t)
+	defer clean()
+	c, err := d.NewCollection(CollectionConfig{
+		Name: "Person",
+		Schema: util.SchemaFromSchemaString(`{
+			"type": "object",
+			"properties": {"_id": {"type": "string"}, "Name": {"type": "string"}}
+		}`),
+		Indexes: []Index{{Path: "Name", Unique: true, CaseInsensitive: true}},
+	})
+	checkErr(t, err)
+	for _, name := range []string{"Bob", "alice", "Carol"} {
+		_, err := c.Create([]byte(`{"Name": "` + name + `"}`))
+		checkErr(t, err)
+	}
+	_, err = c.Create([]byte(`{"Name": "BOB"}`))
+	var uerr *UniqueError
+	if !errors.As(err, &uerr) {
+		t.Fatalf("expected unique constraint to ignore case, got %v", err)
+	}
+	for _, tc := range []struct {
+		name  string
+		query *Query
+		names []string
+	}{
+		{name: "Eq", query: Where("Name").EqInsensitive("bob"), names: []string{"Bob"}},
+		{name: "In", query: Where("Name").Insensitive().In("ALICE", "carol"), names: []string{"Carol", "alice"}},
+		{name: "Gt", query: Where("Name").Insensitive().Gt("b"), names: []string{"Bob", "Carol"}},
+		{name: "Matches", query: Where("Name").Insensitive().Matches("^c"), names: []string{"Carol"}},
+		{name: "Index", query: Where("Name").EqInsensitive("CAROL").UseIndex("Name"), names: []string{"Carol"}},
+		{name: "Sensitive", query: Where("Name").Eq("bob"), names: nil},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			res, err := c.Find(tc.query)
+			checkErr(t, err)
+			var names []string
+			for _, r := range res {
+				v := &struct{ Name string }{}
+				util.InstanceFromJSON(r, v)
+				names = append(names, v.Name)
+			}
+			sort.Strings(names)
+			if !reflect.DeepEqual(names, tc.names) {
+				t.Fatalf("expected %v, got %v", tc.names, names)
+			}
+		})
+	}
+	// Case-sensitive string criteria can't use the index
+	_, err = c.Find(Where("Name").Eq("Bob").UseIndex("Name"))
+	if !errors.Is(err, ErrIndexNotUsable) {
+		t.Fatalf("expected ErrIndexNotUsable, got %v", err)
+	}
+	res, err := c.Find((&Query{}).OrderByInsensitive("Name"))
+	checkErr(t, err)
+	var names []string
+	for _, r := range res {
+		v := &struct{ Name string }{}
+		util.InstanceFromJSON(r, v)
+		names = append(names, v.Name)
+	}
+	if !reflect.DeepEqual(names, []string{"alice", "Bob", "Carol"}) {
+		t.Fatalf("expected case-insensitive order, got %v", names)
+	}
+}
+
+func TestQueryIndexHint(t *testing.T) {
+	c, _, clean := createCollectionWithJSONData(t)
+	defer clean()
+
+	tests := []struct {
+		name  string
+		query *Query
+		err   error
+	}{
+		{
+			name:  "NotFound",
+			query: Where("Meta.TotalReads").Ge(&totreadMin).UseIndex("Not.Valid.Path"),
+			err:   ErrIndexNotFound,
+		},
+		{
+			name:  "ID",
+			query: Where("_id").Eq("foo").UseIndex("_id"),
+			err:   ErrIndexNotFound,
+		},
+		{
+			name:  "OtherField",
+			query: Where("Title").Eq(&title0).And("Meta.TotalReads").Ge(&totreadMin).UseIndex("Title"),
+			err:   ErrIndexNotUsable,
+		},
+		{
+			name:  "OtherFieldInOr",
+			query: Where("Title").Eq(&title0).Or(Where("Meta.TotalReads").Ge(&totreadMin)).UseIndex("Title"),
+			err:   ErrIndexNotUsable,
+		},
+		{
+			name:  "NoCondition",
+			query: (&Query{}).UseIndex("Title"),
+			err:   ErrIndexNotUsable,
+		},
+		{
+			name:  "Usable",
+			query: Where("Title").Eq(&title0).UseIndex("Title"),
+		},
+	}
+	for _, tc := range tests {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			_, err := c.Find(tc.query)
+			if !errors.Is(err, tc.err) {
+				t.Fatalf("expected error %v, got %v", tc.err, err)
+			}
+		})
+	}
+}
+
+func TestQueryDefaultOrder(t *testing.T) {
+	d, clean := createTestDB(t)
+	defer clean()
+	_, err := d.NewCollection(CollectionConfig{
+		Name:           "Invalid",
+		Schema:         util.SchemaFromInstance(&Book{}, false),
+		DefaultOrderBy: Sort{FieldPath: "Not.Valid.Path"},
+	})
+	if !errors.Is(err, ErrInvalidSortingField) {
+		t.Fatalf("expected invalid sorting field error, got %v", err)
+	}
+	c, err := d.NewCollection(CollectionConfig{
+		Name:           "Book",
+		Schema:         util.SchemaFromInstance(&Book{}, false),
+		DefaultOrderBy: Sort{FieldPath: "Meta.TotalReads", Desc: true},
+	})
+	checkErr(t, err)
+	for i := range data {
+		_, err := c.Create(util.JSONFromInstance(data[i]))
+		checkErr(t, err)
+	}
+	assertTitles := func(q *Query, titles ...string) {
+		res, err := c.Find(q)
+		checkErr(t, err)
+		got := make([]string, len(res))
+		for i := range res {
+			book := Book{}
+			util.InstanceFromJSON(res[i], &book)
+			got[i] = book.Title
+		}
+		if !reflect.DeepEqual(got, titles) {
+			t.Fatalf("expected titles %v, got %v", titles, got)
+		}
+	}
+	assertTitles(nil, "Title4", "Title2", "Title3", "Title1")
+	assertTitles(Where("Banned").Eq(false), "Title2", "Title3")
+	assertTitles(OrderBy("Title"), "Title1", "Title2", "Title3", "Title4")
+
+	// The default order is persisted with the collection
+	d.lock.Lock()
+	delete(d.collections, c.name)
+	d.unloaded[c.name] = struct{}{}
+	d.lock.Unlock()
+	c = d.GetCollection("Book")
+	if c == nil {
+		t.Fatal("collection should be reloaded")
+	}
+	if o := c.GetDefaultOrderBy(); o.FieldPath != "Meta.TotalReads" || !o.Desc {
+		t.Fatalf("unexpected default order %v", o)
+	}
+	assertTitles(&Query{}, "Title4", "Title2", "Title3", "Title1")
+}
+
+type BookRating struct {
+	Rating float64
+}
+
+type BookView struct {
+	Title string
+	Meta  BookRating
+}
+
+func TestQueryProjection(t *testing.T) {
+	c, _, clean := createCollectionWithJSONData(t)
+	defer clean()
+
+	t.Run("Select", func(t *testing.T) {
+		res, err := c.Find(Where("Title").Eq(title0).SelectFields("Author", "Meta.TotalReads"))
+		checkErr(t, err)
+		if len(res) != 1 {
+			t.Fatalf("expected one result, got %d", len(res))
+		}
+		var got map[string]interface{}
+		util.InstanceFromJSON(res[0], &got)
+		expected := map[string]interface{}{
+			"Author": "Author1",
+			"Meta":   map[string]interface{}{"TotalReads": 100.0},
+		}
+		if !reflect.DeepEqual(got, expected) {
+			t.Fatalf("unexpected projected result %s", res[0])
+		}
+	})
+
+	t.Run("Typed", func(t *testing.T) {
+		schema := util.SchemaFromInstance(&BookView{}, false)
+		res, err := c.Find(OrderBy("Title").SelectFields("Title", "Meta.Rating").ProjectTo(schema))
+		checkErr(t, err)
+		if len(res) != len(data) {
+			t.Fatalf("expected %d results, got %d", len(data), len(res))
+		}
+		for i := range res {
+			view := BookView{}
+			util.InstanceFromJSON(res[i], &view)
+			expected := BookView{Title: data[i].Title, Meta: BookRating{Rating: data[i].Meta.Rating}}
+			if view != expected {
+				t.Fatalf("expected view %v, got %v", expected, view)
+			}
+		}
+	})
+
+	t.Run("Mismatch", func(t *testing.T) {
+		schema := util.SchemaFromInstance(&BookView{}, false)
+		queries := []*Query{
+			(&Query{}).SelectFields("Title").ProjectTo(schema),
+			(&Query{}).SelectFields("Title", "Meta.Rating", "Author").ProjectTo(schema),
+			(&Query{}).ProjectTo(schema), // Meta has extra fields
+		}
+		for _, q := range queries {
+			if _, err := c.Find(q); !errors.Is(err, ErrProjectionMismatch) {
+				t.Fatalf("expected projection mismatch error, got %v", err)
+			}
+		}
+	})
+}
+
+func TestCompoundIndex(t *testing.T) {
+	d, clean := createTestDB(t)
+	defer clean()
+	c, err := d.NewCollection(CollectionConfig{
+		Name:    "Book",
+		Schema:  util.SchemaFromInstance(&Book{}, false),
+		Indexes: []Index{{Fields: []string{"Author", "Title"}, Unique: true}},
+	})
+	checkErr(t, err)
+	if indexes := c.GetIndexes(); len(indexes) != 1 || indexes[0].Path != "Author,Title" {
+		t.Fatalf("unexpected indexes %v", indexes)
+	}
+	ids := make([]db.InstanceID, len(data))
+	for i := range data {
+		ids[i], err = c.Create(util.JSONFromInstance(data[i]))
+		checkErr(t, err)
+	}
+	assertTitles := func(q *Query, titles ...string) {
+		res, err := c.Find(q.UseIndex("Author,Title"))
+		checkErr(t, err)
+		got := make([]string, len(res))
+		for i := range res {
+			book := Book{}
+			util.InstanceFromJSON(res[i], &book)
+			got[i] = book.Title
+		}
+		if !reflect.DeepEqual(got, append([]string{}, titles...)) {
+			t.Fatalf("expected titles %v, got %v", titles, got)
+		}
+	}
+	assertTitles(Where("Author").Eq("Author1").OrderByDesc("Title"), "Title2", "Title1")
+	assertTitles(Where("Author").Eq("Author1").And("Title").Eq("Title1"), "Title1")
+	assertTitles(Where("Author").Eq("Author2").Or(Where("Title").Eq("Title4")).OrderBy("Title"), "Title3", "Title4")
+
+	if _, err := c.Find(Where("Banned").Eq(true).UseIndex("Author,Title")); !errors.Is(err, ErrIndexNotUsable) {
+		t.Fatalf("expected index not usable error, got %v", err)
+	}
+	if _, err := c.Create(util.JSONFromInstance(data[0])); !errors.Is(err, ErrUniqueExists) {
+		t.Fatalf("expected unique constraint violation, got %v", err)
+	}
+
+	// The index is maintained on save and delete
+	book := data[0]
+	book.ID = ids[0]
+	book.Author = "Author2"
+	checkErr(t, c.Save(util.JSONFromInstance(book)))
+	assertTitles(Where("Author").Eq("Author1"), "Title2")
+	assertTitles(Where("Author").Eq("Author2").OrderBy("Title"), "Title1", "Title3")
+	checkErr(t, c.Delete(ids[1]))
+	assertTitles(Where("Author").Eq("Author1"))
+}
+
+type Article struct {
+	ID   db.InstanceID `json:"_id"`
+	Body string
+}
+
+func TestTextSearch(t *testing.T) {
+	d, clean := createTestDB(t)
+	defer clean()
+	_, err := d.NewCollection(CollectionConfig{
+		Name:    "Invalid",
+		Schema:  util.SchemaFromInstance(&Book{}, false),
+		Indexes: []Index{{Path: "Banned", Text: true}},
+	})
+	if !errors.Is(err, ErrInvalidTextIndex) {
+		t.Fatalf("expected invalid text index error, got %v", err)
+	}
+	scanned, err := d.NewCollection(CollectionConfig{
+		Name:   "Scanned",
+		Schema: util.SchemaFromInstance(&Article{}, false),
+	})
+	checkErr(t, err)
+	indexed, err := d.NewCollection(CollectionConfig{
+		Name:    "Indexed",
+		Schema:  util.SchemaFromInstance(&Article{}, false),
+		Indexes: []Index{{Path: "Body", Text: true}},
+	})
+	checkErr(t, err)
+
+	articles := []Article{
+		{ID: "a1", Body: "The dog runs in the park."},
+		{ID: "a2", Body: "Running dogs, jumping cats!"},
+		{ID: "a3", Body: "Stories about cats"},
+	}
+	for _, a := range articles {
+		_, err := scanned.Create(util.JSONFromInstance(a))
+		checkErr(t, err)
+		_, err = indexed.Create(util.JSONFromInstance(a))
+		checkErr(t, err)
+	}
+	assertIDs := func(c *Collection, q *Query, ids ...db.InstanceID) {
+		res, err := c.Find(q)
+		checkErr(t, err)
+		got := make([]db.InstanceID, len(res))
+		for i := range res {
+			a := Article{}
+			util.InstanceFromJSON(res[i], &a)
+			got[i] = a.ID
+		}
+		if !reflect.DeepEqual(got, append([]db.InstanceID{}, ids...)) {
+			t.Fatalf("expected %v, got %v", ids, got)
+		}
+	}
+	for _, c := range []*Collection{scanned, indexed} {
+		assertIDs(c, Where("Body").TextSearch("dog running"), "a1", "a2")
+		assertIDs(c, Where("Body").TextSearch("CAT"), "a2", "a3")
+		assertIDs(c, Where("Body").TextSearch("story cat"), "a3")
+		assertIDs(c, Where("Body").TextSearch("bird"))
+		assertIDs(c, Where("Body").TextSearch("cats").And("_id").Ne("a2"), "a3")
+	}
+
+	err = indexed.ReadTxn(func(txn *Txn) error {
+		dtxn, err := d.datastore.NewTransaction(true)
+		if err != nil {
+			return err
+		}
+		defer dtxn.Discard()
+		keys, ok, err := indexed.textCandidates(dtxn, Where("Body").TextSearch("jumped"))
+		if err != nil {
+			return err
+		}
+		if !ok || len(keys) != 1 || keys[0].Name() != "a2" {
+			t.Fatalf("expected text index candidates, got %v", keys)
+		}
+		return nil
+	})
+	checkErr(t, err)
+
+	// The index is maintained on save and delete
+	checkErr(t, indexed.Save(util.JSONFromInstance(Article{ID: "a1", Body: "A bird"})))
+	assertIDs(indexed, Where("Body").TextSearch("dog"), "a2")
+	assertIDs(indexed, Where("Body").TextSearch("birds"), "a1")
+	checkErr(t, indexed.Delete("a2"))
+	assertIDs(indexed, Where("Body").TextSearch("cats"), "a3")
+}
+
+func TestFindIterate(t *testing.T) {
+	c, _, clean := createCollectionWithJSONData(t)
+	defer clean()
+
+	for _, q := range []*Query{
+		{},
+		Where("Meta.TotalReads").Gt(110.0).UseIndex("Meta.TotalReads"),
+		OrderByDesc("Meta.Rating").SkipNum(1),
+		OrderByID().LimitTo(2).SelectFields("Title"),
+	} {
+		expected, err := c.Find(q)
+		checkErr(t, err)
+		var got [][]byte
+		err = c.FindIterate(q, func(instance []byte) error {
+			got = append(got, instance)
+			return nil
+		})
+		checkErr(t, err)
+		if !reflect.DeepEqual(got, expected) {
+			t.Fatalf("expected %d iterated instances like Find, got %d", len(expected), len(got))
+		}
+	}
+
+	// An error stops the iteration
+	errStop := errors.New("stop")
+	var n int
+	err := c.FindIterate(&Query{}, func([]byte) error {
+		n++
+		return errStop
+	})
+	if !errors.Is(err, errStop) || n != 1 {
+		t.Fatalf("expected iteration to stop with the callback error, got %v after %d instances", err, n)
+	}
+}
+
+func TestCount(t *testing.T) {
+	c, _, clean := createCollectionWithJSONData(t)
+	defer clean()
+
+	for _, q := range jsonQueries {
+		n, err := c.Count(q.query)
+		checkErr(t, err)
+		if n != len(q.resIdx) {
+			t.Fatalf("%s: expected count %d, got %d", q.name, len(q.resIdx), n)
+		}
+	}
+	n, err := c.Count(Where("Title").Ge("Title2").UseIndex("Title"))
+	checkErr(t, err)
+	if n != 3 {
+		t.Fatalf("expected indexed count 3, got %d", n)
+	}
+	n, err = c.Count(Where("Meta.TotalReads").Gt(110.0).UseIndex("Meta.TotalReads").LimitTo(1))
+	checkErr(t, err)
+	if n != 3 {
+		t.Fatalf("expected limit to be ignored and count 3, got %d", n)
+	}
+	if _, err := c.Count(Where("Author").Eq("Author1").UseIndex("Title")); !errors.Is(err, ErrIndexNotUsable) {
+		t.Fatalf("expected index not usable error, got %v", err)
+	}
+}
+
+func TestQueryContext(t *testing.T) {
+	c, _, clean := createCollectionWithJSONData(t)
+	defer clean()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if _, err := c.Find(&Query{}, WithTxnContext(ctx)); !errors.Is(err, context.Canceled) {
+		t.Fatalf("expected find to be canceled, got %v", err)
+	}
+	if _, err := c.Find(Where("Title").Ge("Title2").UseIndex("Title"), WithTxnContext(ctx)); !errors.Is(err, context.Canceled) {
+		t.Fatalf("expected indexed find to be canceled, got %v", err)
+	}
+	if _, err := c.Count(&Query{}, WithTxnContext(ctx)); !errors.Is(err, context.Canceled) {
+		t.Fatalf("expected count to be canceled, got %v", err)
+	}
+	all, err := c.Find(&Query{})
+	checkErr(t, err)
+	book := &Book{}
+	util.InstanceFromJSON(all[0], book)
+	if _, err := c.FindByID(book.ID, WithTxnContext(ctx)); !errors.Is(err, context.Canceled) {
+		t.Fatalf("expected find by ID to be canceled, got %v", err)
+	}
+
+	// Reads stop once the deadline expires mid-scan
+	ctx, cancel = context.WithCancel(context.Background())
+	defer cancel()
+	var n int
+	err = c.ReadTxn(func(txn *Txn) error {
+		return txn.FindIterate(&Query{}, func([]byte) error {
+			n++
+			cancel()
+			return nil
+		})
+	}, WithTxnContext(ctx))
+	if !errors.Is(err, context.Canceled) || n != 1 {
+		t.Fatalf("expected iteration to stop after the first instance, got %d instances and %v", n, err)
+	}
+}
+
+func TestQueryLimits(t *testing.T) {
+	t.Parallel()
+	d, clean := createTestDB(t, WithNewMaxQueryResults(2), WithNewMaxQueryScan(3))
+	defer clean()
+	c, err := d.NewCollection(CollectionConfig{
+		Name:    "Book",
+		Schema:  util.SchemaFromInstance(&Book{}, false),
+		Indexes: []Index{{Path: "Title"}},
+	})
+	checkErr(t, err)
+	for i := range data {
+		_, err := c.Create(util.JSONFromInstance(data[i]))
+		checkErr(t, err)
+	}
+
+	var lerr *QueryLimitError
+	_, err = c.Find(Where("Title").Eq("Title1"))
+	if !errors.As(err, &lerr) || !lerr.Scan || lerr.Max != 3 {
+		t.Fatalf("expected scan limit error, got %v", err)
+	}
+	if _, err = c.Count(&Query{}); !errors.Is(err, ErrQueryLimitExceeded) {
+		t.Fatalf("expected count to exceed the scan limit, got %v", err)
+	}
+	_, err = c.Find(Where("Title").Ge("Title2").UseIndex("Title"))
+	if !errors.As(err, &lerr) || lerr.Scan || lerr.Max != 2 {
+		t.Fatalf("expected results limit error, got %v", err)
+	}
+	res, err := c.Find(Where("Title").Ge("Title2").UseIndex("Title").LimitTo(2))
+	checkErr(t, err)
+	if len(res) != 2 {
+		t.Fatalf("expected 2 limited results, got %d", len(res))
+	}
+	res, err = c.Find(Where("Title").Eq("Title1").UseIndex("Title"))
+	checkErr(t, err)
+	if len(res) != 1 {
+		t.Fatalf("expected 1 indexed result, got %d", len(res))
+	}
+	var buf strings.Builder
+	checkErr(t, c.Export(&buf))
+	if n := strings.Count(buf.String(), "\n"); n != len(data) {
+		t.Fatalf("expected export of %d instances, got %d", len(data), n)
+	}
+}
+
+func TestExplain(t *testing.T) {
+	c, _, clean := createCollectionWithJSONData(t)
+	defer clean()
+
+	total, err := c.Count(&Query{})
+	checkErr(t, err)
+	tests := []struct {
+		name  string
+		query *Query
+		plan  QueryPlan
+	}{
+		{
+			name:  "Scan",
+			query: Where("Author").Eq("Author1"),
+			plan:  QueryPlan{Scan: ScanCollection, Candidates: total, Sort: SortIDOrder},
+		},
+		{
+			name:  "Index",
+			query: Where("Title").Ge("Title2").UseIndex("Title").LimitTo(2),
+			plan:  QueryPlan{Scan: ScanIndex, Index: "Title", Candidates: 3, Sort: SortIndexOrder, Limited: true},
+		},
+		{
+			name:  "InMemorySort",
+			query: Where("Author").Eq("Author1").OrderBy("Meta.TotalReads").LimitTo(1),
+			plan:  QueryPlan{Scan: ScanCollection, Candidates: total, Sort: SortInMemory},
+		},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			plan, err := c.Explain(tc.query)
+			checkErr(t, err)
+			if !reflect.DeepEqual(*plan, tc.plan) {
+				t.Fatalf("expected plan %+v, got %+v", tc.plan, *plan)
+			}
+		})
+	}
+	if _, err := c.Explain(Where("Author").Eq("Author1").UseIndex("Title")); !errors.Is(err, ErrIndexNotUsable) {
+		t.Fatalf("expected index not usable error, got %v", err)
+	}
+}
+
+func TestAggregate(t *testing.T) {
+	c, _, clean := createCollectionWithJSONData(t)
+	defer clean()
+
+	groups, err := c.Aggregate(OrderBy("Author"), Aggregation{
+		GroupBy: "Author",
+		Accumulators: []Accumulator{
+			Count("books"),
+			Sum("reads", "Meta.TotalReads"),
+			Avg("avgReads", "Meta.TotalReads"),
+			Min("minRating", "Meta.Rating"),
+			Max("lastTitle", "Title"),
+		},
+	})
+	checkErr(t, err)
+	expected := []Group{
+		{Key: "Author1", Values: map[string]interface{}{
+			"books": 2, "reads": 250.0, "avgReads": 125.0, "minRating": 3.2, "lastTitle": "Title2"}},
+		{Key: "Author2", Values: map[string]interface{}{
+			"books": 1, "reads": 120.0, "avgReads": 120.0, "minRating": 4.6, "lastTitle": "Title3"}},
+		{Key: "Author3", Values: map[string]interface{}{
+			"books": 1, "reads": 1000.0, "avgReads": 1000.0, "minRating": 2.6, "lastTitle": "Title4"}},
+	}
+	if !reflect.DeepEqual(groups, expected) {
+		t.Fatalf("expected groups %v, got %v", expected, groups)
+	}
+
+	// Without a group field, all results fall in a single group
+	groups, err = c.Aggregate(Where("Banned").Eq(false), Aggregation{
+		Accumulators: []Accumulator{Count("books"), Sum("reads", "Meta.TotalReads")},
+	})
+	checkErr(t, err)
+	expected = []Group{{Values: map[string]interface{}{"books": 2, "reads": 270.0}}}
+	if !reflect.DeepEqual(groups, expected) {
+		t.Fatalf("expected groups %v, got %v", expected, groups)
+	}
+	groups, err = c.Aggregate(Where("Author").Eq("Nobody"), Aggregation{
+		Accumulators: []Accumulator{Count("books"), Avg("avgReads", "Meta.TotalReads"), Max("maxRating", "Meta.Rating")},
+	})
+	checkErr(t, err)
+	expected = []Group{{Values: map[string]interface{}{"books": 0, "avgReads": nil, "maxRating": nil}}}
+	if !reflect.DeepEqual(groups, expected) {
+		t.Fatalf("expected groups %v, got %v", expected, groups)
+	}
+
+	_, err = c.Aggregate(&Query{}, Aggregation{Accumulators: []Accumulator{Sum("reads", "")}})
+	if !errors.Is(err, ErrInvalidAggregation) {
+		t.Fatalf("expected invalid aggregation error, got %v", err)
+	}
+	_, err = c.Aggregate(&Query{}, Aggregation{Accumulators: []Accumulator{Min("minMeta", "Meta")}})
+	if !errors.Is(err, ErrInvalidAggregation) {
+		t.Fatalf("expected invalid aggregation error, got %v", err)
+	}
+}
+
+func TestQuerySortTies(t *testing.T) {
+	c, d, clean := createCollectionWithJSONData(t)
+	defer clean()
+
+	// The first two books have the same author, and ties are ordered by ID
+	first, second := d[0].ID, d[1].ID
+	if second < first {
+		first, second = second, first
+	}
+	res, err := c.Find(OrderBy("Author").LimitTo(2))
+	checkErr(t, err)
+	var b1, b2 Book
+	util.InstanceFromJSON(res[0], &b1)
+	util.InstanceFromJSON(res[1], &b2)
+	if b1.ID != first || b2.ID != second {
+		t.Fatalf("expected ties ordered by ID %s, %s, got %s, %s", first, second, b1.ID, b2.ID)
+	}
+}
+
+func TestFindPage(t *testing.T) {
+	c, _, clean := createCollectionWithJSONData(t)
+	defer clean()
+
+	ids := func(res [][]byte) []db.InstanceID {
+		got := make([]db.InstanceID, len(res))
+		for i := range res {
+			b := Book{}
+			util.InstanceFromJSON(res[i], &b)
+			got[i] = b.ID
+		}
+		return got
+	}
+	queries := map[string]func() *Query{
+		"default":    func() *Query { return &Query{} },
+		"id desc":    func() *Query { return OrderByIDDesc() },
+		"sort ties":  func() *Query { return OrderBy("Author") },
+		"sort desc":  func() *Query { return OrderByDesc("Meta.TotalReads") },
+		"multi sort": func() *Query { return OrderBy("Author").OrderByDesc("Meta.Rating") },
+		"index":      func() *Query { return Where("Title").Ne("Title2").UseIndex("Title") },
+		"index desc": func() *Query { return Where("Meta.TotalReads").Gt(110.0).UseIndex("Meta.TotalReads").OrderByIDDesc() },
+	}
+	for name, newQuery := range queries {
+		t.Run(name, func(t *testing.T) {
+			all, err := c.Find(newQuery())
+			checkErr(t, err)
+			if len(all) <= 2 {
+				t.Fatalf("expected several pages of results, got %d results", len(all))
+			}
+			var paged []db.InstanceID
+			var cursor string
+			for {
+				q := newQuery().LimitTo(2)
+				if cursor != "" {
+					q.AfterCursor(cursor)
+				}
+				res, next, err := c.FindPage(q)
+				checkErr(t, err)
+				paged = append(paged, ids(res)...)
+				if next == "" {
+					break
+				}
+				cursor = next
+			}
+			if !reflect.DeepEqual(ids(all), paged) {
+				t.Fatalf("expected pages of %v, got %v", ids(all), paged)
+			}
+		})
+	}
+
+	// The cursor continues after deleted instances
+	res, cursor, err := c.FindPage(OrderByID().LimitTo(2))
+	checkErr(t, err)
+	checkErr(t, c.Delete(ids(res)[1]))
+	all, err := c.Find(OrderByID())
+	checkErr(t, err)
+	res, _, err = c.FindPage(OrderByID().LimitTo(2).AfterCursor(cursor))
+	checkErr(t, err)
+	if !reflect.DeepEqual(ids(res), ids(all)[1:3]) {
+		t.Fatalf("expected %v, got %v", ids(all)[1:3], ids(res))
+	}
+
+	_, cursor, err = c.FindPage(OrderBy("Author").LimitTo(1))
+	checkErr(t, err)
+	if _, _, err = c.FindPage(OrderByID().AfterCursor(cursor)); !errors.Is(err, ErrInvalidCursor) {
+		t.Fatalf("expected invalid cursor error, got %v", err)
+	}
+	if _, _, err = c.FindPage((&Query{}).AfterCursor("garbage!")); !errors.Is(err, ErrInvalidCursor) {
+		t.Fatalf("expected invalid cursor error, got %v", err)
+	}
+}
+
+type Place struct {
+	ID       db.InstanceID `json:"_id"`
+	Location []float64
+}
+
+func TestGeoQuery(t *testing.T) {
+	d, clean := createTestDB(t)
+	defer clean()
+	_, err := d.NewCollection(CollectionConfig{
+		Name:    "Invalid",
+		Schema:  util.SchemaFromInstance(&Place{}, false),
+		Indexes: []Index{{Path: "Location", Geo: true, Unique: true}},
+	})
+	if !errors.Is(err, ErrInvalidGeoIndex) {
+		t.Fatalf("expected invalid geo index error, got %v", err)
+	}
+	scanned, err := d.NewCollection(CollectionConfig{
+		Name:   "Scanned",
+		Schema: util.SchemaFromInstance(&Place{}, false),
+	})
+	checkErr(t, err)
+	indexed, err := d.NewCollection(CollectionConfig{
+		Name:    "Indexed",
+		Schema:  util.SchemaFromInstance(&Place{}, false),
+		Indexes: []Index{{Path: "Location", Geo: true}},
+	})
+	checkErr(t, err)
+
+	places := []Place{
+		{ID: "eiffel", Location: []float64{2.2945, 48.8584}},
+		{ID: "louvre", Location: []float64{2.3376, 48.8606}},
+		{ID: "london", Location: []float64{-0.1276, 51.5072}},
+		{ID: "fiji", Location: []float64{179.9, -17.8}},
+		{ID: "samoa", Location: []float64{-179.9, -17.9}},
+	}
+	for _, p := range places {
+		_, err := scanned.Create(util.JSONFromInstance(p))
+		checkErr(t, err)
+		_, err = indexed.Create(util.JSONFromInstance(p))
+		checkErr(t, err)
+	}
+	assertIDs := func(c *Collection, q *Query, ids ...db.InstanceID) {
+		res, err := c.Find(q)
+		checkErr(t, err)
+		got := make([]db.InstanceID, len(res))
+		for i := range res {
+			p := Place{}
+			util.InstanceFromJSON(res[i], &p)
+			got[i] = p.ID
+		}
+		if !reflect.DeepEqual(got, append([]db.InstanceID{}, ids...)) {
+			t.Fatalf("expected %v, got %v", ids, got)
+		}
+	}
+	paris := GeoPoint{Lng: 2.3522, Lat: 48.8566}
+	for _, c := range []*Collection{scanned, indexed} {
+		assertIDs(c, Where("Location").Near(paris, 5000), "eiffel", "louvre")
+		assertIDs(c, Where("Location").Near(paris, 1500), "louvre")
+		assertIDs(c, Where("Location").Near(paris, 500000), "eiffel", "london", "louvre")
+		assertIDs(c, Where("Location").WithinBox(GeoPoint{Lng: -1, Lat: 48}, GeoPoint{Lng: 3, Lat: 52}),
+			"eiffel", "london", "louvre")
+		assertIDs(c, Where("Location").WithinBox(GeoPoint{Lng: 179, Lat: -18}, GeoPoint{Lng: -179, Lat: -17}),
+			"fiji", "samoa")
+		assertIDs(c, Where("Location").Near(GeoPoint{Lng: 180, Lat: -17.85}, 20000), "fiji", "samoa")
+		assertIDs(c, Where("Location").Near(paris, 5000).And("_id").Ne("louvre"), "eiffel")
+	}
+
+	err = indexed.ReadTxn(func(txn *Txn) error {
+		dtxn, err := d.datastore.NewTransaction(true)
+		if err != nil {
+			return err
+		}
+		defer dtxn.Discard()
+		keys, ok, err := indexed.geoCandidates(dtxn, Where("Location").Near(GeoPoint{Lng: -0.13, Lat: 51.5}, 1000))
+		if err != nil {
+			return err
+		}
+		if !ok || len(keys) != 1 || keys[0].Name() != "london" {
+			t.Fatalf("expected geo index candidates, got %v", keys)
+		}
+		return nil
+	})
+	checkErr(t, err)
+
+	// The index is maintained on save and delete
+	checkErr(t, indexed.Save(util.JSONFromInstance(Place{ID: "eiffel", Location: []float64{-0.1195, 51.5033}})))
+	assertIDs(indexed, Where("Location").Near(paris, 5000), "louvre")
+	assertIDs(indexed, Where("Location").Near(GeoPoint{Lng: -0.13, Lat: 51.5}, 5000), "eiffel", "london")
+	checkErr(t, indexed.Delete("london"))
+	assertIDs(indexed, Where("Location").Near(GeoPoint{Lng: -0.13, Lat: 51.5}, 5000), "eiffel")
+}
+
 func createCollectionWithJSONData(t *testing.T) (*Collection, []Book, func()) {
 	s, clean := createTestDB(t)
 	c, err := s.NewCollection(CollectionConfig{
@@ -0,0 +1,207 @@
+package db
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"sync"
+
+	ds "github.com/textileio/go-datastore"
+	"github.com/textileio/go-datastore/query"
+	core "github.com/textileio/go-threads/core/db"
+	"github.com/textileio/go-threads/core/thread"
+)
+
+// ErrQuotaExceeded indicates a write would exceed the storage quota of the db.
+var ErrQuotaExceeded = errors.New("db storage quota exceeded")
+
+var dsQuota = dsPrefix.ChildString("quota")
+
+// QuotaError is returned by writes that would exceed the storage quota of the
+// db, see WithNewQuota.
+type QuotaError struct {
+	// Quota is the storage quota of the db in bytes.
+	Quota int64
+	// Usage is the storage usage of the db in bytes before the write.
+	Usage int64
+	// Size is the number of bytes the write would add.
+	Size int64
+}
+
+func (e *QuotaError) Error() string {
+	return fmt.Sprintf("%s: %d of %d bytes used, write adds %d bytes", ErrQuotaExceeded, e.Usage, e.Quota, e.Size)
+}
+
+func (e *QuotaError) Is(target error) bool {
+	return target == ErrQuotaExceeded
+}
+
+// Usage is the storage usage of a db.
+type Usage struct {
+	// Bytes is the approximate storage size of the instances of the db, keys
+	// included. Indexes and the thread log aren't included.
+	Bytes int64
+	// Quota is the storage quota of the db in bytes, or 0 if it has none.
+	Quota int64
+}
+
+// usageTracker tracks the storage usage of a db. The usage is computed by
+// scanning the instances on first use, and then updated as they're written.
+type usageTracker struct {
+	lock   sync.Mutex
+	loaded bool
+	bytes  int64
+	quota  int64
+}
+
+// add adds delta bytes to the usage, if it's loaded.
+func (u *usageTracker) add(delta int64) {
+	u.lock.Lock()
+	defer u.lock.Unlock()
+	if u.loaded {
+		u.bytes += delta
+	}
+}
+
+// reset makes the usage be computed again on next use.
+func (u *usageTracker) reset() {
+	u.lock.Lock()
+	defer u.lock.Unlock()
+	u.loaded = false
+}
+
+// getUsage returns the storage usage of the db.
+// The caller must hold d.txnlock, so instances aren't written meanwhile.
+func (d *DB) getUsage() (Usage, error) {
+	u := &d.usage
+	u.lock.Lock()
+	defer u.lock.Unlock()
+	if !u.loaded {
+		var bytes int64
+		if err := scanPrefix(d.datastore, baseKey, func(e query.Entry) {
+			bytes += int64(len(e.Key) + len(e.Value))
+		}); err != nil {
+			return Usage{}, err
+		}
+		u.bytes, u.loaded = bytes, true
+	}
+	return Usage{Bytes: u.bytes, Quota: u.quota}, nil
+}
+
+// GetUsage returns the storage usage and quota of the db.
+func (d *DB) GetUsage(opts ...Option) (Usage, error) {
+	args := &Options{}
+	for _, opt := range opts {
+		opt(args)
+	}
+	if err := d.connector.Validate(args.Token, true); err != nil {
+		return Usage{}, err
+	}
+	d.txnlock.RLock()
+	defer d.txnlock.RUnlock()
+	return d.getUsage()
+}
+
+// SetQuota sets the storage quota of the db in bytes, overriding the quota
+// the db was created with, see WithNewQuota. A quota of 0 removes it.
+func (d *DB) SetQuota(quota int64, opts ...Option) error {
+	args := &Options{}
+	for _, opt := range opts {
+		opt(args)
+	}
+	if err := d.connector.Validate(args.Token, false); err != nil {
+		return err
+	}
+	if err := d.datastore.Put(dsQuota, []byte(strconv.FormatInt(quota, 10))); err != nil {
+		return err
+	}
+	d.usage.lock.Lock()
+	d.usage.quota = quota
+	d.usage.lock.Unlock()
+	return nil
+}
+
+// loadQuota loads the storage quota set with SetQuota, if any.
+func (d *DB) loadQuota(quota int64) error {
+	v, err := d.datastore.Get(dsQuota)
+	if err == nil {
+		if quota, err = strconv.ParseInt(string(v), 10, 64); err != nil {
+			return err
+		}
+	} else if !errors.Is(err, ds.ErrNotFound) {
+		return err
+	}
+	d.usage.quota = quota
+	return nil
+}
+
+// checkQuota returns a *QuotaError if the actions would exceed the storage
+// quota of the db. The size of saved instances is estimated from their
+// previous size. Writes that don't grow the usage are allowed.
+func (d *DB) checkQuota(actions []core.Action) error {
+	d.usage.lock.Lock()
+	quota := d.usage.quota
+	d.usage.lock.Unlock()
+	if quota <= 0 {
+		return nil
+	}
+	var size int64
+	for _, a := range actions {
+		keySize := int64(len(baseKey.ChildString(a.CollectionName).ChildString(a.InstanceID.String()).String()))
+		switch a.Type {
+		case core.Create:
+			size += keySize + int64(len(a.Current))
+		case core.Save:
+			size += int64(len(a.Current) - len(a.Previous))
+		case core.Delete:
+			size -= keySize + int64(len(a.Previous))
+		}
+	}
+	if size <= 0 {
+		return nil
+	}
+	usage, err := d.getUsage()
+	if err != nil {
+		return err
+	}
+	if usage.Bytes+size > quota {
+		err := &QuotaError{Quota: quota, Usage: usage.Bytes, Size: size}
+		d.emitEvent(ManagerEvent{Type: EventQuotaExceeded, Collection: actions[0].CollectionName, Quota: err})
+		return err
+	}
+	return nil
+}
+
+// instanceSizeDelta returns the change of storage size of an instance write.
+func instanceSizeDelta(key ds.Key, oldData, newData []byte) int64 {
+	var delta int64
+	if oldData != nil {
+		delta -= int64(len(key.String()) + len(oldData))
+	}
+	if newData != nil {
+		delta += int64(len(key.String()) + len(newData))
+	}
+	return delta
+}
+
+// GetDBUsage returns the storage usage and quota of a managed db.
+func (m *Manager) GetDBUsage(ctx context.Context, id thread.ID, opts ...ManagedOption) (Usage, error) {
+	db, err := m.GetDB(ctx, id, opts...)
+	if err != nil {
+		return Usage{}, err
+	}
+	defer m.ReleaseDB(id)
+	return db.GetUsage()
+}
+
+// SetDBQuota sets the storage quota of a managed db in bytes, overriding the
+// default quota of the manager, see WithNewQuota. A quota of 0 removes it.
+func (m *Manager) SetDBQuota(ctx context.Context, id thread.ID, quota int64, opts ...ManagedOption) error {
+	db, err := m.GetDB(ctx, id, opts...)
+	if err != nil {
+		return err
+	}
+	defer m.ReleaseDB(id)
+	return db.SetQuota(quota)
+}
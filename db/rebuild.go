@@ -0,0 +1,206 @@
+package db
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/ipfs/go-cid"
+	"github.com/libp2p/go-libp2p-core/crypto"
+	"github.com/libp2p/go-libp2p-core/peer"
+	ds "github.com/textileio/go-datastore"
+	"github.com/textileio/go-datastore/query"
+	core "github.com/textileio/go-threads/core/db"
+	"github.com/textileio/go-threads/core/net"
+	"github.com/textileio/go-threads/core/thread"
+)
+
+// RebuildOptions defines options for rebuilding a collection.
+type RebuildOptions struct {
+	Token    thread.Token
+	Progress RebuildProgressFunc
+}
+
+// RebuildOption specifies a rebuild option.
+type RebuildOption func(*RebuildOptions)
+
+// RebuildProgressFunc is called after each replayed record with the number
+// of records done so far and the total number of records in the thread.
+type RebuildProgressFunc func(done, total int)
+
+// WithRebuildToken provides authorization for rebuilding a collection.
+func WithRebuildToken(t thread.Token) RebuildOption {
+	return func(o *RebuildOptions) {
+		o.Token = t
+	}
+}
+
+// WithRebuildProgress sets a function that is called as records are replayed.
+func WithRebuildProgress(f RebuildProgressFunc) RebuildOption {
+	return func(o *RebuildOptions) {
+		o.Progress = f
+	}
+}
+
+// RecordFailure describes a record that couldn't be replayed.
+type RecordFailure struct {
+	// Log is the ID of the log containing the record.
+	Log peer.ID
+	// Record is the record ID.
+	Record cid.Cid
+	// Err is the verification or decoding error.
+	Err error
+}
+
+// RebuildError is returned by RebuildFromLog when some records failed
+// verification or decoding. The collection is rebuilt from the remaining
+// records, so its state may be incomplete.
+type RebuildError struct {
+	Failed []RecordFailure
+}
+
+func (e *RebuildError) Error() string {
+	return fmt.Sprintf("%d records failed verification, first: %s/%s: %s",
+		len(e.Failed), e.Failed[0].Log, e.Failed[0].Record, e.Failed[0].Err)
+}
+
+// logRecord is a record along with the log it belongs to.
+type logRecord struct {
+	rec    net.Record
+	logID  peer.ID
+	pubKey crypto.PubKey
+}
+
+// RebuildFromLog reconstructs the state and indexes of a collection from
+// scratch by replaying the records of the thread. Record signatures are
+// verified against their log keys, and records that fail verification or
+// decoding are skipped and reported with a *RebuildError.
+// Writes to the db are blocked while the collection is rebuilt. Records
+// encrypted with a retired read key can't be replayed.
+func (d *DB) RebuildFromLog(ctx context.Context, collection string, opts ...RebuildOption) error {
+	args := &RebuildOptions{}
+	for _, opt := range opts {
+		opt(args)
+	}
+	if err := d.connector.Validate(args.Token, false); err != nil {
+		return err
+	}
+	d.lock.Lock()
+	c, err := d.getCollection(collection)
+	d.lock.Unlock()
+	if err != nil {
+		return err
+	}
+
+	d.txnlock.Lock()
+	defer d.txnlock.Unlock()
+
+	info, records, err := d.threadRecords(ctx, args.Token)
+	if err != nil {
+		return err
+	}
+
+	var events []core.Event
+	var failed []RecordFailure
+	for i, r := range records {
+		// Decoding loads the record block, which is needed for verification
+		revents, err := d.eventsFromRecord(ctx, r.rec, r.logID, info.Key)
+		if err == nil {
+			err = r.rec.Verify(r.pubKey)
+		}
+		if err != nil {
+			failed = append(failed, RecordFailure{Log: r.logID, Record: r.rec.Cid(), Err: err})
+		} else {
+			for _, e := range revents {
+				if e.Collection() == c.name {
+					events = append(events, e)
+				}
+			}
+		}
+		if args.Progress != nil {
+			args.Progress(i+1, len(records))
+		}
+	}
+
+	if err := c.clearState(); err != nil {
+		return err
+	}
+	d.usage.reset()
+	if len(events) > 0 {
+		// The codec orders events causally before applying them
+		if _, err := d.eventcodec.Reduce(events, d.datastore, baseKey, defaultIndexFunc(d)); err != nil {
+			return err
+		}
+	}
+	if len(failed) > 0 {
+		return &RebuildError{Failed: failed}
+	}
+	return nil
+}
+
+// threadRecords returns the info of the db thread, and the records of its logs.
+func (d *DB) threadRecords(ctx context.Context, token thread.Token) (thread.Info, []logRecord, error) {
+	id := d.connector.ThreadID()
+	info, err := d.connector.Net.GetThread(ctx, id, net.WithThreadToken(token))
+	if err != nil {
+		return thread.Info{}, nil, err
+	}
+	if !info.Key.CanRead() {
+		return thread.Info{}, nil, fmt.Errorf("read key not found for thread %s", id)
+	}
+	var records []logRecord
+	for _, lg := range info.Logs {
+		for rid := lg.Head; rid.Defined(); {
+			rec, err := d.connector.Net.GetRecord(ctx, id, rid, net.WithThreadToken(token))
+			if err != nil {
+				return thread.Info{}, nil, fmt.Errorf("getting record %s/%s: %v", lg.ID, rid, err)
+			}
+			records = append(records, logRecord{rec: rec, logID: lg.ID, pubKey: lg.PubKey})
+			rid = rec.PrevID()
+		}
+	}
+	return info, records, nil
+}
+
+// clearState deletes the instances of the collection along with their
+// indexes, tombstones, modification times, and applied event timestamps.
+func (c *Collection) clearState() error {
+	txn, err := c.db.datastore.NewTransaction(false)
+	if err != nil {
+		return err
+	}
+	defer txn.Discard()
+	for _, prefix := range []ds.Key{c.baseKey(), indexPrefix.Child(c.baseKey()), dsApplied.ChildString(c.name)} {
+		if err := deletePrefix(c.db.datastore, txn, prefix); err != nil {
+			return err
+		}
+	}
+	if err := c.deleteTombstones(txn); err != nil {
+		return err
+	}
+	if err := c.deleteModifiedIndex(txn); err != nil {
+		return err
+	}
+	// The replayed instances are indexed as they're written
+	if err := txn.Put(dsModifiedReady.ChildString(c.name), nil); err != nil {
+		return err
+	}
+	return txn.Commit()
+}
+
+// deletePrefix deletes all keys with the given prefix in txn.
+func deletePrefix(store ds.Datastore, txn ds.Txn, prefix ds.Key) error {
+	results, err := store.Query(query.Query{Prefix: prefix.String(), KeysOnly: true})
+	if err != nil {
+		return err
+	}
+	defer results.Close()
+	for res := range results.Next() {
+		if res.Error != nil {
+			return res.Error
+		}
+		if err := txn.Delete(ds.RawKey(res.Key)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
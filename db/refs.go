@@ -0,0 +1,290 @@
+package db
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	ds "github.com/textileio/go-datastore"
+	"github.com/textileio/go-datastore/query"
+	core "github.com/textileio/go-threads/core/db"
+	"github.com/tidwall/gjson"
+)
+
+// RefDeleteAction selects what happens to the instances referencing
+// an instance that is deleted.
+type RefDeleteAction int
+
+const (
+	// RefRestrict blocks deletes of referenced instances with ErrReferenced.
+	RefRestrict RefDeleteAction = iota
+	// RefCascade deletes the referencing instances along with the referenced one.
+	RefCascade
+)
+
+var (
+	// ErrInvalidReference indicates a reference path isn't an unencrypted string field
+	// of the collection schema, or its target collection or delete action is unknown.
+	ErrInvalidReference = errors.New("invalid reference")
+	// ErrReferenceNotFound indicates a write references an instance that doesn't exist.
+	ErrReferenceNotFound = errors.New("referenced instance not found")
+	// ErrReferenced indicates a delete of an instance that is referenced by
+	// instances of a collection with the RefRestrict delete action.
+	ErrReferenced = errors.New("instance is referenced")
+
+	dsReferences = dsPrefix.ChildString("reference")
+)
+
+// Reference declares that a field of a collection holds the ID of an
+// instance in another, or the same, collection.
+type Reference struct {
+	// Path is the path of the string field holding the referenced instance ID.
+	// Instances without the field, or with an empty ID, don't reference any instance.
+	Path string `json:"path"`
+	// Collection is the name of the referenced collection.
+	Collection string `json:"collection"`
+	// OnDelete selects what happens to referencing instances when the
+	// referenced instance is deleted.
+	OnDelete RefDeleteAction `json:"onDelete"`
+}
+
+// validReferences validates the references of a collection config.
+// The caller must hold d.lock.
+func (d *DB) validReferences(config CollectionConfig) error {
+	for _, ref := range config.References {
+		jt, err := getSchemaTypeAtPath(config.Schema, ref.Path)
+		if err != nil || jt.Type != "string" || ref.Path == idFieldName {
+			return ErrInvalidReference
+		}
+		for _, pth := range config.EncryptedFields {
+			if pth == ref.Path {
+				return ErrInvalidReference
+			}
+		}
+		if ref.Collection != config.Name && !d.hasCollection(ref.Collection) {
+			return ErrInvalidReference
+		}
+		if ref.OnDelete != RefRestrict && ref.OnDelete != RefCascade {
+			return ErrInvalidReference
+		}
+	}
+	return nil
+}
+
+func (d *DB) saveReferences(c *Collection) error {
+	if len(c.references) == 0 {
+		return d.datastore.Delete(dsReferences.ChildString(c.name))
+	}
+	v, err := json.Marshal(c.references)
+	if err != nil {
+		return err
+	}
+	return d.datastore.Put(dsReferences.ChildString(c.name), v)
+}
+
+// loadReferences returns the persisted references of a collection.
+func (d *DB) loadReferences(name string) ([]Reference, error) {
+	v, err := d.datastore.Get(dsReferences.ChildString(name))
+	if errors.Is(err, ds.ErrNotFound) {
+		return nil, nil
+	} else if err != nil {
+		return nil, err
+	}
+	var refs []Reference
+	err = json.Unmarshal(v, &refs)
+	return refs, err
+}
+
+// referrer is a reference of a collection.
+type referrer struct {
+	collection *Collection
+	ref        Reference
+}
+
+// referrers returns the references to the given collection, in collection order.
+// References are read from the datastore, so collections without references
+// to it aren't hydrated.
+func (d *DB) referrers(collection string) ([]referrer, error) {
+	results, err := d.datastore.Query(query.Query{
+		Prefix: dsReferences.String(),
+		Orders: []query.Order{query.OrderByKey{}},
+	})
+	if err != nil {
+		return nil, err
+	}
+	defer results.Close()
+	var refs []referrer
+	for res := range results.Next() {
+		if res.Error != nil {
+			return nil, res.Error
+		}
+		var crefs []Reference
+		if err := json.Unmarshal(res.Value, &crefs); err != nil {
+			return nil, err
+		}
+		for _, ref := range crefs {
+			if ref.Collection != collection {
+				continue
+			}
+			d.lock.Lock()
+			c, err := d.getCollection(ds.RawKey(res.Key).Name())
+			d.lock.Unlock()
+			if err != nil {
+				return nil, err
+			}
+			refs = append(refs, referrer{collection: c, ref: ref})
+		}
+	}
+	return refs, nil
+}
+
+// renameReferences rewrites the stored references to a renamed collection in
+// txn, including its own, which are moved to the new name first, see
+// RenameCollection.
+func renameReferences(store ds.Datastore, txn ds.Txn, oldName, newName string) error {
+	results, err := store.Query(query.Query{Prefix: dsReferences.String()})
+	if err != nil {
+		return err
+	}
+	defer results.Close()
+	for res := range results.Next() {
+		if res.Error != nil {
+			return res.Error
+		}
+		var refs []Reference
+		if err := json.Unmarshal(res.Value, &refs); err != nil {
+			return err
+		}
+		renamed := renamedReferences(refs, oldName, newName)
+		if renamed == nil {
+			continue
+		}
+		v, err := json.Marshal(renamed)
+		if err != nil {
+			return err
+		}
+		key := ds.RawKey(res.Key)
+		if key.Name() == oldName {
+			key = dsReferences.ChildString(newName)
+		}
+		if err := txn.Put(key, v); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// renamedReferences returns a copy of refs where references to a renamed
+// collection use its new name, or nil if none reference it.
+func renamedReferences(refs []Reference, oldName, newName string) []Reference {
+	var renamed []Reference
+	for i, ref := range refs {
+		if ref.Collection != oldName {
+			continue
+		}
+		if renamed == nil {
+			renamed = append([]Reference(nil), refs...)
+		}
+		renamed[i].Collection = newName
+	}
+	return renamed
+}
+
+// instanceRef identifies an instance of a collection.
+type instanceRef struct {
+	collection string
+	id         core.InstanceID
+}
+
+// checkReferences checks that the instances written by the transaction
+// reference existing instances, and applies the delete actions of the
+// references to deleted instances. Cascaded deletes are added to the
+// transaction actions.
+func (t *Txn) checkReferences() error {
+	d := t.collection.db
+	// Whether the instances written by the transaction exist once it commits
+	pending := make(map[instanceRef]bool)
+	for _, a := range t.actions {
+		pending[instanceRef{a.CollectionName, a.InstanceID}] = a.Type != core.Delete
+	}
+	for i := 0; i < len(t.actions); i++ {
+		a := t.actions[i]
+		if a.Type != core.Delete {
+			continue
+		}
+		refs, err := d.referrers(a.CollectionName)
+		if err != nil {
+			return err
+		}
+		for _, r := range refs {
+			ids, err := r.referencing(a.InstanceID)
+			if err != nil {
+				return err
+			}
+			for _, id := range ids {
+				if _, ok := pending[instanceRef{r.collection.name, id}]; ok {
+					// Written instances are checked with their new value
+					continue
+				}
+				if r.ref.OnDelete == RefRestrict {
+					return fmt.Errorf("%w by %s/%s", ErrReferenced, r.collection.name, id)
+				}
+				pending[instanceRef{r.collection.name, id}] = false
+				t.actions = append(t.actions, core.Action{
+					Type:           core.Delete,
+					InstanceID:     id,
+					CollectionName: r.collection.name,
+					Metadata:       t.metadata,
+				})
+			}
+		}
+	}
+	for _, a := range t.actions {
+		if a.Type != core.Create && a.Type != core.Save {
+			continue
+		}
+		c := t.collection
+		if a.CollectionName != c.name {
+			if c = d.GetCollection(a.CollectionName); c == nil {
+				continue
+			}
+		}
+		for _, ref := range c.references {
+			id := gjson.GetBytes(a.Current, ref.Path).String()
+			if id == "" {
+				continue
+			}
+			exists, ok := pending[instanceRef{ref.Collection, core.InstanceID(id)}]
+			if !ok {
+				var err error
+				key := baseKey.ChildString(ref.Collection).ChildString(id)
+				if exists, err = d.datastore.Has(key); err != nil {
+					return err
+				}
+			}
+			if !exists {
+				return fmt.Errorf("%w: %s/%s", ErrReferenceNotFound, ref.Collection, id)
+			}
+		}
+	}
+	return nil
+}
+
+// referencing returns the IDs of the stored instances referencing the given ID.
+func (r referrer) referencing(id core.InstanceID) ([]core.InstanceID, error) {
+	results, err := r.collection.db.datastore.Query(query.Query{Prefix: r.collection.baseKey().String()})
+	if err != nil {
+		return nil, err
+	}
+	defer results.Close()
+	var ids []core.InstanceID
+	for res := range results.Next() {
+		if res.Error != nil {
+			return nil, res.Error
+		}
+		if gjson.GetBytes(res.Value, r.ref.Path).String() == id.String() {
+			ids = append(ids, core.InstanceID(ds.RawKey(res.Key).Name()))
+		}
+	}
+	return ids, nil
+}
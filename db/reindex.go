@@ -0,0 +1,207 @@
+package db
+
+import (
+	"context"
+	"strings"
+
+	ds "github.com/textileio/go-datastore"
+	"github.com/textileio/go-datastore/query"
+	"github.com/textileio/go-threads/core/thread"
+)
+
+// defaultReindexChunkSize is the default number of keys reindexed at a time.
+const defaultReindexChunkSize = 1000
+
+// ReindexOptions defines options for reindexing a collection.
+type ReindexOptions struct {
+	Token     thread.Token
+	ChunkSize int
+	Progress  BatchProgressFunc
+}
+
+// ReindexOption specifies a reindex option.
+type ReindexOption func(*ReindexOptions)
+
+// WithReindexToken provides authorization for reindexing a collection.
+func WithReindexToken(t thread.Token) ReindexOption {
+	return func(o *ReindexOptions) {
+		o.Token = t
+	}
+}
+
+// WithReindexChunkSize sets the number of instances and index values
+// processed by each of the reindexing transactions.
+func WithReindexChunkSize(size int) ReindexOption {
+	return func(o *ReindexOptions) {
+		o.ChunkSize = size
+	}
+}
+
+// WithReindexProgress sets a function that is called after each reindexed chunk
+// with the number of instances and index values processed so far, and the total.
+func WithReindexProgress(f BatchProgressFunc) ReindexOption {
+	return func(o *ReindexOptions) {
+		o.Progress = f
+	}
+}
+
+// Reindex rebuilds the indexes of the collection from its instances, e.g.,
+// after adding an index to a collection with existing instances. Missing index
+// values are added, and stale ones, e.g., of dropped indexes, are removed.
+// Keys are processed in chunks, each of which blocks writes but not reads.
+// Cancelling ctx stops the reindexing between chunks, leaving the indexes
+// partially rebuilt, in which case Reindex can be safely run again.
+func (c *Collection) Reindex(ctx context.Context, opts ...ReindexOption) error {
+	args := &ReindexOptions{ChunkSize: defaultReindexChunkSize}
+	for _, opt := range opts {
+		opt(args)
+	}
+	if args.ChunkSize <= 0 {
+		args.ChunkSize = defaultReindexChunkSize
+	}
+	if err := c.db.connector.Validate(args.Token, false); err != nil {
+		return err
+	}
+	instances, err := listKeys(c.db.datastore, c.baseKey())
+	if err != nil {
+		return err
+	}
+	values, err := listKeys(c.db.datastore, indexPrefix.Child(c.baseKey()))
+	if err != nil {
+		return err
+	}
+	total := len(instances) + len(values)
+	var done int
+	for _, phase := range []struct {
+		keys []ds.Key
+		fn   reindexFunc
+	}{
+		{instances, reindexInstance},
+		{values, pruneIndexValue},
+	} {
+		for start := 0; start < len(phase.keys); start += args.ChunkSize {
+			if err := ctx.Err(); err != nil {
+				return err
+			}
+			end := start + args.ChunkSize
+			if end > len(phase.keys) {
+				end = len(phase.keys)
+			}
+			if err := c.reindexChunk(phase.keys[start:end], phase.fn); err != nil {
+				return err
+			}
+			done += end - start
+			if args.Progress != nil {
+				args.Progress(done, total)
+			}
+		}
+	}
+	return nil
+}
+
+// reindexFunc reindexes the given instance or index value key in txn.
+type reindexFunc func(c *Collection, txn ds.Txn, key ds.Key) error
+
+// reindexChunk applies fn to keys in a single transaction.
+func (c *Collection) reindexChunk(keys []ds.Key, fn reindexFunc) error {
+	d := c.db
+	// Indexes are updated under the dispatcher lock, which reads don't take
+	d.dispatcher.Lock().Lock()
+	defer d.dispatcher.Lock().Unlock()
+	d.lock.Lock()
+	cur, err := d.getCollection(c.name)
+	d.lock.Unlock()
+	if err != nil {
+		return err
+	}
+	txn, err := d.datastore.NewTransaction(false)
+	if err != nil {
+		return err
+	}
+	defer txn.Discard()
+	for _, key := range keys {
+		if err := fn(cur, txn, key); err != nil {
+			return err
+		}
+	}
+	return txn.Commit()
+}
+
+// reindexInstance adds the missing index values of an instance.
+func reindexInstance(c *Collection, txn ds.Txn, key ds.Key) error {
+	v, err := txn.Get(key)
+	if err == ds.ErrNotFound {
+		return nil
+	} else if err != nil {
+		return err
+	}
+	return c.indexAdd(txn, key, v)
+}
+
+// pruneIndexValue removes the instances of an index value that no longer
+// exist or have the value, and the values of dropped indexes.
+func pruneIndexValue(c *Collection, txn ds.Txn, key ds.Key) error {
+	data, err := txn.Get(key)
+	if err == ds.ErrNotFound {
+		return nil
+	} else if err != nil {
+		return err
+	}
+	rel := strings.TrimPrefix(key.String(), indexPrefix.Child(c.baseKey()).String()+"/")
+	pth := strings.SplitN(rel, "/", 2)[0]
+	index, ok := c.indexes[pth]
+	if !ok {
+		return txn.Delete(key)
+	}
+	var keys keyList
+	if err := DefaultDecode(data, &keys); err != nil {
+		return err
+	}
+	kept := make(keyList, 0, len(keys))
+	for _, b := range keys {
+		v, err := txn.Get(ds.RawKey(string(b)))
+		if err == ds.ErrNotFound {
+			continue
+		} else if err != nil {
+			return err
+		}
+		valueKeys, err := indexValues(pth, index, v)
+		if err != nil {
+			return err
+		}
+		for _, valueKey := range valueKeys {
+			if c.indexKey(pth, valueKey).Equal(key) {
+				kept = append(kept, b)
+				break
+			}
+		}
+	}
+	if len(kept) == len(keys) {
+		return nil
+	}
+	if len(kept) == 0 {
+		return txn.Delete(key)
+	}
+	val, err := DefaultEncode(kept)
+	if err != nil {
+		return err
+	}
+	return txn.Put(key, val)
+}
+
+// listKeys returns the keys with the given prefix.
+func listKeys(store ds.Read, prefix ds.Key) ([]ds.Key, error) {
+	results, err := store.Query(query.Query{Prefix: prefix.String(), KeysOnly: true})
+	if err != nil {
+		return nil, err
+	}
+	defer results.Close()
+	var keys []ds.Key
+	for res := range results.Next() {
+		if res.Error != nil {
+			return nil, res.Error
+		}
+		keys = append(keys, ds.RawKey(res.Key))
+	}
+	return keys, nil
+}
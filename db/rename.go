@@ -0,0 +1,201 @@
+package db
+
+import (
+	"encoding/json"
+	"errors"
+	"strings"
+
+	"github.com/alecthomas/jsonschema"
+	ds "github.com/textileio/go-datastore"
+	"github.com/textileio/go-datastore/query"
+)
+
+// RenameCollection atomically renames a collection, moving its instances,
+// indexes, config, and event history to the new name. Writes to the db are
+// blocked while the collection is renamed, and handles to the collection
+// obtained before the rename become stale.
+// References to the collection, including its own, target the new name.
+// The rename is local: records in the thread log keep the old collection name,
+// so peers must rename the collection as well, and events for the old name
+// received after the rename are rejected.
+func (d *DB) RenameCollection(oldName, newName string, opts ...Option) error {
+	args := &Options{}
+	for _, opt := range opts {
+		opt(args)
+	}
+	if err := d.connector.Validate(args.Token, false); err != nil {
+		return err
+	}
+	if !nameRx.MatchString(newName) {
+		return ErrInvalidName
+	}
+
+	// Writes take the txn and dispatcher locks before d.lock
+	d.txnlock.Lock()
+	defer d.txnlock.Unlock()
+	d.dispatcher.Lock().Lock()
+	defer d.dispatcher.Lock().Unlock()
+	d.lock.Lock()
+	defer d.lock.Unlock()
+
+	c, err := d.getCollection(oldName)
+	if err != nil {
+		return err
+	}
+	if d.hasCollection(newName) {
+		return ErrCollectionAlreadyRegistered
+	}
+	schema := &jsonschema.Schema{}
+	if err := json.Unmarshal(c.GetSchema(), schema); err != nil {
+		return err
+	}
+	c.Lock()
+	version, migrations := c.version, c.migrations
+	c.Unlock()
+	nc, err := newCollection(d, CollectionConfig{
+		Name:           newName,
+		WriteValidator: string(c.GetWriteValidator()),
+		ReadFilter:     string(c.GetReadFilter()),
+		DefaultOrderBy: c.defaultOrder,
+		TTLField:       c.ttlField,
+		Schema:         schema,
+		ComputedFields: c.getComputedFields(),
+	})
+	if err != nil {
+		return err
+	}
+	nc.indexes = c.indexes
+	nc.version = version
+	nc.migrations = migrations
+	nc.validationStats = c.validationStats
+	nc.throughput = c.throughput
+	nc.hooks = c.hooks
+	nc.conflict = c.conflict
+	nc.ids = c.ids
+	nc.encryption = c.encryption
+	nc.references = c.references
+	nc.writes = c.writes
+
+	txn, err := d.datastore.NewTransaction(false)
+	if err != nil {
+		return err
+	}
+	defer txn.Discard()
+	for _, prefix := range collectionKeys {
+		if err := moveKey(d.datastore, txn, prefix.ChildString(oldName), prefix.ChildString(newName)); err != nil {
+			return err
+		}
+	}
+	for _, prefix := range collectionKeyPrefixes {
+		if err := movePrefix(d.datastore, txn, prefix.ChildString(oldName), prefix.ChildString(newName)); err != nil {
+			return err
+		}
+	}
+	// Index values list instance keys, so indexes are rebuilt for the new keys
+	if err := deletePrefix(d.datastore, txn, indexPrefix.Child(c.baseKey())); err != nil {
+		return err
+	}
+	results, err := d.datastore.Query(query.Query{Prefix: c.baseKey().String()})
+	if err != nil {
+		return err
+	}
+	defer results.Close()
+	for res := range results.Next() {
+		if res.Error != nil {
+			return res.Error
+		}
+		key := nc.baseKey().ChildString(ds.RawKey(res.Key).Name())
+		if err := txn.Delete(ds.RawKey(res.Key)); err != nil {
+			return err
+		}
+		if err := txn.Put(key, res.Value); err != nil {
+			return err
+		}
+		if err := nc.indexAdd(txn, key, res.Value); err != nil {
+			return err
+		}
+	}
+	if err := renameDispatcherEvents(d.datastore, txn, oldName, newName); err != nil {
+		return err
+	}
+	if err := renameReferences(d.datastore, txn, oldName, newName); err != nil {
+		return err
+	}
+	if err := txn.Commit(); err != nil {
+		return err
+	}
+	delete(d.collections, oldName)
+	d.collections[newName] = nc
+	// References of the other hydrated collections, and the collection's own
+	// references to itself, must target the new name
+	for _, col := range d.collections {
+		if refs := renamedReferences(col.references, oldName, newName); refs != nil {
+			col.references = refs
+		}
+	}
+	// Instance keys changed size
+	d.usage.reset()
+	return nil
+}
+
+// moveKey moves the value at from to to in txn, if any.
+func moveKey(store ds.Datastore, txn ds.Txn, from, to ds.Key) error {
+	v, err := store.Get(from)
+	if errors.Is(err, ds.ErrNotFound) {
+		return nil
+	} else if err != nil {
+		return err
+	}
+	if err := txn.Delete(from); err != nil {
+		return err
+	}
+	return txn.Put(to, v)
+}
+
+// movePrefix moves all keys with the prefix from under the prefix to in txn.
+func movePrefix(store ds.Datastore, txn ds.Txn, from, to ds.Key) error {
+	results, err := store.Query(query.Query{Prefix: from.String()})
+	if err != nil {
+		return err
+	}
+	defer results.Close()
+	for res := range results.Next() {
+		if res.Error != nil {
+			return res.Error
+		}
+		if err := txn.Delete(ds.RawKey(res.Key)); err != nil {
+			return err
+		}
+		key := ds.NewKey(to.String() + strings.TrimPrefix(res.Key, from.String()))
+		if err := txn.Put(key, res.Value); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// renameDispatcherEvents moves the dispatched events of a collection, which
+// are keyed by collection name, see getKey.
+func renameDispatcherEvents(store ds.Datastore, txn ds.Txn, oldName, newName string) error {
+	results, err := store.Query(query.Query{Prefix: dsDispatcherPrefix.String()})
+	if err != nil {
+		return err
+	}
+	defer results.Close()
+	for res := range results.Next() {
+		if res.Error != nil {
+			return res.Error
+		}
+		key := ds.RawKey(res.Key)
+		if key.Type() != oldName {
+			continue
+		}
+		if err := txn.Delete(key); err != nil {
+			return err
+		}
+		if err := txn.Put(key.Parent().ChildString(newName).Instance(key.Name()), res.Value); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,116 @@
+package db
+
+import (
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	ds "github.com/textileio/go-datastore"
+	"github.com/textileio/go-datastore/query"
+)
+
+// defaultActionHistory is the default number of actions kept for resuming listeners.
+const defaultActionHistory = 10000
+
+var (
+	// ErrInvalidResumeToken indicates a listener resume token is unknown, or
+	// older than the actions kept by the db, see WithNewActionHistory.
+	ErrInvalidResumeToken = errors.New("invalid or expired resume token")
+
+	dsActions   = dsPrefix.ChildString("actions")
+	dsActionSeq = dsPrefix.ChildString("actionseq")
+)
+
+// actionKey returns the key of the action with the given resume token.
+func actionKey(token uint64) ds.Key {
+	return dsActions.ChildString(fmt.Sprintf("%020d", token))
+}
+
+// loadActionSeq loads the resume token of the last action.
+func (d *DB) loadActionSeq() error {
+	v, err := d.datastore.Get(dsActionSeq)
+	if errors.Is(err, ds.ErrNotFound) {
+		return nil
+	} else if err != nil {
+		return err
+	}
+	d.actionSeq = binary.BigEndian.Uint64(v)
+	return nil
+}
+
+// recordActions assigns resume tokens to actions, and persists them so
+// listeners can replay them. Only the latest actions are kept.
+// Actions are reduced under the dispatcher lock, so calls are serialized.
+func (d *DB) recordActions(actions []Action) error {
+	if len(actions) == 0 {
+		return nil
+	}
+	txn, err := d.datastore.NewTransaction(false)
+	if err != nil {
+		return err
+	}
+	defer txn.Discard()
+	seq := d.actionSeq
+	for i := range actions {
+		seq++
+		actions[i].ResumeToken = seq
+		v, err := json.Marshal(actions[i])
+		if err != nil {
+			return err
+		}
+		if err := txn.Put(actionKey(seq), v); err != nil {
+			return err
+		}
+		if seq > uint64(d.actionHistory) {
+			if err := txn.Delete(actionKey(seq - uint64(d.actionHistory))); err != nil {
+				return err
+			}
+		}
+	}
+	buf := make([]byte, 8)
+	binary.BigEndian.PutUint64(buf, seq)
+	if err := txn.Put(dsActionSeq, buf); err != nil {
+		return err
+	}
+	if err := txn.Commit(); err != nil {
+		return err
+	}
+	d.actionSeq = seq
+	return nil
+}
+
+// actionsSince returns the kept actions after the one with the given resume token.
+// The caller must hold the dispatcher lock.
+func (d *DB) actionsSince(token uint64) ([]Action, error) {
+	if token > d.actionSeq {
+		return nil, ErrInvalidResumeToken
+	}
+	if token+uint64(d.actionHistory) < d.actionSeq {
+		return nil, ErrInvalidResumeToken
+	}
+	results, err := d.datastore.Query(query.Query{
+		Prefix:     dsActions.String(),
+		SeekPrefix: actionKey(token + 1).String(),
+		Orders:     []query.Order{query.OrderByKey{}},
+	})
+	if err != nil {
+		return nil, err
+	}
+	defer results.Close()
+	var actions []Action
+	for res := range results.Next() {
+		if res.Error != nil {
+			return nil, res.Error
+		}
+		var a Action
+		if err := json.Unmarshal(res.Value, &a); err != nil {
+			return nil, err
+		}
+		if a.ResumeToken <= token { // Not every datastore supports seeking
+			continue
+		}
+		actions = append(actions, a)
+	}
+	return actions, nil
+}
@@ -0,0 +1,177 @@
+package db
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/alecthomas/jsonschema"
+)
+
+// ErrInvalidSchemaRef indicates a schema references a definition of a
+// collection that doesn't exist or doesn't have it.
+var ErrInvalidSchemaRef = errors.New("invalid schema reference")
+
+const definitionsRef = "#/definitions/"
+
+// resolveSchemaRefs returns a copy of the schema of a collection in which
+// references to definitions of other collections, e.g.,
+// "Address#/definitions/Street", are replaced with local references. The
+// referenced definitions are copied to the schema definitions, named after
+// their collection, e.g., "Address.Street", along with the definitions they
+// reference. The caller must hold d.lock.
+func (d *DB) resolveSchemaRefs(name string, schema *jsonschema.Schema) (*jsonschema.Schema, error) {
+	if schema == nil {
+		return nil, nil
+	}
+	b, err := json.Marshal(schema)
+	if err != nil {
+		return nil, err
+	}
+	if !strings.Contains(string(b), definitionsRef) {
+		return schema, nil
+	}
+	var doc map[string]interface{}
+	if err := json.Unmarshal(b, &doc); err != nil {
+		return nil, err
+	}
+	defs, _ := doc["definitions"].(map[string]interface{})
+	if defs == nil {
+		defs = make(map[string]interface{})
+	}
+	r := &schemaRefResolver{db: d, name: name, defs: defs, docs: make(map[string]map[string]interface{})}
+	var resolved bool
+	if err := r.resolve(doc, "", &resolved); err != nil {
+		return nil, err
+	}
+	if !resolved {
+		return schema, nil
+	}
+	doc["definitions"] = defs
+	if b, err = json.Marshal(doc); err != nil {
+		return nil, err
+	}
+	res := &jsonschema.Schema{}
+	if err := json.Unmarshal(b, res); err != nil {
+		return nil, err
+	}
+	return res, nil
+}
+
+// schemaRefResolver copies the definitions referenced by a schema from the
+// schemas of other collections.
+type schemaRefResolver struct {
+	db   *DB
+	name string
+	defs map[string]interface{}
+	// The definitions of the referenced collection schemas
+	docs map[string]map[string]interface{}
+}
+
+// resolve replaces the references of v to other collections with local
+// references. Local references of definitions copied from the from
+// collection are qualified with it.
+func (r *schemaRefResolver) resolve(v interface{}, from string, resolved *bool) error {
+	switch v := v.(type) {
+	case map[string]interface{}:
+		if ref, ok := v["$ref"].(string); ok {
+			collection, def, ok := splitSchemaRef(ref)
+			if !ok && from != "" && strings.HasPrefix(ref, definitionsRef) {
+				collection, def, ok = from, strings.TrimPrefix(ref, definitionsRef), true
+			}
+			if ok {
+				key, err := r.copyDefinition(collection, def, resolved)
+				if err != nil {
+					return err
+				}
+				v["$ref"] = definitionsRef + key
+				*resolved = true
+			}
+		}
+		for k, e := range v {
+			if from == "" && k == "definitions" {
+				// Local definitions are resolved below, so copied ones aren't resolved twice
+				continue
+			}
+			if err := r.resolve(e, from, resolved); err != nil {
+				return err
+			}
+		}
+		if defs, ok := v["definitions"].(map[string]interface{}); ok && from == "" {
+			local := make([]interface{}, 0, len(defs))
+			for _, dv := range defs {
+				local = append(local, dv)
+			}
+			for _, dv := range local {
+				if err := r.resolve(dv, from, resolved); err != nil {
+					return err
+				}
+			}
+		}
+	case []interface{}:
+		for _, e := range v {
+			if err := r.resolve(e, from, resolved); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// copyDefinition copies a definition of a collection schema to the schema
+// definitions, if it isn't already, and returns its local name.
+func (r *schemaRefResolver) copyDefinition(collection, def string, resolved *bool) (string, error) {
+	key := collection + "." + def
+	if _, ok := r.defs[key]; ok {
+		return key, nil
+	}
+	defs, err := r.collectionDefinitions(collection)
+	if err != nil {
+		return "", err
+	}
+	dv, ok := defs[def]
+	if !ok {
+		return "", fmt.Errorf("%w: %s has no definition %s", ErrInvalidSchemaRef, collection, def)
+	}
+	// Definitions are added before they're resolved, so cycles terminate
+	r.defs[key] = dv
+	if err := r.resolve(dv, collection, resolved); err != nil {
+		return "", err
+	}
+	return key, nil
+}
+
+// collectionDefinitions returns the schema definitions of a collection.
+func (r *schemaRefResolver) collectionDefinitions(collection string) (map[string]interface{}, error) {
+	if defs, ok := r.docs[collection]; ok {
+		return defs, nil
+	}
+	if collection == r.name {
+		return nil, fmt.Errorf("%w: %s references itself", ErrInvalidSchemaRef, collection)
+	}
+	c, err := r.db.getCollection(collection)
+	if errors.Is(err, ErrCollectionNotFound) {
+		return nil, fmt.Errorf("%w: collection %s not found", ErrInvalidSchemaRef, collection)
+	} else if err != nil {
+		return nil, err
+	}
+	var doc struct {
+		Definitions map[string]interface{} `json:"definitions"`
+	}
+	if err := json.Unmarshal(c.GetSchema(), &doc); err != nil {
+		return nil, err
+	}
+	r.docs[collection] = doc.Definitions
+	return doc.Definitions, nil
+}
+
+// splitSchemaRef splits a reference to a definition of another collection,
+// e.g., "Address#/definitions/Street", into the collection and definition names.
+func splitSchemaRef(ref string) (collection, def string, ok bool) {
+	i := strings.Index(ref, definitionsRef)
+	if i <= 0 || !nameRx.MatchString(ref[:i]) {
+		return "", "", false
+	}
+	return ref[:i], ref[i+len(definitionsRef):], true
+}
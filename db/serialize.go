@@ -0,0 +1,93 @@
+package db
+
+import (
+	"errors"
+	"sync"
+
+	ds "github.com/textileio/go-datastore"
+)
+
+// WritePositionFunc is called while a write to a collection with serialized
+// writes waits in the write queue, with the number of writes ahead of it.
+// It's called with 0 once the write starts. See CollectionConfig.SerializeWrites.
+type WritePositionFunc func(ahead int)
+
+var dsSerialized = dsPrefix.ChildString("serialized")
+
+// writeQueue serializes the local writes of a collection in arrival order.
+// Writes take increasing tickets, and run once the tickets before them are done.
+type writeQueue struct {
+	lock    sync.Mutex
+	done    *sync.Cond
+	next    uint64
+	serving uint64
+}
+
+func newWriteQueue() *writeQueue {
+	q := &writeQueue{}
+	q.done = sync.NewCond(&q.lock)
+	return q
+}
+
+// enter queues a write and blocks until it's the write turn.
+// position is called without the queue lock as the write advances.
+func (q *writeQueue) enter(position WritePositionFunc) {
+	q.lock.Lock()
+	defer q.lock.Unlock()
+	ticket := q.next
+	q.next++
+	last := -1
+	for {
+		ahead := int(ticket - q.serving)
+		if position != nil && ahead != last {
+			last = ahead
+			q.lock.Unlock()
+			position(ahead)
+			q.lock.Lock()
+		}
+		if ahead == 0 {
+			return
+		}
+		q.done.Wait()
+	}
+}
+
+// leave ends the running write, letting the next one start.
+func (q *writeQueue) leave() {
+	q.lock.Lock()
+	defer q.lock.Unlock()
+	q.serving++
+	q.done.Broadcast()
+}
+
+// len returns the number of queued writes, including the running one.
+func (q *writeQueue) len() int {
+	q.lock.Lock()
+	defer q.lock.Unlock()
+	return int(q.next - q.serving)
+}
+
+// WriteQueueLen returns the number of writes queued to the collection,
+// including the running one, or 0 if its writes aren't serialized.
+func (c *Collection) WriteQueueLen() int {
+	if c.writes == nil {
+		return 0
+	}
+	return c.writes.len()
+}
+
+func (d *DB) saveSerializeWrites(c *Collection) error {
+	if c.writes == nil {
+		return d.datastore.Delete(dsSerialized.ChildString(c.name))
+	}
+	return d.datastore.Put(dsSerialized.ChildString(c.name), []byte{1})
+}
+
+// loadSerializeWrites returns whether the writes of a collection are serialized.
+func (d *DB) loadSerializeWrites(name string) (bool, error) {
+	ok, err := d.datastore.Has(dsSerialized.ChildString(name))
+	if errors.Is(err, ds.ErrNotFound) {
+		return false, nil
+	}
+	return ok, err
+}
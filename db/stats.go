@@ -0,0 +1,84 @@
+package db
+
+import (
+	"time"
+
+	ds "github.com/textileio/go-datastore"
+	"github.com/textileio/go-datastore/query"
+	"github.com/tidwall/gjson"
+)
+
+// CollectionStats are storage statistics of a collection.
+type CollectionStats struct {
+	// Instances is the number of instances.
+	Instances int
+	// Bytes is the approximate storage size of the instances, keys included.
+	Bytes int64
+	// Indexes are the statistics of the collection indexes.
+	Indexes []IndexStats
+	// LastModified is the latest modification time of an instance,
+	// or the zero time if the collection is empty.
+	LastModified time.Time
+}
+
+// IndexStats are storage statistics of a collection index.
+type IndexStats struct {
+	// Path is the indexed field path.
+	Path string
+	// Entries is the number of distinct indexed values.
+	Entries int
+	// Bytes is the approximate storage size of the index, keys included.
+	Bytes int64
+}
+
+// Stats returns storage statistics of the collection, computed by scanning
+// its instances and indexes in a snapshot of the collection.
+func (c *Collection) Stats(opts ...TxnOption) (stats CollectionStats, err error) {
+	opts = append([]TxnOption{WithTxnSnapshot(true)}, opts...)
+	err = c.ReadTxn(func(txn *Txn) error {
+		store := txn.reader()
+		var lastMod int64
+		err := scanPrefix(store, c.baseKey(), func(e query.Entry) {
+			stats.Instances++
+			stats.Bytes += int64(len(e.Key) + len(e.Value))
+			if mod := gjson.GetBytes(e.Value, modFieldName).Int(); mod > lastMod {
+				lastMod = mod
+			}
+		})
+		if err != nil {
+			return err
+		}
+		if lastMod > 0 {
+			stats.LastModified = time.Unix(0, lastMod)
+		}
+		for _, index := range c.GetIndexes() {
+			is := IndexStats{Path: index.Path}
+			prefix := indexPrefix.Child(c.baseKey()).ChildString(index.Path)
+			if err := scanPrefix(store, prefix, func(e query.Entry) {
+				is.Entries++
+				is.Bytes += int64(len(e.Key) + len(e.Value))
+			}); err != nil {
+				return err
+			}
+			stats.Indexes = append(stats.Indexes, is)
+		}
+		return nil
+	}, opts...)
+	return
+}
+
+// scanPrefix calls fn with each of the entries under prefix.
+func scanPrefix(store ds.Read, prefix ds.Key, fn func(e query.Entry)) error {
+	results, err := store.Query(query.Query{Prefix: prefix.String()})
+	if err != nil {
+		return err
+	}
+	defer results.Close()
+	for res := range results.Next() {
+		if res.Error != nil {
+			return res.Error
+		}
+		fn(res.Entry)
+	}
+	return nil
+}
@@ -0,0 +1,151 @@
+package db
+
+import (
+	"errors"
+	"fmt"
+
+	ds "github.com/textileio/go-datastore"
+	dsq "github.com/textileio/go-datastore/query"
+)
+
+// ErrStorage indicates that the underlying datastore failed, as opposed
+// to the operation being rejected by a schema, validator, or constraint.
+// The datastore error is preserved in Err.
+type ErrStorage struct {
+	Err error
+}
+
+func (e *ErrStorage) Error() string {
+	return fmt.Sprintf("storage error: %s", e.Err)
+}
+
+func (e *ErrStorage) Unwrap() error {
+	return e.Err
+}
+
+// storageError wraps a datastore error in an ErrStorage.
+// ds.ErrNotFound is returned as-is since it isn't a storage failure.
+func storageError(err error) error {
+	if err == nil || errors.Is(err, ds.ErrNotFound) {
+		return err
+	}
+	var se *ErrStorage
+	if errors.As(err, &se) {
+		return err
+	}
+	return &ErrStorage{Err: err}
+}
+
+// storageDatastore wraps all errors returned by a datastore in an ErrStorage.
+type storageDatastore struct {
+	ds.TxnDatastore
+}
+
+func wrapStorageErrors(child ds.TxnDatastore) *storageDatastore {
+	return &storageDatastore{TxnDatastore: child}
+}
+
+func (d *storageDatastore) Get(key ds.Key) ([]byte, error) {
+	v, err := d.TxnDatastore.Get(key)
+	return v, storageError(err)
+}
+
+func (d *storageDatastore) Has(key ds.Key) (bool, error) {
+	exists, err := d.TxnDatastore.Has(key)
+	return exists, storageError(err)
+}
+
+func (d *storageDatastore) GetSize(key ds.Key) (int, error) {
+	size, err := d.TxnDatastore.GetSize(key)
+	return size, storageError(err)
+}
+
+func (d *storageDatastore) Query(q dsq.Query) (dsq.Results, error) {
+	res, err := d.TxnDatastore.Query(q)
+	if err != nil {
+		return nil, storageError(err)
+	}
+	return storageResults(res), nil
+}
+
+func (d *storageDatastore) Put(key ds.Key, value []byte) error {
+	return storageError(d.TxnDatastore.Put(key, value))
+}
+
+func (d *storageDatastore) Delete(key ds.Key) error {
+	return storageError(d.TxnDatastore.Delete(key))
+}
+
+func (d *storageDatastore) Sync(prefix ds.Key) error {
+	return storageError(d.TxnDatastore.Sync(prefix))
+}
+
+func (d *storageDatastore) Close() error {
+	return storageError(d.TxnDatastore.Close())
+}
+
+func (d *storageDatastore) NewTransaction(readOnly bool) (ds.Txn, error) {
+	t, err := d.TxnDatastore.NewTransaction(readOnly)
+	if err != nil {
+		return nil, storageError(err)
+	}
+	return &storageTxn{Txn: t}, nil
+}
+
+// storageTxn wraps all errors returned by a datastore txn in an ErrStorage.
+type storageTxn struct {
+	ds.Txn
+}
+
+func (t *storageTxn) Get(key ds.Key) ([]byte, error) {
+	v, err := t.Txn.Get(key)
+	return v, storageError(err)
+}
+
+func (t *storageTxn) Has(key ds.Key) (bool, error) {
+	exists, err := t.Txn.Has(key)
+	return exists, storageError(err)
+}
+
+func (t *storageTxn) GetSize(key ds.Key) (int, error) {
+	size, err := t.Txn.GetSize(key)
+	return size, storageError(err)
+}
+
+func (t *storageTxn) Query(q dsq.Query) (dsq.Results, error) {
+	res, err := t.Txn.Query(q)
+	if err != nil {
+		return nil, storageError(err)
+	}
+	return storageResults(res), nil
+}
+
+func (t *storageTxn) Put(key ds.Key, value []byte) error {
+	return storageError(t.Txn.Put(key, value))
+}
+
+func (t *storageTxn) Delete(key ds.Key) error {
+	return storageError(t.Txn.Delete(key))
+}
+
+func (t *storageTxn) Commit() error {
+	return storageError(t.Txn.Commit())
+}
+
+// storageResults wraps the errors of query results in an ErrStorage.
+func storageResults(res dsq.Results) dsq.Results {
+	return dsq.ResultsFromIterator(res.Query(), dsq.Iterator{
+		Next: func() (dsq.Result, bool) {
+			r, ok := res.NextSync()
+			if ok && r.Error != nil {
+				r.Error = storageError(r.Error)
+			}
+			return r, ok
+		},
+		Close: func() error {
+			return storageError(res.Close())
+		},
+	})
+}
+
+var _ ds.TxnDatastore = (*storageDatastore)(nil)
@@ -0,0 +1,142 @@
+package db
+
+import (
+	"bytes"
+	"errors"
+	"sort"
+	"strings"
+	"unicode"
+
+	ds "github.com/textileio/go-datastore"
+)
+
+// ErrInvalidTextIndex indicates a text index isn't on a single string field, or is unique or case-insensitive.
+var ErrInvalidTextIndex = errors.New("text indexes must be on a single string field and can't be unique or case-insensitive")
+
+// TextSearch is a full-text search operator against a string field.
+// It matches if the field contains all of the terms in value, after
+// tokenizing and stemming both. Queries are enhanced by a text index
+// on the field, see Index.Text.
+func (c *Criterion) TextSearch(value string) *Query {
+	return c.createcriterion(TextSearch, value)
+}
+
+// textTokens returns the unique stemmed terms of s, in order of appearance.
+func textTokens(s string) []string {
+	words := strings.FieldsFunc(strings.ToLower(s), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsNumber(r)
+	})
+	seen := make(map[string]struct{}, len(words))
+	tokens := make([]string, 0, len(words))
+	for _, w := range words {
+		t := stem(w)
+		if _, ok := seen[t]; ok {
+			continue
+		}
+		seen[t] = struct{}{}
+		tokens = append(tokens, t)
+	}
+	return tokens
+}
+
+// stem strips common English inflections from a lowercase word,
+// e.g., "stories" -> "story", "running" -> "run", "boxes" -> "box".
+func stem(w string) string {
+	switch {
+	case len(w) > 4 && strings.HasSuffix(w, "ies"):
+		return w[:len(w)-3] + "y"
+	case len(w) > 5 && strings.HasSuffix(w, "ing"):
+		return undouble(w[:len(w)-3])
+	case len(w) > 4 && strings.HasSuffix(w, "ed"):
+		return undouble(w[:len(w)-2])
+	case len(w) > 4 && strings.HasSuffix(w, "ly"):
+		return w[:len(w)-2]
+	case len(w) > 4 && (strings.HasSuffix(w, "sses") || strings.HasSuffix(w, "xes") ||
+		strings.HasSuffix(w, "zes") || strings.HasSuffix(w, "ches") || strings.HasSuffix(w, "shes")):
+		return w[:len(w)-2]
+	case len(w) > 3 && strings.HasSuffix(w, "s") && !strings.HasSuffix(w, "ss") && !strings.HasSuffix(w, "us"):
+		return w[:len(w)-1]
+	default:
+		return w
+	}
+}
+
+// undouble removes a trailing doubled consonant, e.g., "runn" -> "run".
+func undouble(w string) string {
+	n := len(w)
+	if n < 3 || w[n-1] != w[n-2] || strings.ContainsRune("aeioulsz", rune(w[n-1])) {
+		return w
+	}
+	return w[:n-1]
+}
+
+// textMatch returns whether or not value contains all of the terms.
+func textMatch(value, terms string) bool {
+	tokens := make(map[string]struct{})
+	for _, t := range textTokens(value) {
+		tokens[t] = struct{}{}
+	}
+	for _, t := range textTokens(terms) {
+		if _, ok := tokens[t]; !ok {
+			return false
+		}
+	}
+	return true
+}
+
+// textCandidates returns the keys of the instances containing the terms of
+// text searches in q, using text indexes. Results are ordered by key.
+// If q can't be served by text indexes, ok is false.
+func (c *Collection) textCandidates(txn ds.Txn, q *Query) (keys []ds.Key, ok bool, err error) {
+	if q.Index != "" || q.Seek != "" || len(q.Ors) > 0 {
+		return nil, false, nil
+	}
+	var candidates keyList
+	for _, a := range q.Ands {
+		if a.Operation != TextSearch || a.Value.String == nil || !c.indexes[a.FieldPath].Text {
+			continue
+		}
+		for _, term := range textTokens(*a.Value.String) {
+			data, err := txn.Get(c.indexKey(a.FieldPath, ds.NewKey(term)))
+			if errors.Is(err, ds.ErrNotFound) {
+				return nil, true, nil
+			} else if err != nil {
+				return nil, false, err
+			}
+			held := make(keyList, 0)
+			if err := DefaultDecode(data, &held); err != nil {
+				return nil, false, err
+			}
+			if !ok {
+				candidates, ok = held, true
+			} else {
+				candidates = intersectKeys(candidates, held)
+			}
+			if len(candidates) == 0 {
+				return nil, true, nil
+			}
+		}
+	}
+	if !ok {
+		return nil, false, nil
+	}
+	keys = make([]ds.Key, len(candidates))
+	for i, k := range candidates {
+		keys[i] = ds.RawKey(string(k))
+	}
+	return keys, true, nil
+}
+
+// intersectKeys returns the keys in both of the sorted lists.
+func intersectKeys(a, b keyList) keyList {
+	var res keyList
+	for _, k := range a {
+		i := sort.Search(len(b), func(i int) bool {
+			return bytes.Compare(b[i], k) >= 0
+		})
+		if i < len(b) && bytes.Equal(b[i], k) {
+			res = append(res, k)
+		}
+	}
+	return res
+}
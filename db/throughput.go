@@ -0,0 +1,115 @@
+package db
+
+import (
+	"sync/atomic"
+	"time"
+)
+
+const (
+	// defaultThroughputWindow is the default rolling window of collection throughput stats.
+	defaultThroughputWindow = time.Minute
+	// throughputBuckets is the number of buckets a throughput window is divided in.
+	throughputBuckets = 60
+)
+
+// Throughput are the recent read and write rates of a collection.
+type Throughput struct {
+	// Window is the period the rates are computed over.
+	Window time.Duration
+	// Reads is the number of read transactions per second.
+	Reads float64
+	// Writes is the number of write transactions per second.
+	Writes float64
+	// ReadLatency is the average duration of a read transaction.
+	ReadLatency time.Duration
+	// WriteLatency is the average duration of a write transaction.
+	WriteLatency time.Duration
+}
+
+// throughputBucket accumulates the transactions of a slice of the window.
+type throughputBucket struct {
+	epoch      int64
+	reads      uint64
+	writes     uint64
+	readNanos  uint64
+	writeNanos uint64
+}
+
+// throughputStats keeps rolling transaction counters in a ring of buckets.
+// Counters are updated atomically without locking, so stats are approximate
+// while a bucket is being recycled.
+type throughputStats struct {
+	window  time.Duration
+	width   int64
+	buckets [throughputBuckets]throughputBucket
+}
+
+func newThroughputStats(window time.Duration) *throughputStats {
+	if window <= 0 {
+		window = defaultThroughputWindow
+	}
+	width := int64(window) / throughputBuckets
+	if width == 0 {
+		width = 1
+	}
+	return &throughputStats{window: window, width: width}
+}
+
+// bucket returns the current bucket, resetting it if it's from a previous window.
+func (s *throughputStats) bucket(now time.Time) *throughputBucket {
+	epoch := now.UnixNano() / s.width
+	b := &s.buckets[epoch%throughputBuckets]
+	if old := atomic.LoadInt64(&b.epoch); old != epoch && atomic.CompareAndSwapInt64(&b.epoch, old, epoch) {
+		atomic.StoreUint64(&b.reads, 0)
+		atomic.StoreUint64(&b.writes, 0)
+		atomic.StoreUint64(&b.readNanos, 0)
+		atomic.StoreUint64(&b.writeNanos, 0)
+	}
+	return b
+}
+
+func (s *throughputStats) observe(write bool, start time.Time) {
+	now := time.Now()
+	b := s.bucket(now)
+	elapsed := uint64(now.Sub(start))
+	if write {
+		atomic.AddUint64(&b.writes, 1)
+		atomic.AddUint64(&b.writeNanos, elapsed)
+	} else {
+		atomic.AddUint64(&b.reads, 1)
+		atomic.AddUint64(&b.readNanos, elapsed)
+	}
+}
+
+func (s *throughputStats) get() Throughput {
+	epoch := time.Now().UnixNano() / s.width
+	var reads, writes, readNanos, writeNanos uint64
+	for i := range s.buckets {
+		b := &s.buckets[i]
+		if e := atomic.LoadInt64(&b.epoch); e <= epoch-throughputBuckets || e > epoch {
+			continue
+		}
+		reads += atomic.LoadUint64(&b.reads)
+		writes += atomic.LoadUint64(&b.writes)
+		readNanos += atomic.LoadUint64(&b.readNanos)
+		writeNanos += atomic.LoadUint64(&b.writeNanos)
+	}
+	t := Throughput{
+		Window: s.window,
+		Reads:  float64(reads) / s.window.Seconds(),
+		Writes: float64(writes) / s.window.Seconds(),
+	}
+	if reads > 0 {
+		t.ReadLatency = time.Duration(readNanos / reads)
+	}
+	if writes > 0 {
+		t.WriteLatency = time.Duration(writeNanos / writes)
+	}
+	return t
+}
+
+// Throughput returns the read and write transaction rates of the collection
+// over the db throughput window. See WithNewThroughputWindow.
+func (c *Collection) Throughput() Throughput {
+	return c.throughput.get()
+}
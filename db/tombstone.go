@@ -0,0 +1,197 @@
+package db
+
+import (
+	"encoding/json"
+	"fmt"
+	"time"
+
+	ds "github.com/textileio/go-datastore"
+	"github.com/textileio/go-datastore/query"
+	core "github.com/textileio/go-threads/core/db"
+	"github.com/textileio/go-threads/core/thread"
+)
+
+var (
+	dsTombstones = dsPrefix.ChildString("tombstone")
+)
+
+// DeletedInstance is a tombstoned instance.
+type DeletedInstance struct {
+	// ID is the instance ID.
+	ID core.InstanceID
+	// Deleted is the time the instance was deleted.
+	Deleted time.Time
+	// Instance is the last known state of the instance.
+	Instance []byte
+}
+
+type tombstone struct {
+	Deleted  int64           `json:"deleted"`
+	Instance json.RawMessage `json:"instance"`
+}
+
+// tombstoneKey returns the tombstone key of an instance.
+func tombstoneKey(collection string, id core.InstanceID) ds.Key {
+	return dsTombstones.ChildString(collection).ChildString(id.String())
+}
+
+// updateTombstone keeps the tombstone of an instance up-to-date when tombstone
+// retention is enabled. Deleting an instance records its last state, and
+// re-creating it removes the tombstone.
+func (d *DB) updateTombstone(collection string, key ds.Key, oldData, newData []byte, txn ds.Txn) error {
+	if !d.tombstones {
+		return nil
+	}
+	tk := tombstoneKey(collection, core.InstanceID(key.Name()))
+	if newData != nil {
+		if oldData == nil {
+			return txn.Delete(tk)
+		}
+		return nil
+	}
+	if oldData == nil {
+		return nil
+	}
+	tb, err := json.Marshal(tombstone{
+		Deleted:  time.Now().UnixNano(),
+		Instance: oldData,
+	})
+	if err != nil {
+		return err
+	}
+	return txn.Put(tk, tb)
+}
+
+// IncludeDeleted makes the query also match tombstoned instances.
+// Find marks deleted instances with a _deleted field holding their
+// deletion time as a Unix time in nanoseconds.
+// The db must be created with WithNewTombstoneRetention for deleted instances to be available.
+func (q *Query) IncludeDeleted() *Query {
+	q.WithDeleted = true
+	return q
+}
+
+// IncludeDeletedSince makes the query also match instances tombstoned at or
+// after since, e.g., to reconcile deletions made since the last sync.
+func (q *Query) IncludeDeletedSince(since time.Time) *Query {
+	q.WithDeleted = true
+	q.DeletedSince = since.UnixNano()
+	return q
+}
+
+// FindDeleted executes a Query over tombstoned instances.
+// The db must be created with WithNewTombstoneRetention for deleted instances to be available.
+// Sorting is not supported, results are ordered by ID.
+func (c *Collection) FindDeleted(q *Query, opts ...TxnOption) (instances []DeletedInstance, err error) {
+	err = c.ReadTxn(func(txn *Txn) error {
+		instances, err = txn.FindDeleted(q)
+		return err
+	}, opts...)
+	return
+}
+
+// FindDeleted executes a Query over tombstoned instances in the current txn scope.
+func (t *Txn) FindDeleted(q *Query) ([]DeletedInstance, error) {
+	if err := t.collection.db.connector.Validate(t.token, true); err != nil {
+		return nil, err
+	}
+	if q == nil {
+		q = &Query{}
+	}
+	if err := q.Validate(); err != nil {
+		return nil, fmt.Errorf("invalid query: %s", err)
+	}
+	pk, err := t.token.PubKey()
+	if err != nil {
+		return nil, err
+	}
+	var res []DeletedInstance
+	err = t.collection.iterateDeleted(t.reader(), pk, q, func(d DeletedInstance, _ map[string]interface{}) bool {
+		res = append(res, d)
+		return true
+	})
+	if err != nil {
+		return nil, err
+	}
+	if q.Skip > 0 {
+		if q.Skip >= len(res) {
+			return nil, nil
+		}
+		res = res[q.Skip:]
+	}
+	if q.Limit > 0 && q.Limit < len(res) {
+		res = res[:q.Limit]
+	}
+	return res, nil
+}
+
+// iterateDeleted calls fn with each tombstoned instance matching q until fn returns false.
+func (c *Collection) iterateDeleted(store ds.Read, identity thread.PubKey, q *Query, fn func(DeletedInstance, map[string]interface{}) bool) error {
+	results, err := store.Query(query.Query{
+		Prefix: dsTombstones.ChildString(c.name).String(),
+		Orders: []query.Order{query.OrderByKey{}},
+	})
+	if err != nil {
+		return err
+	}
+	defer results.Close()
+	for res := range results.Next() {
+		if res.Error != nil {
+			return res.Error
+		}
+		tb := tombstone{}
+		if err := json.Unmarshal(res.Value, &tb); err != nil {
+			return err
+		}
+		if tb.Deleted < q.DeletedSince {
+			continue
+		}
+		val := make(map[string]interface{})
+		if err := json.Unmarshal(tb.Instance, &val); err != nil {
+			return err
+		}
+		ok, err := q.match(val)
+		if err != nil {
+			return err
+		}
+		if !ok {
+			continue
+		}
+		instance, err := c.readInstance(identity, tb.Instance)
+		if err != nil {
+			return err
+		}
+		if instance == nil {
+			continue
+		}
+		if !fn(DeletedInstance{
+			ID:       core.InstanceID(ds.RawKey(res.Key).Name()),
+			Deleted:  time.Unix(0, tb.Deleted),
+			Instance: instance,
+		}, val) {
+			return nil
+		}
+	}
+	return nil
+}
+
+// deleteTombstones removes all tombstones of a collection.
+func (c *Collection) deleteTombstones(txn ds.Txn) error {
+	results, err := c.db.datastore.Query(query.Query{
+		Prefix:   dsTombstones.ChildString(c.name).String(),
+		KeysOnly: true,
+	})
+	if err != nil {
+		return err
+	}
+	defer results.Close()
+	for res := range results.Next() {
+		if res.Error != nil {
+			return res.Error
+		}
+		if err := txn.Delete(ds.RawKey(res.Key)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,162 @@
+package db
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	ds "github.com/textileio/go-datastore"
+	"github.com/textileio/go-threads/core/thread"
+)
+
+var errCommitDBTxnCollection = errors.New("collection txns of a db txn are committed with it")
+
+// DBTxn is a transaction spanning several collections of a db.
+// The writes to all collections are committed together in a single record,
+// so remote peers apply them atomically.
+type DBTxn struct {
+	db         *DB
+	token      thread.Token
+	metadata   map[string]string
+	readonly   bool
+	snapshot   ds.Txn
+	compareMod bool
+	ctx        context.Context
+
+	txns []*Txn
+}
+
+// WriteTxn creates an explicit write transaction across collections.
+// Writes are made with the collection transactions returned by
+// DBTxn.Collection, and are committed when f returns without error.
+// Provides the same serializable isolation guarantees as Collection.WriteTxn.
+func (d *DB) WriteTxn(f func(txn *DBTxn) error, opts ...TxnOption) error {
+	txn, err := d.commitDBTxn(f, opts...)
+	if err != nil {
+		return err
+	}
+	// After hooks run once the txn lock is released, so they can write to the db
+	txn.runAfterWriteHooks()
+	return nil
+}
+
+// ReadTxn creates an explicit readonly transaction across collections.
+// With WithTxnSnapshot, all collections are read from the same snapshot.
+func (d *DB) ReadTxn(f func(txn *DBTxn) error, opts ...TxnOption) error {
+	d.txnlock.RLock()
+	defer d.txnlock.RUnlock()
+	if err := d.checkOpen(); err != nil {
+		return err
+	}
+
+	args := &TxnOptions{}
+	for _, opt := range opts {
+		opt(args)
+	}
+	dtxn := &DBTxn{db: d, token: args.Token, readonly: true, ctx: args.Context}
+	if args.Snapshot {
+		snapshot, err := d.datastore.NewTransaction(true)
+		if err != nil {
+			return err
+		}
+		defer snapshot.Discard()
+		dtxn.snapshot = snapshot
+	}
+	return f(dtxn)
+}
+
+func (d *DB) commitDBTxn(f func(txn *DBTxn) error, opts ...TxnOption) (*Txn, error) {
+	start := time.Now()
+	d.txnlock.Lock()
+	defer d.txnlock.Unlock()
+	if err := d.checkOpen(); err != nil {
+		return nil, err
+	}
+
+	args := &TxnOptions{}
+	for _, opt := range opts {
+		opt(args)
+	}
+	dtxn := &DBTxn{db: d, token: args.Token, metadata: args.Metadata, compareMod: args.CompareMod, ctx: args.Context}
+	if err := f(dtxn); err != nil {
+		return nil, err
+	}
+	if len(dtxn.txns) == 0 {
+		return &Txn{}, nil
+	}
+	txn := &Txn{collection: dtxn.txns[0].collection, token: args.Token, metadata: args.Metadata}
+	defer txn.Discard()
+	for _, t := range dtxn.txns {
+		txn.actions = append(txn.actions, t.actions...)
+	}
+	if err := txn.Commit(); err != nil {
+		return nil, err
+	}
+	for _, t := range dtxn.txns {
+		t.collection.throughput.observe(true, start)
+	}
+	return txn, nil
+}
+
+// Collection returns the transaction of the collection with the given name,
+// whose writes are part of the db transaction. Reads of a collection
+// transaction only see the pending writes made to that collection.
+func (t *DBTxn) Collection(name string) (*Txn, error) {
+	for _, txn := range t.txns {
+		if txn.collection.name == name {
+			return txn, nil
+		}
+	}
+	t.db.lock.Lock()
+	c, err := t.db.getCollection(name)
+	t.db.lock.Unlock()
+	if err != nil {
+		return nil, err
+	}
+	txn := &Txn{
+		collection: c,
+		token:      t.token,
+		metadata:   t.metadata,
+		readonly:   t.readonly,
+		compareMod: t.compareMod,
+		ctx:        t.ctx,
+		dbtxn:      t,
+		snapshot:   t.snapshot,
+	}
+	t.txns = append(t.txns, txn)
+	return txn, nil
+}
+
+// checkContext returns the error of the transaction context, if it's done.
+func (t *Txn) checkContext() error {
+	if t.ctx == nil {
+		return nil
+	}
+	return t.ctx.Err()
+}
+
+// pinnedTxn is a snapshot datastore txn that outlives the reads using it.
+type pinnedTxn struct {
+	ds.Txn
+}
+
+// Discard is a no-op, the snapshot is discarded with its transaction.
+func (pinnedTxn) Discard() {}
+
+// readTxn returns a readonly datastore txn for the reads of the transaction,
+// which is its snapshot if it's pinned.
+func (t *Txn) readTxn() (ds.Txn, error) {
+	if t.snapshot != nil {
+		return pinnedTxn{t.snapshot}, nil
+	}
+	return t.collection.db.datastore.NewTransaction(true)
+}
+
+// reader returns the store for the reads of the transaction,
+// which is its snapshot if it's pinned.
+func (t *Txn) reader() ds.Read {
+	if t.snapshot != nil {
+		return t.snapshot
+	}
+	return t.collection.db.datastore
+}
@@ -0,0 +1,165 @@
+package db
+
+import (
+	"strings"
+	"sync"
+	"sync/atomic"
+
+	"github.com/xeipuuv/gojsonschema"
+)
+
+// ValidationFailure describes a write that was rejected by a collection schema.
+type ValidationFailure struct {
+	// Collection is the name of the collection that rejected the write.
+	Collection string
+	// Fields are the failing field paths reported by the schema validator.
+	Fields []string
+	// Instance is the rejected instance, or nil if the db redacts rejected instances.
+	Instance []byte
+}
+
+// SchemaViolation is a constraint of a collection schema violated by an instance.
+type SchemaViolation struct {
+	// Pointer is the JSON pointer, see RFC 6901, of the failing value, e.g.,
+	// "/address/zip". Errors of properties, e.g., missing required properties,
+	// point to the property.
+	Pointer string
+	// Constraint is the type of the violated constraint, e.g., "required",
+	// "invalid_type" or "number_gte".
+	Constraint string
+	// Value is the offending value, or nil if it's missing.
+	Value interface{}
+	// Description describes the violation.
+	Description string
+}
+
+// ValidationError is returned by writes of instances that fail validation
+// against a collection schema. It matches ErrInvalidSchemaInstance.
+type ValidationError struct {
+	// Violations are the violated constraints of the schema.
+	Violations []SchemaViolation
+}
+
+func (e *ValidationError) Error() string {
+	msgs := make([]string, len(e.Violations))
+	for i, v := range e.Violations {
+		field := v.Pointer
+		if field == "" {
+			field = gojsonschema.STRING_CONTEXT_ROOT
+		}
+		msgs[i] = field + ": " + v.Description
+	}
+	return ErrInvalidSchemaInstance.Error() + ": " + strings.Join(msgs, "; ")
+}
+
+// Is returns whether target is ErrInvalidSchemaInstance.
+func (e *ValidationError) Is(target error) bool {
+	return target == ErrInvalidSchemaInstance
+}
+
+// newValidationError returns the validation error of schema validator errors.
+func newValidationError(errs []gojsonschema.ResultError) *ValidationError {
+	verr := &ValidationError{Violations: make([]SchemaViolation, len(errs))}
+	for i, e := range errs {
+		// Context heads are joined with a delimiter unlikely to be part of a key
+		heads := strings.Split(e.Context().String("\x00"), "\x00")[1:]
+		v := SchemaViolation{
+			Constraint:  e.Type(),
+			Value:       e.Value(),
+			Description: e.Description(),
+		}
+		// Property errors are reported on the parent object
+		if p, ok := e.Details()["property"].(string); ok {
+			heads = append(heads, p)
+			switch e.Type() {
+			case "required":
+				v.Value = nil
+			case "invalid_property_name":
+				v.Value = p
+			}
+		}
+		var b strings.Builder
+		for _, h := range heads {
+			b.WriteString("/")
+			b.WriteString(strings.NewReplacer("~", "~0", "/", "~1").Replace(h))
+		}
+		v.Pointer = b.String()
+		verr.Violations[i] = v
+	}
+	return verr
+}
+
+// ValidationFailureHandler is called with each write rejected by a collection schema.
+// Handlers are called synchronously on the write path and should return quickly.
+type ValidationFailureHandler func(f ValidationFailure)
+
+// ValidationStats are the schema validation failure counts of a collection.
+type ValidationStats struct {
+	// Total is the number of rejected instances.
+	Total uint64
+	// Fields is the number of failures per failing field path.
+	Fields map[string]uint64
+}
+
+// validationStats accumulates schema validation failures.
+type validationStats struct {
+	total  uint64
+	lock   sync.Mutex
+	fields map[string]uint64
+}
+
+func (s *validationStats) add(fields []string) {
+	atomic.AddUint64(&s.total, 1)
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	if s.fields == nil {
+		s.fields = make(map[string]uint64)
+	}
+	for _, f := range fields {
+		s.fields[f]++
+	}
+}
+
+func (s *validationStats) get() ValidationStats {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	fields := make(map[string]uint64, len(s.fields))
+	for f, n := range s.fields {
+		fields[f] = n
+	}
+	return ValidationStats{
+		Total:  atomic.LoadUint64(&s.total),
+		Fields: fields,
+	}
+}
+
+// GetValidationStats returns the schema validation failure counts of the collection.
+// Counts are only tracked if the db was created with WithNewValidationMetrics.
+func (c *Collection) GetValidationStats() ValidationStats {
+	return c.validationStats.get()
+}
+
+// observeValidationFailure records a schema validation failure if the db is configured to do so.
+func (c *Collection) observeValidationFailure(v []byte, errs []gojsonschema.ResultError) {
+	if !c.db.validationMetrics && c.db.validationHandler == nil {
+		return
+	}
+	fields := make([]string, len(errs))
+	for i, e := range errs {
+		fields[i] = e.Field()
+	}
+	if c.db.validationMetrics {
+		c.validationStats.add(fields)
+	}
+	if c.db.validationHandler != nil {
+		f := ValidationFailure{
+			Collection: c.name,
+			Fields:     fields,
+		}
+		if !c.db.redactRejected {
+			f.Instance = make([]byte, len(v))
+			copy(f.Instance, v)
+		}
+		c.db.validationHandler(f)
+	}
+}
@@ -0,0 +1,81 @@
+// Package hlc implements hybrid logical clocks.
+// See https://cse.buffalo.edu/tech-reports/2014-04.pdf for the original algorithm.
+//
+// A hybrid logical clock combines wall-clock time with a logical counter, so
+// timestamps stay close to physical time while still preserving causality,
+// regardless of clock skew between peers:
+//
+//	c := hlc.NewClock()
+//	t1 := c.Now()       // timestamp for a local event
+//	c.Update(remote)    // merge a timestamp received from another peer
+//	t2 := c.Now()       // t2 > t1 and t2 > remote
+package hlc
+
+import (
+	"sync"
+	"time"
+)
+
+// Timestamp is a hybrid logical clock timestamp in nanoseconds since the Unix epoch.
+// The logical counter is folded into the nanosecond resolution, i.e., when the
+// physical clock doesn't advance, the timestamp is incremented by one nanosecond.
+// Timestamps are totally ordered by their integer value, are never behind the
+// local wall clock, and remain a close approximation of Unix time.
+type Timestamp int64
+
+// Time returns the timestamp as a time.Time.
+func (t Timestamp) Time() time.Time {
+	return time.Unix(0, int64(t))
+}
+
+// Before returns whether t happened before u.
+func (t Timestamp) Before(u Timestamp) bool {
+	return t < u
+}
+
+// Clock is a hybrid logical clock. It's safe for concurrent use.
+type Clock struct {
+	lock sync.Mutex
+	last Timestamp
+	now  func() time.Time
+}
+
+// NewClock returns a new clock backed by the system wall clock.
+func NewClock() *Clock {
+	return &Clock{now: time.Now}
+}
+
+// Now returns a timestamp for a local or send event.
+// Timestamps returned by the same clock are strictly increasing.
+func (c *Clock) Now() Timestamp {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	pt := c.physicalNow()
+	if pt > c.last {
+		c.last = pt
+	} else {
+		c.last++
+	}
+	return c.last
+}
+
+// Update merges a timestamp received from another peer into the clock, and
+// returns a timestamp for the receive event that happens after remote.
+func (c *Clock) Update(remote Timestamp) Timestamp {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	pt := c.physicalNow()
+	if pt > c.last && pt > remote {
+		c.last = pt
+		return c.last
+	}
+	if remote > c.last {
+		c.last = remote
+	}
+	c.last++
+	return c.last
+}
+
+func (c *Clock) physicalNow() Timestamp {
+	return Timestamp(c.now().UnixNano())
+}
@@ -0,0 +1,56 @@
+package hlc
+
+import (
+	"testing"
+	"time"
+)
+
+func TestNowMonotonic(t *testing.T) {
+	wall := time.Now()
+	c := &Clock{now: func() time.Time { return wall }}
+	t1 := c.Now()
+	t2 := c.Now()
+	if !t1.Before(t2) {
+		t.Fatalf("expected %d before %d", t1, t2)
+	}
+	if t2 != t1+1 {
+		t.Fatalf("expected logical increment, got %d and %d", t1, t2)
+	}
+	if !t1.Time().Equal(wall) {
+		t.Fatal("expected timestamp to match wall clock")
+	}
+
+	// Wall clock moving backwards must not break monotonicity
+	wall = wall.Add(-time.Hour)
+	t3 := c.Now()
+	if !t2.Before(t3) {
+		t.Fatalf("expected %d before %d", t2, t3)
+	}
+}
+
+func TestUpdate(t *testing.T) {
+	wall := time.Now()
+	local := &Clock{now: func() time.Time { return wall }}
+	// Remote peer clock is an hour ahead
+	remote := &Clock{now: func() time.Time { return wall.Add(time.Hour) }}
+
+	rt := remote.Now()
+	ut := local.Update(rt)
+	if !rt.Before(ut) {
+		t.Fatalf("expected %d before %d", rt, ut)
+	}
+	lt := local.Now()
+	if !rt.Before(lt) {
+		t.Fatalf("local events after receive should happen after remote event")
+	}
+
+	// Older remote timestamps don't move the clock backwards
+	wall = wall.Add(2 * time.Hour)
+	nt := local.Now()
+	if !nt.Time().Equal(wall) {
+		t.Fatal("expected clock to catch up with wall clock")
+	}
+	if ut := local.Update(rt); !nt.Before(ut) {
+		t.Fatalf("expected %d before %d", nt, ut)
+	}
+}
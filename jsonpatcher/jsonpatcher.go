@@ -16,6 +16,7 @@ import (
 	"github.com/multiformats/go-multihash"
 	ds "github.com/textileio/go-datastore"
 	core "github.com/textileio/go-threads/core/db"
+	"github.com/textileio/go-threads/hlc"
 )
 
 type operationType int
@@ -51,9 +52,12 @@ type operation struct {
 	JSONPatch  []byte
 }
 
-type jsonPatcher struct{}
+type jsonPatcher struct {
+	clock    *hlc.Clock
+	policies func(collection string) core.ConflictPolicy
+}
 
-var _ core.EventCodec = (*jsonPatcher)(nil)
+var _ core.ConflictEventCodec = (*jsonPatcher)(nil)
 
 func init() {
 	cbornode.RegisterCborType(patchEvent{})
@@ -61,9 +65,27 @@ func init() {
 	cbornode.RegisterCborType(operation{})
 }
 
-// New returns a JSON-Patcher EventCodec
+// New returns a JSON-Patcher EventCodec.
+// Events are timestamped with a hybrid logical clock, which is advanced by
+// events received from other peers, so that events are reduced in causal order
+// regardless of clock skew between peers.
 func New() core.EventCodec {
-	return &jsonPatcher{}
+	return &jsonPatcher{clock: hlc.NewClock()}
+}
+
+// SetConflictPolicies sets the function returning the conflict policy of a
+// collection. It must be set before events are reduced. By default, the changes
+// of every event are merged in causal order.
+func (jp *jsonPatcher) SetConflictPolicies(policies func(collection string) core.ConflictPolicy) {
+	jp.policies = policies
+}
+
+// policy returns the conflict policy of a collection.
+func (jp *jsonPatcher) policy(collection string) core.ConflictPolicy {
+	if jp.policies == nil {
+		return core.ConflictPolicy{}
+	}
+	return jp.policies(collection)
 }
 
 func (jp *jsonPatcher) Create(actions []core.Action) ([]core.Event, format.Node, error) {
@@ -89,10 +111,11 @@ func (jp *jsonPatcher) Create(actions []core.Action) ([]core.Event, format.Node,
 			return nil, nil, err
 		}
 		revents.Patches[i] = patchEvent{
-			Timestamp:      time.Now().UnixNano(),
+			Timestamp:      int64(jp.clock.Now()),
 			ID:             actions[i].InstanceID,
 			CollectionName: actions[i].CollectionName,
 			Patch:          *op,
+			TxnMetadata:    actions[i].Metadata,
 		}
 		events[i] = revents.Patches[i]
 	}
@@ -119,16 +142,37 @@ func (jp *jsonPatcher) Reduce(events []core.Event, datastore ds.TxnDatastore, ba
 			return false
 		}
 
-		return ei.time().Before(ej.time())
+		return ei.HLC().Before(ej.HLC())
 	})
 
-	actions := make([]core.ReduceAction, len(events))
-	for i, e := range events {
+	actions := make([]core.ReduceAction, 0, len(events))
+	for _, e := range events {
 		je, ok := e.(patchEvent)
 		if !ok {
 			return nil, fmt.Errorf("event unrecognized for jsonpatcher eventcodec")
 		}
 		key := baseKey.ChildString(e.Collection()).ChildString(e.InstanceID().String())
+		if policy := jp.policy(e.Collection()); policy.Strategy != core.ConflictMerge {
+			late, err := checkApplied(txn, AppliedKey(baseKey, e.Collection(), e.InstanceID()), je.nanos())
+			if err != nil {
+				return nil, err
+			}
+			if late && policy.Strategy == core.ConflictLastWriterWins {
+				log.Debugf("	dropped %s operation older than the last applied", je.Patch.Type)
+				continue
+			}
+			// Custom strategies without a resolver fall back to merging
+			if late && policy.Resolver != nil {
+				action, ok, err := resolve(txn, key, je, policy.Resolver, indexFunc)
+				if err != nil {
+					return nil, err
+				}
+				if ok {
+					actions = append(actions, action)
+				}
+				continue
+			}
+		}
 		switch je.Patch.Type {
 		case create:
 			exist, err := txn.Has(key)
@@ -144,7 +188,7 @@ func (jp *jsonPatcher) Reduce(events []core.Event, datastore ds.TxnDatastore, ba
 			if err := indexFunc(e.Collection(), key, nil, je.Patch.JSONPatch, txn); err != nil {
 				return nil, fmt.Errorf("error when indexing created data: %w", err)
 			}
-			actions[i] = core.ReduceAction{Type: core.Create, Collection: e.Collection(), InstanceID: e.InstanceID()}
+			actions = append(actions, core.ReduceAction{Type: core.Create, Collection: e.Collection(), InstanceID: e.InstanceID(), Metadata: je.TxnMetadata})
 			log.Debug("\tcreate operation applied")
 		case save:
 			value, err := txn.Get(key)
@@ -163,7 +207,7 @@ func (jp *jsonPatcher) Reduce(events []core.Event, datastore ds.TxnDatastore, ba
 			if err := indexFunc(e.Collection(), key, value, patchedValue, txn); err != nil {
 				return nil, fmt.Errorf("error when indexing created data: %w", err)
 			}
-			actions[i] = core.ReduceAction{Type: core.Save, Collection: e.Collection(), InstanceID: e.InstanceID()}
+			actions = append(actions, core.ReduceAction{Type: core.Save, Collection: e.Collection(), InstanceID: e.InstanceID(), Metadata: je.TxnMetadata})
 			log.Debug("\tsave operation applied")
 		case del:
 			value, err := txn.Get(key)
@@ -176,7 +220,7 @@ func (jp *jsonPatcher) Reduce(events []core.Event, datastore ds.TxnDatastore, ba
 			if err := indexFunc(e.Collection(), key, value, nil, txn); err != nil {
 				return nil, fmt.Errorf("error when removing index: %w", err)
 			}
-			actions[i] = core.ReduceAction{Type: core.Delete, Collection: e.Collection(), InstanceID: e.InstanceID()}
+			actions = append(actions, core.ReduceAction{Type: core.Delete, Collection: e.Collection(), InstanceID: e.InstanceID(), Metadata: je.TxnMetadata})
 			log.Debug("\tdelete operation applied")
 		default:
 			return nil, errUnknownOperation
@@ -189,6 +233,89 @@ func (jp *jsonPatcher) Reduce(events []core.Event, datastore ds.TxnDatastore, ba
 	return actions, nil
 }
 
+// AppliedKey returns the key of the timestamp of the last event applied to an
+// instance, which is kept for collections with a conflict strategy other than
+// merging. Keys are stored next to baseKey.
+func AppliedKey(baseKey ds.Key, collection string, id core.InstanceID) ds.Key {
+	return baseKey.Parent().ChildString("applied").ChildString(collection).ChildString(id.String())
+}
+
+// checkApplied returns whether or not an event with timestamp ts is older than
+// the last event applied to an instance, and records ts otherwise.
+func checkApplied(txn ds.Txn, key ds.Key, ts int64) (bool, error) {
+	v, err := txn.Get(key)
+	if err != nil && !errors.Is(err, ds.ErrNotFound) {
+		return false, err
+	}
+	if len(v) == 8 && int64(binary.BigEndian.Uint64(v)) > ts {
+		return true, nil
+	}
+	buf := make([]byte, 8)
+	binary.BigEndian.PutUint64(buf, uint64(ts))
+	return false, txn.Put(key, buf)
+}
+
+// resolve applies the state returned by a conflict resolver for a late event.
+// It returns false if the instance doesn't change.
+func resolve(txn ds.Txn, key ds.Key, je patchEvent, resolver core.ConflictResolver, indexFunc core.IndexFunc) (core.ReduceAction, bool, error) {
+	current, err := txn.Get(key)
+	if errors.Is(err, ds.ErrNotFound) {
+		current = nil
+	} else if err != nil {
+		return core.ReduceAction{}, false, err
+	}
+	var incoming []byte
+	switch je.Patch.Type {
+	case create:
+		incoming = je.Patch.JSONPatch
+	case save:
+		base := current
+		if base == nil {
+			base = []byte("{}")
+		}
+		if incoming, err = jsonpatch.MergePatch(base, je.Patch.JSONPatch); err != nil {
+			return core.ReduceAction{}, false, fmt.Errorf("error when reducing save event: %w", err)
+		}
+	case del:
+	default:
+		return core.ReduceAction{}, false, errUnknownOperation
+	}
+	resolved, err := resolver(core.Conflict{
+		Collection: je.CollectionName,
+		InstanceID: je.ID,
+		Current:    current,
+		Incoming:   incoming,
+	})
+	if err != nil {
+		return core.ReduceAction{}, false, fmt.Errorf("error when resolving conflict: %w", err)
+	}
+	action := core.ReduceAction{Collection: je.CollectionName, InstanceID: je.ID, Metadata: je.TxnMetadata}
+	switch {
+	case resolved == nil && current == nil:
+		return action, false, nil
+	case resolved == nil:
+		if err := txn.Delete(key); err != nil {
+			return action, false, err
+		}
+		action.Type = core.Delete
+	case bytes.Equal(resolved, current):
+		return action, false, nil
+	default:
+		if err := txn.Put(key, resolved); err != nil {
+			return action, false, err
+		}
+		action.Type = core.Save
+		if current == nil {
+			action.Type = core.Create
+		}
+	}
+	if err := indexFunc(je.CollectionName, key, current, resolved, txn); err != nil {
+		return action, false, fmt.Errorf("error when indexing resolved data: %w", err)
+	}
+	log.Debugf("	resolved %s operation conflict", je.Patch.Type)
+	return action, true, nil
+}
+
 type recordEvents struct {
 	Patches []patchEvent
 }
@@ -203,6 +330,7 @@ func (jp *jsonPatcher) EventsFromBytes(data []byte) ([]core.Event, error) {
 	res := make([]core.Event, len(revents.Patches))
 	for i := range revents.Patches {
 		res[i] = revents.Patches[i]
+		jp.clock.Update(revents.Patches[i].HLC())
 	}
 
 	return res, nil
@@ -241,32 +369,49 @@ type patchEvent struct {
 	ID             core.InstanceID
 	CollectionName string
 	Patch          operation
+	TxnMetadata    map[string]string
 }
 
 func (je patchEvent) Time() []byte {
-	var nanos int64
-	switch ts := je.Timestamp.(type) {
-	case time.Time:
-		nanos = ts.UnixNano()
-	case int64:
-		nanos = ts
-	}
 	buf := new(bytes.Buffer)
 	// Use big endian to preserve lexicographic sorting
-	_ = binary.Write(buf, binary.BigEndian, nanos)
+	_ = binary.Write(buf, binary.BigEndian, je.nanos())
 	return buf.Bytes()
 }
 
+// HLC returns the hybrid logical clock timestamp of the event.
+// Events created before HLC timestamps were introduced return their
+// wall-clock time, which is ordered consistently with HLC timestamps.
+func (je patchEvent) HLC() hlc.Timestamp {
+	return hlc.Timestamp(je.nanos())
+}
+
 func (je patchEvent) time() (t time.Time) {
 	switch ts := je.Timestamp.(type) {
 	case time.Time:
 		t = ts
-	case int:
-		t = time.Unix(0, int64(ts))
+	case int, int64, uint64:
+		t = time.Unix(0, je.nanos())
 	}
 	return t
 }
 
+// nanos returns the event timestamp in nanoseconds.
+// Timestamps may decode as different integer types depending on the encoding.
+func (je patchEvent) nanos() (nanos int64) {
+	switch ts := je.Timestamp.(type) {
+	case time.Time:
+		nanos = ts.UnixNano()
+	case int64:
+		nanos = ts
+	case int:
+		nanos = int64(ts)
+	case uint64:
+		nanos = int64(ts)
+	}
+	return nanos
+}
+
 func (je patchEvent) InstanceID() core.InstanceID {
 	return je.ID
 }
@@ -275,11 +420,17 @@ func (je patchEvent) Collection() string {
 	return je.CollectionName
 }
 
+// Metadata returns the metadata of the transaction that created the event.
+func (je patchEvent) Metadata() map[string]string {
+	return je.TxnMetadata
+}
+
 type patchEventJson struct {
-	Timestamp      interface{}   `json:"timestamp"`
-	ID             string        `json:"_id"`
-	CollectionName string        `json:"collection_name"`
-	Patch          operationJson `json:"patch"`
+	Timestamp      interface{}       `json:"timestamp"`
+	ID             string            `json:"_id"`
+	CollectionName string            `json:"collection_name"`
+	Patch          operationJson     `json:"patch"`
+	Metadata       map[string]string `json:"metadata,omitempty"`
 }
 
 type operationJson struct {
@@ -304,7 +455,11 @@ func (je patchEvent) Marshal() ([]byte, error) {
 			InstanceID: string(je.Patch.InstanceID),
 			JSONPatch:  patch,
 		},
+		Metadata: je.TxnMetadata,
 	})
 }
 
-var _ core.Event = (*patchEvent)(nil)
+var (
+	_ core.HLCEvent      = (*patchEvent)(nil)
+	_ core.MetadataEvent = (*patchEvent)(nil)
+)
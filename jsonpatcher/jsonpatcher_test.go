@@ -3,6 +3,7 @@ package jsonpatcher
 import (
 	"bytes"
 	"encoding/gob"
+	"reflect"
 	"testing"
 	"time"
 
@@ -21,7 +22,7 @@ type patchEventOld struct {
 func init() {
 	cbornode.RegisterCborType(patchEventOld{})
 	cbornode.RegisterCborType(time.Time{})
-	gob.Register(map[string]interface {}{})
+	gob.Register(map[string]interface{}{})
 }
 
 func TestJsonPatcher_Migration(t *testing.T) {
@@ -79,3 +80,59 @@ func TestJsonPatcher_Migration(t *testing.T) {
 		t.Error("encodable time should be equal to input")
 	}
 }
+
+func TestJsonPatcher_HLC(t *testing.T) {
+	local := New().(*jsonPatcher)
+	remote := New().(*jsonPatcher)
+
+	actions := []core.Action{
+		{Type: core.Create, InstanceID: "1", CollectionName: "abc", Current: []byte(`{"_id": "1"}`)},
+		{Type: core.Delete, InstanceID: "1", CollectionName: "abc"},
+	}
+	events, _, err := local.Create(actions)
+	if err != nil {
+		t.Fatal(err)
+	}
+	e1, e2 := events[0].(core.HLCEvent), events[1].(core.HLCEvent)
+	if !e1.HLC().Before(e2.HLC()) {
+		t.Fatal("events in the same record should have increasing timestamps")
+	}
+
+	// Simulate a remote peer with a fast clock
+	remote.clock.Update(e2.HLC() + 1<<40)
+	_, node, err := remote.Create(actions[:1])
+	if err != nil {
+		t.Fatal(err)
+	}
+	revents, err := local.EventsFromBytes(node.RawData())
+	if err != nil {
+		t.Fatal(err)
+	}
+	re := revents[0].(core.HLCEvent)
+	events, _, err = local.Create(actions[1:])
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !re.HLC().Before(events[0].(core.HLCEvent).HLC()) {
+		t.Fatal("local events should happen after received remote events")
+	}
+}
+
+func TestJsonPatcher_Metadata(t *testing.T) {
+	jp := New()
+	md := map[string]string{"request_id": "abc"}
+	actions := []core.Action{
+		{Type: core.Create, InstanceID: "1", CollectionName: "abc", Current: []byte(`{"_id": "1"}`), Metadata: md},
+	}
+	_, node, err := jp.Create(actions)
+	if err != nil {
+		t.Fatal(err)
+	}
+	events, err := jp.EventsFromBytes(node.RawData())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(events[0].(core.MetadataEvent).Metadata(), md) {
+		t.Fatalf("expected metadata %v, got %v", md, events[0].(core.MetadataEvent).Metadata())
+	}
+}
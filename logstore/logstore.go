@@ -10,9 +10,13 @@ import (
 	pstore "github.com/libp2p/go-libp2p-core/peerstore"
 	core "github.com/textileio/go-threads/core/logstore"
 	"github.com/textileio/go-threads/core/thread"
+	sym "github.com/textileio/go-threads/crypto/symmetric"
 )
 
-var _ core.Logstore = (*logstore)(nil)
+var (
+	_ core.Logstore     = (*logstore)(nil)
+	_ core.KeychainBook = (*logstore)(nil)
+)
 
 var managedSuffix = "/managed"
 
@@ -117,11 +121,18 @@ func (ls *logstore) AddThread(info thread.Info) error {
 			if err := ls.AddReadKey(info.ID, info.Key.Read()); err != nil {
 				return err
 			}
-		} else {
-			// Ensure keys are the same
-			if !bytes.Equal(info.Key.Read().Bytes(), rk.Bytes()) {
-				return fmt.Errorf("read-key mismatch")
+		} else if !bytes.Equal(info.Key.Read().Bytes(), rk.Bytes()) {
+			// Ensure keys are the same, or the given key was retired by a rotation
+			rks, err := ls.ReadKeys(info.ID)
+			if err != nil {
+				return err
+			}
+			for _, k := range rks {
+				if bytes.Equal(info.Key.Read().Bytes(), k.Bytes()) {
+					return nil
+				}
 			}
+			return fmt.Errorf("read-key mismatch")
 		}
 	}
 	return nil
@@ -165,6 +176,29 @@ func (ls *logstore) GetThread(id thread.ID) (info thread.Info, err error) {
 	}, nil
 }
 
+// ReadKeys returns the read keychain of a thread. If the key book doesn't
+// retain rotated keys, the keychain only holds the current read key.
+func (ls *logstore) ReadKeys(id thread.ID) ([]*sym.Key, error) {
+	if kb, ok := ls.KeyBook.(core.KeychainBook); ok {
+		return kb.ReadKeys(id)
+	}
+	rk, err := ls.ReadKey(id)
+	if err != nil || rk == nil {
+		return nil, err
+	}
+	return []*sym.Key{rk}, nil
+}
+
+// RotateReadKey makes a key the current read key of a thread, or returns
+// ErrKeychainUnsupported if the key book doesn't retain rotated keys.
+func (ls *logstore) RotateReadKey(id thread.ID, rk *sym.Key) error {
+	kb, ok := ls.KeyBook.(core.KeychainBook)
+	if !ok {
+		return core.ErrKeychainUnsupported
+	}
+	return kb.RotateReadKey(id, rk)
+}
+
 func (ls *logstore) getLogIDs(id thread.ID) (map[peer.ID]struct{}, error) {
 	set := map[peer.ID]struct{}{}
 	logsWithKeys, err := ls.LogsWithKeys(id)
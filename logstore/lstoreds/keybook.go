@@ -21,15 +21,18 @@ type dsKeyBook struct {
 // /threads/keys/<b32 thread id no padding>/<b32 log id no padding>/(pub|priv)
 // Follow and read keys are stored under the following db key pattern:
 // /threads/keys/<b32 thread id no padding>/(service|read)
+// Read keys retired by rotation are concatenated, oldest first, under:
+// /threads/keys/<b32 thread id no padding>/retired
 var (
 	kbBase        = ds.NewKey("/thread/keys")
 	pubSuffix     = ds.NewKey("/pub")
 	privSuffix    = ds.NewKey("/priv")
 	readSuffix    = ds.NewKey("/read")
+	retiredSuffix = ds.NewKey("/retired")
 	serviceSuffix = ds.NewKey("/service")
 )
 
-var _ core.KeyBook = (*dsKeyBook)(nil)
+var _ core.KeychainBook = (*dsKeyBook)(nil)
 
 // NewKeyBook returns a new key book for storing public and private keys
 // of (thread.ID, peer.ID) pairs with durable guarantees by store.
@@ -141,6 +144,71 @@ func (kb *dsKeyBook) AddReadKey(t thread.ID, rk *sym.Key) error {
 	return nil
 }
 
+// ReadKeys returns the read-keychain associated with thread.ID, ordered
+// from the oldest key to the current one.
+func (kb *dsKeyBook) ReadKeys(t thread.ID) ([]*sym.Key, error) {
+	keys, err := kb.retiredReadKeys(t)
+	if err != nil {
+		return nil, err
+	}
+	rk, err := kb.ReadKey(t)
+	if err != nil {
+		return nil, err
+	}
+	if rk != nil {
+		keys = append(keys, rk)
+	}
+	return keys, nil
+}
+
+// RotateReadKey makes rk the current read-key, retiring the previous one.
+func (kb *dsKeyBook) RotateReadKey(t thread.ID, rk *sym.Key) error {
+	if rk == nil {
+		return fmt.Errorf("read-key is nil")
+	}
+	current, err := kb.ReadKey(t)
+	if err != nil {
+		return err
+	}
+	if current != nil {
+		key := dsThreadKey(t, kbBase).Child(retiredSuffix)
+		v, err := kb.ds.Get(key)
+		if err != nil && err != ds.ErrNotFound {
+			return fmt.Errorf("error when getting retired read-keys from datastore: %v", err)
+		}
+		if err := kb.ds.Put(key, append(v, current.Bytes()...)); err != nil {
+			return fmt.Errorf("error when adding retired read-key to datastore: %w", err)
+		}
+	}
+	return kb.AddReadKey(t, rk)
+}
+
+func (kb *dsKeyBook) retiredReadKeys(t thread.ID) ([]*sym.Key, error) {
+	v, err := kb.ds.Get(dsThreadKey(t, kbBase).Child(retiredSuffix))
+	if err == ds.ErrNotFound {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("error when getting retired read-keys from datastore: %v", err)
+	}
+	return splitReadKeys(v)
+}
+
+func splitReadKeys(v []byte) ([]*sym.Key, error) {
+	if len(v)%sym.KeyBytes != 0 {
+		return nil, fmt.Errorf("invalid retired read-keys length %d", len(v))
+	}
+	keys := make([]*sym.Key, 0, len(v)/sym.KeyBytes)
+	for i := 0; i < len(v); i += sym.KeyBytes {
+		key, err := sym.FromBytes(v[i : i+sym.KeyBytes])
+		if err != nil {
+			return nil, err
+		}
+		keys = append(keys, key)
+	}
+	return keys, nil
+}
+
 // ServiceKey returns the service-key associated with thread.ID.
 // In case it doesn't exist, it will return nil.
 func (kb *dsKeyBook) ServiceKey(t thread.ID) (*sym.Key, error) {
@@ -229,6 +297,7 @@ func (kb *dsKeyBook) DumpKeys() (core.DumpKeyBook, error) {
 		pub  = make(map[thread.ID]map[peer.ID]crypto.PubKey)
 		priv = make(map[thread.ID]map[peer.ID]crypto.PrivKey)
 		rks  = make(map[thread.ID][]byte)
+		rkc  = make(map[thread.ID][][]byte)
 		sks  = make(map[thread.ID][]byte)
 	)
 
@@ -296,6 +365,20 @@ func (kb *dsKeyBook) DumpKeys() (core.DumpKeyBook, error) {
 			}
 			rks[tid] = entry.Value
 
+		case retiredSuffix.String():
+			ts := kns[2]
+			tid, err := parseThreadID(ts)
+			if err != nil {
+				return dump, fmt.Errorf("cannot restore thread ID %s: %w", ts, err)
+			}
+			keys, err := splitReadKeys(entry.Value)
+			if err != nil {
+				return dump, fmt.Errorf("cannot restore retired read keys: %w", err)
+			}
+			for _, key := range keys {
+				rkc[tid] = append(rkc[tid], key.Bytes())
+			}
+
 		case serviceSuffix.String():
 			ts := kns[2]
 			tid, err := parseThreadID(ts)
@@ -312,6 +395,7 @@ func (kb *dsKeyBook) DumpKeys() (core.DumpKeyBook, error) {
 	dump.Data.Public = pub
 	dump.Data.Private = priv
 	dump.Data.Read = rks
+	dump.Data.Retired = rkc
 	dump.Data.Service = sks
 
 	return dump, nil
@@ -357,6 +441,19 @@ func (kb *dsKeyBook) RestoreKeys(dump core.DumpKeyBook) error {
 		}
 	}
 
+	for tid, keys := range dump.Data.Retired {
+		var v []byte
+		for _, rk := range keys {
+			if _, err := sym.FromBytes(rk); err != nil {
+				return fmt.Errorf("decoding retired read key for thread %s: %w", tid, err)
+			}
+			v = append(v, rk...)
+		}
+		if err := kb.ds.Put(dsThreadKey(tid, kbBase).Child(retiredSuffix), v); err != nil {
+			return fmt.Errorf("error when adding retired read-keys to datastore: %w", err)
+		}
+	}
+
 	for tid, sk := range dump.Data.Service {
 		key, err := sym.FromBytes(sk)
 		if err != nil {
@@ -14,7 +14,10 @@ import (
 	sym "github.com/textileio/go-threads/crypto/symmetric"
 )
 
-var _ core.Logstore = (*lstore)(nil)
+var (
+	_ core.Logstore     = (*lstore)(nil)
+	_ core.KeychainBook = (*lstore)(nil)
+)
 
 type lstore struct {
 	inMem, persist core.Logstore
@@ -148,6 +151,29 @@ func (l *lstore) AddReadKey(tid thread.ID, key *sym.Key) error {
 	return l.inMem.AddReadKey(tid, key)
 }
 
+func (l *lstore) ReadKeys(tid thread.ID) ([]*sym.Key, error) {
+	inMem, ok := l.inMem.(core.KeychainBook)
+	if !ok {
+		return nil, core.ErrKeychainUnsupported
+	}
+	return inMem.ReadKeys(tid)
+}
+
+func (l *lstore) RotateReadKey(tid thread.ID, key *sym.Key) error {
+	persist, ok := l.persist.(core.KeychainBook)
+	if !ok {
+		return core.ErrKeychainUnsupported
+	}
+	inMem, ok := l.inMem.(core.KeychainBook)
+	if !ok {
+		return core.ErrKeychainUnsupported
+	}
+	if err := persist.RotateReadKey(tid, key); err != nil {
+		return err
+	}
+	return inMem.RotateReadKey(tid, key)
+}
+
 func (l *lstore) ServiceKey(tid thread.ID) (*sym.Key, error) {
 	return l.inMem.ServiceKey(tid)
 }
@@ -17,6 +17,7 @@ type memoryKeyBook struct {
 	pks map[thread.ID]map[peer.ID]crypto.PubKey
 	sks map[thread.ID]map[peer.ID]crypto.PrivKey
 	rks map[thread.ID][]byte
+	rkc map[thread.ID][][]byte
 	fks map[thread.ID][]byte
 }
 
@@ -38,13 +39,14 @@ func (mkb *memoryKeyBook) getPrivKey(t thread.ID, p peer.ID) (crypto.PrivKey, bo
 	return hmap, found
 }
 
-var _ core.KeyBook = (*memoryKeyBook)(nil)
+var _ core.KeychainBook = (*memoryKeyBook)(nil)
 
 func NewKeyBook() core.KeyBook {
 	return &memoryKeyBook{
 		pks: map[thread.ID]map[peer.ID]crypto.PubKey{},
 		sks: map[thread.ID]map[peer.ID]crypto.PrivKey{},
 		rks: map[thread.ID][]byte{},
+		rkc: map[thread.ID][][]byte{},
 		fks: map[thread.ID][]byte{},
 	}
 }
@@ -118,6 +120,41 @@ func (mkb *memoryKeyBook) AddReadKey(t thread.ID, key *sym.Key) error {
 	return nil
 }
 
+func (mkb *memoryKeyBook) ReadKeys(t thread.ID) ([]*sym.Key, error) {
+	mkb.RLock()
+	defer mkb.RUnlock()
+	keys := make([]*sym.Key, 0, len(mkb.rkc[t])+1)
+	for _, b := range mkb.rkc[t] {
+		key, err := sym.FromBytes(b)
+		if err != nil {
+			return nil, err
+		}
+		keys = append(keys, key)
+	}
+	if b := mkb.rks[t]; b != nil {
+		key, err := sym.FromBytes(b)
+		if err != nil {
+			return nil, err
+		}
+		keys = append(keys, key)
+	}
+	return keys, nil
+}
+
+func (mkb *memoryKeyBook) RotateReadKey(t thread.ID, key *sym.Key) error {
+	if key == nil {
+		return errors.New("key is nil (ReadKey)")
+	}
+
+	mkb.Lock()
+	if current := mkb.rks[t]; current != nil {
+		mkb.rkc[t] = append(mkb.rkc[t], current)
+	}
+	mkb.rks[t] = key.Bytes()
+	mkb.Unlock()
+	return nil
+}
+
 func (mkb *memoryKeyBook) ServiceKey(t thread.ID) (key *sym.Key, err error) {
 	mkb.RLock()
 	b := mkb.fks[t]
@@ -144,6 +181,7 @@ func (mkb *memoryKeyBook) ClearKeys(t thread.ID) error {
 	delete(mkb.pks, t)
 	delete(mkb.sks, t)
 	delete(mkb.rks, t)
+	delete(mkb.rkc, t)
 	delete(mkb.fks, t)
 	mkb.Unlock()
 	return nil
@@ -204,6 +242,7 @@ func (mkb *memoryKeyBook) DumpKeys() (core.DumpKeyBook, error) {
 		public  = make(map[thread.ID]map[peer.ID]crypto.PubKey, len(mkb.pks))
 		private = make(map[thread.ID]map[peer.ID]crypto.PrivKey, len(mkb.sks))
 		read    = make(map[thread.ID][]byte, len(mkb.rks))
+		retired = make(map[thread.ID][][]byte, len(mkb.rkc))
 		service = make(map[thread.ID][]byte, len(mkb.fks))
 	)
 
@@ -227,6 +266,10 @@ func (mkb *memoryKeyBook) DumpKeys() (core.DumpKeyBook, error) {
 		read[tid] = key
 	}
 
+	for tid, keys := range mkb.rkc {
+		retired[tid] = append([][]byte(nil), keys...)
+	}
+
 	for tid, key := range mkb.fks {
 		service[tid] = key
 	}
@@ -234,6 +277,7 @@ func (mkb *memoryKeyBook) DumpKeys() (core.DumpKeyBook, error) {
 	dump.Data.Public = public
 	dump.Data.Private = private
 	dump.Data.Read = read
+	dump.Data.Retired = retired
 	dump.Data.Service = service
 
 	return dump, nil
@@ -254,6 +298,10 @@ func (mkb *memoryKeyBook) RestoreKeys(dump core.DumpKeyBook) error {
 	mkb.pks = dump.Data.Public
 	mkb.sks = dump.Data.Private
 	mkb.rks = dump.Data.Read
+	mkb.rkc = dump.Data.Retired
+	if mkb.rkc == nil {
+		mkb.rkc = map[thread.ID][][]byte{}
+	}
 	mkb.fks = dump.Data.Service
 	return nil
 }
@@ -5,6 +5,7 @@ import (
 	"fmt"
 	"io"
 	"log"
+	"time"
 
 	"github.com/ipfs/go-cid"
 	"github.com/ipfs/go-ipld-format"
@@ -197,6 +198,21 @@ func (c *Client) DeleteThread(ctx context.Context, id thread.ID, opts ...core.Th
 	return err
 }
 
+// PauseSync isn't exposed by the remote API yet, sync must be paused on the host.
+func (c *Client) PauseSync(_ context.Context, _ thread.ID, _ ...core.ThreadOption) error {
+	return status.Error(codes.Unimplemented, "pausing sync is not supported by the remote API")
+}
+
+// ResumeSync isn't exposed by the remote API yet, sync must be resumed on the host.
+func (c *Client) ResumeSync(_ context.Context, _ thread.ID, _ ...core.ThreadOption) ([]core.Reconnect, error) {
+	return nil, status.Error(codes.Unimplemented, "resuming sync is not supported by the remote API")
+}
+
+// Heartbeats isn't exposed by the remote API yet, heartbeats must be read on the host.
+func (c *Client) Heartbeats(_ context.Context, _ thread.ID, _ ...core.ThreadOption) (map[peer.ID]time.Time, error) {
+	return nil, status.Error(codes.Unimplemented, "heartbeats are not supported by the remote API")
+}
+
 func (c *Client) AddReplicator(ctx context.Context, id thread.ID, paddr ma.Multiaddr, opts ...core.ThreadOption) (pid peer.ID, err error) {
 	args := &core.ThreadOptions{}
 	for _, opt := range opts {
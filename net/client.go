@@ -351,6 +351,29 @@ func (s *server) pushRecord(ctx context.Context, id thread.ID, lid peer.ID, rec
 	return nil
 }
 
+// pushHeartbeat publishes a signed heartbeat to the thread's heartbeat topic.
+func (s *server) pushHeartbeat(ctx context.Context, id thread.ID) error {
+	if s.ps == nil {
+		return nil
+	}
+	body := &pb.PushRecordRequest_Body{
+		ThreadID: &pb.ProtoThreadID{ID: id},
+		LogID:    &pb.ProtoPeerID{ID: s.net.host.ID()},
+	}
+	sig, key, err := s.signRequestBody(body)
+	if err != nil {
+		return err
+	}
+	req := &pb.PushRecordRequest{
+		Header: &pb.Header{
+			PubKey:    &pb.ProtoPubKey{PubKey: key},
+			Signature: sig,
+		},
+		Body: body,
+	}
+	return s.ps.PublishHeartbeat(ctx, id, req)
+}
+
 // dial attempts to open a gRPC connection over libp2p to a peer.
 func (s *server) dial(peerID peer.ID) (pb.ServiceClient, error) {
 	s.Lock()
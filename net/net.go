@@ -1,8 +1,10 @@
 package net
 
 import (
+	"bytes"
 	"context"
 	"crypto/rand"
+	"encoding/json"
 	"errors"
 	"fmt"
 	"io"
@@ -16,6 +18,7 @@ import (
 	logging "github.com/ipfs/go-log"
 	"github.com/libp2p/go-libp2p-core/crypto"
 	"github.com/libp2p/go-libp2p-core/host"
+	"github.com/libp2p/go-libp2p-core/network"
 	"github.com/libp2p/go-libp2p-core/peer"
 	pstore "github.com/libp2p/go-libp2p-core/peerstore"
 	gostream "github.com/libp2p/go-libp2p-gostream"
@@ -59,6 +62,22 @@ var (
 
 	// tokenChallengeTimeout is the duration of time given to an identity to complete a token challenge.
 	tokenChallengeTimeout = time.Minute
+
+	// ReconnectAttempts is the maximum number of dials made to each peer when resuming sync.
+	ReconnectAttempts = 3
+
+	// ReconnectBackoff is the initial pause between dials to an unreachable peer when resuming sync.
+	// It doubles after each failed attempt.
+	ReconnectBackoff = time.Second
+
+	// syncPausedKey is the thread metadata key marking a thread as paused.
+	syncPausedKey = "sync-paused"
+
+	// syncPeersKey is the thread metadata key of the peers a thread was connected to when paused.
+	syncPeersKey = "sync-peers"
+
+	// errNoMatchingReadKey indicates none of the known read keys of a thread decrypts a record.
+	errNoMatchingReadKey = errors.New("no known read key decrypts the record")
 )
 
 var (
@@ -80,6 +99,8 @@ func (t semaThreadPull) Key() string {
 	return "tp:" + string(t)
 }
 
+var _ core.ReadKeyRotator = (*net)(nil)
+
 // net is an implementation of core.DBNet.
 type net struct {
 	format.DAGService
@@ -105,6 +126,9 @@ type net struct {
 type Config struct {
 	Debug  bool
 	PubSub bool
+	// HeartbeatInterval is the interval between heartbeats published to each thread over pubsub.
+	// Heartbeats let peers judge the liveness of a node without writing records. Zero disables them.
+	HeartbeatInterval time.Duration
 }
 
 // NewNetwork creates an instance of net from the given host and thread store.
@@ -159,6 +183,9 @@ func NewNetwork(
 	}()
 
 	go t.startPulling()
+	if conf.PubSub && conf.HeartbeatInterval > 0 {
+		go t.startHeartbeats(conf.HeartbeatInterval)
+	}
 	return t, nil
 }
 
@@ -397,6 +424,13 @@ func (n *net) PullThread(ctx context.Context, id thread.ID, opts ...core.ThreadO
 
 // pullThread for the new records. This method is thread-safe.
 func (n *net) pullThread(ctx context.Context, tid thread.ID) error {
+	if paused, err := n.syncPaused(tid); err != nil {
+		return err
+	} else if paused {
+		log.Debugf("skip pulling thread %s: sync paused", tid)
+		return nil
+	}
+
 	tps := n.semaphores.Get(semaThreadPull(tid))
 	if !tps.TryAcquire() {
 		log.Debugf("skip pulling thread %s: concurrent pull in progress", tid)
@@ -450,12 +484,195 @@ func (n *net) DeleteThread(ctx context.Context, id thread.ID, opts ...core.Threa
 	return err
 }
 
+func (n *net) RotateReadKey(_ context.Context, id thread.ID, opts ...core.ThreadOption) (thread.Key, error) {
+	args := &core.ThreadOptions{}
+	for _, opt := range opts {
+		opt(args)
+	}
+	if _, err := n.Validate(id, args.Token, false); err != nil {
+		return thread.Key{}, err
+	}
+	info, err := n.store.GetThread(id)
+	if err != nil {
+		return thread.Key{}, err
+	}
+	if !info.Key.CanRead() {
+		return thread.Key{}, fmt.Errorf("a read-key is required to rotate it")
+	}
+
+	ts := n.semaphores.Get(semaThreadUpdate(id))
+	ts.Acquire()
+	defer ts.Release()
+
+	kb, ok := n.store.(lstore.KeychainBook)
+	if !ok {
+		return thread.Key{}, lstore.ErrKeychainUnsupported
+	}
+	rk := args.ReadKey
+	if rk == nil {
+		rk = sym.New()
+	} else {
+		keys, err := kb.ReadKeys(id)
+		if err != nil {
+			return thread.Key{}, err
+		}
+		for _, k := range keys {
+			if bytes.Equal(k.Bytes(), rk.Bytes()) { // Already known
+				return thread.NewKey(info.Key.Service(), keys[len(keys)-1]), nil
+			}
+		}
+	}
+	if err := kb.RotateReadKey(id, rk); err != nil {
+		return thread.Key{}, err
+	}
+	return thread.NewKey(info.Key.Service(), rk), nil
+}
+
 // deleteThread cleans up all the persistent and in-memory bits of a thread. This includes:
 // - Removing all record and event nodes.
 // - Deleting all logstore keys, addresses, and heads.
 // - Cancelling the pubsub subscription and topic.
 // Local subscriptions will not be cancelled and will simply stop reporting.
 // This method is internal and *not* thread-safe. It assumes we currently own the thread-lock.
+func (n *net) PauseSync(_ context.Context, id thread.ID, opts ...core.ThreadOption) error {
+	args := &core.ThreadOptions{}
+	for _, opt := range opts {
+		opt(args)
+	}
+	if _, err := n.Validate(id, args.Token, true); err != nil {
+		return err
+	}
+	info, err := n.store.GetThread(id)
+	if err != nil {
+		return err
+	}
+	peers, err := json.Marshal(n.connectedPeers(info))
+	if err != nil {
+		return err
+	}
+	if err := n.store.PutBytes(id, syncPeersKey, peers); err != nil {
+		return err
+	}
+	log.Debugf("paused sync of thread %s", id)
+	return n.store.PutBool(id, syncPausedKey, true)
+}
+
+func (n *net) ResumeSync(ctx context.Context, id thread.ID, opts ...core.ThreadOption) ([]core.Reconnect, error) {
+	args := &core.ThreadOptions{}
+	for _, opt := range opts {
+		opt(args)
+	}
+	if _, err := n.Validate(id, args.Token, true); err != nil {
+		return nil, err
+	}
+	paused, err := n.syncPaused(id)
+	if err != nil {
+		return nil, err
+	} else if !paused {
+		return nil, nil
+	}
+	var peers []peer.AddrInfo
+	pb, err := n.store.GetBytes(id, syncPeersKey)
+	if err != nil {
+		return nil, err
+	} else if pb != nil {
+		if err := json.Unmarshal(*pb, &peers); err != nil {
+			return nil, err
+		}
+	}
+	if err := n.store.PutBool(id, syncPausedKey, false); err != nil {
+		return nil, err
+	}
+	log.Debugf("resuming sync of thread %s with %d peers", id, len(peers))
+
+	reconnects := make([]core.Reconnect, len(peers))
+	var wg sync.WaitGroup
+	for i, p := range peers {
+		wg.Add(1)
+		go func(i int, p peer.AddrInfo) {
+			defer wg.Done()
+			reconnects[i] = n.reconnect(ctx, p)
+		}(i, p)
+	}
+	wg.Wait()
+	return reconnects, n.pullThread(ctx, id)
+}
+
+func (n *net) Heartbeats(_ context.Context, id thread.ID, opts ...core.ThreadOption) (map[peer.ID]time.Time, error) {
+	args := &core.ThreadOptions{}
+	for _, opt := range opts {
+		opt(args)
+	}
+	if _, err := n.Validate(id, args.Token, true); err != nil {
+		return nil, err
+	}
+	if n.server.ps == nil {
+		return map[peer.ID]time.Time{}, nil
+	}
+	return n.server.ps.Heartbeats(id), nil
+}
+
+// syncPaused returns whether or not syncing a thread is paused.
+func (n *net) syncPaused(id thread.ID) (bool, error) {
+	paused, err := n.store.GetBool(id, syncPausedKey)
+	if err != nil {
+		return false, err
+	}
+	return paused != nil && *paused, nil
+}
+
+// connectedPeers returns the log peers of a thread the host is connected to.
+func (n *net) connectedPeers(info thread.Info) []peer.AddrInfo {
+	peers := make(map[peer.ID]*peer.AddrInfo)
+	for _, l := range info.Logs {
+		for _, addr := range l.Addrs {
+			pid, ok, err := n.callablePeer(addr)
+			if err != nil || !ok {
+				continue
+			}
+			if n.host.Network().Connectedness(pid) != network.Connected {
+				continue
+			}
+			p, ok := peers[pid]
+			if !ok {
+				p = &peer.AddrInfo{ID: pid, Addrs: n.host.Peerstore().Addrs(pid)}
+				peers[pid] = p
+			}
+			if dialable, err := getDialable(addr); err == nil && len(dialable.Bytes()) > 0 {
+				p.Addrs = append(p.Addrs, dialable)
+			}
+		}
+	}
+	res := make([]peer.AddrInfo, 0, len(peers))
+	for _, p := range peers {
+		res = append(res, *p)
+	}
+	return res
+}
+
+// reconnect dials a peer until it's connected, backing off between failed attempts.
+func (n *net) reconnect(ctx context.Context, p peer.AddrInfo) core.Reconnect {
+	rc := core.Reconnect{PeerID: p.ID}
+	backoff := ReconnectBackoff
+	for {
+		rc.Attempts++
+		if rc.Err = n.host.Connect(ctx, p); rc.Err == nil {
+			return rc
+		}
+		log.Debugf("reconnecting to %s failed (attempt %d): %s", p.ID, rc.Attempts, rc.Err)
+		if rc.Attempts >= ReconnectAttempts {
+			return rc
+		}
+		select {
+		case <-time.After(backoff):
+			backoff *= 2
+		case <-ctx.Done():
+			rc.Err = ctx.Err()
+			return rc
+		}
+	}
+}
+
 func (n *net) deleteThread(ctx context.Context, id thread.ID) error {
 	if n.server.ps != nil {
 		if err := n.server.ps.Remove(id); err != nil {
@@ -644,6 +861,10 @@ func (n *net) CreateRecord(ctx context.Context, id thread.ID, body format.Node,
 	if err = n.bus.SendWithTimeout(tr, notifyTimeout); err != nil {
 		return
 	}
+	paused, err := n.syncPaused(id)
+	if err != nil || paused {
+		return tr, err
+	}
 	if err = n.server.pushRecord(ctx, id, lg.ID, tr.Value()); err != nil {
 		return
 	}
@@ -681,6 +902,9 @@ func (n *net) AddRecord(ctx context.Context, id thread.ID, lid peer.ID, rec core
 	if err = n.putRecord(ctx, id, lid, rec); err != nil {
 		return err
 	}
+	if paused, err := n.syncPaused(id); err != nil || paused {
+		return err
+	}
 	return n.server.pushRecord(ctx, id, lid, rec)
 }
 
@@ -833,6 +1057,23 @@ func (n *net) getConnectorProtected(id thread.ID, token core.Token) (*app.Connec
 	return c, true
 }
 
+// handleNetRecord passes a record to the connected app. If the thread read key
+// has been rotated, the record is handed over with the key that encrypted it.
+func (n *net) handleNetRecord(ctx context.Context, connector *app.Connector, tid thread.ID, rec core.ThreadRecord) error {
+	keys, err := n.readKeys(tid)
+	if err != nil {
+		return err
+	}
+	if len(keys) <= 1 {
+		return connector.HandleNetRecord(ctx, rec)
+	}
+	rk, err := n.readKeyForRecord(ctx, rec.Value(), keys)
+	if err != nil {
+		return err
+	}
+	return connector.HandleNetRecordWithReadKey(ctx, rec, rk)
+}
+
 // PutRecord adds an existing record. This method is thread-safe.
 func (n *net) PutRecord(ctx context.Context, id thread.ID, lid peer.ID, rec core.Record) error {
 	if err := id.Validate(); err != nil {
@@ -871,7 +1112,7 @@ func (n *net) putRecord(ctx context.Context, tid thread.ID, lid peer.ID, rec cor
 		}
 
 		if appConnected {
-			if err := connector.HandleNetRecord(ctx, record); err != nil {
+			if err := n.handleNetRecord(ctx, connector, tid, record); err != nil {
 				// Future improvement notes.
 				// If record handling fails there are two options available:
 				// 1. Just interrupt and return error (current behaviour). Log head remains moved and some events
@@ -941,15 +1182,15 @@ func (n *net) loadUnknownRecords(
 		connector, appConnected = n.getConnector(tid)
 		identity                = &thread.Libp2pPubKey{}
 		tRecords                = make([]core.ThreadRecord, 0, len(unknown))
-		readKey                 *sym.Key
+		readKeys                []*sym.Key
 		validate                bool
 	)
 
 	if appConnected {
 		var err error
-		if readKey, err = n.store.ReadKey(tid); err != nil {
+		if readKeys, err = n.readKeys(tid); err != nil {
 			return nil, head, err
-		} else if readKey != nil {
+		} else if len(readKeys) > 0 {
 			validate = true
 		}
 	}
@@ -980,6 +1221,10 @@ func (n *net) loadUnknownRecords(
 		}
 
 		if validate {
+			readKey, err := readKeyForHeader(header, readKeys)
+			if err != nil {
+				return nil, head, err
+			}
 			dbody, err := event.GetBody(ctx, n, readKey)
 			if err != nil {
 				return nil, head, err
@@ -1005,6 +1250,43 @@ func (n *net) loadUnknownRecords(
 	return tRecords, head, nil
 }
 
+// readKeyForHeader returns the key of a read keychain that decrypts an event header.
+// Most recent keys are tried first, since most records are encrypted with the current key.
+func readKeyForHeader(header format.Node, keys []*sym.Key) (*sym.Key, error) {
+	for i := len(keys) - 1; i >= 0; i-- {
+		if _, err := cbor.DecodeBlock(header, keys[i]); err == nil {
+			return keys[i], nil
+		}
+	}
+	return nil, errNoMatchingReadKey
+}
+
+// readKeys returns the read keychain of a thread, or only its read key if the
+// logstore doesn't retain rotated keys.
+func (n *net) readKeys(id thread.ID) ([]*sym.Key, error) {
+	if kb, ok := n.store.(lstore.KeychainBook); ok {
+		return kb.ReadKeys(id)
+	}
+	rk, err := n.store.ReadKey(id)
+	if err != nil || rk == nil {
+		return nil, err
+	}
+	return []*sym.Key{rk}, nil
+}
+
+// readKeyForRecord returns the key of a read keychain that decrypts a record.
+func (n *net) readKeyForRecord(ctx context.Context, rec core.Record, keys []*sym.Key) (*sym.Key, error) {
+	event, err := cbor.EventFromRecord(ctx, n, rec)
+	if err != nil {
+		return nil, err
+	}
+	header, err := event.GetHeader(ctx, n, nil)
+	if err != nil {
+		return nil, err
+	}
+	return readKeyForHeader(header, keys)
+}
+
 func (n *net) currentHead(tid thread.ID, lid peer.ID) (cid.Cid, error) {
 	var head cid.Cid
 	heads, err := n.store.Heads(tid, lid)
@@ -1174,6 +1456,33 @@ PullCycle:
 	}
 }
 
+// startHeartbeats periodically publishes a heartbeat to all threads that aren't paused.
+func (n *net) startHeartbeats(interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+			ts, err := n.store.Threads()
+			if err != nil {
+				log.Errorf("error listing threads: %s", err)
+				continue
+			}
+			for _, tid := range ts {
+				if paused, err := n.syncPaused(tid); err != nil || paused {
+					continue
+				}
+				if err := n.server.pushHeartbeat(n.ctx, tid); err != nil {
+					log.Debugf("error publishing heartbeat to thread %s: %s", tid, err)
+				}
+			}
+
+		case <-n.ctx.Done():
+			return
+		}
+	}
+}
+
 // createLog creates a new log with the given peer as host.
 func (n *net) createLog(id thread.ID, key crypto.Key, identity thread.PubKey) (info thread.LogInfo, err error) {
 	var ok bool
@@ -1325,6 +1634,14 @@ func (n *net) ensureUniqueLog(id thread.ID, key crypto.Key, identity thread.PubK
 // updateRecordsFromLog will fetch lid addrs for new logs & records,
 // and will add them in the local peer store. Method is thread-safe.
 func (n *net) updateRecordsFromLog(tid thread.ID, lid peer.ID) {
+	if paused, err := n.syncPaused(tid); err != nil {
+		log.Errorf("getting sync state of thread %s failed: %v", tid, err)
+		return
+	} else if paused {
+		log.Debugf("skip updating thread %s (log %s): sync paused", tid, lid)
+		return
+	}
+
 	tps := n.semaphores.Get(semaThreadPull(tid))
 	if !tps.TryAcquire() {
 		log.Debugf("skip updating thread %s (log %s): concurrent pull in progress", tid, lid)
@@ -1,6 +1,7 @@
 package net
 
 import (
+	"bytes"
 	"context"
 	rand "crypto/rand"
 	"testing"
@@ -22,6 +23,7 @@ import (
 	"github.com/textileio/go-threads/core/logstore"
 	core "github.com/textileio/go-threads/core/net"
 	"github.com/textileio/go-threads/core/thread"
+	sym "github.com/textileio/go-threads/crypto/symmetric"
 	tstore "github.com/textileio/go-threads/logstore/lstoremem"
 	"github.com/textileio/go-threads/util"
 )
@@ -109,6 +111,75 @@ func TestNet_CreateRecord(t *testing.T) {
 	})
 }
 
+func TestNet_RotateReadKey(t *testing.T) {
+	t.Parallel()
+	n := makeNetwork(t)
+	defer n.Close()
+
+	ctx := context.Background()
+	info := createThread(t, ctx, n)
+
+	body, err := cbornode.WrapObject(map[string]interface{}{
+		"msg": "yo!",
+	}, mh.SHA2_256, -1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	r1, err := n.CreateRecord(ctx, info.ID, body)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	key, err := n.(core.ReadKeyRotator).RotateReadKey(ctx, info.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if bytes.Equal(key.Read().Bytes(), info.Key.Read().Bytes()) {
+		t.Fatal("expected a new read key")
+	}
+	if !bytes.Equal(key.Service().Bytes(), info.Key.Service().Bytes()) {
+		t.Fatal("expected the service key to be unchanged")
+	}
+	info2, err := n.GetThread(ctx, info.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(key.Read().Bytes(), info2.Key.Read().Bytes()) {
+		t.Fatal("expected the thread to use the rotated read key")
+	}
+
+	r2, err := n.CreateRecord(ctx, info.ID, body)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, c := range []struct {
+		rec core.ThreadRecord
+		key *sym.Key
+	}{
+		{rec: r1, key: info.Key.Read()},
+		{rec: r2, key: key.Read()},
+	} {
+		event, err := cbor.GetEvent(ctx, n, c.rec.Value().BlockID())
+		if err != nil {
+			t.Fatal(err)
+		}
+		back, err := event.GetBody(ctx, n, c.key)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if body.String() != back.String() {
+			t.Fatalf("retrieved body does not equal input body")
+		}
+	}
+	event, err := cbor.GetEvent(ctx, n, r2.Value().BlockID())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := event.GetBody(ctx, n, info.Key.Read()); err == nil {
+		t.Fatal("new records shouldn't be readable with the retired read key")
+	}
+}
+
 func TestNet_AddThread(t *testing.T) {
 	t.Parallel()
 	n1 := makeNetwork(t)
@@ -174,6 +245,128 @@ func TestNet_AddThread(t *testing.T) {
 	}
 }
 
+func TestNet_PauseSync(t *testing.T) {
+	t.Parallel()
+	n1 := makeNetwork(t)
+	defer n1.Close()
+	n2 := makeNetwork(t)
+	defer n2.Close()
+
+	n1.Host().Peerstore().AddAddrs(n2.Host().ID(), n2.Host().Addrs(), peerstore.PermanentAddrTTL)
+	n2.Host().Peerstore().AddAddrs(n1.Host().ID(), n1.Host().Addrs(), peerstore.PermanentAddrTTL)
+
+	ctx := context.Background()
+	info := createThread(t, ctx, n1)
+	addr, err := ma.NewMultiaddr("/p2p/" + n1.Host().ID().String() + "/thread/" + info.ID.String())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err = n2.AddThread(ctx, addr, core.WithThreadKey(info.Key)); err != nil {
+		t.Fatal(err)
+	}
+	if err := n2.PauseSync(ctx, info.ID); err != nil {
+		t.Fatal(err)
+	}
+	if paused, err := n2.(*net).syncPaused(info.ID); err != nil || !paused {
+		t.Fatal("expected thread sync to be paused")
+	}
+
+	body, err := cbornode.WrapObject(map[string]interface{}{
+		"msg": "yo!",
+	}, mh.SHA2_256, -1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	rec, err := n1.CreateRecord(ctx, info.ID, body)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := n2.Host().Network().ClosePeer(n1.Host().ID()); err != nil {
+		t.Fatal(err)
+	}
+	reconnects, err := n2.ResumeSync(ctx, info.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if paused, err := n2.(*net).syncPaused(info.ID); err != nil || paused {
+		t.Fatal("expected thread sync to be resumed")
+	}
+	if len(reconnects) != 1 {
+		t.Fatalf("expected 1 reconnect got %d", len(reconnects))
+	}
+	if reconnects[0].PeerID != n1.Host().ID() || reconnects[0].Err != nil {
+		t.Fatalf("expected to reconnect to %s, got %s (%v)", n1.Host().ID(), reconnects[0].PeerID, reconnects[0].Err)
+	}
+	if _, err := n2.GetRecord(ctx, info.ID, rec.Value().Cid()); err != nil {
+		t.Fatalf("expected record to be pulled on resume: %v", err)
+	}
+
+	// Resuming an unpaused thread is a no-op
+	reconnects, err = n2.ResumeSync(ctx, info.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(reconnects) != 0 {
+		t.Fatalf("expected no reconnects got %d", len(reconnects))
+	}
+}
+
+func TestNet_Heartbeats(t *testing.T) {
+	t.Parallel()
+	n1 := makeNetwork(t)
+	defer n1.Close()
+	n2 := makeNetwork(t)
+	defer n2.Close()
+
+	n1.Host().Peerstore().AddAddrs(n2.Host().ID(), n2.Host().Addrs(), peerstore.PermanentAddrTTL)
+	n2.Host().Peerstore().AddAddrs(n1.Host().ID(), n1.Host().Addrs(), peerstore.PermanentAddrTTL)
+
+	ctx := context.Background()
+	info := createThread(t, ctx, n1)
+	addr, err := ma.NewMultiaddr("/p2p/" + n1.Host().ID().String() + "/thread/" + info.ID.String())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err = n2.AddThread(ctx, addr, core.WithThreadKey(info.Key)); err != nil {
+		t.Fatal(err)
+	}
+	before := time.Now()
+	go n1.(*net).startHeartbeats(time.Millisecond * 100)
+
+	var last time.Time
+	for i := 0; i < 50; i++ {
+		hbs, err := n2.Heartbeats(ctx, info.ID)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if hb, ok := hbs[n1.Host().ID()]; ok {
+			last = hb
+			break
+		}
+		time.Sleep(time.Millisecond * 100)
+	}
+	if last.Before(before) {
+		t.Fatal("expected a heartbeat from the thread peer")
+	}
+	hbs, err := n1.Heartbeats(ctx, info.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(hbs) != 0 {
+		t.Fatalf("expected no heartbeats got %d", len(hbs))
+	}
+	info2, err := n2.GetThread(ctx, info.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, l := range info2.Logs {
+		if l.Head.Defined() {
+			t.Fatal("heartbeats shouldn't write records")
+		}
+	}
+}
+
 func TestNet_CreateThreadManaged(t *testing.T) {
 	t.Parallel()
 	n := makeNetwork(t)
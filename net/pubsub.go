@@ -4,6 +4,7 @@ import (
 	"context"
 	"fmt"
 	"sync"
+	"time"
 
 	"github.com/gogo/protobuf/proto"
 	"github.com/libp2p/go-libp2p-core/peer"
@@ -24,6 +25,9 @@ type PubSub struct {
 	ps      *pubsub.PubSub
 	handler Handler
 	m       map[thread.ID]*topic
+
+	hbLock     sync.RWMutex
+	heartbeats map[thread.ID]map[peer.ID]time.Time
 }
 
 type topic struct {
@@ -31,9 +35,19 @@ type topic struct {
 	h *pubsub.TopicEventHandler
 	s *pubsub.Subscription
 
+	hb  *pubsub.Topic
+	hbs *pubsub.Subscription
+
 	cancel context.CancelFunc
 }
 
+// heartbeatTopic returns the name of a thread's heartbeat topic.
+// Heartbeats are kept off the record topic so peers that don't
+// know about them never mistake them for records.
+func heartbeatTopic(id thread.ID) string {
+	return id.String() + "/heartbeat"
+}
+
 // NewPubSub returns a new thread topic manager.
 func NewPubSub(ctx context.Context, host peer.ID, ps *pubsub.PubSub, handler Handler) *PubSub {
 	return &PubSub{
@@ -42,6 +56,8 @@ func NewPubSub(ctx context.Context, host peer.ID, ps *pubsub.PubSub, handler Han
 		ps:      ps,
 		handler: handler,
 		m:       make(map[thread.ID]*topic),
+
+		heartbeats: make(map[thread.ID]map[peer.ID]time.Time),
 	}
 }
 
@@ -67,16 +83,27 @@ func (s *PubSub) Add(id thread.ID) error {
 	if err = s.ps.RegisterTopicValidator(id.String(), s.topicValidator); err != nil {
 		return err
 	}
+	hb, err := s.ps.Join(heartbeatTopic(id))
+	if err != nil {
+		return err
+	}
+	hbs, err := hb.Subscribe()
+	if err != nil {
+		return err
+	}
 
 	ctx, cancel := context.WithCancel(s.ctx)
 	topic := &topic{
 		t:      pt,
 		h:      h,
+		hb:     hb,
+		hbs:    hbs,
 		cancel: cancel,
 	}
 	s.m[id] = topic
 	go s.watch(ctx, id, topic)
 	go s.subscribe(ctx, id, topic)
+	go s.subscribeHeartbeats(ctx, id, topic)
 	return nil
 }
 
@@ -99,8 +126,16 @@ func (s *PubSub) Remove(id thread.ID) error {
 	if err := topic.t.Close(); err != nil {
 		return err
 	}
+	topic.hbs.Cancel()
+	if err := topic.hb.Close(); err != nil {
+		return err
+	}
 	topic.cancel()
 	delete(s.m, id)
+
+	s.hbLock.Lock()
+	delete(s.heartbeats, id)
+	s.hbLock.Unlock()
 	return nil
 }
 
@@ -125,6 +160,34 @@ func (s *PubSub) Publish(ctx context.Context, id thread.ID, req *pb.PushRecordRe
 	return topic.t.Publish(ctx, data)
 }
 
+// PublishHeartbeat publishes a heartbeat request to a thread.
+// A heartbeat is a push record request without a record.
+func (s *PubSub) PublishHeartbeat(ctx context.Context, id thread.ID, req *pb.PushRecordRequest) error {
+	s.RLock()
+	defer s.RUnlock()
+	topic, ok := s.m[id]
+	if !ok {
+		return fmt.Errorf("thread topic not found")
+	}
+
+	data, err := req.Marshal()
+	if err != nil {
+		return err
+	}
+	return topic.hb.Publish(ctx, data)
+}
+
+// Heartbeats returns the time of the last heartbeat received from each thread peer.
+func (s *PubSub) Heartbeats(id thread.ID) map[peer.ID]time.Time {
+	s.hbLock.RLock()
+	defer s.hbLock.RUnlock()
+	res := make(map[peer.ID]time.Time, len(s.heartbeats[id]))
+	for pid, t := range s.heartbeats[id] {
+		res[pid] = t
+	}
+	return res
+}
+
 // watch peer events from a pubsub topic.
 func (s *PubSub) watch(ctx context.Context, id thread.ID, topic *topic) {
 	for {
@@ -172,6 +235,39 @@ func (s *PubSub) subscribe(ctx context.Context, id thread.ID, topic *topic) {
 	}
 }
 
+// subscribeHeartbeats records the heartbeats received from thread peers.
+func (s *PubSub) subscribeHeartbeats(ctx context.Context, id thread.ID, topic *topic) {
+	for {
+		msg, err := topic.hbs.Next(ctx)
+		if err != nil {
+			break
+		}
+		from, req, err := s.handleMsg(msg)
+		if err != nil {
+			log.Errorf("error handling heartbeat: %s", err)
+			continue
+		} else if req == nil {
+			continue
+		}
+		if _, err := verifyRequest(req.Header, req.Body); err != nil {
+			log.Warnf("invalid heartbeat from %s: %s", from, err)
+			continue
+		}
+		if req.Body.ThreadID == nil || !req.Body.ThreadID.ID.Equals(id) {
+			log.Warnf("heartbeat from %s does not match topic thread %s", from, id)
+			continue
+		}
+		log.Debugf("received heartbeat from %s (thread %s)", from, id)
+
+		s.hbLock.Lock()
+		if _, ok := s.heartbeats[id]; !ok {
+			s.heartbeats[id] = make(map[peer.ID]time.Time)
+		}
+		s.heartbeats[id][from] = time.Now()
+		s.hbLock.Unlock()
+	}
+}
+
 func (s *PubSub) handleMsg(m *pubsub.Message) (from peer.ID, rec *pb.PushRecordRequest, err error) {
 	from, err = peer.IDFromBytes(m.From)
 	if err != nil {
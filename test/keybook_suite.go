@@ -19,6 +19,7 @@ var keyBookSuite = map[string]func(kb core.KeyBook) func(*testing.T){
 	"AddGetPrivKey":           testKeyBookPrivKey,
 	"AddGetPubKey":            testKeyBookPubKey,
 	"AddGetReadKey":           testKeyBookReadKey,
+	"RotateReadKey":           testKeyBookRotateReadKey,
 	"AddGetServiceKey":        testKeyBookServiceKey,
 	"LogsWithKeys":            testKeyBookLogs,
 	"testKeyBookClearKeys":    testKeyBookClearKeys,
@@ -143,6 +144,62 @@ func testKeyBookReadKey(kb core.KeyBook) func(t *testing.T) {
 	}
 }
 
+func testKeyBookRotateReadKey(book core.KeyBook) func(t *testing.T) {
+	return func(t *testing.T) {
+		kb, ok := book.(core.KeychainBook)
+		if !ok {
+			t.Skip("key book doesn't support read key rotation")
+		}
+		tid := thread.NewIDV1(thread.Raw, 24)
+
+		if keys, err := kb.ReadKeys(tid); err != nil || len(keys) > 0 {
+			t.Error("expected read keychain to be empty on init without errors")
+		}
+
+		keys := []*sym.Key{sym.New(), sym.New(), sym.New()}
+		if err := kb.AddReadKey(tid, keys[0]); err != nil {
+			t.Fatal(err)
+		}
+		for _, key := range keys[1:] {
+			if err := kb.RotateReadKey(tid, key); err != nil {
+				t.Fatal(err)
+			}
+		}
+
+		if res, err := kb.ReadKey(tid); err != nil || !bytes.Equal(res.Bytes(), keys[2].Bytes()) {
+			t.Error("current read key did not match the last rotated key")
+		}
+		checkChain := func() {
+			res, err := kb.ReadKeys(tid)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(res) != len(keys) {
+				t.Fatalf("expected %d keys in the read keychain, got %d", len(keys), len(res))
+			}
+			for i := range keys {
+				if !bytes.Equal(res[i].Bytes(), keys[i].Bytes()) {
+					t.Errorf("read keychain key %d did not match", i)
+				}
+			}
+		}
+		checkChain()
+
+		// the keychain must survive a dump and restore
+		dump, err := kb.DumpKeys()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := kb.ClearKeys(tid); err != nil {
+			t.Fatal(err)
+		}
+		if err := kb.RestoreKeys(dump); err != nil {
+			t.Fatal(err)
+		}
+		checkChain()
+	}
+}
+
 func testKeyBookServiceKey(kb core.KeyBook) func(t *testing.T) {
 	return func(t *testing.T) {
 		tid := thread.NewIDV1(thread.Raw, 24)
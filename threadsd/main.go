@@ -7,11 +7,13 @@ import (
 	"net"
 	"net/http"
 	"os"
+	"strings"
 	"time"
 
 	"github.com/improbable-eng/grpc-web/go/grpcweb"
 	logging "github.com/ipfs/go-log"
 	connmgr "github.com/libp2p/go-libp2p-connmgr"
+	"github.com/libp2p/go-libp2p-core/peer"
 	ma "github.com/multiformats/go-multiaddr"
 	"github.com/namsral/flag"
 	"github.com/textileio/go-threads/api"
@@ -21,6 +23,7 @@ import (
 	netpb "github.com/textileio/go-threads/net/api/pb"
 	"github.com/textileio/go-threads/util"
 	"google.golang.org/grpc"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
 )
 
 var log = logging.Logger("threadsd")
@@ -30,6 +33,7 @@ func main() {
 
 	repo := fs.String("repo", ".threads", "Repo location")
 	hostAddrStr := fs.String("hostAddr", "/ip4/0.0.0.0/tcp/4006", "Libp2p host bind address")
+	hostWSAddrStr := fs.String("hostWSAddr", "", "Libp2p host WebSocket bind address for browser peers, e.g., /ip4/0.0.0.0/tcp/4007/ws (empty disables, WebTransport isn't supported yet)")
 	apiAddrStr := fs.String("apiAddr", "/ip4/127.0.0.1/tcp/6006", "gRPC API bind address")
 	apiProxyAddrStr := fs.String("apiProxyAddr", "/ip4/127.0.0.1/tcp/6007", "gRPC API web proxy bind address")
 	connLowWater := fs.Int("connLowWater", 100, "Low watermark of libp2p connections that'll be maintained")
@@ -37,6 +41,9 @@ func main() {
 	connGracePeriod := fs.Duration("connGracePeriod", time.Second*20, "Duration a new opened connection is not subject to pruning")
 	keepAliveInterval := fs.Duration("keepAliveInterval", time.Second*5, "Websocket keepalive interval (must be >= 1s)")
 	enableNetPubsub := fs.Bool("enableNetPubsub", false, "Enables thread networking over libp2p pubsub")
+	netHeartbeatInterval := fs.Duration("netHeartbeatInterval", 0, "Interval between thread heartbeats over pubsub (0 disables heartbeats)")
+	relayHop := fs.Bool("relayHop", false, "Enables relaying libp2p connections between other peers")
+	staticRelays := fs.String("staticRelays", "", "Comma-separated p2p addresses of relays used when behind a NAT")
 	debug := fs.Bool("debug", false, "Enables debug logging")
 	if err := fs.Parse(os.Args[1:]); err != nil {
 		log.Fatal(err)
@@ -46,6 +53,34 @@ func main() {
 	if err != nil {
 		log.Fatal(err)
 	}
+	netOpts := []common.NetOption{
+		common.WithNetHostAddr(hostAddr),
+	}
+	if *hostWSAddrStr != "" {
+		hostWSAddr, err := ma.NewMultiaddr(*hostWSAddrStr)
+		if err != nil {
+			log.Fatal(err)
+		}
+		netOpts = append(netOpts, common.WithNetWebSocketAddr(hostWSAddr))
+	}
+	if *relayHop {
+		netOpts = append(netOpts, common.WithNetRelayHop(true))
+	}
+	if *staticRelays != "" {
+		var addrs []ma.Multiaddr
+		for _, s := range strings.Split(*staticRelays, ",") {
+			addr, err := ma.NewMultiaddr(strings.TrimSpace(s))
+			if err != nil {
+				log.Fatal(err)
+			}
+			addrs = append(addrs, addr)
+		}
+		relays, err := peer.AddrInfosFromP2pAddrs(addrs...)
+		if err != nil {
+			log.Fatal(err)
+		}
+		netOpts = append(netOpts, common.WithNetStaticRelays(relays...))
+	}
 	apiAddr, err := ma.NewMultiaddr(*apiAddrStr)
 	if err != nil {
 		log.Fatal(err)
@@ -66,6 +101,7 @@ func main() {
 
 	log.Debugf("repo: %v", *repo)
 	log.Debugf("hostAddr: %v", *hostAddrStr)
+	log.Debugf("hostWSAddr: %v", *hostWSAddrStr)
 	log.Debugf("apiAddr: %v", *apiAddrStr)
 	log.Debugf("apiProxyAddr: %v", *apiProxyAddrStr)
 	log.Debugf("connLowWater: %v", *connLowWater)
@@ -73,14 +109,18 @@ func main() {
 	log.Debugf("connGracePeriod: %v", *connGracePeriod)
 	log.Debugf("keepAliveInterval: %v", *keepAliveInterval)
 	log.Debugf("enableNetPubsub: %v", *enableNetPubsub)
+	log.Debugf("netHeartbeatInterval: %v", *netHeartbeatInterval)
+	log.Debugf("relayHop: %v", *relayHop)
+	log.Debugf("staticRelays: %v", *staticRelays)
 	log.Debugf("debug: %v", *debug)
 
 	n, err := common.DefaultNetwork(
 		*repo,
-		common.WithNetHostAddr(hostAddr),
-		common.WithConnectionManager(connmgr.NewConnManager(*connLowWater, *connHighWater, *connGracePeriod)),
-		common.WithNetPubSub(*enableNetPubsub),
-		common.WithNetDebug(*debug))
+		append(netOpts,
+			common.WithConnectionManager(connmgr.NewConnManager(*connLowWater, *connHighWater, *connGracePeriod)),
+			common.WithNetPubSub(*enableNetPubsub),
+			common.WithNetHeartbeatInterval(*netHeartbeatInterval),
+			common.WithNetDebug(*debug))...)
 	if err != nil {
 		log.Fatal(err)
 	}
@@ -118,6 +158,7 @@ func main() {
 	go func() {
 		pb.RegisterAPIServer(server, service)
 		netpb.RegisterAPIServer(server, netService)
+		healthpb.RegisterHealthServer(server, service.HealthServer())
 		if err := server.Serve(listener); err != nil && !errors.Is(err, grpc.ErrServerStopped) {
 			log.Fatalf("serve error: %v", err)
 		}
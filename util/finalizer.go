@@ -23,10 +23,13 @@ func (r *Finalizer) Add(cs ...io.Closer) {
 func (r *Finalizer) Cleanup(err error) error {
 	// release resources in a reverse order
 	for i := len(r.resources) - 1; i >= 0; i-- {
-		err = multierror.Append(r.resources[i].Close())
+		err = multierror.Append(err, r.resources[i].Close())
 	}
 
-	return err.(*multierror.Error).ErrorOrNil()
+	if merr, ok := err.(*multierror.Error); ok {
+		return merr.ErrorOrNil()
+	}
+	return err
 }
 
 // Transform context cancellation function to be used with finalizer.